@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"mini-k8s/pkg/api"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerConfigMaps 注册 ConfigMap。kubelet 在启动 pod 时读取它们生成环境变量和卷里的文件
+func (s *APIServer) registerConfigMaps(router *gin.Engine) {
+	registerResource(router, s, "configmaps", &resource[api.ConfigMap, *api.ConfigMap]{
+		kind:       "ConfigMap",
+		namespaced: true,
+		create:     s.store.CreateConfigMap,
+		get:        s.store.GetConfigMap,
+		update:     s.store.UpdateConfigMap,
+		delete:     s.store.DeleteConfigMap,
+		list:       s.store.ListConfigMaps,
+		watch:      s.store.WatchConfigMaps,
+		validate:   validateConfigMap,
+	})
+}
+
+// configKeyPattern 是 ConfigMap 和 Secret 的键，键会被用作卷里的文件名
+var configKeyPattern = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+func validateConfigKey(field, key string) error {
+	if len(key) > 253 || !configKeyPattern.MatchString(key) || key == "." || key == ".." {
+		return fmt.Errorf("%s key %q must consist of alphanumeric characters, '-', '_' or '.'", field, key)
+	}
+	return nil
+}
+
+func validateConfigMap(cm *api.ConfigMap) error {
+	size := 0
+	for key, value := range cm.Data {
+		if err := validateConfigKey("data", key); err != nil {
+			return err
+		}
+		if !utf8.ValidString(value) {
+			return fmt.Errorf("data[%s] must be valid UTF-8, use binaryData for other values", key)
+		}
+		size += len(key) + len(value)
+	}
+	for key, value := range cm.BinaryData {
+		if err := validateConfigKey("binaryData", key); err != nil {
+			return err
+		}
+		if _, ok := cm.Data[key]; ok {
+			return fmt.Errorf("key %q is set in both data and binaryData", key)
+		}
+		size += len(key) + len(value)
+	}
+	if size > api.MaxConfigDataSize {
+		return fmt.Errorf("data and binaryData must not be larger than %d bytes in total, got %d", api.MaxConfigDataSize, size)
+	}
+	return nil
+}
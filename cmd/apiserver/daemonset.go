@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"mini-k8s/pkg/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *APIServer) registerDaemonSets(router *gin.Engine) {
+	registerResource(router, s, "daemonsets", &resource[api.DaemonSet, *api.DaemonSet]{
+		kind:        "DaemonSet",
+		namespaced:  true,
+		create:      s.store.CreateDaemonSet,
+		get:         s.store.GetDaemonSet,
+		update:      s.store.UpdateDaemonSet,
+		delete:      s.store.DeleteDaemonSet,
+		list:        s.store.ListDaemonSets,
+		watch:       s.store.WatchDaemonSets,
+		setDefaults: setDaemonSetDefaults,
+		validate:    validateDaemonSet,
+		prepareForCreate: func(ds *api.DaemonSet) {
+			ds.Status = api.DaemonSetStatus{}
+		},
+		spec: func(ds *api.DaemonSet) interface{} {
+			copied := ds.DeepCopy()
+			setDaemonSetDefaults(copied)
+			return copied.Spec
+		},
+		copyStatus: func(from, to *api.DaemonSet) {
+			to.Status = from.Status
+		},
+	})
+}
+
+func setDaemonSetDefaults(ds *api.DaemonSet) {
+	if ds.Spec.UpdateStrategy.Type == "" {
+		ds.Spec.UpdateStrategy.Type = api.RollingUpdateDaemonSetStrategyType
+	}
+	if ds.Spec.UpdateStrategy.Type == api.RollingUpdateDaemonSetStrategyType {
+		if ds.Spec.UpdateStrategy.RollingUpdate == nil {
+			ds.Spec.UpdateStrategy.RollingUpdate = &api.RollingUpdateDaemonSet{}
+		}
+		if ds.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable == nil {
+			unavailable := api.FromInt(1)
+			ds.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable = &unavailable
+		}
+	}
+}
+
+func validateDaemonSet(ds *api.DaemonSet) error {
+	if err := validatePodTemplate(ds.Spec.Selector, &ds.Spec.Template); err != nil {
+		return err
+	}
+	if err := validateAlwaysRestart(&ds.Spec.Template); err != nil {
+		return err
+	}
+	switch ds.Spec.UpdateStrategy.Type {
+	case api.OnDeleteDaemonSetStrategyType:
+	case api.RollingUpdateDaemonSetStrategyType:
+		unavailable, err := ds.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable.ScaledValue(100, true)
+		if err != nil {
+			return fmt.Errorf("spec.updateStrategy.rollingUpdate.maxUnavailable: %w", err)
+		}
+		if unavailable <= 0 {
+			return fmt.Errorf("spec.updateStrategy.rollingUpdate.maxUnavailable must be greater than zero")
+		}
+	default:
+		return fmt.Errorf("spec.updateStrategy.type must be RollingUpdate or OnDelete, got %q", ds.Spec.UpdateStrategy.Type)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"mini-k8s/pkg/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *APIServer) registerDeployments(router *gin.Engine) {
+	registerResource(router, s, "deployments", &resource[api.Deployment, *api.Deployment]{
+		kind:        "Deployment",
+		namespaced:  true,
+		create:      s.store.CreateDeployment,
+		get:         s.store.GetDeployment,
+		update:      s.store.UpdateDeployment,
+		delete:      s.store.DeleteDeployment,
+		list:        s.store.ListDeployments,
+		watch:       s.store.WatchDeployments,
+		setDefaults: setDeploymentDefaults,
+		validate:    validateDeployment,
+		prepareForCreate: func(d *api.Deployment) {
+			d.Status = api.DeploymentStatus{}
+		},
+		spec: func(d *api.Deployment) interface{} {
+			//先补上默认值再比较，避免省略默认字段的 apply 被当成 spec 变化
+			copied := d.DeepCopy()
+			setDeploymentDefaults(copied)
+			return copied.Spec
+		},
+		copyStatus: func(from, to *api.Deployment) {
+			to.Status = from.Status
+		},
+	})
+}
+
+// setDeploymentDefaults 填充没有设置的策略、历史版本数和进度期限
+func setDeploymentDefaults(d *api.Deployment) {
+	if d.Spec.Strategy.Type == "" {
+		d.Spec.Strategy.Type = api.RollingUpdateDeploymentStrategyType
+	}
+	if d.Spec.Strategy.Type == api.RollingUpdateDeploymentStrategyType {
+		if d.Spec.Strategy.RollingUpdate == nil {
+			d.Spec.Strategy.RollingUpdate = &api.RollingUpdateDeployment{}
+		}
+		if d.Spec.Strategy.RollingUpdate.MaxSurge == nil {
+			surge := api.FromString("25%")
+			d.Spec.Strategy.RollingUpdate.MaxSurge = &surge
+		}
+		if d.Spec.Strategy.RollingUpdate.MaxUnavailable == nil {
+			unavailable := api.FromString("25%")
+			d.Spec.Strategy.RollingUpdate.MaxUnavailable = &unavailable
+		}
+	}
+	if d.Spec.RevisionHistoryLimit == nil {
+		limit := api.DefaultRevisionHistoryLimit
+		d.Spec.RevisionHistoryLimit = &limit
+	}
+	if d.Spec.ProgressDeadlineSeconds == nil {
+		deadline := api.DefaultProgressDeadlineSeconds
+		d.Spec.ProgressDeadlineSeconds = &deadline
+	}
+}
+
+func validateDeployment(d *api.Deployment) error {
+	if d.Spec.Replicas < 0 {
+		return fmt.Errorf("spec.replicas must not be negative")
+	}
+	if err := validatePodTemplate(d.Spec.Selector, &d.Spec.Template); err != nil {
+		return err
+	}
+	if err := validateAlwaysRestart(&d.Spec.Template); err != nil {
+		return err
+	}
+	switch d.Spec.Strategy.Type {
+	case api.RecreateDeploymentStrategyType:
+		if d.Spec.Strategy.RollingUpdate != nil {
+			return fmt.Errorf("spec.strategy.rollingUpdate must not be set when strategy type is Recreate")
+		}
+	case api.RollingUpdateDeploymentStrategyType:
+		surge, err := d.Spec.Strategy.RollingUpdate.MaxSurge.ScaledValue(100, true)
+		if err != nil {
+			return fmt.Errorf("spec.strategy.rollingUpdate.maxSurge: %w", err)
+		}
+		unavailable, err := d.Spec.Strategy.RollingUpdate.MaxUnavailable.ScaledValue(100, false)
+		if err != nil {
+			return fmt.Errorf("spec.strategy.rollingUpdate.maxUnavailable: %w", err)
+		}
+		if surge < 0 || unavailable < 0 {
+			return fmt.Errorf("spec.strategy.rollingUpdate values must not be negative")
+		}
+		if surge == 0 && unavailable == 0 {
+			return fmt.Errorf("spec.strategy.rollingUpdate.maxSurge and maxUnavailable must not both be zero")
+		}
+	default:
+		return fmt.Errorf("spec.strategy.type must be RollingUpdate or Recreate, got %q", d.Spec.Strategy.Type)
+	}
+	if *d.Spec.RevisionHistoryLimit < 0 {
+		return fmt.Errorf("spec.revisionHistoryLimit must not be negative")
+	}
+	if *d.Spec.ProgressDeadlineSeconds <= d.Spec.MinReadySeconds {
+		return fmt.Errorf("spec.progressDeadlineSeconds must be greater than minReadySeconds")
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"log"
+	"mini-k8s/pkg/encryption"
+	"mini-k8s/pkg/store"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// setupEncryption 读取加密配置，返回加密 Secret 等资源的存储。
+// 收到 SIGHUP 时重新读取配置文件，轮换 key 不需要重启（重启会丢掉内存里的所有对象）
+func setupEncryption(path string, inner store.Store) (store.Store, error) {
+	config, err := encryption.LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	transformer, err := encryption.NewTransformer(config)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := store.NewEncryptedStore(inner, transformer, config)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Encrypting %v at rest with key %s (%d keys can decrypt)", config.Resources, transformer.PrimaryKey(), len(config.Keys))
+
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			config, err := encryption.LoadConfig(path)
+			if err == nil {
+				err = store.CheckEncryptionConfig(config)
+			}
+			if err == nil {
+				err = transformer.Reload(config)
+			}
+			if err != nil {
+				//配置有错时继续使用原来的配置
+				log.Printf("Failed to reload encryption config, keeping the current one: %v", err)
+				continue
+			}
+			log.Printf("Reloaded encryption config: encrypting %v with key %s (%d keys can decrypt)", config.Resources, transformer.PrimaryKey(), len(config.Keys))
+		}
+	}()
+	return encrypted, nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"mini-k8s/pkg/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultCPUUtilization 是没有设置指标时的 CPU 平均利用率目标
+const defaultCPUUtilization = 80
+
+func (s *APIServer) registerHorizontalPodAutoscalers(router *gin.Engine) {
+	registerResource(router, s, "horizontalpodautoscalers", &resource[api.HorizontalPodAutoscaler, *api.HorizontalPodAutoscaler]{
+		kind:        "HorizontalPodAutoscaler",
+		namespaced:  true,
+		create:      s.store.CreateHorizontalPodAutoscaler,
+		get:         s.store.GetHorizontalPodAutoscaler,
+		update:      s.store.UpdateHorizontalPodAutoscaler,
+		delete:      s.store.DeleteHorizontalPodAutoscaler,
+		list:        s.store.ListHorizontalPodAutoscalers,
+		watch:       s.store.WatchHorizontalPodAutoscalers,
+		setDefaults: setHorizontalPodAutoscalerDefaults,
+		validate:    validateHorizontalPodAutoscaler,
+		prepareForCreate: func(hpa *api.HorizontalPodAutoscaler) {
+			hpa.Status = api.HorizontalPodAutoscalerStatus{}
+		},
+		spec: func(hpa *api.HorizontalPodAutoscaler) interface{} {
+			copied := hpa.DeepCopy()
+			setHorizontalPodAutoscalerDefaults(copied)
+			return copied.Spec
+		},
+		copyStatus: func(from, to *api.HorizontalPodAutoscaler) {
+			to.Status = from.Status
+		},
+	})
+}
+
+// setHorizontalPodAutoscalerDefaults 填充最小副本数、默认的 CPU 指标和扩缩容规则。
+// 扩容默认不等待，每 15 秒最多翻倍或者增加 4 个 pod，取多的那个；缩容回看 5 分钟，每 15 秒最多减到 0
+func setHorizontalPodAutoscalerDefaults(hpa *api.HorizontalPodAutoscaler) {
+	if hpa.Spec.MinReplicas == nil {
+		min := 1
+		hpa.Spec.MinReplicas = &min
+	}
+	if len(hpa.Spec.Metrics) == 0 {
+		utilization := defaultCPUUtilization
+		hpa.Spec.Metrics = []api.MetricSpec{{
+			Type: api.MetricSourceResource,
+			Resource: &api.ResourceMetricSource{
+				Name:   api.ResourceCPU,
+				Target: api.MetricTarget{Type: api.UtilizationMetricType, AverageUtilization: &utilization},
+			},
+		}}
+	}
+	if hpa.Spec.Behavior == nil {
+		hpa.Spec.Behavior = &api.HorizontalPodAutoscalerBehavior{}
+	}
+	hpa.Spec.Behavior.ScaleUp = setScalingRulesDefaults(hpa.Spec.Behavior.ScaleUp, 0, []api.HPAScalingPolicy{
+		{Type: api.PercentScalingPolicy, Value: 100, PeriodSeconds: 15},
+		{Type: api.PodsScalingPolicy, Value: 4, PeriodSeconds: 15},
+	})
+	hpa.Spec.Behavior.ScaleDown = setScalingRulesDefaults(hpa.Spec.Behavior.ScaleDown, 300, []api.HPAScalingPolicy{
+		{Type: api.PercentScalingPolicy, Value: 100, PeriodSeconds: 15},
+	})
+}
+
+func setScalingRulesDefaults(rules *api.HPAScalingRules, window int, policies []api.HPAScalingPolicy) *api.HPAScalingRules {
+	if rules == nil {
+		rules = &api.HPAScalingRules{}
+	}
+	if rules.StabilizationWindowSeconds == nil {
+		rules.StabilizationWindowSeconds = &window
+	}
+	if rules.SelectPolicy == "" {
+		rules.SelectPolicy = api.MaxChangePolicySelect
+	}
+	if len(rules.Policies) == 0 {
+		rules.Policies = policies
+	}
+	return rules
+}
+
+func validateHorizontalPodAutoscaler(hpa *api.HorizontalPodAutoscaler) error {
+	ref := hpa.Spec.ScaleTargetRef
+	if ref.Kind != "Deployment" && ref.Kind != "ReplicaSet" {
+		return fmt.Errorf("spec.scaleTargetRef.kind must be Deployment or ReplicaSet, got %q", ref.Kind)
+	}
+	if ref.Name == "" {
+		return fmt.Errorf("spec.scaleTargetRef.name must be set")
+	}
+	if *hpa.Spec.MinReplicas < 1 {
+		return fmt.Errorf("spec.minReplicas must be at least 1")
+	}
+	if hpa.Spec.MaxReplicas < *hpa.Spec.MinReplicas {
+		return fmt.Errorf("spec.maxReplicas must not be less than spec.minReplicas")
+	}
+	for i, metric := range hpa.Spec.Metrics {
+		if err := validateMetricSpec(fmt.Sprintf("spec.metrics[%d]", i), &metric); err != nil {
+			return err
+		}
+	}
+	if err := validateScalingRules("spec.behavior.scaleUp", hpa.Spec.Behavior.ScaleUp); err != nil {
+		return err
+	}
+	return validateScalingRules("spec.behavior.scaleDown", hpa.Spec.Behavior.ScaleDown)
+}
+
+func validateMetricSpec(field string, metric *api.MetricSpec) error {
+	if metric.Type != api.MetricSourceResource {
+		return fmt.Errorf("%s.type must be Resource, got %q", field, metric.Type)
+	}
+	if metric.Resource == nil {
+		return fmt.Errorf("%s.resource must be set", field)
+	}
+	name := metric.Resource.Name
+	if name != api.ResourceCPU && name != api.ResourceMemory {
+		return fmt.Errorf("%s.resource.name must be cpu or memory, got %q", field, name)
+	}
+	target := metric.Resource.Target
+	switch target.Type {
+	case api.UtilizationMetricType:
+		if target.AverageUtilization == nil || *target.AverageUtilization <= 0 {
+			return fmt.Errorf("%s.resource.target.averageUtilization must be a positive percentage", field)
+		}
+		if target.AverageValue != "" {
+			return fmt.Errorf("%s.resource.target.averageValue must not be set for type Utilization", field)
+		}
+	case api.AverageValueMetricType:
+		if target.AverageUtilization != nil {
+			return fmt.Errorf("%s.resource.target.averageUtilization must not be set for type AverageValue", field)
+		}
+		parse := api.ParseQuantity
+		if name == api.ResourceCPU {
+			parse = api.ParseMilliQuantity
+		}
+		value, err := parse(target.AverageValue)
+		if err != nil {
+			return fmt.Errorf("%s.resource.target.averageValue: %w", field, err)
+		}
+		if value <= 0 {
+			return fmt.Errorf("%s.resource.target.averageValue must be positive", field)
+		}
+	default:
+		return fmt.Errorf("%s.resource.target.type must be Utilization or AverageValue, got %q", field, target.Type)
+	}
+	return nil
+}
+
+func validateScalingRules(field string, rules *api.HPAScalingRules) error {
+	if window := *rules.StabilizationWindowSeconds; window < 0 || window > 3600 {
+		return fmt.Errorf("%s.stabilizationWindowSeconds must be between 0 and 3600", field)
+	}
+	switch rules.SelectPolicy {
+	case api.MaxChangePolicySelect, api.MinChangePolicySelect, api.DisabledPolicySelect:
+	default:
+		return fmt.Errorf("%s.selectPolicy must be Max, Min or Disabled, got %q", field, rules.SelectPolicy)
+	}
+	for i, policy := range rules.Policies {
+		if policy.Type != api.PodsScalingPolicy && policy.Type != api.PercentScalingPolicy {
+			return fmt.Errorf("%s.policies[%d].type must be Pods or Percent, got %q", field, i, policy.Type)
+		}
+		if policy.Value <= 0 {
+			return fmt.Errorf("%s.policies[%d].value must be positive", field, i)
+		}
+		if policy.PeriodSeconds <= 0 || policy.PeriodSeconds > 1800 {
+			return fmt.Errorf("%s.policies[%d].periodSeconds must be between 1 and 1800", field, i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"mini-k8s/pkg/api"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerIngresses 注册 Ingress。API server 只保存规则，转发由 cmd/ingress 完成
+func (s *APIServer) registerIngresses(router *gin.Engine) {
+	registerResource(router, s, "ingresses", &resource[api.Ingress, *api.Ingress]{
+		kind:        "Ingress",
+		namespaced:  true,
+		create:      s.store.CreateIngress,
+		get:         s.store.GetIngress,
+		update:      s.store.UpdateIngress,
+		delete:      s.store.DeleteIngress,
+		list:        s.store.ListIngresses,
+		watch:       s.store.WatchIngresses,
+		validate:    validateIngress,
+		setDefaults: setIngressDefaults,
+		spec:        func(ing *api.Ingress) interface{} { return ing.Spec },
+	})
+}
+
+func setIngressDefaults(ing *api.Ingress) {
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for i := range rule.HTTP.Paths {
+			path := &rule.HTTP.Paths[i]
+			if path.Path == "" {
+				path.Path = "/"
+			}
+			if path.PathType == "" {
+				path.PathType = api.PathTypePrefix
+			}
+		}
+	}
+}
+
+func validateIngress(ing *api.Ingress) error {
+	if len(ing.Spec.Rules) == 0 && ing.Spec.DefaultBackend == nil {
+		return fmt.Errorf("spec must have at least one rule or a defaultBackend")
+	}
+	if ing.Spec.DefaultBackend != nil {
+		if err := validateIngressBackend("spec.defaultBackend", ing.Spec.DefaultBackend); err != nil {
+			return err
+		}
+	}
+	for i, tls := range ing.Spec.TLS {
+		field := fmt.Sprintf("spec.tls[%d]", i)
+		if tls.SecretName == "" {
+			return fmt.Errorf("%s.secretName must be set", field)
+		}
+		for j, host := range tls.Hosts {
+			if err := validateIngressHost(fmt.Sprintf("%s.hosts[%d]", field, j), host); err != nil {
+				return err
+			}
+		}
+	}
+	for i, rule := range ing.Spec.Rules {
+		field := fmt.Sprintf("spec.rules[%d]", i)
+		if rule.Host != "" {
+			if err := validateIngressHost(field+".host", rule.Host); err != nil {
+				return err
+			}
+		}
+		if rule.HTTP == nil || len(rule.HTTP.Paths) == 0 {
+			return fmt.Errorf("%s.http.paths must not be empty", field)
+		}
+		for j, path := range rule.HTTP.Paths {
+			pathField := fmt.Sprintf("%s.http.paths[%d]", field, j)
+			if !strings.HasPrefix(path.Path, "/") {
+				return fmt.Errorf("%s.path must be an absolute path", pathField)
+			}
+			if path.PathType != api.PathTypeExact && path.PathType != api.PathTypePrefix {
+				return fmt.Errorf("%s.pathType must be Exact or Prefix, got %q", pathField, path.PathType)
+			}
+			if err := validateIngressBackend(pathField+".backend", &path.Backend); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var dnsLabelPattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$`)
+
+// validateIngressHost 检查 host 是 DNS 名字：不能是 IP，不能带端口，通配符只能是开头的 *.
+func validateIngressHost(field, host string) error {
+	if net.ParseIP(host) != nil {
+		return fmt.Errorf("%s must be a DNS name, not an IP address", field)
+	}
+	name := strings.TrimPrefix(host, "*.")
+	if name == "" || strings.ContainsAny(name, "*:/") || name != strings.ToLower(name) {
+		return fmt.Errorf("%s %q must be a lowercase DNS name, optionally prefixed with *.", field, host)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if !dnsLabelPattern.MatchString(label) {
+			return fmt.Errorf("%s %q must be a lowercase DNS name, optionally prefixed with *.", field, host)
+		}
+	}
+	return nil
+}
+
+func validateIngressBackend(field string, backend *api.IngressBackend) error {
+	if backend.Service == nil {
+		return fmt.Errorf("%s.service must be set", field)
+	}
+	if backend.Service.Name == "" {
+		return fmt.Errorf("%s.service.name must be set", field)
+	}
+	port := backend.Service.Port
+	if (port.Name == "") == (port.Number == 0) {
+		return fmt.Errorf("%s.service.port must set exactly one of name and number", field)
+	}
+	if port.Number < 0 || port.Number > 65535 {
+		return fmt.Errorf("%s.service.port.number must be between 1 and 65535", field)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/cron"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *APIServer) registerJobs(router *gin.Engine) {
+	registerResource(router, s, "jobs", &resource[api.Job, *api.Job]{
+		kind:        "Job",
+		namespaced:  true,
+		create:      s.store.CreateJob,
+		get:         s.store.GetJob,
+		update:      s.store.UpdateJob,
+		delete:      s.store.DeleteJob,
+		list:        s.store.ListJobs,
+		watch:       s.store.WatchJobs,
+		setDefaults: func(job *api.Job) { setJobSpecDefaults(&job.Spec) },
+		validate:    validateJob,
+		prepareForCreate: func(job *api.Job) {
+			job.Status = api.JobStatus{}
+			generateJobSelector(job)
+		},
+		//选择器由服务端生成，更新时沿用创建时的值
+		prepareForUpdate: func(old, job *api.Job) {
+			job.Spec.Selector = old.Spec.Selector.DeepCopy()
+		},
+		spec: func(job *api.Job) interface{} { return job.Spec },
+		copyStatus: func(from, to *api.Job) {
+			to.Status = from.Status
+		},
+	})
+}
+
+func (s *APIServer) registerCronJobs(router *gin.Engine) {
+	registerResource(router, s, "cronjobs", &resource[api.CronJob, *api.CronJob]{
+		kind:        "CronJob",
+		namespaced:  true,
+		create:      s.store.CreateCronJob,
+		get:         s.store.GetCronJob,
+		update:      s.store.UpdateCronJob,
+		delete:      s.store.DeleteCronJob,
+		list:        s.store.ListCronJobs,
+		watch:       s.store.WatchCronJobs,
+		setDefaults: setCronJobDefaults,
+		validate:    validateCronJob,
+		prepareForCreate: func(cj *api.CronJob) {
+			cj.Status = api.CronJobStatus{}
+		},
+		spec: func(cj *api.CronJob) interface{} { return cj.Spec },
+		copyStatus: func(from, to *api.CronJob) {
+			to.Status = from.Status
+		},
+	})
+}
+
+// generateJobSelector 用 Job 的 UID 生成选择器并加到 pod 模板上，保证不同 Job 的 pod 不会互相匹配
+func generateJobSelector(job *api.Job) {
+	if job.Spec.Template.Metadata.Labels == nil {
+		job.Spec.Template.Metadata.Labels = map[string]string{}
+	}
+	job.Spec.Template.Metadata.Labels[api.ControllerUIDLabel] = job.UID
+	job.Spec.Template.Metadata.Labels[api.JobNameLabel] = job.Name
+	job.Spec.Selector = &api.LabelSelector{MatchLabels: map[string]string{api.ControllerUIDLabel: job.UID}}
+}
+
+func setJobSpecDefaults(spec *api.JobSpec) {
+	if spec.Parallelism == nil {
+		parallelism := 1
+		spec.Parallelism = &parallelism
+	}
+	if spec.Completions == nil {
+		completions := 1
+		spec.Completions = &completions
+	}
+	if spec.BackoffLimit == nil {
+		limit := api.DefaultBackoffLimit
+		spec.BackoffLimit = &limit
+	}
+	if spec.Template.Spec.RestartPolicy == "" {
+		spec.Template.Spec.RestartPolicy = api.RestartPolicyNever
+	}
+}
+
+func validateJobSpec(spec *api.JobSpec) error {
+	if *spec.Parallelism < 0 {
+		return fmt.Errorf("spec.parallelism must not be negative")
+	}
+	if *spec.Completions < 1 {
+		return fmt.Errorf("spec.completions must be at least 1")
+	}
+	if *spec.BackoffLimit < 0 {
+		return fmt.Errorf("spec.backoffLimit must not be negative")
+	}
+	if spec.ActiveDeadlineSeconds != nil && *spec.ActiveDeadlineSeconds <= 0 {
+		return fmt.Errorf("spec.activeDeadlineSeconds must be positive")
+	}
+	if spec.TTLSecondsAfterFinished != nil && *spec.TTLSecondsAfterFinished < 0 {
+		return fmt.Errorf("spec.ttlSecondsAfterFinished must not be negative")
+	}
+	if p := spec.Template.Spec.RestartPolicy; p != api.RestartPolicyNever && p != api.RestartPolicyOnFailure {
+		return fmt.Errorf("spec.template.spec.restartPolicy must be Never or OnFailure, got %q", p)
+	}
+	if spec.Template.Spec.Image == "" {
+		return fmt.Errorf("spec.template.spec.image must be provided")
+	}
+	return nil
+}
+
+func validateJob(job *api.Job) error {
+	if err := validateJobSpec(&job.Spec); err != nil {
+		return err
+	}
+	return validatePodTemplate(job.Spec.Selector, &job.Spec.Template)
+}
+
+func setCronJobDefaults(cj *api.CronJob) {
+	if cj.Spec.ConcurrencyPolicy == "" {
+		cj.Spec.ConcurrencyPolicy = api.AllowConcurrent
+	}
+	if cj.Spec.SuccessfulJobsHistoryLimit == nil {
+		limit := api.DefaultSuccessfulJobsHistoryLimit
+		cj.Spec.SuccessfulJobsHistoryLimit = &limit
+	}
+	if cj.Spec.FailedJobsHistoryLimit == nil {
+		limit := api.DefaultFailedJobsHistoryLimit
+		cj.Spec.FailedJobsHistoryLimit = &limit
+	}
+	setJobSpecDefaults(&cj.Spec.JobTemplate.Spec)
+}
+
+func validateCronJob(cj *api.CronJob) error {
+	if _, err := cron.Parse(cj.Spec.Schedule); err != nil {
+		return fmt.Errorf("spec.schedule: %w", err)
+	}
+	if cj.Spec.TimeZone != nil {
+		if _, err := time.LoadLocation(*cj.Spec.TimeZone); err != nil {
+			return fmt.Errorf("spec.timeZone: unknown time zone %q", *cj.Spec.TimeZone)
+		}
+	}
+	switch cj.Spec.ConcurrencyPolicy {
+	case api.AllowConcurrent, api.ForbidConcurrent, api.ReplaceConcurrent:
+	default:
+		return fmt.Errorf("spec.concurrencyPolicy must be Allow, Forbid or Replace, got %q", cj.Spec.ConcurrencyPolicy)
+	}
+	if cj.Spec.StartingDeadlineSeconds != nil && *cj.Spec.StartingDeadlineSeconds < 0 {
+		return fmt.Errorf("spec.startingDeadlineSeconds must not be negative")
+	}
+	if *cj.Spec.SuccessfulJobsHistoryLimit < 0 || *cj.Spec.FailedJobsHistoryLimit < 0 {
+		return fmt.Errorf("spec job history limits must not be negative")
+	}
+	if err := validateJobSpec(&cj.Spec.JobTemplate.Spec); err != nil {
+		return fmt.Errorf("spec.jobTemplate.%w", err)
+	}
+	return nil
+}
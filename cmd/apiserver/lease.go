@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"mini-k8s/pkg/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerLeases 注册 Lease。Lease 没有 status，抢占和续约都通过带 resourceVersion 的 PUT 完成
+func (s *APIServer) registerLeases(router *gin.Engine) {
+	registerResource(router, s, "leases", &resource[api.Lease, *api.Lease]{
+		kind:       "Lease",
+		namespaced: true,
+		create:     s.store.CreateLease,
+		get:        s.store.GetLease,
+		update:     s.store.UpdateLease,
+		delete:     s.store.DeleteLease,
+		list:       s.store.ListLeases,
+		watch:      s.store.WatchLeases,
+		validate:   validateLease,
+	})
+}
+
+func validateLease(lease *api.Lease) error {
+	if lease.Spec.LeaseDurationSeconds < 0 {
+		return fmt.Errorf("spec.leaseDurationSeconds must not be negative")
+	}
+	if lease.Spec.LeaseTransitions < 0 {
+		return fmt.Errorf("spec.leaseTransitions must not be negative")
+	}
+	return nil
+}
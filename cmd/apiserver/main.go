@@ -1,13 +1,18 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
+	"mini-k8s/pkg/allocator"
 	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/apply"
 	"mini-k8s/pkg/store"
 	"net/http"
+	"net/http/httputil"
 	"strings"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,12 +21,34 @@ const DefaultNamespace = "default"
 
 type APIServer struct {
 	store store.Store
+
+	// serviceIPs 和 nodePorts 给 service 分配 ClusterIP 和 nodePort，由 initServiceAllocators 创建
+	serviceIPRange *allocator.CIDRRange
+	nodePortRange  *allocator.PortRange
+	serviceIPs     *allocator.Allocator
+	nodePorts      *allocator.Allocator
+	// nodeCIDRs 在节点注册时给它分配 pod 子网，由 initNodeCIDRAllocator 创建
+	nodeCIDRs *allocator.Allocator
+	// metricsProxy 把 metrics API 转发给 metrics-server，没有配置时为 nil
+	metricsProxy *httputil.ReverseProxy
+	// evictionMu 让驱逐请求依次检查和扣减 PDB 的名额
+	evictionMu sync.Mutex
 }
 
 func NewAPIServer(s store.Store) *APIServer {
 	return &APIServer{store: s}
 }
 func (s *APIServer) Serve(port string) {
+	router := s.newRouter()
+	log.Printf("API Server starting on port %s using Gin", port)
+	// if err := http.ListenAndServe(":"+port, mux); err != nil { // Old http way
+	if err := router.Run(":" + port); err != nil { // Gin way
+		log.Fatalf("Failed to start Gin server: %v", err)
+	}
+}
+
+// newRouter 注册所有的路由
+func (s *APIServer) newRouter() *gin.Engine {
 	router := gin.Default() // Use Gin router
 
 	// Dev-friendly CORS (lets you open the UI from file:// or another port).
@@ -40,6 +67,17 @@ func (s *APIServer) Serve(port string) {
 	router.GET("/", func(c *gin.Context) { c.Redirect(http.StatusFound, "/ui/") })
 	router.Static("/ui", "./web")
 
+	// Namespace routes
+	// /api/v1/namespaces
+	namespacesGroup := router.Group("/api/v1/namespaces")
+	{
+		namespacesGroup.POST("", s.createNamespaceHandlerGin)
+		namespacesGroup.GET("", s.listNamespacesHandlerGin)
+		namespacesGroup.GET("/:namespace", s.getNamespaceHandlerGin)
+		namespacesGroup.DELETE("/:namespace", s.deleteNamespaceHandlerGin)
+		namespacesGroup.PUT("/:namespace/finalize", s.finalizeNamespaceHandlerGin)
+	}
+
 	// Pod routes
 	// /api/v1/namespaces/{namespace}/pods
 	podsGroup := router.Group("/api/v1/namespaces/:namespace/pods")
@@ -48,9 +86,14 @@ func (s *APIServer) Serve(port string) {
 		podsGroup.GET("", s.listPodsHandlerGin)
 		podsGroup.GET("/:podname", s.getPodHandlerGin)
 		podsGroup.PUT("/:podname", s.updatePodHandlerGin) // Added route for updating a pod
+		podsGroup.PATCH("/:podname", s.patchPodHandlerGin)
 		podsGroup.DELETE("/:podname", s.deletePodHandlerGin)
+		podsGroup.POST("/:podname/eviction", s.evictPodHandlerGin)
 	}
 
+	// /api/v1/pods lists and watches pods across all namespaces
+	router.GET("/api/v1/pods", s.listAllPodsHandlerGin)
+
 	// Node routes
 	// /api/v1/nodes
 	nodesGroup := router.Group("/api/v1/nodes")
@@ -59,14 +102,45 @@ func (s *APIServer) Serve(port string) {
 		nodesGroup.GET("", s.listNodesHandlerGin)
 		nodesGroup.GET("/:nodename", s.getNodeHandlerGin)
 		nodesGroup.PUT("/:nodename", s.updateNodeHandlerGin) // Add PUT route for updating a node
+		nodesGroup.PATCH("/:nodename", s.patchNodeHandlerGin)
 		// DELETE for a node could be added here: nodesGroup.DELETE("/:nodename", s.deleteNodeHandlerGin)
 	}
 
-	log.Printf("API Server starting on port %s using Gin", port)
-	// if err := http.ListenAndServe(":"+port, mux); err != nil { // Old http way
-	if err := router.Run(":" + port); err != nil { // Gin way
-		log.Fatalf("Failed to start Gin server: %v", err)
-	}
+	// Workload routes
+	s.registerReplicaSets(router)
+	s.registerDeployments(router)
+	s.registerJobs(router)
+	s.registerCronJobs(router)
+	s.registerDaemonSets(router)
+	s.registerStatefulSets(router)
+	s.registerControllerRevisions(router)
+
+	// Coordination routes
+	s.registerLeases(router)
+
+	// Networking routes
+	s.registerServices(router)
+	s.registerEndpoints(router)
+	s.registerIngresses(router)
+
+	// Config routes
+	s.registerSecrets(router)
+	s.registerConfigMaps(router)
+
+	// Storage routes
+	s.registerPersistentVolumes(router)
+	s.registerPersistentVolumeClaims(router)
+	s.registerStorageClasses(router)
+
+	// Autoscaling routes
+	s.registerHorizontalPodAutoscalers(router)
+
+	// Policy routes
+	s.registerPodDisruptionBudgets(router)
+
+	// Metrics routes, served by metrics-server
+	s.registerMetrics(router)
+	return router
 }
 func (s *APIServer) createPodHandlerGin(c *gin.Context) {
 	namespace := c.Param("namespace")
@@ -75,20 +149,47 @@ func (s *APIServer) createPodHandlerGin(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "Invalid request body" + err.Error()})
 		return
 	}
-	if pod.Name == "" {
-		c.JSON(400, gin.H{"error": "Pod name must be provided"})
+	if pod.Name == "" && pod.GenerateName == "" {
+		c.JSON(400, gin.H{"error": "Pod name or generateName must be provided"})
 		return
 	}
 	pod.Namespace = namespace
 	if pod.Namespace == "" {
 		pod.Namespace = DefaultNamespace
 	}
+	if !s.checkNamespaceAcceptsObjects(c, pod.Namespace) {
+		return
+	}
+	if err := validatePod(&pod); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid pod: " + err.Error()})
+		return
+	}
+	if err := validateFinalizers(nil, &pod.ObjectMeta); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid pod: " + err.Error()})
+		return
+	}
+	//创建时已经指定了节点的 pod（比如 DaemonSet 的 pod）不经过调度器，直接交给这个节点的 kubelet
 	pod.Phase = api.PodPending
-	pod.NodeName = ""
+	if pod.NodeName != "" {
+		pod.Phase = api.PodScheduled
+	}
+	pod.StartTime = nil
+	pod.RestartCount = 0
+	pod.Reason = ""
+	pod.Message = ""
+	pod.PodIP = ""
+	pod.HostIP = ""
+	initObjectMeta(&pod.ObjectMeta)
+	if err := apply.Update(nil, &pod, fieldManager(c)); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to record managed fields: " + err.Error()})
+		return
+	}
 	if err := s.store.CreatePod(&pod); err != nil {
 		log.Printf("Error creating pod %s/%s in store: %v", pod.Namespace, pod.Name, err) // Log the actual error
 		if strings.Contains(err.Error(), "already exists") {
 			c.JSON(409, gin.H{"error": "failed to create pod because:" + err.Error()})
+		} else if strings.Contains(err.Error(), "must not") {
+			c.JSON(400, gin.H{"error": "Failed to create pod: " + err.Error()})
 		} else {
 			c.JSON(500, gin.H{"error": "Failed to create pod: " + err.Error()}) // 500 for other errors
 		}
@@ -97,6 +198,16 @@ func (s *APIServer) createPodHandlerGin(c *gin.Context) {
 	log.Printf("created pod %s/%s", pod.Namespace, pod.Name)
 	c.JSON(201, pod)
 }
+
+// validatePod 检查 pod 的 spec，创建、更新和 apply 都要经过它。kubelet 按 volumes 和 volumeMounts 在节点上建立目录和符号链接，
+// 不合法的 subPath 或者 hostPath 会让它操作 pod 目录以外的路径
+func validatePod(pod *api.Pod) error {
+	if err := validateRestartPolicy(pod.RestartPolicy); err != nil {
+		return err
+	}
+	return validatePodConfig("", &pod.PodSpec)
+}
+
 func (s *APIServer) getPodHandlerGin(c *gin.Context) {
 	namespace := c.Param("namespace")
 	podName := c.Param("podname")
@@ -108,17 +219,42 @@ func (s *APIServer) getPodHandlerGin(c *gin.Context) {
 	c.JSON(200, pod)
 }
 func (s *APIServer) listPodsHandlerGin(c *gin.Context) {
-	namespace := c.Param("namespace")
+	s.listOrWatchPods(c, c.Param("namespace"))
+}
+
+// listAllPodsHandlerGin 处理 /api/v1/pods，列出或者监听所有命名空间的 pod
+func (s *APIServer) listAllPodsHandlerGin(c *gin.Context) {
+	s.listOrWatchPods(c, "")
+}
+
+func (s *APIServer) listOrWatchPods(c *gin.Context, namespace string) {
+	var events <-chan api.WatchEvent[api.Pod]
+	var stop func()
+	//先订阅再 list，保证 list 之后发生的变化不会丢
+	if isWatch(c) {
+		events, stop = s.store.WatchPods(namespace)
+	}
 	pods, err := s.store.ListPods(namespace)
 	if err != nil {
+		if stop != nil {
+			stop()
+		}
 		c.JSON(500, gin.H{"error": "Failed to list pods: " + err.Error()})
 		return
 	}
+	if events != nil {
+		serveWatch(c, pods, events, stop)
+		return
+	}
 	c.JSON(200, pods)
 }
 func (s *APIServer) deletePodHandlerGin(c *gin.Context) {
 	namespace := c.Param("namespace")
 	podName := c.Param("podname")
+	//pod 不会是其它对象的 owner，三种 propagationPolicy 的效果相同，这里只检查参数是否合法
+	if _, ok := deletionPropagation(c); !ok {
+		return
+	}
 	if err := s.store.DeletePod(namespace, podName); err != nil {
 		log.Printf("Error deleting pod %s/%s from store: %v", namespace, podName, err) // Log the actual error
 		if strings.Contains(err.Error(), "not found") {
@@ -152,15 +288,29 @@ func (s *APIServer) updatePodHandlerGin(c *gin.Context) {
 	}
 
 	// Ensure the pod exists before updating (optional, store might handle this)
-	_, err := s.store.GetPod(namespace, podName)
+	existing, err := s.store.GetPod(namespace, podName)
 	if err != nil {
 		c.JSON(404, gin.H{"error": fmt.Sprintf("Pod %s/%s not found for update: %s", namespace, podName, err.Error())})
 		return
 	}
+	preserveObjectMeta(&existing.ObjectMeta, &pod.ObjectMeta)
+	if err := validatePod(&pod); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid pod: " + err.Error()})
+		return
+	}
+	if err := validateFinalizers(&existing.ObjectMeta, &pod.ObjectMeta); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid pod: " + err.Error()})
+		return
+	}
+	//managedFields 由服务端维护，忽略客户端传上来的值
+	if err := apply.Update(existing, &pod, fieldManager(c)); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to record managed fields: " + err.Error()})
+		return
+	}
 
 	if err := s.store.UpdatePod(&pod); err != nil {
 		log.Printf("Failed to update pod in store: %v", err)
-		c.JSON(500, gin.H{"error": "Failed to update pod: " + err.Error()})
+		c.JSON(storeErrorStatus(err), gin.H{"error": "Failed to update pod: " + err.Error()})
 		return
 	}
 
@@ -179,12 +329,20 @@ func (s *APIServer) createNodeHandlerGin(c *gin.Context) {
 	if node.Status == "" {
 		node.Status = api.NodeNotReady
 	}
+	node.Namespace = ""
+	initObjectMeta(&node.ObjectMeta)
+	if err := apply.Update(nil, &node, fieldManager(c)); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to record managed fields: " + err.Error()})
+		return
+	}
+	allocated, err := s.assignPodCIDR(nil, &node)
+	if err != nil {
+		c.JSON(storeErrorStatus(err), gin.H{"error": "Failed to create node: " + err.Error()})
+		return
+	}
 	if err := s.store.CreateNode(&node); err != nil {
-		if strings.Contains(err.Error(), "already exists") {
-			c.JSON(409, gin.H{"error": "Failed to create node: " + err.Error()})
-		} else {
-			c.JSON(500, gin.H{"error": "Failed to create node: " + err.Error()})
-		}
+		s.releasePodCIDR(allocated)
+		c.JSON(createErrorStatus(err), gin.H{"error": "Failed to create node: " + err.Error()})
 		return
 	}
 	c.JSON(201, node)
@@ -203,11 +361,23 @@ func (s *APIServer) getNodeHandlerGin(c *gin.Context) {
 
 // Gin handler for listing all nodes
 func (s *APIServer) listNodesHandlerGin(c *gin.Context) {
+	var events <-chan api.WatchEvent[api.Node]
+	var stop func()
+	if isWatch(c) {
+		events, stop = s.store.WatchNodes()
+	}
 	nodes, err := s.store.ListNodes()
 	if err != nil {
+		if stop != nil {
+			stop()
+		}
 		c.JSON(500, gin.H{"error": "Failed to list nodes: " + err.Error()})
 		return
 	}
+	if events != nil {
+		serveWatch(c, nodes, events, stop)
+		return
+	}
 	c.JSON(200, nodes)
 }
 func (s *APIServer) updateNodeHandlerGin(c *gin.Context) {
@@ -223,25 +393,256 @@ func (s *APIServer) updateNodeHandlerGin(c *gin.Context) {
 	}
 	updateNode.Name = nodeName
 
-	_, err := s.store.GetNode(nodeName)
+	existing, err := s.store.GetNode(nodeName)
 	if err != nil {
 		c.JSON(404, gin.H{"error": "Node not found: " + err.Error()})
 		return
 	}
+	preserveObjectMeta(&existing.ObjectMeta, &updateNode.ObjectMeta)
+	//podCIDR 分配之后不能修改；之前没有分配子网的节点在这里补上
+	allocated, err := s.assignPodCIDR(existing, &updateNode)
+	if err != nil {
+		c.JSON(storeErrorStatus(err), gin.H{"error": "Failed to update node: " + err.Error()})
+		return
+	}
+	if err := apply.Update(existing, &updateNode, fieldManager(c)); err != nil {
+		s.releasePodCIDR(allocated)
+		c.JSON(500, gin.H{"error": "Failed to record managed fields: " + err.Error()})
+		return
+	}
 	if err := s.store.UpdateNode(&updateNode); err != nil {
-		c.JSON(500, gin.H{"error": "Failed to update node: " + err.Error()})
+		s.releasePodCIDR(allocated)
+		c.JSON(storeErrorStatus(err), gin.H{"error": "Failed to update node: " + err.Error()})
 		return
 	}
 	log.Printf("update node %s", nodeName)
 	c.JSON(200, updateNode)
 
 }
+
+// fieldManager 返回请求里的 fieldManager 参数；没有的话用 User-Agent 的产品名代替
+func fieldManager(c *gin.Context) string {
+	if manager := c.Query("fieldManager"); manager != "" {
+		return manager
+	}
+	userAgent := c.Request.UserAgent()
+	if i := strings.Index(userAgent, "/"); i > 0 {
+		userAgent = userAgent[:i]
+	}
+	if userAgent == "" {
+		return "unknown"
+	}
+	return userAgent
+}
+
+// readApplyPatch 校验 server-side apply 请求并返回 patch 内容；校验失败时已经写好了响应
+func readApplyPatch(c *gin.Context, name, namespace string) ([]byte, bool) {
+	if c.ContentType() != api.ApplyPatchType {
+		c.JSON(415, gin.H{"error": fmt.Sprintf("Unsupported patch content type %q, expected %s", c.ContentType(), api.ApplyPatchType)})
+		return nil, false
+	}
+	if c.Query("fieldManager") == "" {
+		c.JSON(400, gin.H{"error": "fieldManager query parameter is required for apply"})
+		return nil, false
+	}
+	patch, err := c.GetRawData()
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body: " + err.Error()})
+		return nil, false
+	}
+	var meta api.ObjectMeta
+	if err := json.Unmarshal(patch, &meta); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body: " + err.Error()})
+		return nil, false
+	}
+	if meta.Name != "" && meta.Name != name {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("Name in body (%s) does not match name in URL (%s)", meta.Name, name)})
+		return nil, false
+	}
+	if meta.Namespace != "" && meta.Namespace != namespace {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("Namespace in body (%s) does not match namespace in URL (%s)", meta.Namespace, namespace)})
+		return nil, false
+	}
+	return patch, true
+}
+
+func applyErrorStatus(err error) int {
+	if apply.IsConflict(err) {
+		return 409
+	}
+	return 400
+}
+
+// storeErrorStatus 返回写入存储失败时的状态码：对象在读出之后被别人改过（resourceVersion 不一致），或者要求的 ClusterIP、nodePort、podCIDR 已经被占用时是 409
+// getErrorStatus 是读取对象失败时的状态码。对象存在但读不出来（比如解密失败）时返回 500，
+// 不能让客户端以为对象不存在
+func getErrorStatus(err error) int {
+	if strings.Contains(err.Error(), "not found") {
+		return 404
+	}
+	return 500
+}
+
+func storeErrorStatus(err error) int {
+	if strings.Contains(err.Error(), "the object has been modified") || strings.Contains(err.Error(), "already allocated") {
+		return 409
+	}
+	return 500
+}
+
+// createErrorStatus 是创建失败时的状态码：名字或者分配的 IP、端口已经被占用时返回 409，对象本身不合法时返回 400
+func createErrorStatus(err error) int {
+	if strings.Contains(err.Error(), "already exists") || strings.Contains(err.Error(), "already allocated") {
+		return 409
+	}
+	if strings.Contains(err.Error(), "must not") {
+		return 400
+	}
+	return 500
+}
+
+// patchPodHandlerGin 处理 server-side apply：把部分 pod 合并进现有 pod，pod 不存在时直接创建
+func (s *APIServer) patchPodHandlerGin(c *gin.Context) {
+	namespace := c.Param("namespace")
+	podName := c.Param("podname")
+	patch, ok := readApplyPatch(c, podName, namespace)
+	if !ok {
+		return
+	}
+	force := c.Query("force") == "true"
+
+	var live api.Object
+	existing, err := s.store.GetPod(namespace, podName)
+	if err == nil {
+		live = existing
+	}
+	var pod api.Pod
+	if err := apply.Apply(live, patch, c.Query("fieldManager"), force, &pod); err != nil {
+		c.JSON(applyErrorStatus(err), gin.H{"error": "Failed to apply pod: " + err.Error()})
+		return
+	}
+
+	var old *api.ObjectMeta
+	if existing != nil {
+		old = &existing.ObjectMeta
+	}
+	if err := validatePod(&pod); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid pod: " + err.Error()})
+		return
+	}
+	if err := validateFinalizers(old, &pod.ObjectMeta); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid pod: " + err.Error()})
+		return
+	}
+
+	if existing == nil {
+		if !s.checkNamespaceAcceptsObjects(c, namespace) {
+			return
+		}
+		pod.Name = podName
+		pod.Namespace = namespace
+		pod.Phase = api.PodPending
+		pod.NodeName = ""
+		pod.StartTime = nil
+		pod.PodIP = ""
+		pod.HostIP = ""
+		initAppliedObjectMeta(&pod.ObjectMeta)
+		if err := s.store.CreatePod(&pod); err != nil {
+			c.JSON(createErrorStatus(err), gin.H{"error": "Failed to create pod: " + err.Error()})
+			return
+		}
+		log.Printf("created pod %s/%s through apply", namespace, podName)
+		c.JSON(201, pod)
+		return
+	}
+	if err := s.store.UpdatePod(&pod); err != nil {
+		log.Printf("Failed to apply pod in store: %v", err)
+		c.JSON(storeErrorStatus(err), gin.H{"error": "Failed to apply pod: " + err.Error()})
+		return
+	}
+	c.JSON(200, pod)
+}
+
+// patchNodeHandlerGin 处理节点的 server-side apply
+func (s *APIServer) patchNodeHandlerGin(c *gin.Context) {
+	nodeName := c.Param("nodename")
+	patch, ok := readApplyPatch(c, nodeName, "")
+	if !ok {
+		return
+	}
+	force := c.Query("force") == "true"
+
+	var live api.Object
+	existing, err := s.store.GetNode(nodeName)
+	if err == nil {
+		live = existing
+	}
+	var node api.Node
+	if err := apply.Apply(live, patch, c.Query("fieldManager"), force, &node); err != nil {
+		c.JSON(applyErrorStatus(err), gin.H{"error": "Failed to apply node: " + err.Error()})
+		return
+	}
+
+	allocated, err := s.assignPodCIDR(existing, &node)
+	if err != nil {
+		c.JSON(storeErrorStatus(err), gin.H{"error": "Failed to apply node: " + err.Error()})
+		return
+	}
+	if existing == nil {
+		node.Name = nodeName
+		initAppliedObjectMeta(&node.ObjectMeta)
+		if node.Status == "" {
+			node.Status = api.NodeNotReady
+		}
+		if err := s.store.CreateNode(&node); err != nil {
+			s.releasePodCIDR(allocated)
+			c.JSON(createErrorStatus(err), gin.H{"error": "Failed to create node: " + err.Error()})
+			return
+		}
+		c.JSON(201, node)
+		return
+	}
+	if err := s.store.UpdateNode(&node); err != nil {
+		s.releasePodCIDR(allocated)
+		c.JSON(storeErrorStatus(err), gin.H{"error": "Failed to apply node: " + err.Error()})
+		return
+	}
+	log.Printf("applied node %s", nodeName)
+	c.JSON(200, node)
+}
+
 func main() {
 	port := flag.String("port", "8055", "Port to run the api server on")
+	clusterIPRange := flag.String("service-cluster-ip-range", DefaultServiceClusterIPRange, "IPv4 CIDR from which service cluster IPs are allocated")
+	nodePortRange := flag.String("service-node-port-range", DefaultServiceNodePortRange, "Port range reserved for services of type NodePort")
+	clusterCIDR := flag.String("cluster-cidr", DefaultClusterCIDR, "IPv4 CIDR from which each node is given a pod CIDR; empty disables pod CIDR allocation")
+	nodeCIDRMaskSize := flag.Int("node-cidr-mask-size", DefaultNodeCIDRMaskSize, "Prefix length of the pod CIDR given to each node")
+	encryptionConfig := flag.String("encryption-provider-config", "", "JSON file with the resources to encrypt at rest and the AES-GCM keys; reloaded on SIGHUP")
+	metricsServer := flag.String("metrics-server", "", "URL of the metrics server that serves /apis/metrics.k8s.io; empty disables the metrics API")
 	flag.Parse()
 	gin.SetMode(gin.ReleaseMode)
-	dataStore := store.NewInMemoryStore()
+	var dataStore store.Store = store.NewInMemoryStore()
+	if *encryptionConfig != "" {
+		var err error
+		if dataStore, err = setupEncryption(*encryptionConfig, dataStore); err != nil {
+			log.Fatalf("Failed to set up encryption at rest: %v", err)
+		}
+	}
 	server := NewAPIServer(dataStore)
+	if err := server.initServiceAllocators(*clusterIPRange, *nodePortRange); err != nil {
+		log.Fatalf("%v", err)
+	}
+	if err := server.initNodeCIDRAllocator(*clusterCIDR, *nodeCIDRMaskSize); err != nil {
+		log.Fatalf("%v", err)
+	}
+	if *metricsServer != "" {
+		if err := server.setMetricsServer(*metricsServer); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+	if err := server.ensureSystemNamespaces(); err != nil {
+		log.Fatalf("Failed to create system namespaces: %v", err)
+	}
 	server.Serve(*port)
 
 }
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"mini-k8s/pkg/store"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestRouter 返回一个使用内存存储、已经创建了系统命名空间的 API server 的路由
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	s := NewAPIServer(store.NewInMemoryStore())
+	if err := s.ensureSystemNamespaces(); err != nil {
+		t.Fatalf("ensureSystemNamespaces: %v", err)
+	}
+	return s.newRouter()
+}
+
+// do 发送一个 JSON 请求，PATCH 使用 apply 的 content type
+func do(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if method == http.MethodPatch {
+		req.Header.Set("Content-Type", "application/apply-patch+json")
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestPodWritesAreValidated 检查创建、更新和 apply 都拒绝不合法的 pod，
+// 否则 kubelet 会按照 pod 目录以外的 subPath 和 hostPath 操作节点上的文件
+func TestPodWritesAreValidated(t *testing.T) {
+	const pods = "/api/v1/namespaces/default/pods"
+	invalid := []struct {
+		name string
+		spec string
+	}{
+		{name: "subPath escapes the volume", spec: `"volumes":[{"name":"data","emptyDir":{}}],"volumeMounts":[{"name":"data","mountPath":"/data","subPath":"../../etc"}]`},
+		{name: "relative hostPath", spec: `"volumes":[{"name":"data","hostPath":{"path":"etc"}}]`},
+		{name: "unknown restartPolicy", spec: `"restartPolicy":"Bogus"`},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(t)
+			body := `{"name":"web","namespace":"default","image":"sleep",` + tt.spec + `}`
+			if w := do(router, http.MethodPost, pods, body); w.Code != 400 {
+				t.Errorf("POST = %d %s, want 400", w.Code, w.Body)
+			}
+			if w := do(router, http.MethodPatch, pods+"/web?fieldManager=test", body); w.Code != 400 {
+				t.Errorf("apply creating the pod = %d %s, want 400", w.Code, w.Body)
+			}
+			if w := do(router, http.MethodPost, pods, `{"name":"web","namespace":"default","image":"sleep"}`); w.Code != 201 {
+				t.Fatalf("POST valid pod = %d %s", w.Code, w.Body)
+			}
+			if w := do(router, http.MethodPut, pods+"/web", body); w.Code != 400 {
+				t.Errorf("PUT = %d %s, want 400", w.Code, w.Body)
+			}
+			if w := do(router, http.MethodPatch, pods+"/web?fieldManager=test", body); w.Code != 400 {
+				t.Errorf("apply updating the pod = %d %s, want 400", w.Code, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setMetricsServer 设置 metrics.k8s.io API 转发到的 metrics-server
+func (s *APIServer) setMetricsServer(rawURL string) error {
+	target, err := url.Parse(rawURL)
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		return fmt.Errorf("invalid -metrics-server %q: must be a URL like http://localhost:4443", rawURL)
+	}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		log.Printf("Error proxying %s to the metrics server: %v", r.URL.Path, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "{\"error\":%q}\n", "metrics server is unavailable: "+err.Error())
+	}
+	s.metricsProxy = proxy
+	return nil
+}
+
+// registerMetrics 把 /apis/metrics.k8s.io 下的请求原样转发给 metrics-server，没有配置时返回 503
+func (s *APIServer) registerMetrics(router *gin.Engine) {
+	router.GET("/apis/metrics.k8s.io/*path", func(c *gin.Context) {
+		if s.metricsProxy == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "metrics API is not available: the API server was started without -metrics-server"})
+			return
+		}
+		s.metricsProxy.ServeHTTP(c.Writer, c.Request)
+	})
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/apply"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// systemNamespaces 在启动时创建并且不能删除：没有指定命名空间的对象都放在 default 里，
+// kube-system 放系统组件自己的对象，比如 leader election 的 Lease
+var systemNamespaces = []string{DefaultNamespace, api.NamespaceSystem}
+
+// ensureSystemNamespaces 创建还不存在的系统命名空间
+func (s *APIServer) ensureSystemNamespaces() error {
+	for _, name := range systemNamespaces {
+		if _, err := s.store.GetNamespace(name); err == nil {
+			continue
+		}
+		ns := &api.Namespace{
+			ObjectMeta: api.ObjectMeta{Name: name},
+			Spec:       api.NamespaceSpec{Finalizers: []api.FinalizerName{api.FinalizerKubernetes}},
+			Status:     api.NamespaceStatus{Phase: api.NamespaceActive},
+		}
+		initObjectMeta(&ns.ObjectMeta)
+		if err := s.store.CreateNamespace(ns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkNamespaceAcceptsObjects 确认命名空间存在并且不在删除中；不满足时已经写好了响应
+func (s *APIServer) checkNamespaceAcceptsObjects(c *gin.Context, namespace string) bool {
+	ns, err := s.store.GetNamespace(namespace)
+	if err != nil {
+		c.JSON(404, gin.H{"error": "Namespace not found: " + err.Error()})
+		return false
+	}
+	if ns.Status.Phase == api.NamespaceTerminating {
+		c.JSON(403, gin.H{"error": fmt.Sprintf("Namespace %s is being terminated, no new objects may be created in it", namespace)})
+		return false
+	}
+	return true
+}
+
+func (s *APIServer) createNamespaceHandlerGin(c *gin.Context) {
+	var ns api.Namespace
+	if err := c.ShouldBindJSON(&ns); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	if ns.Name == "" {
+		c.JSON(400, gin.H{"error": "Namespace name must be provided"})
+		return
+	}
+	ns.Namespace = ""
+	initObjectMeta(&ns.ObjectMeta)
+	ns.Status.Phase = api.NamespaceActive
+	if len(ns.Spec.Finalizers) == 0 {
+		ns.Spec.Finalizers = []api.FinalizerName{api.FinalizerKubernetes}
+	}
+	if err := apply.Update(nil, &ns, fieldManager(c)); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to record managed fields: " + err.Error()})
+		return
+	}
+	if err := s.store.CreateNamespace(&ns); err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			c.JSON(409, gin.H{"error": "Failed to create namespace: " + err.Error()})
+		} else if strings.Contains(err.Error(), "must not") {
+			c.JSON(400, gin.H{"error": "Failed to create namespace: " + err.Error()})
+		} else {
+			c.JSON(500, gin.H{"error": "Failed to create namespace: " + err.Error()})
+		}
+		return
+	}
+	log.Printf("created namespace %s", ns.Name)
+	c.JSON(201, ns)
+}
+
+func (s *APIServer) getNamespaceHandlerGin(c *gin.Context) {
+	ns, err := s.store.GetNamespace(c.Param("namespace"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": "Namespace not found: " + err.Error()})
+		return
+	}
+	c.JSON(200, ns)
+}
+
+func (s *APIServer) listNamespacesHandlerGin(c *gin.Context) {
+	var events <-chan api.WatchEvent[api.Namespace]
+	var stop func()
+	if isWatch(c) {
+		events, stop = s.store.WatchNamespaces()
+	}
+	namespaces, err := s.store.ListNamespaces()
+	if err != nil {
+		if stop != nil {
+			stop()
+		}
+		c.JSON(500, gin.H{"error": "Failed to list namespaces: " + err.Error()})
+		return
+	}
+	if events != nil {
+		serveWatch(c, namespaces, events, stop)
+		return
+	}
+	c.JSON(200, namespaces)
+}
+
+// deleteNamespaceHandlerGin 只把命名空间标记为 Terminating，namespace controller 会删除里面的对象再完成删除
+func (s *APIServer) deleteNamespaceHandlerGin(c *gin.Context) {
+	name := c.Param("namespace")
+	if slices.Contains(systemNamespaces, name) {
+		c.JSON(403, gin.H{"error": fmt.Sprintf("Namespace %s cannot be deleted", name)})
+		return
+	}
+	//命名空间里的对象总是由 namespace controller 删除，propagationPolicy 只检查是否合法
+	if _, ok := deletionPropagation(c); !ok {
+		return
+	}
+	if err := s.store.DeleteNamespace(name); err != nil {
+		log.Printf("Error deleting namespace %s: %v", name, err)
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(404, gin.H{"error": "Failed to delete namespace: " + err.Error()})
+		} else if strings.Contains(err.Error(), "terminating") {
+			c.JSON(409, gin.H{"error": "Failed to delete namespace: " + err.Error()})
+		} else {
+			c.JSON(500, gin.H{"error": "Failed to delete namespace: " + err.Error()})
+		}
+		return
+	}
+	log.Printf("Namespace %s marked for deletion", name)
+	c.JSON(200, gin.H{"message": fmt.Sprintf("Namespace %s is terminating", name)})
+}
+
+// finalizeNamespaceHandlerGin 只允许修改 spec.finalizers；Terminating 的命名空间没有 finalizer 后就会被删除
+func (s *APIServer) finalizeNamespaceHandlerGin(c *gin.Context) {
+	name := c.Param("namespace")
+	var body api.Namespace
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	if body.Name != "" && body.Name != name {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("Namespace name in body (%s) does not match name in URL (%s)", body.Name, name)})
+		return
+	}
+	existing, err := s.store.GetNamespace(name)
+	if err != nil {
+		c.JSON(404, gin.H{"error": "Namespace not found: " + err.Error()})
+		return
+	}
+	ns := *existing
+	ns.Spec.Finalizers = body.Spec.Finalizers
+	if err := apply.Update(existing, &ns, fieldManager(c)); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to record managed fields: " + err.Error()})
+		return
+	}
+	if err := s.store.UpdateNamespace(&ns); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to finalize namespace: " + err.Error()})
+		return
+	}
+	if ns.DeletionTimestamp != nil && len(ns.Spec.Finalizers) == 0 {
+		log.Printf("Namespace %s finalized and removed", name)
+	}
+	c.JSON(200, ns)
+}
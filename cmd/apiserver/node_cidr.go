@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"mini-k8s/pkg/allocator"
+	"mini-k8s/pkg/api"
+)
+
+const (
+	// DefaultClusterCIDR 是 pod 地址的默认网段，每个节点从中分到一个子网
+	DefaultClusterCIDR = "10.244.0.0/16"
+	// DefaultNodeCIDRMaskSize 是每个节点子网的默认前缀长度
+	DefaultNodeCIDRMaskSize = 24
+)
+
+// initNodeCIDRAllocator 创建节点子网的分配器，分配位图保存在存储里。clusterCIDR 为空时不给节点分配子网
+func (s *APIServer) initNodeCIDRAllocator(clusterCIDR string, maskSize int) error {
+	if clusterCIDR == "" {
+		return nil
+	}
+	subnets, err := allocator.ParseSubnetRange(clusterCIDR, maskSize)
+	if err != nil {
+		return fmt.Errorf("invalid cluster CIDR: %w", err)
+	}
+	s.nodeCIDRs = allocator.New("nodecidrs", subnets, s.store)
+	return nil
+}
+
+// assignPodCIDR 给还没有子网的节点分配一个。old 是更新前的节点，已经分配的子网不能修改。
+// 返回这次新分配的子网，写入存储失败时要用 releasePodCIDR 还回去
+func (s *APIServer) assignPodCIDR(old, node *api.Node) (string, error) {
+	if old != nil && old.PodCIDR != "" {
+		node.PodCIDR = old.PodCIDR
+		return "", nil
+	}
+	if s.nodeCIDRs == nil {
+		return "", nil
+	}
+	if node.PodCIDR == "" {
+		cidr, err := s.nodeCIDRs.AllocateNext()
+		if err != nil {
+			return "", fmt.Errorf("failed to allocate a pod CIDR: %w", err)
+		}
+		node.PodCIDR = cidr
+	} else if err := s.nodeCIDRs.Allocate(node.PodCIDR); err != nil {
+		return "", fmt.Errorf("podCIDR: %w", err)
+	}
+	return node.PodCIDR, nil
+}
+
+func (s *APIServer) releasePodCIDR(cidr string) {
+	if cidr == "" || s.nodeCIDRs == nil {
+		return
+	}
+	if err := s.nodeCIDRs.Release(cidr); err != nil {
+		log.Printf("Failed to release pod CIDR %s: %v", cidr, err)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"mini-k8s/pkg/api"
+	"path"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerPersistentVolumes 注册 PersistentVolume。它们是集群级别的，binder 负责把它们绑定给 PVC
+func (s *APIServer) registerPersistentVolumes(router *gin.Engine) {
+	registerResource(router, s, "persistentvolumes", &resource[api.PersistentVolume, *api.PersistentVolume]{
+		kind:       "PersistentVolume",
+		namespaced: false,
+		create:     s.store.CreatePersistentVolume,
+		get:        func(_, name string) (*api.PersistentVolume, error) { return s.store.GetPersistentVolume(name) },
+		update:     s.store.UpdatePersistentVolume,
+		delete:     func(_, name string) error { return s.store.DeletePersistentVolume(name) },
+		list:       func(string) ([]*api.PersistentVolume, error) { return s.store.ListPersistentVolumes() },
+		watch: func(string) (<-chan api.WatchEvent[api.PersistentVolume], func()) {
+			return s.store.WatchPersistentVolumes()
+		},
+		setDefaults: setPersistentVolumeDefaults,
+		validate:    validatePersistentVolume,
+		prepareForCreate: func(pv *api.PersistentVolume) {
+			pv.Status = api.PersistentVolumeStatus{Phase: api.VolumeAvailable}
+		},
+		prepareForUpdate: func(old, pv *api.PersistentVolume) {
+			//卷在哪里、能被哪些节点使用在创建之后不能修改
+			pv.Spec.Local = old.Spec.Local
+			pv.Spec.NodeAffinity = old.Spec.NodeAffinity
+		},
+		spec: func(pv *api.PersistentVolume) interface{} {
+			return pv.Spec
+		},
+		copyStatus: func(from, to *api.PersistentVolume) {
+			to.Status = from.Status
+		},
+	})
+}
+
+// registerPersistentVolumeClaims 注册 PersistentVolumeClaim。新建的 PVC 带着 pvc-protection finalizer，
+// 还有 pod 在使用时删除它只会给它加上 deletionTimestamp
+func (s *APIServer) registerPersistentVolumeClaims(router *gin.Engine) {
+	registerResource(router, s, "persistentvolumeclaims", &resource[api.PersistentVolumeClaim, *api.PersistentVolumeClaim]{
+		kind:       "PersistentVolumeClaim",
+		namespaced: true,
+		create:     s.store.CreatePersistentVolumeClaim,
+		get:        s.store.GetPersistentVolumeClaim,
+		update:     s.store.UpdatePersistentVolumeClaim,
+		delete:     s.store.DeletePersistentVolumeClaim,
+		list:       s.store.ListPersistentVolumeClaims,
+		watch:      s.store.WatchPersistentVolumeClaims,
+		validate:   validatePersistentVolumeClaim,
+		prepareForCreate: func(pvc *api.PersistentVolumeClaim) {
+			pvc.Status = api.PersistentVolumeClaimStatus{Phase: api.ClaimPending}
+			pvc.AddFinalizer(api.FinalizerPVCProtection)
+			//没有设置 storageClassName 的 PVC 使用默认的 StorageClass，显式设置为空字符串表示不使用任何 class
+			if pvc.Spec.StorageClassName == nil {
+				if class := s.defaultStorageClass(); class != nil {
+					pvc.Spec.StorageClassName = &class.Name
+				}
+			}
+		},
+		prepareForUpdate: func(old, pvc *api.PersistentVolumeClaim) {
+			//PVC 的要求在创建之后不能修改，volumeName 只能由空设置为绑定的卷
+			pvc.Spec.AccessModes = old.Spec.AccessModes
+			pvc.Spec.Resources = old.Spec.Resources
+			pvc.Spec.StorageClassName = old.Spec.StorageClassName
+			if old.Spec.VolumeName != "" {
+				pvc.Spec.VolumeName = old.Spec.VolumeName
+			}
+		},
+		spec: func(pvc *api.PersistentVolumeClaim) interface{} {
+			return pvc.Spec
+		},
+		copyStatus: func(from, to *api.PersistentVolumeClaim) {
+			to.Status = from.Status
+		},
+	})
+}
+
+func setPersistentVolumeDefaults(pv *api.PersistentVolume) {
+	if pv.Spec.PersistentVolumeReclaimPolicy == "" {
+		pv.Spec.PersistentVolumeReclaimPolicy = api.PersistentVolumeReclaimRetain
+	}
+}
+
+func validatePersistentVolume(pv *api.PersistentVolume) error {
+	if _, ok := pv.Spec.Capacity[api.ResourceStorage]; !ok {
+		return fmt.Errorf("spec.capacity.storage must be set")
+	}
+	if _, err := pv.Spec.Capacity.Storage(); err != nil {
+		return fmt.Errorf("spec.capacity.storage: %w", err)
+	}
+	if err := validateAccessModes("spec.accessModes", pv.Spec.AccessModes); err != nil {
+		return err
+	}
+	if err := validateReclaimPolicy("spec.persistentVolumeReclaimPolicy", pv.Spec.PersistentVolumeReclaimPolicy); err != nil {
+		return err
+	}
+	if pv.Spec.StorageClassName != "" && !dnsLabelPattern.MatchString(pv.Spec.StorageClassName) {
+		return fmt.Errorf("spec.storageClassName %q must be a lowercase DNS label", pv.Spec.StorageClassName)
+	}
+	if pv.Spec.Local == nil {
+		return fmt.Errorf("spec.local must be set")
+	}
+	if !path.IsAbs(pv.Spec.Local.Path) {
+		return fmt.Errorf("spec.local.path %q must be an absolute path", pv.Spec.Local.Path)
+	}
+	//local 卷只存在于一个节点上，必须说明是哪个节点
+	if pv.Spec.NodeAffinity == nil || len(pv.Spec.NodeAffinity.Required) == 0 {
+		return fmt.Errorf("spec.nodeAffinity.required must be set for local volumes")
+	}
+	if ref := pv.Spec.ClaimRef; ref != nil && (ref.Namespace == "" || ref.Name == "") {
+		return fmt.Errorf("spec.claimRef must set namespace and name")
+	}
+	return nil
+}
+
+func validatePersistentVolumeClaim(pvc *api.PersistentVolumeClaim) error {
+	if err := validateAccessModes("spec.accessModes", pvc.Spec.AccessModes); err != nil {
+		return err
+	}
+	if _, ok := pvc.Spec.Resources.Requests[api.ResourceStorage]; !ok {
+		return fmt.Errorf("spec.resources.requests.storage must be set")
+	}
+	if _, err := pvc.Spec.Resources.Requests.Storage(); err != nil {
+		return fmt.Errorf("spec.resources.requests.storage: %w", err)
+	}
+	if class := pvc.ClassName(); class != "" && !dnsLabelPattern.MatchString(class) {
+		return fmt.Errorf("spec.storageClassName %q must be a lowercase DNS label", class)
+	}
+	return nil
+}
+
+func validateReclaimPolicy(field string, policy api.PersistentVolumeReclaimPolicy) error {
+	switch policy {
+	case api.PersistentVolumeReclaimRetain, api.PersistentVolumeReclaimDelete:
+		return nil
+	}
+	return fmt.Errorf("%s must be Retain or Delete, got %q", field, policy)
+}
+
+func validateAccessModes(field string, modes []api.PersistentVolumeAccessMode) error {
+	if len(modes) == 0 {
+		return fmt.Errorf("%s must not be empty", field)
+	}
+	for _, mode := range modes {
+		switch mode {
+		case api.ReadWriteOnce, api.ReadOnlyMany, api.ReadWriteMany:
+		default:
+			return fmt.Errorf("%s must contain only ReadWriteOnce, ReadOnlyMany or ReadWriteMany, got %q", field, mode)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"mini-k8s/pkg/api"
+	"path"
+	"strings"
+)
+
+// validatePodConfig 检查 pod 的资源请求、环境变量、卷和挂载。field 是 spec 在对象里的位置，pod 的 spec 是扁平的，传空字符串
+func validatePodConfig(field string, spec *api.PodSpec) error {
+	for name, value := range spec.Resources.Requests {
+		var err error
+		switch name {
+		case api.ResourceCPU:
+			_, err = api.ParseMilliQuantity(value)
+		case api.ResourceMemory:
+			_, err = api.ParseQuantity(value)
+		default:
+			return fmt.Errorf("%sresources.requests may only contain cpu and memory, got %q", field, name)
+		}
+		if err != nil {
+			return fmt.Errorf("%sresources.requests.%s: %w", field, name, err)
+		}
+	}
+	for i, env := range spec.Env {
+		envField := fmt.Sprintf("%senv[%d]", field, i)
+		if env.Name == "" || strings.ContainsAny(env.Name, "=\x00") {
+			return fmt.Errorf("%s.name must be a non-empty name without '='", envField)
+		}
+		if env.ValueFrom == nil {
+			continue
+		}
+		if env.Value != "" {
+			return fmt.Errorf("%s may not set both value and valueFrom", envField)
+		}
+		cmRef, secretRef := env.ValueFrom.ConfigMapKeyRef, env.ValueFrom.SecretKeyRef
+		switch {
+		case (cmRef == nil) == (secretRef == nil):
+			return fmt.Errorf("%s.valueFrom must set exactly one of configMapKeyRef and secretKeyRef", envField)
+		case cmRef != nil:
+			if cmRef.Name == "" {
+				return fmt.Errorf("%s.valueFrom.configMapKeyRef.name must be set", envField)
+			}
+			if err := validateConfigKey(envField+".valueFrom.configMapKeyRef", cmRef.Key); err != nil {
+				return err
+			}
+		default:
+			if secretRef.Name == "" {
+				return fmt.Errorf("%s.valueFrom.secretKeyRef.name must be set", envField)
+			}
+			if err := validateConfigKey(envField+".valueFrom.secretKeyRef", secretRef.Key); err != nil {
+				return err
+			}
+		}
+	}
+	names := map[string]bool{}
+	for i, volume := range spec.Volumes {
+		volumeField := fmt.Sprintf("%svolumes[%d]", field, i)
+		if !dnsLabelPattern.MatchString(volume.Name) {
+			return fmt.Errorf("%s.name %q must be a lowercase DNS label", volumeField, volume.Name)
+		}
+		if names[volume.Name] {
+			return fmt.Errorf("%s.name %q is used by more than one volume", volumeField, volume.Name)
+		}
+		names[volume.Name] = true
+		if n := countVolumeSources(&volume.VolumeSource); n != 1 {
+			return fmt.Errorf("%s must set exactly one volume source, got %d", volumeField, n)
+		}
+		var items []api.KeyToPath
+		switch {
+		case volume.ConfigMap != nil:
+			if volume.ConfigMap.Name == "" {
+				return fmt.Errorf("%s.configMap.name must be set", volumeField)
+			}
+			items = volume.ConfigMap.Items
+		case volume.Secret != nil:
+			if volume.Secret.SecretName == "" {
+				return fmt.Errorf("%s.secret.secretName must be set", volumeField)
+			}
+			items = volume.Secret.Items
+		case volume.EmptyDir != nil:
+			if limit := volume.EmptyDir.SizeLimit; limit != "" {
+				if _, err := api.ParseQuantity(limit); err != nil {
+					return fmt.Errorf("%s.emptyDir.sizeLimit: %w", volumeField, err)
+				}
+			}
+		case volume.HostPath != nil:
+			if !path.IsAbs(volume.HostPath.Path) {
+				return fmt.Errorf("%s.hostPath.path %q must be an absolute path", volumeField, volume.HostPath.Path)
+			}
+			switch volume.HostPath.Type {
+			case "", api.HostPathDirectoryOrCreate, api.HostPathDirectory, api.HostPathFileOrCreate, api.HostPathFile:
+			default:
+				return fmt.Errorf("%s.hostPath.type must be DirectoryOrCreate, Directory, FileOrCreate or File, got %q", volumeField, volume.HostPath.Type)
+			}
+		case volume.PersistentVolumeClaim != nil:
+			if volume.PersistentVolumeClaim.ClaimName == "" {
+				return fmt.Errorf("%s.persistentVolumeClaim.claimName must be set", volumeField)
+			}
+		}
+		paths := map[string]bool{}
+		for j, item := range items {
+			itemField := fmt.Sprintf("%s.items[%d]", volumeField, j)
+			if err := validateConfigKey(itemField, item.Key); err != nil {
+				return err
+			}
+			if err := validateProjectedPath(itemField+".path", item.Path); err != nil {
+				return err
+			}
+			if paths[item.Path] {
+				return fmt.Errorf("%s.path %q is used by more than one item", itemField, item.Path)
+			}
+			paths[item.Path] = true
+		}
+	}
+	mountPaths := map[string]bool{}
+	for i, mount := range spec.VolumeMounts {
+		mountField := fmt.Sprintf("%svolumeMounts[%d]", field, i)
+		if !names[mount.Name] {
+			return fmt.Errorf("%s.name %q does not match any volume", mountField, mount.Name)
+		}
+		if !path.IsAbs(mount.MountPath) || path.Clean(mount.MountPath) != mount.MountPath || mount.MountPath == "/" {
+			return fmt.Errorf("%s.mountPath %q must be a clean absolute path other than /", mountField, mount.MountPath)
+		}
+		//挂载点是 $POD_ROOT 下的符号链接，一个挂载点不能在另一个挂载点里面
+		for other := range mountPaths {
+			if other == mount.MountPath || strings.HasPrefix(mount.MountPath, other+"/") || strings.HasPrefix(other, mount.MountPath+"/") {
+				return fmt.Errorf("%s.mountPath %q overlaps with mount path %q", mountField, mount.MountPath, other)
+			}
+		}
+		mountPaths[mount.MountPath] = true
+		if mount.SubPath != "" {
+			if err := validateProjectedPath(mountField+".subPath", mount.SubPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func countVolumeSources(source *api.VolumeSource) int {
+	n := 0
+	for _, set := range []bool{source.ConfigMap != nil, source.Secret != nil, source.EmptyDir != nil, source.HostPath != nil, source.PersistentVolumeClaim != nil} {
+		if set {
+			n++
+		}
+	}
+	return n
+}
+
+// validateProjectedPath 检查投射的文件路径在卷的目录里面，并且不会和 kubelet 用来原子更新的 ..data 等文件冲突
+func validateProjectedPath(field, p string) error {
+	if p == "" || path.IsAbs(p) || path.Clean(p) != p {
+		return fmt.Errorf("%s %q must be a clean relative path", field, p)
+	}
+	for _, segment := range strings.Split(p, "/") {
+		if strings.HasPrefix(segment, "..") {
+			return fmt.Errorf("%s %q must not contain '..' or elements starting with '..'", field, p)
+		}
+	}
+	return nil
+}
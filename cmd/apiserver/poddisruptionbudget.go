@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"mini-k8s/pkg/api"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *APIServer) registerPodDisruptionBudgets(router *gin.Engine) {
+	registerResource(router, s, "poddisruptionbudgets", &resource[api.PodDisruptionBudget, *api.PodDisruptionBudget]{
+		kind:       "PodDisruptionBudget",
+		namespaced: true,
+		create:     s.store.CreatePodDisruptionBudget,
+		get:        s.store.GetPodDisruptionBudget,
+		update:     s.store.UpdatePodDisruptionBudget,
+		delete:     s.store.DeletePodDisruptionBudget,
+		list:       s.store.ListPodDisruptionBudgets,
+		watch:      s.store.WatchPodDisruptionBudgets,
+		validate:   validatePodDisruptionBudget,
+		prepareForCreate: func(pdb *api.PodDisruptionBudget) {
+			pdb.Status = api.PodDisruptionBudgetStatus{}
+		},
+		spec: func(pdb *api.PodDisruptionBudget) interface{} {
+			return pdb.Spec
+		},
+		copyStatus: func(from, to *api.PodDisruptionBudget) {
+			to.Status = from.Status
+		},
+	})
+}
+
+func validatePodDisruptionBudget(pdb *api.PodDisruptionBudget) error {
+	if (pdb.Spec.MinAvailable == nil) == (pdb.Spec.MaxUnavailable == nil) {
+		return fmt.Errorf("exactly one of spec.minAvailable and spec.maxUnavailable must be set")
+	}
+	if pdb.Spec.Selector == nil || len(pdb.Spec.Selector.MatchLabels) == 0 {
+		return fmt.Errorf("spec.selector.matchLabels must not be empty")
+	}
+	field, value := "spec.minAvailable", pdb.Spec.MinAvailable
+	if value == nil {
+		field, value = "spec.maxUnavailable", pdb.Spec.MaxUnavailable
+	}
+	//用 100 换算百分比只是为了检查格式和范围
+	scaled, err := value.ScaledValue(100, true)
+	if err != nil {
+		return fmt.Errorf("%s: %w", field, err)
+	}
+	if scaled < 0 {
+		return fmt.Errorf("%s must not be negative", field)
+	}
+	if value.IsString && scaled > 100 {
+		return fmt.Errorf("%s must not be more than 100%%", field)
+	}
+	return nil
+}
+
+// evictionRetries 是 PDB 的 status 被 disruption controller 同时更新时，重新读取 PDB 再试的次数
+const evictionRetries = 3
+
+// evictPodHandlerGin 处理 POST pods/<name>/eviction。和直接删除不同，它会先检查选中这个 pod 的 PDB，
+// 驱逐会让健康的 pod 少于 PDB 的要求时返回 429，调用方应该等一会再试。
+// 批准的驱逐会先扣掉 PDB 的 disruptionsAllowed 再删除 pod，evictionMu 让并发的驱逐不会用到同一个名额
+func (s *APIServer) evictPodHandlerGin(c *gin.Context) {
+	namespace := c.Param("namespace")
+	podName := c.Param("podname")
+	var eviction api.Eviction
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&eviction); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid request body: " + err.Error()})
+			return
+		}
+	}
+	if eviction.Name != "" && eviction.Name != podName {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("Eviction name in body (%s) does not match pod name in URL (%s)", eviction.Name, podName)})
+		return
+	}
+	if eviction.Namespace != "" && eviction.Namespace != namespace {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("Eviction namespace in body (%s) does not match namespace in URL (%s)", eviction.Namespace, namespace)})
+		return
+	}
+
+	s.evictionMu.Lock()
+	defer s.evictionMu.Unlock()
+	var status int
+	var err error
+	for i := 0; i < evictionRetries; i++ {
+		status, err = s.evictPod(namespace, podName)
+		if err == nil || !strings.Contains(err.Error(), "has been modified") {
+			break
+		}
+	}
+	if err != nil {
+		log.Printf("Eviction of pod %s/%s refused: %v", namespace, podName, err)
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("Evicted pod %s/%s", namespace, podName)
+	c.JSON(201, gin.H{"message": fmt.Sprintf("Pod %s/%s evicted", namespace, podName)})
+}
+
+// evictPod 检查 PDB 并删除 pod，返回错误时同时返回 HTTP 状态码
+func (s *APIServer) evictPod(namespace, podName string) (int, error) {
+	pod, err := s.store.GetPod(namespace, podName)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return 404, err
+		}
+		return 500, err
+	}
+	//已经在删除的 pod 不会再占用 PDB 的名额
+	if pod.DeletionTimestamp != nil {
+		return 0, nil
+	}
+	//不健康的 pod 本来就不算在 PDB 的健康 pod 里，驱逐它不会减少可用的副本，直接删除。
+	//节点失联时 node lifecycle controller 驱逐的就是这种 pod
+	var pdb *api.PodDisruptionBudget
+	if s.isPodHealthy(pod) {
+		pdb, err = s.podDisruptionBudgetFor(pod)
+		if err != nil {
+			return 500, err
+		}
+		if pdb != nil {
+			if status, err := s.consumeDisruption(pdb, pod); err != nil {
+				return status, err
+			}
+		}
+	}
+	if err := s.store.DeletePod(namespace, podName); err != nil {
+		//pod 已经不在了或者已经开始删除时不用还回名额：controller 下一次同步会把它从 disruptedPods 里去掉，
+		//并且不再把它算成健康的。其它错误说明 pod 还在运行，扣掉的名额要还给 PDB
+		if strings.Contains(err.Error(), "not found") {
+			return 404, err
+		}
+		if strings.Contains(err.Error(), "is terminating") {
+			return 0, nil
+		}
+		if pdb != nil {
+			s.releaseDisruption(pdb, pod)
+		}
+		return 500, err
+	}
+	return 0, nil
+}
+
+// isPodHealthy 和 disruption controller 计算 currentHealthy 时的判断一致：pod 在运行，并且所在的节点 Ready
+func (s *APIServer) isPodHealthy(pod *api.Pod) bool {
+	if pod.Phase != api.PodRunning || pod.NodeName == "" {
+		return false
+	}
+	node, err := s.store.GetNode(pod.NodeName)
+	return err == nil && node.Status == api.NodeReady
+}
+
+// podDisruptionBudgetFor 返回选中 pod 的 PDB，没有时返回 nil。一个 pod 被多个 PDB 选中时无法判断该扣哪个的名额，
+// 和 Kubernetes 一样拒绝驱逐
+func (s *APIServer) podDisruptionBudgetFor(pod *api.Pod) (*api.PodDisruptionBudget, error) {
+	pdbs, err := s.store.ListPodDisruptionBudgets(pod.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	var matched []*api.PodDisruptionBudget
+	for _, pdb := range pdbs {
+		if pdb.Spec.Selector.Matches(pod.Labels) {
+			matched = append(matched, pdb)
+		}
+	}
+	if len(matched) > 1 {
+		return nil, fmt.Errorf("this pod has more than one PodDisruptionBudget, which the eviction subresource does not support")
+	}
+	if len(matched) == 0 {
+		return nil, nil
+	}
+	return matched[0], nil
+}
+
+// consumeDisruption 从 PDB 里扣掉一个驱逐名额，并把 pod 记进 disruptedPods，
+// 这样 controller 在看到 pod 被删除之前不会把它重新算成健康的。currentHealthy 也跟着减一，
+// 和 controller 下一次算出来的一致，被拒绝的驱逐报告的数量才是准确的
+func (s *APIServer) consumeDisruption(pdb *api.PodDisruptionBudget, pod *api.Pod) (int, error) {
+	if pdb.Status.ObservedGeneration < pdb.Generation {
+		return 429, fmt.Errorf("Cannot evict pod as it would violate the pod's disruption budget. The disruption budget %s is still being processed by the server", pdb.Name)
+	}
+	if pdb.Status.DisruptionsAllowed <= 0 {
+		return 429, fmt.Errorf("Cannot evict pod as it would violate the pod's disruption budget. The disruption budget %s needs %d healthy pods and has %d currently",
+			pdb.Name, pdb.Status.DesiredHealthy, pdb.Status.CurrentHealthy)
+	}
+	pdb.Status.DisruptionsAllowed--
+	pdb.Status.CurrentHealthy--
+	if pdb.Status.DisruptedPods == nil {
+		pdb.Status.DisruptedPods = map[string]time.Time{}
+	}
+	pdb.Status.DisruptedPods[pod.Name] = time.Now()
+	if err := s.store.UpdatePodDisruptionBudget(pdb); err != nil {
+		if strings.Contains(err.Error(), "has been modified") {
+			return 409, err
+		}
+		return 500, err
+	}
+	return 0, nil
+}
+
+// releaseDisruption 撤销 consumeDisruption，在 pod 没有删除成功时调用。和 controller 的更新冲突时重新读取 PDB 再试；
+// disruptedPods 里已经没有这个 pod 说明 controller 已经重新计算过了。还是失败时这条记录在 deletionTimeout 之后过期，
+// 名额由 controller 算回来
+func (s *APIServer) releaseDisruption(pdb *api.PodDisruptionBudget, pod *api.Pod) {
+	for i := 0; i < evictionRetries; i++ {
+		current, err := s.store.GetPodDisruptionBudget(pdb.Namespace, pdb.Name)
+		if err != nil {
+			log.Printf("Error restoring disruption budget %s/%s after failing to evict pod %s: %v", pdb.Namespace, pdb.Name, pod.Name, err)
+			return
+		}
+		if _, ok := current.Status.DisruptedPods[pod.Name]; !ok {
+			return
+		}
+		delete(current.Status.DisruptedPods, pod.Name)
+		if len(current.Status.DisruptedPods) == 0 {
+			current.Status.DisruptedPods = nil
+		}
+		current.Status.DisruptionsAllowed++
+		current.Status.CurrentHealthy++
+		err = s.store.UpdatePodDisruptionBudget(current)
+		if err == nil {
+			return
+		}
+		if !strings.Contains(err.Error(), "has been modified") {
+			log.Printf("Error restoring disruption budget %s/%s after failing to evict pod %s: %v", pdb.Namespace, pdb.Name, pod.Name, err)
+			return
+		}
+	}
+}
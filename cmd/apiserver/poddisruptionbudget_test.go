@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/store"
+	"testing"
+)
+
+// deleteFailingStore 让 DeletePod 返回 deleteErr，用来模拟驱逐批准之后删除 pod 失败
+type deleteFailingStore struct {
+	*store.InMemoryStore
+	deleteErr error
+}
+
+func (s *deleteFailingStore) DeletePod(namespace, name string) error {
+	if s.deleteErr != nil {
+		return s.deleteErr
+	}
+	return s.InMemoryStore.DeletePod(namespace, name)
+}
+
+// newEvictionServer 创建一个有两个健康 pod 和一个允许一次驱逐的 PDB 的 API server
+func newEvictionServer(t *testing.T, deleteErr error) *APIServer {
+	t.Helper()
+	st := &deleteFailingStore{InMemoryStore: store.NewInMemoryStore(), deleteErr: deleteErr}
+	if err := st.CreateNode(&api.Node{ObjectMeta: api.ObjectMeta{Name: "node-1"}, Status: api.NodeReady}); err != nil {
+		t.Fatalf("CreateNode: %v", err)
+	}
+	for _, name := range []string{"web-0", "web-1"} {
+		pod := &api.Pod{
+			ObjectMeta: api.ObjectMeta{Namespace: "default", Name: name, Labels: map[string]string{"app": "web"}},
+			PodSpec:    api.PodSpec{Image: "sleep"},
+			NodeName:   "node-1",
+			Phase:      api.PodRunning,
+		}
+		if err := st.CreatePod(pod); err != nil {
+			t.Fatalf("CreatePod: %v", err)
+		}
+	}
+	minAvailable := api.FromInt(1)
+	pdb := &api.PodDisruptionBudget{
+		ObjectMeta: api.ObjectMeta{Namespace: "default", Name: "web", Generation: 1},
+		Spec: api.PodDisruptionBudgetSpec{
+			MinAvailable: &minAvailable,
+			Selector:     &api.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+		Status: api.PodDisruptionBudgetStatus{ObservedGeneration: 1, DisruptionsAllowed: 1, CurrentHealthy: 2, DesiredHealthy: 1, ExpectedPods: 2},
+	}
+	if err := st.CreatePodDisruptionBudget(pdb); err != nil {
+		t.Fatalf("CreatePodDisruptionBudget: %v", err)
+	}
+	return NewAPIServer(st)
+}
+
+func TestEvictPodDisruptionBudget(t *testing.T) {
+	tests := []struct {
+		name          string
+		deleteErr     error
+		wantStatus    int
+		wantAllowed   int
+		wantHealthy   int
+		wantDisrupted bool
+	}{
+		{name: "delete succeeds", wantStatus: 0, wantAllowed: 0, wantHealthy: 1, wantDisrupted: true},
+		{name: "store error restores the budget", deleteErr: fmt.Errorf("store unavailable"), wantStatus: 500, wantAllowed: 1, wantHealthy: 2},
+		//pod 已经不在了，名额留给 controller 按 disruptedPods 重新计算
+		{name: "pod already gone", deleteErr: fmt.Errorf("pod default/web-0 not found"), wantStatus: 404, wantAllowed: 0, wantHealthy: 1, wantDisrupted: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newEvictionServer(t, tt.deleteErr)
+			status, err := s.evictPod("default", "web-0")
+			if status != tt.wantStatus || (err == nil) != (tt.wantStatus == 0) {
+				t.Fatalf("evictPod() = %d, %v, want status %d", status, err, tt.wantStatus)
+			}
+			pdb, err := s.store.GetPodDisruptionBudget("default", "web")
+			if err != nil {
+				t.Fatalf("GetPodDisruptionBudget: %v", err)
+			}
+			if pdb.Status.DisruptionsAllowed != tt.wantAllowed || pdb.Status.CurrentHealthy != tt.wantHealthy {
+				t.Errorf("disruptionsAllowed = %d, currentHealthy = %d, want %d and %d",
+					pdb.Status.DisruptionsAllowed, pdb.Status.CurrentHealthy, tt.wantAllowed, tt.wantHealthy)
+			}
+			if _, ok := pdb.Status.DisruptedPods["web-0"]; ok != tt.wantDisrupted {
+				t.Errorf("disruptedPods = %v, want web-0 recorded: %v", pdb.Status.DisruptedPods, tt.wantDisrupted)
+			}
+		})
+	}
+}
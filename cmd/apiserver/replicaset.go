@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"mini-k8s/pkg/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *APIServer) registerReplicaSets(router *gin.Engine) {
+	registerResource(router, s, "replicasets", &resource[api.ReplicaSet, *api.ReplicaSet]{
+		kind:       "ReplicaSet",
+		namespaced: true,
+		create:     s.store.CreateReplicaSet,
+		get:        s.store.GetReplicaSet,
+		update:     s.store.UpdateReplicaSet,
+		delete:     s.store.DeleteReplicaSet,
+		list:       s.store.ListReplicaSets,
+		watch:      s.store.WatchReplicaSets,
+		validate:   validateReplicaSet,
+		prepareForCreate: func(rs *api.ReplicaSet) {
+			rs.Status = api.ReplicaSetStatus{}
+		},
+		spec: func(rs *api.ReplicaSet) interface{} { return rs.Spec },
+		copyStatus: func(from, to *api.ReplicaSet) {
+			to.Status = from.Status
+		},
+	})
+}
+
+func validateReplicaSet(rs *api.ReplicaSet) error {
+	if rs.Spec.Replicas < 0 {
+		return fmt.Errorf("spec.replicas must not be negative")
+	}
+	if err := validatePodTemplate(rs.Spec.Selector, &rs.Spec.Template); err != nil {
+		return err
+	}
+	return validateAlwaysRestart(&rs.Spec.Template)
+}
+
+// validatePodTemplate 检查选择器非空，并且模板里的 labels 能被选择器选中，否则 controller 会不停地创建 pod
+func validatePodTemplate(selector *api.LabelSelector, template *api.PodTemplateSpec) error {
+	if selector == nil || len(selector.MatchLabels) == 0 {
+		return fmt.Errorf("spec.selector.matchLabels must not be empty")
+	}
+	if !selector.Matches(template.Metadata.Labels) {
+		return fmt.Errorf("spec.template.metadata.labels must match spec.selector")
+	}
+	if template.Spec.Image == "" {
+		return fmt.Errorf("spec.template.spec.image must be provided")
+	}
+	if err := validateRestartPolicy(template.Spec.RestartPolicy); err != nil {
+		return err
+	}
+	return validatePodConfig("spec.template.spec.", &template.Spec)
+}
+
+func validateRestartPolicy(policy api.RestartPolicy) error {
+	switch policy {
+	case "", api.RestartPolicyAlways, api.RestartPolicyOnFailure, api.RestartPolicyNever:
+		return nil
+	}
+	return fmt.Errorf("restartPolicy must be Always, OnFailure or Never, got %q", policy)
+}
+
+// validateAlwaysRestart 检查长期运行的工作负载的模板没有设置会让 pod 结束的重启策略
+func validateAlwaysRestart(template *api.PodTemplateSpec) error {
+	if p := template.Spec.RestartPolicy; p != "" && p != api.RestartPolicyAlways {
+		return fmt.Errorf("spec.template.spec.restartPolicy must be Always, got %q", p)
+	}
+	return nil
+}
@@ -0,0 +1,492 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math/big"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/apply"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// storeObject 约束了可以通过通用 handler 提供服务的资源类型
+type storeObject[T any] interface {
+	*T
+	api.Object
+}
+
+// resource 描述一种通过通用 REST handler 提供服务的资源。Pod、Node、Namespace 的语义比较特殊，仍然使用各自的 handler
+type resource[T any, PT storeObject[T]] struct {
+	kind       string
+	namespaced bool
+
+	create func(PT) error
+	get    func(namespace, name string) (PT, error)
+	update func(PT) error
+	delete func(namespace, name string) error
+	list   func(namespace string) ([]PT, error)
+	watch  func(namespace string) (<-chan api.WatchEvent[T], func())
+
+	// validate 在创建、更新和 apply 之前调用，返回错误时请求以 400 拒绝
+	validate func(obj PT) error
+	// prepareForCreate 在创建前设置默认值
+	prepareForCreate func(obj PT)
+	// prepareForUpdate 在更新前调用，用来保留服务端维护的字段
+	prepareForUpdate func(old, obj PT)
+	// setDefaults 在校验之前给没有设置的字段填上默认值
+	setDefaults func(obj PT)
+	// spec 返回对象的 spec，设置之后 spec 每变化一次 metadata.generation 就加一
+	spec func(obj PT) interface{}
+	// copyStatus 把 from 的 status 复制到 to。设置之后提供 PUT /:name/status 子资源，
+	// 普通的 PUT 和 apply 会忽略 status 的修改，避免 controller 更新 status 时覆盖用户对 spec 的修改
+	copyStatus func(from, to PT)
+}
+
+// registerResource 注册一种资源的 REST 路由。命名空间级别的资源挂在 /api/v1/namespaces/:namespace/<plural>
+// 下，同时提供 /api/v1/<plural> 用于列出和监听所有命名空间；集群级别的资源只挂在 /api/v1/<plural>
+func registerResource[T any, PT storeObject[T]](router *gin.Engine, s *APIServer, plural string, r *resource[T, PT]) {
+	h := &resourceHandler[T, PT]{server: s, resource: r}
+	if r.namespaced {
+		group := router.Group("/api/v1/namespaces/:namespace/" + plural)
+		group.POST("", h.create)
+		group.GET("", h.list)
+		group.GET("/:name", h.get)
+		group.PUT("/:name", h.update)
+		group.PATCH("/:name", h.patch)
+		group.DELETE("/:name", h.delete)
+		if r.copyStatus != nil {
+			group.PUT("/:name/status", h.updateStatus)
+		}
+		router.GET("/api/v1/"+plural, h.list)
+		return
+	}
+	group := router.Group("/api/v1/" + plural)
+	group.POST("", h.create)
+	group.GET("", h.list)
+	group.GET("/:name", h.get)
+	group.PUT("/:name", h.update)
+	group.PATCH("/:name", h.patch)
+	group.DELETE("/:name", h.delete)
+	if r.copyStatus != nil {
+		group.PUT("/:name/status", h.updateStatus)
+	}
+}
+
+type resourceHandler[T any, PT storeObject[T]] struct {
+	server   *APIServer
+	resource *resource[T, PT]
+}
+
+func (h *resourceHandler[T, PT]) describe(namespace, name string) string {
+	if h.resource.namespaced {
+		return fmt.Sprintf("%s %s/%s", h.resource.kind, namespace, name)
+	}
+	return fmt.Sprintf("%s %s", h.resource.kind, name)
+}
+
+// validate 先填充默认值再校验，create、update 和 apply 写入存储前都会调用。创建时 existing 为 nil
+func (h *resourceHandler[T, PT]) validate(existing, obj PT) error {
+	var old *api.ObjectMeta
+	if existing != nil {
+		old = existing.GetObjectMeta()
+	}
+	if err := validateFinalizers(old, obj.GetObjectMeta()); err != nil {
+		return err
+	}
+	if h.resource.setDefaults != nil {
+		h.resource.setDefaults(obj)
+	}
+	if h.resource.validate == nil {
+		return nil
+	}
+	return h.resource.validate(obj)
+}
+
+// prepareForUpdate 在更新和 apply 之前保留 status、维护 generation，并调用资源自己的 prepareForUpdate
+func (h *resourceHandler[T, PT]) prepareForUpdate(existing, obj PT) {
+	obj.GetObjectMeta().DeletionTimestamp = existing.GetObjectMeta().DeletionTimestamp
+	if h.resource.copyStatus != nil {
+		h.resource.copyStatus(existing, obj)
+	}
+	if h.resource.spec != nil && !reflect.DeepEqual(h.resource.spec(existing), h.resource.spec(obj)) {
+		obj.GetObjectMeta().Generation = existing.GetObjectMeta().Generation + 1
+	}
+	if h.resource.prepareForUpdate != nil {
+		h.resource.prepareForUpdate(existing, obj)
+	}
+}
+
+func (h *resourceHandler[T, PT]) create(c *gin.Context) {
+	namespace := c.Param("namespace")
+	obj := PT(new(T))
+	if err := c.ShouldBindJSON(obj); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	meta := obj.GetObjectMeta()
+	if h.resource.namespaced {
+		if meta.Namespace != "" && meta.Namespace != namespace {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("Namespace in body (%s) does not match namespace in URL (%s)", meta.Namespace, namespace)})
+			return
+		}
+		meta.Namespace = namespace
+		if !h.server.checkNamespaceAcceptsObjects(c, namespace) {
+			return
+		}
+	} else {
+		meta.Namespace = ""
+	}
+	if meta.Name == "" && meta.GenerateName == "" {
+		c.JSON(400, gin.H{"error": h.resource.kind + " name or generateName must be provided"})
+		return
+	}
+	initObjectMeta(meta)
+	if h.resource.prepareForCreate != nil {
+		h.resource.prepareForCreate(obj)
+	}
+	if err := h.validate(nil, obj); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid " + h.resource.kind + ": " + err.Error()})
+		return
+	}
+	if err := apply.Update(nil, obj, fieldManager(c)); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to record managed fields: " + err.Error()})
+		return
+	}
+	if err := h.resource.create(obj); err != nil {
+		log.Printf("Error creating %s: %v", h.describe(meta.Namespace, meta.Name), err)
+		c.JSON(createErrorStatus(err), gin.H{"error": "Failed to create " + h.resource.kind + ": " + err.Error()})
+		return
+	}
+	log.Printf("created %s", h.describe(meta.Namespace, meta.Name))
+	c.JSON(201, obj)
+}
+
+func (h *resourceHandler[T, PT]) get(c *gin.Context) {
+	obj, err := h.resource.get(c.Param("namespace"), c.Param("name"))
+	if err != nil {
+		if status := getErrorStatus(err); status != 404 {
+			c.JSON(status, gin.H{"error": "Failed to get " + h.describe(c.Param("namespace"), c.Param("name")) + ": " + err.Error()})
+			return
+		}
+		c.JSON(404, gin.H{"error": h.resource.kind + " not found: " + err.Error()})
+		return
+	}
+	c.JSON(200, obj)
+}
+
+func (h *resourceHandler[T, PT]) list(c *gin.Context) {
+	namespace := c.Param("namespace")
+	var events <-chan api.WatchEvent[T]
+	var stop func()
+	if isWatch(c) {
+		events, stop = h.resource.watch(namespace)
+	}
+	objs, err := h.resource.list(namespace)
+	if err != nil {
+		if stop != nil {
+			stop()
+		}
+		c.JSON(500, gin.H{"error": "Failed to list " + h.resource.kind + ": " + err.Error()})
+		return
+	}
+	if events != nil {
+		existing := make([]*T, 0, len(objs))
+		for _, obj := range objs {
+			existing = append(existing, (*T)(obj))
+		}
+		serveWatch(c, existing, events, stop)
+		return
+	}
+	c.JSON(200, objs)
+}
+
+func (h *resourceHandler[T, PT]) update(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+	obj := PT(new(T))
+	if err := c.ShouldBindJSON(obj); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	meta := obj.GetObjectMeta()
+	if meta.Name != name {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("Name in body (%s) does not match name in URL (%s)", meta.Name, name)})
+		return
+	}
+	if h.resource.namespaced && meta.Namespace != namespace {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("Namespace in body (%s) does not match namespace in URL (%s)", meta.Namespace, namespace)})
+		return
+	}
+	existing, err := h.resource.get(namespace, name)
+	if err != nil {
+		if status := getErrorStatus(err); status != 404 {
+			c.JSON(status, gin.H{"error": fmt.Sprintf("Failed to get %s for update: %s", h.describe(namespace, name), err.Error())})
+			return
+		}
+		c.JSON(404, gin.H{"error": fmt.Sprintf("%s not found for update: %s", h.describe(namespace, name), err.Error())})
+		return
+	}
+	preserveObjectMeta(existing.GetObjectMeta(), meta)
+	h.prepareForUpdate(existing, obj)
+	if err := h.validate(existing, obj); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid " + h.resource.kind + ": " + err.Error()})
+		return
+	}
+	if err := apply.Update(existing, obj, fieldManager(c)); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to record managed fields: " + err.Error()})
+		return
+	}
+	if err := h.updateOrFinalize(obj); err != nil {
+		log.Printf("Failed to update %s in store: %v", h.describe(namespace, name), err)
+		c.JSON(storeErrorStatus(err), gin.H{"error": "Failed to update " + h.resource.kind + ": " + err.Error()})
+		return
+	}
+	c.JSON(200, obj)
+}
+
+// patch 处理 server-side apply，对象不存在时直接创建
+func (h *resourceHandler[T, PT]) patch(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+	patch, ok := readApplyPatch(c, name, namespace)
+	if !ok {
+		return
+	}
+	force := c.Query("force") == "true"
+
+	var live api.Object
+	existing, err := h.resource.get(namespace, name)
+	if err == nil {
+		live = existing
+	} else if status := getErrorStatus(err); status != 404 {
+		c.JSON(status, gin.H{"error": "Failed to get " + h.describe(namespace, name) + ": " + err.Error()})
+		return
+	}
+	obj := PT(new(T))
+	if err := apply.Apply(live, patch, c.Query("fieldManager"), force, obj); err != nil {
+		c.JSON(applyErrorStatus(err), gin.H{"error": "Failed to apply " + h.resource.kind + ": " + err.Error()})
+		return
+	}
+	meta := obj.GetObjectMeta()
+
+	if existing == nil {
+		meta.Name = name
+		meta.Namespace = namespace
+		if h.resource.namespaced && !h.server.checkNamespaceAcceptsObjects(c, namespace) {
+			return
+		}
+		initAppliedObjectMeta(meta)
+		if h.resource.prepareForCreate != nil {
+			h.resource.prepareForCreate(obj)
+		}
+		if err := h.validate(nil, obj); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid " + h.resource.kind + ": " + err.Error()})
+			return
+		}
+		if err := h.resource.create(obj); err != nil {
+			c.JSON(createErrorStatus(err), gin.H{"error": "Failed to create " + h.resource.kind + ": " + err.Error()})
+			return
+		}
+		log.Printf("created %s through apply", h.describe(namespace, name))
+		c.JSON(201, obj)
+		return
+	}
+	h.prepareForUpdate(existing, obj)
+	if err := h.validate(existing, obj); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid " + h.resource.kind + ": " + err.Error()})
+		return
+	}
+	if err := h.updateOrFinalize(obj); err != nil {
+		c.JSON(storeErrorStatus(err), gin.H{"error": "Failed to apply " + h.resource.kind + ": " + err.Error()})
+		return
+	}
+	c.JSON(200, obj)
+}
+
+// updateStatus 处理 PUT /:name/status，只修改 status，spec 和 metadata 保持存储里的值
+func (h *resourceHandler[T, PT]) updateStatus(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+	body := PT(new(T))
+	if err := c.ShouldBindJSON(body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	if body.GetObjectMeta().Name != name {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("Name in body (%s) does not match name in URL (%s)", body.GetObjectMeta().Name, name)})
+		return
+	}
+	existing, err := h.resource.get(namespace, name)
+	if err != nil {
+		c.JSON(404, gin.H{"error": fmt.Sprintf("%s not found for status update: %s", h.describe(namespace, name), err.Error())})
+		return
+	}
+	obj := PT(new(T))
+	*obj = *existing
+	h.resource.copyStatus(body, obj)
+	//带上请求里的 resourceVersion，两个 controller 同时写 status 时后写的一方会冲突，而不是覆盖前一个的结果
+	obj.GetObjectMeta().ResourceVersion = body.GetObjectMeta().ResourceVersion
+	if err := apply.Update(existing, obj, fieldManager(c)); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to record managed fields: " + err.Error()})
+		return
+	}
+	if err := h.resource.update(obj); err != nil {
+		log.Printf("Failed to update status of %s in store: %v", h.describe(namespace, name), err)
+		c.JSON(storeErrorStatus(err), gin.H{"error": "Failed to update " + h.resource.kind + " status: " + err.Error()})
+		return
+	}
+	c.JSON(200, obj)
+}
+
+// delete 处理 DELETE 请求。Orphan 和 Foreground 给对象加上对应的 finalizer，
+// 对象带着 deletionTimestamp 留在存储里，等垃圾回收器处理完 dependents 并移除 finalizer 后才真正删除
+func (h *resourceHandler[T, PT]) delete(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+	policy, ok := deletionPropagation(c)
+	if !ok {
+		return
+	}
+	existing, err := h.resource.get(namespace, name)
+	if err != nil {
+		c.JSON(getErrorStatus(err), gin.H{"error": "Failed to delete " + h.resource.kind + ": " + err.Error()})
+		return
+	}
+	meta := existing.GetObjectMeta()
+	switch policy {
+	case api.DeletePropagationOrphan:
+		meta.AddFinalizer(api.FinalizerOrphanDependents)
+	case api.DeletePropagationForeground:
+		meta.AddFinalizer(api.FinalizerDeleteDependents)
+	}
+	if len(meta.Finalizers) > 0 {
+		if meta.DeletionTimestamp == nil {
+			now := time.Now()
+			meta.DeletionTimestamp = &now
+		}
+		if err := h.resource.update(existing); err != nil {
+			c.JSON(500, gin.H{"error": "Failed to delete " + h.resource.kind + ": " + err.Error()})
+			return
+		}
+		log.Printf("Marked %s for deletion, waiting for finalizers %v", h.describe(namespace, name), meta.Finalizers)
+		c.JSON(200, gin.H{"message": fmt.Sprintf("%s marked for deletion", h.describe(namespace, name))})
+		return
+	}
+	if err := h.resource.delete(namespace, name); err != nil {
+		log.Printf("Error deleting %s: %v", h.describe(namespace, name), err)
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(getErrorStatus(err), gin.H{"error": "Failed to delete " + h.resource.kind + ": " + err.Error()})
+		} else {
+			c.JSON(500, gin.H{"error": "Failed to delete " + h.resource.kind + ": " + err.Error()})
+		}
+		return
+	}
+	log.Printf("Deleted %s", h.describe(namespace, name))
+	c.JSON(200, gin.H{"message": fmt.Sprintf("%s deleted", h.describe(namespace, name))})
+}
+
+// updateOrFinalize 写入更新。对象已经在删除中并且最后一个 finalizer 被移除时，改为从存储里删除它
+func (h *resourceHandler[T, PT]) updateOrFinalize(obj PT) error {
+	meta := obj.GetObjectMeta()
+	if meta.DeletionTimestamp == nil || len(meta.Finalizers) > 0 {
+		return h.resource.update(obj)
+	}
+	if err := h.resource.delete(meta.Namespace, meta.Name); err != nil {
+		return err
+	}
+	log.Printf("Deleted %s after its finalizers were removed", h.describe(meta.Namespace, meta.Name))
+	return nil
+}
+
+// deletionPropagation 读取 DELETE 请求的 propagationPolicy 参数，取值不合法时返回 400
+func deletionPropagation(c *gin.Context) (api.DeletionPropagation, bool) {
+	policy, err := api.ParseDeletionPropagation(c.Query("propagationPolicy"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return "", false
+	}
+	return policy, true
+}
+
+// initObjectMeta 设置创建对象时由服务端维护的元数据：生成名字、UID 和创建时间
+func initObjectMeta(meta *api.ObjectMeta) {
+	if meta.Name == "" && meta.GenerateName != "" {
+		meta.Name = meta.GenerateName + randomSuffix(5)
+	}
+	meta.UID = newUID()
+	meta.Generation = 1
+	now := time.Now()
+	meta.CreationTimestamp = &now
+	meta.DeletionTimestamp = nil
+	meta.ManagedFields = nil
+}
+
+// initAppliedObjectMeta 初始化 apply 创建的对象的元数据，保留 apply.Apply 记录的 managedFields，
+// 这样第一个 applier 拥有它设置的字段，别的 manager 修改这些字段时会冲突
+func initAppliedObjectMeta(meta *api.ObjectMeta) {
+	managed := meta.ManagedFields
+	initObjectMeta(meta)
+	meta.ManagedFields = managed
+}
+
+// finalizerPattern 是 finalizer 名字的格式，可以带一个域名前缀，比如 example.com/cleanup
+var finalizerPattern = regexp.MustCompile(`^([a-z0-9]([-a-z0-9.]*[a-z0-9])?/)?[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+
+// validateFinalizers 检查 finalizer 的格式和重复。old 是更新前的元数据，对象已经在删除中时只能移除 finalizer，不能添加新的
+func validateFinalizers(old, updated *api.ObjectMeta) error {
+	seen := map[string]bool{}
+	for _, f := range updated.Finalizers {
+		if !finalizerPattern.MatchString(f) {
+			return fmt.Errorf("finalizer %q must be a name with an optional domain prefix, such as example.com/cleanup", f)
+		}
+		if seen[f] {
+			return fmt.Errorf("finalizer %q is listed more than once", f)
+		}
+		seen[f] = true
+		if old != nil && old.DeletionTimestamp != nil && !old.HasFinalizer(f) {
+			return fmt.Errorf("finalizer %q cannot be added while the object is being deleted", f)
+		}
+	}
+	return nil
+}
+
+// preserveObjectMeta 在更新时保留客户端不能修改的元数据
+func preserveObjectMeta(old, updated *api.ObjectMeta) {
+	updated.UID = old.UID
+	updated.CreationTimestamp = old.CreationTimestamp
+	updated.GenerateName = old.GenerateName
+	updated.Generation = old.Generation
+	updated.DeletionTimestamp = old.DeletionTimestamp
+}
+
+func newUID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatalf("Failed to generate UID: %v", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+const suffixAlphabet = "bcdfghjklmnpqrstvwxz2456789"
+
+func randomSuffix(n int) string {
+	out := make([]byte, n)
+	for i := range out {
+		idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(suffixAlphabet))))
+		if err != nil {
+			log.Fatalf("Failed to generate name suffix: %v", err)
+		}
+		out[i] = suffixAlphabet[idx.Int64()]
+	}
+	return string(out)
+}
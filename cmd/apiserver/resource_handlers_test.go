@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// TestApplyCreateRecordsOwnership 检查 apply 创建对象时记录了第一个 applier 拥有的字段，
+// 另一个 manager 不带 force 修改这些字段会返回 409，带 force 时可以接管
+func TestApplyCreateRecordsOwnership(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		first       string
+		conflicting string
+	}{
+		{
+			name:        "pod",
+			path:        "/api/v1/namespaces/default/pods/web",
+			first:       `{"name":"web","image":"sleep"}`,
+			conflicting: `{"name":"web","image":"nginx"}`,
+		},
+		{
+			name:        "configmap",
+			path:        "/api/v1/namespaces/default/configmaps/settings",
+			first:       `{"name":"settings","data":{"a":"1"}}`,
+			conflicting: `{"name":"settings","data":{"a":"2"}}`,
+		},
+		{
+			name:        "node",
+			path:        "/api/v1/nodes/node-1",
+			first:       `{"name":"node-1","labels":{"zone":"a"}}`,
+			conflicting: `{"name":"node-1","labels":{"zone":"b"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(t)
+			if w := do(router, http.MethodPatch, tt.path+"?fieldManager=alice", tt.first); w.Code != 201 {
+				t.Fatalf("alice's apply = %d %s, want 201", w.Code, w.Body)
+			}
+			if w := do(router, http.MethodPatch, tt.path+"?fieldManager=bob", tt.conflicting); w.Code != 409 {
+				t.Errorf("bob's conflicting apply = %d %s, want 409", w.Code, w.Body)
+			}
+			if w := do(router, http.MethodPatch, tt.path+"?fieldManager=alice", tt.first); w.Code != 200 {
+				t.Errorf("alice's second apply = %d %s, want 200", w.Code, w.Body)
+			}
+			if w := do(router, http.MethodPatch, tt.path+"?fieldManager=bob&force=true", tt.conflicting); w.Code != 200 {
+				t.Errorf("bob's forced apply = %d %s, want 200", w.Code, w.Body)
+			}
+		})
+	}
+}
+
+// TestUpdateStatusChecksResourceVersion 检查 /status 的写入和普通更新一样检查 resourceVersion
+func TestUpdateStatusChecksResourceVersion(t *testing.T) {
+	router := newTestRouter(t)
+	const path = "/api/v1/namespaces/default/poddisruptionbudgets"
+	w := do(router, http.MethodPost, path, `{"name":"web","spec":{"minAvailable":1,"selector":{"matchLabels":{"app":"web"}}}}`)
+	if w.Code != 201 {
+		t.Fatalf("POST = %d %s", w.Code, w.Body)
+	}
+	var created struct {
+		ResourceVersion string `json:"resourceVersion"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decoding the created object: %v", err)
+	}
+	status := func(resourceVersion string, allowed int) string {
+		return fmt.Sprintf(`{"name":"web","resourceVersion":%q,"status":{"disruptionsAllowed":%d}}`, resourceVersion, allowed)
+	}
+	if w := do(router, http.MethodPut, path+"/web/status", status(created.ResourceVersion, 1)); w.Code != 200 {
+		t.Fatalf("status update with the current resourceVersion = %d %s, want 200", w.Code, w.Body)
+	}
+	//第二个写入方还拿着旧的版本
+	if w := do(router, http.MethodPut, path+"/web/status", status(created.ResourceVersion, 5)); w.Code != 409 {
+		t.Errorf("status update with a stale resourceVersion = %d %s, want 409", w.Code, w.Body)
+	}
+	if w := do(router, http.MethodPut, path+"/web/status", status("", 2)); w.Code != 200 {
+		t.Errorf("status update without a resourceVersion = %d %s, want 200", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"mini-k8s/pkg/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerSecrets 注册 Secret。写入时把 stringData 合并进 data，读出来的对象只有 data
+func (s *APIServer) registerSecrets(router *gin.Engine) {
+	registerResource(router, s, "secrets", &resource[api.Secret, *api.Secret]{
+		kind:        "Secret",
+		namespaced:  true,
+		create:      s.store.CreateSecret,
+		get:         s.store.GetSecret,
+		update:      s.store.UpdateSecret,
+		delete:      s.store.DeleteSecret,
+		list:        s.store.ListSecrets,
+		watch:       s.store.WatchSecrets,
+		validate:    validateSecret,
+		setDefaults: setSecretDefaults,
+	})
+}
+
+func setSecretDefaults(secret *api.Secret) {
+	if secret.Type == "" {
+		secret.Type = api.SecretTypeOpaque
+	}
+	if len(secret.StringData) > 0 && secret.Data == nil {
+		secret.Data = make(map[string][]byte, len(secret.StringData))
+	}
+	for k, v := range secret.StringData {
+		secret.Data[k] = []byte(v)
+	}
+	secret.StringData = nil
+}
+
+func validateSecret(secret *api.Secret) error {
+	size := 0
+	for key, value := range secret.Data {
+		if err := validateConfigKey("data", key); err != nil {
+			return err
+		}
+		size += len(key) + len(value)
+	}
+	if size > api.MaxConfigDataSize {
+		return fmt.Errorf("data must not be larger than %d bytes in total, got %d", api.MaxConfigDataSize, size)
+	}
+	switch secret.Type {
+	case api.SecretTypeOpaque:
+	case api.SecretTypeTLS:
+		//证书和私钥不匹配时 ingress controller 无法加载，在写入时就拒绝
+		if _, err := tls.X509KeyPair(secret.Data[api.TLSCertKey], secret.Data[api.TLSPrivateKeyKey]); err != nil {
+			return fmt.Errorf("secrets of type %s must contain a valid %s and %s: %v", api.SecretTypeTLS, api.TLSCertKey, api.TLSPrivateKeyKey, err)
+		}
+	default:
+		return fmt.Errorf("type must be %s or %s, got %q", api.SecretTypeOpaque, api.SecretTypeTLS, secret.Type)
+	}
+	return nil
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"mini-k8s/pkg/allocator"
+	"mini-k8s/pkg/api"
+	"net"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultServiceClusterIPRange 是 ClusterIP 的默认分配范围
+	DefaultServiceClusterIPRange = "10.96.0.0/16"
+	// DefaultServiceNodePortRange 是 nodePort 的默认分配范围
+	DefaultServiceNodePortRange = "30000-32767"
+)
+
+// initServiceAllocators 创建 ClusterIP 和 nodePort 的分配器，分配位图保存在存储里
+func (s *APIServer) initServiceAllocators(clusterIPRange, nodePortRange string) error {
+	ips, err := allocator.ParseCIDRRange(clusterIPRange)
+	if err != nil {
+		return fmt.Errorf("invalid service cluster IP range: %w", err)
+	}
+	ports, err := allocator.ParsePortRange(nodePortRange)
+	if err != nil {
+		return fmt.Errorf("invalid service node port range: %w", err)
+	}
+	s.serviceIPRange = ips
+	s.nodePortRange = ports
+	s.serviceIPs = allocator.New("serviceips", ips, s.store)
+	s.nodePorts = allocator.New("servicenodeports", ports, s.store)
+	return nil
+}
+
+// registerServices 注册 Service。create、update 和 delete 包了一层，在写入存储的同时分配和释放 ClusterIP 与 nodePort
+func (s *APIServer) registerServices(router *gin.Engine) {
+	registerResource(router, s, "services", &resource[api.Service, *api.Service]{
+		kind:        "Service",
+		namespaced:  true,
+		create:      s.createService,
+		get:         s.store.GetService,
+		update:      s.updateService,
+		delete:      s.deleteService,
+		list:        s.store.ListServices,
+		watch:       s.store.WatchServices,
+		setDefaults: setServiceDefaults,
+		validate:    s.validateService,
+		prepareForUpdate: func(old, svc *api.Service) {
+			//clusterIP 创建之后不能修改
+			svc.Spec.ClusterIP = old.Spec.ClusterIP
+			if svc.Spec.Type == api.ServiceTypeNodePort {
+				preserveNodePorts(old, svc)
+			}
+		},
+		spec: func(svc *api.Service) interface{} {
+			return svc.Spec
+		},
+	})
+}
+
+// registerEndpoints 注册 Endpoints。它们通常由 endpoints controller 维护，没有 selector 的 service 可以手动创建
+func (s *APIServer) registerEndpoints(router *gin.Engine) {
+	registerResource(router, s, "endpoints", &resource[api.Endpoints, *api.Endpoints]{
+		kind:       "Endpoints",
+		namespaced: true,
+		create:     s.store.CreateEndpoints,
+		get:        s.store.GetEndpoints,
+		update:     s.store.UpdateEndpoints,
+		delete:     s.store.DeleteEndpoints,
+		list:       s.store.ListEndpoints,
+		watch:      s.store.WatchEndpoints,
+		validate:   validateEndpoints,
+	})
+}
+
+// preserveNodePorts 让更新时没有写 nodePort 的端口沿用之前分配的值，避免每次更新都换一个 nodePort
+func preserveNodePorts(old, svc *api.Service) {
+	for i := range svc.Spec.Ports {
+		port := &svc.Spec.Ports[i]
+		if port.NodePort != 0 {
+			continue
+		}
+		for _, oldPort := range old.Spec.Ports {
+			if oldPort.NodePort != 0 && oldPort.Port == port.Port && protocolOrDefault(oldPort.Protocol) == protocolOrDefault(port.Protocol) {
+				port.NodePort = oldPort.NodePort
+				break
+			}
+		}
+	}
+}
+
+func protocolOrDefault(p api.Protocol) api.Protocol {
+	if p == "" {
+		return api.ProtocolTCP
+	}
+	return p
+}
+
+func setServiceDefaults(svc *api.Service) {
+	if svc.Spec.Type == "" {
+		svc.Spec.Type = api.ServiceTypeClusterIP
+	}
+	if svc.Spec.SessionAffinity == "" {
+		svc.Spec.SessionAffinity = api.ServiceAffinityNone
+	}
+	if svc.Spec.SessionAffinity == api.ServiceAffinityClientIP {
+		if svc.Spec.SessionAffinityConfig == nil {
+			svc.Spec.SessionAffinityConfig = &api.SessionAffinityConfig{}
+		}
+		if svc.Spec.SessionAffinityConfig.ClientIP == nil {
+			svc.Spec.SessionAffinityConfig.ClientIP = &api.ClientIPConfig{}
+		}
+		if svc.Spec.SessionAffinityConfig.ClientIP.TimeoutSeconds == nil {
+			timeout := api.DefaultClientIPServiceAffinitySeconds
+			svc.Spec.SessionAffinityConfig.ClientIP.TimeoutSeconds = &timeout
+		}
+	}
+	for i := range svc.Spec.Ports {
+		port := &svc.Spec.Ports[i]
+		port.Protocol = protocolOrDefault(port.Protocol)
+		if port.TargetPort == 0 {
+			port.TargetPort = port.Port
+		}
+	}
+}
+
+func (s *APIServer) validateService(svc *api.Service) error {
+	switch svc.Spec.Type {
+	case api.ServiceTypeClusterIP, api.ServiceTypeNodePort:
+	default:
+		return fmt.Errorf("spec.type must be ClusterIP or NodePort, got %q", svc.Spec.Type)
+	}
+	switch ip := svc.Spec.ClusterIP; ip {
+	case "":
+	case api.ClusterIPNone:
+		if svc.Spec.Type != api.ServiceTypeClusterIP {
+			return fmt.Errorf("spec.clusterIP None is only allowed for services of type ClusterIP")
+		}
+	default:
+		if !s.serviceIPRange.Contains(ip) {
+			return fmt.Errorf("spec.clusterIP %s must be an address in the service IP range %s", ip, s.serviceIPRange)
+		}
+	}
+	switch svc.Spec.SessionAffinity {
+	case api.ServiceAffinityNone:
+		if svc.Spec.SessionAffinityConfig != nil {
+			return fmt.Errorf("spec.sessionAffinityConfig may only be set when spec.sessionAffinity is ClientIP")
+		}
+	case api.ServiceAffinityClientIP:
+		timeout := *svc.Spec.SessionAffinityConfig.ClientIP.TimeoutSeconds
+		if timeout <= 0 || timeout > 86400 {
+			return fmt.Errorf("spec.sessionAffinityConfig.clientIP.timeoutSeconds must be between 1 and 86400")
+		}
+	default:
+		return fmt.Errorf("spec.sessionAffinity must be None or ClientIP, got %q", svc.Spec.SessionAffinity)
+	}
+	if len(svc.Spec.Ports) == 0 && !svc.IsHeadless() {
+		return fmt.Errorf("spec.ports must not be empty unless spec.clusterIP is None")
+	}
+	names := map[string]bool{}
+	ports := map[string]bool{}
+	nodePorts := map[string]bool{}
+	for i, port := range svc.Spec.Ports {
+		field := fmt.Sprintf("spec.ports[%d]", i)
+		if len(svc.Spec.Ports) > 1 && port.Name == "" {
+			return fmt.Errorf("%s.name must be set when the service has more than one port", field)
+		}
+		if port.Name != "" {
+			if names[port.Name] {
+				return fmt.Errorf("%s.name %q is used by more than one port", field, port.Name)
+			}
+			names[port.Name] = true
+		}
+		if port.Protocol != api.ProtocolTCP && port.Protocol != api.ProtocolUDP {
+			return fmt.Errorf("%s.protocol must be TCP or UDP, got %q", field, port.Protocol)
+		}
+		if port.Port < 1 || port.Port > 65535 {
+			return fmt.Errorf("%s.port must be between 1 and 65535", field)
+		}
+		if port.TargetPort < 1 || port.TargetPort > 65535 {
+			return fmt.Errorf("%s.targetPort must be between 1 and 65535", field)
+		}
+		key := strconv.Itoa(port.Port) + "/" + string(port.Protocol)
+		if ports[key] {
+			return fmt.Errorf("%s: port %s is listed more than once", field, key)
+		}
+		ports[key] = true
+		if port.NodePort == 0 {
+			continue
+		}
+		if svc.Spec.Type != api.ServiceTypeNodePort {
+			return fmt.Errorf("%s.nodePort may only be set for services of type NodePort", field)
+		}
+		if !s.nodePortRange.Contains(port.NodePort) {
+			return fmt.Errorf("%s.nodePort %d must be in the node port range %s", field, port.NodePort, s.nodePortRange)
+		}
+		nodeKey := strconv.Itoa(port.NodePort) + "/" + string(port.Protocol)
+		if nodePorts[nodeKey] {
+			return fmt.Errorf("%s: nodePort %s is listed more than once", field, nodeKey)
+		}
+		nodePorts[nodeKey] = true
+	}
+	return nil
+}
+
+func validateEndpoints(ep *api.Endpoints) error {
+	for i, subset := range ep.Subsets {
+		for _, addresses := range [][]api.EndpointAddress{subset.Addresses, subset.NotReadyAddresses} {
+			for _, addr := range addresses {
+				if net.ParseIP(addr.IP) == nil {
+					return fmt.Errorf("subsets[%d]: %q is not a valid IP address", i, addr.IP)
+				}
+			}
+		}
+		for _, port := range subset.Ports {
+			if port.Port < 1 || port.Port > 65535 {
+				return fmt.Errorf("subsets[%d]: port %d must be between 1 and 65535", i, port.Port)
+			}
+			if port.Protocol != "" && port.Protocol != api.ProtocolTCP && port.Protocol != api.ProtocolUDP {
+				return fmt.Errorf("subsets[%d]: protocol must be TCP or UDP, got %q", i, port.Protocol)
+			}
+		}
+	}
+	return nil
+}
+
+// serviceAllocations 是一次写入里新分配的 ClusterIP 和 nodePort，写入失败时要还回去
+type serviceAllocations struct {
+	clusterIP string
+	nodePorts []int
+}
+
+func (s *APIServer) release(a serviceAllocations) {
+	if a.clusterIP != "" {
+		if err := s.serviceIPs.Release(a.clusterIP); err != nil {
+			log.Printf("Failed to release cluster IP %s: %v", a.clusterIP, err)
+		}
+	}
+	for _, port := range a.nodePorts {
+		if err := s.nodePorts.Release(strconv.Itoa(port)); err != nil {
+			log.Printf("Failed to release node port %d: %v", port, err)
+		}
+	}
+}
+
+// allocate 给 svc 分配还没有的 ClusterIP 和 nodePort。old 是更新前的 service，它已经占用的 nodePort 不重复分配
+func (s *APIServer) allocate(svc, old *api.Service) (serviceAllocations, error) {
+	var allocated serviceAllocations
+	if old == nil && !svc.IsHeadless() {
+		if svc.Spec.ClusterIP == "" {
+			ip, err := s.serviceIPs.AllocateNext()
+			if err != nil {
+				return allocated, fmt.Errorf("failed to allocate a cluster IP: %w", err)
+			}
+			svc.Spec.ClusterIP = ip
+		} else if err := s.serviceIPs.Allocate(svc.Spec.ClusterIP); err != nil {
+			return allocated, fmt.Errorf("spec.clusterIP: %w", err)
+		}
+		allocated.clusterIP = svc.Spec.ClusterIP
+	}
+	if svc.Spec.Type != api.ServiceTypeNodePort {
+		return allocated, nil
+	}
+	held := nodePortsOf(old)
+	for i := range svc.Spec.Ports {
+		port := &svc.Spec.Ports[i]
+		if port.NodePort != 0 && held[port.NodePort] {
+			continue
+		}
+		if port.NodePort == 0 {
+			value, err := s.nodePorts.AllocateNext()
+			if err != nil {
+				s.release(allocated)
+				return serviceAllocations{}, fmt.Errorf("failed to allocate a node port: %w", err)
+			}
+			port.NodePort, _ = strconv.Atoi(value)
+		} else if err := s.nodePorts.Allocate(strconv.Itoa(port.NodePort)); err != nil {
+			s.release(allocated)
+			return serviceAllocations{}, fmt.Errorf("spec.ports[%d].nodePort: %w", i, err)
+		}
+		allocated.nodePorts = append(allocated.nodePorts, port.NodePort)
+	}
+	return allocated, nil
+}
+
+// nodePortsOf 返回 service 占用的 nodePort，svc 为 nil 时返回空集合
+func nodePortsOf(svc *api.Service) map[int]bool {
+	ports := map[int]bool{}
+	if svc == nil || svc.Spec.Type != api.ServiceTypeNodePort {
+		return ports
+	}
+	for _, port := range svc.Spec.Ports {
+		if port.NodePort != 0 {
+			ports[port.NodePort] = true
+		}
+	}
+	return ports
+}
+
+func (s *APIServer) createService(svc *api.Service) error {
+	allocated, err := s.allocate(svc, nil)
+	if err != nil {
+		return err
+	}
+	if err := s.store.CreateService(svc); err != nil {
+		s.release(allocated)
+		return err
+	}
+	return nil
+}
+
+// updateService 写入更新，成功后释放不再使用的 nodePort
+func (s *APIServer) updateService(svc *api.Service) error {
+	old, err := s.store.GetService(svc.Namespace, svc.Name)
+	if err != nil {
+		return err
+	}
+	allocated, err := s.allocate(svc, old)
+	if err != nil {
+		return err
+	}
+	if err := s.store.UpdateService(svc); err != nil {
+		s.release(allocated)
+		return err
+	}
+	current := nodePortsOf(svc)
+	var unused serviceAllocations
+	for port := range nodePortsOf(old) {
+		if !current[port] {
+			unused.nodePorts = append(unused.nodePorts, port)
+		}
+	}
+	s.release(unused)
+	return nil
+}
+
+// deleteService 从存储里删除 service，然后释放它的 ClusterIP 和 nodePort
+func (s *APIServer) deleteService(namespace, name string) error {
+	svc, err := s.store.GetService(namespace, name)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteService(namespace, name); err != nil {
+		return err
+	}
+	held := serviceAllocations{}
+	if !svc.IsHeadless() {
+		held.clusterIP = svc.Spec.ClusterIP
+	}
+	for port := range nodePortsOf(svc) {
+		held.nodePorts = append(held.nodePorts, port)
+	}
+	s.release(held)
+	return nil
+}
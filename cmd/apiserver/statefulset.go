@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"mini-k8s/pkg/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *APIServer) registerStatefulSets(router *gin.Engine) {
+	registerResource(router, s, "statefulsets", &resource[api.StatefulSet, *api.StatefulSet]{
+		kind:        "StatefulSet",
+		namespaced:  true,
+		create:      s.store.CreateStatefulSet,
+		get:         s.store.GetStatefulSet,
+		update:      s.store.UpdateStatefulSet,
+		delete:      s.store.DeleteStatefulSet,
+		list:        s.store.ListStatefulSets,
+		watch:       s.store.WatchStatefulSets,
+		setDefaults: setStatefulSetDefaults,
+		validate:    validateStatefulSet,
+		prepareForCreate: func(ss *api.StatefulSet) {
+			ss.Status = api.StatefulSetStatus{}
+		},
+		prepareForUpdate: func(old, ss *api.StatefulSet) {
+			//已经创建的 PVC 不会跟着模板变化，和 ControllerRevision 的 data 一样保留原来的值
+			ss.Spec.VolumeClaimTemplates = old.Spec.VolumeClaimTemplates
+		},
+		spec: func(ss *api.StatefulSet) interface{} {
+			copied := ss.DeepCopy()
+			setStatefulSetDefaults(copied)
+			return copied.Spec
+		},
+		copyStatus: func(from, to *api.StatefulSet) {
+			to.Status = from.Status
+		},
+	})
+}
+
+// registerControllerRevisions 注册 ControllerRevision。它由 controller 创建，创建之后 data 不能修改
+func (s *APIServer) registerControllerRevisions(router *gin.Engine) {
+	registerResource(router, s, "controllerrevisions", &resource[api.ControllerRevision, *api.ControllerRevision]{
+		kind:       "ControllerRevision",
+		namespaced: true,
+		create:     s.store.CreateControllerRevision,
+		get:        s.store.GetControllerRevision,
+		update:     s.store.UpdateControllerRevision,
+		delete:     s.store.DeleteControllerRevision,
+		list:       s.store.ListControllerRevisions,
+		watch:      s.store.WatchControllerRevisions,
+		validate: func(cr *api.ControllerRevision) error {
+			if len(cr.Data) == 0 {
+				return fmt.Errorf("data must be provided")
+			}
+			if cr.Revision < 0 {
+				return fmt.Errorf("revision must not be negative")
+			}
+			return nil
+		},
+		prepareForUpdate: func(old, cr *api.ControllerRevision) {
+			//revision 可以修改，StatefulSet 回滚到旧模板时会把旧的 ControllerRevision 的 revision 调成最新
+			cr.Data = old.Data
+		},
+	})
+}
+
+func setStatefulSetDefaults(ss *api.StatefulSet) {
+	if ss.Spec.PodManagementPolicy == "" {
+		ss.Spec.PodManagementPolicy = api.OrderedReadyPodManagement
+	}
+	if ss.Spec.UpdateStrategy.Type == "" {
+		ss.Spec.UpdateStrategy.Type = api.RollingUpdateStatefulSetStrategyType
+	}
+	if ss.Spec.UpdateStrategy.Type == api.RollingUpdateStatefulSetStrategyType {
+		if ss.Spec.UpdateStrategy.RollingUpdate == nil {
+			ss.Spec.UpdateStrategy.RollingUpdate = &api.RollingUpdateStatefulSetStrategy{}
+		}
+		if ss.Spec.UpdateStrategy.RollingUpdate.Partition == nil {
+			partition := 0
+			ss.Spec.UpdateStrategy.RollingUpdate.Partition = &partition
+		}
+	}
+	if ss.Spec.RevisionHistoryLimit == nil {
+		limit := api.DefaultRevisionHistoryLimit
+		ss.Spec.RevisionHistoryLimit = &limit
+	}
+}
+
+func validateStatefulSet(ss *api.StatefulSet) error {
+	if ss.Spec.Replicas < 0 {
+		return fmt.Errorf("spec.replicas must not be negative")
+	}
+	if err := validateVolumeClaimTemplates(ss.Spec.VolumeClaimTemplates); err != nil {
+		return err
+	}
+	//volumeMounts 可以引用 volumeClaimTemplates 里的卷，按 pod 实际会有的卷检查模板
+	var template api.PodTemplateSpec
+	ss.Spec.Template.DeepCopyInto(&template)
+	template.Spec.Volumes = ss.PodVolumes(template.Spec.Volumes, 0)
+	if err := validatePodTemplate(ss.Spec.Selector, &template); err != nil {
+		return err
+	}
+	if err := validateAlwaysRestart(&ss.Spec.Template); err != nil {
+		return err
+	}
+	switch ss.Spec.PodManagementPolicy {
+	case api.OrderedReadyPodManagement, api.ParallelPodManagement:
+	default:
+		return fmt.Errorf("spec.podManagementPolicy must be OrderedReady or Parallel, got %q", ss.Spec.PodManagementPolicy)
+	}
+	switch ss.Spec.UpdateStrategy.Type {
+	case api.OnDeleteStatefulSetStrategyType:
+		if ss.Spec.UpdateStrategy.RollingUpdate != nil {
+			return fmt.Errorf("spec.updateStrategy.rollingUpdate must not be set when update strategy type is OnDelete")
+		}
+	case api.RollingUpdateStatefulSetStrategyType:
+		if *ss.Spec.UpdateStrategy.RollingUpdate.Partition < 0 {
+			return fmt.Errorf("spec.updateStrategy.rollingUpdate.partition must not be negative")
+		}
+	default:
+		return fmt.Errorf("spec.updateStrategy.type must be RollingUpdate or OnDelete, got %q", ss.Spec.UpdateStrategy.Type)
+	}
+	if *ss.Spec.RevisionHistoryLimit < 0 {
+		return fmt.Errorf("spec.revisionHistoryLimit must not be negative")
+	}
+	return nil
+}
+
+func validateVolumeClaimTemplates(claims []api.PersistentVolumeClaim) error {
+	names := map[string]bool{}
+	for i := range claims {
+		claim := &claims[i]
+		field := fmt.Sprintf("spec.volumeClaimTemplates[%d]", i)
+		if !dnsLabelPattern.MatchString(claim.Name) {
+			return fmt.Errorf("%s.name %q must be a lowercase DNS label", field, claim.Name)
+		}
+		if names[claim.Name] {
+			return fmt.Errorf("%s.name %q is used by more than one volume claim template", field, claim.Name)
+		}
+		names[claim.Name] = true
+		if err := validatePersistentVolumeClaim(claim); err != nil {
+			return fmt.Errorf("%s.%w", field, err)
+		}
+	}
+	return nil
+}
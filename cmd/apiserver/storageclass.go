@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"mini-k8s/pkg/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerStorageClasses 注册 StorageClass。它们是集群级别的，创建之后除了 metadata 都不能修改
+func (s *APIServer) registerStorageClasses(router *gin.Engine) {
+	registerResource(router, s, "storageclasses", &resource[api.StorageClass, *api.StorageClass]{
+		kind:        "StorageClass",
+		namespaced:  false,
+		create:      s.store.CreateStorageClass,
+		get:         func(_, name string) (*api.StorageClass, error) { return s.store.GetStorageClass(name) },
+		update:      s.store.UpdateStorageClass,
+		delete:      func(_, name string) error { return s.store.DeleteStorageClass(name) },
+		list:        func(string) ([]*api.StorageClass, error) { return s.store.ListStorageClasses() },
+		watch:       func(string) (<-chan api.WatchEvent[api.StorageClass], func()) { return s.store.WatchStorageClasses() },
+		setDefaults: setStorageClassDefaults,
+		validate:    validateStorageClass,
+		prepareForUpdate: func(old, sc *api.StorageClass) {
+			//已经创建的卷按创建时的参数生成，修改 class 不会影响它们，所以不允许修改
+			sc.Provisioner = old.Provisioner
+			sc.Parameters = old.Parameters
+			sc.ReclaimPolicy = old.ReclaimPolicy
+			sc.VolumeBindingMode = old.VolumeBindingMode
+		},
+	})
+}
+
+// defaultStorageClass 返回默认的 StorageClass，有多个时使用最新创建的那个
+func (s *APIServer) defaultStorageClass() *api.StorageClass {
+	classes, err := s.store.ListStorageClasses()
+	if err != nil {
+		log.Printf("Error listing storage classes: %v", err)
+		return nil
+	}
+	var found *api.StorageClass
+	for _, class := range classes {
+		if !class.IsDefaultClass() {
+			continue
+		}
+		if found == nil || found.CreationTimestamp == nil ||
+			(class.CreationTimestamp != nil && class.CreationTimestamp.After(*found.CreationTimestamp)) {
+			found = class
+		}
+	}
+	return found
+}
+
+func setStorageClassDefaults(sc *api.StorageClass) {
+	if sc.ReclaimPolicy == "" {
+		sc.ReclaimPolicy = api.PersistentVolumeReclaimDelete
+	}
+	if sc.VolumeBindingMode == "" {
+		sc.VolumeBindingMode = api.VolumeBindingImmediate
+	}
+}
+
+func validateStorageClass(sc *api.StorageClass) error {
+	if !dnsLabelPattern.MatchString(sc.Name) {
+		return fmt.Errorf("name %q must be a lowercase DNS label", sc.Name)
+	}
+	if sc.Provisioner == "" {
+		return fmt.Errorf("provisioner must be set")
+	}
+	if err := validateReclaimPolicy("reclaimPolicy", sc.ReclaimPolicy); err != nil {
+		return err
+	}
+	switch sc.VolumeBindingMode {
+	case api.VolumeBindingImmediate, api.VolumeBindingWaitForFirstConsumer:
+	default:
+		return fmt.Errorf("volumeBindingMode must be Immediate or WaitForFirstConsumer, got %q", sc.VolumeBindingMode)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"mini-k8s/pkg/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+// isWatch 判断 list 请求是否带了 watch=true
+func isWatch(c *gin.Context) bool {
+	return c.Query("watch") == "true" || c.Query("watch") == "1"
+}
+
+// serveWatch 先把当前已有的对象作为 ADDED 事件发出去，然后把 store 的变化按每行一个 JSON 持续写给客户端，
+// 直到客户端断开或者 store 关闭了 channel（订阅者跟不上时会被断开，客户端需要重新 watch）
+func serveWatch[T any](c *gin.Context, existing []*T, events <-chan api.WatchEvent[T], stop func()) {
+	defer stop()
+	c.Header("Content-Type", "application/json")
+	c.Header("Cache-Control", "no-cache")
+	c.Status(200)
+	encoder := json.NewEncoder(c.Writer)
+	for _, obj := range existing {
+		if err := encoder.Encode(api.WatchEvent[T]{Type: api.EventAdded, Object: *obj}); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := encoder.Encode(event); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
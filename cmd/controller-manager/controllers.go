@@ -0,0 +1,267 @@
+package main
+
+import (
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/controller/cronjob"
+	"mini-k8s/pkg/controller/daemonset"
+	"mini-k8s/pkg/controller/deployment"
+	"mini-k8s/pkg/controller/disruption"
+	"mini-k8s/pkg/controller/endpoint"
+	"mini-k8s/pkg/controller/garbagecollector"
+	"mini-k8s/pkg/controller/job"
+	"mini-k8s/pkg/controller/localpath"
+	"mini-k8s/pkg/controller/namespace"
+	"mini-k8s/pkg/controller/nodelifecycle"
+	"mini-k8s/pkg/controller/persistentvolume"
+	"mini-k8s/pkg/controller/podautoscaler"
+	"mini-k8s/pkg/controller/replicaset"
+	"mini-k8s/pkg/controller/statefulset"
+	"mini-k8s/pkg/informer"
+	"time"
+)
+
+// syncer 是所有 controller 的共同接口：每次 Sync 都把所有对象同步一遍
+type syncer interface {
+	Sync()
+}
+
+// eventSource 是一个 informer，它的缓存变化时会触发 controller 同步
+type eventSource interface {
+	AddEventHandler(handler func())
+}
+
+// controllerContext 是创建 controller 时可以使用的共享资源
+type controllerContext struct {
+	apiServerURL string
+	informers    *informer.SharedInformerFactory
+	// nodeMonitorGracePeriod 是节点多久没有心跳后被标记为 NotReady
+	nodeMonitorGracePeriod time.Duration
+	// horizontalPodAutoscalerSyncPeriod 是同一个 HPA 两次计算副本数之间的间隔
+	horizontalPodAutoscalerSyncPeriod time.Duration
+}
+
+// newClient 为 controller 创建自己的 client，让它写入的字段仍然记在原来独立进程使用的 field manager 名下
+func (ctx *controllerContext) newClient(fieldManager string) (*api.Client, error) {
+	client, err := api.NewClient(ctx.apiServerURL)
+	if err != nil {
+		return nil, err
+	}
+	client.SetFieldManager(fieldManager)
+	return client, nil
+}
+
+type controllerDescriptor struct {
+	name           string
+	defaultWorkers int
+	// sources 返回 controller 读取的 informer，它们的变化会触发一次同步
+	sources func(f *informer.SharedInformerFactory) []eventSource
+	new     func(ctx *controllerContext, workers int) (syncer, error)
+}
+
+// controllers 是 controller-manager 可以运行的所有 controller，默认全部启用
+var controllers = []controllerDescriptor{
+	{
+		name:           "nodelifecycle",
+		defaultWorkers: 1,
+		sources: func(f *informer.SharedInformerFactory) []eventSource {
+			return []eventSource{f.Nodes(), f.Pods()}
+		},
+		new: func(ctx *controllerContext, workers int) (syncer, error) {
+			client, err := ctx.newClient("node-controller")
+			if err != nil {
+				return nil, err
+			}
+			return nodelifecycle.NewNodeLifecycleController(client, ctx.informers, workers, ctx.nodeMonitorGracePeriod), nil
+		},
+	},
+	{
+		name:           "replicaset",
+		defaultWorkers: 5,
+		sources: func(f *informer.SharedInformerFactory) []eventSource {
+			return []eventSource{f.ReplicaSets(), f.Pods()}
+		},
+		new: func(ctx *controllerContext, workers int) (syncer, error) {
+			client, err := ctx.newClient("replicaset-controller")
+			if err != nil {
+				return nil, err
+			}
+			return replicaset.NewReplicaSetController(client, ctx.informers, workers), nil
+		},
+	},
+	{
+		name:           "deployment",
+		defaultWorkers: 5,
+		sources: func(f *informer.SharedInformerFactory) []eventSource {
+			return []eventSource{f.Deployments(), f.ReplicaSets(), f.Pods()}
+		},
+		new: func(ctx *controllerContext, workers int) (syncer, error) {
+			client, err := ctx.newClient("deployment-controller")
+			if err != nil {
+				return nil, err
+			}
+			return deployment.NewDeploymentController(client, ctx.informers, workers), nil
+		},
+	},
+	{
+		name:           "job",
+		defaultWorkers: 5,
+		sources: func(f *informer.SharedInformerFactory) []eventSource {
+			return []eventSource{f.Jobs(), f.Pods()}
+		},
+		new: func(ctx *controllerContext, workers int) (syncer, error) {
+			client, err := ctx.newClient("job-controller")
+			if err != nil {
+				return nil, err
+			}
+			return job.NewJobController(client, ctx.informers, workers), nil
+		},
+	},
+	{
+		name:           "cronjob",
+		defaultWorkers: 5,
+		sources: func(f *informer.SharedInformerFactory) []eventSource {
+			return []eventSource{f.CronJobs(), f.Jobs()}
+		},
+		new: func(ctx *controllerContext, workers int) (syncer, error) {
+			client, err := ctx.newClient("cronjob-controller")
+			if err != nil {
+				return nil, err
+			}
+			return cronjob.NewCronJobController(client, ctx.informers, workers), nil
+		},
+	},
+	{
+		name:           "daemonset",
+		defaultWorkers: 2,
+		sources: func(f *informer.SharedInformerFactory) []eventSource {
+			return []eventSource{f.DaemonSets(), f.Nodes(), f.Pods()}
+		},
+		new: func(ctx *controllerContext, workers int) (syncer, error) {
+			client, err := ctx.newClient("daemonset-controller")
+			if err != nil {
+				return nil, err
+			}
+			return daemonset.NewDaemonSetController(client, ctx.informers, workers), nil
+		},
+	},
+	{
+		name:           "statefulset",
+		defaultWorkers: 5,
+		sources: func(f *informer.SharedInformerFactory) []eventSource {
+			return []eventSource{f.StatefulSets(), f.Pods(), f.ControllerRevisions()}
+		},
+		new: func(ctx *controllerContext, workers int) (syncer, error) {
+			client, err := ctx.newClient("statefulset-controller")
+			if err != nil {
+				return nil, err
+			}
+			return statefulset.NewStatefulSetController(client, ctx.informers, workers), nil
+		},
+	},
+	{
+		name:           "endpoint",
+		defaultWorkers: 5,
+		sources: func(f *informer.SharedInformerFactory) []eventSource {
+			return []eventSource{f.Services(), f.Pods(), f.Endpoints()}
+		},
+		new: func(ctx *controllerContext, workers int) (syncer, error) {
+			client, err := ctx.newClient("endpoint-controller")
+			if err != nil {
+				return nil, err
+			}
+			return endpoint.NewEndpointsController(client, ctx.informers, workers), nil
+		},
+	},
+	{
+		name:           "garbagecollector",
+		defaultWorkers: 20,
+		sources: func(f *informer.SharedInformerFactory) []eventSource {
+			return []eventSource{f.Pods(), f.ControllerRevisions(), f.ReplicaSets(), f.Jobs(),
+				f.StatefulSets(), f.DaemonSets(), f.Deployments(), f.CronJobs(), f.Endpoints(), f.Services(),
+				f.PersistentVolumes(), f.PersistentVolumeClaims(), f.ConfigMaps(), f.Secrets(), f.Ingresses(),
+				f.HorizontalPodAutoscalers(), f.PodDisruptionBudgets(), f.StorageClasses()}
+		},
+		new: func(ctx *controllerContext, workers int) (syncer, error) {
+			client, err := ctx.newClient("garbage-collector")
+			if err != nil {
+				return nil, err
+			}
+			return garbagecollector.NewGarbageCollector(client, ctx.informers, workers), nil
+		},
+	},
+	{
+		//绑定要保证一个卷只分给一个 PVC，binder 只用一个 worker
+		name:           "persistentvolume-binder",
+		defaultWorkers: 1,
+		sources: func(f *informer.SharedInformerFactory) []eventSource {
+			return []eventSource{f.PersistentVolumes(), f.PersistentVolumeClaims(), f.Pods(), f.StorageClasses(), f.Nodes()}
+		},
+		new: func(ctx *controllerContext, workers int) (syncer, error) {
+			client, err := ctx.newClient("persistentvolume-binder-controller")
+			if err != nil {
+				return nil, err
+			}
+			return persistentvolume.NewPersistentVolumeController(client, ctx.informers), nil
+		},
+	},
+	{
+		//创建卷之前要确认还没有为这个 PVC 创建过，provisioner 只用一个 worker
+		name:           "local-path-provisioner",
+		defaultWorkers: 1,
+		sources: func(f *informer.SharedInformerFactory) []eventSource {
+			return []eventSource{f.PersistentVolumes(), f.PersistentVolumeClaims(), f.StorageClasses(), f.Nodes(), f.Pods()}
+		},
+		new: func(ctx *controllerContext, workers int) (syncer, error) {
+			client, err := ctx.newClient("local-path-provisioner")
+			if err != nil {
+				return nil, err
+			}
+			return localpath.NewLocalPathProvisioner(client, ctx.informers), nil
+		},
+	},
+	{
+		//Pod 的变化很频繁，每个 HPA 仍然只按 horizontalPodAutoscalerSyncPeriod 计算
+		name:           "horizontalpodautoscaling",
+		defaultWorkers: 5,
+		sources: func(f *informer.SharedInformerFactory) []eventSource {
+			return []eventSource{f.HorizontalPodAutoscalers(), f.Deployments(), f.ReplicaSets(), f.Pods()}
+		},
+		new: func(ctx *controllerContext, workers int) (syncer, error) {
+			client, err := ctx.newClient("horizontal-pod-autoscaler")
+			if err != nil {
+				return nil, err
+			}
+			return podautoscaler.NewHorizontalController(client, ctx.informers, workers, ctx.horizontalPodAutoscalerSyncPeriod), nil
+		},
+	},
+	{
+		//节点状态决定 pod 是否健康，副本数决定 maxUnavailable 和百分比换算出的数量
+		name:           "disruption",
+		defaultWorkers: 5,
+		sources: func(f *informer.SharedInformerFactory) []eventSource {
+			return []eventSource{f.PodDisruptionBudgets(), f.Pods(), f.Nodes(), f.ReplicaSets(), f.Deployments(), f.StatefulSets()}
+		},
+		new: func(ctx *controllerContext, workers int) (syncer, error) {
+			client, err := ctx.newClient("disruption-controller")
+			if err != nil {
+				return nil, err
+			}
+			return disruption.NewDisruptionController(client, ctx.informers, workers), nil
+		},
+	},
+	{
+		//namespace controller 直接向 API server list，命名空间的变化只用来触发同步
+		name:           "namespace",
+		defaultWorkers: 5,
+		sources: func(f *informer.SharedInformerFactory) []eventSource {
+			return []eventSource{f.Namespaces()}
+		},
+		new: func(ctx *controllerContext, workers int) (syncer, error) {
+			client, err := ctx.newClient("namespace-controller")
+			if err != nil {
+				return nil, err
+			}
+			return namespace.NewNamespaceController(client, workers), nil
+		},
+	},
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/informer"
+	"mini-k8s/pkg/leaderelection"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// eventBatchDelay 是收到缓存变化之后等待多久再同步：合并短时间内的多次变化，
+// 也给缓存一点时间跟上 controller 自己刚刚写入的对象，避免按旧缓存重复创建 pod
+const eventBatchDelay = 500 * time.Millisecond
+
+// healthChecker 记录每个 controller 最近一次完成同步的时间
+type healthChecker struct {
+	informers *informer.SharedInformerFactory
+	// timeout 是 controller 多久没有完成同步就被认为卡住了
+	timeout time.Duration
+
+	mu       sync.Mutex
+	lastSync map[string]time.Time
+}
+
+func (h *healthChecker) observe(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastSync[name] = time.Now()
+}
+
+// reset 在 controller 停止运行时清空同步记录，不再是 leader 的副本不会因为 controller 没有同步而不健康
+func (h *healthChecker) reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastSync = map[string]time.Time{}
+}
+
+// ServeHTTP 处理 /healthz：informer 都已经完成第一次 list、每个 controller 最近都完成过同步时返回 ok
+func (h *healthChecker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var failures []string
+	if !h.informers.HasSynced() {
+		failures = append(failures, "informers have not synced")
+	}
+	h.mu.Lock()
+	now := time.Now()
+	for name, last := range h.lastSync {
+		if now.Sub(last) > h.timeout {
+			failures = append(failures, fmt.Sprintf("controller %s has not synced since %s", name, last.Format(time.RFC3339)))
+		}
+	}
+	h.mu.Unlock()
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		http.Error(w, strings.Join(failures, "\n"), http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprint(w, "ok")
+}
+
+// enabledControllers 解析 -controllers：* 表示全部，foo 启用 foo，-foo 禁用 foo
+func enabledControllers(spec string) (map[string]bool, error) {
+	known := map[string]bool{}
+	for _, d := range controllers {
+		known[d.name] = true
+	}
+	enabled := map[string]bool{}
+	disabled := map[string]bool{}
+	all := false
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		switch {
+		case item == "":
+		case item == "*":
+			all = true
+		case strings.HasPrefix(item, "-"):
+			if !known[item[1:]] {
+				return nil, fmt.Errorf("unknown controller %q", item[1:])
+			}
+			disabled[item[1:]] = true
+		default:
+			if !known[item] {
+				return nil, fmt.Errorf("unknown controller %q", item)
+			}
+			enabled[item] = true
+		}
+	}
+	result := map[string]bool{}
+	for name := range known {
+		result[name] = (all || enabled[name]) && !disabled[name]
+	}
+	return result, nil
+}
+
+// runController 先同步一次，然后在缓存变化或者到了同步间隔时再同步，直到 stop 被关闭
+func runController(name string, c syncer, trigger <-chan struct{}, interval time.Duration, health *healthChecker, stop <-chan struct{}) {
+	for {
+		c.Sync()
+		health.observe(name)
+		select {
+		case <-stop:
+			return
+		case <-trigger:
+			time.Sleep(eventBatchDelay)
+			select {
+			case <-trigger:
+			default:
+			}
+		case <-time.After(interval):
+		}
+	}
+}
+
+func main() {
+	apiServerURL := flag.String("apiserver", "http://localhost:8055", "URL of the API server")
+	syncInterval := flag.Duration("interval", 5*time.Second, "Interval between full syncs of each controller when nothing changes")
+	enabledSpec := flag.String("controllers", "*", "Comma-separated controllers to run: '*' enables all, 'foo' enables foo, '-foo' disables foo")
+	healthzAddr := flag.String("healthz-bind-address", ":10257", "Address to serve /healthz on; empty disables it")
+	gracePeriod := flag.Duration("node-monitor-grace-period", 40*time.Second, "How long a node may go without a heartbeat before it is marked NotReady")
+	hpaSyncPeriod := flag.Duration("horizontal-pod-autoscaler-sync-period", 15*time.Second, "How often each horizontal pod autoscaler recomputes the replica count from metrics")
+	workers := map[string]*int{}
+	for _, d := range controllers {
+		workers[d.name] = flag.Int("concurrent-"+d.name+"-syncs", d.defaultWorkers, fmt.Sprintf("Number of objects the %s controller syncs concurrently", d.name))
+	}
+	var election leaderelection.Options
+	election.AddFlags(flag.CommandLine, "kube-controller-manager")
+	flag.Parse()
+	enabled, err := enabledControllers(*enabledSpec)
+	if err != nil {
+		log.Fatalf("Invalid -controllers: %v", err)
+	}
+	log.Printf("Starting controller manager with URL %s", *apiServerURL)
+	client, err := api.NewClient(*apiServerURL)
+	if err != nil {
+		log.Fatalf("Error creating client: %s", err)
+	}
+	client.SetFieldManager("controller-manager")
+	ctx := &controllerContext{
+		apiServerURL:                      *apiServerURL,
+		informers:                         informer.NewSharedInformerFactory(client),
+		nodeMonitorGracePeriod:            *gracePeriod,
+		horizontalPodAutoscalerSyncPeriod: *hpaSyncPeriod,
+	}
+	health := &healthChecker{informers: ctx.informers, timeout: 3*(*syncInterval) + time.Minute, lastSync: map[string]time.Time{}}
+
+	type started struct {
+		name    string
+		c       syncer
+		trigger chan struct{}
+	}
+	var running []started
+	for _, d := range controllers {
+		if !enabled[d.name] {
+			log.Printf("Controller %s is disabled", d.name)
+			continue
+		}
+		c, err := d.new(ctx, *workers[d.name])
+		if err != nil {
+			log.Fatalf("Error creating controller %s: %v", d.name, err)
+		}
+		//多次变化只需要排队一次同步
+		trigger := make(chan struct{}, 1)
+		for _, source := range d.sources(ctx.informers) {
+			source.AddEventHandler(func() {
+				select {
+				case trigger <- struct{}{}:
+				default:
+				}
+			})
+		}
+		running = append(running, started{name: d.name, c: c, trigger: trigger})
+	}
+
+	if *healthzAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/healthz", health)
+		go func() {
+			if err := http.ListenAndServe(*healthzAddr, mux); err != nil {
+				log.Fatalf("Error serving /healthz: %v", err)
+			}
+		}()
+	}
+
+	stop := make(chan struct{})
+	ctx.informers.Start(stop)
+	//缓存还是空的时候同步会让 controller 以为 pod 都不存在
+	for !ctx.informers.HasSynced() {
+		time.Sleep(100 * time.Millisecond)
+	}
+	log.Printf("Informer caches are synced")
+
+	//只有 leader 运行 controller，informer 在所有副本上都运行，接手时缓存已经是新的
+	runControllers := func(leading <-chan struct{}) {
+		var wg sync.WaitGroup
+		for _, r := range running {
+			log.Printf("Starting controller %s with %d workers", r.name, *workers[r.name])
+			wg.Add(1)
+			go func(r started) {
+				defer wg.Done()
+				runController(r.name, r.c, r.trigger, *syncInterval, health, leading)
+			}(r)
+		}
+		wg.Wait()
+		health.reset()
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	shutdown := make(chan struct{})
+	go func() {
+		<-signals
+		log.Printf("Shutting down controller manager")
+		close(shutdown)
+	}()
+
+	if !election.LeaderElect {
+		runControllers(shutdown)
+	} else {
+		le, err := leaderelection.NewLeaderElector(election.Config(client, leaderelection.DefaultIdentity(), leaderelection.Callbacks{
+			OnStartedLeading: func(leading <-chan struct{}) {
+				log.Printf("Started leading, starting controllers")
+				runControllers(leading)
+			},
+			OnStoppedLeading: func() {
+				log.Printf("Stopped leading, all controllers have stopped")
+			},
+		}))
+		if err != nil {
+			log.Fatalf("Invalid leader election configuration: %v", err)
+		}
+		le.Run(shutdown)
+	}
+	close(stop)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/dns"
+	"mini-k8s/pkg/informer"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	apiServerURL := flag.String("apiserver", "http://localhost:8055", "URL of the API server")
+	configPath := flag.String("config", "", "Path to a JSON config file with clusterDomain, ttl, upstreams and upstreamTimeout")
+	listenAddr := flag.String("listen", "127.0.0.1:10053", "UDP and TCP address to answer queries on")
+	healthzAddr := flag.String("healthz-bind-address", ":10254", "Address to serve /healthz on; empty disables it")
+	flag.Parse()
+
+	config, err := dns.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	log.Printf("Starting DNS server for %s with URL %s, forwarding other names to %v", config.ClusterDomain, *apiServerURL, config.Upstreams)
+	client, err := api.NewClient(*apiServerURL)
+	if err != nil {
+		log.Fatalf("Error creating client: %s", err)
+	}
+	informers := informer.NewSharedInformerFactory(client)
+	informers.Services()
+	informers.Endpoints()
+	stop := make(chan struct{})
+	informers.Start(stop)
+	//缓存还是空的时候所有 service 都会被回答成不存在
+	for !informers.HasSynced() {
+		time.Sleep(100 * time.Millisecond)
+	}
+	log.Printf("Informer caches are synced")
+
+	if *healthzAddr != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "ok")
+		})
+		go func() {
+			if err := http.ListenAndServe(*healthzAddr, mux); err != nil {
+				log.Fatalf("Error serving /healthz: %v", err)
+			}
+		}()
+	}
+
+	server := dns.NewServer(config, informers)
+	udpConn, err := net.ListenPacket("udp", *listenAddr)
+	if err != nil {
+		log.Fatalf("Error listening on udp %s: %v", *listenAddr, err)
+	}
+	tcpListener, err := net.Listen("tcp", *listenAddr)
+	if err != nil {
+		log.Fatalf("Error listening on tcp %s: %v", *listenAddr, err)
+	}
+	go func() {
+		if err := server.ServeUDP(udpConn); err != nil {
+			log.Fatalf("Error serving udp: %v", err)
+		}
+	}()
+	go func() {
+		if err := server.ServeTCP(tcpListener); err != nil {
+			log.Fatalf("Error serving tcp: %v", err)
+		}
+	}()
+	log.Printf("Answering queries on %s", *listenAddr)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	<-signals
+	log.Printf("Shutting down DNS server")
+	udpConn.Close()
+	tcpListener.Close()
+	close(stop)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/informer"
+	"mini-k8s/pkg/ingress"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// eventBatchDelay 是收到缓存变化之后等待多久再同步，合并短时间内的多次变化
+const eventBatchDelay = 500 * time.Millisecond
+
+func main() {
+	apiServerURL := flag.String("apiserver", "http://localhost:8055", "URL of the API server")
+	httpAddr := flag.String("http-address", ":8080", "Address to serve plain HTTP on; empty disables it")
+	httpsAddr := flag.String("https-address", ":8443", "Address to serve HTTPS on with certificates from Ingress TLS secrets; empty disables it")
+	syncInterval := flag.Duration("interval", 30*time.Second, "Interval between full syncs when nothing changes")
+	healthzAddr := flag.String("healthz-bind-address", ":10255", "Address to serve /healthz and /routes on; empty disables it")
+	flag.Parse()
+
+	log.Printf("Starting ingress controller with URL %s", *apiServerURL)
+	client, err := api.NewClient(*apiServerURL)
+	if err != nil {
+		log.Fatalf("Error creating client: %s", err)
+	}
+	informers := informer.NewSharedInformerFactory(client)
+	ingresses := informers.Ingresses()
+	services := informers.Services()
+	endpoints := informers.Endpoints()
+	secrets := informers.Secrets()
+	//多次变化只需要排队一次同步
+	trigger := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case trigger <- struct{}{}:
+		default:
+		}
+	}
+	ingresses.AddEventHandler(notify)
+	services.AddEventHandler(notify)
+	endpoints.AddEventHandler(notify)
+	secrets.AddEventHandler(notify)
+
+	config := ingress.Config{}
+	if *httpsAddr != "" {
+		if _, port, err := net.SplitHostPort(*httpsAddr); err == nil {
+			config.HTTPSPort, _ = strconv.Atoi(port)
+		}
+	}
+	router := ingress.NewRouter(config)
+
+	if *healthzAddr != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+			if !informers.HasSynced() {
+				http.Error(w, "informers have not synced", http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprint(w, "ok")
+		})
+		mux.HandleFunc("/routes", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(router.Status())
+		})
+		go func() {
+			if err := http.ListenAndServe(*healthzAddr, mux); err != nil {
+				log.Fatalf("Error serving /healthz: %v", err)
+			}
+		}()
+	}
+
+	stop := make(chan struct{})
+	informers.Start(stop)
+	//缓存还是空的时候所有请求都会返回 404
+	for !informers.HasSynced() {
+		time.Sleep(100 * time.Millisecond)
+	}
+	log.Printf("Informer caches are synced")
+	router.Sync(ingresses.List(), services.List(), endpoints.List(), secrets.List())
+
+	var servers []*http.Server
+	if *httpAddr != "" {
+		server := &http.Server{Addr: *httpAddr, Handler: router, ReadHeaderTimeout: 10 * time.Second}
+		servers = append(servers, server)
+		go func() {
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Error serving http on %s: %v", *httpAddr, err)
+			}
+		}()
+		log.Printf("Serving HTTP on %s", *httpAddr)
+	}
+	if *httpsAddr != "" {
+		server := &http.Server{Addr: *httpsAddr, Handler: router, TLSConfig: router.TLSConfig(), ReadHeaderTimeout: 10 * time.Second}
+		servers = append(servers, server)
+		go func() {
+			//证书由 TLSConfig 的 GetCertificate 提供
+			if err := server.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Error serving https on %s: %v", *httpsAddr, err)
+			}
+		}()
+		log.Printf("Serving HTTPS on %s", *httpsAddr)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	for {
+		select {
+		case <-signals:
+			log.Printf("Shutting down ingress controller")
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			for _, server := range servers {
+				server.Shutdown(ctx)
+			}
+			cancel()
+			close(stop)
+			return
+		case <-trigger:
+			time.Sleep(eventBatchDelay)
+			select {
+			case <-trigger:
+			default:
+			}
+		case <-time.After(*syncInterval):
+		}
+		router.Sync(ingresses.List(), services.List(), endpoints.List(), secrets.List())
+	}
+}
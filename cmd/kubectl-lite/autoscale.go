@@ -0,0 +1,173 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"mini-k8s/pkg/api"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// parseRequests 解析 cpu=250m,memory=64Mi 这样的资源请求
+func parseRequests(s string) (api.ResourceList, error) {
+	if s == "" {
+		return nil, nil
+	}
+	requests := api.ResourceList{}
+	for _, part := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid request %q, expected name=quantity", part)
+		}
+		requests[api.ResourceName(name)] = value
+	}
+	return requests, nil
+}
+
+// handleAutoscaleCommand 为 Deployment 或 ReplicaSet 创建一个按 CPU 利用率扩缩容的 HPA，名字和目标相同
+func handleAutoscaleCommand(client *api.Client, args []string) {
+	if len(args) < 2 {
+		fmt.Println("Usage: kubectl-lite autoscale deployment|replicaset <name> --max <n> [--min <n>] [--cpu-percent <n>] [--namespace <ns>]")
+		os.Exit(1)
+	}
+	cmd := flag.NewFlagSet("autoscale", flag.ExitOnError)
+	minReplicas := cmd.Int("min", 0, "Lower limit for the number of pods (default 1)")
+	maxReplicas := cmd.Int("max", 0, "Upper limit for the number of pods")
+	cpuPercent := cmd.Int("cpu-percent", 0, "Target average CPU utilization over all the pods, as a percentage of the requested CPU (default 80)")
+	namespace := cmd.String("namespace", DefaultNamespace, "Namespace of the object")
+	cmd.Parse(args[2:])
+	var kind string
+	switch args[0] {
+	case "deployment", "deploy":
+		kind = "Deployment"
+	case "replicaset", "rs":
+		kind = "ReplicaSet"
+	default:
+		fmt.Printf("Unknown resource type for autoscale: %s\n", args[0])
+		os.Exit(1)
+	}
+	if *maxReplicas < 1 {
+		fmt.Println("Error: --max is required for autoscale")
+		cmd.Usage()
+		os.Exit(1)
+	}
+	hpa := &api.HorizontalPodAutoscaler{
+		ObjectMeta: api.ObjectMeta{Name: args[1], Namespace: *namespace},
+		Spec: api.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: api.CrossVersionObjectReference{Kind: kind, Name: args[1]},
+			MaxReplicas:    *maxReplicas,
+		},
+	}
+	if *minReplicas > 0 {
+		hpa.Spec.MinReplicas = minReplicas
+	}
+	if *cpuPercent > 0 {
+		hpa.Spec.Metrics = []api.MetricSpec{{
+			Type: api.MetricSourceResource,
+			Resource: &api.ResourceMetricSource{
+				Name:   api.ResourceCPU,
+				Target: api.MetricTarget{Type: api.UtilizationMetricType, AverageUtilization: cpuPercent},
+			},
+		}}
+	}
+	created, err := client.CreateHorizontalPodAutoscaler(*namespace, hpa)
+	exitOnError("creating horizontal pod autoscaler", err)
+	fmt.Printf("HorizontalPodAutoscaler %s/%s created\n\n", created.Namespace, created.Name)
+}
+
+func printHorizontalPodAutoscalerTable(hpas []api.HorizontalPodAutoscaler) {
+	sort.Slice(hpas, func(i, j int) bool {
+		if hpas[i].Namespace != hpas[j].Namespace {
+			return hpas[i].Namespace < hpas[j].Namespace
+		}
+		return hpas[i].Name < hpas[j].Name
+	})
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tREFERENCE\tTARGETS\tMINPODS\tMAXPODS\tREPLICAS")
+	for _, hpa := range hpas {
+		var targets []string
+		for _, metric := range hpa.Spec.Metrics {
+			if metric.Resource != nil {
+				targets = append(targets, formatMetricTarget(&hpa, metric.Resource))
+			}
+		}
+		minReplicas := 1
+		if hpa.Spec.MinReplicas != nil {
+			minReplicas = *hpa.Spec.MinReplicas
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s/%s\t%s\t%d\t%d\t%d\n", hpa.Namespace, hpa.Name, hpa.Spec.ScaleTargetRef.Kind, hpa.Spec.ScaleTargetRef.Name,
+			strings.Join(targets, ", "), minReplicas, hpa.Spec.MaxReplicas, hpa.Status.CurrentReplicas)
+	}
+	w.Flush()
+}
+
+// formatMetricTarget 和 kubectl get hpa 一样显示为 "当前值/目标"，还没有指标时当前值是 <unknown>
+func formatMetricTarget(hpa *api.HorizontalPodAutoscaler, source *api.ResourceMetricSource) string {
+	var current *api.MetricValueStatus
+	for _, status := range hpa.Status.CurrentMetrics {
+		if status.Resource != nil && status.Resource.Name == source.Name {
+			current = &status.Resource.Current
+		}
+	}
+	if source.Target.Type == api.UtilizationMetricType && source.Target.AverageUtilization != nil {
+		value := "<unknown>"
+		if current != nil && current.AverageUtilization != nil {
+			value = fmt.Sprintf("%d%%", *current.AverageUtilization)
+		}
+		return fmt.Sprintf("%s: %s/%d%%", source.Name, value, *source.Target.AverageUtilization)
+	}
+	value := "<unknown>"
+	if current != nil && current.AverageValue != "" {
+		value = current.AverageValue
+	}
+	return fmt.Sprintf("%s: %s/%s", source.Name, value, source.Target.AverageValue)
+}
+
+// handleTopCommand 显示 metrics API 提供的 pod 或节点的 CPU 和内存使用量
+func handleTopCommand(client *api.Client, args []string) {
+	if len(args) < 1 {
+		fmt.Println("Usage: kubectl-lite top pods|nodes [--namespace <ns> | --all-namespaces]")
+		os.Exit(1)
+	}
+	cmd := flag.NewFlagSet("top", flag.ExitOnError)
+	namespace := cmd.String("namespace", DefaultNamespace, "Namespace of the pods")
+	allNamespaces := cmd.Bool("all-namespaces", false, "Show pods across all namespaces")
+	cmd.BoolVar(allNamespaces, "A", false, "Shorthand for --all-namespaces")
+	cmd.Parse(args[1:])
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	switch args[0] {
+	case "pods", "pod", "po":
+		var metrics []api.PodMetrics
+		var err error
+		if *allNamespaces {
+			metrics, err = client.ListAllPodMetrics()
+		} else {
+			metrics, err = client.ListPodMetrics(*namespace)
+		}
+		exitOnError("getting pod metrics", err)
+		sort.Slice(metrics, func(i, j int) bool {
+			if metrics[i].Namespace != metrics[j].Namespace {
+				return metrics[i].Namespace < metrics[j].Namespace
+			}
+			return metrics[i].Name < metrics[j].Name
+		})
+		fmt.Fprintln(w, "NAMESPACE\tNAME\tCPU(cores)\tMEMORY(bytes)")
+		for _, m := range metrics {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", m.Namespace, m.Name, m.Usage[api.ResourceCPU], m.Usage[api.ResourceMemory])
+		}
+	case "nodes", "node", "no":
+		metrics, err := client.ListNodeMetrics()
+		exitOnError("getting node metrics", err)
+		sort.Slice(metrics, func(i, j int) bool { return metrics[i].Name < metrics[j].Name })
+		fmt.Fprintln(w, "NAME\tCPU(cores)\tMEMORY(bytes)")
+		for _, m := range metrics {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", m.Name, m.Usage[api.ResourceCPU], m.Usage[api.ResourceMemory])
+		}
+	default:
+		fmt.Printf("Unknown resource type for top: %s\n", args[0])
+		os.Exit(1)
+	}
+	w.Flush()
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"mini-k8s/pkg/api"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// shellCommand 把 --command 的值包装成 sh -c 执行的命令，空字符串表示不设置
+func shellCommand(command string) []string {
+	if command == "" {
+		return nil
+	}
+	return []string{"sh", "-c", command}
+}
+
+// jobSpecFlags 是 create job 和 create cronjob 共用的 Job 参数
+type jobSpecFlags struct {
+	image, command, restartPolicy     string
+	completions, parallelism, backoff int
+	activeDeadline                    int64
+	ttl                               int
+}
+
+func (f *jobSpecFlags) register(cmd *flag.FlagSet) {
+	cmd.StringVar(&f.image, "image", "", "Image to use for the pods")
+	cmd.StringVar(&f.command, "command", "", "Shell command the pods run, e.g. 'echo done'")
+	cmd.StringVar(&f.restartPolicy, "restart-policy", string(api.RestartPolicyNever), "Restart policy of the pods: Never or OnFailure")
+	cmd.IntVar(&f.completions, "completions", 1, "Number of pods that must succeed")
+	cmd.IntVar(&f.parallelism, "parallelism", 1, "Maximum number of pods running at the same time")
+	cmd.IntVar(&f.backoff, "backoff-limit", api.DefaultBackoffLimit, "Number of failures before the job is marked failed")
+	cmd.Int64Var(&f.activeDeadline, "active-deadline", 0, "Seconds the job may run before it is stopped, 0 means no limit")
+	cmd.IntVar(&f.ttl, "ttl", -1, "Seconds after the job finishes before it is deleted, -1 means keep it")
+}
+
+func (f *jobSpecFlags) spec() api.JobSpec {
+	spec := api.JobSpec{
+		Completions:  &f.completions,
+		Parallelism:  &f.parallelism,
+		BackoffLimit: &f.backoff,
+		Template: api.PodTemplateSpec{
+			Spec: api.PodSpec{
+				Image:         f.image,
+				Command:       shellCommand(f.command),
+				RestartPolicy: api.RestartPolicy(f.restartPolicy),
+			},
+		},
+	}
+	if f.activeDeadline > 0 {
+		spec.ActiveDeadlineSeconds = &f.activeDeadline
+	}
+	if f.ttl >= 0 {
+		spec.TTLSecondsAfterFinished = &f.ttl
+	}
+	return spec
+}
+
+func createJob(client *api.Client, args []string) {
+	cmd := flag.NewFlagSet("create job", flag.ExitOnError)
+	name := cmd.String("name", "", "Name of the job")
+	from := cmd.String("from", "", "Create the job from the template of a cronjob, e.g. cronjob/report")
+	namespace := cmd.String("namespace", DefaultNamespace, "Namespace of the job")
+	var f jobSpecFlags
+	f.register(cmd)
+	cmd.Parse(args)
+	if *name == "" {
+		fmt.Println("Error: --name is required for creating a job")
+		cmd.Usage()
+		os.Exit(1)
+	}
+	job := &api.Job{ObjectMeta: api.ObjectMeta{Name: *name, Namespace: *namespace}}
+	if *from != "" {
+		cronJobName, ok := strings.CutPrefix(*from, "cronjob/")
+		if !ok {
+			fmt.Println("Error: --from must be cronjob/<name>")
+			os.Exit(1)
+		}
+		cj, err := client.GetCronJob(*namespace, cronJobName)
+		exitOnError("getting cronjob", err)
+		cj.Spec.JobTemplate.Spec.DeepCopyInto(&job.Spec)
+		job.Labels = cj.Spec.JobTemplate.Metadata.Labels
+	} else {
+		if f.image == "" {
+			fmt.Println("Error: --image is required for creating a job")
+			cmd.Usage()
+			os.Exit(1)
+		}
+		job.Spec = f.spec()
+	}
+	created, err := client.CreateJob(*namespace, job)
+	exitOnError("creating job", err)
+	fmt.Printf("Job %s/%s created\n\n", created.Namespace, created.Name)
+}
+
+func createCronJob(client *api.Client, args []string) {
+	cmd := flag.NewFlagSet("create cronjob", flag.ExitOnError)
+	name := cmd.String("name", "", "Name of the cronjob")
+	schedule := cmd.String("schedule", "", "Cron schedule, e.g. '*/5 * * * *' or @hourly")
+	timeZone := cmd.String("time-zone", "", "Time zone the schedule is interpreted in, e.g. Asia/Shanghai")
+	concurrency := cmd.String("concurrency-policy", string(api.AllowConcurrent), "Allow, Forbid or Replace")
+	namespace := cmd.String("namespace", DefaultNamespace, "Namespace of the cronjob")
+	var f jobSpecFlags
+	f.register(cmd)
+	cmd.Parse(args)
+	if *name == "" || *schedule == "" || f.image == "" {
+		fmt.Println("Error: --name, --schedule and --image are required for creating a cronjob")
+		cmd.Usage()
+		os.Exit(1)
+	}
+	cj := &api.CronJob{
+		ObjectMeta: api.ObjectMeta{Name: *name, Namespace: *namespace},
+		Spec: api.CronJobSpec{
+			Schedule:          *schedule,
+			ConcurrencyPolicy: api.ConcurrencyPolicy(*concurrency),
+			JobTemplate:       api.JobTemplateSpec{Spec: f.spec()},
+		},
+	}
+	if *timeZone != "" {
+		cj.Spec.TimeZone = timeZone
+	}
+	created, err := client.CreateCronJob(*namespace, cj)
+	exitOnError("creating cronjob", err)
+	fmt.Printf("CronJob %s/%s created\n\n", created.Namespace, created.Name)
+}
+
+// printJobTable 以表格形式打印 Job 的完成情况
+func printJobTable(jobs []api.Job) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tCOMPLETIONS\tACTIVE\tFAILED\tSTATUS\tAGE")
+	for _, job := range jobs {
+		state := "Running"
+		if finished, condType := api.IsJobFinished(&job); finished {
+			state = string(condType)
+		}
+		completions := 1
+		if job.Spec.Completions != nil {
+			completions = *job.Spec.Completions
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d/%d\t%d\t%d\t%s\t%s\n", job.Namespace, job.Name, job.Status.Succeeded, completions,
+			job.Status.Active, job.Status.Failed, state, age(job.CreationTimestamp))
+	}
+	w.Flush()
+}
+
+func printCronJobTable(cronJobs []api.CronJob) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tSCHEDULE\tSUSPEND\tACTIVE\tLAST SCHEDULE\tAGE")
+	for _, cj := range cronJobs {
+		last := "<none>"
+		if cj.Status.LastScheduleTime != nil {
+			last = age(cj.Status.LastScheduleTime) + " ago"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%d\t%s\t%s\n", cj.Namespace, cj.Name, cj.Spec.Schedule, cj.Spec.Suspend,
+			len(cj.Status.Active), last, age(cj.CreationTimestamp))
+	}
+	w.Flush()
+}
+
+func age(t *time.Time) string {
+	if t == nil {
+		return "<unknown>"
+	}
+	return time.Since(*t).Round(time.Second).String()
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"mini-k8s/pkg/api"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+func createConfigMap(client *api.Client, args []string) {
+	cmd := flag.NewFlagSet("create configmap", flag.ExitOnError)
+	name := cmd.String("name", "", "Name of the configmap")
+	literals := cmd.String("from-literal", "", "Comma-separated key=value pairs")
+	files := cmd.String("from-file", "", "Comma-separated files as [key=]path; the key defaults to the file name")
+	namespace := cmd.String("namespace", DefaultNamespace, "Namespace of the configmap")
+	cmd.Parse(args)
+	if *name == "" {
+		fmt.Println("Error: --name is required for creating a configmap")
+		cmd.Usage()
+		os.Exit(1)
+	}
+	cm := &api.ConfigMap{ObjectMeta: api.ObjectMeta{Name: *name, Namespace: *namespace}}
+	data, err := parseConfigData(*literals, *files)
+	exitOnError("reading configmap data", err)
+	for k, v := range data {
+		//不是 UTF-8 的文件放进 binaryData
+		if utf8.Valid(v) {
+			if cm.Data == nil {
+				cm.Data = map[string]string{}
+			}
+			cm.Data[k] = string(v)
+		} else {
+			if cm.BinaryData == nil {
+				cm.BinaryData = map[string][]byte{}
+			}
+			cm.BinaryData[k] = v
+		}
+	}
+	created, err := client.CreateConfigMap(*namespace, cm)
+	exitOnError("creating configmap", err)
+	fmt.Printf("ConfigMap %s/%s created\n\n", created.Namespace, created.Name)
+}
+
+// parseConfigData 解析 --from-literal 的 key=value 列表和 --from-file 的 [key=]path 列表
+func parseConfigData(literals, files string) (map[string][]byte, error) {
+	data := map[string][]byte{}
+	for _, pair := range strings.Split(literals, ",") {
+		if pair == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("--from-literal entry %q must be key=value", pair)
+		}
+		data[k] = []byte(v)
+	}
+	for _, item := range strings.Split(files, ",") {
+		if item == "" {
+			continue
+		}
+		key, path, ok := strings.Cut(item, "=")
+		if !ok {
+			key, path = filepath.Base(item), item
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		data[key] = content
+	}
+	return data, nil
+}
+
+func createSecret(client *api.Client, args []string) {
+	if len(args) < 1 || (args[0] != "tls" && args[0] != "generic") {
+		fmt.Println("Usage: kubectl-lite create secret tls|generic --name <name> [flags]")
+		os.Exit(1)
+	}
+	secretType := args[0]
+	cmd := flag.NewFlagSet("create secret "+secretType, flag.ExitOnError)
+	name := cmd.String("name", "", "Name of the secret")
+	certFile := cmd.String("cert", "", "PEM certificate chain file (tls)")
+	keyFile := cmd.String("key", "", "PEM private key file (tls)")
+	literals := cmd.String("from-literal", "", "Comma-separated key=value pairs (generic)")
+	files := cmd.String("from-file", "", "Comma-separated files as [key=]path (generic)")
+	namespace := cmd.String("namespace", DefaultNamespace, "Namespace of the secret")
+	cmd.Parse(args[1:])
+	if *name == "" {
+		fmt.Println("Error: --name is required for creating a secret")
+		cmd.Usage()
+		os.Exit(1)
+	}
+	secret := &api.Secret{ObjectMeta: api.ObjectMeta{Name: *name, Namespace: *namespace}, Data: map[string][]byte{}}
+	if secretType == "tls" {
+		if *certFile == "" || *keyFile == "" {
+			fmt.Println("Error: --cert and --key are required for a tls secret")
+			os.Exit(1)
+		}
+		cert, err := os.ReadFile(*certFile)
+		exitOnError("reading --cert", err)
+		key, err := os.ReadFile(*keyFile)
+		exitOnError("reading --key", err)
+		secret.Type = api.SecretTypeTLS
+		secret.Data[api.TLSCertKey] = cert
+		secret.Data[api.TLSPrivateKeyKey] = key
+	} else {
+		secret.Type = api.SecretTypeOpaque
+		data, err := parseConfigData(*literals, *files)
+		exitOnError("reading secret data", err)
+		secret.Data = data
+	}
+	created, err := client.CreateSecret(*namespace, secret)
+	exitOnError("creating secret", err)
+	fmt.Printf("Secret %s/%s created\n\n", created.Namespace, created.Name)
+}
+
+func printConfigMapTable(configMaps []api.ConfigMap) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tDATA\tAGE")
+	for _, cm := range configMaps {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", cm.Namespace, cm.Name, len(cm.Data)+len(cm.BinaryData), age(cm.CreationTimestamp))
+	}
+	w.Flush()
+}
+
+// printSecretTable 只打印键的数量，不打印内容
+func printSecretTable(secrets []api.Secret) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tTYPE\tDATA\tAGE")
+	for _, secret := range secrets {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", secret.Namespace, secret.Name, secret.Type, len(secret.Data), age(secret.CreationTimestamp))
+	}
+	w.Flush()
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"mini-k8s/pkg/api"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// evictionRetryInterval 是驱逐被 PodDisruptionBudget 拒绝之后等多久再试
+const evictionRetryInterval = 5 * time.Second
+
+// parseIntOrString 把 "2" 解析成整数，把 "50%" 这样的值保留为字符串
+func parseIntOrString(s string) *api.IntOrString {
+	v := api.FromString(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		v = api.FromInt(n)
+	}
+	return &v
+}
+
+func createPodDisruptionBudget(client *api.Client, args []string) {
+	cmd := flag.NewFlagSet("create poddisruptionbudget", flag.ExitOnError)
+	name := cmd.String("name", "", "Name of the pod disruption budget")
+	selector := cmd.String("selector", "", "Labels of the pods the budget applies to, e.g. app=web")
+	minAvailable := cmd.String("min-available", "", "Pods that must stay available, a number or a percentage")
+	maxUnavailable := cmd.String("max-unavailable", "", "Pods that may be unavailable at the same time, a number or a percentage")
+	namespace := cmd.String("namespace", DefaultNamespace, "Namespace of the pod disruption budget")
+	cmd.Parse(args)
+	if *name == "" || *selector == "" {
+		fmt.Println("Error: --name and --selector are required for creating a pod disruption budget")
+		cmd.Usage()
+		os.Exit(1)
+	}
+	if (*minAvailable == "") == (*maxUnavailable == "") {
+		fmt.Println("Error: exactly one of --min-available and --max-unavailable must be set")
+		os.Exit(1)
+	}
+	pdb := &api.PodDisruptionBudget{
+		ObjectMeta: api.ObjectMeta{Name: *name, Namespace: *namespace},
+		Spec: api.PodDisruptionBudgetSpec{
+			Selector: &api.LabelSelector{MatchLabels: api.ParseLabels(*selector)},
+		},
+	}
+	if *minAvailable != "" {
+		pdb.Spec.MinAvailable = parseIntOrString(*minAvailable)
+	} else {
+		pdb.Spec.MaxUnavailable = parseIntOrString(*maxUnavailable)
+	}
+	created, err := client.CreatePodDisruptionBudget(*namespace, pdb)
+	exitOnError("creating pod disruption budget", err)
+	fmt.Printf("PodDisruptionBudget %s/%s created\n\n", created.Namespace, created.Name)
+}
+
+func printPodDisruptionBudgetTable(pdbs []api.PodDisruptionBudget) {
+	sort.Slice(pdbs, func(i, j int) bool {
+		if pdbs[i].Namespace != pdbs[j].Namespace {
+			return pdbs[i].Namespace < pdbs[j].Namespace
+		}
+		return pdbs[i].Name < pdbs[j].Name
+	})
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tMIN AVAILABLE\tMAX UNAVAILABLE\tALLOWED DISRUPTIONS\tHEALTHY")
+	for _, pdb := range pdbs {
+		minAvailable, maxUnavailable := "N/A", "N/A"
+		if pdb.Spec.MinAvailable != nil {
+			minAvailable = pdb.Spec.MinAvailable.String()
+		}
+		if pdb.Spec.MaxUnavailable != nil {
+			maxUnavailable = pdb.Spec.MaxUnavailable.String()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d/%d\n", pdb.Namespace, pdb.Name, minAvailable, maxUnavailable,
+			pdb.Status.DisruptionsAllowed, pdb.Status.CurrentHealthy, pdb.Status.DesiredHealthy)
+	}
+	w.Flush()
+}
+
+// setUnschedulable 添加或删除节点的 unschedulable:NoSchedule 污点，返回节点原来是否已经是这个状态
+func setUnschedulable(client *api.Client, name string, unschedulable bool) bool {
+	node, err := client.GetNode(name)
+	exitOnError("getting node", err)
+	var taints []api.Taint
+	found := false
+	for _, taint := range node.Taints {
+		if taint.Key == api.TaintNodeUnschedulable && taint.Effect == api.TaintEffectNoSchedule {
+			found = true
+			continue
+		}
+		taints = append(taints, taint)
+	}
+	if found == unschedulable {
+		return true
+	}
+	if unschedulable {
+		taints = append(taints, api.Taint{Key: api.TaintNodeUnschedulable, Effect: api.TaintEffectNoSchedule})
+	}
+	node.Taints = taints
+	exitOnError("updating node", client.UpdateNode(node))
+	return false
+}
+
+func handleCordonCommand(client *api.Client, args []string, unschedulable bool) {
+	verb := "cordon"
+	if !unschedulable {
+		verb = "uncordon"
+	}
+	if len(args) < 2 || args[0] != "node" {
+		fmt.Printf("Usage: kubectl-lite %s node <name>\n", verb)
+		os.Exit(1)
+	}
+	if setUnschedulable(client, args[1], unschedulable) {
+		fmt.Printf("Node %s already %sed\n", args[1], verb)
+		return
+	}
+	fmt.Printf("Node %s %sed\n", args[1], verb)
+}
+
+// handleDrainCommand 把节点标记为不可调度，然后通过 eviction API 逐个驱逐上面的 pod。
+// 被 PodDisruptionBudget 拒绝的 pod 每隔 evictionRetryInterval 重试，直到 controller 在别的节点上补齐副本；
+// DaemonSet 的 pod 会被 DaemonSet controller 马上重新创建，和已经结束的 pod 一样跳过
+func handleDrainCommand(client *api.Client, args []string) {
+	if len(args) < 2 || args[0] != "node" {
+		fmt.Println("Usage: kubectl-lite drain node <name> [--force] [--timeout <duration>]")
+		os.Exit(1)
+	}
+	cmd := flag.NewFlagSet("drain", flag.ExitOnError)
+	force := cmd.Bool("force", false, "Also evict pods that are not managed by a controller, they will not be recreated")
+	timeout := cmd.Duration("timeout", 5*time.Minute, "How long to keep retrying evictions and waiting for the pods to be deleted")
+	cmd.Parse(args[2:])
+	nodeName := args[1]
+	setUnschedulable(client, nodeName, true)
+	fmt.Printf("Node %s cordoned\n", nodeName)
+
+	pods, err := client.ListAllPods("")
+	exitOnError("listing pods", err)
+	var pending []api.Pod
+	var unmanaged []string
+	for _, pod := range pods {
+		if pod.NodeName != nodeName || !api.IsPodActive(&pod) || pod.DeletionTimestamp != nil {
+			continue
+		}
+		ref := pod.ControllerRef()
+		if ref != nil && ref.Kind == "DaemonSet" {
+			fmt.Printf("Ignoring DaemonSet-managed pod %s/%s\n", pod.Namespace, pod.Name)
+			continue
+		}
+		if ref == nil && !*force {
+			unmanaged = append(unmanaged, pod.Namespace+"/"+pod.Name)
+			continue
+		}
+		pending = append(pending, pod)
+	}
+	if len(unmanaged) > 0 {
+		fmt.Printf("Error: cannot delete pods not managed by a controller (use --force to override): %s\n", strings.Join(unmanaged, ", "))
+		os.Exit(1)
+	}
+
+	deadline := time.Now().Add(*timeout)
+	var evicted []api.Pod
+	for len(pending) > 0 {
+		var blocked []api.Pod
+		for _, pod := range pending {
+			err := client.EvictPod(pod.Namespace, pod.Name)
+			switch {
+			case err == nil:
+				fmt.Printf("Evicting pod %s/%s\n", pod.Namespace, pod.Name)
+				evicted = append(evicted, pod)
+			case strings.Contains(err.Error(), "not found"):
+			case strings.Contains(err.Error(), "disruption budget"):
+				fmt.Printf("Error when evicting pod %s/%s (will retry after %s): %v\n", pod.Namespace, pod.Name, evictionRetryInterval, err)
+				blocked = append(blocked, pod)
+			default:
+				exitOnError(fmt.Sprintf("evicting pod %s/%s", pod.Namespace, pod.Name), err)
+			}
+		}
+		pending = blocked
+		if len(pending) == 0 {
+			break
+		}
+		if time.Now().Add(evictionRetryInterval).After(deadline) {
+			fmt.Printf("Error: drain did not complete within %s, %d pods could not be evicted\n", *timeout, len(pending))
+			os.Exit(1)
+		}
+		time.Sleep(evictionRetryInterval)
+	}
+
+	//等驱逐的 pod 真正被删除，同名的新 pod（比如 StatefulSet 的）UID 不同，不算
+	for _, pod := range evicted {
+		for {
+			current, err := client.GetPod(pod.Namespace, pod.Name)
+			if (err != nil && strings.Contains(err.Error(), "not found")) || (err == nil && current.UID != pod.UID) {
+				fmt.Printf("Pod %s/%s evicted\n", pod.Namespace, pod.Name)
+				break
+			}
+			if time.Now().After(deadline) {
+				fmt.Printf("Error: drain did not complete within %s, pod %s/%s is still being deleted\n", *timeout, pod.Namespace, pod.Name)
+				os.Exit(1)
+			}
+			time.Sleep(time.Second)
+		}
+	}
+	fmt.Printf("Node %s drained\n", nodeName)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"mini-k8s/pkg/api"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// ruleFlags 收集可以重复的 --rule
+type ruleFlags []string
+
+func (r *ruleFlags) String() string     { return strings.Join(*r, " ") }
+func (r *ruleFlags) Set(v string) error { *r = append(*r, v); return nil }
+
+func createIngress(client *api.Client, args []string) {
+	cmd := flag.NewFlagSet("create ingress", flag.ExitOnError)
+	name := cmd.String("name", "", "Name of the ingress")
+	var rules ruleFlags
+	cmd.Var(&rules, "rule", "Rule as host/path=service:port[,tls=secret]; a path ending in * is a Prefix match, otherwise Exact. Repeatable")
+	defaultBackend := cmd.String("default-backend", "", "Backend for requests matching no rule, as service:port")
+	namespace := cmd.String("namespace", DefaultNamespace, "Namespace of the ingress")
+	cmd.Parse(args)
+	if *name == "" || (len(rules) == 0 && *defaultBackend == "") {
+		fmt.Println("Error: --name and at least one --rule or --default-backend are required for creating an ingress")
+		cmd.Usage()
+		os.Exit(1)
+	}
+	ing := &api.Ingress{ObjectMeta: api.ObjectMeta{Name: *name, Namespace: *namespace}}
+	if *defaultBackend != "" {
+		backend, err := parseIngressBackend(*defaultBackend)
+		exitOnError("parsing --default-backend", err)
+		ing.Spec.DefaultBackend = backend
+	}
+	tlsSecrets := map[string][]string{}
+	for _, rule := range rules {
+		exitOnError("parsing --rule", addIngressRule(&ing.Spec, rule, tlsSecrets))
+	}
+	secretNames := make([]string, 0, len(tlsSecrets))
+	for secret := range tlsSecrets {
+		secretNames = append(secretNames, secret)
+	}
+	sort.Strings(secretNames)
+	for _, secret := range secretNames {
+		ing.Spec.TLS = append(ing.Spec.TLS, api.IngressTLS{Hosts: tlsSecrets[secret], SecretName: secret})
+	}
+	created, err := client.CreateIngress(*namespace, ing)
+	exitOnError("creating ingress", err)
+	fmt.Printf("Ingress %s/%s created\n\n", created.Namespace, created.Name)
+}
+
+// addIngressRule 解析 host/path=service:port[,tls=secret]，同一个 host 的 path 合并到一条规则里
+func addIngressRule(spec *api.IngressSpec, rule string, tlsSecrets map[string][]string) error {
+	rule, options, _ := strings.Cut(rule, ",")
+	hostPath, backendSpec, ok := strings.Cut(rule, "=")
+	if !ok {
+		return fmt.Errorf("rule %q must be host/path=service:port", rule)
+	}
+	backend, err := parseIngressBackend(backendSpec)
+	if err != nil {
+		return err
+	}
+	host, path := hostPath, "/"
+	if i := strings.Index(hostPath, "/"); i >= 0 {
+		host, path = hostPath[:i], hostPath[i:]
+	}
+	pathType := api.PathTypeExact
+	if strings.HasSuffix(path, "*") {
+		path, pathType = strings.TrimSuffix(path, "*"), api.PathTypePrefix
+	}
+	if options != "" {
+		key, secret, _ := strings.Cut(options, "=")
+		if key != "tls" {
+			return fmt.Errorf("unknown rule option %q", options)
+		}
+		if host == "" {
+			return fmt.Errorf("rule %q needs a host to use tls", rule)
+		}
+		if secret == "" {
+			return fmt.Errorf("rule %q: tls needs a secret name", rule)
+		}
+		hosts := tlsSecrets[secret]
+		found := false
+		for _, h := range hosts {
+			found = found || h == host
+		}
+		if !found {
+			tlsSecrets[secret] = append(hosts, host)
+		}
+	}
+	entry := api.HTTPIngressPath{Path: path, PathType: pathType, Backend: *backend}
+	for i := range spec.Rules {
+		if spec.Rules[i].Host == host {
+			spec.Rules[i].HTTP.Paths = append(spec.Rules[i].HTTP.Paths, entry)
+			return nil
+		}
+	}
+	spec.Rules = append(spec.Rules, api.IngressRule{Host: host, HTTP: &api.HTTPIngressRuleValue{Paths: []api.HTTPIngressPath{entry}}})
+	return nil
+}
+
+// parseIngressBackend 解析 service:port，port 是数字时按端口号引用，否则按端口名引用
+func parseIngressBackend(spec string) (*api.IngressBackend, error) {
+	service, port, ok := strings.Cut(spec, ":")
+	if !ok || service == "" || port == "" {
+		return nil, fmt.Errorf("backend %q must be service:port", spec)
+	}
+	backend := &api.IngressBackend{Service: &api.IngressServiceBackend{Name: service}}
+	if n, err := strconv.Atoi(port); err == nil {
+		backend.Service.Port.Number = n
+	} else {
+		backend.Service.Port.Name = port
+	}
+	return backend, nil
+}
+
+func printIngressTable(ingresses []api.Ingress) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tHOSTS\tPORTS\tAGE")
+	for _, ing := range ingresses {
+		var hosts []string
+		for _, rule := range ing.Spec.Rules {
+			host := rule.Host
+			if host == "" {
+				host = "*"
+			}
+			hosts = append(hosts, host)
+		}
+		ports := "80"
+		if len(ing.Spec.TLS) > 0 {
+			ports = "80, 443"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", ing.Namespace, ing.Name, orNone(strings.Join(hosts, ",")), ports, age(ing.CreationTimestamp))
+	}
+	w.Flush()
+}
@@ -7,7 +7,9 @@ import (
 	"log"
 	"mini-k8s/pkg/api"
 	"os"
+	"sort"
 	"strings"
+	"text/tabwriter"
 )
 
 const DefaultNamespace = "default"
@@ -24,6 +26,7 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to create client: %v", err)
 	}
+	client.SetFieldManager("kubectl-lite")
 	command := flag.Args()[0]
 	args := flag.Args()[1:]
 
@@ -36,6 +39,30 @@ func main() {
 		handleDeleteCommand(client, args)
 	case "register":
 		handleRegisterNodeCommand(client, args)
+	case "apply":
+		handleApplyCommand(client, args)
+	case "scale":
+		handleScaleCommand(client, args)
+	case "autoscale":
+		handleAutoscaleCommand(client, args)
+	case "top":
+		handleTopCommand(client, args)
+	case "set":
+		handleSetCommand(client, args)
+	case "rollout":
+		handleRolloutCommand(client, args)
+	case "taint":
+		handleTaintCommand(client, args)
+	case "label":
+		handleLabelCommand(client, args)
+	case "cordon":
+		handleCordonCommand(client, args, true)
+	case "uncordon":
+		handleCordonCommand(client, args, false)
+	case "drain":
+		handleDrainCommand(client, args)
+	case "reencrypt":
+		handleReencryptCommand(client, args)
 	default:
 		fmt.Println("Error: Unknown command.")
 		printUsage()
@@ -45,13 +72,70 @@ func main() {
 func printUsage() {
 	fmt.Println("Usage: kubectl-lite --apiserver <url> <command> <subcommand> [flags]")
 	fmt.Println("Commands:")
-	fmt.Println("  create pod --name <name> --image <image> [--namespace <ns>]")
-	fmt.Println("  get pods [--namespace <ns>]")
+	fmt.Println("  create pod --name <name> --image <image> [--command <cmd>] [--restart-policy <policy>] [--namespace <ns>]")
+	fmt.Println("  get pods [--namespace <ns> | --all-namespaces]")
 	fmt.Println("  get pod <name> [--namespace <ns>]")
+	fmt.Println("  get namespaces")
+	fmt.Println("  get namespace <name>")
 	fmt.Println("  get nodes")
 	fmt.Println("  get node <name>")
 	fmt.Println("  delete pod <name> [--namespace <ns>]")
+	fmt.Println("  create namespace --name <name>")
+	fmt.Println("  delete namespace <name>")
 	fmt.Println("  register node --name <name> --address <addr>")
+	fmt.Println("  create replicaset --name <name> --image <image> --replicas <n> [--labels k=v,...] [--requests cpu=<q>,memory=<q>] [--namespace <ns>]")
+	fmt.Println("  get replicasets [name] [--namespace <ns> | --all-namespaces]")
+	fmt.Println("  delete replicaset <name> [--namespace <ns>] [--cascade background|foreground|orphan]")
+	fmt.Println("  scale replicaset <name> --replicas <n> [--namespace <ns>]")
+	fmt.Println("  create deployment --name <name> --image <image> --replicas <n> [--labels k=v,...] [--requests cpu=<q>,memory=<q>] [--strategy RollingUpdate|Recreate] [--namespace <ns>]")
+	fmt.Println("  get deployments [name] [--namespace <ns> | --all-namespaces]")
+	fmt.Println("  delete deployment <name> [--namespace <ns>] [--cascade background|foreground|orphan]")
+	fmt.Println("  scale deployment <name> --replicas <n> [--namespace <ns>]")
+	fmt.Println("  set image deployment|daemonset|statefulset <name> --image <image> [--namespace <ns>]")
+	fmt.Println("  rollout status|history|undo|pause|resume deployment <name> [--namespace <ns>]")
+	fmt.Println("  create job --name <name> --image <image> [--command <cmd>] [--completions <n>] [--parallelism <n>] [--backoff-limit <n>] [--active-deadline <s>] [--ttl <s>]")
+	fmt.Println("  create job --name <name> --from cronjob/<name>")
+	fmt.Println("  create cronjob --name <name> --schedule <cron> --image <image> [--command <cmd>] [--time-zone <tz>] [--concurrency-policy Allow|Forbid|Replace]")
+	fmt.Println("  create daemonset --name <name> --image <image> [--labels k=v,...] [--node-selector k=v,...] [--max-unavailable <n|%>] [--namespace <ns>]")
+	fmt.Println("  get daemonsets [name] [--namespace <ns> | --all-namespaces]")
+	fmt.Println("  delete daemonset <name> [--namespace <ns>] [--cascade background|foreground|orphan]")
+	fmt.Println("  create statefulset --name <name> --image <image> --replicas <n> [--labels k=v,...] [--service-name <svc>] [--pod-management-policy OrderedReady|Parallel] [--partition <n>] [--namespace <ns>]")
+	fmt.Println("  get statefulsets|controllerrevisions [name] [--namespace <ns> | --all-namespaces]")
+	fmt.Println("  delete statefulset <name> [--namespace <ns>] [--cascade background|foreground|orphan]")
+	fmt.Println("  scale statefulset <name> --replicas <n> [--namespace <ns>]")
+	fmt.Println("  create service --name <name> --port <port[:targetPort][/TCP|UDP],...> [--selector k=v,...] [--type ClusterIP|NodePort] [--cluster-ip <ip>|None] [--node-port <n>] [--session-affinity None|ClientIP] [--session-affinity-timeout <s>] [--namespace <ns>]")
+	fmt.Println("  get services|endpoints [name] [--namespace <ns> | --all-namespaces]")
+	fmt.Println("  delete service <name> [--namespace <ns>]")
+	fmt.Println("  create ingress --name <name> --rule <host/path[*]=service:port[,tls=secret]> ... [--default-backend <service:port>] [--namespace <ns>]")
+	fmt.Println("  create secret tls --name <name> --cert <file> --key <file> [--namespace <ns>]")
+	fmt.Println("  create secret generic --name <name> [--from-literal k=v,...] [--from-file [key=]path,...] [--namespace <ns>]")
+	fmt.Println("  create configmap --name <name> [--from-literal k=v,...] [--from-file [key=]path,...] [--namespace <ns>]")
+	fmt.Println("  get ingresses|secrets|configmaps [name] [--namespace <ns> | --all-namespaces]")
+	fmt.Println("  delete ingress|secret|configmap <name> [--namespace <ns>]")
+	fmt.Println("  reencrypt secrets|configmaps")
+	fmt.Println("  create persistentvolume --name <name> --capacity <size> --path <dir> --node <node> [--access-modes RWO,ROX,RWX] [--storage-class <class>] [--reclaim-policy Retain|Delete]")
+	fmt.Println("  create persistentvolumeclaim --name <name> --request <size> [--access-modes RWO,ROX,RWX] [--storage-class <class>] [--volume-name <pv>] [--namespace <ns>]")
+	fmt.Println("  create storageclass --name <name> [--provisioner <name>] [--parameters k=v,...] [--reclaim-policy Delete|Retain] [--volume-binding-mode Immediate|WaitForFirstConsumer] [--default]")
+	fmt.Println("  get persistentvolumes [name]")
+	fmt.Println("  get persistentvolumeclaims [name] [--namespace <ns> | --all-namespaces]")
+	fmt.Println("  get storageclasses [name]")
+	fmt.Println("  delete persistentvolume|persistentvolumeclaim|storageclass <name> [--namespace <ns>]")
+	fmt.Println("  autoscale deployment|replicaset <name> --max <n> [--min <n>] [--cpu-percent <n>] [--namespace <ns>]")
+	fmt.Println("  get horizontalpodautoscalers [name] [--namespace <ns> | --all-namespaces]")
+	fmt.Println("  delete horizontalpodautoscaler <name> [--namespace <ns>]")
+	fmt.Println("  top pods [--namespace <ns> | --all-namespaces]")
+	fmt.Println("  top nodes")
+	fmt.Println("  get leases [name] [--namespace <ns> | --all-namespaces]")
+	fmt.Println("  taint node <name> key[=value]:Effect[-] ...")
+	fmt.Println("  label node <name> key=value|key- ...")
+	fmt.Println("  cordon|uncordon node <name>")
+	fmt.Println("  drain node <name> [--force] [--timeout <duration>]")
+	fmt.Println("  create poddisruptionbudget --name <name> --selector k=v,... --min-available <n|%>|--max-unavailable <n|%> [--namespace <ns>]")
+	fmt.Println("  get poddisruptionbudgets [name] [--namespace <ns> | --all-namespaces]")
+	fmt.Println("  delete poddisruptionbudget <name> [--namespace <ns>]")
+	fmt.Println("  get jobs|cronjobs [name] [--namespace <ns> | --all-namespaces]")
+	fmt.Println("  delete job|cronjob <name> [--namespace <ns>] [--cascade background|foreground|orphan]")
+	fmt.Println("  apply pod|node|replicaset|deployment|daemonset|statefulset|job|cronjob|ingress|secret|configmap|persistentvolume|persistentvolumeclaim|storageclass|horizontalpodautoscaler|poddisruptionbudget -f <file> [--namespace <ns>] [--field-manager <name>] [--force-conflicts]")
 	fmt.Println("Global flags:")
 	fmt.Println("  --apiserver <url>  URL of the API server (default: http://localhost:8055)")
 }
@@ -69,6 +153,8 @@ func handleCreateCommand(client *api.Client, args []string) {
 		podName := createPodCmd.String("name", "", "Name of the pod")
 		podImage := createPodCmd.String("image", "", "Image to use for the pod")
 		podNamespace := createPodCmd.String("namespace", "", "Namespace of the pod")
+		podCommand := createPodCmd.String("command", "", "Shell command the pod runs, e.g. 'sleep 30'")
+		restartPolicy := createPodCmd.String("restart-policy", "", "Restart policy: Always, OnFailure or Never (default Always)")
 		if err := createPodCmd.Parse(commandArgs); err != nil {
 			fmt.Printf("Error parsing 'create pod' flags: %v\n", err)
 			os.Exit(1)
@@ -79,9 +165,8 @@ func handleCreateCommand(client *api.Client, args []string) {
 			os.Exit(1)
 		}
 		pod := api.Pod{
-			Name:      *podName,
-			Image:     *podImage,
-			Namespace: *podNamespace,
+			ObjectMeta: api.ObjectMeta{Name: *podName, Namespace: *podNamespace},
+			PodSpec:    api.PodSpec{Image: *podImage, Command: shellCommand(*podCommand), RestartPolicy: api.RestartPolicy(*restartPolicy)},
 		}
 		createdPod, err := client.CreatePod(*podNamespace, &pod)
 		if err != nil {
@@ -89,9 +174,55 @@ func handleCreateCommand(client *api.Client, args []string) {
 			os.Exit(1)
 		}
 		fmt.Printf("Pod %s/%s created\n\n", createdPod.Namespace, createdPod.Name)
+	case "namespace", "ns":
+		createNsCmd := flag.NewFlagSet("create namespace", flag.ExitOnError)
+		nsName := createNsCmd.String("name", "", "Name of the namespace")
+		if err := createNsCmd.Parse(commandArgs); err != nil {
+			fmt.Printf("Error parsing 'create namespace' flags: %v\n", err)
+			os.Exit(1)
+		}
+		if *nsName == "" {
+			fmt.Println("Error: --name is required for creating a namespace")
+			createNsCmd.Usage()
+			os.Exit(1)
+		}
+		createdNs, err := client.CreateNamespace(&api.Namespace{ObjectMeta: api.ObjectMeta{Name: *nsName}})
+		if err != nil {
+			fmt.Printf("Error creating namespace: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Namespace %s created\n\n", createdNs.Name)
+	case "replicaset", "rs":
+		createReplicaSet(client, commandArgs)
+	case "deployment", "deploy":
+		createDeployment(client, commandArgs)
+	case "job":
+		createJob(client, commandArgs)
+	case "cronjob", "cj":
+		createCronJob(client, commandArgs)
+	case "daemonset", "ds":
+		createDaemonSet(client, commandArgs)
+	case "statefulset", "sts":
+		createStatefulSet(client, commandArgs)
+	case "service", "svc":
+		createService(client, commandArgs)
+	case "ingress", "ing":
+		createIngress(client, commandArgs)
+	case "secret":
+		createSecret(client, commandArgs)
+	case "configmap", "cm":
+		createConfigMap(client, commandArgs)
+	case "persistentvolume", "pv":
+		createPersistentVolume(client, commandArgs)
+	case "persistentvolumeclaim", "pvc":
+		createPersistentVolumeClaim(client, commandArgs)
+	case "storageclass", "sc":
+		createStorageClass(client, commandArgs)
+	case "poddisruptionbudget", "pdb":
+		createPodDisruptionBudget(client, commandArgs)
 	default:
 		fmt.Printf("Error: Unknown resource type for create: %s\n", resourceType)
-		fmt.Println("Supported resource types for create: pod")
+		fmt.Println("Supported resource types for create: pod, namespace, replicaset, deployment, job, cronjob, daemonset, statefulset, service, ingress, secret, configmap, persistentvolume, persistentvolumeclaim, storageclass, poddisruptionbudget")
 		os.Exit(1)
 	}
 
@@ -99,6 +230,8 @@ func handleCreateCommand(client *api.Client, args []string) {
 func handleGetCommand(client *api.Client, args []string) {
 	getPodCmd := flag.NewFlagSet("get pod", flag.ExitOnError)
 	PodNamespace := getPodCmd.String("namespace", DefaultNamespace, "Namespace of the pod")
+	allNamespaces := getPodCmd.Bool("all-namespaces", false, "List pods across all namespaces")
+	getPodCmd.BoolVar(allNamespaces, "A", false, "Shorthand for --all-namespaces")
 	if len(args) < 1 {
 		fmt.Println("Usage: kubectl-lite create <resource_type> [flags]")
 		fmt.Println("Example: kubectl-lite create pod --name mypod ")
@@ -115,7 +248,14 @@ func handleGetCommand(client *api.Client, args []string) {
 	switch resourceType {
 	case "pod", "pods":
 
-		if resourceName == "" {
+		if resourceName == "" && *allNamespaces {
+			pods, err := client.ListAllPods("")
+			if err != nil {
+				fmt.Printf("Error listing pods: %v\n", err)
+				os.Exit(1)
+			}
+			printPodTable(pods)
+		} else if resourceName == "" {
 			pods, err := client.ListPods(*PodNamespace, "")
 			if err != nil {
 				fmt.Printf("Error listing pods: %v\n", err)
@@ -146,15 +286,283 @@ func handleGetCommand(client *api.Client, args []string) {
 			}
 			prettyPrint(node)
 		}
+	case "namespaces", "namespace", "ns":
+		if resourceName == "" {
+			namespaces, err := client.ListNamespaces()
+			if err != nil {
+				fmt.Printf("Error listing namespaces: %v\n", err)
+				os.Exit(1)
+			}
+			prettyPrint(namespaces)
+		} else {
+			ns, err := client.GetNamespace(resourceName)
+			if err != nil {
+				fmt.Printf("Error getting namespace: %v\n", err)
+				os.Exit(1)
+			}
+			prettyPrint(ns)
+		}
+	case "replicasets", "replicaset", "rs":
+		if resourceName == "" && *allNamespaces {
+			replicaSets, err := client.ListAllReplicaSets()
+			exitOnError("listing replicasets", err)
+			prettyPrint(replicaSets)
+		} else if resourceName == "" {
+			replicaSets, err := client.ListReplicaSets(*PodNamespace)
+			exitOnError("listing replicasets", err)
+			prettyPrint(replicaSets)
+		} else {
+			rs, err := client.GetReplicaSet(*PodNamespace, resourceName)
+			exitOnError("getting replicaset", err)
+			prettyPrint(rs)
+		}
+	case "deployments", "deployment", "deploy":
+		if resourceName == "" && *allNamespaces {
+			deployments, err := client.ListAllDeployments()
+			exitOnError("listing deployments", err)
+			prettyPrint(deployments)
+		} else if resourceName == "" {
+			deployments, err := client.ListDeployments(*PodNamespace)
+			exitOnError("listing deployments", err)
+			prettyPrint(deployments)
+		} else {
+			d, err := client.GetDeployment(*PodNamespace, resourceName)
+			exitOnError("getting deployment", err)
+			prettyPrint(d)
+		}
+	case "daemonsets", "daemonset", "ds":
+		if resourceName == "" && *allNamespaces {
+			daemonSets, err := client.ListAllDaemonSets()
+			exitOnError("listing daemonsets", err)
+			prettyPrint(daemonSets)
+		} else if resourceName == "" {
+			daemonSets, err := client.ListDaemonSets(*PodNamespace)
+			exitOnError("listing daemonsets", err)
+			prettyPrint(daemonSets)
+		} else {
+			ds, err := client.GetDaemonSet(*PodNamespace, resourceName)
+			exitOnError("getting daemonset", err)
+			prettyPrint(ds)
+		}
+	case "statefulsets", "statefulset", "sts":
+		if resourceName == "" && *allNamespaces {
+			sets, err := client.ListAllStatefulSets()
+			exitOnError("listing statefulsets", err)
+			prettyPrint(sets)
+		} else if resourceName == "" {
+			sets, err := client.ListStatefulSets(*PodNamespace)
+			exitOnError("listing statefulsets", err)
+			prettyPrint(sets)
+		} else {
+			ss, err := client.GetStatefulSet(*PodNamespace, resourceName)
+			exitOnError("getting statefulset", err)
+			prettyPrint(ss)
+		}
+	case "controllerrevisions", "controllerrevision":
+		if resourceName == "" && *allNamespaces {
+			revisions, err := client.ListAllControllerRevisions()
+			exitOnError("listing controllerrevisions", err)
+			prettyPrint(revisions)
+		} else if resourceName == "" {
+			revisions, err := client.ListControllerRevisions(*PodNamespace)
+			exitOnError("listing controllerrevisions", err)
+			prettyPrint(revisions)
+		} else {
+			cr, err := client.GetControllerRevision(*PodNamespace, resourceName)
+			exitOnError("getting controllerrevision", err)
+			prettyPrint(cr)
+		}
+	case "services", "service", "svc":
+		if resourceName == "" && *allNamespaces {
+			services, err := client.ListAllServices()
+			exitOnError("listing services", err)
+			printServiceTable(services)
+		} else if resourceName == "" {
+			services, err := client.ListServices(*PodNamespace)
+			exitOnError("listing services", err)
+			printServiceTable(services)
+		} else {
+			svc, err := client.GetService(*PodNamespace, resourceName)
+			exitOnError("getting service", err)
+			prettyPrint(svc)
+		}
+	case "endpoints", "ep":
+		if resourceName == "" && *allNamespaces {
+			endpoints, err := client.ListAllEndpoints()
+			exitOnError("listing endpoints", err)
+			printEndpointsTable(endpoints)
+		} else if resourceName == "" {
+			endpoints, err := client.ListEndpoints(*PodNamespace)
+			exitOnError("listing endpoints", err)
+			printEndpointsTable(endpoints)
+		} else {
+			ep, err := client.GetEndpoints(*PodNamespace, resourceName)
+			exitOnError("getting endpoints", err)
+			prettyPrint(ep)
+		}
+	case "ingresses", "ingress", "ing":
+		if resourceName == "" && *allNamespaces {
+			ingresses, err := client.ListAllIngresses()
+			exitOnError("listing ingresses", err)
+			printIngressTable(ingresses)
+		} else if resourceName == "" {
+			ingresses, err := client.ListIngresses(*PodNamespace)
+			exitOnError("listing ingresses", err)
+			printIngressTable(ingresses)
+		} else {
+			ing, err := client.GetIngress(*PodNamespace, resourceName)
+			exitOnError("getting ingress", err)
+			prettyPrint(ing)
+		}
+	case "secrets", "secret":
+		if resourceName == "" && *allNamespaces {
+			secrets, err := client.ListAllSecrets()
+			exitOnError("listing secrets", err)
+			printSecretTable(secrets)
+		} else if resourceName == "" {
+			secrets, err := client.ListSecrets(*PodNamespace)
+			exitOnError("listing secrets", err)
+			printSecretTable(secrets)
+		} else {
+			secret, err := client.GetSecret(*PodNamespace, resourceName)
+			exitOnError("getting secret", err)
+			prettyPrint(secret)
+		}
+	case "configmaps", "configmap", "cm":
+		if resourceName == "" && *allNamespaces {
+			configMaps, err := client.ListAllConfigMaps()
+			exitOnError("listing configmaps", err)
+			printConfigMapTable(configMaps)
+		} else if resourceName == "" {
+			configMaps, err := client.ListConfigMaps(*PodNamespace)
+			exitOnError("listing configmaps", err)
+			printConfigMapTable(configMaps)
+		} else {
+			cm, err := client.GetConfigMap(*PodNamespace, resourceName)
+			exitOnError("getting configmap", err)
+			prettyPrint(cm)
+		}
+	case "persistentvolumes", "persistentvolume", "pv":
+		if resourceName == "" {
+			volumes, err := client.ListPersistentVolumes()
+			exitOnError("listing persistent volumes", err)
+			printPersistentVolumeTable(volumes)
+		} else {
+			pv, err := client.GetPersistentVolume(resourceName)
+			exitOnError("getting persistent volume", err)
+			prettyPrint(pv)
+		}
+	case "persistentvolumeclaims", "persistentvolumeclaim", "pvc":
+		if resourceName == "" && *allNamespaces {
+			claims, err := client.ListAllPersistentVolumeClaims()
+			exitOnError("listing persistent volume claims", err)
+			printPersistentVolumeClaimTable(claims)
+		} else if resourceName == "" {
+			claims, err := client.ListPersistentVolumeClaims(*PodNamespace)
+			exitOnError("listing persistent volume claims", err)
+			printPersistentVolumeClaimTable(claims)
+		} else {
+			pvc, err := client.GetPersistentVolumeClaim(*PodNamespace, resourceName)
+			exitOnError("getting persistent volume claim", err)
+			prettyPrint(pvc)
+		}
+	case "storageclasses", "storageclass", "sc":
+		if resourceName == "" {
+			classes, err := client.ListStorageClasses()
+			exitOnError("listing storage classes", err)
+			printStorageClassTable(classes)
+		} else {
+			sc, err := client.GetStorageClass(resourceName)
+			exitOnError("getting storage class", err)
+			prettyPrint(sc)
+		}
+	case "horizontalpodautoscalers", "horizontalpodautoscaler", "hpa":
+		if resourceName == "" && *allNamespaces {
+			hpas, err := client.ListAllHorizontalPodAutoscalers()
+			exitOnError("listing horizontal pod autoscalers", err)
+			printHorizontalPodAutoscalerTable(hpas)
+		} else if resourceName == "" {
+			hpas, err := client.ListHorizontalPodAutoscalers(*PodNamespace)
+			exitOnError("listing horizontal pod autoscalers", err)
+			printHorizontalPodAutoscalerTable(hpas)
+		} else {
+			hpa, err := client.GetHorizontalPodAutoscaler(*PodNamespace, resourceName)
+			exitOnError("getting horizontal pod autoscaler", err)
+			prettyPrint(hpa)
+		}
+	case "poddisruptionbudgets", "poddisruptionbudget", "pdb":
+		if resourceName == "" && *allNamespaces {
+			pdbs, err := client.ListAllPodDisruptionBudgets()
+			exitOnError("listing pod disruption budgets", err)
+			printPodDisruptionBudgetTable(pdbs)
+		} else if resourceName == "" {
+			pdbs, err := client.ListPodDisruptionBudgets(*PodNamespace)
+			exitOnError("listing pod disruption budgets", err)
+			printPodDisruptionBudgetTable(pdbs)
+		} else {
+			pdb, err := client.GetPodDisruptionBudget(*PodNamespace, resourceName)
+			exitOnError("getting pod disruption budget", err)
+			prettyPrint(pdb)
+		}
+	case "leases", "lease":
+		if resourceName == "" && *allNamespaces {
+			leases, err := client.ListAllLeases()
+			exitOnError("listing leases", err)
+			prettyPrint(leases)
+		} else if resourceName == "" {
+			leases, err := client.ListLeases(*PodNamespace)
+			exitOnError("listing leases", err)
+			prettyPrint(leases)
+		} else {
+			lease, err := client.GetLease(*PodNamespace, resourceName)
+			exitOnError("getting lease", err)
+			prettyPrint(lease)
+		}
+	case "jobs", "job":
+		if resourceName == "" && *allNamespaces {
+			jobs, err := client.ListAllJobs()
+			exitOnError("listing jobs", err)
+			printJobTable(jobs)
+		} else if resourceName == "" {
+			jobs, err := client.ListJobs(*PodNamespace)
+			exitOnError("listing jobs", err)
+			printJobTable(jobs)
+		} else {
+			job, err := client.GetJob(*PodNamespace, resourceName)
+			exitOnError("getting job", err)
+			prettyPrint(job)
+		}
+	case "cronjobs", "cronjob", "cj":
+		if resourceName == "" && *allNamespaces {
+			cronJobs, err := client.ListAllCronJobs()
+			exitOnError("listing cronjobs", err)
+			printCronJobTable(cronJobs)
+		} else if resourceName == "" {
+			cronJobs, err := client.ListCronJobs(*PodNamespace)
+			exitOnError("listing cronjobs", err)
+			printCronJobTable(cronJobs)
+		} else {
+			cj, err := client.GetCronJob(*PodNamespace, resourceName)
+			exitOnError("getting cronjob", err)
+			prettyPrint(cj)
+		}
 	default:
 		fmt.Printf("Unknown resource type for get: %s\n", resourceType)
 		os.Exit(1)
 	}
 }
 
+// cascadePolicies 把 --cascade 的取值对应到 API 的 propagationPolicy
+var cascadePolicies = map[string]api.DeletionPropagation{
+	"background": api.DeletePropagationBackground,
+	"foreground": api.DeletePropagationForeground,
+	"orphan":     api.DeletePropagationOrphan,
+}
+
 func handleDeleteCommand(client *api.Client, args []string) {
 	deleteCmd := flag.NewFlagSet("delete ", flag.ExitOnError)
 	podnamespace := deleteCmd.String("namespace", DefaultNamespace, "Namespace of the pod")
+	cascade := deleteCmd.String("cascade", "background", "What happens to the objects owned by this one: background, foreground or orphan")
 	if len(args) < 2 {
 		fmt.Println("Usage: kubectl-lite delete <resource_type> [flags]")
 		os.Exit(1)
@@ -162,6 +570,11 @@ func handleDeleteCommand(client *api.Client, args []string) {
 	resourceType := args[0]
 	resourceName := args[1]
 	deleteCmd.Parse(args[2:])
+	policy, ok := cascadePolicies[*cascade]
+	if !ok {
+		fmt.Printf("Error: --cascade must be background, foreground or orphan, got %q\n", *cascade)
+		os.Exit(1)
+	}
 	switch resourceType {
 	case "pod":
 		if resourceName == "" {
@@ -175,6 +588,57 @@ func handleDeleteCommand(client *api.Client, args []string) {
 			}
 			fmt.Printf("Pod %s/%s deleted\n\n", *podnamespace, resourceName)
 		}
+	case "namespace", "ns":
+		if err := client.DeleteNamespace(resourceName); err != nil {
+			fmt.Printf("Error deleting namespace: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Namespace %s marked for deletion\n\n", resourceName)
+	case "replicaset", "rs":
+		exitOnError("deleting replicaset", client.DeleteWithPropagation("replicasets", *podnamespace, resourceName, policy))
+		fmt.Printf("ReplicaSet %s/%s deleted\n\n", *podnamespace, resourceName)
+	case "deployment", "deploy":
+		exitOnError("deleting deployment", client.DeleteWithPropagation("deployments", *podnamespace, resourceName, policy))
+		fmt.Printf("Deployment %s/%s deleted\n\n", *podnamespace, resourceName)
+	case "daemonset", "ds":
+		exitOnError("deleting daemonset", client.DeleteWithPropagation("daemonsets", *podnamespace, resourceName, policy))
+		fmt.Printf("DaemonSet %s/%s deleted\n\n", *podnamespace, resourceName)
+	case "statefulset", "sts":
+		exitOnError("deleting statefulset", client.DeleteWithPropagation("statefulsets", *podnamespace, resourceName, policy))
+		fmt.Printf("StatefulSet %s/%s deleted\n\n", *podnamespace, resourceName)
+	case "job":
+		exitOnError("deleting job", client.DeleteWithPropagation("jobs", *podnamespace, resourceName, policy))
+		fmt.Printf("Job %s/%s deleted\n\n", *podnamespace, resourceName)
+	case "cronjob", "cj":
+		exitOnError("deleting cronjob", client.DeleteWithPropagation("cronjobs", *podnamespace, resourceName, policy))
+		fmt.Printf("CronJob %s/%s deleted\n\n", *podnamespace, resourceName)
+	case "service", "svc":
+		exitOnError("deleting service", client.DeleteWithPropagation("services", *podnamespace, resourceName, policy))
+		fmt.Printf("Service %s/%s deleted\n\n", *podnamespace, resourceName)
+	case "ingress", "ing":
+		exitOnError("deleting ingress", client.DeleteIngress(*podnamespace, resourceName))
+		fmt.Printf("Ingress %s/%s deleted\n\n", *podnamespace, resourceName)
+	case "secret":
+		exitOnError("deleting secret", client.DeleteSecret(*podnamespace, resourceName))
+		fmt.Printf("Secret %s/%s deleted\n\n", *podnamespace, resourceName)
+	case "configmap", "cm":
+		exitOnError("deleting configmap", client.DeleteConfigMap(*podnamespace, resourceName))
+		fmt.Printf("ConfigMap %s/%s deleted\n\n", *podnamespace, resourceName)
+	case "persistentvolume", "pv":
+		exitOnError("deleting persistent volume", client.DeletePersistentVolume(resourceName))
+		fmt.Printf("PersistentVolume %s deleted\n\n", resourceName)
+	case "persistentvolumeclaim", "pvc":
+		exitOnError("deleting persistent volume claim", client.DeletePersistentVolumeClaim(*podnamespace, resourceName))
+		fmt.Printf("PersistentVolumeClaim %s/%s deleted\n\n", *podnamespace, resourceName)
+	case "storageclass", "sc":
+		exitOnError("deleting storage class", client.DeleteStorageClass(resourceName))
+		fmt.Printf("StorageClass %s deleted\n\n", resourceName)
+	case "horizontalpodautoscaler", "hpa":
+		exitOnError("deleting horizontal pod autoscaler", client.DeleteHorizontalPodAutoscaler(*podnamespace, resourceName))
+		fmt.Printf("HorizontalPodAutoscaler %s/%s deleted\n\n", *podnamespace, resourceName)
+	case "poddisruptionbudget", "pdb":
+		exitOnError("deleting pod disruption budget", client.DeletePodDisruptionBudget(*podnamespace, resourceName))
+		fmt.Printf("PodDisruptionBudget %s/%s deleted\n\n", *podnamespace, resourceName)
 	default:
 		fmt.Printf("Unknown resource type for delete: %s\n", resourceType)
 		os.Exit(1)
@@ -206,7 +670,7 @@ func handleRegisterNodeCommand(client *api.Client, args []string) {
 		os.Exit(1)
 	}
 
-	node := &api.Node{Name: *nodeName, Address: *nodeAddress, Status: "Ready"} // Assuming Address field exists in api.Node
+	node := &api.Node{ObjectMeta: api.ObjectMeta{Name: *nodeName}, Address: *nodeAddress, Status: "Ready"} // Assuming Address field exists in api.Node
 	createdNode, err := client.CreateNode(node)
 	if err != nil {
 		log.Fatalf("Error registering node: %v", err)
@@ -215,6 +679,143 @@ func handleRegisterNodeCommand(client *api.Client, args []string) {
 
 }
 
+// handleApplyCommand 把文件里的部分对象通过 server-side apply 提交给 API server
+func handleApplyCommand(client *api.Client, args []string) {
+	if len(args) < 1 {
+		fmt.Println("Usage: kubectl-lite apply <resource_type> -f <file> [flags]")
+		os.Exit(1)
+	}
+	resourceType := args[0]
+	applyCmd := flag.NewFlagSet("apply", flag.ExitOnError)
+	file := applyCmd.String("f", "", "JSON file containing the partial object to apply")
+	namespace := applyCmd.String("namespace", "", "Namespace of the object (defaults to the one in the file)")
+	manager := applyCmd.String("field-manager", "kubectl-lite", "Name of the manager that owns the applied fields")
+	force := applyCmd.Bool("force-conflicts", false, "Take ownership of fields owned by other managers instead of failing")
+	if err := applyCmd.Parse(args[1:]); err != nil {
+		fmt.Printf("Error parsing 'apply' flags: %v\n", err)
+		os.Exit(1)
+	}
+	if *file == "" {
+		fmt.Println("Error: -f is required for apply")
+		applyCmd.Usage()
+		os.Exit(1)
+	}
+	patch, err := os.ReadFile(*file)
+	if err != nil {
+		fmt.Printf("Error reading %s: %v\n", *file, err)
+		os.Exit(1)
+	}
+	var meta api.ObjectMeta
+	if err := json.Unmarshal(patch, &meta); err != nil {
+		fmt.Printf("Error parsing %s: %v\n", *file, err)
+		os.Exit(1)
+	}
+	if meta.Name == "" {
+		fmt.Println("Error: the applied object must have a name")
+		os.Exit(1)
+	}
+	if *namespace == "" {
+		*namespace = meta.Namespace
+	}
+	client.SetFieldManager(*manager)
+
+	switch resourceType {
+	case "pod":
+		pod, err := client.ApplyPod(*namespace, meta.Name, patch, *force)
+		if err != nil {
+			fmt.Printf("Error applying pod: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Pod %s/%s applied\n", pod.Namespace, pod.Name)
+	case "node":
+		node, err := client.ApplyNode(meta.Name, patch, *force)
+		if err != nil {
+			fmt.Printf("Error applying node: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Node %s applied\n", node.Name)
+	case "replicaset", "rs":
+		rs, err := client.ApplyReplicaSet(*namespace, meta.Name, patch, *force)
+		exitOnError("applying replicaset", err)
+		fmt.Printf("ReplicaSet %s/%s applied\n", rs.Namespace, rs.Name)
+	case "deployment", "deploy":
+		d, err := client.ApplyDeployment(*namespace, meta.Name, patch, *force)
+		exitOnError("applying deployment", err)
+		fmt.Printf("Deployment %s/%s applied\n", d.Namespace, d.Name)
+	case "daemonset", "ds":
+		ds, err := client.ApplyDaemonSet(*namespace, meta.Name, patch, *force)
+		exitOnError("applying daemonset", err)
+		fmt.Printf("DaemonSet %s/%s applied\n", ds.Namespace, ds.Name)
+	case "statefulset", "sts":
+		ss, err := client.ApplyStatefulSet(*namespace, meta.Name, patch, *force)
+		exitOnError("applying statefulset", err)
+		fmt.Printf("StatefulSet %s/%s applied\n", ss.Namespace, ss.Name)
+	case "job":
+		job, err := client.ApplyJob(*namespace, meta.Name, patch, *force)
+		exitOnError("applying job", err)
+		fmt.Printf("Job %s/%s applied\n", job.Namespace, job.Name)
+	case "cronjob", "cj":
+		cj, err := client.ApplyCronJob(*namespace, meta.Name, patch, *force)
+		exitOnError("applying cronjob", err)
+		fmt.Printf("CronJob %s/%s applied\n", cj.Namespace, cj.Name)
+	case "ingress", "ing":
+		ing, err := client.ApplyIngress(*namespace, meta.Name, patch, *force)
+		exitOnError("applying ingress", err)
+		fmt.Printf("Ingress %s/%s applied\n", ing.Namespace, ing.Name)
+	case "secret":
+		secret, err := client.ApplySecret(*namespace, meta.Name, patch, *force)
+		exitOnError("applying secret", err)
+		fmt.Printf("Secret %s/%s applied\n", secret.Namespace, secret.Name)
+	case "configmap", "cm":
+		cm, err := client.ApplyConfigMap(*namespace, meta.Name, patch, *force)
+		exitOnError("applying configmap", err)
+		fmt.Printf("ConfigMap %s/%s applied\n", cm.Namespace, cm.Name)
+	case "persistentvolume", "pv":
+		pv, err := client.ApplyPersistentVolume(meta.Name, patch, *force)
+		exitOnError("applying persistent volume", err)
+		fmt.Printf("PersistentVolume %s applied\n", pv.Name)
+	case "persistentvolumeclaim", "pvc":
+		pvc, err := client.ApplyPersistentVolumeClaim(*namespace, meta.Name, patch, *force)
+		exitOnError("applying persistent volume claim", err)
+		fmt.Printf("PersistentVolumeClaim %s/%s applied\n", pvc.Namespace, pvc.Name)
+	case "storageclass", "sc":
+		sc, err := client.ApplyStorageClass(meta.Name, patch, *force)
+		exitOnError("applying storage class", err)
+		fmt.Printf("StorageClass %s applied\n", sc.Name)
+	case "horizontalpodautoscaler", "hpa":
+		hpa, err := client.ApplyHorizontalPodAutoscaler(*namespace, meta.Name, patch, *force)
+		exitOnError("applying horizontal pod autoscaler", err)
+		fmt.Printf("HorizontalPodAutoscaler %s/%s applied\n", hpa.Namespace, hpa.Name)
+	case "poddisruptionbudget", "pdb":
+		pdb, err := client.ApplyPodDisruptionBudget(*namespace, meta.Name, patch, *force)
+		exitOnError("applying pod disruption budget", err)
+		fmt.Printf("PodDisruptionBudget %s/%s applied\n", pdb.Namespace, pdb.Name)
+	default:
+		fmt.Printf("Unknown resource type for apply: %s\n", resourceType)
+		os.Exit(1)
+	}
+}
+
+// printPodTable 以表格形式打印所有命名空间的 pod，第一列是 NAMESPACE
+func printPodTable(pods []api.Pod) {
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tIMAGE\tNODE\tIP\tPHASE")
+	for _, pod := range pods {
+		node := pod.NodeName
+		if node == "" {
+			node = "<none>"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", pod.Namespace, pod.Name, pod.Image, node, orNone(pod.PodIP), pod.Phase)
+	}
+	w.Flush()
+}
+
 func prettyPrint(data interface{}) {
 	enc := json.NewEncoder(os.Stdout)
 	enc.SetIndent("", " ")
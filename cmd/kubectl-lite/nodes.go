@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"mini-k8s/pkg/api"
+	"os"
+	"strings"
+)
+
+// handleTaintCommand 给节点添加污点，以 - 结尾表示删除同 key 同 effect 的污点
+func handleTaintCommand(client *api.Client, args []string) {
+	if len(args) < 3 || args[0] != "node" {
+		fmt.Println("Usage: kubectl-lite taint node <name> key[=value]:Effect[-] ...")
+		os.Exit(1)
+	}
+	node, err := client.GetNode(args[1])
+	exitOnError("getting node", err)
+	for _, arg := range args[2:] {
+		remove := strings.HasSuffix(arg, "-")
+		taint, err := api.ParseTaint(strings.TrimSuffix(arg, "-"))
+		exitOnError("parsing taint", err)
+		var taints []api.Taint
+		for _, existing := range node.Taints {
+			if existing.Key != taint.Key || existing.Effect != taint.Effect {
+				taints = append(taints, existing)
+			}
+		}
+		if !remove {
+			taints = append(taints, taint)
+		}
+		node.Taints = taints
+	}
+	exitOnError("updating node", client.UpdateNode(node))
+	fmt.Printf("Node %s tainted\n", node.Name)
+}
+
+// handleLabelCommand 设置节点的 labels，key- 表示删除这个 label
+func handleLabelCommand(client *api.Client, args []string) {
+	if len(args) < 3 || args[0] != "node" {
+		fmt.Println("Usage: kubectl-lite label node <name> key=value|key- ...")
+		os.Exit(1)
+	}
+	node, err := client.GetNode(args[1])
+	exitOnError("getting node", err)
+	if node.Labels == nil {
+		node.Labels = map[string]string{}
+	}
+	for _, arg := range args[2:] {
+		if key, ok := strings.CutSuffix(arg, "-"); ok {
+			delete(node.Labels, key)
+			continue
+		}
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			fmt.Printf("Error: invalid label %q, expected key=value or key-\n", arg)
+			os.Exit(1)
+		}
+		node.Labels[key] = value
+	}
+	exitOnError("updating node", client.UpdateNode(node))
+	fmt.Printf("Node %s labeled\n", node.Name)
+}
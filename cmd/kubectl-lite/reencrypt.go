@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"mini-k8s/pkg/api"
+	"os"
+	"strings"
+)
+
+// reencryptRetries 是对象被并发修改时重新读取再写回的次数
+const reencryptRetries = 5
+
+// handleReencryptCommand 把所有 Secret 或 ConfigMap 原样写回一遍。API server 写入时用当前的主 key 加密，
+// 所以轮换 key 之后执行一次，旧 key 就可以从加密配置里删除了
+func handleReencryptCommand(client *api.Client, args []string) {
+	if len(args) != 1 {
+		fmt.Println("Usage: kubectl-lite reencrypt secrets|configmaps")
+		os.Exit(1)
+	}
+	var names [][2]string
+	var rewrite func(namespace, name string) error
+	switch args[0] {
+	case "secrets", "secret":
+		secrets, err := client.ListAllSecrets()
+		exitOnError("listing secrets", err)
+		for _, secret := range secrets {
+			names = append(names, [2]string{secret.Namespace, secret.Name})
+		}
+		rewrite = func(namespace, name string) error {
+			secret, err := client.GetSecret(namespace, name)
+			if err != nil {
+				return err
+			}
+			_, err = client.UpdateSecret(secret)
+			return err
+		}
+	case "configmaps", "configmap", "cm":
+		cms, err := client.ListAllConfigMaps()
+		exitOnError("listing configmaps", err)
+		for _, cm := range cms {
+			names = append(names, [2]string{cm.Namespace, cm.Name})
+		}
+		rewrite = func(namespace, name string) error {
+			cm, err := client.GetConfigMap(namespace, name)
+			if err != nil {
+				return err
+			}
+			_, err = client.UpdateConfigMap(cm)
+			return err
+		}
+	default:
+		fmt.Printf("Error: cannot reencrypt %s, only secrets and configmaps\n", args[0])
+		os.Exit(1)
+	}
+
+	rewritten, gone, failed := 0, 0, 0
+	for _, n := range names {
+		err := rewrite(n[0], n[1])
+		//list 之后被别人修改过的对象重新读取再写，对象已经被删除就跳过
+		for i := 0; err != nil && strings.Contains(err.Error(), "modified") && i < reencryptRetries; i++ {
+			err = rewrite(n[0], n[1])
+		}
+		switch {
+		case err == nil:
+			rewritten++
+		case strings.Contains(err.Error(), "not found"):
+			gone++
+		default:
+			failed++
+			fmt.Printf("Error reencrypting %s/%s: %v\n", n[0], n[1], err)
+		}
+	}
+	fmt.Printf("Reencrypted %d %s (%d deleted meanwhile, %d failed)\n", rewritten, args[0], gone, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/controller"
+	"mini-k8s/pkg/controller/deployment"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// handleRolloutCommand 处理 rollout status|history|undo|pause|resume，资源可以写成 deployment <name> 或者 deployment/<name>
+func handleRolloutCommand(client *api.Client, args []string) {
+	if len(args) < 2 {
+		fmt.Println("Usage: kubectl-lite rollout status|history|undo|pause|resume deployment <name> [flags]")
+		os.Exit(1)
+	}
+	action := args[0]
+	resourceType, name, rest := args[1], "", args[2:]
+	if i := strings.Index(resourceType, "/"); i >= 0 {
+		resourceType, name = resourceType[:i], resourceType[i+1:]
+	} else if len(rest) > 0 {
+		name, rest = rest[0], rest[1:]
+	}
+	if resourceType != "deployment" && resourceType != "deploy" {
+		fmt.Printf("Unknown resource type for rollout: %s\n", resourceType)
+		os.Exit(1)
+	}
+	if name == "" {
+		fmt.Println("Error: deployment name is required for rollout")
+		os.Exit(1)
+	}
+	cmd := flag.NewFlagSet("rollout "+action, flag.ExitOnError)
+	namespace := cmd.String("namespace", DefaultNamespace, "Namespace of the deployment")
+	watch := cmd.Bool("watch", true, "Keep watching until the rollout finishes (status only)")
+	timeout := cmd.Duration("timeout", 0, "How long to wait for the rollout before giving up, 0 means forever (status only)")
+	toRevision := cmd.Int64("to-revision", 0, "Revision to roll back to, 0 means the previous revision (undo only)")
+	revision := cmd.Int64("revision", 0, "Show the pod template of this revision (history only)")
+	cmd.Parse(rest)
+
+	switch action {
+	case "status":
+		rolloutStatus(client, *namespace, name, *watch, *timeout)
+	case "history":
+		rolloutHistory(client, *namespace, name, *revision)
+	case "undo":
+		rolloutUndo(client, *namespace, name, *toRevision)
+	case "pause", "resume":
+		d, err := client.GetDeployment(*namespace, name)
+		exitOnError("getting deployment", err)
+		paused := action == "pause"
+		if d.Spec.Paused == paused {
+			fmt.Printf("Deployment %s/%s is already %sd\n", *namespace, name, action)
+			return
+		}
+		d.Spec.Paused = paused
+		_, err = client.UpdateDeployment(d)
+		exitOnError("updating deployment", err)
+		fmt.Printf("Deployment %s/%s %sd\n", *namespace, name, action)
+	default:
+		fmt.Printf("Unknown rollout subcommand: %s\n", action)
+		os.Exit(1)
+	}
+}
+
+// rolloutStatus 轮询 Deployment 的 status，直到滚动更新完成或者超过进度期限
+func rolloutStatus(client *api.Client, namespace, name string, watch bool, timeout time.Duration) {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	last := ""
+	for {
+		d, err := client.GetDeployment(namespace, name)
+		exitOnError("getting deployment", err)
+		message, done := rolloutStatusMessage(d)
+		if message != last {
+			fmt.Println(message)
+			last = message
+		}
+		if done {
+			return
+		}
+		if cond := d.Status.GetCondition(api.DeploymentProgressing); cond != nil && cond.Reason == api.ProgressDeadlineExceededReason {
+			fmt.Printf("error: deployment %q exceeded its progress deadline\n", name)
+			os.Exit(1)
+		}
+		if !watch {
+			return
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			fmt.Printf("error: timed out waiting for the rollout of deployment %q\n", name)
+			os.Exit(1)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func rolloutStatusMessage(d *api.Deployment) (string, bool) {
+	if d.Spec.Paused {
+		return fmt.Sprintf("Deployment %q is paused, resume it to continue the rollout", d.Name), false
+	}
+	if d.Status.ObservedGeneration < d.Generation {
+		return fmt.Sprintf("Waiting for deployment %q spec update to be observed...", d.Name), false
+	}
+	s := d.Status
+	switch {
+	case s.UpdatedReplicas < d.Spec.Replicas:
+		return fmt.Sprintf("Waiting for deployment %q rollout to finish: %d out of %d new replicas have been updated...", d.Name, s.UpdatedReplicas, d.Spec.Replicas), false
+	case s.Replicas > s.UpdatedReplicas:
+		return fmt.Sprintf("Waiting for deployment %q rollout to finish: %d old replicas are pending termination...", d.Name, s.Replicas-s.UpdatedReplicas), false
+	case s.AvailableReplicas < s.UpdatedReplicas:
+		return fmt.Sprintf("Waiting for deployment %q rollout to finish: %d of %d updated replicas are available...", d.Name, s.AvailableReplicas, s.UpdatedReplicas), false
+	}
+	return fmt.Sprintf("deployment %q successfully rolled out", d.Name), true
+}
+
+// ownedReplicaSets 返回 Deployment 的所有 ReplicaSet，按版本号从旧到新排序
+func ownedReplicaSets(client *api.Client, d *api.Deployment) []api.ReplicaSet {
+	replicaSets, err := client.ListReplicaSets(d.Namespace)
+	exitOnError("listing replicasets", err)
+	var owned []api.ReplicaSet
+	for _, rs := range replicaSets {
+		if controller.IsControlledBy(&rs.ObjectMeta, d.UID) {
+			owned = append(owned, rs)
+		}
+	}
+	sort.Slice(owned, func(i, j int) bool { return deployment.Revision(&owned[i]) < deployment.Revision(&owned[j]) })
+	return owned
+}
+
+func rolloutHistory(client *api.Client, namespace, name string, revision int64) {
+	d, err := client.GetDeployment(namespace, name)
+	exitOnError("getting deployment", err)
+	owned := ownedReplicaSets(client, d)
+	if revision > 0 {
+		for _, rs := range owned {
+			if deployment.Revision(&rs) == revision {
+				fmt.Printf("deployment %q with revision #%d\n", name, revision)
+				prettyPrint(rs.Spec.Template)
+				return
+			}
+		}
+		fmt.Printf("Error: revision %d not found for deployment %s/%s\n", revision, namespace, name)
+		os.Exit(1)
+	}
+	fmt.Printf("deployment %q\n", name)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "REVISION\tREPLICASET\tIMAGE\tREPLICAS")
+	for _, rs := range owned {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\n", deployment.Revision(&rs), rs.Name, rs.Spec.Template.Spec.Image, rs.Spec.Replicas)
+	}
+	w.Flush()
+}
+
+// rolloutUndo 把 Deployment 的模板换成指定版本 ReplicaSet 的模板，controller 会把那个 ReplicaSet 当作新版本重新扩容
+func rolloutUndo(client *api.Client, namespace, name string, toRevision int64) {
+	d, err := client.GetDeployment(namespace, name)
+	exitOnError("getting deployment", err)
+	if d.Spec.Paused {
+		fmt.Printf("Error: deployment %s/%s is paused, resume it before rolling back\n", namespace, name)
+		os.Exit(1)
+	}
+	owned := ownedReplicaSets(client, d)
+	var target *api.ReplicaSet
+	if toRevision == 0 {
+		//上一个版本是版本号第二大的 ReplicaSet
+		if len(owned) >= 2 {
+			target = &owned[len(owned)-2]
+		}
+	} else {
+		for i := range owned {
+			if deployment.Revision(&owned[i]) == toRevision {
+				target = &owned[i]
+			}
+		}
+	}
+	if target == nil {
+		fmt.Printf("Error: no revision to roll back to for deployment %s/%s\n", namespace, name)
+		os.Exit(1)
+	}
+	template := target.Spec.Template
+	delete(template.Metadata.Labels, api.PodTemplateHashLabel)
+	d.Spec.Template = template
+	_, err = client.UpdateDeployment(d)
+	exitOnError("rolling back deployment", err)
+	fmt.Printf("Deployment %s/%s rolled back to revision %d\n", namespace, name, deployment.Revision(target))
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"mini-k8s/pkg/api"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+func createService(client *api.Client, args []string) {
+	cmd := flag.NewFlagSet("create service", flag.ExitOnError)
+	name := cmd.String("name", "", "Name of the service")
+	selector := cmd.String("selector", "", "Labels of the backend pods, e.g. app=web (default app=<name>)")
+	ports := cmd.String("port", "", "Comma-separated ports as port[:targetPort][/TCP|UDP], e.g. 80:8080,53/UDP")
+	serviceType := cmd.String("type", string(api.ServiceTypeClusterIP), "Service type: ClusterIP or NodePort")
+	clusterIP := cmd.String("cluster-ip", "", "Cluster IP to request, or None for a headless service (default: allocated)")
+	nodePort := cmd.Int("node-port", 0, "Node port for the first port of a NodePort service (default: allocated)")
+	affinity := cmd.String("session-affinity", string(api.ServiceAffinityNone), "Session affinity: None or ClientIP")
+	affinityTimeout := cmd.Int("session-affinity-timeout", 0, "Seconds a client sticks to the same endpoint with ClientIP affinity (default 10800)")
+	namespace := cmd.String("namespace", DefaultNamespace, "Namespace of the service")
+	cmd.Parse(args)
+	if *name == "" || (*ports == "" && *clusterIP != api.ClusterIPNone) {
+		fmt.Println("Error: --name and --port are required for creating a service")
+		cmd.Usage()
+		os.Exit(1)
+	}
+	servicePorts, err := parseServicePorts(*ports)
+	exitOnError("parsing --port", err)
+	if *nodePort != 0 && len(servicePorts) > 0 {
+		servicePorts[0].NodePort = *nodePort
+	}
+	svc := &api.Service{
+		ObjectMeta: api.ObjectMeta{Name: *name, Namespace: *namespace},
+		Spec: api.ServiceSpec{
+			Type:            api.ServiceType(*serviceType),
+			Selector:        templateLabels(*name, *selector),
+			Ports:           servicePorts,
+			ClusterIP:       *clusterIP,
+			SessionAffinity: api.ServiceAffinity(*affinity),
+		},
+	}
+	if *affinityTimeout != 0 {
+		svc.Spec.SessionAffinityConfig = &api.SessionAffinityConfig{ClientIP: &api.ClientIPConfig{TimeoutSeconds: affinityTimeout}}
+	}
+	created, err := client.CreateService(*namespace, svc)
+	exitOnError("creating service", err)
+	fmt.Printf("Service %s/%s created with cluster IP %s\n\n", created.Namespace, created.Name, created.Spec.ClusterIP)
+}
+
+// parseServicePorts 解析 port[:targetPort][/protocol] 列表。有多个端口时按 <protocol>-<port> 给它们起名字
+func parseServicePorts(spec string) ([]api.ServicePort, error) {
+	var ports []api.ServicePort
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		var port api.ServicePort
+		if rest, protocol, ok := strings.Cut(item, "/"); ok {
+			port.Protocol = api.Protocol(strings.ToUpper(protocol))
+			item = rest
+		}
+		portStr, targetStr, hasTarget := strings.Cut(item, ":")
+		p, err := strconv.Atoi(portStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %q", portStr)
+		}
+		port.Port = p
+		if hasTarget {
+			if port.TargetPort, err = strconv.Atoi(targetStr); err != nil {
+				return nil, fmt.Errorf("invalid target port %q", targetStr)
+			}
+		}
+		ports = append(ports, port)
+	}
+	if len(ports) > 1 {
+		for i := range ports {
+			protocol := ports[i].Protocol
+			if protocol == "" {
+				protocol = api.ProtocolTCP
+			}
+			ports[i].Name = strings.ToLower(string(protocol)) + "-" + strconv.Itoa(ports[i].Port)
+		}
+	}
+	return ports, nil
+}
+
+func printServiceTable(services []api.Service) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tTYPE\tCLUSTER-IP\tPORT(S)\tSELECTOR\tAGE")
+	for _, svc := range services {
+		var ports []string
+		for _, port := range svc.Spec.Ports {
+			s := strconv.Itoa(port.Port)
+			if port.NodePort != 0 {
+				s += ":" + strconv.Itoa(port.NodePort)
+			}
+			ports = append(ports, s+"/"+string(port.Protocol))
+		}
+		var selector []string
+		for k, v := range svc.Spec.Selector {
+			selector = append(selector, k+"="+v)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", svc.Namespace, svc.Name, svc.Spec.Type, svc.Spec.ClusterIP,
+			orNone(strings.Join(ports, ",")), orNone(strings.Join(selector, ",")), age(svc.CreationTimestamp))
+	}
+	w.Flush()
+}
+
+func printEndpointsTable(endpoints []api.Endpoints) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tENDPOINTS\tAGE")
+	for _, ep := range endpoints {
+		var addresses []string
+		for _, subset := range ep.Subsets {
+			for _, addr := range subset.Addresses {
+				if len(subset.Ports) == 0 {
+					addresses = append(addresses, addr.IP)
+				}
+				for _, port := range subset.Ports {
+					addresses = append(addresses, addr.IP+":"+strconv.Itoa(port.Port))
+				}
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", ep.Namespace, ep.Name, orNone(strings.Join(addresses, ",")), age(ep.CreationTimestamp))
+	}
+	w.Flush()
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/controller/localpath"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// accessModeNames 是访问模式的缩写，和 kubectl get pv 显示的一样
+var accessModeNames = map[string]api.PersistentVolumeAccessMode{
+	"RWO": api.ReadWriteOnce,
+	"ROX": api.ReadOnlyMany,
+	"RWX": api.ReadWriteMany,
+}
+
+// parseAccessModes 解析逗号分隔的访问模式，可以用全称也可以用 RWO、ROX、RWX
+func parseAccessModes(s string) ([]api.PersistentVolumeAccessMode, error) {
+	var modes []api.PersistentVolumeAccessMode
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if mode, ok := accessModeNames[part]; ok {
+			modes = append(modes, mode)
+			continue
+		}
+		switch mode := api.PersistentVolumeAccessMode(part); mode {
+		case api.ReadWriteOnce, api.ReadOnlyMany, api.ReadWriteMany:
+			modes = append(modes, mode)
+		default:
+			return nil, fmt.Errorf("unknown access mode %q", part)
+		}
+	}
+	return modes, nil
+}
+
+func formatAccessModes(modes []api.PersistentVolumeAccessMode) string {
+	var names []string
+	for _, mode := range modes {
+		for short, m := range accessModeNames {
+			if m == mode {
+				names = append(names, short)
+			}
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func createPersistentVolume(client *api.Client, args []string) {
+	cmd := flag.NewFlagSet("create persistentvolume", flag.ExitOnError)
+	name := cmd.String("name", "", "Name of the persistent volume")
+	capacity := cmd.String("capacity", "", "Size of the volume, e.g. 10Gi")
+	path := cmd.String("path", "", "Absolute path of the volume directory on the node")
+	node := cmd.String("node", "", "Node the volume directory is on")
+	accessModes := cmd.String("access-modes", "RWO", "Comma separated access modes: RWO, ROX, RWX")
+	storageClass := cmd.String("storage-class", "", "Storage class of the volume")
+	reclaimPolicy := cmd.String("reclaim-policy", "", "What happens to the volume when its claim is deleted (default Retain)")
+	cmd.Parse(args)
+	if *name == "" || *capacity == "" || *path == "" || *node == "" {
+		fmt.Println("Error: --name, --capacity, --path and --node are required for creating a persistent volume")
+		cmd.Usage()
+		os.Exit(1)
+	}
+	modes, err := parseAccessModes(*accessModes)
+	exitOnError("parsing --access-modes", err)
+	pv := &api.PersistentVolume{
+		ObjectMeta: api.ObjectMeta{Name: *name},
+		Spec: api.PersistentVolumeSpec{
+			Capacity:                      api.ResourceList{api.ResourceStorage: *capacity},
+			AccessModes:                   modes,
+			PersistentVolumeReclaimPolicy: api.PersistentVolumeReclaimPolicy(*reclaimPolicy),
+			StorageClassName:              *storageClass,
+			Local:                         &api.LocalVolumeSource{Path: *path},
+			NodeAffinity:                  &api.VolumeNodeAffinity{Required: map[string]string{api.LabelHostname: *node}},
+		},
+	}
+	created, err := client.CreatePersistentVolume(pv)
+	exitOnError("creating persistent volume", err)
+	fmt.Printf("PersistentVolume %s created\n\n", created.Name)
+}
+
+func createPersistentVolumeClaim(client *api.Client, args []string) {
+	cmd := flag.NewFlagSet("create persistentvolumeclaim", flag.ExitOnError)
+	name := cmd.String("name", "", "Name of the claim")
+	request := cmd.String("request", "", "Minimum size of the volume, e.g. 1Gi")
+	accessModes := cmd.String("access-modes", "RWO", "Comma separated access modes: RWO, ROX, RWX")
+	storageClass := cmd.String("storage-class", "", "Storage class of the volume")
+	volumeName := cmd.String("volume-name", "", "Bind only to this persistent volume")
+	namespace := cmd.String("namespace", DefaultNamespace, "Namespace of the claim")
+	cmd.Parse(args)
+	if *name == "" || *request == "" {
+		fmt.Println("Error: --name and --request are required for creating a persistent volume claim")
+		cmd.Usage()
+		os.Exit(1)
+	}
+	modes, err := parseAccessModes(*accessModes)
+	exitOnError("parsing --access-modes", err)
+	pvc := &api.PersistentVolumeClaim{
+		ObjectMeta: api.ObjectMeta{Name: *name, Namespace: *namespace},
+		Spec: api.PersistentVolumeClaimSpec{
+			AccessModes: modes,
+			Resources:   api.VolumeResourceRequirements{Requests: api.ResourceList{api.ResourceStorage: *request}},
+			VolumeName:  *volumeName,
+		},
+	}
+	if *storageClass != "" {
+		pvc.Spec.StorageClassName = storageClass
+	}
+	created, err := client.CreatePersistentVolumeClaim(*namespace, pvc)
+	exitOnError("creating persistent volume claim", err)
+	fmt.Printf("PersistentVolumeClaim %s/%s created\n\n", created.Namespace, created.Name)
+}
+
+func createStorageClass(client *api.Client, args []string) {
+	cmd := flag.NewFlagSet("create storageclass", flag.ExitOnError)
+	name := cmd.String("name", "", "Name of the storage class")
+	provisioner := cmd.String("provisioner", localpath.ProvisionerName, "Provisioner that creates volumes of this class")
+	parameters := cmd.String("parameters", "", "Comma-separated key=value parameters for the provisioner, e.g. basePath=/data")
+	reclaimPolicy := cmd.String("reclaim-policy", "", "Reclaim policy of created volumes: Delete or Retain (default Delete)")
+	bindingMode := cmd.String("volume-binding-mode", "", "Immediate or WaitForFirstConsumer (default Immediate)")
+	isDefault := cmd.Bool("default", false, "Use this class for claims that do not set a storage class")
+	cmd.Parse(args)
+	if *name == "" {
+		fmt.Println("Error: --name is required for creating a storage class")
+		cmd.Usage()
+		os.Exit(1)
+	}
+	sc := &api.StorageClass{
+		ObjectMeta:        api.ObjectMeta{Name: *name},
+		Provisioner:       *provisioner,
+		ReclaimPolicy:     api.PersistentVolumeReclaimPolicy(*reclaimPolicy),
+		VolumeBindingMode: api.VolumeBindingMode(*bindingMode),
+	}
+	if *parameters != "" {
+		sc.Parameters = map[string]string{}
+		for _, pair := range strings.Split(*parameters, ",") {
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok || key == "" {
+				fmt.Printf("Error: --parameters entry %q must be key=value\n", pair)
+				os.Exit(1)
+			}
+			sc.Parameters[key] = value
+		}
+	}
+	if *isDefault {
+		sc.Annotations = map[string]string{api.AnnDefaultStorageClass: "true"}
+	}
+	created, err := client.CreateStorageClass(sc)
+	exitOnError("creating storage class", err)
+	fmt.Printf("StorageClass %s created\n\n", created.Name)
+}
+
+func printStorageClassTable(classes []api.StorageClass) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPROVISIONER\tRECLAIMPOLICY\tVOLUMEBINDINGMODE\tAGE")
+	for _, sc := range classes {
+		name := sc.Name
+		if sc.IsDefaultClass() {
+			name += " (default)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", name, sc.Provisioner, sc.ReclaimPolicy, sc.VolumeBindingMode, age(sc.CreationTimestamp))
+	}
+	w.Flush()
+}
+
+func printPersistentVolumeTable(volumes []api.PersistentVolume) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCAPACITY\tACCESS MODES\tRECLAIM POLICY\tSTATUS\tCLAIM\tSTORAGECLASS\tAGE")
+	for _, pv := range volumes {
+		claim := ""
+		if ref := pv.Spec.ClaimRef; ref != nil {
+			claim = ref.Namespace + "/" + ref.Name
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", pv.Name, pv.Spec.Capacity[api.ResourceStorage], formatAccessModes(pv.Spec.AccessModes),
+			pv.Spec.PersistentVolumeReclaimPolicy, pv.Status.Phase, orNone(claim), orNone(pv.Spec.StorageClassName), age(pv.CreationTimestamp))
+	}
+	w.Flush()
+}
+
+func printPersistentVolumeClaimTable(claims []api.PersistentVolumeClaim) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tSTATUS\tVOLUME\tCAPACITY\tACCESS MODES\tSTORAGECLASS\tAGE")
+	for _, pvc := range claims {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", pvc.Namespace, pvc.Name, pvc.Status.Phase, orNone(pvc.Spec.VolumeName),
+			orNone(pvc.Status.Capacity[api.ResourceStorage]), formatAccessModes(pvc.Status.AccessModes), orNone(pvc.ClassName()), age(pvc.CreationTimestamp))
+	}
+	w.Flush()
+}
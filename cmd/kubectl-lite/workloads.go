@@ -0,0 +1,258 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"mini-k8s/pkg/api"
+	"os"
+	"strconv"
+)
+
+// exitOnError 在 err 不为空时打印错误并退出
+func exitOnError(action string, err error) {
+	if err != nil {
+		fmt.Printf("Error %s: %v\n", action, err)
+		os.Exit(1)
+	}
+}
+
+// templateLabels 返回 --labels 指定的 labels，没有指定时使用 app=<name>
+func templateLabels(name, labels string) map[string]string {
+	if labels == "" {
+		return map[string]string{"app": name}
+	}
+	return api.ParseLabels(labels)
+}
+
+func createReplicaSet(client *api.Client, args []string) {
+	cmd := flag.NewFlagSet("create replicaset", flag.ExitOnError)
+	name := cmd.String("name", "", "Name of the replicaset")
+	image := cmd.String("image", "", "Image to use for the pods")
+	replicas := cmd.Int("replicas", 1, "Desired number of pods")
+	labels := cmd.String("labels", "", "Pod labels used as the selector, e.g. app=web (default app=<name>)")
+	requestsFlag := cmd.String("requests", "", "Resource requests of each pod, e.g. cpu=100m,memory=64Mi")
+	namespace := cmd.String("namespace", DefaultNamespace, "Namespace of the replicaset")
+	cmd.Parse(args)
+	if *name == "" || *image == "" {
+		fmt.Println("Error: --name and --image are required for creating a replicaset")
+		cmd.Usage()
+		os.Exit(1)
+	}
+	podLabels := templateLabels(*name, *labels)
+	requests, err := parseRequests(*requestsFlag)
+	exitOnError("parsing --requests", err)
+	rs := &api.ReplicaSet{
+		ObjectMeta: api.ObjectMeta{Name: *name, Namespace: *namespace},
+		Spec: api.ReplicaSetSpec{
+			Replicas: *replicas,
+			Selector: &api.LabelSelector{MatchLabels: podLabels},
+			Template: api.PodTemplateSpec{
+				Metadata: api.ObjectMeta{Labels: podLabels},
+				Spec:     api.PodSpec{Image: *image, Resources: api.ResourceRequirements{Requests: requests}},
+			},
+		},
+	}
+	created, err := client.CreateReplicaSet(*namespace, rs)
+	exitOnError("creating replicaset", err)
+	fmt.Printf("ReplicaSet %s/%s created\n\n", created.Namespace, created.Name)
+}
+
+func createDeployment(client *api.Client, args []string) {
+	cmd := flag.NewFlagSet("create deployment", flag.ExitOnError)
+	name := cmd.String("name", "", "Name of the deployment")
+	image := cmd.String("image", "", "Image to use for the pods")
+	replicas := cmd.Int("replicas", 1, "Desired number of pods")
+	labels := cmd.String("labels", "", "Pod labels used as the selector, e.g. app=web (default app=<name>)")
+	requestsFlag := cmd.String("requests", "", "Resource requests of each pod, e.g. cpu=100m,memory=64Mi")
+	strategy := cmd.String("strategy", string(api.RollingUpdateDeploymentStrategyType), "Deployment strategy: RollingUpdate or Recreate")
+	namespace := cmd.String("namespace", DefaultNamespace, "Namespace of the deployment")
+	cmd.Parse(args)
+	if *name == "" || *image == "" {
+		fmt.Println("Error: --name and --image are required for creating a deployment")
+		cmd.Usage()
+		os.Exit(1)
+	}
+	podLabels := templateLabels(*name, *labels)
+	requests, err := parseRequests(*requestsFlag)
+	exitOnError("parsing --requests", err)
+	d := &api.Deployment{
+		ObjectMeta: api.ObjectMeta{Name: *name, Namespace: *namespace},
+		Spec: api.DeploymentSpec{
+			Replicas: *replicas,
+			Selector: &api.LabelSelector{MatchLabels: podLabels},
+			Template: api.PodTemplateSpec{
+				Metadata: api.ObjectMeta{Labels: podLabels},
+				Spec:     api.PodSpec{Image: *image, Resources: api.ResourceRequirements{Requests: requests}},
+			},
+			Strategy: api.DeploymentStrategy{Type: api.DeploymentStrategyType(*strategy)},
+		},
+	}
+	created, err := client.CreateDeployment(*namespace, d)
+	exitOnError("creating deployment", err)
+	fmt.Printf("Deployment %s/%s created\n\n", created.Namespace, created.Name)
+}
+
+func createDaemonSet(client *api.Client, args []string) {
+	cmd := flag.NewFlagSet("create daemonset", flag.ExitOnError)
+	name := cmd.String("name", "", "Name of the daemonset")
+	image := cmd.String("image", "", "Image to use for the pods")
+	labels := cmd.String("labels", "", "Pod labels used as the selector, e.g. app=agent (default app=<name>)")
+	nodeSelector := cmd.String("node-selector", "", "Only run on nodes with these labels, e.g. disk=ssd")
+	maxUnavailable := cmd.String("max-unavailable", "1", "Nodes that may be updated at the same time, a number or a percentage")
+	namespace := cmd.String("namespace", DefaultNamespace, "Namespace of the daemonset")
+	cmd.Parse(args)
+	if *name == "" || *image == "" {
+		fmt.Println("Error: --name and --image are required for creating a daemonset")
+		cmd.Usage()
+		os.Exit(1)
+	}
+	podLabels := templateLabels(*name, *labels)
+	unavailable := api.FromString(*maxUnavailable)
+	if n, err := strconv.Atoi(*maxUnavailable); err == nil {
+		unavailable = api.FromInt(n)
+	}
+	ds := &api.DaemonSet{
+		ObjectMeta: api.ObjectMeta{Name: *name, Namespace: *namespace},
+		Spec: api.DaemonSetSpec{
+			Selector: &api.LabelSelector{MatchLabels: podLabels},
+			Template: api.PodTemplateSpec{
+				Metadata: api.ObjectMeta{Labels: podLabels},
+				Spec:     api.PodSpec{Image: *image},
+			},
+			UpdateStrategy: api.DaemonSetUpdateStrategy{
+				Type:          api.RollingUpdateDaemonSetStrategyType,
+				RollingUpdate: &api.RollingUpdateDaemonSet{MaxUnavailable: &unavailable},
+			},
+		},
+	}
+	if *nodeSelector != "" {
+		ds.Spec.Template.Spec.NodeSelector = api.ParseLabels(*nodeSelector)
+	}
+	created, err := client.CreateDaemonSet(*namespace, ds)
+	exitOnError("creating daemonset", err)
+	fmt.Printf("DaemonSet %s/%s created\n\n", created.Namespace, created.Name)
+}
+
+func createStatefulSet(client *api.Client, args []string) {
+	cmd := flag.NewFlagSet("create statefulset", flag.ExitOnError)
+	name := cmd.String("name", "", "Name of the statefulset")
+	image := cmd.String("image", "", "Image to use for the pods")
+	replicas := cmd.Int("replicas", 1, "Desired number of pods")
+	labels := cmd.String("labels", "", "Pod labels used as the selector, e.g. app=db (default app=<name>)")
+	serviceName := cmd.String("service-name", "", "Headless service that gives the pods their network identity")
+	policy := cmd.String("pod-management-policy", string(api.OrderedReadyPodManagement), "Pod management policy: OrderedReady or Parallel")
+	partition := cmd.Int("partition", 0, "Only pods with an ordinal at or above the partition are updated")
+	namespace := cmd.String("namespace", DefaultNamespace, "Namespace of the statefulset")
+	cmd.Parse(args)
+	if *name == "" || *image == "" {
+		fmt.Println("Error: --name and --image are required for creating a statefulset")
+		cmd.Usage()
+		os.Exit(1)
+	}
+	podLabels := templateLabels(*name, *labels)
+	ss := &api.StatefulSet{
+		ObjectMeta: api.ObjectMeta{Name: *name, Namespace: *namespace},
+		Spec: api.StatefulSetSpec{
+			Replicas: *replicas,
+			Selector: &api.LabelSelector{MatchLabels: podLabels},
+			Template: api.PodTemplateSpec{
+				Metadata: api.ObjectMeta{Labels: podLabels},
+				Spec:     api.PodSpec{Image: *image},
+			},
+			ServiceName:         *serviceName,
+			PodManagementPolicy: api.PodManagementPolicyType(*policy),
+			UpdateStrategy: api.StatefulSetUpdateStrategy{
+				Type:          api.RollingUpdateStatefulSetStrategyType,
+				RollingUpdate: &api.RollingUpdateStatefulSetStrategy{Partition: partition},
+			},
+		},
+	}
+	created, err := client.CreateStatefulSet(*namespace, ss)
+	exitOnError("creating statefulset", err)
+	fmt.Printf("StatefulSet %s/%s created\n\n", created.Namespace, created.Name)
+}
+
+// handleSetCommand 修改工作负载的模板，目前只支持 set image
+func handleSetCommand(client *api.Client, args []string) {
+	if len(args) < 3 || args[0] != "image" {
+		fmt.Println("Usage: kubectl-lite set image deployment|daemonset|statefulset <name> --image <image> [--namespace <ns>]")
+		os.Exit(1)
+	}
+	resourceType, name := args[1], args[2]
+	cmd := flag.NewFlagSet("set image", flag.ExitOnError)
+	image := cmd.String("image", "", "New image for the pod template")
+	namespace := cmd.String("namespace", DefaultNamespace, "Namespace of the object")
+	cmd.Parse(args[3:])
+	if *image == "" {
+		fmt.Println("Error: --image is required for set image")
+		os.Exit(1)
+	}
+	switch resourceType {
+	case "deployment", "deploy":
+		d, err := client.GetDeployment(*namespace, name)
+		exitOnError("getting deployment", err)
+		d.Spec.Template.Spec.Image = *image
+		_, err = client.UpdateDeployment(d)
+		exitOnError("updating deployment", err)
+		fmt.Printf("Deployment %s/%s image updated to %s\n", *namespace, name, *image)
+	case "daemonset", "ds":
+		ds, err := client.GetDaemonSet(*namespace, name)
+		exitOnError("getting daemonset", err)
+		ds.Spec.Template.Spec.Image = *image
+		_, err = client.UpdateDaemonSet(ds)
+		exitOnError("updating daemonset", err)
+		fmt.Printf("DaemonSet %s/%s image updated to %s\n", *namespace, name, *image)
+	case "statefulset", "sts":
+		ss, err := client.GetStatefulSet(*namespace, name)
+		exitOnError("getting statefulset", err)
+		ss.Spec.Template.Spec.Image = *image
+		_, err = client.UpdateStatefulSet(ss)
+		exitOnError("updating statefulset", err)
+		fmt.Printf("StatefulSet %s/%s image updated to %s\n", *namespace, name, *image)
+	default:
+		fmt.Printf("Unknown resource type for set image: %s\n", resourceType)
+		os.Exit(1)
+	}
+}
+
+func handleScaleCommand(client *api.Client, args []string) {
+	if len(args) < 2 {
+		fmt.Println("Usage: kubectl-lite scale <resource_type> <name> --replicas <n> [--namespace <ns>]")
+		os.Exit(1)
+	}
+	resourceType, name := args[0], args[1]
+	cmd := flag.NewFlagSet("scale", flag.ExitOnError)
+	replicas := cmd.Int("replicas", -1, "Desired number of pods")
+	namespace := cmd.String("namespace", DefaultNamespace, "Namespace of the object")
+	cmd.Parse(args[2:])
+	if *replicas < 0 {
+		fmt.Println("Error: --replicas is required for scale")
+		os.Exit(1)
+	}
+	switch resourceType {
+	case "replicaset", "rs":
+		rs, err := client.GetReplicaSet(*namespace, name)
+		exitOnError("getting replicaset", err)
+		rs.Spec.Replicas = *replicas
+		_, err = client.UpdateReplicaSet(rs)
+		exitOnError("scaling replicaset", err)
+		fmt.Printf("ReplicaSet %s/%s scaled to %d\n", *namespace, name, *replicas)
+	case "deployment", "deploy":
+		d, err := client.GetDeployment(*namespace, name)
+		exitOnError("getting deployment", err)
+		d.Spec.Replicas = *replicas
+		_, err = client.UpdateDeployment(d)
+		exitOnError("scaling deployment", err)
+		fmt.Printf("Deployment %s/%s scaled to %d\n", *namespace, name, *replicas)
+	case "statefulset", "sts":
+		ss, err := client.GetStatefulSet(*namespace, name)
+		exitOnError("getting statefulset", err)
+		ss.Spec.Replicas = *replicas
+		_, err = client.UpdateStatefulSet(ss)
+		exitOnError("scaling statefulset", err)
+		fmt.Printf("StatefulSet %s/%s scaled to %d\n", *namespace, name, *replicas)
+	default:
+		fmt.Printf("Unknown resource type for scale: %s\n", resourceType)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// dataDirName 是指向当前内容目录的符号链接，切换它就一次性替换了所有文件
+	dataDirName    = "..data"
+	newDataDirName = "..data_tmp"
+)
+
+// atomicWriter 把一组文件原子地写进目录。内容先写进一个带时间戳的新目录，再通过重命名把 ..data
+// 符号链接切换到新目录；目录顶层的每个文件都是指向 ..data 里同名文件的符号链接。
+// 读取文件的进程要么看到全部旧内容，要么看到全部新内容，不会读到写了一半的文件
+type atomicWriter struct {
+	dir string
+}
+
+// write 把 payload 写进目录，键是相对路径。内容和现在相同时什么都不做，返回是否写入了新内容
+func (w *atomicWriter) write(payload map[string][]byte) (bool, error) {
+	if err := os.MkdirAll(w.dir, 0o755); err != nil {
+		return false, err
+	}
+	dataLink := filepath.Join(w.dir, dataDirName)
+	oldDir, err := os.Readlink(dataLink)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	if oldDir != "" && payloadEqual(filepath.Join(w.dir, oldDir), payload) {
+		return false, nil
+	}
+
+	newDir, err := os.MkdirTemp(w.dir, time.Now().Format("..2006_01_02_15_04_05."))
+	if err != nil {
+		return false, err
+	}
+	if err := os.Chmod(newDir, 0o755); err != nil {
+		os.RemoveAll(newDir)
+		return false, err
+	}
+	for path, content := range payload {
+		file := filepath.Join(newDir, path)
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			os.RemoveAll(newDir)
+			return false, err
+		}
+		if err := os.WriteFile(file, content, 0o644); err != nil {
+			os.RemoveAll(newDir)
+			return false, err
+		}
+	}
+
+	//重命名符号链接是原子的，先建好临时链接再覆盖 ..data
+	tmpLink := filepath.Join(w.dir, newDataDirName)
+	os.Remove(tmpLink)
+	if err := os.Symlink(filepath.Base(newDir), tmpLink); err != nil {
+		os.RemoveAll(newDir)
+		return false, err
+	}
+	if err := os.Rename(tmpLink, dataLink); err != nil {
+		os.Remove(tmpLink)
+		os.RemoveAll(newDir)
+		return false, err
+	}
+
+	if err := w.updateUserLinks(payload); err != nil {
+		return true, err
+	}
+	if oldDir != "" {
+		os.RemoveAll(filepath.Join(w.dir, oldDir))
+	}
+	return true, nil
+}
+
+// updateUserLinks 给 payload 的每个顶层路径建立指向 ..data 的符号链接，删除不再存在的链接
+func (w *atomicWriter) updateUserLinks(payload map[string][]byte) error {
+	wanted := map[string]bool{}
+	for path := range payload {
+		top, _, _ := strings.Cut(path, "/")
+		wanted[top] = true
+	}
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, "..") || wanted[name] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(w.dir, name)); err != nil {
+			return err
+		}
+	}
+	for top := range wanted {
+		link := filepath.Join(w.dir, top)
+		if _, err := os.Lstat(link); err == nil {
+			continue
+		}
+		if err := os.Symlink(filepath.Join(dataDirName, top), link); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// payloadEqual 判断目录里的文件是否正好是 payload
+func payloadEqual(dir string, payload map[string][]byte) bool {
+	count := 0
+	equal := true
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		want, ok := payload[filepath.ToSlash(rel)]
+		if !ok {
+			equal = false
+			return filepath.SkipAll
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(got, want) {
+			equal = false
+			return filepath.SkipAll
+		}
+		count++
+		return nil
+	})
+	return err == nil && equal && count == len(payload)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mini-k8s/pkg/allocator"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// podIPAM 从节点的 PodCIDR 里给 pod 分配地址。分配结果保存在文件里，kubelet 重启之后正在运行的 pod 保留原来的地址
+type podIPAM struct {
+	path   string
+	subnet *allocator.CIDRRange
+
+	mu sync.Mutex
+	// allocations 是地址到 pod UID 的映射
+	allocations map[string]string
+}
+
+// ipamState 是保存在磁盘上的分配结果
+type ipamState struct {
+	PodCIDR     string            `json:"podCIDR"`
+	Allocations map[string]string `json:"allocations"`
+}
+
+// newPodIPAM 读取 path 里的分配结果。文件记录的网段和 podCIDR 不一致时（比如节点被删除后重新注册）丢弃原来的记录
+func newPodIPAM(path, podCIDR string) (*podIPAM, error) {
+	subnet, err := allocator.ParseCIDRRange(podCIDR)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pod CIDR: %w", err)
+	}
+	ipam := &podIPAM{path: path, subnet: subnet, allocations: map[string]string{}}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		var state ipamState
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		if state.PodCIDR == subnet.String() {
+			for ip, uid := range state.Allocations {
+				ipam.allocations[ip] = uid
+			}
+		} else {
+			log.Printf("Pod CIDR changed from %s to %s, discarding %d pod IP allocations", state.PodCIDR, subnet, len(state.Allocations))
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := ipam.save(); err != nil {
+		return nil, err
+	}
+	return ipam, nil
+}
+
+// allocate 返回 pod 的地址，还没有时分配一个。第一个地址留给节点上的网桥，不分配给 pod
+func (ipam *podIPAM) allocate(uid string) (string, error) {
+	ipam.mu.Lock()
+	defer ipam.mu.Unlock()
+	for ip, owner := range ipam.allocations {
+		if owner == uid {
+			return ip, nil
+		}
+	}
+	for offset := 1; offset < ipam.subnet.Size(); offset++ {
+		ip := ipam.subnet.Value(offset)
+		if _, used := ipam.allocations[ip]; used {
+			continue
+		}
+		ipam.allocations[ip] = uid
+		if err := ipam.save(); err != nil {
+			delete(ipam.allocations, ip)
+			return "", err
+		}
+		return ip, nil
+	}
+	return "", fmt.Errorf("no free pod IP left in %s", ipam.subnet)
+}
+
+// claim 把 pod 已经在使用的地址记为它的，用于分配记录丢失之后 kubelet 重新接管正在运行的 pod
+func (ipam *podIPAM) claim(uid, ip string) error {
+	ipam.mu.Lock()
+	defer ipam.mu.Unlock()
+	if !ipam.subnet.Contains(ip) {
+		return fmt.Errorf("%s is not in pod CIDR %s", ip, ipam.subnet)
+	}
+	if owner, used := ipam.allocations[ip]; used {
+		if owner != uid {
+			return fmt.Errorf("%s is already allocated to pod %s", ip, owner)
+		}
+		return nil
+	}
+	ipam.allocations[ip] = uid
+	if err := ipam.save(); err != nil {
+		delete(ipam.allocations, ip)
+		return err
+	}
+	return nil
+}
+
+// releaseExcept 释放不属于 uids 中任何 pod 的地址
+func (ipam *podIPAM) releaseExcept(uids map[string]bool) {
+	ipam.mu.Lock()
+	defer ipam.mu.Unlock()
+	released := map[string]string{}
+	for ip, uid := range ipam.allocations {
+		if !uids[uid] {
+			released[ip] = uid
+			delete(ipam.allocations, ip)
+		}
+	}
+	if len(released) == 0 {
+		return
+	}
+	if err := ipam.save(); err != nil {
+		//保存失败时保留记录，下次同步再释放
+		for ip, uid := range released {
+			ipam.allocations[ip] = uid
+		}
+		log.Printf("Error saving pod IP allocations: %v", err)
+		return
+	}
+	for ip, uid := range released {
+		log.Printf("Released IP %s of pod %s", ip, uid)
+	}
+}
+
+// save 先写临时文件再改名，kubelet 在写入过程中退出也不会留下不完整的文件
+func (ipam *podIPAM) save() error {
+	data, err := json.MarshalIndent(ipamState{PodCIDR: ipam.subnet.String(), Allocations: ipam.allocations}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := ipam.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ipam.path)
+}
+
+// resolveHostIP 返回节点地址里的 IP，地址是主机名时解析它，优先使用 IPv4
+func resolveHostIP(address string) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String()
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil || len(ips) == 0 {
+		log.Printf("Cannot resolve node address %s: %v", address, err)
+		return ""
+	}
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			return ip.String()
+		}
+	}
+	return ips[0].String()
+}
@@ -1,18 +1,30 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
+	"fmt"
 	"log"
 	"mini-k8s/pkg/api"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
-const DefaultNamespace = "default"
-
 type Kubelet struct {
 	NodeName    string `json:"nodeName"`
 	NodeAddress string `json:"nodeAddress"`
+	Labels      map[string]string
+	Taints      []api.Taint
 	APIclient   *api.Client
+	runtime     *processRuntime
+	// RootDir 是 kubelet 保存本地状态的目录，比如 pod 地址的分配记录和 pod 的卷
+	RootDir string
+	// hostIP 是节点地址解析出来的 IP，上报为 pod 的 hostIP
+	hostIP string
+	// ipam 从节点的 PodCIDR 里给 pod 分配地址；节点没有 PodCIDR 时为 nil，pod 使用节点的地址
+	ipam *podIPAM
 }
 
 func NewKubelet(name string, address string, apiserverURl string) (*Kubelet, error) {
@@ -22,18 +34,27 @@ func NewKubelet(name string, address string, apiserverURl string) (*Kubelet, err
 		log.Printf("Error creating  client: %s", err)
 		return nil, err
 	}
+	client.SetFieldManager("kubelet")
 	return &Kubelet{
 		NodeName:    name,
 		NodeAddress: address,
 		APIclient:   client,
+		runtime:     newProcessRuntime(),
+		hostIP:      resolveHostIP(address),
 	}, nil
 }
 
 func (kubelet *Kubelet) registerNode() error {
+	now := time.Now()
 	node := &api.Node{
-		Name:    kubelet.NodeName,
-		Address: kubelet.NodeAddress,
-		Status:  api.NodeReady,
+		ObjectMeta:        api.ObjectMeta{Name: kubelet.NodeName, Labels: map[string]string{api.LabelHostname: kubelet.NodeName}},
+		Address:           kubelet.NodeAddress,
+		Status:            api.NodeReady,
+		Taints:            kubelet.Taints,
+		LastHeartbeatTime: &now,
+	}
+	for k, v := range kubelet.Labels {
+		node.Labels[k] = v
 	}
 	createNode, err := kubelet.APIclient.CreateNode(node)
 	//kubelet是无状态的，如果重启了 注册节点并不意味着 系统出问题了 可能是已经注册过了
@@ -44,27 +65,108 @@ func (kubelet *Kubelet) registerNode() error {
 	//创建失败 → 更新节点状态为 Ready
 	//系统恢复正常
 	if err != nil {
-		log.Printf("Failed to register node %s, attempting to update: %v", kubelet.NodeName, err)
-		if errUpdate := kubelet.APIclient.UpdateNode(node); errUpdate != nil {
-			log.Printf("Error updating node: %s", errUpdate)
-			return errUpdate
+		if !strings.Contains(err.Error(), "already exists") {
+			log.Printf("Error registering node %s: %v", kubelet.NodeName, err)
+			return err
+		}
+		log.Printf("Node %s is already registered, updating it", kubelet.NodeName)
+		if errApply := kubelet.reregisterNode(node); errApply != nil {
+			log.Printf("Error updating node: %s", errApply)
+			return errApply
 		}
 		log.Printf("Node %s updated successfully after initial registration failure.", kubelet.NodeName)
-		return nil
+		return kubelet.setupPodNetwork()
 	}
 	log.Printf("Node %s registered successfully with address %s and status %s", createNode.Name, createNode.Address, createNode.Status)
+	return kubelet.setupPodNetwork()
+}
+
+// reregisterNode 在节点已经存在时重新上报地址、labels 和 Ready 状态。和心跳一样只 apply 这些字段，
+// 不会覆盖 node lifecycle controller 加上的不可用污点或者 cordon；--register-with-taints 只在创建节点时生效
+func (kubelet *Kubelet) reregisterNode(node *api.Node) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"name":              node.Name,
+		"labels":            node.Labels,
+		"address":           node.Address,
+		"status":            node.Status,
+		"lastHeartbeatTime": node.LastHeartbeatTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = kubelet.APIclient.ApplyNode(kubelet.NodeName, patch, true)
+	return err
+}
+
+// setupPodNetwork 读取 apiserver 在注册时分给节点的 PodCIDR，从磁盘上恢复 pod 地址的分配记录
+func (kubelet *Kubelet) setupPodNetwork() error {
+	node, err := kubelet.APIclient.GetNode(kubelet.NodeName)
+	if err != nil {
+		return err
+	}
+	if node.PodCIDR == "" {
+		log.Printf("Node %s has no pod CIDR, pods will use the node address %s", kubelet.NodeName, kubelet.hostIP)
+		return nil
+	}
+	ipam, err := newPodIPAM(filepath.Join(kubelet.RootDir, "pod-ips.json"), node.PodCIDR)
+	if err != nil {
+		return fmt.Errorf("setting up pod IP allocation: %w", err)
+	}
+	kubelet.ipam = ipam
+	log.Printf("Node %s allocates pod IPs from %s", kubelet.NodeName, node.PodCIDR)
+	return nil
+}
+
+// assignPodIP 给 pod 分配地址；节点没有 PodCIDR 时 pod 使用节点的地址
+func (kubelet *Kubelet) assignPodIP(pod *api.Pod) error {
+	pod.HostIP = kubelet.hostIP
+	if kubelet.ipam == nil {
+		pod.PodIP = kubelet.hostIP
+		return nil
+	}
+	ip, err := kubelet.ipam.allocate(pod.UID)
+	if err != nil {
+		return err
+	}
+	pod.PodIP = ip
 	return nil
 }
+
+// updateNodeStatus 上报节点 Ready 和心跳时间。只 apply 这两个字段，不会覆盖 controller 加在节点上的污点；
+// 节点被 node lifecycle controller 标记为 NotReady 后 status 字段归它管理，所以要强制取回所有权
+func (kubelet *Kubelet) updateNodeStatus() {
+	patch, err := json.Marshal(map[string]interface{}{
+		"name":              kubelet.NodeName,
+		"status":            api.NodeReady,
+		"lastHeartbeatTime": time.Now(),
+	})
+	if err != nil {
+		log.Printf("[%s] Error encoding node status: %v", kubelet.NodeName, err)
+		return
+	}
+	if _, err := kubelet.APIclient.ApplyNode(kubelet.NodeName, patch, true); err != nil {
+		log.Printf("[%s] Error updating node status: %v", kubelet.NodeName, err)
+	}
+}
+
 func (kubelet *Kubelet) syncPods() {
 	log.Printf("[%s] syncing pods", kubelet.NodeName)
-	pods, err := kubelet.APIclient.ListPods(DefaultNamespace, "")
+	//pod 可能在任何命名空间里，按 NodeName 过滤出属于本节点的
+	pods, err := kubelet.APIclient.ListAllPods("")
 	if err != nil {
 		log.Printf("Error listing pods: %s", err)
 		return
 	}
+	known := map[string]bool{}
+	//keepDirs 是还需要本地目录的 pod，其它 pod 的目录在同步结束后删除
+	keepDirs := map[string]bool{}
+	src := newConfigSource(kubelet.APIclient)
+	//holdsIP 是还需要地址的 pod，其它 pod 的地址在同步结束后释放
+	holdsIP := map[string]bool{}
 	for _, pod := range pods {
 		//先检查这个pod 是不是属于这个NOde
 		if pod.NodeName == kubelet.NodeName {
+			known[pod.UID] = true
 			//检查这个pod是不是属于被删除状态
 			if pod.DeletionTimestamp != nil {
 				//一旦有了 DeletionTimestamp，Pod 通常会处于以下两个阶段之一：
@@ -74,7 +176,9 @@ func (kubelet *Kubelet) syncPods() {
 				//任何运行中的状态（Running/Pending）： 即便 Pod 正在 Running，
 				//只要 DeletionTimestamp 一出现，它的逻辑身份就立刻变成了“待销毁”。Kubelet
 				//必须停止一切正常业务，转而处理终止逻辑。
-				if pod.Phase != api.PodSucceeded && pod.Phase != api.PodDeleted && pod.Phase != api.PodFailed {
+				//已经结束（Succeeded/Failed）的 pod 也需要标记为 Deleted，删除才算完成
+				kubelet.runtime.kill(pod.UID)
+				if pod.Phase != api.PodDeleted {
 					log.Printf("[%s] Detected terminating pod %s. Simulating cleanup and marking as Deleted.", kubelet.NodeName, pod.Name)
 					updatePod := pod
 					//执行物理上的消除
@@ -90,12 +194,42 @@ func (kubelet *Kubelet) syncPods() {
 				continue
 			}
 
+			keepDirs[pod.UID] = true
+			if pod.Phase == api.PodScheduled || pod.Phase == api.PodRunning {
+				holdsIP[pod.UID] = true
+			}
 			switch pod.Phase {
 			case api.PodScheduled:
 				log.Printf("[%s] Found scheduled pod %s. 'Starting' it...", kubelet.NodeName, pod.Name)
 				updatePod := pod
+				if err := kubelet.assignPodIP(&updatePod); err != nil {
+					log.Printf("[%s] Error assigning an IP to pod %s, will retry: %v", kubelet.NodeName, pod.Name, err)
+					continue
+				}
+				//引用的 ConfigMap 或 Secret 还不存在时 pod 留在 Scheduled，等它们创建之后再启动
+				if err := kubelet.setupVolumes(&updatePod, src); err != nil {
+					kubelet.reportConfigError(&updatePod, err)
+					continue
+				}
+				env, err := kubelet.containerEnv(&updatePod, src)
+				if err != nil {
+					kubelet.reportConfigError(&updatePod, err)
+					continue
+				}
 				//使用容器运行时 拉镜像 跑起来....
 				updatePod.Phase = api.PodRunning
+				updatePod.Reason = ""
+				updatePod.Message = ""
+				now := time.Now()
+				updatePod.StartTime = &now
+				if len(pod.Command) > 0 {
+					if err := kubelet.runtime.start(podRef(&pod), pod.Command, env, kubelet.podRoot(&pod)); err != nil {
+						log.Printf("[%s] Error starting command of pod %s: %v", kubelet.NodeName, pod.Name, err)
+						updatePod.Phase = api.PodFailed
+						updatePod.Reason = "StartError"
+						updatePod.Message = err.Error()
+					}
+				}
 				if err := kubelet.APIclient.UpdatePod(&updatePod); err != nil {
 					log.Printf("[%s] Error updating pod %s to Running: %v", kubelet.NodeName, pod.Name, err)
 				} else {
@@ -103,7 +237,18 @@ func (kubelet *Kubelet) syncPods() {
 				}
 
 			case api.PodRunning:
-				break
+				kubelet.syncPodIP(&pod)
+				//ConfigMap 和 Secret 变化之后更新卷里的文件，环境变量只在进程启动时读取
+				if err := kubelet.setupVolumes(&pod, src); err != nil {
+					log.Printf("[%s] Error refreshing volumes of pod %s, keeping the current files: %v", kubelet.NodeName, pod.Name, err)
+				}
+				if message := kubelet.emptyDirOverLimit(&pod); message != "" {
+					kubelet.evictPod(pod, message)
+					continue
+				}
+				if len(pod.Command) > 0 {
+					kubelet.syncProcess(pod, src)
+				}
 
 			case api.PodTerminating:
 				log.Printf("[%s] Pod %s found in Terminating phase. Processing termination.", kubelet.NodeName, pod.Name)
@@ -141,13 +286,134 @@ func (kubelet *Kubelet) syncPods() {
 
 		}
 	}
+	kubelet.runtime.killUnknown(known)
+	kubelet.cleanupPodDirs(keepDirs)
+	if kubelet.ipam != nil {
+		kubelet.ipam.releaseExcept(holdsIP)
+	}
+}
+
+func podRef(pod *api.Pod) api.PodReference {
+	return api.PodReference{Name: pod.Name, Namespace: pod.Namespace, UID: pod.UID}
+}
+
+// podEnv 返回传给 pod 进程的环境变量。进程和节点共用网络，可以监听 POD_IP 让每个 pod 有自己的地址
+func podEnv(pod *api.Pod) []string {
+	return []string{
+		"POD_NAME=" + pod.Name,
+		"POD_NAMESPACE=" + pod.Namespace,
+		"POD_IP=" + pod.PodIP,
+		"HOST_IP=" + pod.HostIP,
+	}
+}
+
+// reportConfigError 记录 pod 因为配置错误不能启动的原因，原因没有变化时不重复更新
+func (kubelet *Kubelet) reportConfigError(pod *api.Pod, err error) {
+	log.Printf("[%s] Cannot start pod %s, will retry: %v", kubelet.NodeName, pod.Name, err)
+	if pod.Reason == "CreateContainerConfigError" && pod.Message == err.Error() {
+		return
+	}
+	pod.Reason = "CreateContainerConfigError"
+	pod.Message = err.Error()
+	if err := kubelet.APIclient.UpdatePod(pod); err != nil {
+		log.Printf("[%s] Error reporting the start failure of pod %s: %v", kubelet.NodeName, pod.Name, err)
+	}
+}
+
+// evictPod 结束 pod 的进程并把它标记为 Failed，原因是 Evicted。pod 的目录保留到 pod 被删除
+func (kubelet *Kubelet) evictPod(pod api.Pod, message string) {
+	log.Printf("[%s] Evicting pod %s: %s", kubelet.NodeName, pod.Name, message)
+	kubelet.runtime.kill(pod.UID)
+	pod.Phase = api.PodFailed
+	pod.Reason = "Evicted"
+	pod.Message = message
+	if err := kubelet.APIclient.UpdatePod(&pod); err != nil {
+		log.Printf("[%s] Error marking pod %s as evicted: %v", kubelet.NodeName, pod.Name, err)
+	}
+}
+
+// syncPodIP 确保 Running pod 的地址记录在分配结果里，分配记录丢失时重新接管它的地址；没有地址的 pod 补上一个
+func (kubelet *Kubelet) syncPodIP(pod *api.Pod) {
+	if pod.PodIP != "" {
+		if kubelet.ipam != nil {
+			if err := kubelet.ipam.claim(pod.UID, pod.PodIP); err != nil {
+				log.Printf("[%s] Cannot keep IP %s of pod %s: %v", kubelet.NodeName, pod.PodIP, pod.Name, err)
+			}
+		}
+		return
+	}
+	if err := kubelet.assignPodIP(pod); err != nil {
+		log.Printf("[%s] Error assigning an IP to pod %s: %v", kubelet.NodeName, pod.Name, err)
+		return
+	}
+	if err := kubelet.APIclient.UpdatePod(pod); err != nil {
+		log.Printf("[%s] Error reporting IP of pod %s: %v", kubelet.NodeName, pod.Name, err)
+		return
+	}
+	//UpdatePod 不返回新的 resourceVersion，重新读取后面的更新才不会冲突
+	if latest, err := kubelet.APIclient.GetPod(pod.Namespace, pod.Name); err == nil {
+		*pod = *latest
+	}
+	log.Printf("[%s] Pod %s has IP %s.", kubelet.NodeName, pod.Name, pod.PodIP)
+}
 
+// syncProcess 检查 Running pod 的进程：按 restartPolicy 在原地重启退出的进程，或者把 pod 标记为 Succeeded/Failed
+func (kubelet *Kubelet) syncProcess(pod api.Pod, src *configSource) {
+	tracked, exited, exitCode := kubelet.runtime.status(pod.UID)
+	if tracked && !exited {
+		return
+	}
+	updatePod := pod
+	policy := pod.RestartPolicy
+	if policy == "" {
+		policy = api.RestartPolicyAlways
+	}
+	switch {
+	//kubelet 重启之后不知道原来的进程怎么样了，重新启动它
+	case !tracked:
+		log.Printf("[%s] Pod %s has no running process, starting its command again.", kubelet.NodeName, pod.Name)
+	case exitCode == 0 && policy != api.RestartPolicyAlways:
+		kubelet.runtime.kill(pod.UID)
+		updatePod.Phase = api.PodSucceeded
+		updatePod.Reason = "Completed"
+		updatePod.Message = ""
+	case exitCode != 0 && policy == api.RestartPolicyNever:
+		kubelet.runtime.kill(pod.UID)
+		updatePod.Phase = api.PodFailed
+		updatePod.Reason = "Error"
+		updatePod.Message = fmt.Sprintf("command exited with code %d", exitCode)
+	default:
+		updatePod.RestartCount++
+		log.Printf("[%s] Command of pod %s exited with code %d, restarting it (restart policy %s).", kubelet.NodeName, pod.Name, exitCode, policy)
+	}
+	if updatePod.Phase == api.PodRunning {
+		env, err := kubelet.containerEnv(&updatePod, src)
+		if err != nil {
+			log.Printf("[%s] Cannot restart command of pod %s, will retry: %v", kubelet.NodeName, pod.Name, err)
+			return
+		}
+		if err := kubelet.runtime.start(podRef(&pod), pod.Command, env, kubelet.podRoot(&pod)); err != nil {
+			log.Printf("[%s] Error restarting command of pod %s: %v", kubelet.NodeName, pod.Name, err)
+			updatePod.Phase = api.PodFailed
+			updatePod.Reason = "StartError"
+			updatePod.Message = err.Error()
+		}
+	}
+	if err := kubelet.APIclient.UpdatePod(&updatePod); err != nil {
+		log.Printf("[%s] Error updating pod %s after its command exited: %v", kubelet.NodeName, pod.Name, err)
+		return
+	}
+	log.Printf("[%s] Pod %s is now %s (restarts: %d).", kubelet.NodeName, pod.Name, updatePod.Phase, updatePod.RestartCount)
 }
 func main() {
 	nodeName := flag.String("name", "", "Name of this node (kubelet)")
-	nodeAddress := flag.String("address", "localhost:10250", "Address of this node (e.g. IP or hostname, port is informational for mock)")
+	nodeAddress := flag.String("address", "localhost:10250", "Address of this node (IP or hostname and port); /stats/summary and /healthz are served on it")
 	apiServerURL := flag.String("apiserver", "http://localhost:8055", "URL of the API server")
 	syncInterval := flag.Duration("sync-interval", 10*time.Second, "Pod synchronization interval")
+	statusUpdateFrequency := flag.Duration("node-status-update-frequency", 10*time.Second, "Interval between node heartbeats sent to the API server")
+	nodeLabels := flag.String("node-labels", "", "Labels to add when registering the node, e.g. disk=ssd,zone=a")
+	registerTaints := flag.String("register-with-taints", "", "Taints to add when registering the node, e.g. dedicated=gpu:NoSchedule,other:NoExecute")
+	rootDir := flag.String("root-dir", "", "Directory for kubelet state such as pod IP allocations and pod volumes (default <tmp>/mini-k8s-kubelet/<name>)")
 	flag.Parse()
 	if *nodeName == "" {
 		log.Fatalf("Node name must be specified using -name flag")
@@ -157,10 +423,33 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to create Kubelet: %v", err)
 	}
+	kubelet.RootDir = *rootDir
+	if kubelet.RootDir == "" {
+		kubelet.RootDir = filepath.Join(os.TempDir(), "mini-k8s-kubelet", *nodeName)
+	}
+	if *nodeLabels != "" {
+		kubelet.Labels = api.ParseLabels(*nodeLabels)
+	}
+	if *registerTaints != "" {
+		for _, s := range strings.Split(*registerTaints, ",") {
+			taint, err := api.ParseTaint(s)
+			if err != nil {
+				log.Fatalf("Invalid -register-with-taints: %v", err)
+			}
+			kubelet.Taints = append(kubelet.Taints, taint)
+		}
+	}
 	if err := kubelet.registerNode(); err != nil {
 		log.Fatalf("Failed to register node with API server: %v. Ensure API server is running.", err)
 	}
 
+	go kubelet.serveStats()
+	go func() {
+		for {
+			time.Sleep(*statusUpdateFrequency)
+			kubelet.updateNodeStatus()
+		}
+	}()
 	log.Printf("Kubelet for node '%s' registered. Starting pod sync loop with interval %v.", *nodeName, *syncInterval)
 	for {
 		kubelet.syncPods()
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"mini-k8s/pkg/api"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// processRuntime 在节点上把设置了 command 的 pod 作为本地进程运行，按 pod UID 记录进程和它的退出码
+type processRuntime struct {
+	mu        sync.Mutex
+	processes map[string]*process
+}
+
+type process struct {
+	pod      api.PodReference
+	cmd      *exec.Cmd
+	exited   bool
+	exitCode int
+}
+
+func newProcessRuntime() *processRuntime {
+	return &processRuntime{processes: make(map[string]*process)}
+}
+
+// start 在 dir 里启动 pod 的 command，dir 为空时使用 kubelet 的工作目录。env 追加在 kubelet 自己的环境变量后面，
+// 进程退出后记录退出码
+func (r *processRuntime) start(pod api.PodReference, command []string, env []string, dir string) error {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	p := &process{pod: pod, cmd: cmd}
+	r.mu.Lock()
+	r.processes[pod.UID] = p
+	r.mu.Unlock()
+	go func() {
+		err := cmd.Wait()
+		code := 0
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.ExitCode()
+		} else if err != nil {
+			code = -1
+		}
+		r.mu.Lock()
+		p.exited = true
+		p.exitCode = code
+		r.mu.Unlock()
+	}()
+	return nil
+}
+
+// status 返回进程是否被跟踪、是否已经退出以及退出码
+func (r *processRuntime) status(uid string) (tracked, exited bool, exitCode int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.processes[uid]
+	if !ok {
+		return false, false, 0
+	}
+	return true, p.exited, p.exitCode
+}
+
+// running 返回还在运行的进程的 PID，键是进程所属的 pod
+func (r *processRuntime) running() map[api.PodReference]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pids := map[api.PodReference]int{}
+	for _, p := range r.processes {
+		if !p.exited {
+			pids[p.pod] = p.cmd.Process.Pid
+		}
+	}
+	return pids
+}
+
+// kill 结束进程并停止跟踪它
+func (r *processRuntime) kill(uid string) {
+	r.mu.Lock()
+	p, ok := r.processes[uid]
+	delete(r.processes, uid)
+	r.mu.Unlock()
+	if ok && !p.exited {
+		if err := p.cmd.Process.Kill(); err != nil {
+			log.Printf("Error killing process %d of pod %s: %v", p.cmd.Process.Pid, uid, err)
+		}
+	}
+}
+
+// killUnknown 结束不属于 uids 中任何 pod 的进程，pod 被直接从存储里删除时靠它回收进程
+func (r *processRuntime) killUnknown(uids map[string]bool) {
+	r.mu.Lock()
+	var stale []string
+	for uid := range r.processes {
+		if !uids[uid] {
+			stale = append(stale, uid)
+		}
+	}
+	r.mu.Unlock()
+	for _, uid := range stale {
+		r.kill(uid)
+	}
+}
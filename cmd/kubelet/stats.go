@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mini-k8s/pkg/api"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultKubeletPort 是节点地址没有带端口时 kubelet 监听的端口
+const defaultKubeletPort = "10250"
+
+// clockTicks 是 /proc 里 CPU 时间的单位，Linux 上 USER_HZ 总是 100
+const clockTicks = 100
+
+// serveStats 在节点地址上提供 /healthz 和 /stats/summary，metrics-server 从这里抓取使用量
+func (kubelet *Kubelet) serveStats() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	})
+	mux.HandleFunc("/stats/summary", func(w http.ResponseWriter, r *http.Request) {
+		summary, err := kubelet.summary()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(summary)
+	})
+	addr := listenAddress(kubelet.NodeAddress)
+	log.Printf("Serving /stats/summary on %s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		//拿不到端口时 pod 照常运行，只是没有使用量
+		log.Printf("Error serving /stats/summary on %s: %v", addr, err)
+	}
+}
+
+func listenAddress(address string) string {
+	if _, _, err := net.SplitHostPort(address); err == nil {
+		return address
+	}
+	return net.JoinHostPort(address, defaultKubeletPort)
+}
+
+// summary 读取节点和每个 pod 的使用量。pod 的使用量是它的进程和所有子孙进程的总和，
+// CPU 时间包括已经退出并被回收的子进程用掉的时间
+func (kubelet *Kubelet) summary() (*api.Summary, error) {
+	now := time.Now()
+	procs, err := readProcesses()
+	if err != nil {
+		return nil, err
+	}
+	children := map[int][]int{}
+	for pid, p := range procs {
+		children[p.ppid] = append(children[p.ppid], pid)
+	}
+	summary := &api.Summary{Node: api.NodeStats{NodeName: kubelet.NodeName}, Pods: []api.PodStats{}}
+	if cpu, err := readNodeCPU(); err == nil {
+		summary.Node.CPU = &api.CPUStats{Time: now, UsageCoreNanoSeconds: ticksToNanoseconds(cpu)}
+	} else {
+		log.Printf("Error reading node CPU usage: %v", err)
+	}
+	if memory, err := readNodeMemory(); err == nil {
+		summary.Node.Memory = &api.MemoryStats{Time: now, WorkingSetBytes: memory}
+	} else {
+		log.Printf("Error reading node memory usage: %v", err)
+	}
+	pageSize := uint64(os.Getpagesize())
+	for ref, pid := range kubelet.runtime.running() {
+		if _, ok := procs[pid]; !ok {
+			continue
+		}
+		var ticks, pages uint64
+		stack := []int{pid}
+		for len(stack) > 0 {
+			p := procs[stack[len(stack)-1]]
+			stack = append(stack[:len(stack)-1], children[p.pid]...)
+			ticks += p.ticks
+			pages += p.residentPages
+		}
+		summary.Pods = append(summary.Pods, api.PodStats{
+			PodRef: ref,
+			CPU:    &api.CPUStats{Time: now, UsageCoreNanoSeconds: ticksToNanoseconds(ticks)},
+			Memory: &api.MemoryStats{Time: now, WorkingSetBytes: pages * pageSize},
+		})
+	}
+	return summary, nil
+}
+
+func ticksToNanoseconds(ticks uint64) uint64 {
+	return ticks * uint64(time.Second) / clockTicks
+}
+
+type procStat struct {
+	pid  int
+	ppid int
+	// ticks 是进程自己和已经回收的子进程的 user+system 时间
+	ticks         uint64
+	residentPages uint64
+}
+
+// readProcesses 读取 /proc 里所有进程的父进程、CPU 时间和常驻内存，读的时候已经退出的进程被跳过
+func readProcesses() (map[int]*procStat, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	procs := map[int]*procStat{}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		p, err := readProcess(pid)
+		if err != nil {
+			continue
+		}
+		procs[pid] = p
+	}
+	return procs, nil
+}
+
+func readProcess(pid int) (*procStat, error) {
+	dir := filepath.Join("/proc", strconv.Itoa(pid))
+	data, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return nil, err
+	}
+	//进程名可能包含空格和括号，从最后一个 ')' 之后开始按空格分割，fields[0] 是状态
+	s := string(data)
+	fields := strings.Fields(s[strings.LastIndexByte(s, ')')+1:])
+	if len(fields) < 15 {
+		return nil, fmt.Errorf("unexpected format of %s/stat", dir)
+	}
+	p := &procStat{pid: pid}
+	if p.ppid, err = strconv.Atoi(fields[1]); err != nil {
+		return nil, err
+	}
+	//utime stime cutime cstime
+	for _, field := range fields[11:15] {
+		n, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			p.ticks += uint64(n)
+		}
+	}
+	data, err = os.ReadFile(filepath.Join(dir, "statm"))
+	if err != nil {
+		return nil, err
+	}
+	statm := strings.Fields(string(data))
+	if len(statm) < 2 {
+		return nil, fmt.Errorf("unexpected format of %s/statm", dir)
+	}
+	if p.residentPages, err = strconv.ParseUint(statm[1], 10, 64); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// readNodeCPU 返回 /proc/stat 里所有 CPU 不空闲的时间
+func readNodeCPU() (uint64, error) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 9 || fields[0] != "cpu" {
+			continue
+		}
+		//user nice system idle iowait irq softirq steal，去掉 idle 和 iowait
+		var ticks uint64
+		for i, field := range fields[1:9] {
+			if i == 3 || i == 4 {
+				continue
+			}
+			n, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			ticks += n
+		}
+		return ticks, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("no cpu line in /proc/stat")
+}
+
+// readNodeMemory 返回节点正在使用的内存，也就是 MemTotal 减去 MemAvailable
+func readNodeMemory() (uint64, error) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	values := map[string]uint64{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		if n, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			values[strings.TrimSuffix(fields[0], ":")] = n * 1024
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	total, ok := values["MemTotal"]
+	available, ok2 := values["MemAvailable"]
+	if !ok || !ok2 || available > total {
+		return 0, fmt.Errorf("no MemTotal or MemAvailable in /proc/meminfo")
+	}
+	return total - available, nil
+}
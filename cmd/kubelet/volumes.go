@@ -0,0 +1,462 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"mini-k8s/pkg/api"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configSource 缓存一次同步里读取的 ConfigMap、Secret、PVC 和 PV，多个 pod 引用同一个对象时只向 API server 读取一次。
+// 每次同步重新创建，所以对象变化之后下一次同步就能看到
+type configSource struct {
+	client     *api.Client
+	configMaps map[string]*api.ConfigMap
+	secrets    map[string]*api.Secret
+	claims     map[string]*api.PersistentVolumeClaim
+	volumes    map[string]*api.PersistentVolume
+	errs       map[string]error
+}
+
+func newConfigSource(client *api.Client) *configSource {
+	return &configSource{
+		client:     client,
+		configMaps: map[string]*api.ConfigMap{},
+		secrets:    map[string]*api.Secret{},
+		claims:     map[string]*api.PersistentVolumeClaim{},
+		volumes:    map[string]*api.PersistentVolume{},
+		errs:       map[string]error{},
+	}
+}
+
+// configMap 返回 ConfigMap，不存在时返回 nil 和 nil
+func (s *configSource) configMap(namespace, name string) (*api.ConfigMap, error) {
+	key := "configmap/" + namespace + "/" + name
+	if cm, ok := s.configMaps[key]; ok {
+		return cm, s.errs[key]
+	}
+	cm, err := s.client.GetConfigMap(namespace, name)
+	if err != nil && strings.Contains(err.Error(), "not found") {
+		cm, err = nil, nil
+	}
+	s.configMaps[key], s.errs[key] = cm, err
+	return cm, err
+}
+
+// secret 返回 Secret，不存在时返回 nil 和 nil
+func (s *configSource) secret(namespace, name string) (*api.Secret, error) {
+	key := "secret/" + namespace + "/" + name
+	if secret, ok := s.secrets[key]; ok {
+		return secret, s.errs[key]
+	}
+	secret, err := s.client.GetSecret(namespace, name)
+	if err != nil && strings.Contains(err.Error(), "not found") {
+		secret, err = nil, nil
+	}
+	s.secrets[key], s.errs[key] = secret, err
+	return secret, err
+}
+
+// boundVolume 返回 PVC 绑定的卷。PVC 不存在或者还没有绑定时返回错误
+func (s *configSource) boundVolume(namespace, claimName string) (*api.PersistentVolume, error) {
+	key := "pvc/" + namespace + "/" + claimName
+	pvc, ok := s.claims[key]
+	if !ok {
+		var err error
+		pvc, err = s.client.GetPersistentVolumeClaim(namespace, claimName)
+		s.claims[key], s.errs[key] = pvc, err
+	}
+	if err := s.errs[key]; err != nil {
+		return nil, err
+	}
+	if pvc.Status.Phase != api.ClaimBound {
+		return nil, fmt.Errorf("persistentvolumeclaim %s/%s is not bound", namespace, claimName)
+	}
+	pvKey := "pv/" + pvc.Spec.VolumeName
+	pv, ok := s.volumes[pvKey]
+	if !ok {
+		var err error
+		pv, err = s.client.GetPersistentVolume(pvc.Spec.VolumeName)
+		s.volumes[pvKey], s.errs[pvKey] = pv, err
+	}
+	if err := s.errs[pvKey]; err != nil {
+		return nil, err
+	}
+	return pv, nil
+}
+
+func isOptional(optional *bool) bool {
+	return optional != nil && *optional
+}
+
+// podDir 是 pod 在节点上的目录，pod 删除后整个目录被清理
+func (kubelet *Kubelet) podDir(uid string) string {
+	return filepath.Join(kubelet.RootDir, "pods", uid)
+}
+
+func (kubelet *Kubelet) volumesDir(uid string) string {
+	return filepath.Join(kubelet.podDir(uid), "volumes")
+}
+
+// podRoot 是 pod 的挂载点所在的目录，也是进程的工作目录。没有 volumeMounts 的 pod 返回空字符串，
+// 进程在 kubelet 的工作目录里运行
+func (kubelet *Kubelet) podRoot(pod *api.Pod) string {
+	if len(pod.VolumeMounts) == 0 {
+		return ""
+	}
+	return filepath.Join(kubelet.podDir(pod.UID), "rootfs")
+}
+
+// containerEnv 返回 pod 进程的环境变量：kubelet 设置的变量在前，pod 的 env 在后，同名时后面的生效
+func (kubelet *Kubelet) containerEnv(pod *api.Pod, src *configSource) ([]string, error) {
+	env := append(podEnv(pod), "POD_VOLUMES="+kubelet.volumesDir(pod.UID))
+	if root := kubelet.podRoot(pod); root != "" {
+		env = append(env, "POD_ROOT="+root)
+	}
+	for _, e := range pod.Env {
+		if e.ValueFrom == nil {
+			env = append(env, e.Name+"="+e.Value)
+			continue
+		}
+		value, ok, err := resolveEnvSource(pod.Namespace, e.ValueFrom, src)
+		if err != nil {
+			return nil, fmt.Errorf("env %s: %w", e.Name, err)
+		}
+		if ok {
+			env = append(env, e.Name+"="+value)
+		}
+	}
+	return env, nil
+}
+
+// resolveEnvSource 读取 valueFrom 引用的值。引用是 optional 并且对象或键不存在时 ok 为 false
+func resolveEnvSource(namespace string, from *api.EnvVarSource, src *configSource) (value string, ok bool, err error) {
+	if ref := from.ConfigMapKeyRef; ref != nil {
+		cm, err := src.configMap(namespace, ref.Name)
+		if err != nil {
+			return "", false, err
+		}
+		if cm != nil {
+			if v, found := cm.Data[ref.Key]; found {
+				return v, true, nil
+			}
+			if v, found := cm.BinaryData[ref.Key]; found {
+				return string(v), true, nil
+			}
+		}
+		if isOptional(ref.Optional) {
+			return "", false, nil
+		}
+		if cm == nil {
+			return "", false, fmt.Errorf("configmap %s/%s not found", namespace, ref.Name)
+		}
+		return "", false, fmt.Errorf("key %q not found in configmap %s/%s", ref.Key, namespace, ref.Name)
+	}
+	ref := from.SecretKeyRef
+	secret, err := src.secret(namespace, ref.Name)
+	if err != nil {
+		return "", false, err
+	}
+	if secret != nil {
+		if v, found := secret.Data[ref.Key]; found {
+			return string(v), true, nil
+		}
+	}
+	if isOptional(ref.Optional) {
+		return "", false, nil
+	}
+	if secret == nil {
+		return "", false, fmt.Errorf("secret %s/%s not found", namespace, ref.Name)
+	}
+	return "", false, fmt.Errorf("key %q not found in secret %s/%s", ref.Key, namespace, ref.Name)
+}
+
+// volumePayload 返回卷里应该有的文件，键是卷里的相对路径
+func volumePayload(namespace string, volume *api.Volume, src *configSource) (map[string][]byte, error) {
+	var data map[string][]byte
+	var items []api.KeyToPath
+	var optional bool
+	var what string
+	switch {
+	case volume.ConfigMap != nil:
+		what = "configmap " + namespace + "/" + volume.ConfigMap.Name
+		items, optional = volume.ConfigMap.Items, isOptional(volume.ConfigMap.Optional)
+		cm, err := src.configMap(namespace, volume.ConfigMap.Name)
+		if err != nil {
+			return nil, err
+		}
+		if cm != nil {
+			data = make(map[string][]byte, len(cm.Data)+len(cm.BinaryData))
+			for k, v := range cm.Data {
+				data[k] = []byte(v)
+			}
+			for k, v := range cm.BinaryData {
+				data[k] = v
+			}
+		}
+	case volume.Secret != nil:
+		what = "secret " + namespace + "/" + volume.Secret.SecretName
+		items, optional = volume.Secret.Items, isOptional(volume.Secret.Optional)
+		secret, err := src.secret(namespace, volume.Secret.SecretName)
+		if err != nil {
+			return nil, err
+		}
+		if secret != nil {
+			data = secret.Data
+		}
+	default:
+		return nil, fmt.Errorf("volume %s has no supported source", volume.Name)
+	}
+	//optional 的对象不存在时卷是空的
+	if data == nil {
+		if optional {
+			return map[string][]byte{}, nil
+		}
+		return nil, fmt.Errorf("%s not found", what)
+	}
+	payload := map[string][]byte{}
+	if len(items) == 0 {
+		for k, v := range data {
+			payload[k] = v
+		}
+		return payload, nil
+	}
+	for _, item := range items {
+		v, ok := data[item.Key]
+		if !ok {
+			if optional {
+				continue
+			}
+			return nil, fmt.Errorf("key %q not found in %s", item.Key, what)
+		}
+		payload[item.Path] = v
+	}
+	return payload, nil
+}
+
+// setupVolumes 把 pod 的卷准备在 $POD_VOLUMES/<name>，再在 $POD_ROOT 下建立挂载点。启动 pod 时任何一个卷出错都返回错误；
+// 运行中刷新时出错的卷保留原来的内容
+func (kubelet *Kubelet) setupVolumes(pod *api.Pod, src *configSource) error {
+	for i := range pod.Volumes {
+		volume := &pod.Volumes[i]
+		if err := kubelet.setupVolume(pod, volume, src); err != nil {
+			return fmt.Errorf("volume %s: %w", volume.Name, err)
+		}
+	}
+	return kubelet.setupMounts(pod)
+}
+
+func (kubelet *Kubelet) setupVolume(pod *api.Pod, volume *api.Volume, src *configSource) error {
+	dir, err := pathWithin(kubelet.volumesDir(pod.UID), volume.Name)
+	if err != nil {
+		return err
+	}
+	switch {
+	case volume.EmptyDir != nil:
+		return os.MkdirAll(dir, 0o755)
+	case volume.HostPath != nil:
+		if err := prepareHostPath(volume.HostPath); err != nil {
+			return err
+		}
+		return ensureSymlink(dir, volume.HostPath.Path)
+	case volume.PersistentVolumeClaim != nil:
+		pv, err := src.boundVolume(pod.Namespace, volume.PersistentVolumeClaim.ClaimName)
+		if err != nil {
+			return err
+		}
+		if pv.Spec.Local == nil {
+			return fmt.Errorf("persistentvolume %s has no local path", pv.Name)
+		}
+		//local 卷的目录第一次使用时创建，pod 删除后目录和数据都保留
+		if err := os.MkdirAll(pv.Spec.Local.Path, 0o755); err != nil {
+			return err
+		}
+		return ensureSymlink(dir, pv.Spec.Local.Path)
+	}
+	payload, err := volumePayload(pod.Namespace, volume, src)
+	if err != nil {
+		return err
+	}
+	writer := &atomicWriter{dir: dir}
+	changed, err := writer.write(payload)
+	if err != nil {
+		return err
+	}
+	if changed {
+		log.Printf("[%s] Updated volume %s of pod %s (%d files).", kubelet.NodeName, volume.Name, pod.Name, len(payload))
+	}
+	return nil
+}
+
+// prepareHostPath 按 type 检查节点上的路径，*OrCreate 在路径不存在时创建
+func prepareHostPath(source *api.HostPathVolumeSource) error {
+	info, err := os.Stat(source.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	exists := err == nil
+	switch source.Type {
+	case api.HostPathDirectoryOrCreate:
+		if !exists {
+			return os.MkdirAll(source.Path, 0o755)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("hostPath %s is not a directory", source.Path)
+		}
+	case api.HostPathDirectory:
+		if !exists || !info.IsDir() {
+			return fmt.Errorf("hostPath %s is not an existing directory", source.Path)
+		}
+	case api.HostPathFileOrCreate:
+		if !exists {
+			f, err := os.OpenFile(source.Path, os.O_CREATE|os.O_WRONLY, 0o644)
+			if err != nil {
+				return err
+			}
+			return f.Close()
+		}
+		if info.IsDir() {
+			return fmt.Errorf("hostPath %s is a directory", source.Path)
+		}
+	case api.HostPathFile:
+		if !exists || info.IsDir() {
+			return fmt.Errorf("hostPath %s is not an existing file", source.Path)
+		}
+	}
+	return nil
+}
+
+// ensureSymlink 让 link 是指向 target 的符号链接，已经是时不做修改
+func ensureSymlink(link, target string) error {
+	if current, err := os.Readlink(link); err == nil {
+		if current == target {
+			return nil
+		}
+		if err := os.Remove(link); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		//同名的不是符号链接，比如 pod 被改成了别的卷类型
+		if err := os.RemoveAll(link); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(link), 0o755); err != nil {
+		return err
+	}
+	return os.Symlink(target, link)
+}
+
+// setupMounts 在 $POD_ROOT 下按 mountPath 建立指向卷的符号链接，subPath 不存在时在卷里创建这个目录
+func (kubelet *Kubelet) setupMounts(pod *api.Pod) error {
+	root := kubelet.podRoot(pod)
+	for _, mount := range pod.VolumeMounts {
+		target, err := pathWithin(kubelet.volumesDir(pod.UID), mount.Name)
+		if err != nil {
+			return fmt.Errorf("mount %s: %w", mount.MountPath, err)
+		}
+		if mount.SubPath != "" {
+			if target, err = pathWithin(target, mount.SubPath); err != nil {
+				return fmt.Errorf("mount %s: %w", mount.MountPath, err)
+			}
+			if _, err := os.Stat(target); os.IsNotExist(err) {
+				if err := os.MkdirAll(target, 0o755); err != nil {
+					return fmt.Errorf("mount %s: %w", mount.MountPath, err)
+				}
+			}
+		}
+		link, err := pathWithin(root, mount.MountPath)
+		if err != nil {
+			return fmt.Errorf("mount %s: %w", mount.MountPath, err)
+		}
+		if err := ensureSymlink(link, target); err != nil {
+			return fmt.Errorf("mount %s: %w", mount.MountPath, err)
+		}
+	}
+	return nil
+}
+
+// pathWithin 返回 dir 下的 elem，拼出来的路径不在 dir 里面时返回错误。apiserver 已经校验过卷名、subPath 和 mountPath，
+// 这里在创建和删除节点上的文件之前再检查一次，绕过校验的 pod 也不能动到自己的 pod 目录以外的路径
+func pathWithin(dir, elem string) (string, error) {
+	p := filepath.Join(dir, elem)
+	rel, err := filepath.Rel(dir, p)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q resolves outside %s", elem, dir)
+	}
+	return p, nil
+}
+
+// emptyDirOverLimit 返回用量超过 sizeLimit 的 emptyDir 卷的说明，都没有超过时返回空字符串
+func (kubelet *Kubelet) emptyDirOverLimit(pod *api.Pod) string {
+	for _, volume := range pod.Volumes {
+		if volume.EmptyDir == nil || volume.EmptyDir.SizeLimit == "" {
+			continue
+		}
+		limit, err := api.ParseQuantity(volume.EmptyDir.SizeLimit)
+		if err != nil {
+			continue
+		}
+		usage, err := dirUsage(filepath.Join(kubelet.volumesDir(pod.UID), volume.Name))
+		if err != nil {
+			log.Printf("[%s] Error measuring volume %s of pod %s: %v", kubelet.NodeName, volume.Name, pod.Name, err)
+			continue
+		}
+		if usage > limit {
+			return fmt.Sprintf("Usage of EmptyDir volume %q exceeds the limit %q.", volume.Name, volume.EmptyDir.SizeLimit)
+		}
+	}
+	return ""
+}
+
+// dirUsage 返回目录里所有文件的大小之和，不跟随符号链接
+func dirUsage(dir string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			//统计的同时进程可能正在删除文件
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		total += info.Size()
+		return nil
+	})
+	return total, err
+}
+
+// cleanupPodDirs 删除不在 keep 里的 pod 的目录。hostPath 和 PVC 的卷只是符号链接，删除时不会跟随，节点上的数据保留
+func (kubelet *Kubelet) cleanupPodDirs(keep map[string]bool) {
+	root := filepath.Join(kubelet.RootDir, "pods")
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("[%s] Error reading pod directories: %v", kubelet.NodeName, err)
+		}
+		return
+	}
+	for _, entry := range entries {
+		if keep[entry.Name()] {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(root, entry.Name())); err != nil {
+			log.Printf("[%s] Error removing directory of pod %s: %v", kubelet.NodeName, entry.Name(), err)
+			continue
+		}
+		log.Printf("[%s] Removed directory of pod %s.", kubelet.NodeName, entry.Name())
+	}
+}
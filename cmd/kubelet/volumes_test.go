@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestPathWithin(t *testing.T) {
+	tests := []struct {
+		elem    string
+		want    string
+		wantErr bool
+	}{
+		{elem: "data", want: "/pods/uid/volumes/data"},
+		{elem: "data/logs", want: "/pods/uid/volumes/data/logs"},
+		{elem: "a/../b", want: "/pods/uid/volumes/b"},
+		{elem: "/etc", want: "/pods/uid/volumes/etc"},
+		{elem: "", wantErr: true},
+		{elem: ".", wantErr: true},
+		{elem: "..", wantErr: true},
+		{elem: "../../etc", wantErr: true},
+		{elem: "data/../../other-pod", wantErr: true},
+		{elem: "..data", want: "/pods/uid/volumes/..data"},
+	}
+	for _, tt := range tests {
+		got, err := pathWithin("/pods/uid/volumes", tt.elem)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("pathWithin(%q) = %q, %v, want %q, error %v", tt.elem, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"mini-k8s/pkg/api"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// metrics-server 定期抓取就绪节点上 kubelet 的 /stats/summary，把累计的 CPU 时间换算成使用率，
+// 以 metrics.k8s.io API 提供最近的使用量。API server 用 -metrics-server 把这个 API 转发到这里
+func main() {
+	apiServerURL := flag.String("apiserver", "http://localhost:8055", "URL of the API server")
+	port := flag.String("port", "4443", "Port to serve the metrics API on")
+	resolution := flag.Duration("metric-resolution", 15*time.Second, "Interval between scrapes of the kubelets")
+	kubeletPort := flag.String("kubelet-port", "10250", "Port of the kubelet when the node address has none")
+	flag.Parse()
+
+	log.Printf("Starting metrics server with URL %s, scraping every %v", *apiServerURL, *resolution)
+	client, err := api.NewClient(*apiServerURL)
+	if err != nil {
+		log.Fatalf("Error creating client: %s", err)
+	}
+	//抓取超时不能超过抓取间隔，否则慢节点会让两次抓取重叠
+	s := newScraper(client, *kubeletPort, *resolution)
+	go func() {
+		for {
+			s.scrape()
+			time.Sleep(*resolution)
+		}
+	}()
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.GET("/healthz", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	group := router.Group("/apis/" + api.MetricsGroupVersion)
+	{
+		group.GET("/nodes", func(c *gin.Context) {
+			c.JSON(http.StatusOK, s.listNodeMetrics())
+		})
+		group.GET("/nodes/:name", func(c *gin.Context) {
+			metrics, ok := s.nodeMetrics(c.Param("name"))
+			if !ok {
+				c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("node metrics %s not found", c.Param("name"))})
+				return
+			}
+			c.JSON(http.StatusOK, metrics)
+		})
+		group.GET("/pods", func(c *gin.Context) {
+			c.JSON(http.StatusOK, s.listPodMetrics(""))
+		})
+		group.GET("/namespaces/:namespace/pods", func(c *gin.Context) {
+			c.JSON(http.StatusOK, s.listPodMetrics(c.Param("namespace")))
+		})
+		group.GET("/namespaces/:namespace/pods/:name", func(c *gin.Context) {
+			namespace, name := c.Param("namespace"), c.Param("name")
+			for _, metrics := range s.listPodMetrics(namespace) {
+				if metrics.Name == name {
+					c.JSON(http.StatusOK, metrics)
+					return
+				}
+			}
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("pod metrics %s/%s not found", namespace, name)})
+		})
+	}
+	log.Printf("Serving the metrics API on port %s", *port)
+	if err := router.Run(":" + *port); err != nil {
+		log.Fatalf("Failed to serve the metrics API: %v", err)
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"mini-k8s/pkg/api"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// sample 是一次抓取得到的累计 CPU 时间和当前内存
+type sample struct {
+	time    time.Time
+	cpuNano uint64
+	memory  uint64
+}
+
+// usage 是最近两次抓取算出来的使用量
+type usage struct {
+	timestamp time.Time
+	window    time.Duration
+	cpuMilli  int64
+	memory    int64
+}
+
+// podKey 用 UID 区分同名的 pod，pod 重建之后累计值从头开始
+type podKey struct {
+	namespace, name, uid string
+}
+
+type scraper struct {
+	client      *api.Client
+	kubeletPort string
+	httpClient  *http.Client
+
+	mu sync.RWMutex
+	// 上一次抓取的样本，用来和这一次的样本做差
+	lastNodes map[string]sample
+	lastPods  map[podKey]sample
+	nodes     map[string]usage
+	pods      map[podKey]usage
+}
+
+func newScraper(client *api.Client, kubeletPort string, timeout time.Duration) *scraper {
+	return &scraper{
+		client:      client,
+		kubeletPort: kubeletPort,
+		httpClient:  &http.Client{Timeout: timeout},
+		lastNodes:   map[string]sample{},
+		lastPods:    map[podKey]sample{},
+		nodes:       map[string]usage{},
+		pods:        map[podKey]usage{},
+	}
+}
+
+// scrape 抓取所有就绪节点的 /stats/summary。一个节点或 pod 要抓到两次才有 CPU 使用率，
+// 这一次没有抓到的节点和 pod 的使用量被丢弃
+func (s *scraper) scrape() {
+	nodes, err := s.client.ListNodes("")
+	if err != nil {
+		log.Printf("Error listing nodes: %v", err)
+		return
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	summaries := map[string]*api.Summary{}
+	for _, node := range nodes {
+		if node.Status != api.NodeReady {
+			continue
+		}
+		wg.Add(1)
+		go func(node api.Node) {
+			defer wg.Done()
+			summary, err := s.fetchSummary(&node)
+			if err != nil {
+				log.Printf("Error scraping node %s: %v", node.Name, err)
+				return
+			}
+			mu.Lock()
+			summaries[node.Name] = summary
+			mu.Unlock()
+		}(node)
+	}
+	wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lastNodes, lastPods := s.lastNodes, s.lastPods
+	s.lastNodes, s.lastPods = map[string]sample{}, map[podKey]sample{}
+	s.nodes, s.pods = map[string]usage{}, map[podKey]usage{}
+	for name, summary := range summaries {
+		if cur, ok := toSample(summary.Node.CPU, summary.Node.Memory); ok {
+			s.lastNodes[name] = cur
+			if u, ok := rate(lastNodes[name], cur); ok {
+				s.nodes[name] = u
+			}
+		}
+		for _, pod := range summary.Pods {
+			cur, ok := toSample(pod.CPU, pod.Memory)
+			if !ok {
+				continue
+			}
+			key := podKey{pod.PodRef.Namespace, pod.PodRef.Name, pod.PodRef.UID}
+			s.lastPods[key] = cur
+			if u, ok := rate(lastPods[key], cur); ok {
+				s.pods[key] = u
+			}
+		}
+	}
+}
+
+func (s *scraper) fetchSummary(node *api.Node) (*api.Summary, error) {
+	addr := node.Address
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, s.kubeletPort)
+	}
+	resp, err := s.httpClient.Get("http://" + addr + "/stats/summary")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("kubelet returned %s", resp.Status)
+	}
+	var summary api.Summary
+	if err := json.NewDecoder(resp.Body).Decode(&summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+func toSample(cpu *api.CPUStats, memory *api.MemoryStats) (sample, bool) {
+	if cpu == nil || memory == nil {
+		return sample{}, false
+	}
+	return sample{time: cpu.Time, cpuNano: cpu.UsageCoreNanoSeconds, memory: memory.WorkingSetBytes}, true
+}
+
+// rate 用两次样本的差算出这段时间里平均每秒用掉的 CPU 时间。累计值变小说明进程重启过，这一次不算
+func rate(prev, cur sample) (usage, bool) {
+	window := cur.time.Sub(prev.time)
+	if prev.time.IsZero() || window <= 0 || cur.cpuNano < prev.cpuNano {
+		return usage{}, false
+	}
+	return usage{
+		timestamp: cur.time,
+		window:    window,
+		cpuMilli:  int64(float64(cur.cpuNano-prev.cpuNano) / float64(window.Nanoseconds()) * 1000),
+		memory:    int64(cur.memory),
+	}, true
+}
+
+func (u usage) resources() api.ResourceList {
+	return api.ResourceList{
+		api.ResourceCPU:    api.FormatMilliQuantity(u.cpuMilli),
+		api.ResourceMemory: api.FormatQuantity(u.memory),
+	}
+}
+
+func (s *scraper) nodeMetrics(name string) (api.NodeMetrics, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	u, ok := s.nodes[name]
+	if !ok {
+		return api.NodeMetrics{}, false
+	}
+	return api.NodeMetrics{
+		ObjectMeta: api.ObjectMeta{Name: name},
+		Timestamp:  u.timestamp,
+		Window:     u.window.Round(time.Millisecond).String(),
+		Usage:      u.resources(),
+	}, true
+}
+
+func (s *scraper) listNodeMetrics() []api.NodeMetrics {
+	s.mu.RLock()
+	names := make([]string, 0, len(s.nodes))
+	for name := range s.nodes {
+		names = append(names, name)
+	}
+	s.mu.RUnlock()
+	metrics := []api.NodeMetrics{}
+	for _, name := range names {
+		if m, ok := s.nodeMetrics(name); ok {
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics
+}
+
+// listPodMetrics 返回命名空间里 pod 的使用量，namespace 为空时返回所有命名空间的
+func (s *scraper) listPodMetrics(namespace string) []api.PodMetrics {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	metrics := []api.PodMetrics{}
+	for key, u := range s.pods {
+		if namespace != "" && key.namespace != namespace {
+			continue
+		}
+		metrics = append(metrics, api.PodMetrics{
+			ObjectMeta: api.ObjectMeta{Name: key.name, Namespace: key.namespace, UID: key.uid},
+			Timestamp:  u.timestamp,
+			Window:     u.window.Round(time.Millisecond).String(),
+			Usage:      u.resources(),
+		})
+	}
+	return metrics
+}
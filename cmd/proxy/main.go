@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/informer"
+	"mini-k8s/pkg/proxy"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// eventBatchDelay 是收到缓存变化之后等待多久再同步，合并短时间内的多次变化
+const eventBatchDelay = 500 * time.Millisecond
+
+// affinityCleanupInterval 是清理过期会话亲和性记录的间隔
+const affinityCleanupInterval = time.Minute
+
+func main() {
+	apiServerURL := flag.String("apiserver", "http://localhost:8055", "URL of the API server")
+	bindAddress := flag.String("bind-address", "127.0.0.1", "Address to open service ports on when the cluster IP cannot be bound directly; ports are chosen at random")
+	nodePortAddress := flag.String("nodeport-address", "0.0.0.0", "Address to open node ports on")
+	udpTimeout := flag.Duration("udp-timeout", 30*time.Second, "How long an idle UDP session is kept open")
+	syncInterval := flag.Duration("interval", 30*time.Second, "Interval between full syncs when nothing changes")
+	healthzAddr := flag.String("healthz-bind-address", ":10256", "Address to serve /healthz and /proxies on; empty disables it")
+	flag.Parse()
+
+	log.Printf("Starting proxy with URL %s", *apiServerURL)
+	client, err := api.NewClient(*apiServerURL)
+	if err != nil {
+		log.Fatalf("Error creating client: %s", err)
+	}
+	informers := informer.NewSharedInformerFactory(client)
+	services := informers.Services()
+	endpoints := informers.Endpoints()
+	//多次变化只需要排队一次同步
+	trigger := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case trigger <- struct{}{}:
+		default:
+		}
+	}
+	services.AddEventHandler(notify)
+	endpoints.AddEventHandler(notify)
+
+	proxier := proxy.NewProxier(proxy.Config{
+		BindAddress:     *bindAddress,
+		NodePortAddress: *nodePortAddress,
+		UDPIdleTimeout:  *udpTimeout,
+	})
+
+	if *healthzAddr != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+			if !informers.HasSynced() {
+				http.Error(w, "informers have not synced", http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprint(w, "ok")
+		})
+		//ClusterIP 不能直接监听时，客户端从 /proxies 查到 service 端口实际监听的地址
+		mux.HandleFunc("/proxies", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(proxier.Status())
+		})
+		go func() {
+			if err := http.ListenAndServe(*healthzAddr, mux); err != nil {
+				log.Fatalf("Error serving /healthz: %v", err)
+			}
+		}()
+	}
+
+	stop := make(chan struct{})
+	informers.Start(stop)
+	//缓存还是空的时候同步会关闭所有已经打开的端口
+	for !informers.HasSynced() {
+		time.Sleep(100 * time.Millisecond)
+	}
+	log.Printf("Informer caches are synced")
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	cleanup := time.NewTicker(affinityCleanupInterval)
+	defer cleanup.Stop()
+	for {
+		proxier.Sync(services.List(), endpoints.List())
+		select {
+		case <-signals:
+			log.Printf("Shutting down proxy")
+			close(stop)
+			proxier.Close()
+			return
+		case <-trigger:
+			time.Sleep(eventBatchDelay)
+			select {
+			case <-trigger:
+			default:
+			}
+		case <-cleanup.C:
+			proxier.CleanupStaleAffinity()
+		case <-time.After(*syncInterval):
+		}
+	}
+}
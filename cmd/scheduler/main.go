@@ -4,15 +4,37 @@ import (
 	"flag"
 	"log"
 	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/leaderelection"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
-const DefaultNamespace = "default" //如果不标注就去遍历默认的
-var nextNodeIndex = 0
+// scheduler 保存调度状态。同一时间只有持有 lease 的副本在调度，轮询位置属于这个副本自己
+type scheduler struct {
+	client *api.Client
+	// nextNodeIndex 是轮询选择节点的位置
+	nextNodeIndex int
+}
+
+// run 每隔 interval 调度一次，直到 stop 被关闭
+func (s *scheduler) run(interval time.Duration, stop <-chan struct{}) {
+	for {
+		s.schedulePods()
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
 
 // 调度器的主函数，负责获取待调度的Pod并分配到就绪节点
-func schedulePods(Client *api.Client) {
-	pendingPods, err := Client.ListPods(DefaultNamespace, api.PodPending)
+func (s *scheduler) schedulePods() {
+	Client := s.client
+	//调度所有命名空间里待调度的 pod
+	pendingPods, err := Client.ListAllPods(api.PodPending)
 	if err != nil {
 		log.Printf("Failed to fetch pending pods: %s", err)
 		return
@@ -32,17 +54,34 @@ func schedulePods(Client *api.Client) {
 		return
 	}
 	log.Printf("Found %d ready nodes", len(readyNodes))
+	volumes := newVolumeBinding(Client)
 	for _, pod := range pendingPods {
 		if pod.DeletionTimestamp != nil {
-			log.Printf("Pod %s is being deleted", pod.Name)
+			log.Printf("Pod %s/%s is being deleted", pod.Namespace, pod.Name)
 			continue
 		}
-		if len(readyNodes) == 0 {
-			log.Printf("No ready nodes available to schedule pod %s", pod.Name)
+		//local 卷只在一个节点上，使用它的 pod 只能调度到那个节点
+		volumeRequirements, delayedClaims, err := volumes.nodeRequirements(&pod)
+		if err != nil {
+			log.Printf("Cannot schedule pod %s/%s yet: %v", pod.Namespace, pod.Name, err)
+			continue
+		}
+		feasibleNodes := filterNodes(&pod, filterVolumeNodes(readyNodes, volumeRequirements))
+		if len(feasibleNodes) == 0 {
+			log.Printf("No ready node matches the node selector, taints and volumes for pod %s/%s", pod.Namespace, pod.Name)
+			continue
+		}
+		selectedNode := feasibleNodes[s.nextNodeIndex%len(feasibleNodes)]
+		s.nextNodeIndex++
+		//WaitForFirstConsumer 的 PVC 先在选中的节点上绑定卷，pod 等它们绑定之后再调度到卷所在的节点
+		if len(delayedClaims) > 0 {
+			if err := volumes.selectNode(delayedClaims, selectedNode.Name); err != nil {
+				log.Printf("Cannot schedule pod %s/%s yet: %v", pod.Namespace, pod.Name, err)
+			} else {
+				log.Printf("Selected node %s for the volumes of pod %s/%s, waiting for them to be bound", selectedNode.Name, pod.Namespace, pod.Name)
+			}
 			continue
 		}
-		selectedNode := readyNodes[nextNodeIndex%len(readyNodes)]
-		nextNodeIndex++
 
 		podToudpdate := pod
 		podToudpdate.NodeName = selectedNode.Name
@@ -58,19 +97,63 @@ func schedulePods(Client *api.Client) {
 	}
 }
 
+// filterNodes 返回 pod 可以放上去的节点。如果有节点没有 pod 不容忍的 PreferNoSchedule 污点，只返回这些节点
+func filterNodes(pod *api.Pod, nodes []api.Node) []api.Node {
+	var feasible, preferred []api.Node
+	for i := range nodes {
+		if !api.PodFitsNode(&pod.PodSpec, &nodes[i]) {
+			continue
+		}
+		feasible = append(feasible, nodes[i])
+		if _, ok := api.FindUntoleratedTaint(nodes[i].Taints, pod.Tolerations, api.TaintEffectPreferNoSchedule); !ok {
+			preferred = append(preferred, nodes[i])
+		}
+	}
+	if len(preferred) > 0 {
+		return preferred
+	}
+	return feasible
+}
+
 func main() {
 	apiServerURL := flag.String("apiserver", "http://localhost:8055", "URL of the API server")
 	scheduleInterval := flag.Duration("interval", 5*time.Second, "Interval between scheduling pods")
+	var election leaderelection.Options
+	election.AddFlags(flag.CommandLine, "kube-scheduler")
 	flag.Parse()
 	log.Printf("Starting Scheduler with URL %s", *apiServerURL)
 	client, err := api.NewClient(*apiServerURL)
 	if err != nil {
 		log.Fatalf("Error creating client: %s", err)
 	}
+	client.SetFieldManager("scheduler")
 	log.Printf("Scheduler created with URL %s", *apiServerURL)
-	for {
-		schedulePods(client)
-		time.Sleep(*scheduleInterval)
-	}
 
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signals
+		log.Printf("Shutting down scheduler")
+		close(stop)
+	}()
+
+	if !election.LeaderElect {
+		(&scheduler{client: client}).run(*scheduleInterval, stop)
+		return
+	}
+	//每次当选都从新的调度状态开始
+	le, err := leaderelection.NewLeaderElector(election.Config(client, leaderelection.DefaultIdentity(), leaderelection.Callbacks{
+		OnStartedLeading: func(leading <-chan struct{}) {
+			log.Printf("Started leading, scheduling pods")
+			(&scheduler{client: client}).run(*scheduleInterval, leading)
+		},
+		OnStoppedLeading: func() {
+			log.Printf("Stopped leading, no longer scheduling pods")
+		},
+	}))
+	if err != nil {
+		log.Fatalf("Invalid leader election configuration: %v", err)
+	}
+	le.Run(stop)
 }
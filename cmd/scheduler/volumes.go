@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"mini-k8s/pkg/api"
+	"strings"
+)
+
+// volumeBinding 读取 pod 使用的 PVC、它们绑定的卷和 StorageClass，一轮调度里每个对象只读取一次
+type volumeBinding struct {
+	client  *api.Client
+	claims  map[string]*api.PersistentVolumeClaim
+	volumes map[string]*api.PersistentVolume
+	// classes 里值为 nil 表示 class 不存在
+	classes map[string]*api.StorageClass
+}
+
+func newVolumeBinding(client *api.Client) *volumeBinding {
+	return &volumeBinding{client: client, claims: map[string]*api.PersistentVolumeClaim{}, volumes: map[string]*api.PersistentVolume{},
+		classes: map[string]*api.StorageClass{}}
+}
+
+// nodeRequirements 返回 pod 的卷要求节点具有的 labels，以及等调度器选择节点之后才绑定的 WaitForFirstConsumer PVC。
+// PVC 不存在、还没有绑定或者正在为它创建卷时返回错误，pod 等下一轮再调度
+func (vb *volumeBinding) nodeRequirements(pod *api.Pod) ([]map[string]string, []*api.PersistentVolumeClaim, error) {
+	var required []map[string]string
+	var delayed []*api.PersistentVolumeClaim
+	for _, volume := range pod.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc, err := vb.claim(pod.Namespace, volume.PersistentVolumeClaim.ClaimName)
+		if err != nil {
+			return nil, nil, err
+		}
+		key := pvc.Namespace + "/" + pvc.Name
+		if pvc.DeletionTimestamp != nil {
+			return nil, nil, fmt.Errorf("persistentvolumeclaim %s is being deleted", key)
+		}
+		if pvc.Status.Phase != api.ClaimBound {
+			class, err := vb.storageClass(pvc.ClassName())
+			if err != nil {
+				return nil, nil, err
+			}
+			if class == nil || class.VolumeBindingMode != api.VolumeBindingWaitForFirstConsumer {
+				return nil, nil, fmt.Errorf("persistentvolumeclaim %s is not bound yet", key)
+			}
+			if node := pvc.Annotations[api.AnnSelectedNode]; node != "" {
+				return nil, nil, fmt.Errorf("persistentvolumeclaim %s is waiting for a volume on node %s", key, node)
+			}
+			delayed = append(delayed, pvc)
+			continue
+		}
+		pv, err := vb.boundVolume(pvc)
+		if err != nil {
+			return nil, nil, err
+		}
+		if pv.Spec.NodeAffinity != nil {
+			required = append(required, pv.Spec.NodeAffinity.Required)
+		}
+	}
+	return required, delayed, nil
+}
+
+// selectNode 把 pod 要去的节点记在 WaitForFirstConsumer 的 PVC 上，binder 只绑定这个节点上的卷，
+// 没有合适的卷时 provisioner 在这个节点上创建
+func (vb *volumeBinding) selectNode(claims []*api.PersistentVolumeClaim, node string) error {
+	for _, pvc := range claims {
+		if pvc.Annotations == nil {
+			pvc.Annotations = map[string]string{}
+		}
+		pvc.Annotations[api.AnnSelectedNode] = node
+		updated, err := vb.client.UpdatePersistentVolumeClaim(pvc)
+		if err != nil {
+			return fmt.Errorf("selecting node for persistentvolumeclaim %s/%s: %w", pvc.Namespace, pvc.Name, err)
+		}
+		vb.claims[pvc.Namespace+"/"+pvc.Name] = updated
+	}
+	return nil
+}
+
+func (vb *volumeBinding) claim(namespace, claimName string) (*api.PersistentVolumeClaim, error) {
+	key := namespace + "/" + claimName
+	if pvc, ok := vb.claims[key]; ok {
+		return pvc, nil
+	}
+	pvc, err := vb.client.GetPersistentVolumeClaim(namespace, claimName)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, fmt.Errorf("persistentvolumeclaim %s not found", key)
+		}
+		return nil, err
+	}
+	vb.claims[key] = pvc
+	return pvc, nil
+}
+
+// storageClass 返回 PVC 使用的 StorageClass，PVC 不使用 class 或者 class 不存在时返回 nil
+func (vb *volumeBinding) storageClass(name string) (*api.StorageClass, error) {
+	if name == "" {
+		return nil, nil
+	}
+	if class, ok := vb.classes[name]; ok {
+		return class, nil
+	}
+	class, err := vb.client.GetStorageClass(name)
+	if err != nil {
+		if !strings.Contains(err.Error(), "not found") {
+			return nil, err
+		}
+		class = nil
+	}
+	vb.classes[name] = class
+	return class, nil
+}
+
+func (vb *volumeBinding) boundVolume(pvc *api.PersistentVolumeClaim) (*api.PersistentVolume, error) {
+	key := pvc.Namespace + "/" + pvc.Name
+	pv, ok := vb.volumes[pvc.Spec.VolumeName]
+	if !ok {
+		var err error
+		pv, err = vb.client.GetPersistentVolume(pvc.Spec.VolumeName)
+		if err != nil {
+			return nil, fmt.Errorf("volume %s of persistentvolumeclaim %s: %w", pvc.Spec.VolumeName, key, err)
+		}
+		vb.volumes[pvc.Spec.VolumeName] = pv
+	}
+	return pv, nil
+}
+
+// filterVolumeNodes 只保留满足所有卷的节点要求的节点
+func filterVolumeNodes(nodes []api.Node, required []map[string]string) []api.Node {
+	if len(required) == 0 {
+		return nodes
+	}
+	var out []api.Node
+	for _, node := range nodes {
+		fits := true
+		for _, labels := range required {
+			fits = fits && api.MatchesNodeSelector(labels, node.Labels)
+		}
+		if fits {
+			out = append(out, node)
+		}
+	}
+	return out
+}
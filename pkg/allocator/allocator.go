@@ -0,0 +1,166 @@
+// Package allocator hands out values from a fixed range, such as service
+// ClusterIPs from a CIDR or node ports from a port range. Which values are taken
+// is kept as a bitmap in a RangeAllocation object in the store, so allocations
+// share the lifetime of the objects that hold them.
+package allocator
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"mini-k8s/pkg/api"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrFull 表示范围里已经没有空闲的值
+	ErrFull = errors.New("range is full")
+	// ErrAllocated 表示指定的值已经被分配
+	ErrAllocated = errors.New("provided value is already allocated")
+)
+
+// maxConflictRetries 是写回位图时遇到 resourceVersion 冲突的最大重试次数
+const maxConflictRetries = 5
+
+// Range 把一段连续的值映射到位图的下标
+type Range interface {
+	// String 是范围的文本形式，保存在 RangeAllocation.Range 里
+	String() string
+	Size() int
+	// Offset 返回 value 在范围里的下标，不在范围里时返回错误
+	Offset(value string) (int, error)
+	Value(offset int) string
+}
+
+// Store 是保存位图需要的存储操作，store.Store 实现了它
+type Store interface {
+	CreateRangeAllocation(ra *api.RangeAllocation) error
+	GetRangeAllocation(name string) (*api.RangeAllocation, error)
+	UpdateRangeAllocation(ra *api.RangeAllocation) error
+}
+
+// Allocator 从 Range 里分配值，每次分配或释放都读出位图、修改后带着 resourceVersion 写回
+type Allocator struct {
+	name  string
+	r     Range
+	store Store
+
+	mu sync.Mutex
+}
+
+// New 返回一个把位图保存在名为 name 的 RangeAllocation 里的 Allocator
+func New(name string, r Range, store Store) *Allocator {
+	return &Allocator{name: name, r: r, store: store}
+}
+
+func (a *Allocator) Range() Range {
+	return a.r
+}
+
+// Allocate 分配指定的值
+func (a *Allocator) Allocate(value string) error {
+	offset, err := a.r.Offset(value)
+	if err != nil {
+		return err
+	}
+	return a.modify(func(bits *big.Int) error {
+		if bits.Bit(offset) == 1 {
+			return fmt.Errorf("%s: %w", value, ErrAllocated)
+		}
+		bits.SetBit(bits, offset, 1)
+		return nil
+	})
+}
+
+// AllocateNext 分配一个空闲的值。从随机位置开始找，减少刚释放的值马上被重新分配的机会
+func (a *Allocator) AllocateNext() (string, error) {
+	size := a.r.Size()
+	var offset int
+	err := a.modify(func(bits *big.Int) error {
+		start := rand.Intn(size)
+		for i := 0; i < size; i++ {
+			offset = (start + i) % size
+			if bits.Bit(offset) == 0 {
+				bits.SetBit(bits, offset, 1)
+				return nil
+			}
+		}
+		return ErrFull
+	})
+	if err != nil {
+		return "", err
+	}
+	return a.r.Value(offset), nil
+}
+
+// Release 释放一个值。不在范围里或者没有分配的值直接忽略
+func (a *Allocator) Release(value string) error {
+	offset, err := a.r.Offset(value)
+	if err != nil {
+		return nil
+	}
+	return a.modify(func(bits *big.Int) error {
+		bits.SetBit(bits, offset, 0)
+		return nil
+	})
+}
+
+// Has 表示值是否已经被分配
+func (a *Allocator) Has(value string) (bool, error) {
+	offset, err := a.r.Offset(value)
+	if err != nil {
+		return false, err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, bits, err := a.load()
+	if err != nil {
+		return false, err
+	}
+	return bits.Bit(offset) == 1, nil
+}
+
+// modify 读出位图，调用 fn 修改后写回。同一个进程里用锁串行，多个写入者之间靠 resourceVersion 冲突重试
+func (a *Allocator) modify(fn func(bits *big.Int) error) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for attempt := 0; ; attempt++ {
+		ra, bits, err := a.load()
+		if err != nil {
+			return err
+		}
+		if err := fn(bits); err != nil {
+			return err
+		}
+		ra.Data = bits.Bytes()
+		if ra.ResourceVersion == "" {
+			err = a.store.CreateRangeAllocation(ra)
+		} else {
+			err = a.store.UpdateRangeAllocation(ra)
+		}
+		if err == nil {
+			return nil
+		}
+		conflict := strings.Contains(err.Error(), "the object has been modified") || strings.Contains(err.Error(), "already exists")
+		if !conflict || attempt >= maxConflictRetries {
+			return fmt.Errorf("saving allocations for %s: %w", a.name, err)
+		}
+	}
+}
+
+// load 读出位图，RangeAllocation 还不存在时返回一个空的
+func (a *Allocator) load() (*api.RangeAllocation, *big.Int, error) {
+	ra, err := a.store.GetRangeAllocation(a.name)
+	if err != nil {
+		if !strings.Contains(err.Error(), "not found") {
+			return nil, nil, err
+		}
+		return &api.RangeAllocation{ObjectMeta: api.ObjectMeta{Name: a.name}, Range: a.r.String()}, new(big.Int), nil
+	}
+	if ra.Range != a.r.String() {
+		return nil, nil, fmt.Errorf("allocations for %s were made from range %s, which does not match the configured range %s", a.name, ra.Range, a.r.String())
+	}
+	return ra, new(big.Int).SetBytes(ra.Data), nil
+}
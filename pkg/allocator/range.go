@@ -0,0 +1,159 @@
+package allocator
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// CIDRRange 是一个 IPv4 网段里可以分配的地址，不包括网络地址和广播地址
+type CIDRRange struct {
+	network *net.IPNet
+	// base 是第一个可分配的地址
+	base uint32
+	size int
+}
+
+// ParseCIDRRange 解析 IPv4 网段，比如 10.96.0.0/16
+func ParseCIDRRange(cidr string) (*CIDRRange, error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+	if network.IP.To4() == nil {
+		return nil, fmt.Errorf("%s is not an IPv4 range", cidr)
+	}
+	ones, bits := network.Mask.Size()
+	if bits-ones < 2 || bits-ones > 24 {
+		return nil, fmt.Errorf("range %s must have a prefix length between /8 and /30", cidr)
+	}
+	return &CIDRRange{
+		network: network,
+		base:    binary.BigEndian.Uint32(network.IP.To4()) + 1,
+		size:    1<<(bits-ones) - 2,
+	}, nil
+}
+
+func (r *CIDRRange) String() string { return r.network.String() }
+func (r *CIDRRange) Size() int      { return r.size }
+
+// Contains 表示 ip 是否是范围里可以分配的地址
+func (r *CIDRRange) Contains(ip string) bool {
+	_, err := r.Offset(ip)
+	return err == nil
+}
+
+func (r *CIDRRange) Offset(value string) (int, error) {
+	ip := net.ParseIP(value).To4()
+	if ip == nil {
+		return 0, fmt.Errorf("%q is not a valid IPv4 address", value)
+	}
+	n := binary.BigEndian.Uint32(ip)
+	if !r.network.Contains(ip) || n < r.base || n >= r.base+uint32(r.size) {
+		return 0, fmt.Errorf("%s is not in the allocatable range of %s", value, r.network)
+	}
+	return int(n - r.base), nil
+}
+
+func (r *CIDRRange) Value(offset int) string {
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, r.base+uint32(offset))
+	return ip.String()
+}
+
+// PortRange 是一段连续的端口，包含两端
+type PortRange struct {
+	Base int
+	Max  int
+}
+
+// ParsePortRange 解析 30000-32767 这样的端口范围
+func ParsePortRange(s string) (*PortRange, error) {
+	low, high, ok := strings.Cut(s, "-")
+	if !ok {
+		return nil, fmt.Errorf("port range %q must look like 30000-32767", s)
+	}
+	base, err := strconv.Atoi(strings.TrimSpace(low))
+	if err != nil {
+		return nil, fmt.Errorf("port range %q: %v", s, err)
+	}
+	max, err := strconv.Atoi(strings.TrimSpace(high))
+	if err != nil {
+		return nil, fmt.Errorf("port range %q: %v", s, err)
+	}
+	if base < 1 || max > 65535 || base > max {
+		return nil, fmt.Errorf("port range %q must be within 1-65535 with the lower port first", s)
+	}
+	return &PortRange{Base: base, Max: max}, nil
+}
+
+func (r *PortRange) String() string { return fmt.Sprintf("%d-%d", r.Base, r.Max) }
+func (r *PortRange) Size() int      { return r.Max - r.Base + 1 }
+
+func (r *PortRange) Contains(port int) bool {
+	return port >= r.Base && port <= r.Max
+}
+
+func (r *PortRange) Offset(value string) (int, error) {
+	port, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a valid port", value)
+	}
+	if !r.Contains(port) {
+		return 0, fmt.Errorf("port %d is not in the range %s", port, r)
+	}
+	return port - r.Base, nil
+}
+
+func (r *PortRange) Value(offset int) string {
+	return strconv.Itoa(r.Base + offset)
+}
+
+// SubnetRange 把一个 IPv4 网段切成固定长度的子网，按子网分配，比如从 10.244.0.0/16 里分配 /24 给每个节点
+type SubnetRange struct {
+	network  *net.IPNet
+	maskSize int
+	size     int
+}
+
+// ParseSubnetRange 解析网段 cidr，maskSize 是每个子网的前缀长度
+func ParseSubnetRange(cidr string, maskSize int) (*SubnetRange, error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+	if network.IP.To4() == nil {
+		return nil, fmt.Errorf("%s is not an IPv4 range", cidr)
+	}
+	ones, _ := network.Mask.Size()
+	if maskSize < ones || maskSize > 30 {
+		return nil, fmt.Errorf("subnet prefix length %d must be between /%d and /30", maskSize, ones)
+	}
+	if maskSize-ones > 24 {
+		return nil, fmt.Errorf("range %s has too many /%d subnets", cidr, maskSize)
+	}
+	return &SubnetRange{network: network, maskSize: maskSize, size: 1 << (maskSize - ones)}, nil
+}
+
+func (r *SubnetRange) String() string { return fmt.Sprintf("%s/%d", r.network, r.maskSize) }
+func (r *SubnetRange) Size() int      { return r.size }
+
+func (r *SubnetRange) Offset(value string) (int, error) {
+	ip, subnet, err := net.ParseCIDR(value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a valid CIDR", value)
+	}
+	if ones, _ := subnet.Mask.Size(); ones != r.maskSize || !ip.Equal(subnet.IP) || !r.network.Contains(subnet.IP) {
+		return 0, fmt.Errorf("%s is not a /%d subnet of %s", value, r.maskSize, r.network)
+	}
+	first := binary.BigEndian.Uint32(r.network.IP.To4())
+	return int((binary.BigEndian.Uint32(subnet.IP.To4()) - first) >> (32 - r.maskSize)), nil
+}
+
+func (r *SubnetRange) Value(offset int) string {
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, binary.BigEndian.Uint32(r.network.IP.To4())+uint32(offset)<<(32-r.maskSize))
+	return fmt.Sprintf("%s/%d", ip, r.maskSize)
+}
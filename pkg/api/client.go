@@ -10,8 +10,9 @@ import (
 )
 
 type Client struct {
-	baseURL    *url.URL
-	httpClient *http.Client
+	baseURL      *url.URL
+	httpClient   *http.Client
+	fieldManager string
 }
 
 func NewClient(baseURLStr string) (*Client, error) {
@@ -25,6 +26,12 @@ func NewClient(baseURLStr string) (*Client, error) {
 	}, nil
 }
 
+// SetFieldManager sets the manager name sent with every write, so the API
+// server can record which fields this client owns.
+func (c *Client) SetFieldManager(name string) {
+	c.fieldManager = name
+}
+
 func (c *Client) buildURL(pathSegments ...string) string {
 	finalPath := c.baseURL.Path
 	for _, segment := range pathSegments {
@@ -35,6 +42,19 @@ func (c *Client) buildURL(pathSegments ...string) string {
 	return u.String()
 }
 
+// buildWriteURL 和 buildURL 一样，但会带上 fieldManager 参数，用于创建、更新和 apply 请求
+func (c *Client) buildWriteURL(query url.Values, pathSegments ...string) string {
+	u, _ := url.Parse(c.buildURL(pathSegments...))
+	if query == nil {
+		query = url.Values{}
+	}
+	if c.fieldManager != "" {
+		query.Set("fieldManager", c.fieldManager)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
 func decodeAPIError(resp *http.Response) error {
 	var payload struct {
 		Error string `json:"error"`
@@ -45,11 +65,55 @@ func decodeAPIError(resp *http.Response) error {
 	return fmt.Errorf("server returned %d %s", resp.StatusCode, resp.Status)
 }
 
+// do 发送一个 JSON 请求：in 不为 nil 时作为请求体，响应状态码不在 expected 里时返回 API 错误，out 不为 nil 时解码响应体
+func (c *Client) do(method, urlStr string, in, out interface{}, expected ...int) error {
+	var body *bytes.Buffer
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("marshalling request: %w", err)
+		}
+		body = bytes.NewBuffer(data)
+	} else {
+		body = &bytes.Buffer{}
+	}
+	req, err := http.NewRequest(method, urlStr, body)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	ok := false
+	for _, code := range expected {
+		if resp.StatusCode == code {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return decodeAPIError(resp)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("decoding response: %w", err)
+		}
+	}
+	return nil
+}
+
 func (c *Client) CreatePod(namespace string, pod *Pod) (*Pod, error) {
 	if namespace == "" {
 		namespace = "default"
 	}
-	urlStr := c.buildURL("api", "v1", "namespaces", namespace, "pods")
+	urlStr := c.buildWriteURL(nil, "api", "v1", "namespaces", namespace, "pods")
 
 	body, err := json.Marshal(pod)
 	if err != nil {
@@ -130,8 +194,22 @@ func (c *Client) ListPods(namespace string, phase PodPhase) ([]Pod, error) {
 	if err := json.NewDecoder(resp.Body).Decode(&all); err != nil {
 		return nil, fmt.Errorf("decoding response: %w", err)
 	}
+	return filterPodsByPhase(all, phase), nil
+}
+
+// ListAllPods lists pods across all namespaces, optionally filtered by phase.
+func (c *Client) ListAllPods(phase PodPhase) ([]Pod, error) {
+	urlStr := c.buildURL("api", "v1", "pods")
+	var all []Pod
+	if err := c.do(http.MethodGet, urlStr, nil, &all, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return filterPodsByPhase(all, phase), nil
+}
+
+func filterPodsByPhase(all []Pod, phase PodPhase) []Pod {
 	if phase == "" {
-		return all, nil
+		return all
 	}
 	out := make([]Pod, 0, len(all))
 	for _, p := range all {
@@ -139,7 +217,7 @@ func (c *Client) ListPods(namespace string, phase PodPhase) ([]Pod, error) {
 			out = append(out, p)
 		}
 	}
-	return out, nil
+	return out
 }
 
 func (c *Client) UpdatePod(pod *Pod) error {
@@ -149,7 +227,7 @@ func (c *Client) UpdatePod(pod *Pod) error {
 	if pod.Namespace == "" {
 		pod.Namespace = "default"
 	}
-	urlStr := c.buildURL("api", "v1", "namespaces", pod.Namespace, "pods", pod.Name)
+	urlStr := c.buildWriteURL(nil, "api", "v1", "namespaces", pod.Namespace, "pods", pod.Name)
 
 	body, err := json.Marshal(pod)
 	if err != nil {
@@ -195,8 +273,53 @@ func (c *Client) DeletePod(namespace, name string) error {
 	return nil
 }
 
+// ApplyPod sends patch, a partial pod in JSON, as a server-side apply on behalf
+// of the client's field manager. The pod is created if it does not exist.
+// With force set, fields owned by other managers are taken over instead of
+// being reported as conflicts.
+func (c *Client) ApplyPod(namespace, name string, patch []byte, force bool) (*Pod, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+	var pod Pod
+	if err := c.apply(patch, force, &pod, "api", "v1", "namespaces", namespace, "pods", name); err != nil {
+		return nil, err
+	}
+	return &pod, nil
+}
+
+func (c *Client) apply(patch []byte, force bool, out interface{}, pathSegments ...string) error {
+	if c.fieldManager == "" {
+		return fmt.Errorf("a field manager must be set before applying")
+	}
+	query := url.Values{}
+	if force {
+		query.Set("force", "true")
+	}
+	urlStr := c.buildWriteURL(query, pathSegments...)
+	req, err := http.NewRequest(http.MethodPatch, urlStr, bytes.NewBuffer(patch))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", ApplyPatchType)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return decodeAPIError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
 func (c *Client) CreateNode(node *Node) (*Node, error) {
-	urlStr := c.buildURL("api", "v1", "nodes")
+	urlStr := c.buildWriteURL(nil, "api", "v1", "nodes")
 	body, err := json.Marshal(node)
 	if err != nil {
 		return nil, fmt.Errorf("marshalling node: %w", err)
@@ -285,7 +408,7 @@ func (c *Client) UpdateNode(node *Node) error {
 	if node == nil || node.Name == "" {
 		return fmt.Errorf("node name must be specified for update")
 	}
-	urlStr := c.buildURL("api", "v1", "nodes", node.Name)
+	urlStr := c.buildWriteURL(nil, "api", "v1", "nodes", node.Name)
 
 	body, err := json.Marshal(node)
 	if err != nil {
@@ -308,3 +431,13 @@ func (c *Client) UpdateNode(node *Node) error {
 	}
 	return nil
 }
+
+// ApplyNode sends patch, a partial node in JSON, as a server-side apply on
+// behalf of the client's field manager.
+func (c *Client) ApplyNode(name string, patch []byte, force bool) (*Node, error) {
+	var node Node
+	if err := c.apply(patch, force, &node, "api", "v1", "nodes", name); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
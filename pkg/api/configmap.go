@@ -0,0 +1,39 @@
+package api
+
+// ConfigMap 保存不敏感的配置，pod 通过环境变量或者卷里的文件使用它
+type ConfigMap struct {
+	ObjectMeta
+	Data map[string]string `json:"data,omitempty"`
+	// BinaryData 保存不是 UTF-8 文本的值，在 JSON 里是 base64 编码的，键不能和 Data 重复
+	BinaryData map[string][]byte `json:"binaryData,omitempty"`
+}
+
+// MaxConfigDataSize 是一个 ConfigMap 或 Secret 所有键和值加起来的大小上限
+const MaxConfigDataSize = 1 << 20
+
+func (in *ConfigMap) DeepCopyInto(out *ConfigMap) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Data = copyStringMap(in.Data)
+	out.BinaryData = copyBytesMap(in.BinaryData)
+}
+
+func (in *ConfigMap) DeepCopy() *ConfigMap {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMap)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func copyBytesMap(in map[string][]byte) map[string][]byte {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string][]byte, len(in))
+	for k, v := range in {
+		out[k] = append([]byte(nil), v...)
+	}
+	return out
+}
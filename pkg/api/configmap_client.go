@@ -0,0 +1,40 @@
+package api
+
+import "fmt"
+
+func (c *Client) CreateConfigMap(namespace string, configMap *ConfigMap) (*ConfigMap, error) {
+	return createObject(c, configMap, namespacedPath(namespace, "configmaps")...)
+}
+
+func (c *Client) GetConfigMap(namespace, name string) (*ConfigMap, error) {
+	return getObject[ConfigMap](c, namespacedPath(namespace, "configmaps", name)...)
+}
+
+func (c *Client) ListConfigMaps(namespace string) ([]ConfigMap, error) {
+	return listObjects[ConfigMap](c, namespacedPath(namespace, "configmaps")...)
+}
+
+// ListAllConfigMaps lists ConfigMaps across all namespaces.
+func (c *Client) ListAllConfigMaps() ([]ConfigMap, error) {
+	return listObjects[ConfigMap](c, clusterPath("configmaps")...)
+}
+
+func (c *Client) UpdateConfigMap(configMap *ConfigMap) (*ConfigMap, error) {
+	if configMap == nil || configMap.Name == "" {
+		return nil, fmt.Errorf("configMap name must be specified for update")
+	}
+	return updateObject(c, configMap, namespacedPath(configMap.Namespace, "configmaps", configMap.Name)...)
+}
+
+func (c *Client) DeleteConfigMap(namespace, name string) error {
+	return deleteObject(c, namespacedPath(namespace, "configmaps", name)...)
+}
+
+func (c *Client) ApplyConfigMap(namespace, name string, patch []byte, force bool) (*ConfigMap, error) {
+	return applyObject[ConfigMap](c, patch, force, namespacedPath(namespace, "configmaps", name)...)
+}
+
+// WatchAllConfigMaps 监听所有命名空间里 ConfigMap 的变化
+func (c *Client) WatchAllConfigMaps() (<-chan WatchEvent[ConfigMap], func(), error) {
+	return watch[ConfigMap](c, c.buildURL(clusterPath("configmaps")...))
+}
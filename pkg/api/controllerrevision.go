@@ -0,0 +1,29 @@
+package api
+
+import "encoding/json"
+
+// ControllerRevision 保存 controller 某个版本的模板，StatefulSet 用它在分区更新时按旧模板重建 pod
+type ControllerRevision struct {
+	ObjectMeta
+	// Data 是这个版本的 pod 模板，JSON 格式
+	Data     json.RawMessage `json:"data"`
+	Revision int64           `json:"revision"`
+}
+
+func (in *ControllerRevision) DeepCopyInto(out *ControllerRevision) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Data != nil {
+		out.Data = make(json.RawMessage, len(in.Data))
+		copy(out.Data, in.Data)
+	}
+}
+
+func (in *ControllerRevision) DeepCopy() *ControllerRevision {
+	if in == nil {
+		return nil
+	}
+	out := new(ControllerRevision)
+	in.DeepCopyInto(out)
+	return out
+}
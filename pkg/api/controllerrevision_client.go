@@ -0,0 +1,40 @@
+package api
+
+import "fmt"
+
+func (c *Client) CreateControllerRevision(namespace string, cr *ControllerRevision) (*ControllerRevision, error) {
+	return createObject(c, cr, namespacedPath(namespace, "controllerrevisions")...)
+}
+
+func (c *Client) GetControllerRevision(namespace, name string) (*ControllerRevision, error) {
+	return getObject[ControllerRevision](c, namespacedPath(namespace, "controllerrevisions", name)...)
+}
+
+func (c *Client) ListControllerRevisions(namespace string) ([]ControllerRevision, error) {
+	return listObjects[ControllerRevision](c, namespacedPath(namespace, "controllerrevisions")...)
+}
+
+// ListAllControllerRevisions lists ControllerRevisions across all namespaces.
+func (c *Client) ListAllControllerRevisions() ([]ControllerRevision, error) {
+	return listObjects[ControllerRevision](c, clusterPath("controllerrevisions")...)
+}
+
+func (c *Client) UpdateControllerRevision(cr *ControllerRevision) (*ControllerRevision, error) {
+	if cr == nil || cr.Name == "" {
+		return nil, fmt.Errorf("controllerrevision name must be specified for update")
+	}
+	return updateObject(c, cr, namespacedPath(cr.Namespace, "controllerrevisions", cr.Name)...)
+}
+
+func (c *Client) DeleteControllerRevision(namespace, name string) error {
+	return deleteObject(c, namespacedPath(namespace, "controllerrevisions", name)...)
+}
+
+func (c *Client) ApplyControllerRevision(namespace, name string, patch []byte, force bool) (*ControllerRevision, error) {
+	return applyObject[ControllerRevision](c, patch, force, namespacedPath(namespace, "controllerrevisions", name)...)
+}
+
+// WatchAllControllerRevisions 监听所有命名空间里 ControllerRevision 的变化
+func (c *Client) WatchAllControllerRevisions() (<-chan WatchEvent[ControllerRevision], func(), error) {
+	return watch[ControllerRevision](c, c.buildURL(clusterPath("controllerrevisions")...))
+}
@@ -0,0 +1,81 @@
+package api
+
+import "time"
+
+type ConcurrencyPolicy string
+
+const (
+	// AllowConcurrent 允许上一次的 Job 还没结束时创建新的 Job
+	AllowConcurrent ConcurrencyPolicy = "Allow"
+	// ForbidConcurrent 上一次的 Job 还在运行时跳过这次调度
+	ForbidConcurrent ConcurrencyPolicy = "Forbid"
+	// ReplaceConcurrent 删除还在运行的 Job，用新的 Job 替换它
+	ReplaceConcurrent ConcurrencyPolicy = "Replace"
+)
+
+const (
+	DefaultSuccessfulJobsHistoryLimit = 3
+	DefaultFailedJobsHistoryLimit     = 1
+)
+
+type CronJob struct {
+	ObjectMeta
+	Spec   CronJobSpec   `json:"spec"`
+	Status CronJobStatus `json:"status"`
+}
+
+type CronJobSpec struct {
+	// Schedule 是标准的 5 段 cron 表达式，也支持 @hourly、@daily 等写法
+	Schedule string `json:"schedule"`
+	// TimeZone 是解释 Schedule 使用的时区名，比如 Asia/Shanghai，默认使用 controller 所在机器的时区
+	TimeZone *string `json:"timeZone,omitempty"`
+	// StartingDeadlineSeconds 设置之后，错过调度时间超过这么多秒的 Job 不再补建
+	StartingDeadlineSeconds *int64            `json:"startingDeadlineSeconds,omitempty"`
+	ConcurrencyPolicy       ConcurrencyPolicy `json:"concurrencyPolicy,omitempty"` //默认 Allow
+	// Suspend 为 true 时不再创建新的 Job，已经在运行的不受影响
+	Suspend                    bool            `json:"suspend,omitempty"`
+	SuccessfulJobsHistoryLimit *int            `json:"successfulJobsHistoryLimit,omitempty"` //默认 3
+	FailedJobsHistoryLimit     *int            `json:"failedJobsHistoryLimit,omitempty"`     //默认 1
+	JobTemplate                JobTemplateSpec `json:"jobTemplate"`
+}
+
+// JobTemplateSpec 描述 CronJob 每次创建的 Job
+type JobTemplateSpec struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     JobSpec    `json:"spec"`
+}
+
+type CronJobStatus struct {
+	Active             []ObjectReference `json:"active,omitempty"` //还没有结束的 Job
+	LastScheduleTime   *time.Time        `json:"lastScheduleTime,omitempty"`
+	LastSuccessfulTime *time.Time        `json:"lastSuccessfulTime,omitempty"`
+}
+
+func (in *CronJob) DeepCopyInto(out *CronJob) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Spec.TimeZone != nil {
+		tz := *in.Spec.TimeZone
+		out.Spec.TimeZone = &tz
+	}
+	out.Spec.StartingDeadlineSeconds = copyInt64Ptr(in.Spec.StartingDeadlineSeconds)
+	out.Spec.SuccessfulJobsHistoryLimit = copyIntPtr(in.Spec.SuccessfulJobsHistoryLimit)
+	out.Spec.FailedJobsHistoryLimit = copyIntPtr(in.Spec.FailedJobsHistoryLimit)
+	in.Spec.JobTemplate.Metadata.DeepCopyInto(&out.Spec.JobTemplate.Metadata)
+	in.Spec.JobTemplate.Spec.DeepCopyInto(&out.Spec.JobTemplate.Spec)
+	if in.Status.Active != nil {
+		out.Status.Active = make([]ObjectReference, len(in.Status.Active))
+		copy(out.Status.Active, in.Status.Active)
+	}
+	out.Status.LastScheduleTime = copyTime(in.Status.LastScheduleTime)
+	out.Status.LastSuccessfulTime = copyTime(in.Status.LastSuccessfulTime)
+}
+
+func (in *CronJob) DeepCopy() *CronJob {
+	if in == nil {
+		return nil
+	}
+	out := new(CronJob)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,48 @@
+package api
+
+import "fmt"
+
+func (c *Client) CreateCronJob(namespace string, cj *CronJob) (*CronJob, error) {
+	return createObject(c, cj, namespacedPath(namespace, "cronjobs")...)
+}
+
+func (c *Client) GetCronJob(namespace, name string) (*CronJob, error) {
+	return getObject[CronJob](c, namespacedPath(namespace, "cronjobs", name)...)
+}
+
+func (c *Client) ListCronJobs(namespace string) ([]CronJob, error) {
+	return listObjects[CronJob](c, namespacedPath(namespace, "cronjobs")...)
+}
+
+// ListAllCronJobs lists CronJobs across all namespaces.
+func (c *Client) ListAllCronJobs() ([]CronJob, error) {
+	return listObjects[CronJob](c, clusterPath("cronjobs")...)
+}
+
+func (c *Client) UpdateCronJob(cj *CronJob) (*CronJob, error) {
+	if cj == nil || cj.Name == "" {
+		return nil, fmt.Errorf("cronjob name must be specified for update")
+	}
+	return updateObject(c, cj, namespacedPath(cj.Namespace, "cronjobs", cj.Name)...)
+}
+
+// UpdateCronJobStatus 只更新 status 子资源，spec 的修改会被忽略
+func (c *Client) UpdateCronJobStatus(cj *CronJob) (*CronJob, error) {
+	if cj == nil || cj.Name == "" {
+		return nil, fmt.Errorf("cronjob name must be specified for status update")
+	}
+	return updateObject(c, cj, namespacedPath(cj.Namespace, "cronjobs", cj.Name, "status")...)
+}
+
+func (c *Client) DeleteCronJob(namespace, name string) error {
+	return deleteObject(c, namespacedPath(namespace, "cronjobs", name)...)
+}
+
+func (c *Client) ApplyCronJob(namespace, name string, patch []byte, force bool) (*CronJob, error) {
+	return applyObject[CronJob](c, patch, force, namespacedPath(namespace, "cronjobs", name)...)
+}
+
+// WatchAllCronJobs 监听所有命名空间里 CronJob 的变化
+func (c *Client) WatchAllCronJobs() (<-chan WatchEvent[CronJob], func(), error) {
+	return watch[CronJob](c, c.buildURL(clusterPath("cronjobs")...))
+}
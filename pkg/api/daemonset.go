@@ -0,0 +1,68 @@
+package api
+
+type DaemonSetUpdateStrategyType string
+
+const (
+	// RollingUpdateDaemonSetStrategyType 模板变化后逐个节点替换旧的 pod，同时不可用的节点数不超过 maxUnavailable
+	RollingUpdateDaemonSetStrategyType DaemonSetUpdateStrategyType = "RollingUpdate"
+	// OnDeleteDaemonSetStrategyType 只有旧的 pod 被手动删除后才用新模板重建
+	OnDeleteDaemonSetStrategyType DaemonSetUpdateStrategyType = "OnDelete"
+)
+
+// ControllerRevisionHashLabel 记录 DaemonSet 和 StatefulSet 的 pod 是按哪个版本的模板创建的
+const ControllerRevisionHashLabel = "controller-revision-hash"
+
+type DaemonSet struct {
+	ObjectMeta
+	Spec   DaemonSetSpec   `json:"spec"`
+	Status DaemonSetStatus `json:"status"`
+}
+
+type DaemonSetSpec struct {
+	Selector        *LabelSelector          `json:"selector"`
+	Template        PodTemplateSpec         `json:"template"`
+	UpdateStrategy  DaemonSetUpdateStrategy `json:"updateStrategy"`
+	MinReadySeconds int                     `json:"minReadySeconds,omitempty"`
+}
+
+type DaemonSetUpdateStrategy struct {
+	Type          DaemonSetUpdateStrategyType `json:"type"`
+	RollingUpdate *RollingUpdateDaemonSet     `json:"rollingUpdate,omitempty"`
+}
+
+type RollingUpdateDaemonSet struct {
+	// MaxUnavailable 是更新过程中最多可以有多少个节点上的 pod 不可用，百分比向上取整，默认 1
+	MaxUnavailable *IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+type DaemonSetStatus struct {
+	ObservedGeneration     int64 `json:"observedGeneration"`
+	DesiredNumberScheduled int   `json:"desiredNumberScheduled"` //应该运行 pod 的节点数
+	CurrentNumberScheduled int   `json:"currentNumberScheduled"` //应该运行并且正在运行 pod 的节点数
+	NumberMisscheduled     int   `json:"numberMisscheduled"`     //不应该运行却在运行 pod 的节点数
+	NumberReady            int   `json:"numberReady"`
+	UpdatedNumberScheduled int   `json:"updatedNumberScheduled"` //运行着当前模板 pod 的节点数
+	NumberAvailable        int   `json:"numberAvailable"`
+	NumberUnavailable      int   `json:"numberUnavailable"`
+}
+
+func (in *DaemonSet) DeepCopyInto(out *DaemonSet) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec.Selector = in.Spec.Selector.DeepCopy()
+	in.Spec.Template.DeepCopyInto(&out.Spec.Template)
+	if in.Spec.UpdateStrategy.RollingUpdate != nil {
+		out.Spec.UpdateStrategy.RollingUpdate = &RollingUpdateDaemonSet{
+			MaxUnavailable: copyIntOrString(in.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable),
+		}
+	}
+}
+
+func (in *DaemonSet) DeepCopy() *DaemonSet {
+	if in == nil {
+		return nil
+	}
+	out := new(DaemonSet)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,48 @@
+package api
+
+import "fmt"
+
+func (c *Client) CreateDaemonSet(namespace string, ds *DaemonSet) (*DaemonSet, error) {
+	return createObject(c, ds, namespacedPath(namespace, "daemonsets")...)
+}
+
+func (c *Client) GetDaemonSet(namespace, name string) (*DaemonSet, error) {
+	return getObject[DaemonSet](c, namespacedPath(namespace, "daemonsets", name)...)
+}
+
+func (c *Client) ListDaemonSets(namespace string) ([]DaemonSet, error) {
+	return listObjects[DaemonSet](c, namespacedPath(namespace, "daemonsets")...)
+}
+
+// ListAllDaemonSets lists DaemonSets across all namespaces.
+func (c *Client) ListAllDaemonSets() ([]DaemonSet, error) {
+	return listObjects[DaemonSet](c, clusterPath("daemonsets")...)
+}
+
+func (c *Client) UpdateDaemonSet(ds *DaemonSet) (*DaemonSet, error) {
+	if ds == nil || ds.Name == "" {
+		return nil, fmt.Errorf("daemonset name must be specified for update")
+	}
+	return updateObject(c, ds, namespacedPath(ds.Namespace, "daemonsets", ds.Name)...)
+}
+
+// UpdateDaemonSetStatus 只更新 status 子资源，spec 的修改会被忽略
+func (c *Client) UpdateDaemonSetStatus(ds *DaemonSet) (*DaemonSet, error) {
+	if ds == nil || ds.Name == "" {
+		return nil, fmt.Errorf("daemonset name must be specified for status update")
+	}
+	return updateObject(c, ds, namespacedPath(ds.Namespace, "daemonsets", ds.Name, "status")...)
+}
+
+func (c *Client) DeleteDaemonSet(namespace, name string) error {
+	return deleteObject(c, namespacedPath(namespace, "daemonsets", name)...)
+}
+
+func (c *Client) ApplyDaemonSet(namespace, name string, patch []byte, force bool) (*DaemonSet, error) {
+	return applyObject[DaemonSet](c, patch, force, namespacedPath(namespace, "daemonsets", name)...)
+}
+
+// WatchAllDaemonSets 监听所有命名空间里 DaemonSet 的变化
+func (c *Client) WatchAllDaemonSets() (<-chan WatchEvent[DaemonSet], func(), error) {
+	return watch[DaemonSet](c, c.buildURL(clusterPath("daemonsets")...))
+}
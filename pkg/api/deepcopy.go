@@ -0,0 +1,148 @@
+package api
+
+import "time"
+
+// 下面的 DeepCopy 方法是手写的：store 在读写边界上复制对象，调用方拿到的对象和 store 里保存的互不影响。
+// 新增字段时如果是指针、slice 或 map，需要在这里同步处理
+
+func copyTime(in *time.Time) *time.Time {
+	if in == nil {
+		return nil
+	}
+	out := *in
+	return &out
+}
+
+func (in *ManagedFieldsEntry) DeepCopyInto(out *ManagedFieldsEntry) {
+	*out = *in
+	out.Time = copyTime(in.Time)
+	if in.Fields != nil {
+		out.Fields = make([]string, len(in.Fields))
+		copy(out.Fields, in.Fields)
+	}
+}
+
+func copyStringMap(in map[string]string) map[string]string {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
+func (in *ObjectMeta) DeepCopyInto(out *ObjectMeta) {
+	*out = *in
+	out.CreationTimestamp = copyTime(in.CreationTimestamp)
+	out.DeletionTimestamp = copyTime(in.DeletionTimestamp)
+	out.Labels = copyStringMap(in.Labels)
+	out.Annotations = copyStringMap(in.Annotations)
+	if in.OwnerReferences != nil {
+		out.OwnerReferences = make([]OwnerReference, len(in.OwnerReferences))
+		copy(out.OwnerReferences, in.OwnerReferences)
+	}
+	if in.Finalizers != nil {
+		out.Finalizers = make([]string, len(in.Finalizers))
+		copy(out.Finalizers, in.Finalizers)
+	}
+	if in.ManagedFields != nil {
+		out.ManagedFields = make([]ManagedFieldsEntry, len(in.ManagedFields))
+		for i := range in.ManagedFields {
+			in.ManagedFields[i].DeepCopyInto(&out.ManagedFields[i])
+		}
+	}
+}
+
+func (in *PodSpec) DeepCopyInto(out *PodSpec) {
+	*out = *in
+	if in.Command != nil {
+		out.Command = make([]string, len(in.Command))
+		copy(out.Command, in.Command)
+	}
+	out.NodeSelector = copyStringMap(in.NodeSelector)
+	if in.Tolerations != nil {
+		out.Tolerations = make([]Toleration, len(in.Tolerations))
+		copy(out.Tolerations, in.Tolerations)
+	}
+	if in.Env != nil {
+		out.Env = make([]EnvVar, len(in.Env))
+		for i := range in.Env {
+			in.Env[i].DeepCopyInto(&out.Env[i])
+		}
+	}
+	if in.Volumes != nil {
+		out.Volumes = make([]Volume, len(in.Volumes))
+		for i := range in.Volumes {
+			in.Volumes[i].DeepCopyInto(&out.Volumes[i])
+		}
+	}
+	out.Resources.Requests = in.Resources.Requests.DeepCopy()
+	if in.VolumeMounts != nil {
+		out.VolumeMounts = make([]VolumeMount, len(in.VolumeMounts))
+		copy(out.VolumeMounts, in.VolumeMounts)
+	}
+}
+
+func (in *PodTemplateSpec) DeepCopyInto(out *PodTemplateSpec) {
+	*out = *in
+	in.Metadata.DeepCopyInto(&out.Metadata)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+func (in *Pod) DeepCopyInto(out *Pod) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.PodSpec.DeepCopyInto(&out.PodSpec)
+	out.StartTime = copyTime(in.StartTime)
+}
+
+func (in *Pod) DeepCopy() *Pod {
+	if in == nil {
+		return nil
+	}
+	out := new(Pod)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *Node) DeepCopyInto(out *Node) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Taints != nil {
+		out.Taints = make([]Taint, len(in.Taints))
+		copy(out.Taints, in.Taints)
+	}
+	if in.LastHeartbeatTime != nil {
+		t := *in.LastHeartbeatTime
+		out.LastHeartbeatTime = &t
+	}
+}
+
+func (in *Node) DeepCopy() *Node {
+	if in == nil {
+		return nil
+	}
+	out := new(Node)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *Namespace) DeepCopyInto(out *Namespace) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Spec.Finalizers != nil {
+		out.Spec.Finalizers = make([]FinalizerName, len(in.Spec.Finalizers))
+		copy(out.Spec.Finalizers, in.Spec.Finalizers)
+	}
+}
+
+func (in *Namespace) DeepCopy() *Namespace {
+	if in == nil {
+		return nil
+	}
+	out := new(Namespace)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,62 @@
+package api
+
+import "fmt"
+
+// DeletionPropagation 决定删除一个对象时怎样处理 ownerReferences 指向它的 dependents
+type DeletionPropagation string
+
+const (
+	// DeletePropagationBackground 立即删除对象，由垃圾回收器在后台删除它的 dependents
+	DeletePropagationBackground DeletionPropagation = "Background"
+	// DeletePropagationForeground 对象先进入删除中状态，等 blockOwnerDeletion 的 dependents 都删除之后才删除
+	DeletePropagationForeground DeletionPropagation = "Foreground"
+	// DeletePropagationOrphan 删除对象但保留 dependents，并去掉它们指向这个对象的 ownerReference
+	DeletePropagationOrphan DeletionPropagation = "Orphan"
+)
+
+const (
+	// FinalizerOrphanDependents 表示垃圾回收器还没有去掉 dependents 的 ownerReference
+	FinalizerOrphanDependents = "orphan"
+	// FinalizerDeleteDependents 表示垃圾回收器还在等待 dependents 删除
+	FinalizerDeleteDependents = "foregroundDeletion"
+)
+
+// ParseDeletionPropagation 解析 propagationPolicy 参数，为空时使用 Background
+func ParseDeletionPropagation(s string) (DeletionPropagation, error) {
+	switch policy := DeletionPropagation(s); policy {
+	case "":
+		return DeletePropagationBackground, nil
+	case DeletePropagationBackground, DeletePropagationForeground, DeletePropagationOrphan:
+		return policy, nil
+	}
+	return "", fmt.Errorf("propagationPolicy must be Background, Foreground or Orphan, got %q", s)
+}
+
+func (m *ObjectMeta) HasFinalizer(finalizer string) bool {
+	for _, f := range m.Finalizers {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// AddFinalizer 添加 finalizer，已经存在时不做修改
+func (m *ObjectMeta) AddFinalizer(finalizer string) {
+	if !m.HasFinalizer(finalizer) {
+		m.Finalizers = append(m.Finalizers, finalizer)
+	}
+}
+
+// RemoveFinalizer 删除 finalizer，返回它原来是否存在
+func (m *ObjectMeta) RemoveFinalizer(finalizer string) bool {
+	var kept []string
+	for _, f := range m.Finalizers {
+		if f != finalizer {
+			kept = append(kept, f)
+		}
+	}
+	removed := len(kept) != len(m.Finalizers)
+	m.Finalizers = kept
+	return removed
+}
@@ -0,0 +1,162 @@
+package api
+
+import "time"
+
+type DeploymentStrategyType string
+
+const (
+	// RollingUpdateDeploymentStrategyType 按 maxSurge/maxUnavailable 逐步用新的 ReplicaSet 替换旧的
+	RollingUpdateDeploymentStrategyType DeploymentStrategyType = "RollingUpdate"
+	// RecreateDeploymentStrategyType 先删除所有旧的 pod，再创建新的
+	RecreateDeploymentStrategyType DeploymentStrategyType = "Recreate"
+)
+
+const (
+	// RevisionAnnotation 记录 ReplicaSet 对应的 Deployment 版本号
+	RevisionAnnotation = "deployment.kubernetes.io/revision"
+	// PodTemplateHashLabel 是加在 ReplicaSet 选择器和 pod 上的模板哈希，用来区分不同版本的 pod
+	PodTemplateHashLabel = "pod-template-hash"
+
+	DefaultRevisionHistoryLimit    = 10
+	DefaultProgressDeadlineSeconds = 600
+)
+
+type Deployment struct {
+	ObjectMeta
+	Spec   DeploymentSpec   `json:"spec"`
+	Status DeploymentStatus `json:"status"`
+}
+
+type DeploymentSpec struct {
+	Replicas        int                `json:"replicas"`
+	Selector        *LabelSelector     `json:"selector"`
+	Template        PodTemplateSpec    `json:"template"`
+	Strategy        DeploymentStrategy `json:"strategy"`
+	MinReadySeconds int                `json:"minReadySeconds,omitempty"`
+	// RevisionHistoryLimit 是保留的旧 ReplicaSet 数量，用于回滚，默认 10
+	RevisionHistoryLimit *int `json:"revisionHistoryLimit,omitempty"`
+	// Paused 为 true 时 controller 不会推进滚动更新，只会调整副本数
+	Paused bool `json:"paused,omitempty"`
+	// ProgressDeadlineSeconds 是滚动更新没有进展多久之后被标记为失败，默认 600
+	ProgressDeadlineSeconds *int `json:"progressDeadlineSeconds,omitempty"`
+}
+
+type DeploymentStrategy struct {
+	Type          DeploymentStrategyType   `json:"type"`
+	RollingUpdate *RollingUpdateDeployment `json:"rollingUpdate,omitempty"`
+}
+
+type RollingUpdateDeployment struct {
+	// MaxSurge 是更新过程中最多可以超出期望副本数多少个 pod，百分比向上取整，默认 25%
+	MaxSurge *IntOrString `json:"maxSurge,omitempty"`
+	// MaxUnavailable 是更新过程中最多可以有多少个 pod 不可用，百分比向下取整，默认 25%
+	MaxUnavailable *IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+type DeploymentStatus struct {
+	// ObservedGeneration 是 controller 最近一次处理的 metadata.generation
+	ObservedGeneration  int64                 `json:"observedGeneration"`
+	Replicas            int                   `json:"replicas"`
+	UpdatedReplicas     int                   `json:"updatedReplicas"` //属于当前模板的 pod 数量
+	ReadyReplicas       int                   `json:"readyReplicas"`
+	AvailableReplicas   int                   `json:"availableReplicas"`
+	UnavailableReplicas int                   `json:"unavailableReplicas"`
+	Conditions          []DeploymentCondition `json:"conditions,omitempty"`
+}
+
+type DeploymentConditionType string
+
+const (
+	DeploymentAvailable   DeploymentConditionType = "Available"
+	DeploymentProgressing DeploymentConditionType = "Progressing"
+)
+
+type ConditionStatus string
+
+const (
+	ConditionTrue    ConditionStatus = "True"
+	ConditionFalse   ConditionStatus = "False"
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+// Progressing condition 的 reason
+const (
+	NewReplicaSetAvailableReason   = "NewReplicaSetAvailable"
+	ReplicaSetUpdatedReason        = "ReplicaSetUpdated"
+	ProgressDeadlineExceededReason = "ProgressDeadlineExceeded"
+	DeploymentPausedReason         = "DeploymentPaused"
+	DeploymentResumedReason        = "DeploymentResumed"
+	MinimumReplicasAvailable       = "MinimumReplicasAvailable"
+	MinimumReplicasUnavailable     = "MinimumReplicasUnavailable"
+)
+
+type DeploymentCondition struct {
+	Type               DeploymentConditionType `json:"type"`
+	Status             ConditionStatus         `json:"status"`
+	Reason             string                  `json:"reason,omitempty"`
+	Message            string                  `json:"message,omitempty"`
+	LastUpdateTime     *time.Time              `json:"lastUpdateTime,omitempty"`
+	LastTransitionTime *time.Time              `json:"lastTransitionTime,omitempty"`
+}
+
+// GetCondition 返回指定类型的 condition，没有则返回 nil
+func (s *DeploymentStatus) GetCondition(condType DeploymentConditionType) *DeploymentCondition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == condType {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func copyIntPtr(in *int) *int {
+	if in == nil {
+		return nil
+	}
+	out := *in
+	return &out
+}
+
+func copyIntOrString(in *IntOrString) *IntOrString {
+	if in == nil {
+		return nil
+	}
+	out := *in
+	return &out
+}
+
+func (in *DeploymentCondition) DeepCopyInto(out *DeploymentCondition) {
+	*out = *in
+	out.LastUpdateTime = copyTime(in.LastUpdateTime)
+	out.LastTransitionTime = copyTime(in.LastTransitionTime)
+}
+
+func (in *Deployment) DeepCopyInto(out *Deployment) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec.Selector = in.Spec.Selector.DeepCopy()
+	in.Spec.Template.DeepCopyInto(&out.Spec.Template)
+	if in.Spec.Strategy.RollingUpdate != nil {
+		out.Spec.Strategy.RollingUpdate = &RollingUpdateDeployment{
+			MaxSurge:       copyIntOrString(in.Spec.Strategy.RollingUpdate.MaxSurge),
+			MaxUnavailable: copyIntOrString(in.Spec.Strategy.RollingUpdate.MaxUnavailable),
+		}
+	}
+	out.Spec.RevisionHistoryLimit = copyIntPtr(in.Spec.RevisionHistoryLimit)
+	out.Spec.ProgressDeadlineSeconds = copyIntPtr(in.Spec.ProgressDeadlineSeconds)
+	if in.Status.Conditions != nil {
+		out.Status.Conditions = make([]DeploymentCondition, len(in.Status.Conditions))
+		for i := range in.Status.Conditions {
+			in.Status.Conditions[i].DeepCopyInto(&out.Status.Conditions[i])
+		}
+	}
+}
+
+func (in *Deployment) DeepCopy() *Deployment {
+	if in == nil {
+		return nil
+	}
+	out := new(Deployment)
+	in.DeepCopyInto(out)
+	return out
+}
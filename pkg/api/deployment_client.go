@@ -0,0 +1,48 @@
+package api
+
+import "fmt"
+
+func (c *Client) CreateDeployment(namespace string, d *Deployment) (*Deployment, error) {
+	return createObject(c, d, namespacedPath(namespace, "deployments")...)
+}
+
+func (c *Client) GetDeployment(namespace, name string) (*Deployment, error) {
+	return getObject[Deployment](c, namespacedPath(namespace, "deployments", name)...)
+}
+
+func (c *Client) ListDeployments(namespace string) ([]Deployment, error) {
+	return listObjects[Deployment](c, namespacedPath(namespace, "deployments")...)
+}
+
+// ListAllDeployments lists Deployments across all namespaces.
+func (c *Client) ListAllDeployments() ([]Deployment, error) {
+	return listObjects[Deployment](c, clusterPath("deployments")...)
+}
+
+func (c *Client) UpdateDeployment(d *Deployment) (*Deployment, error) {
+	if d == nil || d.Name == "" {
+		return nil, fmt.Errorf("deployment name must be specified for update")
+	}
+	return updateObject(c, d, namespacedPath(d.Namespace, "deployments", d.Name)...)
+}
+
+// UpdateDeploymentStatus 只更新 status 子资源，spec 的修改会被忽略
+func (c *Client) UpdateDeploymentStatus(d *Deployment) (*Deployment, error) {
+	if d == nil || d.Name == "" {
+		return nil, fmt.Errorf("deployment name must be specified for status update")
+	}
+	return updateObject(c, d, namespacedPath(d.Namespace, "deployments", d.Name, "status")...)
+}
+
+func (c *Client) DeleteDeployment(namespace, name string) error {
+	return deleteObject(c, namespacedPath(namespace, "deployments", name)...)
+}
+
+func (c *Client) ApplyDeployment(namespace, name string, patch []byte, force bool) (*Deployment, error) {
+	return applyObject[Deployment](c, patch, force, namespacedPath(namespace, "deployments", name)...)
+}
+
+// WatchAllDeployments 监听所有命名空间里 Deployment 的变化
+func (c *Client) WatchAllDeployments() (<-chan WatchEvent[Deployment], func(), error) {
+	return watch[Deployment](c, c.buildURL(clusterPath("deployments")...))
+}
@@ -0,0 +1,40 @@
+package api
+
+import "fmt"
+
+func (c *Client) CreateEndpoints(namespace string, endpoints *Endpoints) (*Endpoints, error) {
+	return createObject(c, endpoints, namespacedPath(namespace, "endpoints")...)
+}
+
+func (c *Client) GetEndpoints(namespace, name string) (*Endpoints, error) {
+	return getObject[Endpoints](c, namespacedPath(namespace, "endpoints", name)...)
+}
+
+func (c *Client) ListEndpoints(namespace string) ([]Endpoints, error) {
+	return listObjects[Endpoints](c, namespacedPath(namespace, "endpoints")...)
+}
+
+// ListAllEndpoints lists Endpoints across all namespaces.
+func (c *Client) ListAllEndpoints() ([]Endpoints, error) {
+	return listObjects[Endpoints](c, clusterPath("endpoints")...)
+}
+
+func (c *Client) UpdateEndpoints(endpoints *Endpoints) (*Endpoints, error) {
+	if endpoints == nil || endpoints.Name == "" {
+		return nil, fmt.Errorf("endpoints name must be specified for update")
+	}
+	return updateObject(c, endpoints, namespacedPath(endpoints.Namespace, "endpoints", endpoints.Name)...)
+}
+
+func (c *Client) DeleteEndpoints(namespace, name string) error {
+	return deleteObject(c, namespacedPath(namespace, "endpoints", name)...)
+}
+
+func (c *Client) ApplyEndpoints(namespace, name string, patch []byte, force bool) (*Endpoints, error) {
+	return applyObject[Endpoints](c, patch, force, namespacedPath(namespace, "endpoints", name)...)
+}
+
+// WatchAllEndpoints 监听所有命名空间里 Endpoints 的变化
+func (c *Client) WatchAllEndpoints() (<-chan WatchEvent[Endpoints], func(), error) {
+	return watch[Endpoints](c, c.buildURL(clusterPath("endpoints")...))
+}
@@ -0,0 +1,54 @@
+package api
+
+// EnvVar 是传给 pod 进程的一个环境变量，值直接写在 Value 里或者从 ConfigMap、Secret 里读取
+type EnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+	// ValueFrom 和 Value 只能设置一个
+	ValueFrom *EnvVarSource `json:"valueFrom,omitempty"`
+}
+
+// EnvVarSource 引用同一个命名空间里 ConfigMap 或 Secret 的一个键，两者只能设置一个
+type EnvVarSource struct {
+	ConfigMapKeyRef *ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+	SecretKeyRef    *SecretKeySelector    `json:"secretKeyRef,omitempty"`
+}
+
+type ConfigMapKeySelector struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+	// Optional 为 true 时 ConfigMap 或键不存在不算错误，不设置这个环境变量
+	Optional *bool `json:"optional,omitempty"`
+}
+
+type SecretKeySelector struct {
+	Name     string `json:"name"`
+	Key      string `json:"key"`
+	Optional *bool  `json:"optional,omitempty"`
+}
+
+func (in *EnvVar) DeepCopyInto(out *EnvVar) {
+	*out = *in
+	if in.ValueFrom == nil {
+		return
+	}
+	out.ValueFrom = &EnvVarSource{}
+	if ref := in.ValueFrom.ConfigMapKeyRef; ref != nil {
+		r := *ref
+		r.Optional = copyBoolPtr(ref.Optional)
+		out.ValueFrom.ConfigMapKeyRef = &r
+	}
+	if ref := in.ValueFrom.SecretKeyRef; ref != nil {
+		r := *ref
+		r.Optional = copyBoolPtr(ref.Optional)
+		out.ValueFrom.SecretKeyRef = &r
+	}
+}
+
+func copyBoolPtr(in *bool) *bool {
+	if in == nil {
+		return nil
+	}
+	v := *in
+	return &v
+}
@@ -0,0 +1,205 @@
+package api
+
+import "time"
+
+// HorizontalPodAutoscaler 根据 pod 的 CPU 和内存使用量调整 Deployment 或 ReplicaSet 的副本数
+type HorizontalPodAutoscaler struct {
+	ObjectMeta
+	Spec   HorizontalPodAutoscalerSpec   `json:"spec"`
+	Status HorizontalPodAutoscalerStatus `json:"status"`
+}
+
+type HorizontalPodAutoscalerSpec struct {
+	// ScaleTargetRef 是被调整副本数的对象，和 HPA 在同一个命名空间
+	ScaleTargetRef CrossVersionObjectReference `json:"scaleTargetRef"`
+	// MinReplicas 默认是 1
+	MinReplicas *int `json:"minReplicas,omitempty"`
+	MaxReplicas int  `json:"maxReplicas"`
+	// Metrics 为空时默认是 CPU 平均利用率 80%。有多个指标时取算出来最大的副本数
+	Metrics []MetricSpec `json:"metrics,omitempty"`
+	// Behavior 限制扩容和缩容的速度，没有设置的部分使用默认值
+	Behavior *HorizontalPodAutoscalerBehavior `json:"behavior,omitempty"`
+}
+
+type CrossVersionObjectReference struct {
+	// Kind 是 Deployment 或 ReplicaSet
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+type MetricSourceType string
+
+// MetricSourceResource 是目前唯一支持的指标来源，也就是 metrics-server 提供的 pod 的 CPU 和内存
+const MetricSourceResource MetricSourceType = "Resource"
+
+type MetricSpec struct {
+	Type     MetricSourceType      `json:"type"`
+	Resource *ResourceMetricSource `json:"resource,omitempty"`
+}
+
+type ResourceMetricSource struct {
+	// Name 是 cpu 或 memory
+	Name   ResourceName `json:"name"`
+	Target MetricTarget `json:"target"`
+}
+
+type MetricTargetType string
+
+const (
+	// UtilizationMetricType 的目标是使用量占 pod 请求量的平均百分比，pod 必须设置了这种资源的请求
+	UtilizationMetricType MetricTargetType = "Utilization"
+	// AverageValueMetricType 的目标是每个 pod 的平均使用量
+	AverageValueMetricType MetricTargetType = "AverageValue"
+)
+
+type MetricTarget struct {
+	Type               MetricTargetType `json:"type"`
+	AverageUtilization *int             `json:"averageUtilization,omitempty"`
+	// AverageValue 是 "500m"、"256Mi" 这样的数量
+	AverageValue string `json:"averageValue,omitempty"`
+}
+
+type HorizontalPodAutoscalerBehavior struct {
+	ScaleUp   *HPAScalingRules `json:"scaleUp,omitempty"`
+	ScaleDown *HPAScalingRules `json:"scaleDown,omitempty"`
+}
+
+type ScalingPolicySelect string
+
+const (
+	// MaxChangePolicySelect 选择允许变化最多的策略
+	MaxChangePolicySelect ScalingPolicySelect = "Max"
+	// MinChangePolicySelect 选择允许变化最少的策略
+	MinChangePolicySelect ScalingPolicySelect = "Min"
+	// DisabledPolicySelect 禁止这个方向的调整
+	DisabledPolicySelect ScalingPolicySelect = "Disabled"
+)
+
+type HPAScalingRules struct {
+	// StabilizationWindowSeconds 是回看多久之内算出来的副本数：扩容取窗口内的最小值，缩容取最大值，
+	// 避免指标抖动时副本数来回变化。扩容默认 0，缩容默认 300
+	StabilizationWindowSeconds *int                `json:"stabilizationWindowSeconds,omitempty"`
+	SelectPolicy               ScalingPolicySelect `json:"selectPolicy,omitempty"`
+	Policies                   []HPAScalingPolicy  `json:"policies,omitempty"`
+}
+
+type HPAScalingPolicyType string
+
+const (
+	// PodsScalingPolicy 限制 PeriodSeconds 之内最多增加或减少多少个 pod
+	PodsScalingPolicy HPAScalingPolicyType = "Pods"
+	// PercentScalingPolicy 限制 PeriodSeconds 之内最多增加或减少当前副本数的百分之多少
+	PercentScalingPolicy HPAScalingPolicyType = "Percent"
+)
+
+type HPAScalingPolicy struct {
+	Type          HPAScalingPolicyType `json:"type"`
+	Value         int                  `json:"value"`
+	PeriodSeconds int                  `json:"periodSeconds"`
+}
+
+type HorizontalPodAutoscalerStatus struct {
+	ObservedGeneration int64 `json:"observedGeneration"`
+	// LastScaleTime 是最近一次修改目标副本数的时间
+	LastScaleTime   *time.Time                         `json:"lastScaleTime,omitempty"`
+	CurrentReplicas int                                `json:"currentReplicas"`
+	DesiredReplicas int                                `json:"desiredReplicas"`
+	CurrentMetrics  []MetricStatus                     `json:"currentMetrics,omitempty"`
+	Conditions      []HorizontalPodAutoscalerCondition `json:"conditions,omitempty"`
+}
+
+type MetricStatus struct {
+	Type     MetricSourceType      `json:"type"`
+	Resource *ResourceMetricStatus `json:"resource,omitempty"`
+}
+
+type ResourceMetricStatus struct {
+	Name    ResourceName      `json:"name"`
+	Current MetricValueStatus `json:"current"`
+}
+
+type MetricValueStatus struct {
+	AverageUtilization *int   `json:"averageUtilization,omitempty"`
+	AverageValue       string `json:"averageValue,omitempty"`
+}
+
+type HorizontalPodAutoscalerConditionType string
+
+const (
+	// AbleToScale 表示能否读取和修改目标的副本数，以及是否被扩缩容策略限制住了
+	AbleToScale HorizontalPodAutoscalerConditionType = "AbleToScale"
+	// ScalingActive 表示能否算出副本数，指标拿不到时为 False
+	ScalingActive HorizontalPodAutoscalerConditionType = "ScalingActive"
+	// ScalingLimited 表示算出来的副本数是否被 minReplicas 或 maxReplicas 限制了
+	ScalingLimited HorizontalPodAutoscalerConditionType = "ScalingLimited"
+)
+
+type HorizontalPodAutoscalerCondition struct {
+	Type               HorizontalPodAutoscalerConditionType `json:"type"`
+	Status             ConditionStatus                      `json:"status"`
+	Reason             string                               `json:"reason,omitempty"`
+	Message            string                               `json:"message,omitempty"`
+	LastTransitionTime *time.Time                           `json:"lastTransitionTime,omitempty"`
+}
+
+func copyScalingRules(in *HPAScalingRules) *HPAScalingRules {
+	if in == nil {
+		return nil
+	}
+	return &HPAScalingRules{
+		StabilizationWindowSeconds: copyIntPtr(in.StabilizationWindowSeconds),
+		SelectPolicy:               in.SelectPolicy,
+		Policies:                   append([]HPAScalingPolicy(nil), in.Policies...),
+	}
+}
+
+func (in *HorizontalPodAutoscaler) DeepCopyInto(out *HorizontalPodAutoscaler) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec.MinReplicas = copyIntPtr(in.Spec.MinReplicas)
+	if in.Spec.Metrics != nil {
+		out.Spec.Metrics = make([]MetricSpec, len(in.Spec.Metrics))
+		for i, m := range in.Spec.Metrics {
+			out.Spec.Metrics[i] = m
+			if m.Resource != nil {
+				r := *m.Resource
+				r.Target.AverageUtilization = copyIntPtr(m.Resource.Target.AverageUtilization)
+				out.Spec.Metrics[i].Resource = &r
+			}
+		}
+	}
+	if in.Spec.Behavior != nil {
+		out.Spec.Behavior = &HorizontalPodAutoscalerBehavior{
+			ScaleUp:   copyScalingRules(in.Spec.Behavior.ScaleUp),
+			ScaleDown: copyScalingRules(in.Spec.Behavior.ScaleDown),
+		}
+	}
+	out.Status.LastScaleTime = copyTime(in.Status.LastScaleTime)
+	if in.Status.CurrentMetrics != nil {
+		out.Status.CurrentMetrics = make([]MetricStatus, len(in.Status.CurrentMetrics))
+		for i, m := range in.Status.CurrentMetrics {
+			out.Status.CurrentMetrics[i] = m
+			if m.Resource != nil {
+				r := *m.Resource
+				r.Current.AverageUtilization = copyIntPtr(m.Resource.Current.AverageUtilization)
+				out.Status.CurrentMetrics[i].Resource = &r
+			}
+		}
+	}
+	if in.Status.Conditions != nil {
+		out.Status.Conditions = make([]HorizontalPodAutoscalerCondition, len(in.Status.Conditions))
+		for i, c := range in.Status.Conditions {
+			out.Status.Conditions[i] = c
+			out.Status.Conditions[i].LastTransitionTime = copyTime(c.LastTransitionTime)
+		}
+	}
+}
+
+func (in *HorizontalPodAutoscaler) DeepCopy() *HorizontalPodAutoscaler {
+	if in == nil {
+		return nil
+	}
+	out := new(HorizontalPodAutoscaler)
+	in.DeepCopyInto(out)
+	return out
+}
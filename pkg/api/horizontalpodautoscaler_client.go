@@ -0,0 +1,48 @@
+package api
+
+import "fmt"
+
+func (c *Client) CreateHorizontalPodAutoscaler(namespace string, hpa *HorizontalPodAutoscaler) (*HorizontalPodAutoscaler, error) {
+	return createObject(c, hpa, namespacedPath(namespace, "horizontalpodautoscalers")...)
+}
+
+func (c *Client) GetHorizontalPodAutoscaler(namespace, name string) (*HorizontalPodAutoscaler, error) {
+	return getObject[HorizontalPodAutoscaler](c, namespacedPath(namespace, "horizontalpodautoscalers", name)...)
+}
+
+func (c *Client) ListHorizontalPodAutoscalers(namespace string) ([]HorizontalPodAutoscaler, error) {
+	return listObjects[HorizontalPodAutoscaler](c, namespacedPath(namespace, "horizontalpodautoscalers")...)
+}
+
+// ListAllHorizontalPodAutoscalers lists HorizontalPodAutoscalers across all namespaces.
+func (c *Client) ListAllHorizontalPodAutoscalers() ([]HorizontalPodAutoscaler, error) {
+	return listObjects[HorizontalPodAutoscaler](c, clusterPath("horizontalpodautoscalers")...)
+}
+
+func (c *Client) UpdateHorizontalPodAutoscaler(hpa *HorizontalPodAutoscaler) (*HorizontalPodAutoscaler, error) {
+	if hpa == nil || hpa.Name == "" {
+		return nil, fmt.Errorf("horizontal pod autoscaler name must be specified for update")
+	}
+	return updateObject(c, hpa, namespacedPath(hpa.Namespace, "horizontalpodautoscalers", hpa.Name)...)
+}
+
+// UpdateHorizontalPodAutoscalerStatus 只更新 status 子资源，spec 的修改会被忽略
+func (c *Client) UpdateHorizontalPodAutoscalerStatus(hpa *HorizontalPodAutoscaler) (*HorizontalPodAutoscaler, error) {
+	if hpa == nil || hpa.Name == "" {
+		return nil, fmt.Errorf("horizontal pod autoscaler name must be specified for status update")
+	}
+	return updateObject(c, hpa, namespacedPath(hpa.Namespace, "horizontalpodautoscalers", hpa.Name, "status")...)
+}
+
+func (c *Client) DeleteHorizontalPodAutoscaler(namespace, name string) error {
+	return deleteObject(c, namespacedPath(namespace, "horizontalpodautoscalers", name)...)
+}
+
+func (c *Client) ApplyHorizontalPodAutoscaler(namespace, name string, patch []byte, force bool) (*HorizontalPodAutoscaler, error) {
+	return applyObject[HorizontalPodAutoscaler](c, patch, force, namespacedPath(namespace, "horizontalpodautoscalers", name)...)
+}
+
+// WatchAllHorizontalPodAutoscalers 监听所有命名空间里 HorizontalPodAutoscaler 的变化
+func (c *Client) WatchAllHorizontalPodAutoscalers() (<-chan WatchEvent[HorizontalPodAutoscaler], func(), error) {
+	return watch[HorizontalPodAutoscaler](c, c.buildURL(clusterPath("horizontalpodautoscalers")...))
+}
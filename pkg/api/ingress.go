@@ -0,0 +1,110 @@
+package api
+
+// Ingress 按 host 和 path 把集群外的 HTTP 请求转发给 service，由 ingress controller 实现
+type Ingress struct {
+	ObjectMeta
+	Spec IngressSpec `json:"spec"`
+}
+
+type IngressSpec struct {
+	// DefaultBackend 处理没有匹配任何规则的请求
+	DefaultBackend *IngressBackend `json:"defaultBackend,omitempty"`
+	// TLS 列出用 HTTPS 提供服务的 host 和它们的证书
+	TLS   []IngressTLS  `json:"tls,omitempty"`
+	Rules []IngressRule `json:"rules,omitempty"`
+}
+
+type IngressTLS struct {
+	// Hosts 是使用这个证书的 host，可以是 *.example.com 这样的通配符
+	Hosts []string `json:"hosts,omitempty"`
+	// SecretName 是同一个命名空间里 kubernetes.io/tls 类型的 Secret，包含 tls.crt 和 tls.key
+	SecretName string `json:"secretName,omitempty"`
+}
+
+type IngressRule struct {
+	// Host 为空时规则匹配所有 host；*.example.com 匹配 example.com 下一级的所有名字
+	Host string                `json:"host,omitempty"`
+	HTTP *HTTPIngressRuleValue `json:"http,omitempty"`
+}
+
+type HTTPIngressRuleValue struct {
+	Paths []HTTPIngressPath `json:"paths"`
+}
+
+type PathType string
+
+const (
+	// PathTypeExact 要求请求路径和 path 完全相同
+	PathTypeExact PathType = "Exact"
+	// PathTypePrefix 按 / 分隔的路径段匹配前缀：/foo 匹配 /foo 和 /foo/bar，不匹配 /foobar
+	PathTypePrefix PathType = "Prefix"
+)
+
+type HTTPIngressPath struct {
+	Path     string         `json:"path,omitempty"`
+	PathType PathType       `json:"pathType,omitempty"` //默认 Prefix
+	Backend  IngressBackend `json:"backend"`
+}
+
+type IngressBackend struct {
+	Service *IngressServiceBackend `json:"service,omitempty"`
+}
+
+// IngressServiceBackend 引用同一个命名空间里 service 的一个端口
+type IngressServiceBackend struct {
+	Name string             `json:"name"`
+	Port ServiceBackendPort `json:"port"`
+}
+
+// ServiceBackendPort 按名字或者端口号引用 service 的端口，两者只能设置一个
+type ServiceBackendPort struct {
+	Name   string `json:"name,omitempty"`
+	Number int    `json:"number,omitempty"`
+}
+
+func (in *IngressBackend) DeepCopy() *IngressBackend {
+	if in == nil {
+		return nil
+	}
+	out := *in
+	if in.Service != nil {
+		s := *in.Service
+		out.Service = &s
+	}
+	return &out
+}
+
+func (in *Ingress) DeepCopyInto(out *Ingress) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec.DefaultBackend = in.Spec.DefaultBackend.DeepCopy()
+	if in.Spec.TLS != nil {
+		out.Spec.TLS = make([]IngressTLS, len(in.Spec.TLS))
+		for i, tls := range in.Spec.TLS {
+			out.Spec.TLS[i] = IngressTLS{Hosts: append([]string(nil), tls.Hosts...), SecretName: tls.SecretName}
+		}
+	}
+	if in.Spec.Rules != nil {
+		out.Spec.Rules = make([]IngressRule, len(in.Spec.Rules))
+		for i, rule := range in.Spec.Rules {
+			out.Spec.Rules[i] = IngressRule{Host: rule.Host}
+			if rule.HTTP != nil {
+				paths := make([]HTTPIngressPath, len(rule.HTTP.Paths))
+				for j, p := range rule.HTTP.Paths {
+					paths[j] = p
+					paths[j].Backend = *p.Backend.DeepCopy()
+				}
+				out.Spec.Rules[i].HTTP = &HTTPIngressRuleValue{Paths: paths}
+			}
+		}
+	}
+}
+
+func (in *Ingress) DeepCopy() *Ingress {
+	if in == nil {
+		return nil
+	}
+	out := new(Ingress)
+	in.DeepCopyInto(out)
+	return out
+}
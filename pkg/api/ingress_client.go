@@ -0,0 +1,40 @@
+package api
+
+import "fmt"
+
+func (c *Client) CreateIngress(namespace string, ingress *Ingress) (*Ingress, error) {
+	return createObject(c, ingress, namespacedPath(namespace, "ingresses")...)
+}
+
+func (c *Client) GetIngress(namespace, name string) (*Ingress, error) {
+	return getObject[Ingress](c, namespacedPath(namespace, "ingresses", name)...)
+}
+
+func (c *Client) ListIngresses(namespace string) ([]Ingress, error) {
+	return listObjects[Ingress](c, namespacedPath(namespace, "ingresses")...)
+}
+
+// ListAllIngresses lists Ingresses across all namespaces.
+func (c *Client) ListAllIngresses() ([]Ingress, error) {
+	return listObjects[Ingress](c, clusterPath("ingresses")...)
+}
+
+func (c *Client) UpdateIngress(ingress *Ingress) (*Ingress, error) {
+	if ingress == nil || ingress.Name == "" {
+		return nil, fmt.Errorf("ingress name must be specified for update")
+	}
+	return updateObject(c, ingress, namespacedPath(ingress.Namespace, "ingresses", ingress.Name)...)
+}
+
+func (c *Client) DeleteIngress(namespace, name string) error {
+	return deleteObject(c, namespacedPath(namespace, "ingresses", name)...)
+}
+
+func (c *Client) ApplyIngress(namespace, name string, patch []byte, force bool) (*Ingress, error) {
+	return applyObject[Ingress](c, patch, force, namespacedPath(namespace, "ingresses", name)...)
+}
+
+// WatchAllIngresses 监听所有命名空间里 Ingress 的变化
+func (c *Client) WatchAllIngresses() (<-chan WatchEvent[Ingress], func(), error) {
+	return watch[Ingress](c, c.buildURL(clusterPath("ingresses")...))
+}
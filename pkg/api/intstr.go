@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// IntOrString 可以是一个整数，也可以是 "25%" 这样的百分比，用于 maxSurge、maxUnavailable 等字段
+type IntOrString struct {
+	IsString bool
+	IntVal   int
+	StrVal   string
+}
+
+func FromInt(i int) IntOrString {
+	return IntOrString{IntVal: i}
+}
+
+func FromString(s string) IntOrString {
+	return IntOrString{IsString: true, StrVal: s}
+}
+
+func (v IntOrString) MarshalJSON() ([]byte, error) {
+	if v.IsString {
+		return json.Marshal(v.StrVal)
+	}
+	return json.Marshal(v.IntVal)
+}
+
+func (v *IntOrString) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		v.IsString = true
+		return json.Unmarshal(data, &v.StrVal)
+	}
+	v.IsString = false
+	return json.Unmarshal(data, &v.IntVal)
+}
+
+func (v IntOrString) String() string {
+	if v.IsString {
+		return v.StrVal
+	}
+	return strconv.Itoa(v.IntVal)
+}
+
+// ScaledValue 把百分比换算成 total 的一部分，roundUp 决定向上还是向下取整；整数直接返回
+func (v *IntOrString) ScaledValue(total int, roundUp bool) (int, error) {
+	if v == nil {
+		return 0, nil
+	}
+	if !v.IsString {
+		return v.IntVal, nil
+	}
+	if !strings.HasSuffix(v.StrVal, "%") {
+		return 0, fmt.Errorf("invalid value %q: must be an integer or a percentage", v.StrVal)
+	}
+	percent, err := strconv.Atoi(strings.TrimSuffix(v.StrVal, "%"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q: %w", v.StrVal, err)
+	}
+	value := float64(percent) * float64(total) / 100
+	if roundUp {
+		return int(math.Ceil(value)), nil
+	}
+	return int(math.Floor(value)), nil
+}
@@ -0,0 +1,126 @@
+package api
+
+import "time"
+
+const (
+	// JobNameLabel 和 ControllerUIDLabel 由 API server 加在 Job 的 pod 模板上，并作为 Job 自动生成的选择器
+	JobNameLabel       = "job-name"
+	ControllerUIDLabel = "controller-uid"
+
+	DefaultBackoffLimit = 6
+)
+
+type Job struct {
+	ObjectMeta
+	Spec   JobSpec   `json:"spec"`
+	Status JobStatus `json:"status"`
+}
+
+type JobSpec struct {
+	// Parallelism 是同时运行的 pod 数量上限，默认 1
+	Parallelism *int `json:"parallelism,omitempty"`
+	// Completions 是需要成功结束的 pod 数量，默认 1
+	Completions *int `json:"completions,omitempty"`
+	// BackoffLimit 是 Job 被标记为失败之前允许的失败次数，默认 6
+	BackoffLimit *int `json:"backoffLimit,omitempty"`
+	// ActiveDeadlineSeconds 是 Job 从开始运行算起的最长时间，超过后停止所有 pod 并标记为失败
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+	// TTLSecondsAfterFinished 设置之后，Job 结束这么多秒后连同它的 pod 一起被删除
+	TTLSecondsAfterFinished *int `json:"ttlSecondsAfterFinished,omitempty"`
+	// Selector 由 API server 根据 Job 的 UID 生成
+	Selector *LabelSelector  `json:"selector,omitempty"`
+	Template PodTemplateSpec `json:"template"`
+}
+
+type JobStatus struct {
+	StartTime      *time.Time     `json:"startTime,omitempty"`
+	CompletionTime *time.Time     `json:"completionTime,omitempty"`
+	Active         int            `json:"active"`    //正在运行的 pod 数量
+	Succeeded      int            `json:"succeeded"` //成功结束的 pod 数量
+	Failed         int            `json:"failed"`    //失败的 pod 数量，加上 OnFailure 时原地重启的次数
+	Conditions     []JobCondition `json:"conditions,omitempty"`
+}
+
+type JobConditionType string
+
+const (
+	JobComplete JobConditionType = "Complete"
+	JobFailed   JobConditionType = "Failed"
+)
+
+// Failed condition 的 reason
+const (
+	JobReasonBackoffLimitExceeded = "BackoffLimitExceeded"
+	JobReasonDeadlineExceeded     = "DeadlineExceeded"
+)
+
+type JobCondition struct {
+	Type               JobConditionType `json:"type"`
+	Status             ConditionStatus  `json:"status"`
+	Reason             string           `json:"reason,omitempty"`
+	Message            string           `json:"message,omitempty"`
+	LastTransitionTime *time.Time       `json:"lastTransitionTime,omitempty"`
+}
+
+// IsJobFinished 判断 Job 是否已经成功或者失败，返回结束的 condition 类型
+func IsJobFinished(job *Job) (bool, JobConditionType) {
+	for _, c := range job.Status.Conditions {
+		if (c.Type == JobComplete || c.Type == JobFailed) && c.Status == ConditionTrue {
+			return true, c.Type
+		}
+	}
+	return false, ""
+}
+
+// JobFinishTime 返回 Job 结束的时间，没有结束时返回 nil
+func JobFinishTime(job *Job) *time.Time {
+	for _, c := range job.Status.Conditions {
+		if (c.Type == JobComplete || c.Type == JobFailed) && c.Status == ConditionTrue {
+			return c.LastTransitionTime
+		}
+	}
+	return nil
+}
+
+func copyInt64Ptr(in *int64) *int64 {
+	if in == nil {
+		return nil
+	}
+	out := *in
+	return &out
+}
+
+func (in *JobSpec) DeepCopyInto(out *JobSpec) {
+	*out = *in
+	out.Parallelism = copyIntPtr(in.Parallelism)
+	out.Completions = copyIntPtr(in.Completions)
+	out.BackoffLimit = copyIntPtr(in.BackoffLimit)
+	out.ActiveDeadlineSeconds = copyInt64Ptr(in.ActiveDeadlineSeconds)
+	out.TTLSecondsAfterFinished = copyIntPtr(in.TTLSecondsAfterFinished)
+	out.Selector = in.Selector.DeepCopy()
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+func (in *Job) DeepCopyInto(out *Job) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status.StartTime = copyTime(in.Status.StartTime)
+	out.Status.CompletionTime = copyTime(in.Status.CompletionTime)
+	if in.Status.Conditions != nil {
+		out.Status.Conditions = make([]JobCondition, len(in.Status.Conditions))
+		for i, c := range in.Status.Conditions {
+			c.LastTransitionTime = copyTime(c.LastTransitionTime)
+			out.Status.Conditions[i] = c
+		}
+	}
+}
+
+func (in *Job) DeepCopy() *Job {
+	if in == nil {
+		return nil
+	}
+	out := new(Job)
+	in.DeepCopyInto(out)
+	return out
+}
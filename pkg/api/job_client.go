@@ -0,0 +1,48 @@
+package api
+
+import "fmt"
+
+func (c *Client) CreateJob(namespace string, job *Job) (*Job, error) {
+	return createObject(c, job, namespacedPath(namespace, "jobs")...)
+}
+
+func (c *Client) GetJob(namespace, name string) (*Job, error) {
+	return getObject[Job](c, namespacedPath(namespace, "jobs", name)...)
+}
+
+func (c *Client) ListJobs(namespace string) ([]Job, error) {
+	return listObjects[Job](c, namespacedPath(namespace, "jobs")...)
+}
+
+// ListAllJobs lists Jobs across all namespaces.
+func (c *Client) ListAllJobs() ([]Job, error) {
+	return listObjects[Job](c, clusterPath("jobs")...)
+}
+
+func (c *Client) UpdateJob(job *Job) (*Job, error) {
+	if job == nil || job.Name == "" {
+		return nil, fmt.Errorf("job name must be specified for update")
+	}
+	return updateObject(c, job, namespacedPath(job.Namespace, "jobs", job.Name)...)
+}
+
+// UpdateJobStatus 只更新 status 子资源，spec 的修改会被忽略
+func (c *Client) UpdateJobStatus(job *Job) (*Job, error) {
+	if job == nil || job.Name == "" {
+		return nil, fmt.Errorf("job name must be specified for status update")
+	}
+	return updateObject(c, job, namespacedPath(job.Namespace, "jobs", job.Name, "status")...)
+}
+
+func (c *Client) DeleteJob(namespace, name string) error {
+	return deleteObject(c, namespacedPath(namespace, "jobs", name)...)
+}
+
+func (c *Client) ApplyJob(namespace, name string, patch []byte, force bool) (*Job, error) {
+	return applyObject[Job](c, patch, force, namespacedPath(namespace, "jobs", name)...)
+}
+
+// WatchAllJobs 监听所有命名空间里 Job 的变化
+func (c *Client) WatchAllJobs() (<-chan WatchEvent[Job], func(), error) {
+	return watch[Job](c, c.buildURL(clusterPath("jobs")...))
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"sort"
+	"strings"
+)
+
+// LabelSelector 选择 labels 里包含 MatchLabels 所有键值对的对象
+type LabelSelector struct {
+	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+}
+
+// Matches 判断 labels 是否满足选择器；nil 或者空选择器不匹配任何对象，避免 controller 误接管整个命名空间的 pod
+func (s *LabelSelector) Matches(labels map[string]string) bool {
+	if s == nil || len(s.MatchLabels) == 0 {
+		return false
+	}
+	for k, v := range s.MatchLabels {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (in *LabelSelector) DeepCopy() *LabelSelector {
+	if in == nil {
+		return nil
+	}
+	return &LabelSelector{MatchLabels: copyStringMap(in.MatchLabels)}
+}
+
+// FormatLabels 把 labels 格式化成 k1=v1,k2=v2，按 key 排序
+func FormatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+labels[k])
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseLabels 解析 k1=v1,k2=v2 形式的 labels
+func ParseLabels(s string) map[string]string {
+	labels := map[string]string{}
+	for _, part := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) == 2 && kv[0] != "" {
+			labels[kv[0]] = kv[1]
+		}
+	}
+	return labels
+}
@@ -0,0 +1,43 @@
+package api
+
+import "time"
+
+// Lease 是一个带有效期的租约。leader election 用它记录当前的 leader：holder 定期续约，
+// 其它候选者看到租约过期之后才能用 resourceVersion 做 compare-and-swap 抢占
+type Lease struct {
+	ObjectMeta
+	Spec LeaseSpec `json:"spec"`
+}
+
+type LeaseSpec struct {
+	// HolderIdentity 是当前持有租约的候选者，为空表示没有人持有
+	HolderIdentity string `json:"holderIdentity,omitempty"`
+	// LeaseDurationSeconds 是从 RenewTime 开始租约的有效期
+	LeaseDurationSeconds int        `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          *time.Time `json:"acquireTime,omitempty"`
+	RenewTime            *time.Time `json:"renewTime,omitempty"`
+	// LeaseTransitions 是租约换过多少次 holder
+	LeaseTransitions int `json:"leaseTransitions,omitempty"`
+}
+
+func (in *Lease) DeepCopyInto(out *Lease) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Spec.AcquireTime != nil {
+		t := *in.Spec.AcquireTime
+		out.Spec.AcquireTime = &t
+	}
+	if in.Spec.RenewTime != nil {
+		t := *in.Spec.RenewTime
+		out.Spec.RenewTime = &t
+	}
+}
+
+func (in *Lease) DeepCopy() *Lease {
+	if in == nil {
+		return nil
+	}
+	out := new(Lease)
+	in.DeepCopyInto(out)
+	return out
+}
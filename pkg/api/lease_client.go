@@ -0,0 +1,40 @@
+package api
+
+import "fmt"
+
+func (c *Client) CreateLease(namespace string, lease *Lease) (*Lease, error) {
+	return createObject(c, lease, namespacedPath(namespace, "leases")...)
+}
+
+func (c *Client) GetLease(namespace, name string) (*Lease, error) {
+	return getObject[Lease](c, namespacedPath(namespace, "leases", name)...)
+}
+
+func (c *Client) ListLeases(namespace string) ([]Lease, error) {
+	return listObjects[Lease](c, namespacedPath(namespace, "leases")...)
+}
+
+// ListAllLeases lists Leases across all namespaces.
+func (c *Client) ListAllLeases() ([]Lease, error) {
+	return listObjects[Lease](c, clusterPath("leases")...)
+}
+
+func (c *Client) UpdateLease(lease *Lease) (*Lease, error) {
+	if lease == nil || lease.Name == "" {
+		return nil, fmt.Errorf("lease name must be specified for update")
+	}
+	return updateObject(c, lease, namespacedPath(lease.Namespace, "leases", lease.Name)...)
+}
+
+func (c *Client) DeleteLease(namespace, name string) error {
+	return deleteObject(c, namespacedPath(namespace, "leases", name)...)
+}
+
+func (c *Client) ApplyLease(namespace, name string, patch []byte, force bool) (*Lease, error) {
+	return applyObject[Lease](c, patch, force, namespacedPath(namespace, "leases", name)...)
+}
+
+// WatchAllLeases 监听所有命名空间里 Lease 的变化
+func (c *Client) WatchAllLeases() (<-chan WatchEvent[Lease], func(), error) {
+	return watch[Lease](c, c.buildURL(clusterPath("leases")...))
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// 下面这些方法只读写对象的元数据，不需要知道资源的具体类型，垃圾回收器用它们处理所有资源的 ownerReferences 和 finalizers
+
+// ListMetadata 列出所有命名空间里某种资源的对象，只解码元数据字段
+func (c *Client) ListMetadata(plural string) ([]ObjectMeta, error) {
+	return listObjects[ObjectMeta](c, clusterPath(plural)...)
+}
+
+// ListNamespacedMetadata 列出一个命名空间里某种资源的对象，只解码元数据字段
+func (c *Client) ListNamespacedMetadata(namespace, plural string) ([]ObjectMeta, error) {
+	return listObjects[ObjectMeta](c, namespacedPath(namespace, plural)...)
+}
+
+// objectPath 返回对象的路径，namespace 为空表示集群级别的对象，比如 PersistentVolume
+func objectPath(namespace, plural, name string) []string {
+	if namespace == "" {
+		return clusterPath(plural, name)
+	}
+	return namespacedPath(namespace, plural, name)
+}
+
+func (c *Client) GetMetadata(plural, namespace, name string) (*ObjectMeta, error) {
+	return getObject[ObjectMeta](c, objectPath(namespace, plural, name)...)
+}
+
+// UpdateMetadata 读出对象，把 ownerReferences 和 finalizers 换成 meta 里的值后写回，其它字段保持服务端的值。
+// 对象已经被删除重建（UID 不同）时返回错误
+func (c *Client) UpdateMetadata(plural string, meta *ObjectMeta) error {
+	path := objectPath(meta.Namespace, plural, meta.Name)
+	obj, err := getObject[map[string]json.RawMessage](c, path...)
+	if err != nil {
+		return err
+	}
+	var uid string
+	if err := json.Unmarshal((*obj)["uid"], &uid); err != nil || uid != meta.UID {
+		return fmt.Errorf("%s %s/%s has been replaced, expected uid %s", plural, meta.Namespace, meta.Name, meta.UID)
+	}
+	if err := setRawField(*obj, "ownerReferences", meta.OwnerReferences, len(meta.OwnerReferences) == 0); err != nil {
+		return err
+	}
+	if err := setRawField(*obj, "finalizers", meta.Finalizers, len(meta.Finalizers) == 0); err != nil {
+		return err
+	}
+	_, err = updateObject(c, obj, path...)
+	return err
+}
+
+func setRawField(obj map[string]json.RawMessage, key string, value interface{}, empty bool) error {
+	if empty {
+		delete(obj, key)
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	obj[key] = data
+	return nil
+}
+
+// DeleteWithPropagation 按 policy 删除对象，policy 决定它的 dependents 是被删除还是被保留
+func (c *Client) DeleteWithPropagation(plural, namespace, name string, policy DeletionPropagation) error {
+	u, _ := url.Parse(c.buildURL(objectPath(namespace, plural, name)...))
+	u.RawQuery = url.Values{"propagationPolicy": {string(policy)}}.Encode()
+	return c.do(http.MethodDelete, u.String(), nil, nil, http.StatusOK, http.StatusNoContent)
+}
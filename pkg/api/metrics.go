@@ -0,0 +1,56 @@
+package api
+
+import "time"
+
+// Summary 是 kubelet 在 /stats/summary 上报的节点和 pod 的资源使用量，都是累计值或者瞬时值，
+// metrics-server 用两次抓取之间的差值算出 CPU 使用率
+type Summary struct {
+	Node NodeStats  `json:"node"`
+	Pods []PodStats `json:"pods"`
+}
+
+type NodeStats struct {
+	NodeName string       `json:"nodeName"`
+	CPU      *CPUStats    `json:"cpu,omitempty"`
+	Memory   *MemoryStats `json:"memory,omitempty"`
+}
+
+type PodStats struct {
+	PodRef PodReference `json:"podRef"`
+	CPU    *CPUStats    `json:"cpu,omitempty"`
+	Memory *MemoryStats `json:"memory,omitempty"`
+}
+
+type PodReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	UID       string `json:"uid"`
+}
+
+type CPUStats struct {
+	Time time.Time `json:"time"`
+	// UsageCoreNanoSeconds 是从进程启动开始累计使用的 CPU 时间
+	UsageCoreNanoSeconds uint64 `json:"usageCoreNanoSeconds"`
+}
+
+type MemoryStats struct {
+	Time time.Time `json:"time"`
+	// WorkingSetBytes 是进程当前占用的物理内存
+	WorkingSetBytes uint64 `json:"workingSetBytes"`
+}
+
+// PodMetrics 是 metrics API 返回的 pod 在 Window 这段时间里的平均 CPU 使用量和当前的内存使用量
+type PodMetrics struct {
+	ObjectMeta
+	Timestamp time.Time    `json:"timestamp"`
+	Window    string       `json:"window"`
+	Usage     ResourceList `json:"usage"`
+}
+
+// NodeMetrics 和 PodMetrics 一样，是整个节点的使用量
+type NodeMetrics struct {
+	ObjectMeta
+	Timestamp time.Time    `json:"timestamp"`
+	Window    string       `json:"window"`
+	Usage     ResourceList `json:"usage"`
+}
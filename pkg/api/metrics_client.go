@@ -0,0 +1,32 @@
+package api
+
+// MetricsGroupVersion 是 metrics API 的路径前缀，API server 把它转发给 metrics-server
+const MetricsGroupVersion = "metrics.k8s.io/v1beta1"
+
+func metricsPath(segments ...string) []string {
+	return append([]string{"apis", "metrics.k8s.io", "v1beta1"}, segments...)
+}
+
+// ListPodMetrics 返回命名空间里 pod 的使用量，还没有被 metrics-server 抓取过两次的 pod 不在结果里
+func (c *Client) ListPodMetrics(namespace string) ([]PodMetrics, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+	return listObjects[PodMetrics](c, metricsPath("namespaces", namespace, "pods")...)
+}
+
+func (c *Client) ListAllPodMetrics() ([]PodMetrics, error) {
+	return listObjects[PodMetrics](c, metricsPath("pods")...)
+}
+
+func (c *Client) GetPodMetrics(namespace, name string) (*PodMetrics, error) {
+	return getObject[PodMetrics](c, metricsPath("namespaces", namespace, "pods", name)...)
+}
+
+func (c *Client) ListNodeMetrics() ([]NodeMetrics, error) {
+	return listObjects[NodeMetrics](c, metricsPath("nodes")...)
+}
+
+func (c *Client) GetNodeMetrics(name string) (*NodeMetrics, error) {
+	return getObject[NodeMetrics](c, metricsPath("nodes", name)...)
+}
@@ -0,0 +1,31 @@
+package api
+
+const (
+	NamespaceActive      NamespacePhase = "Active"      // The namespace accepts new objects.
+	NamespaceTerminating NamespacePhase = "Terminating" // The namespace is being deleted; no new objects may be created in it.
+)
+
+// NamespaceSystem 是系统组件存放自己对象的命名空间，API server 启动时创建，不能删除
+const NamespaceSystem = "kube-system"
+
+// FinalizerKubernetes 由 namespace controller 负责：清空命名空间里的所有对象之后才会移除它
+const FinalizerKubernetes FinalizerName = "kubernetes"
+
+type NamespacePhase string
+
+type FinalizerName string
+
+type Namespace struct {
+	ObjectMeta
+	Spec   NamespaceSpec   `json:"spec"`
+	Status NamespaceStatus `json:"status"`
+}
+
+type NamespaceSpec struct {
+	// Finalizers 全部被移除之后，处于 Terminating 的命名空间才会真正从存储里删除
+	Finalizers []FinalizerName `json:"finalizers,omitempty"`
+}
+
+type NamespaceStatus struct {
+	Phase NamespacePhase `json:"phase"`
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+)
+
+func (c *Client) CreateNamespace(ns *Namespace) (*Namespace, error) {
+	urlStr := c.buildWriteURL(nil, "api", "v1", "namespaces")
+	var created Namespace
+	if err := c.do(http.MethodPost, urlStr, ns, &created, http.StatusCreated); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (c *Client) GetNamespace(name string) (*Namespace, error) {
+	urlStr := c.buildURL("api", "v1", "namespaces", name)
+	var ns Namespace
+	if err := c.do(http.MethodGet, urlStr, nil, &ns, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return &ns, nil
+}
+
+func (c *Client) ListNamespaces() ([]Namespace, error) {
+	urlStr := c.buildURL("api", "v1", "namespaces")
+	var all []Namespace
+	if err := c.do(http.MethodGet, urlStr, nil, &all, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// DeleteNamespace 只是把命名空间标记为 Terminating，真正的删除由 namespace controller 完成
+func (c *Client) DeleteNamespace(name string) error {
+	urlStr := c.buildURL("api", "v1", "namespaces", name)
+	return c.do(http.MethodDelete, urlStr, nil, nil, http.StatusOK, http.StatusNoContent)
+}
+
+// FinalizeNamespace replaces the namespace's finalizers. Once a terminating
+// namespace has no finalizers left the API server removes it.
+func (c *Client) FinalizeNamespace(ns *Namespace) error {
+	if ns == nil || ns.Name == "" {
+		return fmt.Errorf("namespace name must be specified for finalize")
+	}
+	urlStr := c.buildWriteURL(nil, "api", "v1", "namespaces", ns.Name, "finalize")
+	return c.do(http.MethodPut, urlStr, ns, nil, http.StatusOK)
+}
+
+// WatchNamespaces 监听命名空间的变化
+func (c *Client) WatchNamespaces() (<-chan WatchEvent[Namespace], func(), error) {
+	return watch[Namespace](c, c.buildURL("api", "v1", "namespaces"))
+}
@@ -0,0 +1,185 @@
+package api
+
+// PersistentVolume 是集群级别的一块存储，由管理员创建，通过 PersistentVolumeClaim 绑定给一个命名空间使用
+type PersistentVolume struct {
+	ObjectMeta
+	Spec   PersistentVolumeSpec   `json:"spec"`
+	Status PersistentVolumeStatus `json:"status,omitempty"`
+}
+
+type PersistentVolumeSpec struct {
+	// Capacity 的 storage 是卷的大小
+	Capacity    ResourceList                 `json:"capacity"`
+	AccessModes []PersistentVolumeAccessMode `json:"accessModes"`
+	// PersistentVolumeReclaimPolicy 决定绑定的 PVC 删除之后怎样处理卷，默认 Retain
+	PersistentVolumeReclaimPolicy PersistentVolumeReclaimPolicy `json:"persistentVolumeReclaimPolicy,omitempty"`
+	// StorageClassName 为空的卷只能绑定没有指定 storageClassName 的 PVC
+	StorageClassName string `json:"storageClassName,omitempty"`
+	// ClaimRef 是卷绑定的 PVC。创建时设置表示把卷预留给这个 PVC
+	ClaimRef *ObjectReference `json:"claimRef,omitempty"`
+	// Local 是节点上的一个目录，kubelet 在它不存在时创建
+	Local *LocalVolumeSource `json:"local,omitempty"`
+	// NodeAffinity 限制可以使用这个卷的节点，使用卷的 pod 只会被调度到这些节点
+	NodeAffinity *VolumeNodeAffinity `json:"nodeAffinity,omitempty"`
+}
+
+type LocalVolumeSource struct {
+	// Path 是节点上的绝对路径
+	Path string `json:"path"`
+}
+
+// VolumeNodeAffinity 的 Required 和 pod 的 nodeSelector 一样，节点的 labels 必须包含所有键值对
+type VolumeNodeAffinity struct {
+	Required map[string]string `json:"required"`
+}
+
+// PersistentVolumeAccessMode 描述卷可以怎样被挂载
+type PersistentVolumeAccessMode string
+
+const (
+	// ReadWriteOnce 只能被一个节点读写挂载，同一个节点上的多个 pod 可以共用
+	ReadWriteOnce PersistentVolumeAccessMode = "ReadWriteOnce"
+	// ReadOnlyMany 可以被多个节点只读挂载
+	ReadOnlyMany PersistentVolumeAccessMode = "ReadOnlyMany"
+	// ReadWriteMany 可以被多个节点读写挂载
+	ReadWriteMany PersistentVolumeAccessMode = "ReadWriteMany"
+)
+
+type PersistentVolumeReclaimPolicy string
+
+const (
+	// PersistentVolumeReclaimRetain 保留卷和它的数据，卷变成 Released，由管理员手动处理
+	PersistentVolumeReclaimRetain PersistentVolumeReclaimPolicy = "Retain"
+	// PersistentVolumeReclaimDelete 删除卷和它的数据，只有动态创建的卷可以由 provisioner 删除
+	PersistentVolumeReclaimDelete PersistentVolumeReclaimPolicy = "Delete"
+)
+
+type PersistentVolumeStatus struct {
+	Phase PersistentVolumePhase `json:"phase,omitempty"`
+	// Message 说明卷为什么处于这个阶段
+	Message string `json:"message,omitempty"`
+}
+
+type PersistentVolumePhase string
+
+const (
+	// VolumeAvailable 的卷还没有绑定，可以绑定给 PVC
+	VolumeAvailable PersistentVolumePhase = "Available"
+	// VolumeBound 的卷已经绑定给 claimRef 指向的 PVC
+	VolumeBound PersistentVolumePhase = "Bound"
+	// VolumeReleased 的卷绑定的 PVC 已经删除，不会再绑定给别的 PVC。回收策略是 Delete 时接着被删除
+	VolumeReleased PersistentVolumePhase = "Released"
+	// VolumeFailed 的卷回收失败
+	VolumeFailed PersistentVolumePhase = "Failed"
+)
+
+// PersistentVolumeClaim 是命名空间里对存储的申请，binder 把它绑定到一个满足容量和访问模式的 PersistentVolume
+type PersistentVolumeClaim struct {
+	ObjectMeta
+	Spec   PersistentVolumeClaimSpec   `json:"spec"`
+	Status PersistentVolumeClaimStatus `json:"status,omitempty"`
+}
+
+type PersistentVolumeClaimSpec struct {
+	AccessModes []PersistentVolumeAccessMode `json:"accessModes"`
+	// Resources 的 requests.storage 是需要的最小容量
+	Resources VolumeResourceRequirements `json:"resources"`
+	// StorageClassName 为 nil 或空字符串时只绑定没有 storageClassName 的卷
+	StorageClassName *string `json:"storageClassName,omitempty"`
+	// VolumeName 是绑定的卷。创建时设置表示只绑定这个卷
+	VolumeName string `json:"volumeName,omitempty"`
+}
+
+type VolumeResourceRequirements struct {
+	Requests ResourceList `json:"requests,omitempty"`
+}
+
+type PersistentVolumeClaimStatus struct {
+	Phase PersistentVolumeClaimPhase `json:"phase,omitempty"`
+	// AccessModes 和 Capacity 是绑定的卷实际提供的
+	AccessModes []PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+	Capacity    ResourceList                 `json:"capacity,omitempty"`
+}
+
+type PersistentVolumeClaimPhase string
+
+const (
+	ClaimPending PersistentVolumeClaimPhase = "Pending"
+	ClaimBound   PersistentVolumeClaimPhase = "Bound"
+	// ClaimLost 的 PVC 绑定的卷已经不存在了
+	ClaimLost PersistentVolumeClaimPhase = "Lost"
+)
+
+// FinalizerPVCProtection 阻止删除还有 pod 在使用的 PVC，binder 在没有 pod 使用它之后移除
+const FinalizerPVCProtection = "kubernetes.io/pvc-protection"
+
+// ClassName 返回 PVC 要求的 storageClassName，没有设置时为空
+func (pvc *PersistentVolumeClaim) ClassName() string {
+	if pvc.Spec.StorageClassName == nil {
+		return ""
+	}
+	return *pvc.Spec.StorageClassName
+}
+
+// HasAccessModes 判断 modes 是否包含 requested 里的所有访问模式
+func HasAccessModes(modes, requested []PersistentVolumeAccessMode) bool {
+	for _, r := range requested {
+		found := false
+		for _, m := range modes {
+			found = found || m == r
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (in *PersistentVolume) DeepCopyInto(out *PersistentVolume) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec.Capacity = in.Spec.Capacity.DeepCopy()
+	out.Spec.AccessModes = append([]PersistentVolumeAccessMode(nil), in.Spec.AccessModes...)
+	if in.Spec.ClaimRef != nil {
+		ref := *in.Spec.ClaimRef
+		out.Spec.ClaimRef = &ref
+	}
+	if in.Spec.Local != nil {
+		local := *in.Spec.Local
+		out.Spec.Local = &local
+	}
+	if in.Spec.NodeAffinity != nil {
+		out.Spec.NodeAffinity = &VolumeNodeAffinity{Required: copyStringMap(in.Spec.NodeAffinity.Required)}
+	}
+}
+
+func (in *PersistentVolume) DeepCopy() *PersistentVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(PersistentVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *PersistentVolumeClaim) DeepCopyInto(out *PersistentVolumeClaim) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec.AccessModes = append([]PersistentVolumeAccessMode(nil), in.Spec.AccessModes...)
+	out.Spec.Resources.Requests = in.Spec.Resources.Requests.DeepCopy()
+	if in.Spec.StorageClassName != nil {
+		name := *in.Spec.StorageClassName
+		out.Spec.StorageClassName = &name
+	}
+	out.Status.AccessModes = append([]PersistentVolumeAccessMode(nil), in.Status.AccessModes...)
+	out.Status.Capacity = in.Status.Capacity.DeepCopy()
+}
+
+func (in *PersistentVolumeClaim) DeepCopy() *PersistentVolumeClaim {
+	if in == nil {
+		return nil
+	}
+	out := new(PersistentVolumeClaim)
+	in.DeepCopyInto(out)
+	return out
+}
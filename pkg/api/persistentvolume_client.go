@@ -0,0 +1,43 @@
+package api
+
+import "fmt"
+
+func (c *Client) CreatePersistentVolume(pv *PersistentVolume) (*PersistentVolume, error) {
+	return createObject(c, pv, clusterPath("persistentvolumes")...)
+}
+
+func (c *Client) GetPersistentVolume(name string) (*PersistentVolume, error) {
+	return getObject[PersistentVolume](c, clusterPath("persistentvolumes", name)...)
+}
+
+func (c *Client) ListPersistentVolumes() ([]PersistentVolume, error) {
+	return listObjects[PersistentVolume](c, clusterPath("persistentvolumes")...)
+}
+
+func (c *Client) UpdatePersistentVolume(pv *PersistentVolume) (*PersistentVolume, error) {
+	if pv == nil || pv.Name == "" {
+		return nil, fmt.Errorf("persistentvolume name must be specified for update")
+	}
+	return updateObject(c, pv, clusterPath("persistentvolumes", pv.Name)...)
+}
+
+// UpdatePersistentVolumeStatus 只更新 status 子资源，spec 的修改会被忽略
+func (c *Client) UpdatePersistentVolumeStatus(pv *PersistentVolume) (*PersistentVolume, error) {
+	if pv == nil || pv.Name == "" {
+		return nil, fmt.Errorf("persistentvolume name must be specified for status update")
+	}
+	return updateObject(c, pv, clusterPath("persistentvolumes", pv.Name, "status")...)
+}
+
+func (c *Client) DeletePersistentVolume(name string) error {
+	return deleteObject(c, clusterPath("persistentvolumes", name)...)
+}
+
+func (c *Client) ApplyPersistentVolume(name string, patch []byte, force bool) (*PersistentVolume, error) {
+	return applyObject[PersistentVolume](c, patch, force, clusterPath("persistentvolumes", name)...)
+}
+
+// WatchPersistentVolumes 监听 PersistentVolume 的变化
+func (c *Client) WatchPersistentVolumes() (<-chan WatchEvent[PersistentVolume], func(), error) {
+	return watch[PersistentVolume](c, c.buildURL(clusterPath("persistentvolumes")...))
+}
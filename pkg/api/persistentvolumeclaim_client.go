@@ -0,0 +1,48 @@
+package api
+
+import "fmt"
+
+func (c *Client) CreatePersistentVolumeClaim(namespace string, pvc *PersistentVolumeClaim) (*PersistentVolumeClaim, error) {
+	return createObject(c, pvc, namespacedPath(namespace, "persistentvolumeclaims")...)
+}
+
+func (c *Client) GetPersistentVolumeClaim(namespace, name string) (*PersistentVolumeClaim, error) {
+	return getObject[PersistentVolumeClaim](c, namespacedPath(namespace, "persistentvolumeclaims", name)...)
+}
+
+func (c *Client) ListPersistentVolumeClaims(namespace string) ([]PersistentVolumeClaim, error) {
+	return listObjects[PersistentVolumeClaim](c, namespacedPath(namespace, "persistentvolumeclaims")...)
+}
+
+// ListAllPersistentVolumeClaims lists PersistentVolumeClaims across all namespaces.
+func (c *Client) ListAllPersistentVolumeClaims() ([]PersistentVolumeClaim, error) {
+	return listObjects[PersistentVolumeClaim](c, clusterPath("persistentvolumeclaims")...)
+}
+
+func (c *Client) UpdatePersistentVolumeClaim(pvc *PersistentVolumeClaim) (*PersistentVolumeClaim, error) {
+	if pvc == nil || pvc.Name == "" {
+		return nil, fmt.Errorf("persistentvolumeclaim name must be specified for update")
+	}
+	return updateObject(c, pvc, namespacedPath(pvc.Namespace, "persistentvolumeclaims", pvc.Name)...)
+}
+
+// UpdatePersistentVolumeClaimStatus 只更新 status 子资源，spec 的修改会被忽略
+func (c *Client) UpdatePersistentVolumeClaimStatus(pvc *PersistentVolumeClaim) (*PersistentVolumeClaim, error) {
+	if pvc == nil || pvc.Name == "" {
+		return nil, fmt.Errorf("persistentvolumeclaim name must be specified for status update")
+	}
+	return updateObject(c, pvc, namespacedPath(pvc.Namespace, "persistentvolumeclaims", pvc.Name, "status")...)
+}
+
+func (c *Client) DeletePersistentVolumeClaim(namespace, name string) error {
+	return deleteObject(c, namespacedPath(namespace, "persistentvolumeclaims", name)...)
+}
+
+func (c *Client) ApplyPersistentVolumeClaim(namespace, name string, patch []byte, force bool) (*PersistentVolumeClaim, error) {
+	return applyObject[PersistentVolumeClaim](c, patch, force, namespacedPath(namespace, "persistentvolumeclaims", name)...)
+}
+
+// WatchAllPersistentVolumeClaims 监听所有命名空间里 PersistentVolumeClaim 的变化
+func (c *Client) WatchAllPersistentVolumeClaims() (<-chan WatchEvent[PersistentVolumeClaim], func(), error) {
+	return watch[PersistentVolumeClaim](c, c.buildURL(clusterPath("persistentvolumeclaims")...))
+}
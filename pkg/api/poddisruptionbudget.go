@@ -0,0 +1,60 @@
+package api
+
+import "time"
+
+// PodDisruptionBudget 限制同时有多少个被选中的 pod 可以因为主动的操作而被驱逐，比如排空节点。
+// 它只对通过 eviction 子资源的驱逐生效，直接删除 pod 不受限制
+type PodDisruptionBudget struct {
+	ObjectMeta
+	Spec   PodDisruptionBudgetSpec   `json:"spec"`
+	Status PodDisruptionBudgetStatus `json:"status"`
+}
+
+type PodDisruptionBudgetSpec struct {
+	// MinAvailable 和 MaxUnavailable 只能设置一个，可以是数量也可以是百分比。
+	// 百分比和 MaxUnavailable 按 pod 的 controller 的期望副本数计算
+	MinAvailable   *IntOrString   `json:"minAvailable,omitempty"`
+	MaxUnavailable *IntOrString   `json:"maxUnavailable,omitempty"`
+	Selector       *LabelSelector `json:"selector"`
+}
+
+type PodDisruptionBudgetStatus struct {
+	ObservedGeneration int64 `json:"observedGeneration"`
+	// DisruptedPods 记录已经批准驱逐、但 controller 还没看到被删除的 pod 和批准的时间，
+	// 这些 pod 不再算作健康，避免 controller 用旧的缓存把已经用掉的名额又算回来
+	DisruptedPods map[string]time.Time `json:"disruptedPods,omitempty"`
+	// DisruptionsAllowed 是现在还可以驱逐多少个 pod
+	DisruptionsAllowed int `json:"disruptionsAllowed"`
+	CurrentHealthy     int `json:"currentHealthy"`
+	DesiredHealthy     int `json:"desiredHealthy"`
+	// ExpectedPods 是计算百分比时使用的 pod 总数
+	ExpectedPods int `json:"expectedPods"`
+}
+
+// Eviction 是 POST pods/<name>/eviction 的请求体，name 和 namespace 可以省略，省略时使用路径里的
+type Eviction struct {
+	ObjectMeta
+}
+
+func (in *PodDisruptionBudget) DeepCopyInto(out *PodDisruptionBudget) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec.MinAvailable = copyIntOrString(in.Spec.MinAvailable)
+	out.Spec.MaxUnavailable = copyIntOrString(in.Spec.MaxUnavailable)
+	out.Spec.Selector = in.Spec.Selector.DeepCopy()
+	if in.Status.DisruptedPods != nil {
+		out.Status.DisruptedPods = make(map[string]time.Time, len(in.Status.DisruptedPods))
+		for name, t := range in.Status.DisruptedPods {
+			out.Status.DisruptedPods[name] = t
+		}
+	}
+}
+
+func (in *PodDisruptionBudget) DeepCopy() *PodDisruptionBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(PodDisruptionBudget)
+	in.DeepCopyInto(out)
+	return out
+}
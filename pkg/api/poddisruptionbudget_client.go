@@ -0,0 +1,58 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+)
+
+func (c *Client) CreatePodDisruptionBudget(namespace string, pdb *PodDisruptionBudget) (*PodDisruptionBudget, error) {
+	return createObject(c, pdb, namespacedPath(namespace, "poddisruptionbudgets")...)
+}
+
+func (c *Client) GetPodDisruptionBudget(namespace, name string) (*PodDisruptionBudget, error) {
+	return getObject[PodDisruptionBudget](c, namespacedPath(namespace, "poddisruptionbudgets", name)...)
+}
+
+func (c *Client) ListPodDisruptionBudgets(namespace string) ([]PodDisruptionBudget, error) {
+	return listObjects[PodDisruptionBudget](c, namespacedPath(namespace, "poddisruptionbudgets")...)
+}
+
+// ListAllPodDisruptionBudgets lists PodDisruptionBudgets across all namespaces.
+func (c *Client) ListAllPodDisruptionBudgets() ([]PodDisruptionBudget, error) {
+	return listObjects[PodDisruptionBudget](c, clusterPath("poddisruptionbudgets")...)
+}
+
+func (c *Client) UpdatePodDisruptionBudget(pdb *PodDisruptionBudget) (*PodDisruptionBudget, error) {
+	if pdb == nil || pdb.Name == "" {
+		return nil, fmt.Errorf("pod disruption budget name must be specified for update")
+	}
+	return updateObject(c, pdb, namespacedPath(pdb.Namespace, "poddisruptionbudgets", pdb.Name)...)
+}
+
+// UpdatePodDisruptionBudgetStatus 只更新 status 子资源，spec 的修改会被忽略
+func (c *Client) UpdatePodDisruptionBudgetStatus(pdb *PodDisruptionBudget) (*PodDisruptionBudget, error) {
+	if pdb == nil || pdb.Name == "" {
+		return nil, fmt.Errorf("pod disruption budget name must be specified for status update")
+	}
+	return updateObject(c, pdb, namespacedPath(pdb.Namespace, "poddisruptionbudgets", pdb.Name, "status")...)
+}
+
+func (c *Client) DeletePodDisruptionBudget(namespace, name string) error {
+	return deleteObject(c, namespacedPath(namespace, "poddisruptionbudgets", name)...)
+}
+
+func (c *Client) ApplyPodDisruptionBudget(namespace, name string, patch []byte, force bool) (*PodDisruptionBudget, error) {
+	return applyObject[PodDisruptionBudget](c, patch, force, namespacedPath(namespace, "poddisruptionbudgets", name)...)
+}
+
+// WatchAllPodDisruptionBudgets 监听所有命名空间里 PodDisruptionBudget 的变化
+func (c *Client) WatchAllPodDisruptionBudgets() (<-chan WatchEvent[PodDisruptionBudget], func(), error) {
+	return watch[PodDisruptionBudget](c, c.buildURL(clusterPath("poddisruptionbudgets")...))
+}
+
+// EvictPod 通过 eviction 子资源删除 pod。驱逐会违反 PodDisruptionBudget 时服务器返回 429，
+// 错误里说明是哪个 budget，调用者应该稍后重试
+func (c *Client) EvictPod(namespace, name string) error {
+	eviction := &Eviction{ObjectMeta: ObjectMeta{Name: name, Namespace: namespace}}
+	return c.do(http.MethodPost, c.buildURL(namespacedPath(namespace, "pods", name, "eviction")...), eviction, nil, http.StatusCreated)
+}
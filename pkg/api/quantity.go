@@ -0,0 +1,126 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ResourceName 是 ResourceList 的键，比如 storage
+type ResourceName string
+
+const (
+	// ResourceStorage 是卷的容量，单位是字节
+	ResourceStorage ResourceName = "storage"
+	// ResourceCPU 是 CPU 核数，可以用 "100m" 表示千分之一核
+	ResourceCPU ResourceName = "cpu"
+	// ResourceMemory 是内存，单位是字节
+	ResourceMemory ResourceName = "memory"
+)
+
+// ResourceList 记录各种资源的数量，值是 "10Gi"、"500M"、"250m" 这样的字符串
+type ResourceList map[ResourceName]string
+
+// quantitySuffixes 是数量可以使用的后缀，二进制后缀按 1024 进位，十进制后缀按 1000 进位
+var quantitySuffixes = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40}, {"Pi", 1 << 50}, {"Ei", 1 << 60},
+	{"k", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12}, {"P", 1e15}, {"E", 1e18},
+}
+
+// ParseQuantity 把 "1Gi"、"500M"、"1024" 这样的数量解析成整数，不能是负数，带小数时向上取整
+func ParseQuantity(s string) (int64, error) {
+	return parseQuantity(s, 1)
+}
+
+// ParseMilliQuantity 把数量解析成千分之一的单位，"100m" 得到 100，"1.5" 得到 1500。CPU 用它表示千分之一核
+func ParseMilliQuantity(s string) (int64, error) {
+	if number, ok := strings.CutSuffix(s, "m"); ok {
+		return parseNumber(s, number, 1)
+	}
+	return parseQuantity(s, 1000)
+}
+
+// parseQuantity 解析带后缀的数量，结果再乘以 scale
+func parseQuantity(s string, scale int64) (int64, error) {
+	number, multiplier := s, int64(1)
+	for _, q := range quantitySuffixes {
+		if strings.HasSuffix(s, q.suffix) {
+			number, multiplier = strings.TrimSuffix(s, q.suffix), q.multiplier
+			break
+		}
+	}
+	if multiplier > math.MaxInt64/scale {
+		return 0, fmt.Errorf("quantity %q is too large", s)
+	}
+	return parseNumber(s, number, multiplier*scale)
+}
+
+func parseNumber(s, number string, multiplier int64) (int64, error) {
+	if number == "" {
+		return 0, fmt.Errorf("invalid quantity %q", s)
+	}
+	if n, err := strconv.ParseInt(number, 10, 64); err == nil {
+		if n < 0 {
+			return 0, fmt.Errorf("quantity %q must not be negative", s)
+		}
+		if n > math.MaxInt64/multiplier {
+			return 0, fmt.Errorf("quantity %q is too large", s)
+		}
+		return n * multiplier, nil
+	}
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("invalid quantity %q", s)
+	}
+	if f < 0 {
+		return 0, fmt.Errorf("quantity %q must not be negative", s)
+	}
+	value := math.Ceil(f * float64(multiplier))
+	if value >= math.MaxInt64 {
+		return 0, fmt.Errorf("quantity %q is too large", s)
+	}
+	return int64(value), nil
+}
+
+// FormatMilliQuantity 把千分之一单位的数量格式化成 "250m"
+func FormatMilliQuantity(milli int64) string {
+	return strconv.FormatInt(milli, 10) + "m"
+}
+
+// FormatQuantity 把数量格式化成能整除的最大二进制后缀，比如 "512Mi"，不能整除 1024 时不带后缀
+func FormatQuantity(value int64) string {
+	for i := len(quantitySuffixes) - 1; i >= 0; i-- {
+		q := quantitySuffixes[i]
+		if !strings.HasSuffix(q.suffix, "i") || value == 0 {
+			continue
+		}
+		if value%q.multiplier == 0 {
+			return strconv.FormatInt(value/q.multiplier, 10) + q.suffix
+		}
+	}
+	return strconv.FormatInt(value, 10)
+}
+
+// Storage 返回 storage 的字节数，没有设置时返回 0
+func (l ResourceList) Storage() (int64, error) {
+	s, ok := l[ResourceStorage]
+	if !ok {
+		return 0, nil
+	}
+	return ParseQuantity(s)
+}
+
+func (in ResourceList) DeepCopy() ResourceList {
+	if in == nil {
+		return nil
+	}
+	out := make(ResourceList, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,55 @@
+package api
+
+import "time"
+
+type ReplicaSet struct {
+	ObjectMeta
+	Spec   ReplicaSetSpec   `json:"spec"`
+	Status ReplicaSetStatus `json:"status"`
+}
+
+type ReplicaSetSpec struct {
+	Replicas int             `json:"replicas"`
+	Selector *LabelSelector  `json:"selector"`
+	Template PodTemplateSpec `json:"template"`
+	// MinReadySeconds 是 pod 进入 Running 之后至少要保持多久才算 available
+	MinReadySeconds int `json:"minReadySeconds,omitempty"`
+}
+
+type ReplicaSetStatus struct {
+	Replicas          int `json:"replicas"`          //没有被删除、也没有结束的 pod 数量
+	ReadyReplicas     int `json:"readyReplicas"`     //处于 Running 的 pod 数量
+	AvailableReplicas int `json:"availableReplicas"` //Running 超过 MinReadySeconds 的 pod 数量
+}
+
+func (in *ReplicaSet) DeepCopyInto(out *ReplicaSet) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec.Selector = in.Spec.Selector.DeepCopy()
+	in.Spec.Template.DeepCopyInto(&out.Spec.Template)
+}
+
+func (in *ReplicaSet) DeepCopy() *ReplicaSet {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// IsPodActive 判断 pod 是否还算在副本数里：没有被删除，也没有运行结束
+func IsPodActive(pod *Pod) bool {
+	return pod.DeletionTimestamp == nil && pod.Phase != PodSucceeded && pod.Phase != PodFailed && pod.Phase != PodDeleted
+}
+
+// IsPodAvailable 判断 pod 是否已经 Running 了至少 minReadySeconds 秒
+func IsPodAvailable(pod *Pod, minReadySeconds int, now time.Time) bool {
+	if pod.Phase != PodRunning || pod.DeletionTimestamp != nil {
+		return false
+	}
+	if minReadySeconds == 0 {
+		return true
+	}
+	return pod.StartTime != nil && !pod.StartTime.Add(time.Duration(minReadySeconds)*time.Second).After(now)
+}
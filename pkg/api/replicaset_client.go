@@ -0,0 +1,48 @@
+package api
+
+import "fmt"
+
+func (c *Client) CreateReplicaSet(namespace string, rs *ReplicaSet) (*ReplicaSet, error) {
+	return createObject(c, rs, namespacedPath(namespace, "replicasets")...)
+}
+
+func (c *Client) GetReplicaSet(namespace, name string) (*ReplicaSet, error) {
+	return getObject[ReplicaSet](c, namespacedPath(namespace, "replicasets", name)...)
+}
+
+func (c *Client) ListReplicaSets(namespace string) ([]ReplicaSet, error) {
+	return listObjects[ReplicaSet](c, namespacedPath(namespace, "replicasets")...)
+}
+
+// ListAllReplicaSets lists ReplicaSets across all namespaces.
+func (c *Client) ListAllReplicaSets() ([]ReplicaSet, error) {
+	return listObjects[ReplicaSet](c, clusterPath("replicasets")...)
+}
+
+func (c *Client) UpdateReplicaSet(rs *ReplicaSet) (*ReplicaSet, error) {
+	if rs == nil || rs.Name == "" {
+		return nil, fmt.Errorf("replicaset name must be specified for update")
+	}
+	return updateObject(c, rs, namespacedPath(rs.Namespace, "replicasets", rs.Name)...)
+}
+
+// UpdateReplicaSetStatus 只更新 status 子资源，spec 的修改会被忽略
+func (c *Client) UpdateReplicaSetStatus(rs *ReplicaSet) (*ReplicaSet, error) {
+	if rs == nil || rs.Name == "" {
+		return nil, fmt.Errorf("replicaset name must be specified for status update")
+	}
+	return updateObject(c, rs, namespacedPath(rs.Namespace, "replicasets", rs.Name, "status")...)
+}
+
+func (c *Client) DeleteReplicaSet(namespace, name string) error {
+	return deleteObject(c, namespacedPath(namespace, "replicasets", name)...)
+}
+
+func (c *Client) ApplyReplicaSet(namespace, name string, patch []byte, force bool) (*ReplicaSet, error) {
+	return applyObject[ReplicaSet](c, patch, force, namespacedPath(namespace, "replicasets", name)...)
+}
+
+// WatchAllReplicaSets 监听所有命名空间里 ReplicaSet 的变化
+func (c *Client) WatchAllReplicaSets() (<-chan WatchEvent[ReplicaSet], func(), error) {
+	return watch[ReplicaSet](c, c.buildURL(clusterPath("replicasets")...))
+}
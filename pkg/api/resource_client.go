@@ -0,0 +1,60 @@
+package api
+
+import "net/http"
+
+// 下面这些泛型函数是各种资源的 client 方法共用的 CRUD 实现，pathSegments 是资源在 API server 上的路径
+
+func namespacedPath(namespace, plural string, name ...string) []string {
+	if namespace == "" {
+		namespace = "default"
+	}
+	return append([]string{"api", "v1", "namespaces", namespace, plural}, name...)
+}
+
+func clusterPath(plural string, name ...string) []string {
+	return append([]string{"api", "v1", plural}, name...)
+}
+
+func createObject[T any](c *Client, obj *T, pathSegments ...string) (*T, error) {
+	var created T
+	if err := c.do(http.MethodPost, c.buildWriteURL(nil, pathSegments...), obj, &created, http.StatusCreated); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func getObject[T any](c *Client, pathSegments ...string) (*T, error) {
+	var obj T
+	if err := c.do(http.MethodGet, c.buildURL(pathSegments...), nil, &obj, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
+
+func listObjects[T any](c *Client, pathSegments ...string) ([]T, error) {
+	var all []T
+	if err := c.do(http.MethodGet, c.buildURL(pathSegments...), nil, &all, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+func updateObject[T any](c *Client, obj *T, pathSegments ...string) (*T, error) {
+	var updated T
+	if err := c.do(http.MethodPut, c.buildWriteURL(nil, pathSegments...), obj, &updated, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func deleteObject(c *Client, pathSegments ...string) error {
+	return c.do(http.MethodDelete, c.buildURL(pathSegments...), nil, nil, http.StatusOK, http.StatusNoContent)
+}
+
+func applyObject[T any](c *Client, patch []byte, force bool, pathSegments ...string) (*T, error) {
+	var obj T
+	if err := c.apply(patch, force, &obj, pathSegments...); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
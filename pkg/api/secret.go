@@ -0,0 +1,41 @@
+package api
+
+// Secret 保存证书、密码这类敏感数据
+type Secret struct {
+	ObjectMeta
+	Type SecretType `json:"type,omitempty"`
+	// Data 的值在 JSON 里是 base64 编码的
+	Data map[string][]byte `json:"data,omitempty"`
+	// StringData 只用于写入：API server 把它合并进 Data 之后清空，同一个键以 StringData 为准
+	StringData map[string]string `json:"stringData,omitempty"`
+}
+
+type SecretType string
+
+const (
+	// SecretTypeOpaque 是任意的键值对，默认类型
+	SecretTypeOpaque SecretType = "Opaque"
+	// SecretTypeTLS 必须包含 PEM 格式的证书链 tls.crt 和私钥 tls.key
+	SecretTypeTLS SecretType = "kubernetes.io/tls"
+)
+
+const (
+	TLSCertKey       = "tls.crt"
+	TLSPrivateKeyKey = "tls.key"
+)
+
+func (in *Secret) DeepCopyInto(out *Secret) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Data = copyBytesMap(in.Data)
+	out.StringData = copyStringMap(in.StringData)
+}
+
+func (in *Secret) DeepCopy() *Secret {
+	if in == nil {
+		return nil
+	}
+	out := new(Secret)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,40 @@
+package api
+
+import "fmt"
+
+func (c *Client) CreateSecret(namespace string, secret *Secret) (*Secret, error) {
+	return createObject(c, secret, namespacedPath(namespace, "secrets")...)
+}
+
+func (c *Client) GetSecret(namespace, name string) (*Secret, error) {
+	return getObject[Secret](c, namespacedPath(namespace, "secrets", name)...)
+}
+
+func (c *Client) ListSecrets(namespace string) ([]Secret, error) {
+	return listObjects[Secret](c, namespacedPath(namespace, "secrets")...)
+}
+
+// ListAllSecrets lists Secrets across all namespaces.
+func (c *Client) ListAllSecrets() ([]Secret, error) {
+	return listObjects[Secret](c, clusterPath("secrets")...)
+}
+
+func (c *Client) UpdateSecret(secret *Secret) (*Secret, error) {
+	if secret == nil || secret.Name == "" {
+		return nil, fmt.Errorf("secret name must be specified for update")
+	}
+	return updateObject(c, secret, namespacedPath(secret.Namespace, "secrets", secret.Name)...)
+}
+
+func (c *Client) DeleteSecret(namespace, name string) error {
+	return deleteObject(c, namespacedPath(namespace, "secrets", name)...)
+}
+
+func (c *Client) ApplySecret(namespace, name string, patch []byte, force bool) (*Secret, error) {
+	return applyObject[Secret](c, patch, force, namespacedPath(namespace, "secrets", name)...)
+}
+
+// WatchAllSecrets 监听所有命名空间里 Secret 的变化
+func (c *Client) WatchAllSecrets() (<-chan WatchEvent[Secret], func(), error) {
+	return watch[Secret](c, c.buildURL(clusterPath("secrets")...))
+}
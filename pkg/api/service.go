@@ -0,0 +1,199 @@
+package api
+
+// Service 给一组 pod 一个稳定的虚拟 IP 和端口，selector 选中的 pod 由 endpoints controller 写进同名的 Endpoints
+type Service struct {
+	ObjectMeta
+	Spec ServiceSpec `json:"spec"`
+}
+
+type ServiceType string
+
+const (
+	// ServiceTypeClusterIP 只分配集群内部的虚拟 IP，默认类型
+	ServiceTypeClusterIP ServiceType = "ClusterIP"
+	// ServiceTypeNodePort 在 ClusterIP 之外，每个端口还在所有节点上分配一个 nodePort
+	ServiceTypeNodePort ServiceType = "NodePort"
+)
+
+// ClusterIPNone 表示 headless service：不分配虚拟 IP，DNS 直接返回 pod 的地址
+const ClusterIPNone = "None"
+
+type Protocol string
+
+const (
+	ProtocolTCP Protocol = "TCP"
+	ProtocolUDP Protocol = "UDP"
+)
+
+// ServiceAffinity 决定同一个客户端的连接是否总是转发到同一个后端
+type ServiceAffinity string
+
+const (
+	ServiceAffinityNone     ServiceAffinity = "None"
+	ServiceAffinityClientIP ServiceAffinity = "ClientIP"
+)
+
+// DefaultClientIPServiceAffinitySeconds 是 ClientIP 亲和性默认的保持时间，三小时
+const DefaultClientIPServiceAffinitySeconds = 10800
+
+type ServiceSpec struct {
+	Type ServiceType `json:"type,omitempty"`
+	// Selector 选中的 pod 是这个 service 的后端；为空时 endpoints controller 不管理它的 Endpoints，可以手动维护
+	Selector map[string]string `json:"selector,omitempty"`
+	Ports    []ServicePort     `json:"ports,omitempty"`
+	// ClusterIP 创建时由 API server 从 service IP 段里分配，也可以指定一个段内的空闲地址或者 None，创建之后不能修改
+	ClusterIP string `json:"clusterIP,omitempty"`
+	// SessionAffinity 为 ClientIP 时，同一个客户端 IP 的连接在 timeoutSeconds 内都转发到同一个后端，默认 None
+	SessionAffinity       ServiceAffinity        `json:"sessionAffinity,omitempty"`
+	SessionAffinityConfig *SessionAffinityConfig `json:"sessionAffinityConfig,omitempty"`
+}
+
+type SessionAffinityConfig struct {
+	ClientIP *ClientIPConfig `json:"clientIP,omitempty"`
+}
+
+type ClientIPConfig struct {
+	// TimeoutSeconds 是客户端最后一次连接之后亲和性保持的时间
+	TimeoutSeconds *int `json:"timeoutSeconds,omitempty"`
+}
+
+type ServicePort struct {
+	// Name 在有多个端口时用来区分它们，Endpoints 里的端口使用同样的名字
+	Name     string   `json:"name,omitempty"`
+	Protocol Protocol `json:"protocol,omitempty"` //默认 TCP
+	Port     int      `json:"port"`
+	// TargetPort 是 pod 上接收流量的端口，默认和 Port 相同
+	TargetPort int `json:"targetPort,omitempty"`
+	// NodePort 只对 NodePort 类型有效，不指定时由 API server 从 nodePort 范围里分配
+	NodePort int `json:"nodePort,omitempty"`
+}
+
+// IsHeadless 表示 service 是否没有虚拟 IP
+func (s *Service) IsHeadless() bool {
+	return s.Spec.ClusterIP == ClusterIPNone
+}
+
+func (in *Service) DeepCopyInto(out *Service) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec.Selector = copyStringMap(in.Spec.Selector)
+	if in.Spec.Ports != nil {
+		out.Spec.Ports = make([]ServicePort, len(in.Spec.Ports))
+		copy(out.Spec.Ports, in.Spec.Ports)
+	}
+	if in.Spec.SessionAffinityConfig != nil {
+		config := SessionAffinityConfig{}
+		if in.Spec.SessionAffinityConfig.ClientIP != nil {
+			config.ClientIP = &ClientIPConfig{TimeoutSeconds: copyIntPtr(in.Spec.SessionAffinityConfig.ClientIP.TimeoutSeconds)}
+		}
+		out.Spec.SessionAffinityConfig = &config
+	}
+}
+
+func (in *Service) DeepCopy() *Service {
+	if in == nil {
+		return nil
+	}
+	out := new(Service)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// Endpoints 记录 service 当前的后端地址，名字和 service 相同
+type Endpoints struct {
+	ObjectMeta
+	Subsets []EndpointSubset `json:"subsets,omitempty"`
+}
+
+// EndpointSubset 是一组地址和它们共同开放的端口
+type EndpointSubset struct {
+	// Addresses 是可以接收流量的后端
+	Addresses []EndpointAddress `json:"addresses,omitempty"`
+	// NotReadyAddresses 是已经分配到节点、但还没有运行起来的后端，不应该接收流量
+	NotReadyAddresses []EndpointAddress `json:"notReadyAddresses,omitempty"`
+	Ports             []EndpointPort    `json:"ports,omitempty"`
+}
+
+type EndpointAddress struct {
+	IP string `json:"ip"`
+	// Hostname 是 pod 的 hostname，pod 的 subdomain 等于 service 名字时才设置，headless service 用它给每个 pod 一个 DNS 名
+	Hostname  string           `json:"hostname,omitempty"`
+	NodeName  string           `json:"nodeName,omitempty"`
+	TargetRef *ObjectReference `json:"targetRef,omitempty"`
+}
+
+type EndpointPort struct {
+	Name     string   `json:"name,omitempty"`
+	Port     int      `json:"port"`
+	Protocol Protocol `json:"protocol,omitempty"`
+}
+
+func (in *Endpoints) DeepCopyInto(out *Endpoints) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Subsets != nil {
+		out.Subsets = make([]EndpointSubset, len(in.Subsets))
+		for i := range in.Subsets {
+			out.Subsets[i] = EndpointSubset{
+				Addresses:         copyEndpointAddresses(in.Subsets[i].Addresses),
+				NotReadyAddresses: copyEndpointAddresses(in.Subsets[i].NotReadyAddresses),
+			}
+			if in.Subsets[i].Ports != nil {
+				out.Subsets[i].Ports = make([]EndpointPort, len(in.Subsets[i].Ports))
+				copy(out.Subsets[i].Ports, in.Subsets[i].Ports)
+			}
+		}
+	}
+}
+
+func (in *Endpoints) DeepCopy() *Endpoints {
+	if in == nil {
+		return nil
+	}
+	out := new(Endpoints)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func copyEndpointAddresses(in []EndpointAddress) []EndpointAddress {
+	if in == nil {
+		return nil
+	}
+	out := make([]EndpointAddress, len(in))
+	for i := range in {
+		out[i] = in[i]
+		if in[i].TargetRef != nil {
+			ref := *in[i].TargetRef
+			out[i].TargetRef = &ref
+		}
+	}
+	return out
+}
+
+// RangeAllocation 是 API server 内部保存的分配位图，比如已经分配出去的 ClusterIP 和 nodePort。
+// 它不通过 REST 提供，只写在存储里，让分配结果和 service 一起保存
+type RangeAllocation struct {
+	ObjectMeta
+	// Range 是位图覆盖的范围，比如 10.96.0.0/16 或者 30000-32767
+	Range string `json:"range"`
+	// Data 是位图，第 i 位为 1 表示范围里第 i 个值已经分配
+	Data []byte `json:"data,omitempty"`
+}
+
+func (in *RangeAllocation) DeepCopyInto(out *RangeAllocation) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Data != nil {
+		out.Data = make([]byte, len(in.Data))
+		copy(out.Data, in.Data)
+	}
+}
+
+func (in *RangeAllocation) DeepCopy() *RangeAllocation {
+	if in == nil {
+		return nil
+	}
+	out := new(RangeAllocation)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,40 @@
+package api
+
+import "fmt"
+
+func (c *Client) CreateService(namespace string, service *Service) (*Service, error) {
+	return createObject(c, service, namespacedPath(namespace, "services")...)
+}
+
+func (c *Client) GetService(namespace, name string) (*Service, error) {
+	return getObject[Service](c, namespacedPath(namespace, "services", name)...)
+}
+
+func (c *Client) ListServices(namespace string) ([]Service, error) {
+	return listObjects[Service](c, namespacedPath(namespace, "services")...)
+}
+
+// ListAllServices lists Services across all namespaces.
+func (c *Client) ListAllServices() ([]Service, error) {
+	return listObjects[Service](c, clusterPath("services")...)
+}
+
+func (c *Client) UpdateService(service *Service) (*Service, error) {
+	if service == nil || service.Name == "" {
+		return nil, fmt.Errorf("service name must be specified for update")
+	}
+	return updateObject(c, service, namespacedPath(service.Namespace, "services", service.Name)...)
+}
+
+func (c *Client) DeleteService(namespace, name string) error {
+	return deleteObject(c, namespacedPath(namespace, "services", name)...)
+}
+
+func (c *Client) ApplyService(namespace, name string, patch []byte, force bool) (*Service, error) {
+	return applyObject[Service](c, patch, force, namespacedPath(namespace, "services", name)...)
+}
+
+// WatchAllServices 监听所有命名空间里 Service 的变化
+func (c *Client) WatchAllServices() (<-chan WatchEvent[Service], func(), error) {
+	return watch[Service](c, c.buildURL(clusterPath("services")...))
+}
@@ -0,0 +1,124 @@
+package api
+
+import "fmt"
+
+type PodManagementPolicyType string
+
+const (
+	// OrderedReadyPodManagement 按序号从小到大逐个创建 pod，前一个 Running 之后才创建下一个，缩容时从大到小逐个删除
+	OrderedReadyPodManagement PodManagementPolicyType = "OrderedReady"
+	// ParallelPodManagement 同时创建和删除所有 pod
+	ParallelPodManagement PodManagementPolicyType = "Parallel"
+)
+
+type StatefulSetUpdateStrategyType string
+
+const (
+	// RollingUpdateStatefulSetStrategyType 按序号从大到小逐个替换旧模板的 pod，序号小于 partition 的 pod 保持旧版本
+	RollingUpdateStatefulSetStrategyType StatefulSetUpdateStrategyType = "RollingUpdate"
+	// OnDeleteStatefulSetStrategyType 只有旧的 pod 被手动删除后才用新模板重建
+	OnDeleteStatefulSetStrategyType StatefulSetUpdateStrategyType = "OnDelete"
+)
+
+// StatefulSetPodNameLabel 加在 StatefulSet 的每个 pod 上，值为 pod 名，方便为单个副本创建 Service
+const StatefulSetPodNameLabel = "statefulset.kubernetes.io/pod-name"
+
+type StatefulSet struct {
+	ObjectMeta
+	Spec   StatefulSetSpec   `json:"spec"`
+	Status StatefulSetStatus `json:"status"`
+}
+
+type StatefulSetSpec struct {
+	Replicas int             `json:"replicas"`
+	Selector *LabelSelector  `json:"selector"`
+	Template PodTemplateSpec `json:"template"`
+	// ServiceName 是负责这组 pod 网络标识的 headless Service，pod 的 subdomain 会设置成它
+	ServiceName         string                    `json:"serviceName,omitempty"`
+	PodManagementPolicy PodManagementPolicyType   `json:"podManagementPolicy,omitempty"` //默认 OrderedReady
+	UpdateStrategy      StatefulSetUpdateStrategy `json:"updateStrategy"`
+	MinReadySeconds     int                       `json:"minReadySeconds,omitempty"`
+	// RevisionHistoryLimit 是保留的旧 ControllerRevision 数量，默认 10
+	RevisionHistoryLimit *int `json:"revisionHistoryLimit,omitempty"`
+	// VolumeClaimTemplates 为每个副本创建自己的 PVC，名字是 <模板名>-<statefulset>-<序号>，
+	// 作为和模板同名的卷加到 pod 上。缩容或者删除 StatefulSet 时 PVC 保留，副本重建后继续使用原来的数据。创建之后不能修改
+	VolumeClaimTemplates []PersistentVolumeClaim `json:"volumeClaimTemplates,omitempty"`
+}
+
+type StatefulSetUpdateStrategy struct {
+	Type          StatefulSetUpdateStrategyType     `json:"type"`
+	RollingUpdate *RollingUpdateStatefulSetStrategy `json:"rollingUpdate,omitempty"`
+}
+
+type RollingUpdateStatefulSetStrategy struct {
+	// Partition 设置之后只更新序号大于等于它的 pod，用于金丝雀发布，默认 0
+	Partition *int `json:"partition,omitempty"`
+}
+
+type StatefulSetStatus struct {
+	ObservedGeneration int64 `json:"observedGeneration"`
+	Replicas           int   `json:"replicas"`
+	ReadyReplicas      int   `json:"readyReplicas"`
+	AvailableReplicas  int   `json:"availableReplicas"`
+	CurrentReplicas    int   `json:"currentReplicas"` //按 currentRevision 创建的 pod 数量
+	UpdatedReplicas    int   `json:"updatedReplicas"` //按 updateRevision 创建的 pod 数量
+	// CurrentRevision 是滚动更新开始前的版本，所有 pod 都更新之后变成 UpdateRevision
+	CurrentRevision string `json:"currentRevision,omitempty"`
+	UpdateRevision  string `json:"updateRevision,omitempty"`
+}
+
+func (in *StatefulSet) DeepCopyInto(out *StatefulSet) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec.Selector = in.Spec.Selector.DeepCopy()
+	in.Spec.Template.DeepCopyInto(&out.Spec.Template)
+	if in.Spec.UpdateStrategy.RollingUpdate != nil {
+		out.Spec.UpdateStrategy.RollingUpdate = &RollingUpdateStatefulSetStrategy{
+			Partition: copyIntPtr(in.Spec.UpdateStrategy.RollingUpdate.Partition),
+		}
+	}
+	out.Spec.RevisionHistoryLimit = copyIntPtr(in.Spec.RevisionHistoryLimit)
+	if in.Spec.VolumeClaimTemplates != nil {
+		out.Spec.VolumeClaimTemplates = make([]PersistentVolumeClaim, len(in.Spec.VolumeClaimTemplates))
+		for i := range in.Spec.VolumeClaimTemplates {
+			in.Spec.VolumeClaimTemplates[i].DeepCopyInto(&out.Spec.VolumeClaimTemplates[i])
+		}
+	}
+}
+
+// PersistentVolumeClaimName 返回第 ordinal 个副本按 claim 模板创建的 PVC 的名字
+func (ss *StatefulSet) PersistentVolumeClaimName(claim *PersistentVolumeClaim, ordinal int) string {
+	return fmt.Sprintf("%s-%s-%d", claim.Name, ss.Name, ordinal)
+}
+
+// PodVolumes 返回第 ordinal 个副本的卷：pod 模板里的卷 templateVolumes 加上每个 volumeClaimTemplate 对应的 PVC，
+// 同名时 PVC 替换模板里的卷
+func (ss *StatefulSet) PodVolumes(templateVolumes []Volume, ordinal int) []Volume {
+	volumes := append([]Volume(nil), templateVolumes...)
+	for i := range ss.Spec.VolumeClaimTemplates {
+		claim := &ss.Spec.VolumeClaimTemplates[i]
+		volume := Volume{Name: claim.Name, VolumeSource: VolumeSource{
+			PersistentVolumeClaim: &PersistentVolumeClaimVolumeSource{ClaimName: ss.PersistentVolumeClaimName(claim, ordinal)},
+		}}
+		replaced := false
+		for j := range volumes {
+			if volumes[j].Name == claim.Name {
+				volumes[j] = volume
+				replaced = true
+			}
+		}
+		if !replaced {
+			volumes = append(volumes, volume)
+		}
+	}
+	return volumes
+}
+
+func (in *StatefulSet) DeepCopy() *StatefulSet {
+	if in == nil {
+		return nil
+	}
+	out := new(StatefulSet)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,48 @@
+package api
+
+import "fmt"
+
+func (c *Client) CreateStatefulSet(namespace string, ss *StatefulSet) (*StatefulSet, error) {
+	return createObject(c, ss, namespacedPath(namespace, "statefulsets")...)
+}
+
+func (c *Client) GetStatefulSet(namespace, name string) (*StatefulSet, error) {
+	return getObject[StatefulSet](c, namespacedPath(namespace, "statefulsets", name)...)
+}
+
+func (c *Client) ListStatefulSets(namespace string) ([]StatefulSet, error) {
+	return listObjects[StatefulSet](c, namespacedPath(namespace, "statefulsets")...)
+}
+
+// ListAllStatefulSets lists StatefulSets across all namespaces.
+func (c *Client) ListAllStatefulSets() ([]StatefulSet, error) {
+	return listObjects[StatefulSet](c, clusterPath("statefulsets")...)
+}
+
+func (c *Client) UpdateStatefulSet(ss *StatefulSet) (*StatefulSet, error) {
+	if ss == nil || ss.Name == "" {
+		return nil, fmt.Errorf("statefulset name must be specified for update")
+	}
+	return updateObject(c, ss, namespacedPath(ss.Namespace, "statefulsets", ss.Name)...)
+}
+
+// UpdateStatefulSetStatus 只更新 status 子资源，spec 的修改会被忽略
+func (c *Client) UpdateStatefulSetStatus(ss *StatefulSet) (*StatefulSet, error) {
+	if ss == nil || ss.Name == "" {
+		return nil, fmt.Errorf("statefulset name must be specified for status update")
+	}
+	return updateObject(c, ss, namespacedPath(ss.Namespace, "statefulsets", ss.Name, "status")...)
+}
+
+func (c *Client) DeleteStatefulSet(namespace, name string) error {
+	return deleteObject(c, namespacedPath(namespace, "statefulsets", name)...)
+}
+
+func (c *Client) ApplyStatefulSet(namespace, name string, patch []byte, force bool) (*StatefulSet, error) {
+	return applyObject[StatefulSet](c, patch, force, namespacedPath(namespace, "statefulsets", name)...)
+}
+
+// WatchAllStatefulSets 监听所有命名空间里 StatefulSet 的变化
+func (c *Client) WatchAllStatefulSets() (<-chan WatchEvent[StatefulSet], func(), error) {
+	return watch[StatefulSet](c, c.buildURL(clusterPath("statefulsets")...))
+}
@@ -0,0 +1,56 @@
+package api
+
+// StorageClass 描述一类可以动态创建的存储。PVC 通过 storageClassName 选择它，
+// 没有现成的卷可以绑定时由 Provisioner 对应的 provisioner 创建一个卷
+type StorageClass struct {
+	ObjectMeta
+	// Provisioner 是负责为这个 class 创建卷的 provisioner 的名字
+	Provisioner string `json:"provisioner"`
+	// Parameters 传给 provisioner，含义由 provisioner 决定
+	Parameters map[string]string `json:"parameters,omitempty"`
+	// ReclaimPolicy 是创建出来的卷的回收策略，默认 Delete
+	ReclaimPolicy PersistentVolumeReclaimPolicy `json:"reclaimPolicy,omitempty"`
+	// VolumeBindingMode 决定什么时候绑定和创建卷，默认 Immediate
+	VolumeBindingMode VolumeBindingMode `json:"volumeBindingMode,omitempty"`
+}
+
+type VolumeBindingMode string
+
+const (
+	// VolumeBindingImmediate 的 PVC 创建之后马上绑定，没有合适的卷时马上创建
+	VolumeBindingImmediate VolumeBindingMode = "Immediate"
+	// VolumeBindingWaitForFirstConsumer 的 PVC 等到第一个使用它的 pod 被调度时才绑定，
+	// 卷创建在 pod 要去的节点上
+	VolumeBindingWaitForFirstConsumer VolumeBindingMode = "WaitForFirstConsumer"
+)
+
+const (
+	// AnnDefaultStorageClass 为 "true" 的 StorageClass 是默认的，创建时没有设置 storageClassName 的 PVC 会使用它
+	AnnDefaultStorageClass = "storageclass.kubernetes.io/is-default-class"
+	// AnnStorageProvisioner 由 binder 加在找不到卷的 PVC 上，值是应该为它创建卷的 provisioner
+	AnnStorageProvisioner = "volume.kubernetes.io/storage-provisioner"
+	// AnnSelectedNode 由调度器加在 WaitForFirstConsumer 的 PVC 上，值是使用它的 pod 要去的节点
+	AnnSelectedNode = "volume.kubernetes.io/selected-node"
+	// AnnDynamicallyProvisioned 记录创建这个卷的 provisioner，删除卷时由它清理
+	AnnDynamicallyProvisioned = "pv.kubernetes.io/provisioned-by"
+)
+
+// IsDefaultClass 判断 class 是不是默认的 StorageClass
+func (sc *StorageClass) IsDefaultClass() bool {
+	return sc.Annotations[AnnDefaultStorageClass] == "true"
+}
+
+func (in *StorageClass) DeepCopyInto(out *StorageClass) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Parameters = copyStringMap(in.Parameters)
+}
+
+func (in *StorageClass) DeepCopy() *StorageClass {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageClass)
+	in.DeepCopyInto(out)
+	return out
+}
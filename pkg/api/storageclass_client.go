@@ -0,0 +1,35 @@
+package api
+
+import "fmt"
+
+func (c *Client) CreateStorageClass(sc *StorageClass) (*StorageClass, error) {
+	return createObject(c, sc, clusterPath("storageclasses")...)
+}
+
+func (c *Client) GetStorageClass(name string) (*StorageClass, error) {
+	return getObject[StorageClass](c, clusterPath("storageclasses", name)...)
+}
+
+func (c *Client) ListStorageClasses() ([]StorageClass, error) {
+	return listObjects[StorageClass](c, clusterPath("storageclasses")...)
+}
+
+func (c *Client) UpdateStorageClass(sc *StorageClass) (*StorageClass, error) {
+	if sc == nil || sc.Name == "" {
+		return nil, fmt.Errorf("storageclass name must be specified for update")
+	}
+	return updateObject(c, sc, clusterPath("storageclasses", sc.Name)...)
+}
+
+func (c *Client) DeleteStorageClass(name string) error {
+	return deleteObject(c, clusterPath("storageclasses", name)...)
+}
+
+func (c *Client) ApplyStorageClass(name string, patch []byte, force bool) (*StorageClass, error) {
+	return applyObject[StorageClass](c, patch, force, clusterPath("storageclasses", name)...)
+}
+
+// WatchStorageClasses 监听 StorageClass 的变化
+func (c *Client) WatchStorageClasses() (<-chan WatchEvent[StorageClass], func(), error) {
+	return watch[StorageClass](c, c.buildURL(clusterPath("storageclasses")...))
+}
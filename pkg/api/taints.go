@@ -0,0 +1,134 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+type TaintEffect string
+
+const (
+	// TaintEffectNoSchedule 不容忍这个污点的 pod 不会被调度到节点上，已经在上面的 pod 不受影响
+	TaintEffectNoSchedule TaintEffect = "NoSchedule"
+	// TaintEffectPreferNoSchedule 调度器尽量避开这个节点
+	TaintEffectPreferNoSchedule TaintEffect = "PreferNoSchedule"
+	// TaintEffectNoExecute 不容忍这个污点的 pod 不会被调度上去，已经在上面运行的也会被驱逐
+	TaintEffectNoExecute TaintEffect = "NoExecute"
+)
+
+// 系统使用的污点
+const (
+	TaintNodeNotReady      = "node.kubernetes.io/not-ready"
+	TaintNodeUnreachable   = "node.kubernetes.io/unreachable"
+	TaintNodeUnschedulable = "node.kubernetes.io/unschedulable"
+)
+
+// LabelHostname 是 kubelet 注册节点时加上的 label，值为节点名
+const LabelHostname = "kubernetes.io/hostname"
+
+type Taint struct {
+	Key    string      `json:"key"`
+	Value  string      `json:"value,omitempty"`
+	Effect TaintEffect `json:"effect"`
+}
+
+type TolerationOperator string
+
+const (
+	TolerationOpExists TolerationOperator = "Exists"
+	TolerationOpEqual  TolerationOperator = "Equal"
+)
+
+// Toleration 让 pod 可以容忍匹配的污点。Key 为空且 Operator 为 Exists 时容忍所有污点，Effect 为空时匹配所有效果
+type Toleration struct {
+	Key      string             `json:"key,omitempty"`
+	Operator TolerationOperator `json:"operator,omitempty"` //默认 Equal
+	Value    string             `json:"value,omitempty"`
+	Effect   TaintEffect        `json:"effect,omitempty"`
+}
+
+// ToleratesTaint 判断这个 toleration 是否容忍 taint
+func (t *Toleration) ToleratesTaint(taint *Taint) bool {
+	if t.Effect != "" && t.Effect != taint.Effect {
+		return false
+	}
+	if t.Key != "" && t.Key != taint.Key {
+		return false
+	}
+	switch t.Operator {
+	case TolerationOpExists:
+		return true
+	case "", TolerationOpEqual:
+		return t.Key != "" && t.Value == taint.Value
+	}
+	return false
+}
+
+// TolerationsTolerateTaint 判断 tolerations 里是否有一个容忍 taint
+func TolerationsTolerateTaint(tolerations []Toleration, taint *Taint) bool {
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindUntoleratedTaint 返回节点上第一个效果在 effects 里、并且没有被 tolerations 容忍的污点
+func FindUntoleratedTaint(taints []Taint, tolerations []Toleration, effects ...TaintEffect) (*Taint, bool) {
+	for i := range taints {
+		for _, effect := range effects {
+			if taints[i].Effect == effect && !TolerationsTolerateTaint(tolerations, &taints[i]) {
+				return &taints[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// MatchesNodeSelector 判断节点的 labels 是否包含 nodeSelector 的所有键值对，空的 nodeSelector 匹配所有节点
+func MatchesNodeSelector(nodeSelector, labels map[string]string) bool {
+	for k, v := range nodeSelector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// PodFitsNode 判断 pod 能否放到节点上：节点满足 nodeSelector，并且 pod 容忍节点上所有 NoSchedule 和 NoExecute 污点
+func PodFitsNode(spec *PodSpec, node *Node) bool {
+	if !MatchesNodeSelector(spec.NodeSelector, node.Labels) {
+		return false
+	}
+	_, untolerated := FindUntoleratedTaint(node.Taints, spec.Tolerations, TaintEffectNoSchedule, TaintEffectNoExecute)
+	return !untolerated
+}
+
+func (t Taint) String() string {
+	if t.Value == "" {
+		return t.Key + ":" + string(t.Effect)
+	}
+	return t.Key + "=" + t.Value + ":" + string(t.Effect)
+}
+
+// ParseTaint 解析 key=value:Effect 或者 key:Effect 形式的污点
+func ParseTaint(s string) (Taint, error) {
+	i := strings.LastIndex(s, ":")
+	if i < 0 {
+		return Taint{}, fmt.Errorf("invalid taint %q: expected key=value:Effect", s)
+	}
+	taint := Taint{Key: s[:i], Effect: TaintEffect(s[i+1:])}
+	if k, v, ok := strings.Cut(taint.Key, "="); ok {
+		taint.Key, taint.Value = k, v
+	}
+	switch taint.Effect {
+	case TaintEffectNoSchedule, TaintEffectPreferNoSchedule, TaintEffectNoExecute:
+	default:
+		return Taint{}, fmt.Errorf("invalid taint effect %q: must be NoSchedule, PreferNoSchedule or NoExecute", taint.Effect)
+	}
+	if taint.Key == "" {
+		return Taint{}, fmt.Errorf("invalid taint %q: key must not be empty", s)
+	}
+	return taint, nil
+}
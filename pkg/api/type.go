@@ -17,20 +17,156 @@ const (
 	NodeNotReady NodeStatus = "NotReady"
 )
 
+// ApplyPatchType 是 server-side apply 请求使用的 Content-Type
+const ApplyPatchType = "application/apply-patch+json"
+
+const (
+	ManagedFieldsOperationApply  ManagedFieldsOperation = "Apply"  // Fields were set through a server-side apply PATCH.
+	ManagedFieldsOperationUpdate ManagedFieldsOperation = "Update" // Fields were set through a create or a PUT.
+)
+
 type NodeStatus string
 
+// ObjectMeta 是所有资源共有的元数据，嵌入到资源里后 JSON 仍然是扁平的（name、namespace 与其它字段同级）
+type ObjectMeta struct {
+	Name              string               `json:"name"`
+	GenerateName      string               `json:"generateName,omitempty"` //name 为空时，API server 用它加上随机后缀生成名字
+	Namespace         string               `json:"namespace,omitempty"`
+	UID               string               `json:"uid,omitempty"`             //创建时由 API server 分配，同名对象删除重建后 UID 不同
+	ResourceVersion   string               `json:"resourceVersion,omitempty"` //每次写入都会变化；更新时带上它，只有对象没被别人改过才会成功
+	CreationTimestamp *time.Time           `json:"creationTimestamp,omitempty"`
+	DeletionTimestamp *time.Time           `json:"deletionTimestamp,omitempty"` //启用软删除功能，以便 pod 能被优雅地清理
+	Generation        int64                `json:"generation,omitempty"`        //spec 每变化一次加一，controller 用 status.observedGeneration 表示已经处理到哪一代
+	Labels            map[string]string    `json:"labels,omitempty"`
+	Annotations       map[string]string    `json:"annotations,omitempty"`
+	OwnerReferences   []OwnerReference     `json:"ownerReferences,omitempty"`
+	Finalizers        []string             `json:"finalizers,omitempty"`    //设置了 deletionTimestamp 之后，要等所有 finalizer 都被移除才真正从存储里删除
+	ManagedFields     []ManagedFieldsEntry `json:"managedFields,omitempty"` //记录每个字段归哪个 manager 所有，server-side apply 用它来判断冲突
+}
+
+// OwnerReference 指向创建并管理这个对象的对象，比如 ReplicaSet 创建的 pod
+type OwnerReference struct {
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	UID        string `json:"uid"`
+	Controller bool   `json:"controller,omitempty"` //同一个对象最多只有一个 controller owner
+	// BlockOwnerDeletion 为 true 时，owner 以 Foreground 方式删除要等这个对象删除之后才完成
+	BlockOwnerDeletion bool `json:"blockOwnerDeletion,omitempty"`
+}
+
+// ObjectReference 指向另一个对象，比如 CronJob 正在运行的 Job
+type ObjectReference struct {
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	UID       string `json:"uid,omitempty"`
+}
+
+// ControllerRef 返回对象的 controller owner，没有时返回 nil
+func (m *ObjectMeta) ControllerRef() *OwnerReference {
+	for i := range m.OwnerReferences {
+		if m.OwnerReferences[i].Controller {
+			return &m.OwnerReferences[i]
+		}
+	}
+	return nil
+}
+
+type ManagedFieldsOperation string
+
+// ManagedFieldsEntry records the set of fields one manager owns. Fields are
+// JSON pointers (RFC 6901) into the object, such as "/image" or "/labels/app".
+type ManagedFieldsEntry struct {
+	Manager   string                 `json:"manager"`
+	Operation ManagedFieldsOperation `json:"operation"`
+	Time      *time.Time             `json:"time,omitempty"`
+	Fields    []string               `json:"fields"`
+}
+
 type Pod struct {
-	Name              string     `json:"name"`
-	Namespace         string     `json:"namespace"`
-	Image             string     `json:"image"`
-	NodeName          string     `json:"nodeName"`
-	Phase             PodPhase   `json:"phase"`                       //跟踪容器在其生命周期中的状态：待处理、已调度、正在运行、终止中、已删除等
-	DeletionTimestamp *time.Time `json:"deletionTimestamp,omitempty"` //启用软删除功能，以便 pod 能被优雅地清理
+	ObjectMeta
+	PodSpec
+	NodeName  string     `json:"nodeName"`
+	Phase     PodPhase   `json:"phase"`               //跟踪容器在其生命周期中的状态：待处理、已调度、正在运行、终止中、已删除等
+	StartTime *time.Time `json:"startTime,omitempty"` //kubelet 把 pod 启动起来（Running）的时间
+	//RestartCount 是 kubelet 在原地重启 command 的次数，Job 把它算作失败次数
+	RestartCount int    `json:"restartCount,omitempty"`
+	Reason       string `json:"reason,omitempty"`  //pod 进入 Succeeded/Failed 的原因，比如 Completed、Error
+	Message      string `json:"message,omitempty"` //对 Reason 的补充说明，比如退出码
+	//PodIP 是 kubelet 从节点的 PodCIDR 里给 pod 分配的地址，HostIP 是 pod 所在节点的地址
+	PodIP  string `json:"podIP,omitempty"`
+	HostIP string `json:"hostIP,omitempty"`
+}
+
+// PodSpec 是 pod 中由用户描述的部分，嵌入到 Pod 里 JSON 仍然是扁平的；pod 模板也使用它
+type PodSpec struct {
+	Image string `json:"image"`
+	// Command 为空时 kubelet 只模拟一个一直运行的容器；不为空时 kubelet 在节点上执行这个命令，按退出码决定 pod 是否成功
+	Command       []string      `json:"command,omitempty"`
+	RestartPolicy RestartPolicy `json:"restartPolicy,omitempty"` //默认 Always
+	// NodeSelector 要求节点的 labels 包含这些键值对
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	Tolerations  []Toleration      `json:"tolerations,omitempty"`
+	// Hostname 和 Subdomain 组成 pod 的 DNS 名 <hostname>.<subdomain>.<namespace>，StatefulSet 用它们给每个副本稳定的网络标识
+	Hostname  string `json:"hostname,omitempty"`
+	Subdomain string `json:"subdomain,omitempty"`
+	// Env 追加在 kubelet 设置的 POD_NAME 等环境变量之后，同名时覆盖它们
+	Env          []EnvVar      `json:"env,omitempty"`
+	Volumes      []Volume      `json:"volumes,omitempty"`
+	VolumeMounts []VolumeMount `json:"volumeMounts,omitempty"`
+	// Resources 的 requests 是 pod 需要的 cpu 和 memory，HPA 按它计算使用率
+	Resources ResourceRequirements `json:"resources,omitempty"`
+}
+
+// ResourceRequirements 描述 pod 需要的资源
+type ResourceRequirements struct {
+	Requests ResourceList `json:"requests,omitempty"`
+}
+
+// RestartPolicy 决定 command 退出之后 kubelet 是否在原地重启它
+type RestartPolicy string
+
+const (
+	RestartPolicyAlways    RestartPolicy = "Always"
+	RestartPolicyOnFailure RestartPolicy = "OnFailure"
+	RestartPolicyNever     RestartPolicy = "Never"
+)
+
+// PodTemplateSpec 描述 controller 创建 pod 时使用的模板
+type PodTemplateSpec struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     PodSpec    `json:"spec"`
 }
 type PodPhase string
 
 type Node struct {
-	Name    string     `json:"name"`
-	Address string     `json:"address"`
+	ObjectMeta
+	Address string `json:"address"`
+	// PodCIDR 是 apiserver 在节点注册时从集群网段里分给它的子网，kubelet 从中给 pod 分配地址
+	PodCIDR string     `json:"podCIDR,omitempty"`
 	Status  NodeStatus `json:"status"`
+	Taints  []Taint    `json:"taints,omitempty"`
+	// LastHeartbeatTime 是 kubelet 最近一次上报节点状态的时间，node lifecycle controller 据此判断节点是否失联
+	LastHeartbeatTime *time.Time `json:"lastHeartbeatTime,omitempty"`
+}
+
+// Object 由所有嵌入了 ObjectMeta 的资源实现，方便通用逻辑（比如 server-side apply）处理不同类型的资源
+type Object interface {
+	GetObjectMeta() *ObjectMeta
+}
+
+func (m *ObjectMeta) GetObjectMeta() *ObjectMeta { return m }
+
+const (
+	EventAdded    EventType = "ADDED"
+	EventModified EventType = "MODIFIED"
+	EventDeleted  EventType = "DELETED"
+)
+
+type EventType string
+
+// WatchEvent 是 watch 接口返回的一条变化，每行一个 JSON
+type WatchEvent[T any] struct {
+	Type   EventType `json:"type"`
+	Object T         `json:"object"`
 }
@@ -0,0 +1,113 @@
+package api
+
+// Volume 是 pod 可以使用的一个目录。pod 进程和节点共用文件系统，kubelet 把每个卷准备在
+// $POD_VOLUMES/<name> 下，进程从那里读取；volumeMounts 再把卷挂到 $POD_ROOT 下的路径
+type Volume struct {
+	Name string `json:"name"`
+	VolumeSource
+}
+
+// VolumeSource 描述卷的内容，只能设置一种
+type VolumeSource struct {
+	// ConfigMap 把 ConfigMap 的键投射成文件，ConfigMap 变化之后 kubelet 会更新文件
+	ConfigMap *ConfigMapVolumeSource `json:"configMap,omitempty"`
+	// Secret 把 Secret 的键投射成文件
+	Secret *SecretVolumeSource `json:"secret,omitempty"`
+	// EmptyDir 是 pod 启动时创建的空目录，进程重启后内容还在，pod 删除时一起删除
+	EmptyDir *EmptyDirVolumeSource `json:"emptyDir,omitempty"`
+	// HostPath 直接使用节点上的一个路径，pod 删除后内容保留
+	HostPath *HostPathVolumeSource `json:"hostPath,omitempty"`
+	// PersistentVolumeClaim 使用同一个命名空间里的 PVC 绑定的卷
+	PersistentVolumeClaim *PersistentVolumeClaimVolumeSource `json:"persistentVolumeClaim,omitempty"`
+}
+
+type EmptyDirVolumeSource struct {
+	// SizeLimit 是目录里的文件总共可以使用的字节数，比如 "64Mi"。超出之后 kubelet 驱逐 pod，为空时不限制
+	SizeLimit string `json:"sizeLimit,omitempty"`
+}
+
+type HostPathVolumeSource struct {
+	// Path 是节点上的绝对路径
+	Path string `json:"path"`
+	// Type 决定 kubelet 启动 pod 之前怎样检查这个路径，为空时不检查
+	Type HostPathType `json:"type,omitempty"`
+}
+
+type HostPathType string
+
+const (
+	// HostPathDirectoryOrCreate 路径不存在时创建一个空目录
+	HostPathDirectoryOrCreate HostPathType = "DirectoryOrCreate"
+	// HostPathDirectory 路径必须是已经存在的目录
+	HostPathDirectory HostPathType = "Directory"
+	// HostPathFileOrCreate 路径不存在时创建一个空文件，它的父目录必须存在
+	HostPathFileOrCreate HostPathType = "FileOrCreate"
+	// HostPathFile 路径必须是已经存在的文件
+	HostPathFile HostPathType = "File"
+)
+
+type PersistentVolumeClaimVolumeSource struct {
+	ClaimName string `json:"claimName"`
+	// ReadOnly 为 true 时所有挂载都是只读的
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
+// VolumeMount 把卷挂到 pod 里的一个路径。pod 进程没有自己的根文件系统，kubelet 在 $POD_ROOT 下按 MountPath
+// 建立指向卷的符号链接，进程的工作目录就是 $POD_ROOT
+type VolumeMount struct {
+	// Name 是 volumes 里的卷名
+	Name string `json:"name"`
+	// MountPath 是以 / 开头的路径，对应 $POD_ROOT<mountPath>
+	MountPath string `json:"mountPath"`
+	// SubPath 只挂载卷里的这个相对路径，不存在时 kubelet 创建这个目录
+	SubPath string `json:"subPath,omitempty"`
+	// ReadOnly 只是声明：进程和节点共用文件系统，kubelet 没有办法阻止进程写入
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
+type ConfigMapVolumeSource struct {
+	Name string `json:"name"`
+	// Items 为空时投射所有键，文件名就是键；不为空时只投射列出的键
+	Items    []KeyToPath `json:"items,omitempty"`
+	Optional *bool       `json:"optional,omitempty"`
+}
+
+type SecretVolumeSource struct {
+	SecretName string      `json:"secretName"`
+	Items      []KeyToPath `json:"items,omitempty"`
+	Optional   *bool       `json:"optional,omitempty"`
+}
+
+// KeyToPath 把一个键投射到卷里的相对路径，路径可以包含子目录，不能包含 ..
+type KeyToPath struct {
+	Key  string `json:"key"`
+	Path string `json:"path"`
+}
+
+func (in *Volume) DeepCopyInto(out *Volume) {
+	*out = *in
+	if src := in.ConfigMap; src != nil {
+		s := *src
+		s.Items = append([]KeyToPath(nil), src.Items...)
+		s.Optional = copyBoolPtr(src.Optional)
+		out.ConfigMap = &s
+	}
+	if src := in.Secret; src != nil {
+		s := *src
+		s.Items = append([]KeyToPath(nil), src.Items...)
+		s.Optional = copyBoolPtr(src.Optional)
+		out.Secret = &s
+	}
+	if in.EmptyDir != nil {
+		s := *in.EmptyDir
+		out.EmptyDir = &s
+	}
+	if in.HostPath != nil {
+		s := *in.HostPath
+		out.HostPath = &s
+	}
+	if in.PersistentVolumeClaim != nil {
+		s := *in.PersistentVolumeClaim
+		out.PersistentVolumeClaim = &s
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// watch 打开一个 watch 长连接，把服务端每行一个的事件解码后发到返回的 channel。
+// 连接断开时 channel 会被关闭，调用方应该重新 list 再 watch；调用返回的函数可以主动结束 watch
+func watch[T any](c *Client, urlStr string) (<-chan WatchEvent[T], func(), error) {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing watch URL: %w", err)
+	}
+	query := u.Query()
+	query.Set("watch", "true")
+	u.RawQuery = query.Encode()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		cancel()
+		return nil, nil, fmt.Errorf("creating request: %w", err)
+	}
+	//watch 是长连接，不能使用带超时的 httpClient
+	watchClient := &http.Client{Transport: c.httpClient.Transport}
+	resp, err := watchClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, nil, fmt.Errorf("executing request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		cancel()
+		return nil, nil, decodeAPIError(resp)
+	}
+
+	events := make(chan WatchEvent[T])
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+		decoder := json.NewDecoder(resp.Body)
+		for {
+			var event WatchEvent[T]
+			if err := decoder.Decode(&event); err != nil {
+				return
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, cancel, nil
+}
+
+// WatchPods 监听命名空间里 pod 的变化，第一批事件是当前已有的 pod（ADDED）
+func (c *Client) WatchPods(namespace string) (<-chan WatchEvent[Pod], func(), error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+	return watch[Pod](c, c.buildURL("api", "v1", "namespaces", namespace, "pods"))
+}
+
+// WatchAllPods 监听所有命名空间里 pod 的变化
+func (c *Client) WatchAllPods() (<-chan WatchEvent[Pod], func(), error) {
+	return watch[Pod](c, c.buildURL("api", "v1", "pods"))
+}
+
+// WatchNodes 监听节点的变化
+func (c *Client) WatchNodes() (<-chan WatchEvent[Node], func(), error) {
+	return watch[Node](c, c.buildURL("api", "v1", "nodes"))
+}
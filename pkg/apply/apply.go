@@ -0,0 +1,255 @@
+// Package apply implements server-side apply: merging partial objects and
+// tracking which field manager owns which fields through managedFields.
+package apply
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mini-k8s/pkg/api"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Conflict struct {
+	Manager string `json:"manager"`
+	Field   string `json:"field"`
+}
+
+// ConflictError is returned when an apply would change fields owned by
+// another manager and force was not requested.
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	parts := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		parts = append(parts, fmt.Sprintf("field %s is owned by %q", c.Field, c.Manager))
+	}
+	return "apply conflict: " + strings.Join(parts, "; ")
+}
+
+func IsConflict(err error) bool {
+	var conflictErr *ConflictError
+	return errors.As(err, &conflictErr)
+}
+
+// Apply merges the partial object in patch into live on behalf of manager and
+// decodes the result, including its updated managedFields, into out. live is
+// nil when the apply creates the object.
+func Apply(live api.Object, patch []byte, manager string, force bool, out api.Object) error {
+	if manager == "" {
+		return fmt.Errorf("fieldManager is required for apply")
+	}
+	liveMap := map[string]interface{}{}
+	var managed []api.ManagedFieldsEntry
+	if live != nil {
+		m, err := toMap(live)
+		if err != nil {
+			return err
+		}
+		liveMap = m
+		managed = live.GetObjectMeta().ManagedFields
+	}
+	patchMap, err := decodeMap(patch)
+	if err != nil {
+		return err
+	}
+	patchPaths := leafPaths(patchMap, "")
+	//值为 null 的字段表示删除，删除之后就不再归任何人所有
+	var applied []string
+	for _, path := range patchPaths {
+		if value, _ := getPath(patchMap, path); value != nil {
+			applied = append(applied, path)
+		}
+	}
+
+	merged, err := toMap(liveMap)
+	if err != nil {
+		return err
+	}
+	merge(merged, patchMap)
+
+	//别的 manager 拥有的字段如果会被这次 apply 改成不同的值，就是冲突；值相同则变成共同拥有
+	var conflicts []Conflict
+	lost := map[string]map[string]bool{}
+	for _, entry := range managed {
+		if entry.Manager == manager {
+			continue
+		}
+		for _, field := range entry.Fields {
+			if overlapsAny(field, patchPaths) && !sameValue(liveMap, merged, field) {
+				conflicts = append(conflicts, Conflict{Manager: entry.Manager, Field: field})
+				if lost[entry.Manager] == nil {
+					lost[entry.Manager] = map[string]bool{}
+				}
+				lost[entry.Manager][field] = true
+			}
+		}
+	}
+	if len(conflicts) > 0 && !force {
+		return &ConflictError{Conflicts: conflicts}
+	}
+
+	//上一次 apply 设置过、这次没有再出现的字段，如果没有别人拥有，就从对象里删掉
+	for _, entry := range managed {
+		if entry.Manager != manager || entry.Operation != api.ManagedFieldsOperationApply {
+			continue
+		}
+		for _, field := range entry.Fields {
+			if overlapsAny(field, patchPaths) || ownedByOthers(managed, manager, field) {
+				continue
+			}
+			deletePath(merged, field)
+		}
+	}
+
+	now := time.Now()
+	var result []api.ManagedFieldsEntry
+	for _, entry := range managed {
+		if entry.Manager == manager && entry.Operation == api.ManagedFieldsOperationApply {
+			continue
+		}
+		var fields []string
+		for _, field := range entry.Fields {
+			if lost[entry.Manager][field] {
+				continue
+			}
+			if entry.Manager == manager && overlapsAny(field, applied) {
+				continue
+			}
+			fields = append(fields, field)
+		}
+		if len(fields) > 0 {
+			entry.Fields = fields
+			result = append(result, entry)
+		}
+	}
+	if len(applied) > 0 {
+		result = append(result, api.ManagedFieldsEntry{
+			Manager:   manager,
+			Operation: api.ManagedFieldsOperationApply,
+			Time:      &now,
+			Fields:    applied,
+		})
+	}
+	return decodeInto(merged, live, out, result)
+}
+
+// Update records the fields that differ between old and updated as owned by
+// manager through an Update operation, and stores the resulting managedFields
+// on updated. old is nil when the object is being created.
+func Update(old, updated api.Object, manager string) error {
+	if manager == "" {
+		return fmt.Errorf("fieldManager is required for update")
+	}
+	oldMap := map[string]interface{}{}
+	var managed []api.ManagedFieldsEntry
+	if old != nil {
+		m, err := toMap(old)
+		if err != nil {
+			return err
+		}
+		oldMap = m
+		managed = old.GetObjectMeta().ManagedFields
+	}
+	newMap, err := toMap(updated)
+	if err != nil {
+		return err
+	}
+	changed := changedPaths(oldMap, newMap)
+	var owned []string
+	for _, path := range changed {
+		if _, ok := getPath(newMap, path); ok {
+			owned = append(owned, path)
+		}
+	}
+
+	now := time.Now()
+	found := false
+	var result []api.ManagedFieldsEntry
+	for _, entry := range managed {
+		isOwn := entry.Manager == manager && entry.Operation == api.ManagedFieldsOperationUpdate
+		var fields []string
+		for _, field := range entry.Fields {
+			if _, ok := getPath(newMap, field); !ok {
+				continue
+			}
+			if !isOwn && entry.Manager != manager && overlapsAny(field, changed) {
+				continue
+			}
+			fields = append(fields, field)
+		}
+		if isOwn {
+			found = true
+			fields = union(fields, owned)
+			if len(owned) > 0 {
+				entry.Time = &now
+			}
+		}
+		if len(fields) > 0 {
+			entry.Fields = fields
+			result = append(result, entry)
+		}
+	}
+	if !found && len(owned) > 0 {
+		result = append(result, api.ManagedFieldsEntry{
+			Manager:   manager,
+			Operation: api.ManagedFieldsOperationUpdate,
+			Time:      &now,
+			Fields:    owned,
+		})
+	}
+	updated.GetObjectMeta().ManagedFields = result
+	return nil
+}
+
+func ownedByOthers(managed []api.ManagedFieldsEntry, manager, field string) bool {
+	for _, entry := range managed {
+		if entry.Manager != manager && overlapsAny(field, entry.Fields) {
+			return true
+		}
+	}
+	return false
+}
+
+func union(a, b []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, path := range append(a, b...) {
+		if !seen[path] {
+			seen[path] = true
+			out = append(out, path)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// decodeInto 把合并后的 map 写回类型化的对象，并恢复服务端维护的元数据
+func decodeInto(merged map[string]interface{}, live, out api.Object, managed []api.ManagedFieldsEntry) error {
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return fmt.Errorf("marshalling merged object: %w", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decoding merged object: %w", err)
+	}
+	meta := out.GetObjectMeta()
+	if live != nil {
+		//服务端维护的元数据保持存储里的值，labels、annotations、ownerReferences 和 finalizers 使用 apply 的结果
+		liveMeta := live.GetObjectMeta()
+		meta.Name = liveMeta.Name
+		meta.GenerateName = liveMeta.GenerateName
+		meta.Namespace = liveMeta.Namespace
+		meta.UID = liveMeta.UID
+		meta.ResourceVersion = liveMeta.ResourceVersion
+		meta.CreationTimestamp = liveMeta.CreationTimestamp
+		meta.Generation = liveMeta.Generation
+		meta.DeletionTimestamp = liveMeta.DeletionTimestamp
+	}
+	meta.ManagedFields = managed
+	return nil
+}
@@ -0,0 +1,164 @@
+package apply
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ignoredFields 是由服务端维护的元数据，不参与字段归属
+var ignoredFields = map[string]bool{
+	"name":              true,
+	"generateName":      true,
+	"namespace":         true,
+	"uid":               true,
+	"resourceVersion":   true,
+	"creationTimestamp": true,
+	"generation":        true,
+	"deletionTimestamp": true,
+	"managedFields":     true,
+}
+
+// toMap 把一个资源对象转换成通用的 JSON map，并去掉不参与字段归属的元数据
+func toMap(obj interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling object: %w", err)
+	}
+	return decodeMap(data)
+}
+
+func decodeMap(data []byte) (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("decoding object: %w", err)
+	}
+	for field := range ignoredFields {
+		delete(m, field)
+	}
+	return m, nil
+}
+
+func escapeToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+func splitPath(path string) []string {
+	tokens := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens
+}
+
+// leafPaths 返回 m 中所有叶子字段的路径。数组和标量都看作叶子，空 map 也是叶子
+func leafPaths(m map[string]interface{}, prefix string) []string {
+	var paths []string
+	for key, value := range m {
+		path := prefix + "/" + escapeToken(key)
+		if child, ok := value.(map[string]interface{}); ok && len(child) > 0 {
+			paths = append(paths, leafPaths(child, path)...)
+			continue
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func getPath(m map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = m
+	for _, token := range splitPath(path) {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = obj[token]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// deletePath 删除 path 指向的字段，并把因此变空的父级 map 一并删掉
+func deletePath(m map[string]interface{}, path string) {
+	tokens := splitPath(path)
+	parents := []map[string]interface{}{m}
+	current := m
+	for _, token := range tokens[:len(tokens)-1] {
+		child, ok := current[token].(map[string]interface{})
+		if !ok {
+			return
+		}
+		parents = append(parents, child)
+		current = child
+	}
+	delete(current, tokens[len(tokens)-1])
+	for i := len(parents) - 1; i > 0; i-- {
+		if len(parents[i]) > 0 {
+			return
+		}
+		delete(parents[i-1], tokens[i-1])
+	}
+}
+
+// merge 把 patch 递归合并进 dst；patch 里显式的 null 表示删除该字段
+func merge(dst, patch map[string]interface{}) {
+	for key, value := range patch {
+		if value == nil {
+			delete(dst, key)
+			continue
+		}
+		if patchChild, ok := value.(map[string]interface{}); ok {
+			if dstChild, ok := dst[key].(map[string]interface{}); ok {
+				merge(dstChild, patchChild)
+				continue
+			}
+			copied := map[string]interface{}{}
+			merge(copied, patchChild)
+			dst[key] = copied
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// overlaps 判断两个字段路径是否指向同一个字段，或者一个是另一个的父字段
+func overlaps(a, b string) bool {
+	return a == b || strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
+
+func overlapsAny(path string, paths []string) bool {
+	for _, p := range paths {
+		if overlaps(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameValue(a, b map[string]interface{}, path string) bool {
+	va, okA := getPath(a, path)
+	vb, okB := getPath(b, path)
+	return okA == okB && reflect.DeepEqual(va, vb)
+}
+
+// changedPaths 返回 old 和 updated 之间新增、修改或删除的叶子字段
+func changedPaths(old, updated map[string]interface{}) []string {
+	seen := map[string]bool{}
+	var changed []string
+	for _, path := range append(leafPaths(old, ""), leafPaths(updated, "")...) {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		if !sameValue(old, updated, path) {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
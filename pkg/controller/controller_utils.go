@@ -0,0 +1,73 @@
+// Package controller holds helpers shared by the controllers that create and
+// manage pods on behalf of a parent object.
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"mini-k8s/pkg/api"
+	"sort"
+)
+
+// NewControllerRef 返回指向 owner 的 controller owner reference
+func NewControllerRef(kind string, owner *api.ObjectMeta) api.OwnerReference {
+	return api.OwnerReference{Kind: kind, Name: owner.Name, UID: owner.UID, Controller: true, BlockOwnerDeletion: true}
+}
+
+// IsControlledBy 判断对象的 controller owner 是不是 UID 为 ownerUID 的对象
+func IsControlledBy(meta *api.ObjectMeta, ownerUID string) bool {
+	ref := meta.ControllerRef()
+	return ref != nil && ref.UID == ownerUID
+}
+
+// PodFromTemplate 按模板生成一个 pod，名字由 API server 根据 generateName 生成
+func PodFromTemplate(template *api.PodTemplateSpec, namespace, generateName string, ownerRef api.OwnerReference) *api.Pod {
+	pod := &api.Pod{}
+	template.Spec.DeepCopyInto(&pod.PodSpec)
+	pod.Namespace = namespace
+	pod.GenerateName = generateName
+	pod.Labels = map[string]string{}
+	for k, v := range template.Metadata.Labels {
+		pod.Labels[k] = v
+	}
+	pod.OwnerReferences = []api.OwnerReference{ownerRef}
+	return pod
+}
+
+func podPhaseRank(pod *api.Pod) int {
+	switch {
+	case pod.NodeName == "":
+		return 0
+	case pod.Phase == api.PodPending || pod.Phase == api.PodScheduled:
+		return 1
+	case pod.Phase == api.PodRunning:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// SortPodsForDeletion 按删除优先级排序：没调度的在前，然后是还没跑起来的，同一类里新创建的在前，
+// 这样缩容时尽量保留已经稳定运行的 pod
+func SortPodsForDeletion(pods []api.Pod) {
+	sort.SliceStable(pods, func(i, j int) bool {
+		ri, rj := podPhaseRank(&pods[i]), podPhaseRank(&pods[j])
+		if ri != rj {
+			return ri < rj
+		}
+		ti, tj := pods[i].CreationTimestamp, pods[j].CreationTimestamp
+		if ti == nil || tj == nil {
+			return pods[i].Name > pods[j].Name
+		}
+		return ti.After(*tj)
+	})
+}
+
+// ComputeHash 返回 pod 模板的哈希，模板不变时结果不变，用来给每个版本的 ReplicaSet 命名
+func ComputeHash(template *api.PodTemplateSpec) string {
+	hasher := fnv.New32a()
+	data, _ := json.Marshal(template)
+	hasher.Write(data)
+	return fmt.Sprintf("%08x", hasher.Sum32())
+}
@@ -0,0 +1,256 @@
+// Package cronjob contains the controller that creates Jobs from CronJobs on
+// their cron schedule.
+package cronjob
+
+import (
+	"fmt"
+	"log"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/controller"
+	"mini-k8s/pkg/cron"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const controllerKind = "CronJob"
+
+// maxMissedSchedules 是最多逐个数多少次错过的调度时间，超过之后不再往后数并打印警告。
+// 无论错过多少次都只为最近的一次创建 Job
+const maxMissedSchedules = 100
+
+type CronJobController struct {
+	client  *api.Client
+	listers controller.Listers
+	// workers 是同时同步的对象数量
+	workers int
+}
+
+func NewCronJobController(client *api.Client, listers controller.Listers, workers int) *CronJobController {
+	return &CronJobController{client: client, listers: listers, workers: workers}
+}
+
+// Sync 对每个 CronJob 更新正在运行的 Job 列表，按调度时间和并发策略创建新的 Job，并清理超出历史数量的旧 Job
+func (cc *CronJobController) Sync() {
+	cronJobs, err := cc.listers.ListCronJobs()
+	if err != nil {
+		log.Printf("Error listing cronjobs: %v", err)
+		return
+	}
+	if len(cronJobs) == 0 {
+		return
+	}
+	jobs, err := cc.listers.ListJobs()
+	if err != nil {
+		log.Printf("Error listing jobs: %v", err)
+		return
+	}
+	controller.Parallelize(cc.workers, len(cronJobs), func(i int) {
+		if err := cc.syncCronJob(&cronJobs[i], jobs); err != nil {
+			log.Printf("Error syncing cronjob %s/%s: %v", cronJobs[i].Namespace, cronJobs[i].Name, err)
+		}
+	})
+}
+
+func (cc *CronJobController) syncCronJob(cj *api.CronJob, allJobs []api.Job) error {
+	if cj.DeletionTimestamp != nil {
+		return nil
+	}
+	var owned []api.Job
+	for _, job := range allJobs {
+		if job.Namespace == cj.Namespace && controller.IsControlledBy(&job.ObjectMeta, cj.UID) {
+			owned = append(owned, job)
+		}
+	}
+
+	status := api.CronJobStatus{
+		LastScheduleTime:   cj.Status.LastScheduleTime,
+		LastSuccessfulTime: cj.Status.LastSuccessfulTime,
+	}
+	var active []api.Job
+	for _, job := range owned {
+		finished, condType := api.IsJobFinished(&job)
+		if !finished {
+			active = append(active, job)
+			continue
+		}
+		if finishTime := api.JobFinishTime(&job); condType == api.JobComplete && finishTime != nil &&
+			(status.LastSuccessfulTime == nil || finishTime.After(*status.LastSuccessfulTime)) {
+			status.LastSuccessfulTime = finishTime
+		}
+	}
+
+	if err := cc.cleanupFinishedJobs(cj, owned); err != nil {
+		return err
+	}
+
+	if !cj.Spec.Suspend {
+		var err error
+		if active, err = cc.scheduleJob(cj, &status, active); err != nil {
+			return err
+		}
+	}
+	for _, job := range active {
+		if job.DeletionTimestamp == nil {
+			status.Active = append(status.Active, api.ObjectReference{Kind: "Job", Namespace: job.Namespace, Name: job.Name, UID: job.UID})
+		}
+	}
+	if reflect.DeepEqual(status, cj.Status) {
+		return nil
+	}
+	cj.Status = status
+	_, err := cc.client.UpdateCronJobStatus(cj)
+	return err
+}
+
+// scheduleJob 找到最近一次错过的调度时间，按并发策略决定是否为它创建 Job，返回调度之后仍在运行的 Job
+func (cc *CronJobController) scheduleJob(cj *api.CronJob, status *api.CronJobStatus, active []api.Job) ([]api.Job, error) {
+	schedule, err := cron.Parse(cj.Spec.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", cj.Spec.Schedule, err)
+	}
+	loc := time.Local
+	if cj.Spec.TimeZone != nil {
+		if loc, err = time.LoadLocation(*cj.Spec.TimeZone); err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %w", *cj.Spec.TimeZone, err)
+		}
+	}
+	now := time.Now().In(loc)
+	scheduledTime, missed := mostRecentScheduleTime(cj, schedule, now)
+	if scheduledTime == nil {
+		return active, nil
+	}
+	if missed > maxMissedSchedules {
+		log.Printf("Cronjob %s/%s missed more than %d start times, check clock skew or set startingDeadlineSeconds", cj.Namespace, cj.Name, maxMissedSchedules)
+	}
+
+	switch cj.Spec.ConcurrencyPolicy {
+	case api.ForbidConcurrent:
+		if len(active) > 0 {
+			//不更新 lastScheduleTime，等上一个 Job 结束后在 startingDeadlineSeconds 之内仍然可以补建
+			log.Printf("Cronjob %s/%s skipped the run at %s because a previous job is still running", cj.Namespace, cj.Name, scheduledTime.Format(time.RFC3339))
+			return active, nil
+		}
+	case api.ReplaceConcurrent:
+		for _, job := range active {
+			if err := cc.client.DeleteJob(job.Namespace, job.Name); err != nil {
+				return nil, err
+			}
+			log.Printf("Deleted job %s/%s of cronjob %s to replace it", job.Namespace, job.Name, cj.Name)
+		}
+		active = nil
+	}
+
+	job := jobFromTemplate(cj, *scheduledTime)
+	created, err := cc.client.CreateJob(cj.Namespace, job)
+	if err != nil && !strings.Contains(err.Error(), "already exists") {
+		return nil, err
+	}
+	if err == nil {
+		log.Printf("Created job %s/%s for cronjob %s scheduled at %s", created.Namespace, created.Name, cj.Name, scheduledTime.Format(time.RFC3339))
+		active = append(active, *created)
+	}
+	scheduled := scheduledTime.UTC()
+	status.LastScheduleTime = &scheduled
+	return active, nil
+}
+
+// mostRecentScheduleTime 返回上一次调度之后、now 之前最近的一个调度时间，以及这段时间里一共错过了几次。
+// 设置了 startingDeadlineSeconds 时只考虑这个期限之内的调度时间。
+// 错过超过 maxMissedSchedules 次时不再数下去，返回的次数是 maxMissedSchedules+1，最近的时间由 latestScheduleTime 找
+func mostRecentScheduleTime(cj *api.CronJob, schedule *cron.Schedule, now time.Time) (*time.Time, int) {
+	var earliest time.Time
+	switch {
+	case cj.Status.LastScheduleTime != nil:
+		earliest = *cj.Status.LastScheduleTime
+	case cj.CreationTimestamp != nil:
+		earliest = *cj.CreationTimestamp
+	default:
+		earliest = now
+	}
+	if cj.Spec.StartingDeadlineSeconds != nil {
+		deadline := now.Add(-time.Duration(*cj.Spec.StartingDeadlineSeconds) * time.Second)
+		if deadline.After(earliest) {
+			earliest = deadline
+		}
+	}
+	var latest *time.Time
+	missed := 0
+	for t := schedule.Next(earliest.In(now.Location())); !t.IsZero() && !t.After(now); t = schedule.Next(t) {
+		scheduled := t
+		latest = &scheduled
+		missed++
+		if missed > maxMissedSchedules {
+			scheduled = latestScheduleTime(schedule, t, now)
+			return &scheduled, missed
+		}
+	}
+	return latest, missed
+}
+
+// latestScheduleTime 返回 from 到 now 之间最后一个调度时间，from 本身是一个调度时间。
+// 从 now 往前按 1 分钟、2 分钟、4 分钟……扩大窗口，第一个包含调度时间的窗口里最后一个就是结果，
+// 这样控制器停了很久之后也不用把中间每一次错过的时间都算一遍
+func latestScheduleTime(schedule *cron.Schedule, from, now time.Time) time.Time {
+	start := from
+	for d := time.Minute; now.Add(-d).After(from); d *= 2 {
+		if t := schedule.Next(now.Add(-d)); !t.IsZero() && !t.After(now) {
+			start = t
+			break
+		}
+	}
+	latest := start
+	for t := schedule.Next(start); !t.IsZero() && !t.After(now); t = schedule.Next(t) {
+		latest = t
+	}
+	return latest
+}
+
+// jobFromTemplate 按模板生成 Job，名字里带上调度时间（分钟数），同一个调度时间不会重复创建
+func jobFromTemplate(cj *api.CronJob, scheduledTime time.Time) *api.Job {
+	job := &api.Job{}
+	cj.Spec.JobTemplate.Metadata.DeepCopyInto(&job.ObjectMeta)
+	cj.Spec.JobTemplate.Spec.DeepCopyInto(&job.Spec)
+	job.Name = fmt.Sprintf("%s-%d", cj.Name, scheduledTime.Unix()/60)
+	job.GenerateName = ""
+	job.Namespace = cj.Namespace
+	job.OwnerReferences = []api.OwnerReference{controller.NewControllerRef(controllerKind, &cj.ObjectMeta)}
+	return job
+}
+
+// cleanupFinishedJobs 按结束时间保留最近的 successfulJobsHistoryLimit 个成功和 failedJobsHistoryLimit 个失败的 Job
+func (cc *CronJobController) cleanupFinishedJobs(cj *api.CronJob, owned []api.Job) error {
+	var succeeded, failed []api.Job
+	for _, job := range owned {
+		if job.DeletionTimestamp != nil {
+			continue
+		}
+		switch finished, condType := api.IsJobFinished(&job); {
+		case finished && condType == api.JobComplete:
+			succeeded = append(succeeded, job)
+		case finished:
+			failed = append(failed, job)
+		}
+	}
+	if err := cc.deleteOldestJobs(cj, succeeded, *cj.Spec.SuccessfulJobsHistoryLimit); err != nil {
+		return err
+	}
+	return cc.deleteOldestJobs(cj, failed, *cj.Spec.FailedJobsHistoryLimit)
+}
+
+func (cc *CronJobController) deleteOldestJobs(cj *api.CronJob, jobs []api.Job, limit int) error {
+	if len(jobs) <= limit {
+		return nil
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return api.JobFinishTime(&jobs[i]).Before(*api.JobFinishTime(&jobs[j]))
+	})
+	for _, job := range jobs[:len(jobs)-limit] {
+		if err := cc.client.DeleteJob(job.Namespace, job.Name); err != nil {
+			return err
+		}
+		log.Printf("Deleted job %s/%s from the history of cronjob %s", job.Namespace, job.Name, cj.Name)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package cronjob
+
+import (
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/cron"
+	"testing"
+	"time"
+)
+
+func TestMostRecentScheduleTime(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 30, 20, 0, time.UTC)
+	deadline := int64(600)
+	tests := []struct {
+		name         string
+		spec         string
+		lastSchedule time.Time
+		deadline     *int64
+		wantTime     time.Time
+		wantMissed   int
+	}{
+		{
+			name:         "one missed run",
+			spec:         "*/10 * * * *",
+			lastSchedule: time.Date(2026, 10, 18, 12, 20, 0, 0, time.UTC),
+			wantTime:     time.Date(2026, 10, 18, 12, 30, 0, 0, time.UTC),
+			wantMissed:   1,
+		},
+		{
+			name:         "nothing due yet",
+			spec:         "0 * * * *",
+			lastSchedule: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+			wantMissed:   0,
+		},
+		{
+			name:         "every minute, stopped for a year",
+			spec:         "* * * * *",
+			lastSchedule: now.AddDate(-1, 0, 0),
+			wantTime:     time.Date(2026, 10, 18, 12, 30, 0, 0, time.UTC),
+			wantMissed:   maxMissedSchedules + 1,
+		},
+		{
+			name:         "sparse schedule, stopped for years",
+			spec:         "15 3 1 * *",
+			lastSchedule: now.AddDate(-20, 0, 0),
+			wantTime:     time.Date(2026, 10, 1, 3, 15, 0, 0, time.UTC),
+			wantMissed:   maxMissedSchedules + 1,
+		},
+		{
+			name:         "startingDeadlineSeconds limits the missed runs",
+			spec:         "* * * * *",
+			lastSchedule: now.AddDate(-1, 0, 0),
+			deadline:     &deadline,
+			wantTime:     time.Date(2026, 10, 18, 12, 30, 0, 0, time.UTC),
+			wantMissed:   10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := cron.Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.spec, err)
+			}
+			last := tt.lastSchedule
+			cj := &api.CronJob{
+				Spec:   api.CronJobSpec{Schedule: tt.spec, StartingDeadlineSeconds: tt.deadline},
+				Status: api.CronJobStatus{LastScheduleTime: &last},
+			}
+			got, missed := mostRecentScheduleTime(cj, schedule, now)
+			if missed != tt.wantMissed {
+				t.Errorf("missed = %d, want %d", missed, tt.wantMissed)
+			}
+			if tt.wantTime.IsZero() {
+				if got != nil {
+					t.Errorf("most recent time = %s, want none", got)
+				}
+				return
+			}
+			if got == nil || !got.Equal(tt.wantTime) {
+				t.Errorf("most recent time = %v, want %s", got, tt.wantTime)
+			}
+		})
+	}
+}
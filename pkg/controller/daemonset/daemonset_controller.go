@@ -0,0 +1,289 @@
+// Package daemonset contains the controller that runs one pod of each
+// DaemonSet on every Ready node matching its node selector and taints.
+package daemonset
+
+import (
+	"log"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/controller"
+	"sort"
+	"strings"
+	"time"
+)
+
+const controllerKind = "DaemonSet"
+
+type DaemonSetController struct {
+	client  *api.Client
+	listers controller.Listers
+	// workers 是同时同步的对象数量
+	workers int
+}
+
+func NewDaemonSetController(client *api.Client, listers controller.Listers, workers int) *DaemonSetController {
+	return &DaemonSetController{client: client, listers: listers, workers: workers}
+}
+
+// Sync 对每个 DaemonSet 计算应该运行 pod 的节点，在缺少 pod 的节点上直接创建绑定到该节点的 pod，
+// 删除不该运行的 pod，并按更新策略替换旧模板的 pod
+func (dsc *DaemonSetController) Sync() {
+	daemonSets, err := dsc.listers.ListDaemonSets()
+	if err != nil {
+		log.Printf("Error listing daemonsets: %v", err)
+		return
+	}
+	if len(daemonSets) == 0 {
+		return
+	}
+	nodes, err := dsc.listers.ListNodes()
+	if err != nil {
+		log.Printf("Error listing nodes: %v", err)
+		return
+	}
+	pods, err := dsc.listers.ListPods()
+	if err != nil {
+		log.Printf("Error listing pods: %v", err)
+		return
+	}
+	controller.Parallelize(dsc.workers, len(daemonSets), func(i int) {
+		if err := dsc.syncDaemonSet(&daemonSets[i], nodes, pods); err != nil {
+			log.Printf("Error syncing daemonset %s/%s: %v", daemonSets[i].Namespace, daemonSets[i].Name, err)
+		}
+	})
+}
+
+// nodePlacement 描述一个节点和 DaemonSet 的关系
+type nodePlacement struct {
+	node *api.Node
+	// shouldRun 表示节点满足 nodeSelector 并且 pod 容忍节点的污点
+	shouldRun bool
+	pods      []api.Pod
+}
+
+func (dsc *DaemonSetController) syncDaemonSet(ds *api.DaemonSet, nodes []api.Node, allPods []api.Pod) error {
+	if ds.DeletionTimestamp != nil {
+		return nil
+	}
+	hash := controller.ComputeHash(&ds.Spec.Template)
+	//判断节点时用 pod 实际会有的容忍，否则节点被加上不可用的污点时 DaemonSet 会删掉自己的 pod
+	spec := ds.Spec.Template.Spec
+	spec.Tolerations = daemonPodTolerations(ds)
+	placements := map[string]*nodePlacement{}
+	for i := range nodes {
+		placements[nodes[i].Name] = &nodePlacement{node: &nodes[i], shouldRun: api.PodFitsNode(&spec, &nodes[i])}
+	}
+	for _, pod := range allPods {
+		if pod.Namespace != ds.Namespace || !controller.IsControlledBy(&pod.ObjectMeta, ds.UID) || pod.DeletionTimestamp != nil {
+			continue
+		}
+		p, ok := placements[pod.NodeName]
+		if !ok {
+			//节点已经不存在了
+			if err := dsc.deletePod(ds, &pod, "its node no longer exists"); err != nil {
+				return err
+			}
+			continue
+		}
+		//结束了的 pod 删掉，下一轮重新创建
+		if pod.Phase == api.PodFailed || pod.Phase == api.PodSucceeded {
+			if err := dsc.deletePod(ds, &pod, "it has terminated"); err != nil {
+				return err
+			}
+			continue
+		}
+		p.pods = append(p.pods, pod)
+	}
+
+	names := make([]string, 0, len(placements))
+	for name := range placements {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := placements[name]
+		switch {
+		case !p.shouldRun:
+			//节点不再适合运行 pod 是节点引起的自愿中断，通过 eviction 遵守 PDB；被拒绝的 pod 留到下一轮再试
+			var kept []api.Pod
+			for i := range p.pods {
+				evicted, err := dsc.evictPod(ds, &p.pods[i], "the node no longer matches its selector or taints")
+				if err != nil {
+					return err
+				}
+				if !evicted {
+					kept = append(kept, p.pods[i])
+				}
+			}
+			p.pods = kept
+		case len(p.pods) == 0 && p.node.Status == api.NodeReady:
+			created, err := dsc.createPod(ds, p.node, hash, spec.Tolerations)
+			if err != nil {
+				return err
+			}
+			p.pods = append(p.pods, *created)
+		case len(p.pods) > 1:
+			//同一个节点上只保留最早创建的 pod
+			sort.Slice(p.pods, func(i, j int) bool { return olderThan(&p.pods[i], &p.pods[j]) })
+			for i := range p.pods[1:] {
+				if err := dsc.deletePod(ds, &p.pods[1+i], "the node already runs another pod of the daemonset"); err != nil {
+					return err
+				}
+			}
+			p.pods = p.pods[:1]
+		}
+	}
+
+	if ds.Spec.UpdateStrategy.Type == api.RollingUpdateDaemonSetStrategyType {
+		if err := dsc.rollingUpdate(ds, placements, names, hash); err != nil {
+			return err
+		}
+	}
+	return dsc.updateStatus(ds, placements, hash)
+}
+
+// rollingUpdate 删除旧模板的 pod，让下一轮同步用新模板重建。不可用的旧 pod 直接删除，
+// 可用的旧 pod 只在不可用节点数小于 maxUnavailable 时才删除，所以默认一次只替换一个节点
+func (dsc *DaemonSetController) rollingUpdate(ds *api.DaemonSet, placements map[string]*nodePlacement, names []string, hash string) error {
+	desired := 0
+	for _, p := range placements {
+		if p.shouldRun {
+			desired++
+		}
+	}
+	maxUnavailable, err := ds.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable.ScaledValue(desired, true)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	unavailable := 0
+	var oldAvailable []*api.Pod
+	for _, name := range names {
+		p := placements[name]
+		if !p.shouldRun {
+			continue
+		}
+		if len(p.pods) == 0 {
+			unavailable++
+			continue
+		}
+		pod := &p.pods[0]
+		available := api.IsPodAvailable(pod, ds.Spec.MinReadySeconds, now)
+		if !available {
+			unavailable++
+		}
+		if pod.Labels[api.ControllerRevisionHashLabel] == hash {
+			continue
+		}
+		if !available {
+			if err := dsc.deletePod(ds, pod, "it runs an old template and is unavailable"); err != nil {
+				return err
+			}
+			continue
+		}
+		oldAvailable = append(oldAvailable, pod)
+	}
+	for _, pod := range oldAvailable {
+		if unavailable >= maxUnavailable {
+			break
+		}
+		if err := dsc.deletePod(ds, pod, "it runs an old template"); err != nil {
+			return err
+		}
+		unavailable++
+	}
+	return nil
+}
+
+// daemonPodTolerations 返回 DaemonSet 的 pod 的容忍：模板里的加上节点暂时不可用和被 cordon 时的污点。
+// 节点暂时不可用时 pod 不应该被驱逐，被 cordon 的节点上的 pod 由 drain 跳过，也不应该被 DaemonSet 自己删掉
+func daemonPodTolerations(ds *api.DaemonSet) []api.Toleration {
+	return append(append([]api.Toleration(nil), ds.Spec.Template.Spec.Tolerations...),
+		api.Toleration{Key: api.TaintNodeNotReady, Operator: api.TolerationOpExists, Effect: api.TaintEffectNoExecute},
+		api.Toleration{Key: api.TaintNodeUnreachable, Operator: api.TolerationOpExists, Effect: api.TaintEffectNoExecute},
+		api.Toleration{Key: api.TaintNodeUnschedulable, Operator: api.TolerationOpExists, Effect: api.TaintEffectNoSchedule},
+	)
+}
+
+// createPod 在节点上创建 pod，tolerations 是 daemonPodTolerations 的结果，和判断节点时用的一致
+func (dsc *DaemonSetController) createPod(ds *api.DaemonSet, node *api.Node, hash string, tolerations []api.Toleration) (*api.Pod, error) {
+	ref := controller.NewControllerRef(controllerKind, &ds.ObjectMeta)
+	pod := controller.PodFromTemplate(&ds.Spec.Template, ds.Namespace, ds.Name+"-", ref)
+	pod.Labels[api.ControllerRevisionHashLabel] = hash
+	pod.NodeName = node.Name
+	pod.Tolerations = append([]api.Toleration(nil), tolerations...)
+	created, err := dsc.client.CreatePod(ds.Namespace, pod)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Created pod %s/%s on node %s for daemonset %s", created.Namespace, created.Name, node.Name, ds.Name)
+	return created, nil
+}
+
+// evictPod 通过 eviction API 删除 pod。驱逐会违反 PodDisruptionBudget 时返回 false，不算错误
+func (dsc *DaemonSetController) evictPod(ds *api.DaemonSet, pod *api.Pod, reason string) (bool, error) {
+	if err := dsc.client.EvictPod(pod.Namespace, pod.Name); err != nil {
+		if strings.Contains(err.Error(), "disruption budget") {
+			log.Printf("Cannot evict pod %s/%s on node %s of daemonset %s yet: %v", pod.Namespace, pod.Name, pod.NodeName, ds.Name, err)
+			return false, nil
+		}
+		if strings.Contains(err.Error(), "not found") {
+			return true, nil
+		}
+		return false, err
+	}
+	log.Printf("Evicted pod %s/%s on node %s of daemonset %s because %s", pod.Namespace, pod.Name, pod.NodeName, ds.Name, reason)
+	return true, nil
+}
+
+func (dsc *DaemonSetController) deletePod(ds *api.DaemonSet, pod *api.Pod, reason string) error {
+	if err := dsc.client.DeletePod(pod.Namespace, pod.Name); err != nil {
+		return err
+	}
+	log.Printf("Deleted pod %s/%s on node %s of daemonset %s because %s", pod.Namespace, pod.Name, pod.NodeName, ds.Name, reason)
+	return nil
+}
+
+func olderThan(a, b *api.Pod) bool {
+	if a.CreationTimestamp == nil || b.CreationTimestamp == nil {
+		return a.Name < b.Name
+	}
+	return a.CreationTimestamp.Before(*b.CreationTimestamp)
+}
+
+func (dsc *DaemonSetController) updateStatus(ds *api.DaemonSet, placements map[string]*nodePlacement, hash string) error {
+	now := time.Now()
+	status := api.DaemonSetStatus{ObservedGeneration: ds.Generation}
+	for _, p := range placements {
+		if !p.shouldRun {
+			if len(p.pods) > 0 {
+				status.NumberMisscheduled++
+			}
+			continue
+		}
+		status.DesiredNumberScheduled++
+		if len(p.pods) == 0 {
+			status.NumberUnavailable++
+			continue
+		}
+		//被 rollingUpdate 删除的 pod 仍然算在这一轮的统计里，下一轮同步时会更新
+		pod := &p.pods[0]
+		status.CurrentNumberScheduled++
+		if pod.Phase == api.PodRunning {
+			status.NumberReady++
+		}
+		if pod.Labels[api.ControllerRevisionHashLabel] == hash {
+			status.UpdatedNumberScheduled++
+		}
+		if api.IsPodAvailable(pod, ds.Spec.MinReadySeconds, now) {
+			status.NumberAvailable++
+		} else {
+			status.NumberUnavailable++
+		}
+	}
+	if status == ds.Status {
+		return nil
+	}
+	ds.Status = status
+	_, err := dsc.client.UpdateDaemonSetStatus(ds)
+	return err
+}
@@ -0,0 +1,150 @@
+package daemonset
+
+import (
+	"encoding/json"
+	"io"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/controller"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeAPIServer 记录 controller 发出的删除、驱逐、创建请求和写入的 status，创建和更新时把请求体原样返回。
+// blockEvictions 为 true 时像 PDB 不允许中断那样用 429 拒绝驱逐
+type fakeAPIServer struct {
+	mu             sync.Mutex
+	deleted        []string
+	evicted        []string
+	created        []api.Pod
+	status         api.DaemonSetStatus
+	blockEvictions bool
+}
+
+func (f *fakeAPIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	body, _ := io.ReadAll(r.Body)
+	switch {
+	case r.Method == http.MethodDelete:
+		f.deleted = append(f.deleted, r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("{}"))
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/eviction"):
+		if f.blockEvictions {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":"Cannot evict pod as it would violate the pod's disruption budget."}`))
+			return
+		}
+		name := strings.TrimSuffix(r.URL.Path, "/eviction")
+		f.evicted = append(f.evicted, name[strings.LastIndex(name, "/")+1:])
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("{}"))
+	case r.Method == http.MethodPost:
+		var pod api.Pod
+		json.Unmarshal(body, &pod)
+		f.created = append(f.created, pod)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	case r.Method == http.MethodPut && strings.HasSuffix(r.URL.Path, "/status"):
+		var ds api.DaemonSet
+		json.Unmarshal(body, &ds)
+		f.status = ds.Status
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	default:
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	}
+}
+
+// taintedCluster 返回一个 DaemonSet、五个节点和前四个节点上这个 DaemonSet 的 pod：
+// 节点分别不可用、失联、被 cordon、带着模板不容忍的污点，最后一个是还没有 pod 的新节点
+func taintedCluster() (*api.DaemonSet, []api.Node, []api.Pod) {
+	ds := &api.DaemonSet{
+		ObjectMeta: api.ObjectMeta{Namespace: "kube-system", Name: "agent", UID: "ds-uid"},
+		Spec: api.DaemonSetSpec{
+			Selector:       &api.LabelSelector{MatchLabels: map[string]string{"app": "agent"}},
+			Template:       api.PodTemplateSpec{Metadata: api.ObjectMeta{Labels: map[string]string{"app": "agent"}}, Spec: api.PodSpec{Image: "sleep"}},
+			UpdateStrategy: api.DaemonSetUpdateStrategy{Type: api.OnDeleteDaemonSetStrategyType},
+		},
+	}
+	nodes := []api.Node{
+		{ObjectMeta: api.ObjectMeta{Name: "not-ready"}, Status: api.NodeNotReady, Taints: []api.Taint{{Key: api.TaintNodeNotReady, Effect: api.TaintEffectNoExecute}}},
+		{ObjectMeta: api.ObjectMeta{Name: "unreachable"}, Status: api.NodeNotReady, Taints: []api.Taint{{Key: api.TaintNodeUnreachable, Effect: api.TaintEffectNoExecute}}},
+		{ObjectMeta: api.ObjectMeta{Name: "cordoned"}, Status: api.NodeReady, Taints: []api.Taint{{Key: api.TaintNodeUnschedulable, Effect: api.TaintEffectNoSchedule}}},
+		{ObjectMeta: api.ObjectMeta{Name: "dedicated"}, Status: api.NodeReady, Taints: []api.Taint{{Key: "dedicated", Value: "db", Effect: api.TaintEffectNoSchedule}}},
+		{ObjectMeta: api.ObjectMeta{Name: "new"}, Status: api.NodeReady},
+	}
+	hash := controller.ComputeHash(&ds.Spec.Template)
+	var pods []api.Pod
+	for _, node := range nodes[:4] {
+		pods = append(pods, api.Pod{
+			ObjectMeta: api.ObjectMeta{
+				Namespace:       ds.Namespace,
+				Name:            "agent-" + node.Name,
+				Labels:          map[string]string{"app": "agent", api.ControllerRevisionHashLabel: hash},
+				OwnerReferences: []api.OwnerReference{controller.NewControllerRef(controllerKind, &ds.ObjectMeta)},
+			},
+			NodeName: node.Name,
+			Phase:    api.PodRunning,
+		})
+	}
+	return ds, nodes, pods
+}
+
+func newFakeClient(t *testing.T, fake *fakeAPIServer) *api.Client {
+	t.Helper()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	client, err := api.NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client
+}
+
+// TestTaintedNodesKeepDaemonPods 检查节点被 node lifecycle controller 加上不可用的污点或者被 cordon 之后，
+// DaemonSet 不会删除节点上自己的 pod，新建的 pod 也带着同样的容忍；模板不容忍的污点仍然会让 pod 被驱逐
+func TestTaintedNodesKeepDaemonPods(t *testing.T) {
+	fake := &fakeAPIServer{}
+	ds, nodes, pods := taintedCluster()
+
+	dsc := NewDaemonSetController(newFakeClient(t, fake), nil, 1)
+	if err := dsc.syncDaemonSet(ds, nodes, pods); err != nil {
+		t.Fatalf("syncDaemonSet: %v", err)
+	}
+	if len(fake.deleted) != 0 {
+		t.Errorf("deleted pods %v, want none", fake.deleted)
+	}
+	if len(fake.evicted) != 1 || fake.evicted[0] != "agent-dedicated" {
+		t.Errorf("evicted pods %v, want only agent-dedicated", fake.evicted)
+	}
+	if len(fake.created) != 1 || fake.created[0].NodeName != "new" {
+		t.Fatalf("created pods %v, want one pod on node new", fake.created)
+	}
+	for _, node := range nodes[:3] {
+		if !api.PodFitsNode(&fake.created[0].PodSpec, &node) {
+			t.Errorf("created pod does not tolerate the taints of node %s: %v", node.Name, fake.created[0].Tolerations)
+		}
+	}
+}
+
+// TestBlockedEvictionKeepsDaemonPod 检查 PDB 拒绝驱逐时 DaemonSet 不会改用删除，pod 留在节点上算作调度错误
+func TestBlockedEvictionKeepsDaemonPod(t *testing.T) {
+	fake := &fakeAPIServer{blockEvictions: true}
+	ds, nodes, pods := taintedCluster()
+
+	dsc := NewDaemonSetController(newFakeClient(t, fake), nil, 1)
+	if err := dsc.syncDaemonSet(ds, nodes, pods); err != nil {
+		t.Fatalf("syncDaemonSet: %v", err)
+	}
+	if len(fake.deleted) != 0 || len(fake.evicted) != 0 {
+		t.Errorf("deleted %v and evicted %v, want the pod on node dedicated kept", fake.deleted, fake.evicted)
+	}
+	if fake.status.NumberMisscheduled != 1 {
+		t.Errorf("numberMisscheduled = %d, want 1", fake.status.NumberMisscheduled)
+	}
+}
@@ -0,0 +1,357 @@
+// Package deployment contains the controller that rolls Deployments out by
+// creating one ReplicaSet per pod template revision and scaling them according
+// to the deployment strategy.
+package deployment
+
+import (
+	"log"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/controller"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+const controllerKind = "Deployment"
+
+type DeploymentController struct {
+	client  *api.Client
+	listers controller.Listers
+	// workers 是同时同步的对象数量
+	workers int
+}
+
+func NewDeploymentController(client *api.Client, listers controller.Listers, workers int) *DeploymentController {
+	return &DeploymentController{client: client, listers: listers, workers: workers}
+}
+
+// Sync 对每个 Deployment 找出属于当前模板的新 ReplicaSet 和其余旧的 ReplicaSet，按策略推进滚动更新，然后更新 status
+func (dc *DeploymentController) Sync() {
+	deployments, err := dc.listers.ListDeployments()
+	if err != nil {
+		log.Printf("Error listing deployments: %v", err)
+		return
+	}
+	if len(deployments) == 0 {
+		return
+	}
+	replicaSets, err := dc.listers.ListReplicaSets()
+	if err != nil {
+		log.Printf("Error listing replicasets: %v", err)
+		return
+	}
+	pods, err := dc.listers.ListPods()
+	if err != nil {
+		log.Printf("Error listing pods: %v", err)
+		return
+	}
+	controller.Parallelize(dc.workers, len(deployments), func(i int) {
+		if err := dc.syncDeployment(&deployments[i], replicaSets, pods); err != nil {
+			log.Printf("Error syncing deployment %s/%s: %v", deployments[i].Namespace, deployments[i].Name, err)
+		}
+	})
+}
+
+func (dc *DeploymentController) syncDeployment(d *api.Deployment, allReplicaSets []api.ReplicaSet, allPods []api.Pod) error {
+	if d.DeletionTimestamp != nil {
+		return nil
+	}
+	var owned []*api.ReplicaSet
+	for i := range allReplicaSets {
+		rs := &allReplicaSets[i]
+		if rs.Namespace == d.Namespace && controller.IsControlledBy(&rs.ObjectMeta, d.UID) {
+			owned = append(owned, rs)
+		}
+	}
+	newRS, oldRSs := findNewReplicaSet(d, owned)
+
+	//暂停的 Deployment 不推进滚动更新，只在没有旧版本时同步副本数
+	if d.Spec.Paused {
+		if newRS != nil && totalReplicas(oldRSs) == 0 && newRS.Spec.Replicas != d.Spec.Replicas {
+			if err := dc.scaleReplicaSet(newRS, d.Spec.Replicas, d); err != nil {
+				return err
+			}
+		}
+		return dc.updateStatus(d, newRS, oldRSs, false)
+	}
+
+	progressed := false
+	if newRS == nil {
+		created, err := dc.createNewReplicaSet(d, owned)
+		if err != nil {
+			return err
+		}
+		newRS = created
+		progressed = true
+	} else if err := dc.syncRevision(d, newRS, oldRSs); err != nil {
+		return err
+	}
+
+	var scaled bool
+	var err error
+	if d.Spec.Strategy.Type == api.RecreateDeploymentStrategyType {
+		scaled, err = dc.rolloutRecreate(d, newRS, oldRSs, allPods)
+	} else {
+		scaled, err = dc.rolloutRolling(d, newRS, oldRSs)
+	}
+	if err != nil {
+		return err
+	}
+	if err := dc.cleanupOldReplicaSets(d, oldRSs); err != nil {
+		return err
+	}
+	return dc.updateStatus(d, newRS, oldRSs, progressed || scaled)
+}
+
+// findNewReplicaSet 返回模板和 Deployment 当前模板相同（忽略 pod-template-hash label）的 ReplicaSet，以及其余的旧 ReplicaSet
+func findNewReplicaSet(d *api.Deployment, owned []*api.ReplicaSet) (*api.ReplicaSet, []*api.ReplicaSet) {
+	var newRS *api.ReplicaSet
+	var oldRSs []*api.ReplicaSet
+	for _, rs := range owned {
+		if newRS == nil && equalIgnoreHash(&rs.Spec.Template, &d.Spec.Template) {
+			newRS = rs
+			continue
+		}
+		oldRSs = append(oldRSs, rs)
+	}
+	return newRS, oldRSs
+}
+
+func equalIgnoreHash(a, b *api.PodTemplateSpec) bool {
+	a1, b1 := templateWithoutHash(a), templateWithoutHash(b)
+	return reflect.DeepEqual(a1, b1)
+}
+
+// templateWithoutHash 返回去掉 pod-template-hash label 之后的模板副本，回滚时也用它从旧 ReplicaSet 恢复模板
+func templateWithoutHash(template *api.PodTemplateSpec) api.PodTemplateSpec {
+	var out api.PodTemplateSpec
+	template.DeepCopyInto(&out)
+	delete(out.Metadata.Labels, api.PodTemplateHashLabel)
+	if len(out.Metadata.Labels) == 0 {
+		out.Metadata.Labels = nil
+	}
+	return out
+}
+
+// Revision 返回 ReplicaSet 的版本号，没有或者无法解析时返回 0
+func Revision(rs *api.ReplicaSet) int64 {
+	revision, err := strconv.ParseInt(rs.Annotations[api.RevisionAnnotation], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return revision
+}
+
+func maxRevision(replicaSets []*api.ReplicaSet) int64 {
+	var max int64
+	for _, rs := range replicaSets {
+		if r := Revision(rs); r > max {
+			max = r
+		}
+	}
+	return max
+}
+
+func (dc *DeploymentController) createNewReplicaSet(d *api.Deployment, owned []*api.ReplicaSet) (*api.ReplicaSet, error) {
+	hash := controller.ComputeHash(&d.Spec.Template)
+	rs := &api.ReplicaSet{
+		ObjectMeta: api.ObjectMeta{
+			Name:            d.Name + "-" + hash,
+			Namespace:       d.Namespace,
+			Labels:          map[string]string{},
+			Annotations:     map[string]string{api.RevisionAnnotation: strconv.FormatInt(maxRevision(owned)+1, 10)},
+			OwnerReferences: []api.OwnerReference{controller.NewControllerRef(controllerKind, &d.ObjectMeta)},
+		},
+		Spec: api.ReplicaSetSpec{
+			Selector:        d.Spec.Selector.DeepCopy(),
+			MinReadySeconds: d.Spec.MinReadySeconds,
+		},
+	}
+	d.Spec.Template.DeepCopyInto(&rs.Spec.Template)
+	if rs.Spec.Template.Metadata.Labels == nil {
+		rs.Spec.Template.Metadata.Labels = map[string]string{}
+	}
+	rs.Spec.Template.Metadata.Labels[api.PodTemplateHashLabel] = hash
+	rs.Spec.Selector.MatchLabels[api.PodTemplateHashLabel] = hash
+	for k, v := range d.Spec.Template.Metadata.Labels {
+		rs.Labels[k] = v
+	}
+	rs.Labels[api.PodTemplateHashLabel] = hash
+	created, err := dc.client.CreateReplicaSet(d.Namespace, rs)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Created replicaset %s/%s (revision %s) for deployment %s", created.Namespace, created.Name, created.Annotations[api.RevisionAnnotation], d.Name)
+	return created, nil
+}
+
+// syncRevision 保证新 ReplicaSet 的版本号最大。回滚到旧模板时，旧的 ReplicaSet 重新变成新的，版本号也随之更新
+func (dc *DeploymentController) syncRevision(d *api.Deployment, newRS *api.ReplicaSet, oldRSs []*api.ReplicaSet) error {
+	maxOld := maxRevision(oldRSs)
+	if Revision(newRS) > maxOld && newRS.Spec.MinReadySeconds == d.Spec.MinReadySeconds {
+		return nil
+	}
+	if Revision(newRS) <= maxOld {
+		if newRS.Annotations == nil {
+			newRS.Annotations = map[string]string{}
+		}
+		newRS.Annotations[api.RevisionAnnotation] = strconv.FormatInt(maxOld+1, 10)
+	}
+	newRS.Spec.MinReadySeconds = d.Spec.MinReadySeconds
+	updated, err := dc.client.UpdateReplicaSet(newRS)
+	if err != nil {
+		return err
+	}
+	*newRS = *updated
+	log.Printf("Replicaset %s/%s is now revision %s of deployment %s", newRS.Namespace, newRS.Name, newRS.Annotations[api.RevisionAnnotation], d.Name)
+	return nil
+}
+
+func (dc *DeploymentController) scaleReplicaSet(rs *api.ReplicaSet, replicas int, d *api.Deployment) error {
+	old := rs.Spec.Replicas
+	rs.Spec.Replicas = replicas
+	updated, err := dc.client.UpdateReplicaSet(rs)
+	if err != nil {
+		rs.Spec.Replicas = old
+		return err
+	}
+	*rs = *updated
+	log.Printf("Scaled replicaset %s/%s of deployment %s from %d to %d", rs.Namespace, rs.Name, d.Name, old, replicas)
+	return nil
+}
+
+// cleanupOldReplicaSets 删除超过 revisionHistoryLimit 的、已经缩容到 0 的旧 ReplicaSet，先删版本最老的
+func (dc *DeploymentController) cleanupOldReplicaSets(d *api.Deployment, oldRSs []*api.ReplicaSet) error {
+	limit := api.DefaultRevisionHistoryLimit
+	if d.Spec.RevisionHistoryLimit != nil {
+		limit = *d.Spec.RevisionHistoryLimit
+	}
+	var cleanable []*api.ReplicaSet
+	for _, rs := range oldRSs {
+		if rs.Spec.Replicas == 0 && rs.Status.Replicas == 0 && rs.DeletionTimestamp == nil {
+			cleanable = append(cleanable, rs)
+		}
+	}
+	if len(cleanable) <= limit {
+		return nil
+	}
+	sortByRevision(cleanable)
+	for _, rs := range cleanable[:len(cleanable)-limit] {
+		if err := dc.client.DeleteReplicaSet(rs.Namespace, rs.Name); err != nil {
+			return err
+		}
+		log.Printf("Deleted old replicaset %s/%s (revision %d) of deployment %s", rs.Namespace, rs.Name, Revision(rs), d.Name)
+	}
+	return nil
+}
+
+func totalReplicas(replicaSets []*api.ReplicaSet) int {
+	total := 0
+	for _, rs := range replicaSets {
+		total += rs.Spec.Replicas
+	}
+	return total
+}
+
+// updateStatus 汇总所有 ReplicaSet 的 status，并维护 Available 和 Progressing 两个 condition。
+// progressed 表示这次同步创建或者伸缩了 ReplicaSet，超过 progressDeadlineSeconds 没有任何进展的滚动更新会被标记为失败
+func (dc *DeploymentController) updateStatus(d *api.Deployment, newRS *api.ReplicaSet, oldRSs []*api.ReplicaSet, progressed bool) error {
+	now := time.Now()
+	status := api.DeploymentStatus{ObservedGeneration: d.Generation}
+	for _, rs := range append([]*api.ReplicaSet{newRS}, oldRSs...) {
+		if rs == nil {
+			continue
+		}
+		status.Replicas += rs.Status.Replicas
+		status.ReadyReplicas += rs.Status.ReadyReplicas
+		status.AvailableReplicas += rs.Status.AvailableReplicas
+	}
+	if newRS != nil {
+		status.UpdatedReplicas = newRS.Status.Replicas
+	}
+	if unavailable := d.Spec.Replicas - status.AvailableReplicas; unavailable > 0 {
+		status.UnavailableReplicas = unavailable
+	}
+	for i := range d.Status.Conditions {
+		var c api.DeploymentCondition
+		d.Status.Conditions[i].DeepCopyInto(&c)
+		status.Conditions = append(status.Conditions, c)
+	}
+
+	_, maxUnavailable, _ := resolveFenceposts(d)
+	if status.AvailableReplicas >= d.Spec.Replicas-maxUnavailable {
+		setCondition(&status, api.DeploymentAvailable, api.ConditionTrue, api.MinimumReplicasAvailable, "Deployment has minimum availability.", now)
+	} else {
+		setCondition(&status, api.DeploymentAvailable, api.ConditionFalse, api.MinimumReplicasUnavailable, "Deployment does not have minimum availability.", now)
+	}
+
+	//副本数的变化也算作进展
+	if status.Replicas != d.Status.Replicas || status.UpdatedReplicas != d.Status.UpdatedReplicas ||
+		status.ReadyReplicas != d.Status.ReadyReplicas || status.AvailableReplicas != d.Status.AvailableReplicas {
+		progressed = true
+	}
+	progressing := status.GetCondition(api.DeploymentProgressing)
+	complete := newRS != nil && status.UpdatedReplicas == d.Spec.Replicas && status.Replicas == d.Spec.Replicas &&
+		status.AvailableReplicas == d.Spec.Replicas && totalReplicas(oldRSs) == 0
+	switch {
+	case d.Spec.Paused:
+		setCondition(&status, api.DeploymentProgressing, api.ConditionUnknown, api.DeploymentPausedReason, "Deployment is paused.", now)
+	case progressing != nil && progressing.Reason == api.DeploymentPausedReason:
+		setCondition(&status, api.DeploymentProgressing, api.ConditionUnknown, api.DeploymentResumedReason, "Deployment is resumed.", now)
+	case complete:
+		setCondition(&status, api.DeploymentProgressing, api.ConditionTrue, api.NewReplicaSetAvailableReason,
+			"ReplicaSet \""+newRS.Name+"\" has successfully progressed.", now)
+	case progressed || progressing == nil || progressing.Reason == api.NewReplicaSetAvailableReason:
+		message := "Deployment is progressing."
+		if newRS != nil {
+			message = "ReplicaSet \"" + newRS.Name + "\" is progressing."
+		}
+		setCondition(&status, api.DeploymentProgressing, api.ConditionTrue, api.ReplicaSetUpdatedReason, message, now)
+	case progressing.Reason != api.ProgressDeadlineExceededReason && progressing.LastUpdateTime != nil:
+		deadline := api.DefaultProgressDeadlineSeconds
+		if d.Spec.ProgressDeadlineSeconds != nil {
+			deadline = *d.Spec.ProgressDeadlineSeconds
+		}
+		if progressing.LastUpdateTime.Add(time.Duration(deadline) * time.Second).Before(now) {
+			message := "Deployment has exceeded its progress deadline."
+			if newRS != nil {
+				message = "ReplicaSet \"" + newRS.Name + "\" has timed out progressing."
+			}
+			setCondition(&status, api.DeploymentProgressing, api.ConditionFalse, api.ProgressDeadlineExceededReason, message, now)
+			log.Printf("Deployment %s/%s exceeded its progress deadline of %ds", d.Namespace, d.Name, deadline)
+		}
+	}
+
+	if reflect.DeepEqual(status, d.Status) {
+		return nil
+	}
+	d.Status = status
+	_, err := dc.client.UpdateDeploymentStatus(d)
+	return err
+}
+
+// setCondition 更新指定类型的 condition。status 和 reason 都没变时保留原来的时间，这样 lastUpdateTime 可以表示最近一次进展
+func setCondition(status *api.DeploymentStatus, condType api.DeploymentConditionType, condStatus api.ConditionStatus, reason, message string, now time.Time) {
+	existing := status.GetCondition(condType)
+	if existing == nil {
+		status.Conditions = append(status.Conditions, api.DeploymentCondition{
+			Type: condType, Status: condStatus, Reason: reason, Message: message,
+			LastUpdateTime: &now, LastTransitionTime: &now,
+		})
+		return
+	}
+	if existing.Status == condStatus && existing.Reason == reason && existing.Message == message {
+		//同一个 reason 下的 ReplicaSetUpdated 每次有进展都要刷新时间
+		if reason == api.ReplicaSetUpdatedReason {
+			existing.LastUpdateTime = &now
+		}
+		return
+	}
+	if existing.Status != condStatus {
+		existing.LastTransitionTime = &now
+	}
+	existing.Status = condStatus
+	existing.Reason = reason
+	existing.Message = message
+	existing.LastUpdateTime = &now
+}
@@ -0,0 +1,154 @@
+package deployment
+
+import (
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/controller"
+	"sort"
+)
+
+// resolveFenceposts 把 maxSurge 和 maxUnavailable 换算成 pod 数量：surge 向上取整，unavailable 向下取整。
+// 两者都为 0 时滚动更新无法推进，所以 unavailable 至少为 1
+func resolveFenceposts(d *api.Deployment) (int, int, error) {
+	if d.Spec.Strategy.Type == api.RecreateDeploymentStrategyType || d.Spec.Strategy.RollingUpdate == nil {
+		return 0, 0, nil
+	}
+	surge, err := d.Spec.Strategy.RollingUpdate.MaxSurge.ScaledValue(d.Spec.Replicas, true)
+	if err != nil {
+		return 0, 0, err
+	}
+	unavailable, err := d.Spec.Strategy.RollingUpdate.MaxUnavailable.ScaledValue(d.Spec.Replicas, false)
+	if err != nil {
+		return 0, 0, err
+	}
+	if surge == 0 && unavailable == 0 {
+		unavailable = 1
+	}
+	if unavailable > d.Spec.Replicas {
+		unavailable = d.Spec.Replicas
+	}
+	return surge, unavailable, nil
+}
+
+// rolloutRolling 先在 maxSurge 允许的范围内扩容新 ReplicaSet，再在 maxUnavailable 允许的范围内缩容旧 ReplicaSet。
+// 返回值表示这次是否伸缩了 ReplicaSet
+func (dc *DeploymentController) rolloutRolling(d *api.Deployment, newRS *api.ReplicaSet, oldRSs []*api.ReplicaSet) (bool, error) {
+	maxSurge, maxUnavailable, err := resolveFenceposts(d)
+	if err != nil {
+		return false, err
+	}
+	scaledUp, err := dc.reconcileNewReplicaSet(d, newRS, oldRSs, maxSurge)
+	if err != nil {
+		return false, err
+	}
+	scaledDown, err := dc.reconcileOldReplicaSets(d, newRS, oldRSs, maxUnavailable)
+	if err != nil {
+		return false, err
+	}
+	return scaledUp || scaledDown, nil
+}
+
+func (dc *DeploymentController) reconcileNewReplicaSet(d *api.Deployment, newRS *api.ReplicaSet, oldRSs []*api.ReplicaSet, maxSurge int) (bool, error) {
+	if newRS.Spec.Replicas == d.Spec.Replicas {
+		return false, nil
+	}
+	if newRS.Spec.Replicas > d.Spec.Replicas {
+		return true, dc.scaleReplicaSet(newRS, d.Spec.Replicas, d)
+	}
+	//所有 ReplicaSet 的副本数之和不能超过 replicas + maxSurge
+	current := newRS.Spec.Replicas + totalReplicas(oldRSs)
+	scaleUp := d.Spec.Replicas + maxSurge - current
+	if remaining := d.Spec.Replicas - newRS.Spec.Replicas; scaleUp > remaining {
+		scaleUp = remaining
+	}
+	if scaleUp <= 0 {
+		return false, nil
+	}
+	return true, dc.scaleReplicaSet(newRS, newRS.Spec.Replicas+scaleUp, d)
+}
+
+// reconcileOldReplicaSets 缩容旧的 ReplicaSet，保证可用的 pod 不少于 replicas - maxUnavailable。
+// 旧 ReplicaSet 里不可用的副本先被清理掉，它们不影响可用性
+func (dc *DeploymentController) reconcileOldReplicaSets(d *api.Deployment, newRS *api.ReplicaSet, oldRSs []*api.ReplicaSet, maxUnavailable int) (bool, error) {
+	if totalReplicas(oldRSs) == 0 {
+		return false, nil
+	}
+	allPods := newRS.Spec.Replicas + totalReplicas(oldRSs)
+	minAvailable := d.Spec.Replicas - maxUnavailable
+	newRSUnavailable := newRS.Spec.Replicas - newRS.Status.AvailableReplicas
+	maxScaledDown := allPods - minAvailable - newRSUnavailable
+	if maxScaledDown <= 0 {
+		return false, nil
+	}
+
+	sortByRevision(oldRSs)
+	scaled := false
+	for _, rs := range oldRSs {
+		if maxScaledDown <= 0 {
+			break
+		}
+		unhealthy := rs.Spec.Replicas - rs.Status.AvailableReplicas
+		if unhealthy <= 0 {
+			continue
+		}
+		if unhealthy > maxScaledDown {
+			unhealthy = maxScaledDown
+		}
+		if err := dc.scaleReplicaSet(rs, rs.Spec.Replicas-unhealthy, d); err != nil {
+			return scaled, err
+		}
+		maxScaledDown -= unhealthy
+		scaled = true
+	}
+
+	available := newRS.Status.AvailableReplicas
+	for _, rs := range oldRSs {
+		available += min(rs.Spec.Replicas, rs.Status.AvailableReplicas)
+	}
+	scaleDown := available - minAvailable
+	for _, rs := range oldRSs {
+		if scaleDown <= 0 {
+			break
+		}
+		if rs.Spec.Replicas == 0 {
+			continue
+		}
+		count := min(rs.Spec.Replicas, scaleDown)
+		if err := dc.scaleReplicaSet(rs, rs.Spec.Replicas-count, d); err != nil {
+			return scaled, err
+		}
+		scaleDown -= count
+		scaled = true
+	}
+	return scaled, nil
+}
+
+// rolloutRecreate 先把旧的 ReplicaSet 缩容到 0，等旧的 pod 全部结束之后再扩容新的 ReplicaSet
+func (dc *DeploymentController) rolloutRecreate(d *api.Deployment, newRS *api.ReplicaSet, oldRSs []*api.ReplicaSet, allPods []api.Pod) (bool, error) {
+	scaled := false
+	for _, rs := range oldRSs {
+		if rs.Spec.Replicas == 0 {
+			continue
+		}
+		if err := dc.scaleReplicaSet(rs, 0, d); err != nil {
+			return scaled, err
+		}
+		scaled = true
+	}
+	for _, rs := range oldRSs {
+		for i := range allPods {
+			pod := &allPods[i]
+			if pod.Namespace == rs.Namespace && controller.IsControlledBy(&pod.ObjectMeta, rs.UID) && pod.Phase != api.PodDeleted {
+				return scaled, nil
+			}
+		}
+	}
+	if newRS.Spec.Replicas == d.Spec.Replicas {
+		return scaled, nil
+	}
+	return true, dc.scaleReplicaSet(newRS, d.Spec.Replicas, d)
+}
+
+// sortByRevision 按版本号从旧到新排序，缩容时先缩最老的版本
+func sortByRevision(replicaSets []*api.ReplicaSet) {
+	sort.SliceStable(replicaSets, func(i, j int) bool { return Revision(replicaSets[i]) < Revision(replicaSets[j]) })
+}
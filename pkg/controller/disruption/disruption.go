@@ -0,0 +1,253 @@
+// Package disruption contains the controller that keeps the status of each
+// PodDisruptionBudget up to date: how many of the selected pods are healthy,
+// how many must stay healthy, and how many may be evicted right now. The
+// API server's eviction subresource reads that status to decide whether an
+// eviction is allowed.
+package disruption
+
+import (
+	"fmt"
+	"log"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/controller"
+	"reflect"
+	"time"
+)
+
+// deletionTimeout 是 disruptedPods 里的记录保留多久。pod 被批准驱逐之后这么久还没有开始删除，
+// 说明删除失败了，这个名额重新算回来
+const deletionTimeout = 2 * time.Minute
+
+type DisruptionController struct {
+	client  *api.Client
+	listers controller.Listers
+	workers int
+}
+
+func NewDisruptionController(client *api.Client, listers controller.Listers, workers int) *DisruptionController {
+	return &DisruptionController{client: client, listers: listers, workers: workers}
+}
+
+// Sync 重新计算所有 PDB 的 status
+func (dc *DisruptionController) Sync() {
+	pdbs, err := dc.listers.ListPodDisruptionBudgets()
+	if err != nil {
+		log.Printf("Error listing pod disruption budgets: %v", err)
+		return
+	}
+	if len(pdbs) == 0 {
+		return
+	}
+	state, err := dc.loadState()
+	if err != nil {
+		log.Printf("Error loading pods and controllers: %v", err)
+		return
+	}
+	now := time.Now()
+	controller.Parallelize(dc.workers, len(pdbs), func(i int) {
+		pdb := &pdbs[i]
+		if pdb.DeletionTimestamp != nil {
+			return
+		}
+		if err := dc.sync(pdb, state, now); err != nil {
+			log.Printf("Error syncing pod disruption budget %s/%s: %v", pdb.Namespace, pdb.Name, err)
+		}
+	})
+}
+
+// clusterState 是一次同步里所有 PDB 共用的 pod、节点和 controller
+type clusterState struct {
+	pods         []api.Pod
+	readyNodes   map[string]bool
+	deployments  map[string]*api.Deployment
+	replicaSets  map[string]*api.ReplicaSet
+	statefulSets map[string]*api.StatefulSet
+}
+
+func (dc *DisruptionController) loadState() (*clusterState, error) {
+	pods, err := dc.listers.ListPods()
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := dc.listers.ListNodes()
+	if err != nil {
+		return nil, err
+	}
+	deployments, err := dc.listers.ListDeployments()
+	if err != nil {
+		return nil, err
+	}
+	replicaSets, err := dc.listers.ListReplicaSets()
+	if err != nil {
+		return nil, err
+	}
+	statefulSets, err := dc.listers.ListStatefulSets()
+	if err != nil {
+		return nil, err
+	}
+	state := &clusterState{
+		pods:         pods,
+		readyNodes:   map[string]bool{},
+		deployments:  map[string]*api.Deployment{},
+		replicaSets:  map[string]*api.ReplicaSet{},
+		statefulSets: map[string]*api.StatefulSet{},
+	}
+	for _, node := range nodes {
+		if node.Status == api.NodeReady {
+			state.readyNodes[node.Name] = true
+		}
+	}
+	for i := range deployments {
+		state.deployments[deployments[i].UID] = &deployments[i]
+	}
+	for i := range replicaSets {
+		state.replicaSets[replicaSets[i].UID] = &replicaSets[i]
+	}
+	for i := range statefulSets {
+		state.statefulSets[statefulSets[i].UID] = &statefulSets[i]
+	}
+	return state, nil
+}
+
+func (dc *DisruptionController) sync(pdb *api.PodDisruptionBudget, state *clusterState, now time.Time) error {
+	var pods []*api.Pod
+	for i := range state.pods {
+		pod := &state.pods[i]
+		if pod.Namespace == pdb.Namespace && pdb.Spec.Selector.Matches(pod.Labels) {
+			pods = append(pods, pod)
+		}
+	}
+	updated := pdb.DeepCopy()
+	updated.Status.ObservedGeneration = pdb.Generation
+	updated.Status.DisruptedPods = pruneDisruptedPods(pdb.Status.DisruptedPods, pods, now)
+
+	expected, desired, err := desiredHealthy(pdb, pods, state)
+	if err != nil {
+		//算不出需要多少健康的 pod 时不允许任何驱逐，和 Kubernetes 一样宁可让排空卡住也不冒险
+		log.Printf("Pod disruption budget %s/%s: %v", pdb.Namespace, pdb.Name, err)
+		expected, desired = 0, 0
+		updated.Status.DisruptionsAllowed = 0
+	}
+	healthy := 0
+	for _, pod := range pods {
+		if _, disrupted := updated.Status.DisruptedPods[pod.Name]; disrupted {
+			continue
+		}
+		if pod.Phase == api.PodRunning && pod.DeletionTimestamp == nil && state.readyNodes[pod.NodeName] {
+			healthy++
+		}
+	}
+	updated.Status.ExpectedPods = expected
+	updated.Status.DesiredHealthy = desired
+	updated.Status.CurrentHealthy = healthy
+	if err == nil {
+		updated.Status.DisruptionsAllowed = max(0, healthy-desired)
+	}
+	if reflect.DeepEqual(pdb.Status, updated.Status) {
+		return nil
+	}
+	//status 里带着读到的 resourceVersion，期间有驱逐扣过名额时更新会冲突，下一次同步用新的 disruptedPods 重新计算
+	_, err = dc.client.UpdatePodDisruptionBudgetStatus(updated)
+	return err
+}
+
+// pruneDisruptedPods 去掉已经删除、已经开始删除或者等了太久还没删除的 pod
+func pruneDisruptedPods(disrupted map[string]time.Time, pods []*api.Pod, now time.Time) map[string]time.Time {
+	if len(disrupted) == 0 {
+		return nil
+	}
+	byName := map[string]*api.Pod{}
+	for _, pod := range pods {
+		byName[pod.Name] = pod
+	}
+	result := map[string]time.Time{}
+	for name, t := range disrupted {
+		pod, ok := byName[name]
+		if !ok || pod.DeletionTimestamp != nil || now.Sub(t) > deletionTimeout {
+			continue
+		}
+		result[name] = t
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// desiredHealthy 返回期望的 pod 总数和至少要保持健康的 pod 数。
+// minAvailable 是整数时期望的 pod 数就是选中的活跃 pod 数；maxUnavailable 和百分比的 minAvailable
+// 按这些 pod 的 controller 的副本数计算，因为正在被替换的 pod 不应该让名额变多
+func desiredHealthy(pdb *api.PodDisruptionBudget, pods []*api.Pod, state *clusterState) (int, int, error) {
+	if pdb.Spec.MinAvailable != nil && !pdb.Spec.MinAvailable.IsString {
+		expected := 0
+		for _, pod := range pods {
+			if api.IsPodActive(pod) {
+				expected++
+			}
+		}
+		return expected, pdb.Spec.MinAvailable.IntVal, nil
+	}
+	expected, err := expectedScale(pods, state)
+	if err != nil {
+		return 0, 0, err
+	}
+	if pdb.Spec.MaxUnavailable != nil {
+		unavailable, err := pdb.Spec.MaxUnavailable.ScaledValue(expected, true)
+		if err != nil {
+			return 0, 0, err
+		}
+		return expected, max(0, expected-unavailable), nil
+	}
+	available, err := pdb.Spec.MinAvailable.ScaledValue(expected, true)
+	if err != nil {
+		return 0, 0, err
+	}
+	return expected, available, nil
+}
+
+// expectedScale 把选中的 pod 所属 controller 的副本数加起来，每个 controller 只算一次。
+// Deployment 创建的 ReplicaSet 按 Deployment 的副本数算，这样滚动更新时新旧两个 ReplicaSet 不会被算两遍
+func expectedScale(pods []*api.Pod, state *clusterState) (int, error) {
+	counted := map[string]bool{}
+	expected := 0
+	for _, pod := range pods {
+		ref := pod.ControllerRef()
+		if ref == nil {
+			return 0, fmt.Errorf("pod %s has no controller, a percentage or maxUnavailable cannot be used", pod.Name)
+		}
+		uid, replicas, err := controllerScale(ref, state)
+		if err != nil {
+			return 0, fmt.Errorf("pod %s: %w", pod.Name, err)
+		}
+		if !counted[uid] {
+			counted[uid] = true
+			expected += replicas
+		}
+	}
+	return expected, nil
+}
+
+func controllerScale(ref *api.OwnerReference, state *clusterState) (string, int, error) {
+	switch ref.Kind {
+	case "ReplicaSet":
+		rs, ok := state.replicaSets[ref.UID]
+		if !ok {
+			return "", 0, fmt.Errorf("replicaset %s not found", ref.Name)
+		}
+		if owner := rs.ControllerRef(); owner != nil && owner.Kind == "Deployment" {
+			d, ok := state.deployments[owner.UID]
+			if !ok {
+				return "", 0, fmt.Errorf("deployment %s not found", owner.Name)
+			}
+			return d.UID, d.Spec.Replicas, nil
+		}
+		return rs.UID, rs.Spec.Replicas, nil
+	case "StatefulSet":
+		ss, ok := state.statefulSets[ref.UID]
+		if !ok {
+			return "", 0, fmt.Errorf("statefulset %s not found", ref.Name)
+		}
+		return ss.UID, ss.Spec.Replicas, nil
+	}
+	return "", 0, fmt.Errorf("controller kind %s does not have a scale", ref.Kind)
+}
@@ -0,0 +1,154 @@
+// Package endpoint contains the controller that keeps the Endpoints object of
+// each Service in sync with the pods its selector matches.
+package endpoint
+
+import (
+	"log"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/controller"
+	"reflect"
+	"sort"
+)
+
+const controllerKind = "Service"
+
+type EndpointsController struct {
+	client  *api.Client
+	listers controller.Listers
+	// workers 是同时同步的对象数量
+	workers int
+}
+
+func NewEndpointsController(client *api.Client, listers controller.Listers, workers int) *EndpointsController {
+	return &EndpointsController{client: client, listers: listers, workers: workers}
+}
+
+// Sync 对每个有 selector 的 service，把选中的 pod 的地址和 service 的目标端口写进同名的 Endpoints。
+// Endpoints 以 service 为 owner，service 删除后由垃圾回收器清理
+func (ec *EndpointsController) Sync() {
+	services, err := ec.listers.ListServices()
+	if err != nil {
+		log.Printf("Error listing services: %v", err)
+		return
+	}
+	if len(services) == 0 {
+		return
+	}
+	pods, err := ec.listers.ListPods()
+	if err != nil {
+		log.Printf("Error listing pods: %v", err)
+		return
+	}
+	endpoints, err := ec.listers.ListEndpoints()
+	if err != nil {
+		log.Printf("Error listing endpoints: %v", err)
+		return
+	}
+	existing := map[string]*api.Endpoints{}
+	for i := range endpoints {
+		existing[endpoints[i].Namespace+"/"+endpoints[i].Name] = &endpoints[i]
+	}
+	controller.Parallelize(ec.workers, len(services), func(i int) {
+		svc := &services[i]
+		if err := ec.syncService(svc, pods, existing[svc.Namespace+"/"+svc.Name]); err != nil {
+			log.Printf("Error syncing endpoints for service %s/%s: %v", svc.Namespace, svc.Name, err)
+		}
+	})
+}
+
+func (ec *EndpointsController) syncService(svc *api.Service, pods []api.Pod, current *api.Endpoints) error {
+	//没有 selector 的 service 的 Endpoints 由用户自己维护
+	if len(svc.Spec.Selector) == 0 || svc.DeletionTimestamp != nil {
+		return nil
+	}
+	subsets := desiredSubsets(svc, pods)
+	if current == nil {
+		ep := &api.Endpoints{
+			ObjectMeta: api.ObjectMeta{
+				Name:            svc.Name,
+				Namespace:       svc.Namespace,
+				Labels:          copyLabels(svc.Labels),
+				OwnerReferences: []api.OwnerReference{controller.NewControllerRef(controllerKind, &svc.ObjectMeta)},
+			},
+			Subsets: subsets,
+		}
+		if _, err := ec.client.CreateEndpoints(svc.Namespace, ep); err != nil {
+			return err
+		}
+		log.Printf("Created endpoints %s/%s with %d ready addresses", svc.Namespace, svc.Name, countAddresses(subsets))
+		return nil
+	}
+	if current.DeletionTimestamp != nil || reflect.DeepEqual(current.Subsets, subsets) {
+		return nil
+	}
+	current.Subsets = subsets
+	if _, err := ec.client.UpdateEndpoints(current); err != nil {
+		return err
+	}
+	log.Printf("Updated endpoints %s/%s to %d ready addresses", svc.Namespace, svc.Name, countAddresses(subsets))
+	return nil
+}
+
+// desiredSubsets 返回 service 应该有的 Endpoints。所有后端开放相同的端口，所以最多只有一个 subset
+func desiredSubsets(svc *api.Service, pods []api.Pod) []api.EndpointSubset {
+	selector := &api.LabelSelector{MatchLabels: svc.Spec.Selector}
+	var subset api.EndpointSubset
+	for i := range pods {
+		pod := &pods[i]
+		//kubelet 还没有给 pod 分配地址时没有办法转发给它
+		if pod.Namespace != svc.Namespace || !selector.Matches(pod.Labels) || !api.IsPodActive(pod) || pod.PodIP == "" {
+			continue
+		}
+		addr := api.EndpointAddress{
+			IP:        pod.PodIP,
+			NodeName:  pod.NodeName,
+			TargetRef: &api.ObjectReference{Kind: "Pod", Namespace: pod.Namespace, Name: pod.Name, UID: pod.UID},
+		}
+		if pod.Hostname != "" && pod.Subdomain == svc.Name {
+			addr.Hostname = pod.Hostname
+		}
+		if pod.Phase == api.PodRunning {
+			subset.Addresses = append(subset.Addresses, addr)
+		} else {
+			subset.NotReadyAddresses = append(subset.NotReadyAddresses, addr)
+		}
+	}
+	if len(subset.Addresses) == 0 && len(subset.NotReadyAddresses) == 0 {
+		return nil
+	}
+	sortAddresses(subset.Addresses)
+	sortAddresses(subset.NotReadyAddresses)
+	for _, port := range svc.Spec.Ports {
+		subset.Ports = append(subset.Ports, api.EndpointPort{Name: port.Name, Port: port.TargetPort, Protocol: port.Protocol})
+	}
+	return []api.EndpointSubset{subset}
+}
+
+// sortAddresses 按 IP 和 pod 名排序，pod 列表的顺序变化不会导致 Endpoints 被重写
+func sortAddresses(addresses []api.EndpointAddress) {
+	sort.Slice(addresses, func(i, j int) bool {
+		if addresses[i].IP != addresses[j].IP {
+			return addresses[i].IP < addresses[j].IP
+		}
+		return addresses[i].TargetRef.Name < addresses[j].TargetRef.Name
+	})
+}
+
+func countAddresses(subsets []api.EndpointSubset) int {
+	n := 0
+	for _, subset := range subsets {
+		n += len(subset.Addresses)
+	}
+	return n
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+	out := make(map[string]string, len(labels))
+	for k, v := range labels {
+		out[k] = v
+	}
+	return out
+}
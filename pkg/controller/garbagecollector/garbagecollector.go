@@ -0,0 +1,257 @@
+// Package garbagecollector contains the controller that deletes objects whose
+// owners are gone and carries out Foreground and Orphan deletions.
+package garbagecollector
+
+import (
+	"log"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/controller"
+	"strings"
+)
+
+// resource 是垃圾回收器管理的一种资源
+type resource struct {
+	kind   string
+	plural string
+	// clusterScoped 的对象没有命名空间，可以做任何命名空间里对象的 owner
+	clusterScoped bool
+}
+
+// resources 按 dependents 在前、owners 在后的顺序排列。对象引用的 owner 一定比它先创建，
+// 所以依次向 API server list 时，列表里找不到的 owner 多半已经被删除了。informer 的缓存之间没有这个顺序保证，
+// 因此图里找不到 owner 时总是再向 API server 确认一次（见 ownerAbsent）。
+// Node、Namespace 和 Lease 不在这里：它们不会被级联删除，作为 owner 时也一律当作存在
+var resources = []resource{
+	{kind: "PersistentVolume", plural: "persistentvolumes", clusterScoped: true},
+	{kind: "PersistentVolumeClaim", plural: "persistentvolumeclaims"},
+	{kind: "ConfigMap", plural: "configmaps"},
+	{kind: "Secret", plural: "secrets"},
+	{kind: "Ingress", plural: "ingresses"},
+	{kind: "HorizontalPodAutoscaler", plural: "horizontalpodautoscalers"},
+	{kind: "PodDisruptionBudget", plural: "poddisruptionbudgets"},
+	{kind: "Pod", plural: "pods"},
+	{kind: "ControllerRevision", plural: "controllerrevisions"},
+	{kind: "ReplicaSet", plural: "replicasets"},
+	{kind: "Job", plural: "jobs"},
+	{kind: "StatefulSet", plural: "statefulsets"},
+	{kind: "DaemonSet", plural: "daemonsets"},
+	{kind: "Deployment", plural: "deployments"},
+	{kind: "CronJob", plural: "cronjobs"},
+	{kind: "Endpoints", plural: "endpoints"},
+	{kind: "Service", plural: "services"},
+	{kind: "StorageClass", plural: "storageclasses", clusterScoped: true},
+}
+
+// node 是所有权图里的一个对象
+type node struct {
+	resource   *resource
+	meta       api.ObjectMeta
+	dependents []*node
+}
+
+func (n *node) String() string {
+	return strings.ToLower(n.resource.kind) + " " + n.meta.Namespace + "/" + n.meta.Name
+}
+
+// beingDeleted 表示对象已经在删除中，不需要再对它发起删除
+func (n *node) beingDeleted() bool {
+	return n.meta.DeletionTimestamp != nil
+}
+
+// waitingForDependents 表示对象正在以 Foreground 方式删除
+func (n *node) waitingForDependents() bool {
+	return n.beingDeleted() && n.meta.HasFinalizer(api.FinalizerDeleteDependents)
+}
+
+// graph 是一次同步时读出的所有权图，按 UID 索引
+type graph struct {
+	nodes []*node
+	uids  map[string]*node
+}
+
+type GarbageCollector struct {
+	client  *api.Client
+	listers controller.Listers
+	// workers 是同时处理的对象数量
+	workers int
+	kinds   map[string]*resource
+}
+
+func NewGarbageCollector(client *api.Client, listers controller.Listers, workers int) *GarbageCollector {
+	kinds := make(map[string]*resource, len(resources))
+	for i := range resources {
+		kinds[resources[i].kind] = &resources[i]
+	}
+	return &GarbageCollector{client: client, listers: listers, workers: workers, kinds: kinds}
+}
+
+// Sync 构建所有权图，先处理正在以 Orphan 或 Foreground 方式删除的对象，再删除 owner 都已经不存在的对象
+func (gc *GarbageCollector) Sync() {
+	g, err := gc.buildGraph()
+	if err != nil {
+		log.Printf("Error building the ownership graph: %v", err)
+		return
+	}
+	controller.Parallelize(gc.workers, len(g.nodes), func(i int) {
+		n := g.nodes[i]
+		if !n.beingDeleted() {
+			return
+		}
+		var err error
+		switch {
+		case n.meta.HasFinalizer(api.FinalizerOrphanDependents):
+			err = gc.orphanDependents(n)
+		case n.meta.HasFinalizer(api.FinalizerDeleteDependents):
+			err = gc.processDeletingDependents(n)
+		}
+		if err != nil {
+			log.Printf("Error finalizing %s: %v", n, err)
+		}
+	})
+	controller.Parallelize(gc.workers, len(g.nodes), func(i int) {
+		n := g.nodes[i]
+		if len(n.meta.OwnerReferences) == 0 || n.beingDeleted() {
+			return
+		}
+		if err := gc.attemptToDelete(g, n); err != nil {
+			log.Printf("Error collecting %s: %v", n, err)
+		}
+	})
+}
+
+func (gc *GarbageCollector) buildGraph() (*graph, error) {
+	g := &graph{uids: map[string]*node{}}
+	for i := range resources {
+		metas, err := gc.listers.ListMetadata(resources[i].plural)
+		if err != nil {
+			return nil, err
+		}
+		for _, meta := range metas {
+			n := &node{resource: &resources[i], meta: meta}
+			g.nodes = append(g.nodes, n)
+			g.uids[meta.UID] = n
+		}
+	}
+	for _, n := range g.nodes {
+		for _, ref := range n.meta.OwnerReferences {
+			if owner := g.owner(n, ref); owner != nil {
+				owner.dependents = append(owner.dependents, n)
+			}
+		}
+	}
+	return g, nil
+}
+
+// owner 返回 ref 指向的对象。owner 的 kind 必须一致，并且和 dependent 在同一个命名空间或者是集群级别的对象
+func (g *graph) owner(n *node, ref api.OwnerReference) *node {
+	owner, ok := g.uids[ref.UID]
+	if !ok || owner.resource.kind != ref.Kind || (!owner.resource.clusterScoped && owner.meta.Namespace != n.meta.Namespace) {
+		return nil
+	}
+	return owner
+}
+
+// attemptToDelete 检查对象的 owners：还有存在的 owner 时只去掉指向已删除 owner 的引用；
+// owner 都不存在或者都在以 Foreground 方式删除时删除这个对象
+func (gc *GarbageCollector) attemptToDelete(g *graph, n *node) error {
+	var solid, dangling, waiting []api.OwnerReference
+	for _, ref := range n.meta.OwnerReferences {
+		owner := g.owner(n, ref)
+		switch {
+		case owner == nil && gc.ownerAbsent(n, ref):
+			dangling = append(dangling, ref)
+		case owner != nil && owner.waitingForDependents():
+			waiting = append(waiting, ref)
+		default:
+			solid = append(solid, ref)
+		}
+	}
+	if len(dangling) == 0 && len(waiting) == 0 {
+		return nil
+	}
+	if len(solid) > 0 {
+		//还有其它 owner 时不能删除，只去掉已经不存在和正在删除的 owner，Foreground 删除的 owner 不必再等它
+		meta := n.meta
+		meta.OwnerReferences = solid
+		if err := gc.client.UpdateMetadata(n.resource.plural, &meta); err != nil {
+			return err
+		}
+		log.Printf("Removed %d owner references from %s, it still has owner %s %s", len(dangling)+len(waiting), n, solid[0].Kind, solid[0].Name)
+		return nil
+	}
+	//owner 在 Foreground 删除时，有自己 dependents 的对象也用 Foreground 删除，让删除一层层地等待
+	policy := api.DeletePropagationBackground
+	if len(waiting) > 0 && len(n.dependents) > 0 {
+		policy = api.DeletePropagationForeground
+	}
+	if err := gc.client.DeleteWithPropagation(n.resource.plural, n.meta.Namespace, n.meta.Name, policy); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil
+		}
+		return err
+	}
+	log.Printf("Deleted %s with propagation %s because its owners are gone or being deleted", n, policy)
+	return nil
+}
+
+// ownerAbsent 在图里找不到 owner 时向 API server 确认。不在 resources 里的 kind 一律认为存在，避免误删
+func (gc *GarbageCollector) ownerAbsent(n *node, ref api.OwnerReference) bool {
+	r, ok := gc.kinds[ref.Kind]
+	if !ok {
+		return false
+	}
+	namespace := n.meta.Namespace
+	if r.clusterScoped {
+		namespace = ""
+	}
+	meta, err := gc.client.GetMetadata(r.plural, namespace, ref.Name)
+	if err != nil {
+		return strings.Contains(err.Error(), "not found")
+	}
+	return meta.UID != ref.UID
+}
+
+// orphanDependents 去掉 dependents 指向 owner 的引用，然后移除 owner 的 orphan finalizer
+func (gc *GarbageCollector) orphanDependents(owner *node) error {
+	for _, dep := range owner.dependents {
+		meta := dep.meta
+		meta.OwnerReferences = nil
+		for _, ref := range dep.meta.OwnerReferences {
+			if ref.UID != owner.meta.UID {
+				meta.OwnerReferences = append(meta.OwnerReferences, ref)
+			}
+		}
+		if err := gc.client.UpdateMetadata(dep.resource.plural, &meta); err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				continue
+			}
+			return err
+		}
+		log.Printf("Orphaned %s from %s", dep, owner)
+	}
+	return gc.removeFinalizer(owner, api.FinalizerOrphanDependents)
+}
+
+// processDeletingDependents 在 blockOwnerDeletion 的 dependents 都删除之后移除 owner 的 foregroundDeletion finalizer。
+// dependents 本身由 attemptToDelete 删除
+func (gc *GarbageCollector) processDeletingDependents(owner *node) error {
+	for _, dep := range owner.dependents {
+		for _, ref := range dep.meta.OwnerReferences {
+			if ref.UID == owner.meta.UID && ref.BlockOwnerDeletion {
+				return nil
+			}
+		}
+	}
+	return gc.removeFinalizer(owner, api.FinalizerDeleteDependents)
+}
+
+func (gc *GarbageCollector) removeFinalizer(n *node, finalizer string) error {
+	meta := n.meta
+	meta.Finalizers = append([]string(nil), n.meta.Finalizers...)
+	meta.RemoveFinalizer(finalizer)
+	if err := gc.client.UpdateMetadata(n.resource.plural, &meta); err != nil {
+		return err
+	}
+	log.Printf("Removed finalizer %s from %s", finalizer, n)
+	return nil
+}
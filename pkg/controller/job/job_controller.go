@@ -0,0 +1,172 @@
+// Package job contains the controller that runs Jobs to completion by
+// creating pods until enough of them have succeeded.
+package job
+
+import (
+	"fmt"
+	"log"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/controller"
+	"reflect"
+	"time"
+)
+
+const controllerKind = "Job"
+
+type JobController struct {
+	client  *api.Client
+	listers controller.Listers
+	// workers 是同时同步的对象数量
+	workers int
+}
+
+func NewJobController(client *api.Client, listers controller.Listers, workers int) *JobController {
+	return &JobController{client: client, listers: listers, workers: workers}
+}
+
+// Sync 对每个 Job 统计成功和失败的 pod，补足正在运行的 pod，判断 Job 是否完成、失败或者到期需要删除
+func (jc *JobController) Sync() {
+	jobs, err := jc.listers.ListJobs()
+	if err != nil {
+		log.Printf("Error listing jobs: %v", err)
+		return
+	}
+	pods, err := jc.listers.ListPods()
+	if err != nil {
+		log.Printf("Error listing pods: %v", err)
+		return
+	}
+	controller.Parallelize(jc.workers, len(jobs), func(i int) {
+		if err := jc.syncJob(&jobs[i], pods); err != nil {
+			log.Printf("Error syncing job %s/%s: %v", jobs[i].Namespace, jobs[i].Name, err)
+		}
+	})
+}
+
+func (jc *JobController) syncJob(job *api.Job, allPods []api.Pod) error {
+	if job.DeletionTimestamp != nil {
+		return nil
+	}
+	now := time.Now()
+	var active, succeeded, failed []api.Pod
+	restarts := 0
+	for _, pod := range allPods {
+		if pod.Namespace != job.Namespace || !controller.IsControlledBy(&pod.ObjectMeta, job.UID) {
+			continue
+		}
+		restarts += pod.RestartCount
+		switch {
+		case pod.Phase == api.PodSucceeded:
+			succeeded = append(succeeded, pod)
+		case pod.Phase == api.PodFailed:
+			failed = append(failed, pod)
+		case api.IsPodActive(&pod):
+			active = append(active, pod)
+		}
+	}
+
+	if finished, _ := api.IsJobFinished(job); finished {
+		//结束的 Job 不再创建 pod，只需要等 TTL 到期后删除
+		return jc.deleteIfExpired(job, now)
+	}
+
+	status := api.JobStatus{
+		StartTime:      job.Status.StartTime,
+		CompletionTime: job.Status.CompletionTime,
+		Succeeded:      len(succeeded),
+		Failed:         len(failed) + restarts,
+		Conditions:     job.Status.Conditions,
+	}
+	if status.StartTime == nil {
+		status.StartTime = &now
+	}
+	completions, parallelism, backoffLimit := *job.Spec.Completions, *job.Spec.Parallelism, *job.Spec.BackoffLimit
+
+	switch {
+	case status.Failed > backoffLimit:
+		status.Conditions = append(status.Conditions, newCondition(api.JobFailed, api.JobReasonBackoffLimitExceeded,
+			fmt.Sprintf("Job has reached the specified backoff limit of %d", backoffLimit), now))
+	case job.Spec.ActiveDeadlineSeconds != nil && now.Sub(*status.StartTime) >= time.Duration(*job.Spec.ActiveDeadlineSeconds)*time.Second:
+		status.Conditions = append(status.Conditions, newCondition(api.JobFailed, api.JobReasonDeadlineExceeded,
+			fmt.Sprintf("Job was active longer than specified deadline of %ds", *job.Spec.ActiveDeadlineSeconds), now))
+	case status.Succeeded >= completions:
+		status.Conditions = append(status.Conditions, newCondition(api.JobComplete, "", "", now))
+		status.CompletionTime = &now
+	}
+
+	if finished, condType := isFinished(&status); finished {
+		//失败的 Job 要停止还在运行的 pod
+		for _, pod := range active {
+			if err := jc.client.DeletePod(pod.Namespace, pod.Name); err != nil {
+				return err
+			}
+		}
+		log.Printf("Job %s/%s finished: %s (succeeded %d, failed %d)", job.Namespace, job.Name, condType, status.Succeeded, status.Failed)
+		active = nil
+	} else {
+		var err error
+		if active, err = jc.manageActivePods(job, active, min(parallelism, completions-status.Succeeded)); err != nil {
+			return err
+		}
+	}
+	status.Active = len(active)
+
+	if reflect.DeepEqual(status, job.Status) {
+		return nil
+	}
+	job.Status = status
+	_, err := jc.client.UpdateJobStatus(job)
+	return err
+}
+
+// manageActivePods 让正在运行的 pod 数量等于 want，返回调整之后正在运行的 pod
+func (jc *JobController) manageActivePods(job *api.Job, active []api.Pod, want int) ([]api.Pod, error) {
+	if want < 0 {
+		want = 0
+	}
+	if len(active) > want {
+		controller.SortPodsForDeletion(active)
+		for _, pod := range active[:len(active)-want] {
+			if err := jc.client.DeletePod(pod.Namespace, pod.Name); err != nil {
+				return active, err
+			}
+			log.Printf("Deleted pod %s/%s to reduce parallelism of job %s", pod.Namespace, pod.Name, job.Name)
+		}
+		return active[len(active)-want:], nil
+	}
+	ref := controller.NewControllerRef(controllerKind, &job.ObjectMeta)
+	for len(active) < want {
+		pod := controller.PodFromTemplate(&job.Spec.Template, job.Namespace, job.Name+"-", ref)
+		created, err := jc.client.CreatePod(job.Namespace, pod)
+		if err != nil {
+			return active, err
+		}
+		log.Printf("Created pod %s/%s for job %s", created.Namespace, created.Name, job.Name)
+		active = append(active, *created)
+	}
+	return active, nil
+}
+
+// deleteIfExpired 在 ttlSecondsAfterFinished 到期后删除 Job，它的 pod 随后由垃圾回收器删除
+func (jc *JobController) deleteIfExpired(job *api.Job, now time.Time) error {
+	finishTime := api.JobFinishTime(job)
+	if job.Spec.TTLSecondsAfterFinished == nil || finishTime == nil {
+		return nil
+	}
+	if now.Before(finishTime.Add(time.Duration(*job.Spec.TTLSecondsAfterFinished) * time.Second)) {
+		return nil
+	}
+	if err := jc.client.DeleteJob(job.Namespace, job.Name); err != nil {
+		return err
+	}
+	log.Printf("Deleted job %s/%s %ds after it finished", job.Namespace, job.Name, *job.Spec.TTLSecondsAfterFinished)
+	return nil
+}
+
+func newCondition(condType api.JobConditionType, reason, message string, now time.Time) api.JobCondition {
+	return api.JobCondition{Type: condType, Status: api.ConditionTrue, Reason: reason, Message: message, LastTransitionTime: &now}
+}
+
+func isFinished(status *api.JobStatus) (bool, api.JobConditionType) {
+	return api.IsJobFinished(&api.Job{Status: *status})
+}
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"mini-k8s/pkg/api"
+	"sync"
+)
+
+// Listers 是 controller 读取对象的来源。controller-manager 里的 controller 共享 informer 的本地缓存，
+// 返回的对象归调用方所有，可以修改
+type Listers interface {
+	ListPods() ([]api.Pod, error)
+	ListNodes() ([]api.Node, error)
+	ListNamespaces() ([]api.Namespace, error)
+	ListReplicaSets() ([]api.ReplicaSet, error)
+	ListDeployments() ([]api.Deployment, error)
+	ListJobs() ([]api.Job, error)
+	ListCronJobs() ([]api.CronJob, error)
+	ListDaemonSets() ([]api.DaemonSet, error)
+	ListStatefulSets() ([]api.StatefulSet, error)
+	ListControllerRevisions() ([]api.ControllerRevision, error)
+	ListServices() ([]api.Service, error)
+	ListEndpoints() ([]api.Endpoints, error)
+	ListPersistentVolumes() ([]api.PersistentVolume, error)
+	ListPersistentVolumeClaims() ([]api.PersistentVolumeClaim, error)
+	ListStorageClasses() ([]api.StorageClass, error)
+	ListHorizontalPodAutoscalers() ([]api.HorizontalPodAutoscaler, error)
+	ListPodDisruptionBudgets() ([]api.PodDisruptionBudget, error)
+	// ListMetadata 按资源的复数名返回所有命名空间里对象的元数据
+	ListMetadata(resource string) ([]api.ObjectMeta, error)
+}
+
+// Parallelize 用 workers 个 goroutine 对 0 到 pieces-1 分别调用 do，全部完成后返回。
+// controller 用它并发地同步互不相关的对象，workers 小于 1 时按 1 处理
+func Parallelize(workers, pieces int, do func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > pieces {
+		workers = pieces
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				do(i)
+			}
+		}()
+	}
+	for i := 0; i < pieces; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
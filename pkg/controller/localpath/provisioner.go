@@ -0,0 +1,265 @@
+// Package localpath contains a dynamic provisioner that creates
+// PersistentVolumes backed by a directory on one node for claims of
+// StorageClasses whose provisioner is ProvisionerName, and deletes the
+// directory again when such a volume with reclaim policy Delete is released.
+package localpath
+
+import (
+	"fmt"
+	"log"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/controller"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ProvisionerName 是 StorageClass 的 provisioner 字段使用这个 provisioner 时的值
+const ProvisionerName = "mini-k8s.io/local-path"
+
+const (
+	// ParameterBasePath 是 StorageClass 的参数，卷的目录创建在节点的这个目录下
+	ParameterBasePath = "basePath"
+	// DefaultBasePath 是没有设置 basePath 时使用的目录
+	DefaultBasePath = "/opt/local-path-provisioner"
+)
+
+// helperPodPrefix 是删除卷目录的 pod 的名字前缀，后面是卷的名字
+const helperPodPrefix = "helper-delete-"
+
+type LocalPathProvisioner struct {
+	client  *api.Client
+	listers controller.Listers
+}
+
+// NewLocalPathProvisioner 返回 provisioner。卷的目录由 kubelet 在第一个使用它的 pod 启动时创建，
+// 删除目录时在卷所在的节点上运行一个 pod
+func NewLocalPathProvisioner(client *api.Client, listers controller.Listers) *LocalPathProvisioner {
+	return &LocalPathProvisioner{client: client, listers: listers}
+}
+
+// Sync 为 binder 交给这个 provisioner 的 PVC 创建卷，再删除回收策略是 Delete 的 Released 卷
+func (p *LocalPathProvisioner) Sync() {
+	volumes, err := p.listers.ListPersistentVolumes()
+	if err != nil {
+		log.Printf("Error listing persistent volumes: %v", err)
+		return
+	}
+	claims, err := p.listers.ListPersistentVolumeClaims()
+	if err != nil {
+		log.Printf("Error listing persistent volume claims: %v", err)
+		return
+	}
+	classes, err := p.listers.ListStorageClasses()
+	if err != nil {
+		log.Printf("Error listing storage classes: %v", err)
+		return
+	}
+	nodes, err := p.listers.ListNodes()
+	if err != nil {
+		log.Printf("Error listing nodes: %v", err)
+		return
+	}
+	pods, err := p.listers.ListPods()
+	if err != nil {
+		log.Printf("Error listing pods: %v", err)
+		return
+	}
+	classesByName := map[string]*api.StorageClass{}
+	for i := range classes {
+		if classes[i].Provisioner == ProvisionerName {
+			classesByName[classes[i].Name] = &classes[i]
+		}
+	}
+	volumesByName := map[string]*api.PersistentVolume{}
+	for i := range volumes {
+		volumesByName[volumes[i].Name] = &volumes[i]
+	}
+	for i := range claims {
+		pvc := &claims[i]
+		if pvc.Annotations[api.AnnStorageProvisioner] != ProvisionerName || pvc.DeletionTimestamp != nil || pvc.Spec.VolumeName != "" {
+			continue
+		}
+		class := classesByName[pvc.ClassName()]
+		if class == nil {
+			log.Printf("Storage class %q of claim %s/%s does not use provisioner %s", pvc.ClassName(), pvc.Namespace, pvc.Name, ProvisionerName)
+			continue
+		}
+		if volumesByName[volumeName(pvc)] != nil {
+			//卷已经创建，等 binder 绑定
+			continue
+		}
+		if err := p.provision(pvc, class, nodes, volumes); err != nil {
+			log.Printf("Error provisioning a volume for claim %s/%s: %v", pvc.Namespace, pvc.Name, err)
+		}
+	}
+	podsByName := map[string]*api.Pod{}
+	for i := range pods {
+		if pods[i].Namespace == api.NamespaceSystem && strings.HasPrefix(pods[i].Name, helperPodPrefix) {
+			podsByName[pods[i].Name] = &pods[i]
+		}
+	}
+	for i := range volumes {
+		pv := &volumes[i]
+		if pv.Annotations[api.AnnDynamicallyProvisioned] != ProvisionerName || pv.DeletionTimestamp != nil ||
+			pv.Status.Phase != api.VolumeReleased || pv.Spec.PersistentVolumeReclaimPolicy != api.PersistentVolumeReclaimDelete {
+			continue
+		}
+		if err := p.deleteVolume(pv, podsByName[helperPodPrefix+pv.Name]); err != nil {
+			log.Printf("Error deleting persistent volume %s: %v", pv.Name, err)
+		}
+	}
+}
+
+// volumeName 是为 PVC 创建的卷的名字，同一个 PVC 总是得到同一个名字，重复创建会失败而不是多出一个卷
+func volumeName(pvc *api.PersistentVolumeClaim) string {
+	return "pvc-" + pvc.UID
+}
+
+// provision 在 PVC 选定的节点上创建卷，Immediate 的 PVC 选择已有本地卷最少的就绪节点。
+// 卷通过 claimRef 预留给这个 PVC，其它 PVC 不会绑定它
+func (p *LocalPathProvisioner) provision(pvc *api.PersistentVolumeClaim, class *api.StorageClass, nodes []api.Node, volumes []api.PersistentVolume) error {
+	//目录只在一个节点上，不能被多个节点使用
+	for _, mode := range pvc.Spec.AccessModes {
+		if mode != api.ReadWriteOnce {
+			return fmt.Errorf("only %s is supported, claim requests %s", api.ReadWriteOnce, mode)
+		}
+	}
+	node := pvc.Annotations[api.AnnSelectedNode]
+	if node == "" {
+		if class.VolumeBindingMode == api.VolumeBindingWaitForFirstConsumer {
+			return nil
+		}
+		node = pickNode(nodes, volumes)
+		if node == "" {
+			return fmt.Errorf("no ready node to create the volume on")
+		}
+	}
+	basePath := class.Parameters[ParameterBasePath]
+	if basePath == "" {
+		basePath = DefaultBasePath
+	}
+	if !path.IsAbs(basePath) {
+		return fmt.Errorf("parameter %s %q of storage class %s must be an absolute path", ParameterBasePath, basePath, class.Name)
+	}
+	name := volumeName(pvc)
+	pv := &api.PersistentVolume{
+		ObjectMeta: api.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{api.AnnDynamicallyProvisioned: ProvisionerName},
+		},
+		Spec: api.PersistentVolumeSpec{
+			//目录的大小没有限制，容量只用来和 PVC 的请求比较
+			Capacity:                      pvc.Spec.Resources.Requests.DeepCopy(),
+			AccessModes:                   []api.PersistentVolumeAccessMode{api.ReadWriteOnce},
+			PersistentVolumeReclaimPolicy: class.ReclaimPolicy,
+			StorageClassName:              class.Name,
+			ClaimRef:                      &api.ObjectReference{Kind: "PersistentVolumeClaim", Namespace: pvc.Namespace, Name: pvc.Name, UID: pvc.UID},
+			Local:                         &api.LocalVolumeSource{Path: path.Join(basePath, name+"_"+pvc.Namespace+"_"+pvc.Name)},
+			NodeAffinity:                  &api.VolumeNodeAffinity{Required: map[string]string{api.LabelHostname: node}},
+		},
+	}
+	if _, err := p.client.CreatePersistentVolume(pv); err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			return nil
+		}
+		return err
+	}
+	log.Printf("Created persistent volume %s at %s on node %s for claim %s/%s", name, pv.Spec.Local.Path, node, pvc.Namespace, pvc.Name)
+	return nil
+}
+
+// pickNode 返回没有 NoSchedule 污点的就绪节点里，这个 provisioner 创建的卷最少的那个
+func pickNode(nodes []api.Node, volumes []api.PersistentVolume) string {
+	counts := map[string]int{}
+	for i := range volumes {
+		pv := &volumes[i]
+		if pv.Annotations[api.AnnDynamicallyProvisioned] == ProvisionerName && pv.Spec.NodeAffinity != nil {
+			counts[pv.Spec.NodeAffinity.Required[api.LabelHostname]]++
+		}
+	}
+	var candidates []string
+	for i := range nodes {
+		if nodes[i].Status != api.NodeReady {
+			continue
+		}
+		if _, ok := api.FindUntoleratedTaint(nodes[i].Taints, nil, api.TaintEffectNoSchedule); ok {
+			continue
+		}
+		candidates = append(candidates, nodes[i].Name)
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if counts[candidates[i]] != counts[candidates[j]] {
+			return counts[candidates[i]] < counts[candidates[j]]
+		}
+		return candidates[i] < candidates[j]
+	})
+	return candidates[0]
+}
+
+// deleteVolume 在卷所在的节点上运行一个 pod 删除卷的目录，pod 成功结束后删除卷。
+// pod 失败时把卷标记为 Failed，管理员处理之后可以去掉卷的 claimRef 让它重新可用，或者直接删除卷
+func (p *LocalPathProvisioner) deleteVolume(pv *api.PersistentVolume, helper *api.Pod) error {
+	if helper == nil {
+		return p.createHelperPod(pv)
+	}
+	switch helper.Phase {
+	case api.PodSucceeded:
+		if err := p.client.DeletePersistentVolume(pv.Name); err != nil && !strings.Contains(err.Error(), "not found") {
+			return err
+		}
+		log.Printf("Deleted persistent volume %s and its directory %s", pv.Name, pv.Spec.Local.Path)
+	case api.PodFailed:
+		pv.Status.Phase = api.VolumeFailed
+		pv.Status.Message = fmt.Sprintf("deleting directory %s failed: %s", pv.Spec.Local.Path, helper.Message)
+		if _, err := p.client.UpdatePersistentVolumeStatus(pv); err != nil {
+			return err
+		}
+		log.Printf("Failed to delete the directory of persistent volume %s, marked it as Failed", pv.Name)
+	default:
+		return nil
+	}
+	if err := p.client.DeletePod(helper.Namespace, helper.Name); err != nil && !strings.Contains(err.Error(), "not found") {
+		return err
+	}
+	return nil
+}
+
+// createHelperPod 创建删除卷目录的 pod。pod 通过 hostPath 挂载目录的父目录，只删除这个卷自己的目录
+func (p *LocalPathProvisioner) createHelperPod(pv *api.PersistentVolume) error {
+	node := ""
+	if pv.Spec.NodeAffinity != nil {
+		node = pv.Spec.NodeAffinity.Required[api.LabelHostname]
+	}
+	if node == "" || pv.Spec.Local == nil {
+		return fmt.Errorf("volume has no local path on a node")
+	}
+	parent, dir := path.Split(pv.Spec.Local.Path)
+	pod := &api.Pod{
+		ObjectMeta: api.ObjectMeta{Name: helperPodPrefix + pv.Name, Namespace: api.NamespaceSystem},
+		PodSpec: api.PodSpec{
+			Image:         "busybox",
+			Command:       []string{"sh", "-c", `rm -rf "$POD_VOLUMES/data/$VOLUME_DIR"`},
+			Env:           []api.EnvVar{{Name: "VOLUME_DIR", Value: dir}},
+			RestartPolicy: api.RestartPolicyNever,
+			Volumes: []api.Volume{{
+				Name:         "data",
+				VolumeSource: api.VolumeSource{HostPath: &api.HostPathVolumeSource{Path: parent, Type: api.HostPathDirectoryOrCreate}},
+			}},
+			//节点暂时失联时也不要驱逐，等它恢复之后把目录删掉
+			Tolerations: []api.Toleration{{Operator: api.TolerationOpExists}},
+		},
+		NodeName: node,
+	}
+	if _, err := p.client.CreatePod(api.NamespaceSystem, pod); err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			return nil
+		}
+		return err
+	}
+	log.Printf("Started pod %s/%s on node %s to delete the directory of persistent volume %s", pod.Namespace, pod.Name, node, pv.Name)
+	return nil
+}
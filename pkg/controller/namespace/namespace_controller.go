@@ -0,0 +1,138 @@
+// Package namespace contains the controller that empties terminating
+// namespaces and then finalizes them.
+package namespace
+
+import (
+	"log"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/controller"
+	"strings"
+)
+
+// NamespaceController 总是直接向 API server list，不读 informer 的缓存：
+// 缓存稍有滞后就可能在命名空间还没清空的时候移除 finalizer
+type NamespaceController struct {
+	client *api.Client
+	// workers 是同时清理的命名空间数量
+	workers int
+}
+
+func NewNamespaceController(client *api.Client, workers int) *NamespaceController {
+	return &NamespaceController{client: client, workers: workers}
+}
+
+// Sync 处理所有 Terminating 的命名空间：先删除里面的所有对象，等对象都消失之后再移除 kubernetes finalizer
+func (nc *NamespaceController) Sync() {
+	namespaces, err := nc.client.ListNamespaces()
+	if err != nil {
+		log.Printf("Error listing namespaces: %v", err)
+		return
+	}
+	controller.Parallelize(nc.workers, len(namespaces), func(i int) {
+		nc.syncNamespace(&namespaces[i])
+	})
+}
+
+func (nc *NamespaceController) syncNamespace(ns *api.Namespace) {
+	if ns.DeletionTimestamp == nil || !hasFinalizer(ns, api.FinalizerKubernetes) {
+		return
+	}
+	remaining, err := nc.deleteContent(ns.Name)
+	if err != nil {
+		log.Printf("Error deleting content of namespace %s: %v", ns.Name, err)
+		return
+	}
+	if remaining > 0 {
+		log.Printf("Namespace %s still has %d objects, waiting for them to be removed", ns.Name, remaining)
+		return
+	}
+	ns.Spec.Finalizers = removeFinalizer(ns.Spec.Finalizers, api.FinalizerKubernetes)
+	if err := nc.client.FinalizeNamespace(ns); err != nil {
+		log.Printf("Error finalizing namespace %s: %v", ns.Name, err)
+		return
+	}
+	log.Printf("Namespace %s is empty and has been finalized", ns.Name)
+}
+
+// resource 是命名空间里的一种资源
+type resource struct {
+	kind   string
+	plural string
+}
+
+// contentResources 是命名空间里所有的资源，命名空间清理时按这个顺序删除：
+// 先删除 controller，避免它们在 pod 被删掉后又重新创建；pod 停止之后再删除它们用到的 PVC、Secret 和 ConfigMap。
+// 对象都通过 API server 删除，删除 Service 时它分配的 ClusterIP 和 NodePort 也会被释放
+var contentResources = []resource{
+	{kind: "HorizontalPodAutoscaler", plural: "horizontalpodautoscalers"},
+	{kind: "CronJob", plural: "cronjobs"},
+	{kind: "Job", plural: "jobs"},
+	{kind: "DaemonSet", plural: "daemonsets"},
+	{kind: "StatefulSet", plural: "statefulsets"},
+	{kind: "ControllerRevision", plural: "controllerrevisions"},
+	{kind: "Deployment", plural: "deployments"},
+	{kind: "ReplicaSet", plural: "replicasets"},
+	{kind: "Pod", plural: "pods"},
+	{kind: "PodDisruptionBudget", plural: "poddisruptionbudgets"},
+	{kind: "Ingress", plural: "ingresses"},
+	{kind: "Service", plural: "services"},
+	{kind: "Endpoints", plural: "endpoints"},
+	{kind: "Lease", plural: "leases"},
+	{kind: "PersistentVolumeClaim", plural: "persistentvolumeclaims"},
+	{kind: "Secret", plural: "secrets"},
+	{kind: "ConfigMap", plural: "configmaps"},
+}
+
+// deleteContent 对命名空间里还没有被删除的对象发起删除，返回仍然存在的对象数量
+func (nc *NamespaceController) deleteContent(namespace string) (int, error) {
+	for _, r := range contentResources {
+		metas, err := nc.client.ListNamespacedMetadata(namespace, r.plural)
+		if err != nil {
+			return 0, err
+		}
+		for _, meta := range metas {
+			//已经在删除中的对象由 kubelet、垃圾回收器或者对应的 controller 负责清理
+			if meta.DeletionTimestamp != nil {
+				continue
+			}
+			err := nc.client.DeleteWithPropagation(r.plural, namespace, meta.Name, api.DeletePropagationBackground)
+			if err != nil {
+				//垃圾回收器可能已经删除了 owner 不存在的对象
+				if strings.Contains(err.Error(), "not found") {
+					continue
+				}
+				return 0, err
+			}
+			log.Printf("Deleted %s %s/%s for namespace termination", strings.ToLower(r.kind), namespace, meta.Name)
+		}
+	}
+	//没有调度过的 pod 和没有 finalizer 的对象会被立即删除，重新列一次才知道还剩多少
+	remaining := 0
+	for _, r := range contentResources {
+		metas, err := nc.client.ListNamespacedMetadata(namespace, r.plural)
+		if err != nil {
+			return 0, err
+		}
+		remaining += len(metas)
+	}
+	return remaining, nil
+}
+
+func hasFinalizer(ns *api.Namespace, finalizer api.FinalizerName) bool {
+	for _, f := range ns.Spec.Finalizers {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+func removeFinalizer(finalizers []api.FinalizerName, finalizer api.FinalizerName) []api.FinalizerName {
+	var out []api.FinalizerName
+	for _, f := range finalizers {
+		if f != finalizer {
+			out = append(out, f)
+		}
+	}
+	return out
+}
@@ -0,0 +1,134 @@
+// Package nodelifecycle contains the controller that marks nodes whose kubelet
+// stopped sending heartbeats as NotReady, taints nodes that are not ready and
+// evicts the pods that do not tolerate a node's NoExecute taints.
+package nodelifecycle
+
+import (
+	"log"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/controller"
+	"strings"
+	"time"
+)
+
+type NodeLifecycleController struct {
+	client  *api.Client
+	listers controller.Listers
+	// workers 是同时处理的节点数量
+	workers int
+	// gracePeriod 是节点多久没有心跳之后被认为失联
+	gracePeriod time.Duration
+}
+
+func NewNodeLifecycleController(client *api.Client, listers controller.Listers, workers int, gracePeriod time.Duration) *NodeLifecycleController {
+	return &NodeLifecycleController{client: client, listers: listers, workers: workers, gracePeriod: gracePeriod}
+}
+
+// Sync 根据心跳更新每个节点的状态和 not-ready/unreachable 污点，然后驱逐不容忍节点上 NoExecute 污点的 pod
+func (nc *NodeLifecycleController) Sync() {
+	nodes, err := nc.listers.ListNodes()
+	if err != nil {
+		log.Printf("Error listing nodes: %v", err)
+		return
+	}
+	if len(nodes) == 0 {
+		return
+	}
+	pods, err := nc.listers.ListPods()
+	if err != nil {
+		log.Printf("Error listing pods: %v", err)
+		return
+	}
+	podsByNode := map[string][]api.Pod{}
+	for _, pod := range pods {
+		if pod.NodeName != "" {
+			podsByNode[pod.NodeName] = append(podsByNode[pod.NodeName], pod)
+		}
+	}
+	now := time.Now()
+	controller.Parallelize(nc.workers, len(nodes), func(i int) {
+		node := &nodes[i]
+		if err := nc.monitorNode(node, now); err != nil {
+			log.Printf("Error updating node %s: %v", node.Name, err)
+			return
+		}
+		nc.evictPods(node, podsByNode[node.Name])
+	})
+}
+
+// monitorNode 在心跳超时的时候把节点标记为 NotReady，并让节点上的 not-ready/unreachable 污点和状态保持一致：
+// 失联的节点加 unreachable，kubelet 报告 NotReady 的节点加 not-ready，Ready 的节点两个都去掉
+func (nc *NodeLifecycleController) monitorNode(node *api.Node, now time.Time) error {
+	//kubelet 还没有上报过心跳时从节点创建的时间开始计算，只注册了节点却没有 kubelet 的节点同样会失联
+	probe := node.LastHeartbeatTime
+	if probe == nil {
+		probe = node.CreationTimestamp
+	}
+	unreachable := probe != nil && now.Sub(*probe) > nc.gracePeriod
+	status := node.Status
+	if unreachable {
+		status = api.NodeNotReady
+	}
+	var taints []api.Taint
+	for _, taint := range node.Taints {
+		if !isReadinessTaint(&taint) {
+			taints = append(taints, taint)
+		}
+	}
+	switch {
+	case unreachable:
+		taints = append(taints, api.Taint{Key: api.TaintNodeUnreachable, Effect: api.TaintEffectNoExecute})
+	case status == api.NodeNotReady:
+		taints = append(taints, api.Taint{Key: api.TaintNodeNotReady, Effect: api.TaintEffectNoExecute})
+	}
+	if status == node.Status && equalTaints(taints, node.Taints) {
+		return nil
+	}
+	if status != node.Status {
+		log.Printf("Node %s is now %s (last heartbeat at %s)", node.Name, status, probe.Format(time.RFC3339))
+	}
+	node.Status = status
+	node.Taints = taints
+	return nc.client.UpdateNode(node)
+}
+
+// evictPods 通过 eviction API 驱逐节点上不容忍节点 NoExecute 污点的 pod，由 controller 在其它节点上重新创建。
+// 失联节点上的 pod 不算健康，不受 PDB 限制；手动加上的 NoExecute 污点会遵守 PDB，被拒绝的 pod 下一次同步再试
+func (nc *NodeLifecycleController) evictPods(node *api.Node, pods []api.Pod) {
+	for i := range pods {
+		pod := &pods[i]
+		if !api.IsPodActive(pod) {
+			continue
+		}
+		taint, untolerated := api.FindUntoleratedTaint(node.Taints, pod.Tolerations, api.TaintEffectNoExecute)
+		if !untolerated {
+			continue
+		}
+		if err := nc.client.EvictPod(pod.Namespace, pod.Name); err != nil {
+			if strings.Contains(err.Error(), "disruption budget") {
+				log.Printf("Cannot evict pod %s/%s from node %s yet: %v", pod.Namespace, pod.Name, node.Name, err)
+			} else if !strings.Contains(err.Error(), "not found") {
+				log.Printf("Error evicting pod %s/%s from node %s: %v", pod.Namespace, pod.Name, node.Name, err)
+			}
+			continue
+		}
+		log.Printf("Evicted pod %s/%s from node %s because it does not tolerate taint %s", pod.Namespace, pod.Name, node.Name, taint)
+	}
+}
+
+// isReadinessTaint 判断污点是不是这个 controller 根据节点状态维护的污点
+func isReadinessTaint(taint *api.Taint) bool {
+	return taint.Effect == api.TaintEffectNoExecute && (taint.Key == api.TaintNodeNotReady || taint.Key == api.TaintNodeUnreachable)
+}
+
+func equalTaints(a, b []api.Taint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,341 @@
+// Package persistentvolume contains the controller that binds
+// PersistentVolumeClaims to PersistentVolumes, asks the StorageClass
+// provisioner for a volume when none fits, releases volumes whose claim
+// was deleted and removes the protection finalizer from unused claims.
+package persistentvolume
+
+import (
+	"fmt"
+	"log"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/controller"
+	"sort"
+	"time"
+)
+
+type PersistentVolumeController struct {
+	client  *api.Client
+	listers controller.Listers
+}
+
+// NewPersistentVolumeController 返回 binder。绑定时要保证一个卷只分给一个 PVC，所以它总是按顺序同步
+func NewPersistentVolumeController(client *api.Client, listers controller.Listers) *PersistentVolumeController {
+	return &PersistentVolumeController{client: client, listers: listers}
+}
+
+// Sync 先更新卷的状态，再给 Pending 的 PVC 找卷。绑定分几步写入：先写卷的 claimRef，
+// 再写 PVC 的 volumeName 和两边的 status；中途失败时下一次同步从卷的 claimRef 接着完成
+func (pc *PersistentVolumeController) Sync() {
+	volumes, err := pc.listers.ListPersistentVolumes()
+	if err != nil {
+		log.Printf("Error listing persistent volumes: %v", err)
+		return
+	}
+	claims, err := pc.listers.ListPersistentVolumeClaims()
+	if err != nil {
+		log.Printf("Error listing persistent volume claims: %v", err)
+		return
+	}
+	if len(volumes) == 0 && len(claims) == 0 {
+		return
+	}
+	pods, err := pc.listers.ListPods()
+	if err != nil {
+		log.Printf("Error listing pods: %v", err)
+		return
+	}
+	classes, err := pc.listers.ListStorageClasses()
+	if err != nil {
+		log.Printf("Error listing storage classes: %v", err)
+		return
+	}
+	nodes, err := pc.listers.ListNodes()
+	if err != nil {
+		log.Printf("Error listing nodes: %v", err)
+		return
+	}
+	classesByName := map[string]*api.StorageClass{}
+	for i := range classes {
+		classesByName[classes[i].Name] = &classes[i]
+	}
+	nodeLabels := map[string]map[string]string{}
+	for _, node := range nodes {
+		nodeLabels[node.Name] = node.Labels
+	}
+	claimsByKey := map[string]*api.PersistentVolumeClaim{}
+	for i := range claims {
+		claimsByKey[claims[i].Namespace+"/"+claims[i].Name] = &claims[i]
+	}
+	volumesByName := map[string]*api.PersistentVolume{}
+	for i := range volumes {
+		pv := &volumes[i]
+		volumesByName[pv.Name] = pv
+		if err := pc.syncVolume(pv, claimsByKey); err != nil {
+			log.Printf("Error syncing persistent volume %s: %v", pv.Name, err)
+		}
+	}
+	//按创建时间处理 PVC，先创建的先分到卷
+	sort.Slice(claims, func(i, j int) bool {
+		return creationTime(&claims[i].ObjectMeta).Before(creationTime(&claims[j].ObjectMeta))
+	})
+	for i := range claims {
+		pvc := &claims[i]
+		if pvc.DeletionTimestamp != nil {
+			pc.releaseProtection(pvc, pods)
+			continue
+		}
+		if err := pc.syncClaim(pvc, volumes, volumesByName, classesByName[pvc.ClassName()], nodeLabels); err != nil {
+			log.Printf("Error syncing persistent volume claim %s/%s: %v", pvc.Namespace, pvc.Name, err)
+		}
+	}
+}
+
+// syncVolume 根据 claimRef 指向的 PVC 是否还在、是否绑定了这个卷，把卷标记为 Available、Bound 或 Released
+func (pc *PersistentVolumeController) syncVolume(pv *api.PersistentVolume, claims map[string]*api.PersistentVolumeClaim) error {
+	if pv.DeletionTimestamp != nil {
+		return nil
+	}
+	ref := pv.Spec.ClaimRef
+	if ref == nil {
+		//管理员去掉 Released 卷的 claimRef 表示卷已经清理好，可以重新绑定
+		return pc.setVolumePhase(pv, api.VolumeAvailable, "")
+	}
+	//claimRef 没有 UID 表示卷预留给一个还没有绑定的 PVC
+	if ref.UID == "" {
+		return nil
+	}
+	pvc := claims[ref.Namespace+"/"+ref.Name]
+	if pvc == nil || pvc.UID != ref.UID {
+		return pc.releaseVolume(pv, fmt.Sprintf("claim %s/%s was deleted", ref.Namespace, ref.Name))
+	}
+	if pvc.Spec.VolumeName == pv.Name {
+		return pc.setVolumePhase(pv, api.VolumeBound, "")
+	}
+	if pvc.Spec.VolumeName != "" {
+		//provisioner 为 PVC 创建的卷还没有绑定时，PVC 已经绑定了别的卷
+		return pc.releaseVolume(pv, fmt.Sprintf("claim %s/%s is bound to volume %s", ref.Namespace, ref.Name, pvc.Spec.VolumeName))
+	}
+	return nil
+}
+
+// releaseVolume 把不再被 PVC 使用的卷标记为 Released，回收策略是 Delete 的卷接着由创建它的 provisioner 删除。
+// 不是动态创建的卷没有 provisioner 可以删除，标记为 Failed 等管理员处理
+func (pc *PersistentVolumeController) releaseVolume(pv *api.PersistentVolume, reason string) error {
+	if pv.Status.Phase == api.VolumeReleased || pv.Status.Phase == api.VolumeFailed {
+		return nil
+	}
+	if pv.Spec.PersistentVolumeReclaimPolicy == api.PersistentVolumeReclaimDelete && pv.Annotations[api.AnnDynamicallyProvisioned] == "" {
+		log.Printf("Persistent volume %s is no longer used (%s) but was not dynamically provisioned, cannot delete it", pv.Name, reason)
+		return pc.setVolumePhase(pv, api.VolumeFailed, reason+", no provisioner can delete a volume that was not dynamically provisioned")
+	}
+	log.Printf("Persistent volume %s is no longer used (%s), reclaim policy is %s", pv.Name, reason, pv.Spec.PersistentVolumeReclaimPolicy)
+	return pc.setVolumePhase(pv, api.VolumeReleased, reason)
+}
+
+func (pc *PersistentVolumeController) setVolumePhase(pv *api.PersistentVolume, phase api.PersistentVolumePhase, message string) error {
+	if pv.Status.Phase == phase && pv.Status.Message == message {
+		return nil
+	}
+	pv.Status.Phase = phase
+	pv.Status.Message = message
+	updated, err := pc.client.UpdatePersistentVolumeStatus(pv)
+	if err != nil {
+		return err
+	}
+	*pv = *updated
+	log.Printf("Persistent volume %s is now %s", pv.Name, phase)
+	return nil
+}
+
+// syncClaim 给 PVC 找一个卷并完成绑定，找不到时请求 class 的 provisioner 创建一个；已经绑定的 PVC 检查卷是否还在。
+// class 为 nil 表示 PVC 不使用 StorageClass 或者 class 还没有创建
+func (pc *PersistentVolumeController) syncClaim(pvc *api.PersistentVolumeClaim, volumes []api.PersistentVolume, volumesByName map[string]*api.PersistentVolume,
+	class *api.StorageClass, nodeLabels map[string]map[string]string) error {
+	if pvc.Spec.VolumeName != "" {
+		pv := volumesByName[pvc.Spec.VolumeName]
+		if pv == nil {
+			if pvc.Status.Phase == api.ClaimBound {
+				log.Printf("Volume %s of claim %s/%s no longer exists", pvc.Spec.VolumeName, pvc.Namespace, pvc.Name)
+				return pc.setClaimStatus(pvc, nil, api.ClaimLost)
+			}
+			//预先指定的卷还没有创建
+			return nil
+		}
+		if ref := pv.Spec.ClaimRef; ref != nil && ref.UID != "" && ref.UID != pvc.UID {
+			//卷已经绑定给了别的 PVC，这个 PVC 一直 Pending
+			return nil
+		}
+		if pv.Spec.ClaimRef == nil || pv.Spec.ClaimRef.UID == "" {
+			if err := checkVolumeFits(pv, pvc); err != nil {
+				return err
+			}
+		}
+		return pc.bind(pvc, pv)
+	}
+	//WaitForFirstConsumer 的 PVC 等调度器选好节点，只绑定那个节点上的卷
+	var labels map[string]string
+	if class != nil && class.VolumeBindingMode == api.VolumeBindingWaitForFirstConsumer {
+		node := pvc.Annotations[api.AnnSelectedNode]
+		if node == "" {
+			return nil
+		}
+		if labels = nodeLabels[node]; labels == nil {
+			return fmt.Errorf("selected node %s does not exist", node)
+		}
+	}
+	pv := findVolume(pvc, volumes, labels)
+	if pv == nil {
+		return pc.requestProvisioning(pvc, class)
+	}
+	return pc.bind(pvc, pv)
+}
+
+// requestProvisioning 在 PVC 上记录应该为它创建卷的 provisioner，provisioner 创建的卷预留给这个 PVC，下一次同步时绑定
+func (pc *PersistentVolumeController) requestProvisioning(pvc *api.PersistentVolumeClaim, class *api.StorageClass) error {
+	if class == nil || pvc.Annotations[api.AnnStorageProvisioner] == class.Provisioner {
+		return nil
+	}
+	if pvc.Annotations == nil {
+		pvc.Annotations = map[string]string{}
+	}
+	pvc.Annotations[api.AnnStorageProvisioner] = class.Provisioner
+	updated, err := pc.client.UpdatePersistentVolumeClaim(pvc)
+	if err != nil {
+		return fmt.Errorf("requesting a volume from provisioner %s: %w", class.Provisioner, err)
+	}
+	*pvc = *updated
+	log.Printf("No persistent volume matches claim %s/%s, waiting for provisioner %s to create one", pvc.Namespace, pvc.Name, class.Provisioner)
+	return nil
+}
+
+// bind 把卷和 PVC 互相指向对方，两边都标记为 Bound。每一步都检查是否已经完成，可以重复调用
+func (pc *PersistentVolumeController) bind(pvc *api.PersistentVolumeClaim, pv *api.PersistentVolume) error {
+	if ref := pv.Spec.ClaimRef; ref == nil || ref.UID != pvc.UID {
+		pv.Spec.ClaimRef = &api.ObjectReference{Kind: "PersistentVolumeClaim", Namespace: pvc.Namespace, Name: pvc.Name, UID: pvc.UID}
+		updated, err := pc.client.UpdatePersistentVolume(pv)
+		if err != nil {
+			return fmt.Errorf("binding volume %s: %w", pv.Name, err)
+		}
+		*pv = *updated
+	}
+	if err := pc.setVolumePhase(pv, api.VolumeBound, ""); err != nil {
+		return err
+	}
+	if pvc.Spec.VolumeName != pv.Name {
+		pvc.Spec.VolumeName = pv.Name
+		updated, err := pc.client.UpdatePersistentVolumeClaim(pvc)
+		if err != nil {
+			return fmt.Errorf("binding claim to volume %s: %w", pv.Name, err)
+		}
+		*pvc = *updated
+	}
+	if pvc.Status.Phase == api.ClaimBound {
+		return nil
+	}
+	if err := pc.setClaimStatus(pvc, pv, api.ClaimBound); err != nil {
+		return err
+	}
+	log.Printf("Bound claim %s/%s to persistent volume %s", pvc.Namespace, pvc.Name, pv.Name)
+	return nil
+}
+
+// setClaimStatus 更新 PVC 的阶段，绑定时记录卷实际提供的容量和访问模式
+func (pc *PersistentVolumeController) setClaimStatus(pvc *api.PersistentVolumeClaim, pv *api.PersistentVolume, phase api.PersistentVolumeClaimPhase) error {
+	pvc.Status.Phase = phase
+	if pv != nil {
+		pvc.Status.AccessModes = pv.Spec.AccessModes
+		pvc.Status.Capacity = pv.Spec.Capacity.DeepCopy()
+	}
+	updated, err := pc.client.UpdatePersistentVolumeClaimStatus(pvc)
+	if err != nil {
+		return err
+	}
+	*pvc = *updated
+	return nil
+}
+
+// findVolume 返回可以绑定给 PVC 的最小的卷，预留给这个 PVC 的卷优先。nodeLabels 不为 nil 时只考虑这个节点可以使用的卷
+func findVolume(pvc *api.PersistentVolumeClaim, volumes []api.PersistentVolume, nodeLabels map[string]string) *api.PersistentVolume {
+	var best *api.PersistentVolume
+	var bestSize int64
+	for i := range volumes {
+		pv := &volumes[i]
+		if pv.DeletionTimestamp != nil || pv.Status.Phase != api.VolumeAvailable {
+			continue
+		}
+		if nodeLabels != nil && pv.Spec.NodeAffinity != nil && !api.MatchesNodeSelector(pv.Spec.NodeAffinity.Required, nodeLabels) {
+			continue
+		}
+		if ref := pv.Spec.ClaimRef; ref != nil {
+			if ref.Namespace != pvc.Namespace || ref.Name != pvc.Name || (ref.UID != "" && ref.UID != pvc.UID) {
+				continue
+			}
+			if checkVolumeFits(pv, pvc) == nil {
+				return pv
+			}
+			continue
+		}
+		if checkVolumeFits(pv, pvc) != nil {
+			continue
+		}
+		size, _ := pv.Spec.Capacity.Storage()
+		if best == nil || size < bestSize || (size == bestSize && pv.Name < best.Name) {
+			best, bestSize = pv, size
+		}
+	}
+	return best
+}
+
+// checkVolumeFits 检查卷的 storageClass、访问模式和容量满足 PVC 的要求
+func checkVolumeFits(pv *api.PersistentVolume, pvc *api.PersistentVolumeClaim) error {
+	if pv.Spec.StorageClassName != pvc.ClassName() {
+		return fmt.Errorf("volume %s has storage class %q, claim wants %q", pv.Name, pv.Spec.StorageClassName, pvc.ClassName())
+	}
+	if !api.HasAccessModes(pv.Spec.AccessModes, pvc.Spec.AccessModes) {
+		return fmt.Errorf("volume %s does not support access modes %v", pv.Name, pvc.Spec.AccessModes)
+	}
+	capacity, err := pv.Spec.Capacity.Storage()
+	if err != nil {
+		return err
+	}
+	request, err := pvc.Spec.Resources.Requests.Storage()
+	if err != nil {
+		return err
+	}
+	if capacity < request {
+		return fmt.Errorf("volume %s has %s, claim requests %s", pv.Name, pv.Spec.Capacity[api.ResourceStorage], pvc.Spec.Resources.Requests[api.ResourceStorage])
+	}
+	return nil
+}
+
+// releaseProtection 在删除中的 PVC 不再被任何没有结束的 pod 使用之后移除 pvc-protection finalizer，让它被真正删除
+func (pc *PersistentVolumeController) releaseProtection(pvc *api.PersistentVolumeClaim, pods []api.Pod) {
+	if !pvc.HasFinalizer(api.FinalizerPVCProtection) {
+		return
+	}
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Namespace != pvc.Namespace || pod.Phase == api.PodSucceeded || pod.Phase == api.PodFailed || pod.Phase == api.PodDeleted {
+			continue
+		}
+		for _, volume := range pod.Volumes {
+			if volume.PersistentVolumeClaim != nil && volume.PersistentVolumeClaim.ClaimName == pvc.Name {
+				log.Printf("Claim %s/%s is still used by pod %s, waiting before deleting it", pvc.Namespace, pvc.Name, pod.Name)
+				return
+			}
+		}
+	}
+	pvc.RemoveFinalizer(api.FinalizerPVCProtection)
+	if _, err := pc.client.UpdatePersistentVolumeClaim(pvc); err != nil {
+		log.Printf("Error removing protection of claim %s/%s: %v", pvc.Namespace, pvc.Name, err)
+		return
+	}
+	log.Printf("Claim %s/%s is no longer used, removed its protection", pvc.Namespace, pvc.Name)
+}
+
+func creationTime(meta *api.ObjectMeta) time.Time {
+	if meta.CreationTimestamp == nil {
+		return time.Time{}
+	}
+	return *meta.CreationTimestamp
+}
@@ -0,0 +1,166 @@
+package podautoscaler
+
+import (
+	"math"
+	"mini-k8s/pkg/api"
+	"time"
+)
+
+// normalizeDesiredReplicas 先用稳定窗口平滑按指标算出来的副本数，再按扩缩容策略限制这一次最多能变化多少，
+// 最后限制在 minReplicas 和 maxReplicas 之间。返回的 reason 和 message 用于 ScalingLimited condition
+func (a *HorizontalController) normalizeDesiredReplicas(hpa *api.HorizontalPodAutoscaler, current, proposal int, now time.Time) (int, string, string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	stabilized := a.stabilizeRecommendation(hpa, current, proposal, now)
+	return a.limitByBehavior(hpa, current, stabilized, now)
+}
+
+// stabilizeRecommendation 记录这一次的推荐值。扩容取扩容窗口内推荐值的最小值，缩容取缩容窗口内的最大值，
+// 所以只有在整个窗口里都需要更多（更少）副本时才会扩容（缩容）
+func (a *HorizontalController) stabilizeRecommendation(hpa *api.HorizontalPodAutoscaler, current, proposal int, now time.Time) int {
+	behavior := hpa.Spec.Behavior
+	upWindow := time.Duration(*behavior.ScaleUp.StabilizationWindowSeconds) * time.Second
+	downWindow := time.Duration(*behavior.ScaleDown.StabilizationWindowSeconds) * time.Second
+	longest := max(upWindow, downWindow)
+	up, down := proposal, proposal
+	var kept []timestampedRecommendation
+	for _, rec := range a.recommendations[hpa.UID] {
+		age := now.Sub(rec.timestamp)
+		if age < upWindow {
+			up = min(up, rec.replicas)
+		}
+		if age < downWindow {
+			down = max(down, rec.replicas)
+		}
+		if age < longest {
+			kept = append(kept, rec)
+		}
+	}
+	a.recommendations[hpa.UID] = append(kept, timestampedRecommendation{replicas: proposal, timestamp: now})
+	recommendation := current
+	if recommendation < up {
+		recommendation = up
+	}
+	if recommendation > down {
+		recommendation = down
+	}
+	return recommendation
+}
+
+func (a *HorizontalController) limitByBehavior(hpa *api.HorizontalPodAutoscaler, current, desired int, now time.Time) (int, string, string) {
+	minReplicas, maxReplicas := *hpa.Spec.MinReplicas, hpa.Spec.MaxReplicas
+	if desired > current {
+		limit := max(current, scaleUpLimit(current, a.scaleUpEvents[hpa.UID], hpa.Spec.Behavior.ScaleUp, now))
+		allowed, reason, message := maxReplicas, "TooManyReplicas", "the desired replica count is more than the maximum replica count"
+		if limit < maxReplicas {
+			allowed, reason, message = limit, "ScaleUpLimit", "the desired replica count is increasing faster than the maximum scale rate"
+		}
+		if desired > allowed {
+			return allowed, reason, message
+		}
+	} else if desired < current {
+		limit := min(current, scaleDownLimit(current, a.scaleDownEvents[hpa.UID], hpa.Spec.Behavior.ScaleDown, now))
+		allowed, reason, message := minReplicas, "TooFewReplicas", "the desired replica count is less than the minimum replica count"
+		if limit > minReplicas {
+			allowed, reason, message = limit, "ScaleDownLimit", "the desired replica count is decreasing faster than the maximum scale rate"
+		}
+		if desired < allowed {
+			return allowed, reason, message
+		}
+	}
+	if desired > maxReplicas {
+		return maxReplicas, "TooManyReplicas", "the desired replica count is more than the maximum replica count"
+	}
+	if desired < minReplicas {
+		return minReplicas, "TooFewReplicas", "the desired replica count is less than the minimum replica count"
+	}
+	return desired, "DesiredWithinRange", "the desired count is within the acceptable range"
+}
+
+// scaleUpLimit 返回策略允许扩容到的副本数。每个策略从它的周期开始时的副本数算起，
+// 周期内已经增加的副本要扣掉；selectPolicy 为 Max 时取允许最多的策略
+func scaleUpLimit(current int, events []timestampedScaleEvent, rules *api.HPAScalingRules, now time.Time) int {
+	if rules.SelectPolicy == api.DisabledPolicySelect {
+		return current
+	}
+	result := math.MinInt
+	if rules.SelectPolicy == api.MinChangePolicySelect {
+		result = math.MaxInt
+	}
+	for _, policy := range rules.Policies {
+		start := current - replicasChangedInPeriod(events, policy.PeriodSeconds, now)
+		var proposed int
+		if policy.Type == api.PodsScalingPolicy {
+			proposed = start + policy.Value
+		} else {
+			proposed = int(math.Ceil(float64(start) * (1 + float64(policy.Value)/100)))
+		}
+		if rules.SelectPolicy == api.MinChangePolicySelect {
+			result = min(result, proposed)
+		} else {
+			result = max(result, proposed)
+		}
+	}
+	return result
+}
+
+// scaleDownLimit 和 scaleUpLimit 一样，返回策略允许缩容到的副本数
+func scaleDownLimit(current int, events []timestampedScaleEvent, rules *api.HPAScalingRules, now time.Time) int {
+	if rules.SelectPolicy == api.DisabledPolicySelect {
+		return current
+	}
+	result := math.MaxInt
+	if rules.SelectPolicy == api.MinChangePolicySelect {
+		result = math.MinInt
+	}
+	for _, policy := range rules.Policies {
+		start := current + replicasChangedInPeriod(events, policy.PeriodSeconds, now)
+		var proposed int
+		if policy.Type == api.PodsScalingPolicy {
+			proposed = start - policy.Value
+		} else {
+			proposed = int(float64(start) * (1 - float64(policy.Value)/100))
+		}
+		if rules.SelectPolicy == api.MinChangePolicySelect {
+			result = max(result, proposed)
+		} else {
+			result = min(result, proposed)
+		}
+	}
+	return result
+}
+
+func replicasChangedInPeriod(events []timestampedScaleEvent, periodSeconds int, now time.Time) int {
+	period := time.Duration(periodSeconds) * time.Second
+	changed := 0
+	for _, event := range events {
+		if now.Sub(event.timestamp) < period {
+			changed += event.replicaChange
+		}
+	}
+	return changed
+}
+
+// recordScaleEvent 记录一次扩容或缩容，同时丢掉已经超出所有策略周期的记录
+func (a *HorizontalController) recordScaleEvent(uid string, current, desired int, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if desired > current {
+		a.scaleUpEvents[uid] = append(trimScaleEvents(a.scaleUpEvents[uid], now), timestampedScaleEvent{replicaChange: desired - current, timestamp: now})
+	} else {
+		a.scaleDownEvents[uid] = append(trimScaleEvents(a.scaleDownEvents[uid], now), timestampedScaleEvent{replicaChange: current - desired, timestamp: now})
+	}
+}
+
+// maxPolicyPeriod 是策略周期的上限，更早的记录不会再被用到
+const maxPolicyPeriod = 1800 * time.Second
+
+func trimScaleEvents(events []timestampedScaleEvent, now time.Time) []timestampedScaleEvent {
+	var kept []timestampedScaleEvent
+	for _, event := range events {
+		if now.Sub(event.timestamp) < maxPolicyPeriod {
+			kept = append(kept, event)
+		}
+	}
+	return kept
+}
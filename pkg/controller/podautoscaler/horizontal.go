@@ -0,0 +1,307 @@
+// Package podautoscaler contains the horizontal pod autoscaler controller.
+// It reads pod usage from the metrics API served by metrics-server and sets
+// the replica count of the Deployment or ReplicaSet targeted by each
+// HorizontalPodAutoscaler, smoothing the result with a stabilization window
+// and limiting how fast it may change with the scale-up and scale-down
+// policies.
+package podautoscaler
+
+import (
+	"fmt"
+	"log"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/controller"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+type HorizontalController struct {
+	client  *api.Client
+	listers controller.Listers
+	workers int
+	// syncPeriod 是同一个 HPA 两次计算之间至少间隔多久，和 metrics-server 的抓取间隔差不多即可
+	syncPeriod time.Duration
+
+	mu sync.Mutex
+	// 下面的状态按 HPA 的 UID 保存在内存里，controller-manager 重启之后从头开始记录
+	lastEvaluated   map[string]time.Time
+	recommendations map[string][]timestampedRecommendation
+	scaleUpEvents   map[string][]timestampedScaleEvent
+	scaleDownEvents map[string][]timestampedScaleEvent
+}
+
+// timestampedRecommendation 是某一次按指标算出来、还没有经过稳定窗口的副本数
+type timestampedRecommendation struct {
+	replicas  int
+	timestamp time.Time
+}
+
+// timestampedScaleEvent 是某一次调整副本数时增加或减少的数量
+type timestampedScaleEvent struct {
+	replicaChange int
+	timestamp     time.Time
+}
+
+func NewHorizontalController(client *api.Client, listers controller.Listers, workers int, syncPeriod time.Duration) *HorizontalController {
+	return &HorizontalController{
+		client:          client,
+		listers:         listers,
+		workers:         workers,
+		syncPeriod:      syncPeriod,
+		lastEvaluated:   map[string]time.Time{},
+		recommendations: map[string][]timestampedRecommendation{},
+		scaleUpEvents:   map[string][]timestampedScaleEvent{},
+		scaleDownEvents: map[string][]timestampedScaleEvent{},
+	}
+}
+
+// Sync 计算距离上次计算已经超过 syncPeriod、或者 spec 被修改过的 HPA
+func (a *HorizontalController) Sync() {
+	hpas, err := a.listers.ListHorizontalPodAutoscalers()
+	if err != nil {
+		log.Printf("Error listing horizontal pod autoscalers: %v", err)
+		return
+	}
+	now := time.Now()
+	var due []*api.HorizontalPodAutoscaler
+	a.mu.Lock()
+	live := map[string]bool{}
+	for i := range hpas {
+		hpa := &hpas[i]
+		live[hpa.UID] = true
+		if hpa.DeletionTimestamp != nil {
+			continue
+		}
+		last, ok := a.lastEvaluated[hpa.UID]
+		if ok && now.Sub(last) < a.syncPeriod && hpa.Status.ObservedGeneration == hpa.Generation {
+			continue
+		}
+		a.lastEvaluated[hpa.UID] = now
+		due = append(due, hpa)
+	}
+	//删除的 HPA 的记录不再需要
+	for uid := range a.lastEvaluated {
+		if !live[uid] {
+			delete(a.lastEvaluated, uid)
+			delete(a.recommendations, uid)
+			delete(a.scaleUpEvents, uid)
+			delete(a.scaleDownEvents, uid)
+		}
+	}
+	a.mu.Unlock()
+	if len(due) == 0 {
+		return
+	}
+
+	pods, err := a.listers.ListPods()
+	if err != nil {
+		log.Printf("Error listing pods: %v", err)
+		return
+	}
+	deployments, err := a.listers.ListDeployments()
+	if err != nil {
+		log.Printf("Error listing deployments: %v", err)
+		return
+	}
+	replicaSets, err := a.listers.ListReplicaSets()
+	if err != nil {
+		log.Printf("Error listing replicasets: %v", err)
+		return
+	}
+	metrics := &metricsCache{client: a.client, namespaces: map[string]namespaceMetrics{}}
+	controller.Parallelize(a.workers, len(due), func(i int) {
+		hpa := due[i]
+		if err := a.reconcile(hpa, pods, deployments, replicaSets, metrics, now); err != nil {
+			//失败原因记录在 condition 里，等下一个 syncPeriod 再重试
+			log.Printf("Error syncing horizontal pod autoscaler %s/%s: %v", hpa.Namespace, hpa.Name, err)
+		}
+	})
+}
+
+// metricsCache 让同一个命名空间的多个 HPA 在一次同步里只查询一次 metrics API
+type metricsCache struct {
+	client     *api.Client
+	mu         sync.Mutex
+	namespaces map[string]namespaceMetrics
+}
+
+type namespaceMetrics struct {
+	pods map[string]api.PodMetrics
+	err  error
+}
+
+func (m *metricsCache) podMetrics(namespace string) (map[string]api.PodMetrics, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if cached, ok := m.namespaces[namespace]; ok {
+		return cached.pods, cached.err
+	}
+	list, err := m.client.ListPodMetrics(namespace)
+	pods := map[string]api.PodMetrics{}
+	for _, pm := range list {
+		pods[pm.Name] = pm
+	}
+	m.namespaces[namespace] = namespaceMetrics{pods: pods, err: err}
+	return pods, err
+}
+
+// scaleTarget 是 HPA 调整的对象的副本数和选择器
+type scaleTarget struct {
+	replicas       int
+	statusReplicas int
+	selector       *api.LabelSelector
+	scale          func(replicas int) error
+}
+
+func (a *HorizontalController) scaleTargetFor(hpa *api.HorizontalPodAutoscaler, deployments []api.Deployment, replicaSets []api.ReplicaSet) (*scaleTarget, error) {
+	ref := hpa.Spec.ScaleTargetRef
+	switch ref.Kind {
+	case "Deployment":
+		for i := range deployments {
+			d := &deployments[i]
+			if d.Namespace == hpa.Namespace && d.Name == ref.Name && d.DeletionTimestamp == nil {
+				return &scaleTarget{replicas: d.Spec.Replicas, statusReplicas: d.Status.Replicas, selector: d.Spec.Selector, scale: func(replicas int) error {
+					updated := d.DeepCopy()
+					updated.Spec.Replicas = replicas
+					_, err := a.client.UpdateDeployment(updated)
+					return err
+				}}, nil
+			}
+		}
+	case "ReplicaSet":
+		for i := range replicaSets {
+			rs := &replicaSets[i]
+			if rs.Namespace == hpa.Namespace && rs.Name == ref.Name && rs.DeletionTimestamp == nil {
+				return &scaleTarget{replicas: rs.Spec.Replicas, statusReplicas: rs.Status.Replicas, selector: rs.Spec.Selector, scale: func(replicas int) error {
+					updated := rs.DeepCopy()
+					updated.Spec.Replicas = replicas
+					_, err := a.client.UpdateReplicaSet(updated)
+					return err
+				}}, nil
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported scale target kind %q", ref.Kind)
+	}
+	return nil, fmt.Errorf("%s %s/%s not found", ref.Kind, hpa.Namespace, ref.Name)
+}
+
+// reconcile 按指标算出副本数，经过稳定窗口和速率限制之后写到目标上，再更新 HPA 的 status
+func (a *HorizontalController) reconcile(hpa *api.HorizontalPodAutoscaler, allPods []api.Pod, deployments []api.Deployment, replicaSets []api.ReplicaSet, metrics *metricsCache, now time.Time) error {
+	updated := hpa.DeepCopy()
+	updated.Status.ObservedGeneration = hpa.Generation
+	status := &updated.Status
+
+	target, err := a.scaleTargetFor(hpa, deployments, replicaSets)
+	if err != nil {
+		setCondition(status, api.AbleToScale, api.ConditionFalse, "FailedGetScale", fmt.Sprintf("the HPA controller was unable to get the target's current scale: %v", err), now)
+		return a.updateStatus(hpa, updated)
+	}
+	status.CurrentReplicas = target.statusReplicas
+	current := target.replicas
+	if current == 0 {
+		//副本数被手动设成 0 时暂停自动扩缩容，改回非 0 之后恢复
+		setCondition(status, api.ScalingActive, api.ConditionFalse, "ScalingDisabled", "scaling is disabled since the replica count of the target is zero", now)
+		status.DesiredReplicas = 0
+		status.CurrentMetrics = nil
+		return a.updateStatus(hpa, updated)
+	}
+
+	var pods []api.Pod
+	for _, pod := range allPods {
+		if pod.Namespace == hpa.Namespace && target.selector.Matches(pod.Labels) {
+			pods = append(pods, pod)
+		}
+	}
+	proposal, statuses, err := a.computeReplicasForMetrics(hpa, current, pods, metrics)
+	if err != nil {
+		setCondition(status, api.ScalingActive, api.ConditionFalse, "FailedGetResourceMetric", fmt.Sprintf("the HPA was unable to compute the replica count: %v", err), now)
+		if updateErr := a.updateStatus(hpa, updated); updateErr != nil {
+			return updateErr
+		}
+		return err
+	}
+	setCondition(status, api.ScalingActive, api.ConditionTrue, "ValidMetricFound", "the HPA was able to successfully calculate a replica count from resource metrics", now)
+	status.CurrentMetrics = statuses
+
+	desired, reason, message := a.normalizeDesiredReplicas(hpa, current, proposal, now)
+	if reason == "DesiredWithinRange" {
+		setCondition(status, api.ScalingLimited, api.ConditionFalse, reason, message, now)
+	} else {
+		setCondition(status, api.ScalingLimited, api.ConditionTrue, reason, message, now)
+	}
+	if desired != current {
+		if err := target.scale(desired); err != nil {
+			setCondition(status, api.AbleToScale, api.ConditionFalse, "FailedUpdateScale", fmt.Sprintf("the HPA controller was unable to update the target scale: %v", err), now)
+			if updateErr := a.updateStatus(hpa, updated); updateErr != nil {
+				return updateErr
+			}
+			return err
+		}
+		a.recordScaleEvent(hpa.UID, current, desired, now)
+		log.Printf("Scaled %s %s/%s from %d to %d replicas for horizontal pod autoscaler %s", hpa.Spec.ScaleTargetRef.Kind, hpa.Namespace, hpa.Spec.ScaleTargetRef.Name, current, desired, hpa.Name)
+		status.LastScaleTime = &now
+		setCondition(status, api.AbleToScale, api.ConditionTrue, "SucceededRescale", fmt.Sprintf("the HPA controller was able to update the target scale to %d", desired), now)
+	} else {
+		setCondition(status, api.AbleToScale, api.ConditionTrue, "ReadyForNewScale", "recommended size matches current size", now)
+	}
+	status.DesiredReplicas = desired
+	return a.updateStatus(hpa, updated)
+}
+
+// computeReplicasForMetrics 取所有指标算出来的最大副本数。有指标拿不到时不缩容，因为拿不到的那个指标可能需要更多副本
+func (a *HorizontalController) computeReplicasForMetrics(hpa *api.HorizontalPodAutoscaler, current int, pods []api.Pod, metrics *metricsCache) (int, []api.MetricStatus, error) {
+	podMetrics, err := metrics.podMetrics(hpa.Namespace)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to get metrics from the metrics API: %w", err)
+	}
+	replicas := 0
+	var statuses []api.MetricStatus
+	var errs []string
+	for _, spec := range hpa.Spec.Metrics {
+		proposal, status, err := calculateResourceReplicas(spec.Resource, current, pods, podMetrics)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		statuses = append(statuses, *status)
+		if proposal > replicas {
+			replicas = proposal
+		}
+	}
+	if len(statuses) == 0 {
+		return 0, nil, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	if len(errs) > 0 && replicas < current {
+		replicas = current
+	}
+	return replicas, statuses, nil
+}
+
+func (a *HorizontalController) updateStatus(old, updated *api.HorizontalPodAutoscaler) error {
+	if reflect.DeepEqual(old.Status, updated.Status) {
+		return nil
+	}
+	_, err := a.client.UpdateHorizontalPodAutoscalerStatus(updated)
+	return err
+}
+
+// setCondition 设置 condition，状态变化时才更新 lastTransitionTime
+func setCondition(status *api.HorizontalPodAutoscalerStatus, condType api.HorizontalPodAutoscalerConditionType, condStatus api.ConditionStatus, reason, message string, now time.Time) {
+	for i := range status.Conditions {
+		c := &status.Conditions[i]
+		if c.Type != condType {
+			continue
+		}
+		if c.Status != condStatus {
+			c.LastTransitionTime = &now
+		}
+		c.Status, c.Reason, c.Message = condStatus, reason, message
+		return
+	}
+	status.Conditions = append(status.Conditions, api.HorizontalPodAutoscalerCondition{
+		Type: condType, Status: condStatus, Reason: reason, Message: message, LastTransitionTime: &now,
+	})
+}
@@ -0,0 +1,150 @@
+package podautoscaler
+
+import (
+	"fmt"
+	"math"
+	"mini-k8s/pkg/api"
+)
+
+// tolerance 是使用量和目标的比值离 1 多远以内不调整副本数，避免指标的小幅波动让副本数来回变化
+const tolerance = 0.1
+
+// resourceParser 返回解析资源数量的函数：CPU 以 milli 为单位，内存以字节为单位
+func resourceParser(name api.ResourceName) func(string) (int64, error) {
+	if name == api.ResourceCPU {
+		return api.ParseMilliQuantity
+	}
+	return api.ParseQuantity
+}
+
+func formatResource(name api.ResourceName, value int64) string {
+	if name == api.ResourceCPU {
+		return api.FormatMilliQuantity(value)
+	}
+	return api.FormatQuantity(value)
+}
+
+// calculateResourceReplicas 按一个资源指标算出需要的副本数，和 Kubernetes 的算法一样：
+// 副本数 = ceil(有指标的 pod 数 × 平均使用量 / 目标)。还没有指标的 pod 在缩容时按正好用到目标算，
+// 在扩容时按没有使用算；还没有 Running 的 pod 只在扩容时按没有使用算。这样缺少的指标只会让调整更保守
+func calculateResourceReplicas(source *api.ResourceMetricSource, currentReplicas int, pods []api.Pod, metrics map[string]api.PodMetrics) (int, *api.MetricStatus, error) {
+	parse := resourceParser(source.Name)
+	usage := map[string]int64{}
+	requests := map[string]int64{}
+	var missing, ignored []string
+	for i := range pods {
+		pod := &pods[i]
+		if !api.IsPodActive(pod) {
+			continue
+		}
+		if request, ok := pod.Resources.Requests[source.Name]; ok {
+			value, err := parse(request)
+			if err != nil {
+				return 0, nil, fmt.Errorf("invalid %s request on pod %s: %w", source.Name, pod.Name, err)
+			}
+			requests[pod.Name] = value
+		} else if source.Target.Type == api.UtilizationMetricType {
+			return 0, nil, fmt.Errorf("missing request for %s on pod %s", source.Name, pod.Name)
+		}
+		if pod.Phase != api.PodRunning {
+			ignored = append(ignored, pod.Name)
+			continue
+		}
+		m, ok := metrics[pod.Name]
+		if !ok || m.UID != pod.UID {
+			missing = append(missing, pod.Name)
+			continue
+		}
+		value, err := parse(m.Usage[source.Name])
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid %s usage of pod %s: %w", source.Name, pod.Name, err)
+		}
+		usage[pod.Name] = value
+	}
+	if len(usage) == 0 {
+		if len(missing) == 0 && len(ignored) == 0 {
+			return 0, nil, fmt.Errorf("no pods match the selector of the scale target")
+		}
+		return 0, nil, fmt.Errorf("no %s metrics returned from the metrics API for any of the %d pods", source.Name, len(missing)+len(ignored))
+	}
+
+	//ratio 返回这些 pod 的平均使用量和目标的比值，target 是一个 pod 正好用到目标时的使用量
+	var ratio func(usage map[string]int64) float64
+	var target func(pod string) int64
+	status := &api.MetricStatus{Type: api.MetricSourceResource, Resource: &api.ResourceMetricStatus{Name: source.Name}}
+	var total int64
+	for _, value := range usage {
+		total += value
+	}
+	status.Resource.Current.AverageValue = formatResource(source.Name, total/int64(len(usage)))
+	if source.Target.Type == api.UtilizationMetricType {
+		targetUtilization := float64(*source.Target.AverageUtilization)
+		ratio = func(usage map[string]int64) float64 {
+			var used, requested int64
+			for pod, value := range usage {
+				used += value
+				requested += requests[pod]
+			}
+			if requested == 0 {
+				return 0
+			}
+			return float64(used) * 100 / float64(requested) / targetUtilization
+		}
+		target = func(pod string) int64 {
+			return int64(float64(requests[pod]) * targetUtilization / 100)
+		}
+		var requested int64
+		for pod := range usage {
+			requested += requests[pod]
+		}
+		if requested == 0 {
+			return 0, nil, fmt.Errorf("%s requests of the pods add up to zero", source.Name)
+		}
+		utilization := int(total * 100 / requested)
+		status.Resource.Current.AverageUtilization = &utilization
+	} else {
+		targetValue, err := parse(source.Target.AverageValue)
+		if err != nil {
+			return 0, nil, err
+		}
+		ratio = func(usage map[string]int64) float64 {
+			var used int64
+			for _, value := range usage {
+				used += value
+			}
+			return float64(used) / float64(len(usage)) / float64(targetValue)
+		}
+		target = func(string) int64 { return targetValue }
+	}
+
+	usageRatio := ratio(usage)
+	if len(missing) == 0 && len(ignored) == 0 {
+		if math.Abs(1-usageRatio) <= tolerance {
+			return currentReplicas, status, nil
+		}
+		return int(math.Ceil(usageRatio * float64(len(usage)))), status, nil
+	}
+	for _, pod := range missing {
+		if usageRatio < 1 {
+			usage[pod] = target(pod)
+		} else {
+			usage[pod] = 0
+		}
+	}
+	if usageRatio > 1 {
+		for _, pod := range ignored {
+			usage[pod] = 0
+		}
+	}
+	newRatio := ratio(usage)
+	//补上缺少的指标之后方向反了或者落进了容忍范围，就不调整
+	if math.Abs(1-newRatio) <= tolerance || (usageRatio < 1 && newRatio > 1) || (usageRatio > 1 && newRatio < 1) {
+		return currentReplicas, status, nil
+	}
+	replicas := int(math.Ceil(newRatio * float64(len(usage))))
+	//补上的指标只能让调整更小，不能让缩容变成扩容、或者让扩容更多
+	if (newRatio < 1 && replicas > currentReplicas) || (newRatio > 1 && replicas < currentReplicas) {
+		return currentReplicas, status, nil
+	}
+	return replicas, status, nil
+}
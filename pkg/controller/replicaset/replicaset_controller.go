@@ -0,0 +1,144 @@
+// Package replicaset contains the controller that keeps the number of pods
+// selected by each ReplicaSet at its desired replica count.
+package replicaset
+
+import (
+	"log"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/controller"
+	"time"
+)
+
+const controllerKind = "ReplicaSet"
+
+type ReplicaSetController struct {
+	client  *api.Client
+	listers controller.Listers
+	// workers 是同时同步的对象数量
+	workers int
+}
+
+func NewReplicaSetController(client *api.Client, listers controller.Listers, workers int) *ReplicaSetController {
+	return &ReplicaSetController{client: client, listers: listers, workers: workers}
+}
+
+// Sync 对每个 ReplicaSet 比较期望副本数和实际活着的 pod 数量，多删少补，然后更新 status
+func (rsc *ReplicaSetController) Sync() {
+	replicaSets, err := rsc.listers.ListReplicaSets()
+	if err != nil {
+		log.Printf("Error listing replicasets: %v", err)
+		return
+	}
+	if len(replicaSets) == 0 {
+		return
+	}
+	pods, err := rsc.listers.ListPods()
+	if err != nil {
+		log.Printf("Error listing pods: %v", err)
+		return
+	}
+	controller.Parallelize(rsc.workers, len(replicaSets), func(i int) {
+		if err := rsc.syncReplicaSet(&replicaSets[i], pods); err != nil {
+			log.Printf("Error syncing replicaset %s/%s: %v", replicaSets[i].Namespace, replicaSets[i].Name, err)
+		}
+	})
+}
+
+func (rsc *ReplicaSetController) syncReplicaSet(rs *api.ReplicaSet, allPods []api.Pod) error {
+	if rs.DeletionTimestamp != nil {
+		return nil
+	}
+	owned, err := rsc.claimPods(rs, allPods)
+	if err != nil {
+		return err
+	}
+	var active []api.Pod
+	for _, pod := range owned {
+		if api.IsPodActive(&pod) {
+			active = append(active, pod)
+		}
+	}
+
+	diff := rs.Spec.Replicas - len(active)
+	if diff > 0 {
+		ref := controller.NewControllerRef(controllerKind, &rs.ObjectMeta)
+		for i := 0; i < diff; i++ {
+			pod := controller.PodFromTemplate(&rs.Spec.Template, rs.Namespace, rs.Name+"-", ref)
+			created, err := rsc.client.CreatePod(rs.Namespace, pod)
+			if err != nil {
+				return err
+			}
+			log.Printf("Created pod %s/%s for replicaset %s", created.Namespace, created.Name, rs.Name)
+		}
+	} else if diff < 0 {
+		controller.SortPodsForDeletion(active)
+		//缩容是用户要求的，直接删除，不经过 eviction，也不受 PDB 限制
+		for _, pod := range active[:-diff] {
+			if err := rsc.client.DeletePod(pod.Namespace, pod.Name); err != nil {
+				return err
+			}
+			log.Printf("Deleted pod %s/%s to scale down replicaset %s", pod.Namespace, pod.Name, rs.Name)
+		}
+	}
+	return rsc.updateStatus(rs, active)
+}
+
+// claimPods 返回属于这个 ReplicaSet 的 pod：已经指向它的、并且 labels 仍然匹配的 pod，
+// 以及匹配选择器但还没有 controller 的孤儿 pod（会被收养）。labels 不再匹配的 pod 会被释放
+func (rsc *ReplicaSetController) claimPods(rs *api.ReplicaSet, allPods []api.Pod) ([]api.Pod, error) {
+	var owned []api.Pod
+	for _, pod := range allPods {
+		if pod.Namespace != rs.Namespace {
+			continue
+		}
+		matches := rs.Spec.Selector.Matches(pod.Labels)
+		ref := pod.ControllerRef()
+		switch {
+		case ref != nil && ref.UID == rs.UID && matches:
+			owned = append(owned, pod)
+		case ref != nil && ref.UID == rs.UID:
+			pod.OwnerReferences = removeOwnerRef(pod.OwnerReferences, rs.UID)
+			if err := rsc.client.UpdatePod(&pod); err != nil {
+				return nil, err
+			}
+			log.Printf("Released pod %s/%s from replicaset %s", pod.Namespace, pod.Name, rs.Name)
+		case ref == nil && matches && api.IsPodActive(&pod):
+			pod.OwnerReferences = append(pod.OwnerReferences, controller.NewControllerRef(controllerKind, &rs.ObjectMeta))
+			if err := rsc.client.UpdatePod(&pod); err != nil {
+				return nil, err
+			}
+			log.Printf("Adopted pod %s/%s into replicaset %s", pod.Namespace, pod.Name, rs.Name)
+			owned = append(owned, pod)
+		}
+	}
+	return owned, nil
+}
+
+func (rsc *ReplicaSetController) updateStatus(rs *api.ReplicaSet, active []api.Pod) error {
+	now := time.Now()
+	status := api.ReplicaSetStatus{Replicas: len(active)}
+	for i := range active {
+		if active[i].Phase == api.PodRunning {
+			status.ReadyReplicas++
+		}
+		if api.IsPodAvailable(&active[i], rs.Spec.MinReadySeconds, now) {
+			status.AvailableReplicas++
+		}
+	}
+	if status == rs.Status {
+		return nil
+	}
+	rs.Status = status
+	_, err := rsc.client.UpdateReplicaSetStatus(rs)
+	return err
+}
+
+func removeOwnerRef(refs []api.OwnerReference, uid string) []api.OwnerReference {
+	var out []api.OwnerReference
+	for _, ref := range refs {
+		if ref.UID != uid {
+			out = append(out, ref)
+		}
+	}
+	return out
+}
@@ -0,0 +1,396 @@
+// Package statefulset contains the controller that runs the pods of a
+// StatefulSet under stable, ordinal names and rolls them out in order.
+package statefulset
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/controller"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const controllerKind = "StatefulSet"
+
+type StatefulSetController struct {
+	client  *api.Client
+	listers controller.Listers
+	// workers 是同时同步的对象数量
+	workers int
+}
+
+func NewStatefulSetController(client *api.Client, listers controller.Listers, workers int) *StatefulSetController {
+	return &StatefulSetController{client: client, listers: listers, workers: workers}
+}
+
+// Sync 对每个 StatefulSet 按序号创建、替换和删除 pod，记录模板的每个版本，并更新 status
+func (sc *StatefulSetController) Sync() {
+	sets, err := sc.listers.ListStatefulSets()
+	if err != nil {
+		log.Printf("Error listing statefulsets: %v", err)
+		return
+	}
+	if len(sets) == 0 {
+		return
+	}
+	pods, err := sc.listers.ListPods()
+	if err != nil {
+		log.Printf("Error listing pods: %v", err)
+		return
+	}
+	revisions, err := sc.listers.ListControllerRevisions()
+	if err != nil {
+		log.Printf("Error listing controllerrevisions: %v", err)
+		return
+	}
+	controller.Parallelize(sc.workers, len(sets), func(i int) {
+		if err := sc.syncStatefulSet(&sets[i], pods, revisions); err != nil {
+			log.Printf("Error syncing statefulset %s/%s: %v", sets[i].Namespace, sets[i].Name, err)
+		}
+	})
+}
+
+// statefulSetState 是一次同步中 StatefulSet 的 pod 和模板版本
+type statefulSetState struct {
+	set *api.StatefulSet
+	// replicas[i] 是序号为 i 的 pod，还不存在时为 nil
+	replicas []*api.Pod
+	// condemned 是序号超出副本数或者名字不符合规则的 pod，按序号从大到小排列
+	condemned       []*api.Pod
+	currentRevision *api.ControllerRevision
+	updateRevision  *api.ControllerRevision
+	revisions       []*api.ControllerRevision
+}
+
+func (sc *StatefulSetController) syncStatefulSet(ss *api.StatefulSet, allPods []api.Pod, allRevisions []api.ControllerRevision) error {
+	if ss.DeletionTimestamp != nil {
+		return nil
+	}
+	state := &statefulSetState{set: ss, replicas: make([]*api.Pod, ss.Spec.Replicas)}
+	for i := range allRevisions {
+		if allRevisions[i].Namespace == ss.Namespace && controller.IsControlledBy(&allRevisions[i].ObjectMeta, ss.UID) {
+			state.revisions = append(state.revisions, &allRevisions[i])
+		}
+	}
+	if err := sc.syncRevisions(state); err != nil {
+		return err
+	}
+
+	for i := range allPods {
+		pod := &allPods[i]
+		if pod.Namespace != ss.Namespace || !controller.IsControlledBy(&pod.ObjectMeta, ss.UID) {
+			continue
+		}
+		if ordinal := getOrdinal(ss, pod); ordinal >= 0 && ordinal < ss.Spec.Replicas {
+			state.replicas[ordinal] = pod
+		} else {
+			state.condemned = append(state.condemned, pod)
+		}
+	}
+	sort.Slice(state.condemned, func(i, j int) bool {
+		return getOrdinal(ss, state.condemned[i]) > getOrdinal(ss, state.condemned[j])
+	})
+
+	if err := sc.reconcilePods(state); err != nil {
+		return err
+	}
+	if err := sc.updateStatus(state); err != nil {
+		return err
+	}
+	return sc.truncateHistory(state)
+}
+
+// syncRevisions 找到或者创建当前模板对应的 ControllerRevision 作为 updateRevision，
+// 并从 status.currentRevision 找到滚动更新开始前的版本
+func (sc *StatefulSetController) syncRevisions(state *statefulSetState) error {
+	ss := state.set
+	name := ss.Name + "-" + controller.ComputeHash(&ss.Spec.Template)
+	var maxRevision int64
+	for _, cr := range state.revisions {
+		maxRevision = max(maxRevision, cr.Revision)
+		if cr.Name == name {
+			state.updateRevision = cr
+		}
+		if cr.Name == ss.Status.CurrentRevision {
+			state.currentRevision = cr
+		}
+	}
+	switch {
+	case state.updateRevision == nil:
+		data, err := json.Marshal(ss.Spec.Template)
+		if err != nil {
+			return err
+		}
+		cr := &api.ControllerRevision{
+			ObjectMeta: api.ObjectMeta{
+				Name:            name,
+				Namespace:       ss.Namespace,
+				Labels:          copyLabels(ss.Spec.Template.Metadata.Labels),
+				OwnerReferences: []api.OwnerReference{controller.NewControllerRef(controllerKind, &ss.ObjectMeta)},
+			},
+			Data:     data,
+			Revision: maxRevision + 1,
+		}
+		created, err := sc.client.CreateControllerRevision(ss.Namespace, cr)
+		if err != nil {
+			return err
+		}
+		log.Printf("Created controllerrevision %s/%s (revision %d) for statefulset %s", created.Namespace, created.Name, created.Revision, ss.Name)
+		state.updateRevision = created
+		state.revisions = append(state.revisions, created)
+	case state.updateRevision.Revision < maxRevision:
+		//模板改回了以前的某个版本，复用旧的 ControllerRevision 并把它的版本号调成最新
+		state.updateRevision.Revision = maxRevision + 1
+		updated, err := sc.client.UpdateControllerRevision(state.updateRevision)
+		if err != nil {
+			return err
+		}
+		*state.updateRevision = *updated
+	}
+	if state.currentRevision == nil {
+		state.currentRevision = state.updateRevision
+	}
+	return nil
+}
+
+// reconcilePods 让每个序号都有一个健康的 pod，删除多余的 pod，并按更新策略替换旧版本的 pod。
+// OrderedReady 策略下每次只操作一个 pod，前面的 pod 不健康时不继续往后处理
+func (sc *StatefulSetController) reconcilePods(state *statefulSetState) error {
+	ss := state.set
+	monotonic := ss.Spec.PodManagementPolicy != api.ParallelPodManagement
+	now := time.Now()
+
+	for ordinal, pod := range state.replicas {
+		switch {
+		case pod == nil:
+			created, err := sc.createPod(state, ordinal)
+			if err != nil {
+				return err
+			}
+			state.replicas[ordinal] = created
+			if monotonic {
+				return nil
+			}
+		case pod.DeletionTimestamp != nil:
+			//等旧的 pod 删除之后再用同样的名字重建
+			if monotonic {
+				return nil
+			}
+		case pod.Phase == api.PodFailed || pod.Phase == api.PodSucceeded:
+			if err := sc.deletePod(ss, pod, "it has terminated"); err != nil {
+				return err
+			}
+			if monotonic {
+				return nil
+			}
+		case !api.IsPodAvailable(pod, ss.Spec.MinReadySeconds, now):
+			if monotonic {
+				log.Printf("Statefulset %s/%s is waiting for pod %s to be available", ss.Namespace, ss.Name, pod.Name)
+				return nil
+			}
+		}
+	}
+
+	//缩容从序号最大的 pod 开始
+	for _, pod := range state.condemned {
+		if pod.DeletionTimestamp != nil {
+			if monotonic {
+				return nil
+			}
+			continue
+		}
+		if err := sc.deletePod(ss, pod, "its ordinal is not below the replica count"); err != nil {
+			return err
+		}
+		if monotonic {
+			return nil
+		}
+	}
+
+	if ss.Spec.UpdateStrategy.Type != api.RollingUpdateStatefulSetStrategyType {
+		return nil
+	}
+	//滚动更新从序号最大的 pod 开始逐个替换，序号小于 partition 的 pod 保持原来的版本
+	for ordinal := len(state.replicas) - 1; ordinal >= *ss.Spec.UpdateStrategy.RollingUpdate.Partition; ordinal-- {
+		pod := state.replicas[ordinal]
+		if pod.Labels[api.ControllerRevisionHashLabel] != state.updateRevision.Name && pod.DeletionTimestamp == nil {
+			return sc.deletePod(ss, pod, fmt.Sprintf("it runs revision %s instead of %s", pod.Labels[api.ControllerRevisionHashLabel], state.updateRevision.Name))
+		}
+		if !api.IsPodAvailable(pod, ss.Spec.MinReadySeconds, now) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// createPod 创建序号为 ordinal 的 pod。RollingUpdate 策略下序号小于 partition 的 pod 按 currentRevision 创建，其余按 updateRevision 创建
+func (sc *StatefulSetController) createPod(state *statefulSetState, ordinal int) (*api.Pod, error) {
+	ss := state.set
+	revision := state.updateRevision
+	if ss.Spec.UpdateStrategy.Type == api.RollingUpdateStatefulSetStrategyType && ordinal < *ss.Spec.UpdateStrategy.RollingUpdate.Partition {
+		revision = state.currentRevision
+	}
+	var template api.PodTemplateSpec
+	if err := json.Unmarshal(revision.Data, &template); err != nil {
+		return nil, fmt.Errorf("decoding controllerrevision %s: %w", revision.Name, err)
+	}
+	if err := sc.createPersistentVolumeClaims(ss, ordinal); err != nil {
+		return nil, err
+	}
+	pod := controller.PodFromTemplate(&template, ss.Namespace, "", controller.NewControllerRef(controllerKind, &ss.ObjectMeta))
+	pod.Name = podName(ss, ordinal)
+	pod.Volumes = ss.PodVolumes(pod.Volumes, ordinal)
+	pod.Labels[api.StatefulSetPodNameLabel] = pod.Name
+	pod.Labels[api.ControllerRevisionHashLabel] = revision.Name
+	pod.Hostname = pod.Name
+	pod.Subdomain = ss.Spec.ServiceName
+	created, err := sc.client.CreatePod(ss.Namespace, pod)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Created pod %s/%s for statefulset %s at revision %s", created.Namespace, created.Name, ss.Name, revision.Name)
+	return created, nil
+}
+
+// createPersistentVolumeClaims 为序号为 ordinal 的 pod 创建还不存在的 PVC。PVC 没有 ownerReference，
+// 缩容和删除 StatefulSet 之后仍然保留，同一个序号的 pod 重建之后会用回原来的 PVC。
+// 正在删除的 PVC 要等它消失之后再重新创建，否则 pod 会用上一个马上就要被删除的卷
+func (sc *StatefulSetController) createPersistentVolumeClaims(ss *api.StatefulSet, ordinal int) error {
+	for i := range ss.Spec.VolumeClaimTemplates {
+		claim := ss.Spec.VolumeClaimTemplates[i].DeepCopy()
+		name := ss.PersistentVolumeClaimName(claim, ordinal)
+		existing, err := sc.client.GetPersistentVolumeClaim(ss.Namespace, name)
+		if err == nil {
+			if existing.DeletionTimestamp != nil {
+				return fmt.Errorf("persistentvolumeclaim %s/%s for pod %s is being deleted", ss.Namespace, name, podName(ss, ordinal))
+			}
+			continue
+		}
+		if !strings.Contains(err.Error(), "not found") {
+			return err
+		}
+		labels := copyLabels(claim.Labels)
+		for k, v := range ss.Spec.Selector.MatchLabels {
+			labels[k] = v
+		}
+		pvc := &api.PersistentVolumeClaim{
+			ObjectMeta: api.ObjectMeta{Name: name, Namespace: ss.Namespace, Labels: labels},
+			Spec:       claim.Spec,
+		}
+		if _, err := sc.client.CreatePersistentVolumeClaim(ss.Namespace, pvc); err != nil && !strings.Contains(err.Error(), "already exists") {
+			return err
+		}
+		log.Printf("Created persistentvolumeclaim %s/%s for pod %s of statefulset %s", ss.Namespace, name, podName(ss, ordinal), ss.Name)
+	}
+	return nil
+}
+
+// deletePod 直接删除 pod，不经过 eviction：缩容、滚动更新和清理结束的 pod 是用户改了 StatefulSet 引起的，不受 PDB 限制
+func (sc *StatefulSetController) deletePod(ss *api.StatefulSet, pod *api.Pod, reason string) error {
+	if err := sc.client.DeletePod(pod.Namespace, pod.Name); err != nil {
+		return err
+	}
+	log.Printf("Deleted pod %s/%s of statefulset %s because %s", pod.Namespace, pod.Name, ss.Name, reason)
+	//pod 由 kubelet 停止后才会从存储里删除，这一轮后面的步骤把它当作正在删除的 pod
+	now := time.Now()
+	pod.DeletionTimestamp = &now
+	return nil
+}
+
+func (sc *StatefulSetController) updateStatus(state *statefulSetState) error {
+	ss := state.set
+	now := time.Now()
+	status := api.StatefulSetStatus{
+		ObservedGeneration: ss.Generation,
+		CurrentRevision:    state.currentRevision.Name,
+		UpdateRevision:     state.updateRevision.Name,
+	}
+	pods := append(append([]*api.Pod{}, state.replicas...), state.condemned...)
+	for _, pod := range pods {
+		if pod == nil || pod.DeletionTimestamp != nil {
+			continue
+		}
+		status.Replicas++
+		if pod.Phase == api.PodRunning {
+			status.ReadyReplicas++
+		}
+		if api.IsPodAvailable(pod, ss.Spec.MinReadySeconds, now) {
+			status.AvailableReplicas++
+		}
+		if pod.Labels[api.ControllerRevisionHashLabel] == status.CurrentRevision {
+			status.CurrentReplicas++
+		}
+		if pod.Labels[api.ControllerRevisionHashLabel] == status.UpdateRevision {
+			status.UpdatedReplicas++
+		}
+	}
+	//所有副本都更新并就绪之后滚动更新结束，新版本成为 currentRevision
+	if status.UpdatedReplicas == ss.Spec.Replicas && status.ReadyReplicas == ss.Spec.Replicas && status.Replicas == ss.Spec.Replicas {
+		status.CurrentRevision = status.UpdateRevision
+		status.CurrentReplicas = status.UpdatedReplicas
+		state.currentRevision = state.updateRevision
+	}
+	if status == ss.Status {
+		return nil
+	}
+	ss.Status = status
+	_, err := sc.client.UpdateStatefulSetStatus(ss)
+	return err
+}
+
+// truncateHistory 删除没有 pod 在用的旧 ControllerRevision，只保留最近的 revisionHistoryLimit 个
+func (sc *StatefulSetController) truncateHistory(state *statefulSetState) error {
+	live := map[string]bool{state.currentRevision.Name: true, state.updateRevision.Name: true}
+	for _, pod := range append(append([]*api.Pod{}, state.replicas...), state.condemned...) {
+		if pod != nil {
+			live[pod.Labels[api.ControllerRevisionHashLabel]] = true
+		}
+	}
+	var history []*api.ControllerRevision
+	for _, cr := range state.revisions {
+		if !live[cr.Name] {
+			history = append(history, cr)
+		}
+	}
+	limit := *state.set.Spec.RevisionHistoryLimit
+	if len(history) <= limit {
+		return nil
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].Revision < history[j].Revision })
+	for _, cr := range history[:len(history)-limit] {
+		if err := sc.client.DeleteControllerRevision(cr.Namespace, cr.Name); err != nil {
+			return err
+		}
+		log.Printf("Deleted controllerrevision %s/%s (revision %d) of statefulset %s", cr.Namespace, cr.Name, cr.Revision, state.set.Name)
+	}
+	return nil
+}
+
+func podName(ss *api.StatefulSet, ordinal int) string {
+	return fmt.Sprintf("%s-%d", ss.Name, ordinal)
+}
+
+// getOrdinal 从 pod 名 <statefulset>-<序号> 解析序号，名字不符合规则时返回 -1
+func getOrdinal(ss *api.StatefulSet, pod *api.Pod) int {
+	suffix, ok := strings.CutPrefix(pod.Name, ss.Name+"-")
+	if !ok {
+		return -1
+	}
+	ordinal, err := strconv.Atoi(suffix)
+	if err != nil || ordinal < 0 || podName(ss, ordinal) != pod.Name {
+		return -1
+	}
+	return ordinal
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	copied := make(map[string]string, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+	return copied
+}
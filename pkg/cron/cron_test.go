@@ -0,0 +1,60 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDayOfMonthAndDayOfWeek(t *testing.T) {
+	// 2026-10-18 是星期天，10-19 是星期一
+	tests := []struct {
+		spec string
+		day  time.Time
+		want bool
+	}{
+		//日是 * 时只看星期
+		{"0 0 * * 1", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), false},
+		{"0 0 * * 1", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), true},
+		//两者都有限制时满足任意一个即可
+		{"0 0 18 * 1", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), true},
+		{"0 0 18 * 1", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), true},
+		{"0 0 18 * 1", time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC), false},
+		//"*/2" 带步长，算作有限制：单数日或者星期一
+		{"0 0 */2 * 1", time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), true},
+		{"0 0 */2 * 1", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), false},
+		{"0 0 */2 * 1", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), true},
+		{"0 0 1 * */2", time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC), true},
+		{"0 0 1 * */2", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.spec, err)
+		}
+		if got := s.dayMatches(tt.day); got != tt.want {
+			t.Errorf("%q on %s: dayMatches = %v, want %v", tt.spec, tt.day.Format("Mon 2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	from := time.Date(2026, 10, 18, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 10, 18, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 18, 10, 15, 0, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.spec, err)
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q: Next(%s) = %s, want %s", tt.spec, from, got, tt.want)
+		}
+	}
+}
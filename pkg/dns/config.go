@@ -0,0 +1,110 @@
+package dns
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultClusterDomain = "cluster.local"
+	DefaultTTL           = 5
+	// defaultResolvConf 是配置里没有 upstreams 时读取上游服务器的文件
+	defaultResolvConf = "/etc/resolv.conf"
+)
+
+// Config 是 DNS 服务器的配置，可以从 JSON 文件读取
+type Config struct {
+	// ClusterDomain 是集群的域名，service 的名字是 <service>.<namespace>.svc.<ClusterDomain>
+	ClusterDomain string `json:"clusterDomain,omitempty"`
+	// TTL 是集群内记录的 TTL，单位是秒
+	TTL uint32 `json:"ttl,omitempty"`
+	// Upstreams 是集群域名以外的名字转发到的服务器，按顺序尝试。为空时使用 /etc/resolv.conf 里的 nameserver
+	Upstreams []string `json:"upstreams,omitempty"`
+	// UpstreamTimeout 是等待一个上游服务器回复的时间，比如 "2s"
+	UpstreamTimeout string `json:"upstreamTimeout,omitempty"`
+
+	upstreamTimeout time.Duration
+}
+
+// LoadConfig 读取配置文件，path 为空时返回默认配置
+func LoadConfig(path string) (*Config, error) {
+	config := &Config{}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+	}
+	if err := config.complete(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// complete 填充默认值并检查配置
+func (c *Config) complete() error {
+	c.ClusterDomain = strings.Trim(strings.ToLower(c.ClusterDomain), ".")
+	if c.ClusterDomain == "" {
+		c.ClusterDomain = DefaultClusterDomain
+	}
+	if c.TTL == 0 {
+		c.TTL = DefaultTTL
+	}
+	c.upstreamTimeout = 2 * time.Second
+	if c.UpstreamTimeout != "" {
+		d, err := time.ParseDuration(c.UpstreamTimeout)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid upstreamTimeout %q", c.UpstreamTimeout)
+		}
+		c.upstreamTimeout = d
+	}
+	if len(c.Upstreams) == 0 {
+		upstreams, err := readResolvConf(defaultResolvConf)
+		if err != nil {
+			return fmt.Errorf("no upstreams configured and %s is unusable: %w", defaultResolvConf, err)
+		}
+		c.Upstreams = upstreams
+	}
+	for i, upstream := range c.Upstreams {
+		//没有写端口时使用 53
+		if _, _, err := net.SplitHostPort(upstream); err != nil {
+			if net.ParseIP(upstream) == nil {
+				return fmt.Errorf("invalid upstream %q", upstream)
+			}
+			c.Upstreams[i] = net.JoinHostPort(upstream, "53")
+		}
+	}
+	return nil
+}
+
+// readResolvConf 返回 resolv.conf 里的 nameserver
+func readResolvConf(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var servers []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" && net.ParseIP(fields[1]) != nil {
+			servers = append(servers, net.JoinHostPort(fields[1], "53"))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("no nameserver found")
+	}
+	return servers, nil
+}
@@ -0,0 +1,366 @@
+package dns
+
+import (
+	"mini-k8s/pkg/api"
+	"net"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Lister 提供回答查询需要的 service 和 endpoints，informer.SharedInformerFactory 实现了它
+type Lister interface {
+	ListServices() ([]api.Service, error)
+	ListEndpoints() ([]api.Endpoints, error)
+}
+
+// answer 是集群域名内一个查询的结果
+type answer struct {
+	rcode      dnsmessage.RCode
+	answers    []dnsmessage.Resource
+	additional []dnsmessage.Resource
+}
+
+// resolver 在一次查询里使用同一份 service 和 endpoints 的快照
+type resolver struct {
+	config    *Config
+	services  []api.Service
+	endpoints map[string]*api.Endpoints
+}
+
+func newResolver(config *Config, lister Lister) (*resolver, error) {
+	services, err := lister.ListServices()
+	if err != nil {
+		return nil, err
+	}
+	endpoints, err := lister.ListEndpoints()
+	if err != nil {
+		return nil, err
+	}
+	r := &resolver{config: config, services: services, endpoints: map[string]*api.Endpoints{}}
+	for i := range endpoints {
+		r.endpoints[endpoints[i].Namespace+"/"+endpoints[i].Name] = &endpoints[i]
+	}
+	return r, nil
+}
+
+func (r *resolver) service(namespace, name string) *api.Service {
+	for i := range r.services {
+		if r.services[i].Namespace == namespace && r.services[i].Name == name {
+			return &r.services[i]
+		}
+	}
+	return nil
+}
+
+// inClusterDomain 表示 name 是否属于集群域名，集群域名内的名字不转发给上游
+func (r *resolver) inClusterDomain(name string) bool {
+	return name == r.config.ClusterDomain || strings.HasSuffix(name, "."+r.config.ClusterDomain)
+}
+
+// resolve 回答集群域名内的查询。name 是小写、没有结尾点号的名字
+func (r *resolver) resolve(name string, qtype dnsmessage.Type) answer {
+	labels := strings.Split(strings.TrimSuffix(strings.TrimSuffix(name, r.config.ClusterDomain), "."), ".")
+	if name == r.config.ClusterDomain {
+		labels = nil
+	}
+	n := len(labels)
+	switch {
+	case n >= 3 && labels[n-1] == "svc":
+		return r.resolveService(name, labels[:n-1], qtype)
+	case n == 3 && labels[n-1] == "pod":
+		return r.resolvePod(name, labels[0], qtype)
+	case n <= 2 && n > 0 && (labels[n-1] == "svc" || labels[n-1] == "pod"), n == 0:
+		//域名本身存在，只是没有记录
+		return answer{rcode: dnsmessage.RCodeSuccess}
+	}
+	return answer{rcode: dnsmessage.RCodeNameError}
+}
+
+// resolveService 回答 [<hostname>.]<service>.<namespace>.svc 和 _<port>._<proto>.<service>.<namespace>.svc
+func (r *resolver) resolveService(name string, labels []string, qtype dnsmessage.Type) answer {
+	n := len(labels)
+	namespace, serviceName := labels[n-1], labels[n-2]
+	svc := r.service(namespace, serviceName)
+	if svc == nil {
+		return answer{rcode: dnsmessage.RCodeNameError}
+	}
+	serviceFQDN := serviceName + "." + namespace + ".svc." + r.config.ClusterDomain
+	switch n {
+	case 2:
+		result := answer{rcode: dnsmessage.RCodeSuccess}
+		switch qtype {
+		case dnsmessage.TypeA, dnsmessage.TypeAAAA:
+			for _, ip := range r.serviceIPs(svc) {
+				if rr, ok := r.addressRecord(name, ip, qtype); ok {
+					result.answers = append(result.answers, rr)
+				}
+			}
+		case dnsmessage.TypeSRV:
+			result.answers, result.additional = r.srvRecords(name, svc, serviceFQDN, "", "")
+		}
+		return result
+	case 3:
+		//headless service 的每个后端有自己的名字：有 hostname 时是 hostname，否则是用 - 连接的 IP
+		if !svc.IsHeadless() {
+			return answer{rcode: dnsmessage.RCodeNameError}
+		}
+		var ips []string
+		for _, addr := range r.readyAddresses(svc) {
+			if endpointLabel(addr) == labels[0] {
+				ips = append(ips, addr.IP)
+			}
+		}
+		if len(ips) == 0 {
+			return answer{rcode: dnsmessage.RCodeNameError}
+		}
+		result := answer{rcode: dnsmessage.RCodeSuccess}
+		for _, ip := range ips {
+			if rr, ok := r.addressRecord(name, ip, qtype); ok {
+				result.answers = append(result.answers, rr)
+			}
+		}
+		return result
+	case 4:
+		if !strings.HasPrefix(labels[0], "_") || !strings.HasPrefix(labels[1], "_") {
+			return answer{rcode: dnsmessage.RCodeNameError}
+		}
+		answers, additional := r.srvRecords(name, svc, serviceFQDN, labels[0][1:], labels[1][1:])
+		if len(answers) == 0 {
+			return answer{rcode: dnsmessage.RCodeNameError}
+		}
+		result := answer{rcode: dnsmessage.RCodeSuccess}
+		if qtype == dnsmessage.TypeSRV {
+			result.answers, result.additional = answers, additional
+		}
+		return result
+	}
+	return answer{rcode: dnsmessage.RCodeNameError}
+}
+
+// resolvePod 回答 <a-b-c-d>.<namespace>.pod，名字本身就是 pod 的 IP
+func (r *resolver) resolvePod(name, label string, qtype dnsmessage.Type) answer {
+	ip := net.ParseIP(strings.ReplaceAll(label, "-", "."))
+	if ip == nil || ip.To4() == nil {
+		ip = net.ParseIP(strings.ReplaceAll(label, "-", ":"))
+	}
+	if ip == nil {
+		return answer{rcode: dnsmessage.RCodeNameError}
+	}
+	result := answer{rcode: dnsmessage.RCodeSuccess}
+	if rr, ok := r.addressRecord(name, ip.String(), qtype); ok {
+		result.answers = append(result.answers, rr)
+	}
+	return result
+}
+
+// serviceIPs 返回 service 名字解析到的地址：普通 service 是 ClusterIP，headless service 是所有就绪的后端
+func (r *resolver) serviceIPs(svc *api.Service) []string {
+	if !svc.IsHeadless() {
+		if svc.Spec.ClusterIP == "" {
+			return nil
+		}
+		return []string{svc.Spec.ClusterIP}
+	}
+	var ips []string
+	seen := map[string]bool{}
+	for _, addr := range r.readyAddresses(svc) {
+		if !seen[addr.IP] {
+			seen[addr.IP] = true
+			ips = append(ips, addr.IP)
+		}
+	}
+	sort.Strings(ips)
+	return ips
+}
+
+func (r *resolver) readyAddresses(svc *api.Service) []api.EndpointAddress {
+	ep := r.endpoints[svc.Namespace+"/"+svc.Name]
+	if ep == nil {
+		return nil
+	}
+	var addresses []api.EndpointAddress
+	for _, subset := range ep.Subsets {
+		addresses = append(addresses, subset.Addresses...)
+	}
+	return addresses
+}
+
+// srvRecords 返回 service 端口的 SRV 记录和目标的地址记录。portName 和 protocol 为空时返回所有端口
+func (r *resolver) srvRecords(name string, svc *api.Service, serviceFQDN, portName, protocol string) (answers, additional []dnsmessage.Resource) {
+	matches := func(n string, p api.Protocol) bool {
+		if portName == "" && protocol == "" {
+			return true
+		}
+		if p == "" {
+			p = api.ProtocolTCP
+		}
+		return strings.EqualFold(n, portName) && strings.EqualFold(string(p), protocol)
+	}
+	if !svc.IsHeadless() {
+		if svc.Spec.ClusterIP == "" {
+			return nil, nil
+		}
+		for _, port := range svc.Spec.Ports {
+			if !matches(port.Name, port.Protocol) {
+				continue
+			}
+			answers = append(answers, r.srvRecord(name, serviceFQDN, port.Port))
+		}
+		if len(answers) > 0 {
+			if rr, ok := r.addressRecord(serviceFQDN, svc.Spec.ClusterIP, addressType(svc.Spec.ClusterIP)); ok {
+				additional = append(additional, rr)
+			}
+		}
+		return answers, additional
+	}
+	//headless service 的 SRV 记录指向每个后端自己的名字
+	ep := r.endpoints[svc.Namespace+"/"+svc.Name]
+	if ep == nil {
+		return nil, nil
+	}
+	added := map[string]bool{}
+	for _, subset := range ep.Subsets {
+		for _, port := range subset.Ports {
+			if !matches(port.Name, port.Protocol) {
+				continue
+			}
+			for _, addr := range subset.Addresses {
+				target := endpointLabel(addr) + "." + serviceFQDN
+				answers = append(answers, r.srvRecord(name, target, port.Port))
+				if added[target+"/"+addr.IP] {
+					continue
+				}
+				added[target+"/"+addr.IP] = true
+				if rr, ok := r.addressRecord(target, addr.IP, addressType(addr.IP)); ok {
+					additional = append(additional, rr)
+				}
+			}
+		}
+	}
+	return answers, additional
+}
+
+// resolvePTR 回答集群内地址的反向查询：ClusterIP 指向 service，headless service 的后端指向它自己的名字。
+// 地址不属于集群时 ok 为 false
+func (r *resolver) resolvePTR(name string) (result answer, ok bool) {
+	ip := reverseIP(name)
+	if ip == nil {
+		return answer{}, false
+	}
+	target := ""
+	for i := range r.services {
+		svc := &r.services[i]
+		serviceFQDN := svc.Name + "." + svc.Namespace + ".svc." + r.config.ClusterDomain
+		if !svc.IsHeadless() {
+			if clusterIP := net.ParseIP(svc.Spec.ClusterIP); clusterIP != nil && clusterIP.Equal(ip) {
+				target = serviceFQDN
+				break
+			}
+			continue
+		}
+		for _, addr := range r.readyAddresses(svc) {
+			if addrIP := net.ParseIP(addr.IP); addrIP != nil && addrIP.Equal(ip) {
+				target = endpointLabel(addr) + "." + serviceFQDN
+				break
+			}
+		}
+		if target != "" {
+			break
+		}
+	}
+	if target == "" {
+		return answer{}, false
+	}
+	ptr := dnsmessage.Resource{
+		Header: r.header(name, dnsmessage.TypePTR),
+		Body:   &dnsmessage.PTRResource{PTR: mustName(target)},
+	}
+	return answer{rcode: dnsmessage.RCodeSuccess, answers: []dnsmessage.Resource{ptr}}, true
+}
+
+func (r *resolver) srvRecord(name, target string, port int) dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: r.header(name, dnsmessage.TypeSRV),
+		Body:   &dnsmessage.SRVResource{Priority: 0, Weight: 100, Port: uint16(port), Target: mustName(target)},
+	}
+}
+
+// addressRecord 返回 name 指向 ip 的 A 或 AAAA 记录，ip 的类型和 qtype 不一致时 ok 为 false
+func (r *resolver) addressRecord(name, ip string, qtype dnsmessage.Type) (dnsmessage.Resource, bool) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return dnsmessage.Resource{}, false
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		if qtype != dnsmessage.TypeA {
+			return dnsmessage.Resource{}, false
+		}
+		var a dnsmessage.AResource
+		copy(a.A[:], v4)
+		return dnsmessage.Resource{Header: r.header(name, dnsmessage.TypeA), Body: &a}, true
+	}
+	if qtype != dnsmessage.TypeAAAA {
+		return dnsmessage.Resource{}, false
+	}
+	var aaaa dnsmessage.AAAAResource
+	copy(aaaa.AAAA[:], parsed.To16())
+	return dnsmessage.Resource{Header: r.header(name, dnsmessage.TypeAAAA), Body: &aaaa}, true
+}
+
+func (r *resolver) header(name string, t dnsmessage.Type) dnsmessage.ResourceHeader {
+	return dnsmessage.ResourceHeader{Name: mustName(name), Type: t, Class: dnsmessage.ClassINET, TTL: r.config.TTL}
+}
+
+func addressType(ip string) dnsmessage.Type {
+	if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() == nil {
+		return dnsmessage.TypeAAAA
+	}
+	return dnsmessage.TypeA
+}
+
+// endpointLabel 返回后端在 headless service 下的名字
+func endpointLabel(addr api.EndpointAddress) string {
+	if addr.Hostname != "" {
+		return addr.Hostname
+	}
+	return strings.NewReplacer(".", "-", ":", "-").Replace(addr.IP)
+}
+
+// reverseIP 解析 in-addr.arpa 和 ip6.arpa 名字里的地址
+func reverseIP(name string) net.IP {
+	if rest, ok := strings.CutSuffix(name, ".in-addr.arpa"); ok {
+		octets := strings.Split(rest, ".")
+		if len(octets) != 4 {
+			return nil
+		}
+		for i, j := 0, len(octets)-1; i < j; i, j = i+1, j-1 {
+			octets[i], octets[j] = octets[j], octets[i]
+		}
+		return net.ParseIP(strings.Join(octets, ".")).To4()
+	}
+	if rest, ok := strings.CutSuffix(name, ".ip6.arpa"); ok {
+		nibbles := strings.Split(rest, ".")
+		if len(nibbles) != 32 {
+			return nil
+		}
+		var b strings.Builder
+		for i := len(nibbles) - 1; i >= 0; i-- {
+			if len(nibbles[i]) != 1 {
+				return nil
+			}
+			b.WriteString(nibbles[i])
+			if i%4 == 0 && i != 0 {
+				b.WriteByte(':')
+			}
+		}
+		return net.ParseIP(b.String())
+	}
+	return nil
+}
+
+// mustName 把集群内生成的名字转换成 dnsmessage.Name，名字都来自合法的对象名，不会超过长度限制
+func mustName(name string) dnsmessage.Name {
+	return dnsmessage.MustNewName(name + ".")
+}
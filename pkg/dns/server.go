@@ -0,0 +1,265 @@
+// Package dns implements a small cluster DNS server. It answers A, AAAA, SRV
+// and PTR queries under the cluster domain from the services and endpoints
+// known to the API server, and forwards every other name to the upstream
+// resolvers from its configuration.
+package dns
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// maxUDPSize 是查询没有 EDNS 时 UDP 回复的最大长度
+	maxUDPSize = 512
+	// maxMessageSize 是 TCP 消息和 EDNS 声明的最大长度
+	maxMessageSize = 65535
+	// tcpIdleTimeout 是 TCP 连接等待下一个查询的时间
+	tcpIdleTimeout = 10 * time.Second
+)
+
+// Server 回答 DNS 查询
+type Server struct {
+	config *Config
+	lister Lister
+}
+
+func NewServer(config *Config, lister Lister) *Server {
+	return &Server{config: config, lister: lister}
+}
+
+// ServeUDP 在 conn 上回答查询，直到 conn 被关闭
+func (s *Server) ServeUDP(conn net.PacketConn) error {
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		query := append([]byte(nil), buf[:n]...)
+		go func() {
+			if reply := s.handle(query, false); reply != nil {
+				conn.WriteTo(reply, addr)
+			}
+		}()
+	}
+}
+
+// ServeTCP 在 listener 上回答查询，直到 listener 被关闭
+func (s *Server) ServeTCP(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.serveTCPConn(conn)
+	}
+}
+
+// serveTCPConn 处理一个 TCP 连接上的查询，每个消息前面有两个字节的长度
+func (s *Server) serveTCPConn(conn net.Conn) {
+	defer conn.Close()
+	for {
+		conn.SetDeadline(time.Now().Add(tcpIdleTimeout))
+		query, err := readTCPMessage(conn)
+		if err != nil {
+			return
+		}
+		reply := s.handle(query, true)
+		if reply == nil {
+			return
+		}
+		if err := writeTCPMessage(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// handle 返回查询的回复，查询无法解析时返回 nil
+func (s *Server) handle(query []byte, tcp bool) []byte {
+	var p dnsmessage.Parser
+	header, err := p.Start(query)
+	if err != nil || header.Response {
+		return nil
+	}
+	question, err := p.Question()
+	if err != nil {
+		return s.reply(header, nil, answer{rcode: dnsmessage.RCodeFormatError}, maxUDPSize)
+	}
+	size := maxUDPSize
+	if tcp {
+		size = maxMessageSize
+	} else if ednsSize := udpSizeOf(&p); ednsSize > size {
+		size = ednsSize
+	}
+	if header.OpCode != 0 {
+		return s.reply(header, &question, answer{rcode: dnsmessage.RCodeNotImplemented}, size)
+	}
+
+	name := strings.ToLower(strings.TrimSuffix(question.Name.String(), "."))
+	resolver, err := newResolver(s.config, s.lister)
+	if err != nil {
+		log.Printf("Error listing services for %s: %v", name, err)
+		return s.reply(header, &question, answer{rcode: dnsmessage.RCodeServerFailure}, size)
+	}
+	if question.Class == dnsmessage.ClassINET {
+		if resolver.inClusterDomain(name) {
+			return s.reply(header, &question, resolver.resolve(name, question.Type), size)
+		}
+		//集群地址的反向查询由本服务器回答，其它地址交给上游
+		if question.Type == dnsmessage.TypePTR {
+			if result, ok := resolver.resolvePTR(name); ok {
+				return s.reply(header, &question, result, size)
+			}
+		}
+	}
+	reply, err := s.forward(query, tcp)
+	if err != nil {
+		log.Printf("Error forwarding query for %s: %v", name, err)
+		return s.reply(header, &question, answer{rcode: dnsmessage.RCodeServerFailure}, size)
+	}
+	return reply
+}
+
+// reply 构造回复，超过 size 时去掉记录并设置 TC，让客户端改用 TCP 重试
+func (s *Server) reply(query dnsmessage.Header, question *dnsmessage.Question, result answer, size int) []byte {
+	header := dnsmessage.Header{
+		ID:                 query.ID,
+		Response:           true,
+		OpCode:             query.OpCode,
+		Authoritative:      result.rcode == dnsmessage.RCodeSuccess || result.rcode == dnsmessage.RCodeNameError,
+		RecursionDesired:   query.RecursionDesired,
+		RecursionAvailable: true,
+		RCode:              result.rcode,
+	}
+	msg := dnsmessage.Message{Header: header, Answers: result.answers, Additionals: result.additional}
+	if question != nil {
+		msg.Questions = []dnsmessage.Question{*question}
+	}
+	data, err := msg.AppendPack(make([]byte, 0, maxUDPSize))
+	if err != nil {
+		log.Printf("Error packing reply: %v", err)
+		return nil
+	}
+	if len(data) <= size {
+		return data
+	}
+	msg.Truncated = true
+	msg.Answers, msg.Additionals = nil, nil
+	data, err = msg.Pack()
+	if err != nil {
+		log.Printf("Error packing reply: %v", err)
+		return nil
+	}
+	return data
+}
+
+// udpSizeOf 返回查询的 EDNS OPT 记录里声明的 UDP 长度，没有时返回 0
+func udpSizeOf(p *dnsmessage.Parser) int {
+	if p.SkipAllQuestions() != nil || p.SkipAllAnswers() != nil || p.SkipAllAuthorities() != nil {
+		return 0
+	}
+	for {
+		h, err := p.AdditionalHeader()
+		if err != nil {
+			return 0
+		}
+		if h.Type == dnsmessage.TypeOPT {
+			//OPT 记录的 class 字段是 UDP 长度
+			return min(int(h.Class), maxMessageSize)
+		}
+		if err := p.SkipAdditional(); err != nil {
+			return 0
+		}
+	}
+}
+
+// forward 把查询按顺序发给上游服务器，返回第一个回复
+func (s *Server) forward(query []byte, tcp bool) ([]byte, error) {
+	var lastErr error
+	for _, upstream := range s.config.Upstreams {
+		reply, err := exchange(upstream, query, tcp, s.config.upstreamTimeout)
+		if err == nil {
+			return reply, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// exchange 向一个上游服务器发送查询。截断的 UDP 回复原样返回，客户端会自己改用 TCP 重试
+func exchange(upstream string, query []byte, tcp bool, timeout time.Duration) ([]byte, error) {
+	if tcp {
+		return exchangeTCP(upstream, query, timeout)
+	}
+	conn, err := net.DialTimeout("udp", upstream, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, maxMessageSize)
+	id := binary.BigEndian.Uint16(query)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		var p dnsmessage.Parser
+		header, err := p.Start(buf[:n])
+		//忽略 ID 不匹配的迟到回复
+		if err != nil || header.ID != id {
+			continue
+		}
+		return append([]byte(nil), buf[:n]...), nil
+	}
+}
+
+func exchangeTCP(upstream string, query []byte, timeout time.Duration) ([]byte, error) {
+	conn, err := net.DialTimeout("tcp", upstream, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	if err := writeTCPMessage(conn, query); err != nil {
+		return nil, err
+	}
+	return readTCPMessage(conn)
+}
+
+func readTCPMessage(r io.Reader) ([]byte, error) {
+	var length [2]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, err
+	}
+	msg := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func writeTCPMessage(w io.Writer, msg []byte) error {
+	buf := make([]byte, 2+len(msg))
+	binary.BigEndian.PutUint16(buf, uint16(len(msg)))
+	copy(buf[2:], msg)
+	_, err := w.Write(buf)
+	return err
+}
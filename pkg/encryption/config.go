@@ -0,0 +1,84 @@
+// Package encryption implements encryption at rest: values of selected
+// resources are sealed with AES-GCM before they reach the store, using keys
+// read from a local config file.
+package encryption
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Config 是加密配置，从 JSON 文件读取，比如
+//
+//	{"resources": ["secrets"], "keys": [{"name": "key2", "secret": "<base64>"}, {"name": "key1", "secret": "<base64>"}]}
+//
+// 第一个 key 用来加密，所有列出的 key 都可以解密。轮换时先把新 key 加到列表末尾让所有 API server 都能解密，
+// 再把它移到第一个，重新写入所有对象之后删除旧 key
+type Config struct {
+	// Resources 是需要加密的资源的复数名
+	Resources []string `json:"resources"`
+	Keys      []Key    `json:"keys"`
+}
+
+type Key struct {
+	// Name 写在每个加密值的前缀里，解密时按它找到 key，不能包含 ':'
+	Name string `json:"name"`
+	// Secret 是 base64 编码的 16、24 或 32 字节 AES 密钥
+	Secret string `json:"secret"`
+}
+
+// LoadConfig 读取并检查配置文件
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+func (c *Config) validate() error {
+	if len(c.Keys) == 0 {
+		return fmt.Errorf("keys must not be empty")
+	}
+	names := map[string]bool{}
+	for i, key := range c.Keys {
+		if key.Name == "" || strings.Contains(key.Name, ":") {
+			return fmt.Errorf("keys[%d].name must be non-empty and must not contain ':'", i)
+		}
+		if names[key.Name] {
+			return fmt.Errorf("keys[%d].name %q is used by more than one key", i, key.Name)
+		}
+		names[key.Name] = true
+		if _, err := key.bytes(); err != nil {
+			return fmt.Errorf("keys[%d].secret: %w", i, err)
+		}
+	}
+	for i, resource := range c.Resources {
+		if resource == "" {
+			return fmt.Errorf("resources[%d] must not be empty", i)
+		}
+	}
+	return nil
+}
+
+func (k Key) bytes() ([]byte, error) {
+	secret, err := base64.StdEncoding.DecodeString(k.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("must be base64 encoded: %w", err)
+	}
+	switch len(secret) {
+	case 16, 24, 32:
+		return secret, nil
+	}
+	return nil, fmt.Errorf("must be 16, 24 or 32 bytes, got %d", len(secret))
+}
@@ -0,0 +1,110 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// Prefix 标记一个加密过的值，完整的格式是 enc:aesgcm:v1:<key name>:<base64(nonce || ciphertext)>。
+// 没有这个前缀的值被当成明文读出，所以开启加密之前写入的对象仍然可以读取
+const Prefix = "enc:aesgcm:v1:"
+
+// Transformer 加密和解密单个值，配置可以在运行时替换
+type Transformer struct {
+	state atomic.Pointer[transformerState]
+}
+
+type transformerState struct {
+	resources map[string]bool
+	primary   string
+	keys      map[string]cipher.AEAD
+}
+
+func NewTransformer(config *Config) (*Transformer, error) {
+	t := &Transformer{}
+	if err := t.Reload(config); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Reload 换成新的配置，之后的写入使用新配置的第一个 key
+func (t *Transformer) Reload(config *Config) error {
+	if err := config.validate(); err != nil {
+		return err
+	}
+	state := &transformerState{resources: map[string]bool{}, keys: map[string]cipher.AEAD{}}
+	for _, resource := range config.Resources {
+		state.resources[resource] = true
+	}
+	for _, key := range config.Keys {
+		secret, _ := key.bytes()
+		block, err := aes.NewCipher(secret)
+		if err != nil {
+			return fmt.Errorf("key %s: %w", key.Name, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return fmt.Errorf("key %s: %w", key.Name, err)
+		}
+		state.keys[key.Name] = aead
+	}
+	state.primary = config.Keys[0].Name
+	t.state.Store(state)
+	return nil
+}
+
+// Encrypts 返回写入 resource 时是否需要加密
+func (t *Transformer) Encrypts(resource string) bool {
+	return t.state.Load().resources[resource]
+}
+
+// PrimaryKey 返回加密使用的 key 的名字
+func (t *Transformer) PrimaryKey() string {
+	return t.state.Load().primary
+}
+
+// Encrypt 用第一个 key 加密 plaintext。additionalData 参与认证但不加密，调用者传入对象和键的位置，
+// 这样密文被挪到别的对象或者别的键下面时解密会失败
+func (t *Transformer) Encrypt(plaintext, additionalData []byte) (string, error) {
+	state := t.state.Load()
+	aead := state.keys[state.primary]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, additionalData)
+	return Prefix + state.primary + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密 Encrypt 返回的值，value 不是加密过的值时原样返回
+func (t *Transformer) Decrypt(value string, additionalData []byte) ([]byte, error) {
+	if !strings.HasPrefix(value, Prefix) {
+		return []byte(value), nil
+	}
+	name, encoded, ok := strings.Cut(value[len(Prefix):], ":")
+	if !ok {
+		return nil, fmt.Errorf("malformed encrypted value")
+	}
+	aead, ok := t.state.Load().keys[name]
+	if !ok {
+		return nil, fmt.Errorf("value was encrypted with key %q which is not in the encryption config", name)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("malformed encrypted value: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("malformed encrypted value: too short")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting with key %q: %w", name, err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,268 @@
+package informer
+
+import (
+	"fmt"
+	"mini-k8s/pkg/api"
+	"sync"
+)
+
+// runnable 是 factory 管理的 informer，与具体的资源类型无关
+type runnable interface {
+	Run(stop <-chan struct{})
+	HasSynced() bool
+	ListMetadata() []api.ObjectMeta
+}
+
+// SharedInformerFactory 为每种资源最多创建一个 informer，让同一个进程里的 controller 共享缓存。
+// 它实现了 controller.Listers，第一次读取某种资源时才创建对应的 informer
+type SharedInformerFactory struct {
+	client *api.Client
+
+	mu        sync.Mutex
+	informers map[string]runnable
+	// stop 在 Start 之后不为 nil，之后才创建的 informer 会立刻启动
+	stop <-chan struct{}
+}
+
+func NewSharedInformerFactory(client *api.Client) *SharedInformerFactory {
+	return &SharedInformerFactory{client: client, informers: map[string]runnable{}}
+}
+
+// informerFor 返回资源的 informer，不存在时用 create 创建
+func informerFor[T any, PT object[T]](f *SharedInformerFactory, resource string, create func() *Informer[T, PT]) *Informer[T, PT] {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if inf, ok := f.informers[resource]; ok {
+		return inf.(*Informer[T, PT])
+	}
+	inf := create()
+	f.informers[resource] = inf
+	if f.stop != nil {
+		go inf.Run(f.stop)
+	}
+	return inf
+}
+
+// Start 在后台运行所有已经创建的 informer，直到 stop 被关闭
+func (f *SharedInformerFactory) Start(stop <-chan struct{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.stop != nil {
+		return
+	}
+	f.stop = stop
+	for _, inf := range f.informers {
+		go inf.Run(stop)
+	}
+}
+
+// HasSynced 表示所有 informer 是否都已经完成了第一次 list
+func (f *SharedInformerFactory) HasSynced() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, inf := range f.informers {
+		if !inf.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *SharedInformerFactory) Pods() *Informer[api.Pod, *api.Pod] {
+	return informerFor(f, "pods", func() *Informer[api.Pod, *api.Pod] {
+		return newInformer[api.Pod, *api.Pod]("pods", func() ([]api.Pod, error) { return f.client.ListAllPods("") }, f.client.WatchAllPods)
+	})
+}
+
+func (f *SharedInformerFactory) Nodes() *Informer[api.Node, *api.Node] {
+	return informerFor(f, "nodes", func() *Informer[api.Node, *api.Node] {
+		return newInformer[api.Node, *api.Node]("nodes", func() ([]api.Node, error) { return f.client.ListNodes("") }, f.client.WatchNodes)
+	})
+}
+
+func (f *SharedInformerFactory) Namespaces() *Informer[api.Namespace, *api.Namespace] {
+	return informerFor(f, "namespaces", func() *Informer[api.Namespace, *api.Namespace] {
+		return newInformer[api.Namespace, *api.Namespace]("namespaces", f.client.ListNamespaces, f.client.WatchNamespaces)
+	})
+}
+
+func (f *SharedInformerFactory) ReplicaSets() *Informer[api.ReplicaSet, *api.ReplicaSet] {
+	return informerFor(f, "replicasets", func() *Informer[api.ReplicaSet, *api.ReplicaSet] {
+		return newInformer[api.ReplicaSet, *api.ReplicaSet]("replicasets", f.client.ListAllReplicaSets, f.client.WatchAllReplicaSets)
+	})
+}
+
+func (f *SharedInformerFactory) Deployments() *Informer[api.Deployment, *api.Deployment] {
+	return informerFor(f, "deployments", func() *Informer[api.Deployment, *api.Deployment] {
+		return newInformer[api.Deployment, *api.Deployment]("deployments", f.client.ListAllDeployments, f.client.WatchAllDeployments)
+	})
+}
+
+func (f *SharedInformerFactory) Jobs() *Informer[api.Job, *api.Job] {
+	return informerFor(f, "jobs", func() *Informer[api.Job, *api.Job] {
+		return newInformer[api.Job, *api.Job]("jobs", f.client.ListAllJobs, f.client.WatchAllJobs)
+	})
+}
+
+func (f *SharedInformerFactory) CronJobs() *Informer[api.CronJob, *api.CronJob] {
+	return informerFor(f, "cronjobs", func() *Informer[api.CronJob, *api.CronJob] {
+		return newInformer[api.CronJob, *api.CronJob]("cronjobs", f.client.ListAllCronJobs, f.client.WatchAllCronJobs)
+	})
+}
+
+func (f *SharedInformerFactory) DaemonSets() *Informer[api.DaemonSet, *api.DaemonSet] {
+	return informerFor(f, "daemonsets", func() *Informer[api.DaemonSet, *api.DaemonSet] {
+		return newInformer[api.DaemonSet, *api.DaemonSet]("daemonsets", f.client.ListAllDaemonSets, f.client.WatchAllDaemonSets)
+	})
+}
+
+func (f *SharedInformerFactory) StatefulSets() *Informer[api.StatefulSet, *api.StatefulSet] {
+	return informerFor(f, "statefulsets", func() *Informer[api.StatefulSet, *api.StatefulSet] {
+		return newInformer[api.StatefulSet, *api.StatefulSet]("statefulsets", f.client.ListAllStatefulSets, f.client.WatchAllStatefulSets)
+	})
+}
+
+func (f *SharedInformerFactory) ControllerRevisions() *Informer[api.ControllerRevision, *api.ControllerRevision] {
+	return informerFor(f, "controllerrevisions", func() *Informer[api.ControllerRevision, *api.ControllerRevision] {
+		return newInformer[api.ControllerRevision, *api.ControllerRevision]("controllerrevisions", f.client.ListAllControllerRevisions, f.client.WatchAllControllerRevisions)
+	})
+}
+
+func (f *SharedInformerFactory) Services() *Informer[api.Service, *api.Service] {
+	return informerFor(f, "services", func() *Informer[api.Service, *api.Service] {
+		return newInformer[api.Service, *api.Service]("services", f.client.ListAllServices, f.client.WatchAllServices)
+	})
+}
+
+func (f *SharedInformerFactory) Endpoints() *Informer[api.Endpoints, *api.Endpoints] {
+	return informerFor(f, "endpoints", func() *Informer[api.Endpoints, *api.Endpoints] {
+		return newInformer[api.Endpoints, *api.Endpoints]("endpoints", f.client.ListAllEndpoints, f.client.WatchAllEndpoints)
+	})
+}
+
+func (f *SharedInformerFactory) Ingresses() *Informer[api.Ingress, *api.Ingress] {
+	return informerFor(f, "ingresses", func() *Informer[api.Ingress, *api.Ingress] {
+		return newInformer[api.Ingress, *api.Ingress]("ingresses", f.client.ListAllIngresses, f.client.WatchAllIngresses)
+	})
+}
+
+func (f *SharedInformerFactory) Secrets() *Informer[api.Secret, *api.Secret] {
+	return informerFor(f, "secrets", func() *Informer[api.Secret, *api.Secret] {
+		return newInformer[api.Secret, *api.Secret]("secrets", f.client.ListAllSecrets, f.client.WatchAllSecrets)
+	})
+}
+
+func (f *SharedInformerFactory) PersistentVolumes() *Informer[api.PersistentVolume, *api.PersistentVolume] {
+	return informerFor(f, "persistentvolumes", func() *Informer[api.PersistentVolume, *api.PersistentVolume] {
+		return newInformer[api.PersistentVolume, *api.PersistentVolume]("persistentvolumes", f.client.ListPersistentVolumes, f.client.WatchPersistentVolumes)
+	})
+}
+
+func (f *SharedInformerFactory) PersistentVolumeClaims() *Informer[api.PersistentVolumeClaim, *api.PersistentVolumeClaim] {
+	return informerFor(f, "persistentvolumeclaims", func() *Informer[api.PersistentVolumeClaim, *api.PersistentVolumeClaim] {
+		return newInformer[api.PersistentVolumeClaim, *api.PersistentVolumeClaim]("persistentvolumeclaims", f.client.ListAllPersistentVolumeClaims, f.client.WatchAllPersistentVolumeClaims)
+	})
+}
+
+func (f *SharedInformerFactory) StorageClasses() *Informer[api.StorageClass, *api.StorageClass] {
+	return informerFor(f, "storageclasses", func() *Informer[api.StorageClass, *api.StorageClass] {
+		return newInformer[api.StorageClass, *api.StorageClass]("storageclasses", f.client.ListStorageClasses, f.client.WatchStorageClasses)
+	})
+}
+
+func (f *SharedInformerFactory) HorizontalPodAutoscalers() *Informer[api.HorizontalPodAutoscaler, *api.HorizontalPodAutoscaler] {
+	return informerFor(f, "horizontalpodautoscalers", func() *Informer[api.HorizontalPodAutoscaler, *api.HorizontalPodAutoscaler] {
+		return newInformer[api.HorizontalPodAutoscaler, *api.HorizontalPodAutoscaler]("horizontalpodautoscalers", f.client.ListAllHorizontalPodAutoscalers, f.client.WatchAllHorizontalPodAutoscalers)
+	})
+}
+
+func (f *SharedInformerFactory) PodDisruptionBudgets() *Informer[api.PodDisruptionBudget, *api.PodDisruptionBudget] {
+	return informerFor(f, "poddisruptionbudgets", func() *Informer[api.PodDisruptionBudget, *api.PodDisruptionBudget] {
+		return newInformer[api.PodDisruptionBudget, *api.PodDisruptionBudget]("poddisruptionbudgets", f.client.ListAllPodDisruptionBudgets, f.client.WatchAllPodDisruptionBudgets)
+	})
+}
+
+func (f *SharedInformerFactory) ConfigMaps() *Informer[api.ConfigMap, *api.ConfigMap] {
+	return informerFor(f, "configmaps", func() *Informer[api.ConfigMap, *api.ConfigMap] {
+		return newInformer[api.ConfigMap, *api.ConfigMap]("configmaps", f.client.ListAllConfigMaps, f.client.WatchAllConfigMaps)
+	})
+}
+
+// metadataInformers 让 ListMetadata 可以按复数名找到 informer
+var metadataInformers = map[string]func(f *SharedInformerFactory) runnable{
+	"pods":                     func(f *SharedInformerFactory) runnable { return f.Pods() },
+	"nodes":                    func(f *SharedInformerFactory) runnable { return f.Nodes() },
+	"namespaces":               func(f *SharedInformerFactory) runnable { return f.Namespaces() },
+	"replicasets":              func(f *SharedInformerFactory) runnable { return f.ReplicaSets() },
+	"deployments":              func(f *SharedInformerFactory) runnable { return f.Deployments() },
+	"jobs":                     func(f *SharedInformerFactory) runnable { return f.Jobs() },
+	"cronjobs":                 func(f *SharedInformerFactory) runnable { return f.CronJobs() },
+	"daemonsets":               func(f *SharedInformerFactory) runnable { return f.DaemonSets() },
+	"statefulsets":             func(f *SharedInformerFactory) runnable { return f.StatefulSets() },
+	"controllerrevisions":      func(f *SharedInformerFactory) runnable { return f.ControllerRevisions() },
+	"services":                 func(f *SharedInformerFactory) runnable { return f.Services() },
+	"endpoints":                func(f *SharedInformerFactory) runnable { return f.Endpoints() },
+	"ingresses":                func(f *SharedInformerFactory) runnable { return f.Ingresses() },
+	"secrets":                  func(f *SharedInformerFactory) runnable { return f.Secrets() },
+	"configmaps":               func(f *SharedInformerFactory) runnable { return f.ConfigMaps() },
+	"persistentvolumes":        func(f *SharedInformerFactory) runnable { return f.PersistentVolumes() },
+	"persistentvolumeclaims":   func(f *SharedInformerFactory) runnable { return f.PersistentVolumeClaims() },
+	"storageclasses":           func(f *SharedInformerFactory) runnable { return f.StorageClasses() },
+	"horizontalpodautoscalers": func(f *SharedInformerFactory) runnable { return f.HorizontalPodAutoscalers() },
+	"poddisruptionbudgets":     func(f *SharedInformerFactory) runnable { return f.PodDisruptionBudgets() },
+}
+
+// 下面的方法实现 controller.Listers，从缓存里读取对象
+
+func (f *SharedInformerFactory) ListPods() ([]api.Pod, error)   { return f.Pods().List(), nil }
+func (f *SharedInformerFactory) ListNodes() ([]api.Node, error) { return f.Nodes().List(), nil }
+func (f *SharedInformerFactory) ListNamespaces() ([]api.Namespace, error) {
+	return f.Namespaces().List(), nil
+}
+func (f *SharedInformerFactory) ListReplicaSets() ([]api.ReplicaSet, error) {
+	return f.ReplicaSets().List(), nil
+}
+func (f *SharedInformerFactory) ListDeployments() ([]api.Deployment, error) {
+	return f.Deployments().List(), nil
+}
+func (f *SharedInformerFactory) ListJobs() ([]api.Job, error) { return f.Jobs().List(), nil }
+func (f *SharedInformerFactory) ListCronJobs() ([]api.CronJob, error) {
+	return f.CronJobs().List(), nil
+}
+func (f *SharedInformerFactory) ListDaemonSets() ([]api.DaemonSet, error) {
+	return f.DaemonSets().List(), nil
+}
+func (f *SharedInformerFactory) ListStatefulSets() ([]api.StatefulSet, error) {
+	return f.StatefulSets().List(), nil
+}
+func (f *SharedInformerFactory) ListControllerRevisions() ([]api.ControllerRevision, error) {
+	return f.ControllerRevisions().List(), nil
+}
+func (f *SharedInformerFactory) ListServices() ([]api.Service, error) {
+	return f.Services().List(), nil
+}
+func (f *SharedInformerFactory) ListEndpoints() ([]api.Endpoints, error) {
+	return f.Endpoints().List(), nil
+}
+func (f *SharedInformerFactory) ListPersistentVolumes() ([]api.PersistentVolume, error) {
+	return f.PersistentVolumes().List(), nil
+}
+func (f *SharedInformerFactory) ListPersistentVolumeClaims() ([]api.PersistentVolumeClaim, error) {
+	return f.PersistentVolumeClaims().List(), nil
+}
+func (f *SharedInformerFactory) ListStorageClasses() ([]api.StorageClass, error) {
+	return f.StorageClasses().List(), nil
+}
+func (f *SharedInformerFactory) ListHorizontalPodAutoscalers() ([]api.HorizontalPodAutoscaler, error) {
+	return f.HorizontalPodAutoscalers().List(), nil
+}
+func (f *SharedInformerFactory) ListPodDisruptionBudgets() ([]api.PodDisruptionBudget, error) {
+	return f.PodDisruptionBudgets().List(), nil
+}
+func (f *SharedInformerFactory) ListMetadata(resource string) ([]api.ObjectMeta, error) {
+	get, ok := metadataInformers[resource]
+	if !ok {
+		return nil, fmt.Errorf("no informer for resource %s", resource)
+	}
+	return get(f).ListMetadata(), nil
+}
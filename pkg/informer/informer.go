@@ -0,0 +1,152 @@
+// Package informer keeps local caches of API objects up to date with list and
+// watch, so that several controllers in one process can share them instead of
+// each listing everything from the API server on every sync.
+package informer
+
+import (
+	"log"
+	"mini-k8s/pkg/api"
+	"sync"
+	"time"
+)
+
+// retryPeriod 是 list 或者 watch 失败之后重试前等待的时间
+const retryPeriod = time.Second
+
+// object 是可以放进 informer 缓存的资源
+type object[T any] interface {
+	*T
+	api.Object
+	DeepCopy() *T
+}
+
+// Informer 用 list 和 watch 维护一种资源在所有命名空间里的本地缓存，缓存变化时调用注册的 handler
+type Informer[T any, PT object[T]] struct {
+	resource string
+	list     func() ([]T, error)
+	watch    func() (<-chan api.WatchEvent[T], func(), error)
+
+	mu       sync.RWMutex
+	items    map[string]*T
+	synced   bool
+	handlers []func()
+}
+
+func newInformer[T any, PT object[T]](resource string, list func() ([]T, error), watch func() (<-chan api.WatchEvent[T], func(), error)) *Informer[T, PT] {
+	return &Informer[T, PT]{resource: resource, list: list, watch: watch, items: map[string]*T{}}
+}
+
+// AddEventHandler 注册一个缓存变化时调用的函数。handler 在 informer 的 goroutine 里被调用，不能阻塞
+func (inf *Informer[T, PT]) AddEventHandler(handler func()) {
+	inf.mu.Lock()
+	defer inf.mu.Unlock()
+	inf.handlers = append(inf.handlers, handler)
+}
+
+// HasSynced 表示缓存是否已经完成了第一次 list
+func (inf *Informer[T, PT]) HasSynced() bool {
+	inf.mu.RLock()
+	defer inf.mu.RUnlock()
+	return inf.synced
+}
+
+// List 返回缓存里所有对象的副本
+func (inf *Informer[T, PT]) List() []T {
+	inf.mu.RLock()
+	defer inf.mu.RUnlock()
+	out := make([]T, 0, len(inf.items))
+	for _, obj := range inf.items {
+		out = append(out, *PT(obj).DeepCopy())
+	}
+	return out
+}
+
+// ListMetadata 返回缓存里所有对象元数据的副本
+func (inf *Informer[T, PT]) ListMetadata() []api.ObjectMeta {
+	inf.mu.RLock()
+	defer inf.mu.RUnlock()
+	out := make([]api.ObjectMeta, 0, len(inf.items))
+	for _, obj := range inf.items {
+		var meta api.ObjectMeta
+		PT(obj).GetObjectMeta().DeepCopyInto(&meta)
+		out = append(out, meta)
+	}
+	return out
+}
+
+// Run 持续维护缓存直到 stop 被关闭：先打开 watch 再 list，保证 list 之后的变化都能从 watch 收到；
+// watch 断开之后重新来一遍
+func (inf *Informer[T, PT]) Run(stop <-chan struct{}) {
+	for {
+		if err := inf.listAndWatch(stop); err != nil {
+			log.Printf("Error watching %s: %v", inf.resource, err)
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(retryPeriod):
+		}
+	}
+}
+
+func (inf *Informer[T, PT]) listAndWatch(stop <-chan struct{}) error {
+	events, cancel, err := inf.watch()
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	objs, err := inf.list()
+	if err != nil {
+		return err
+	}
+	inf.replace(objs)
+	for {
+		select {
+		case <-stop:
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			inf.apply(event)
+		}
+	}
+}
+
+func (inf *Informer[T, PT]) replace(objs []T) {
+	inf.mu.Lock()
+	inf.items = make(map[string]*T, len(objs))
+	for i := range objs {
+		inf.items[key(PT(&objs[i]))] = &objs[i]
+	}
+	inf.synced = true
+	handlers := inf.handlers
+	inf.mu.Unlock()
+	notify(handlers)
+}
+
+// apply 把 watch 事件合并进缓存。watch 开头的 ADDED 事件可能比 list 的结果旧，后面的事件会把它们改正
+func (inf *Informer[T, PT]) apply(event api.WatchEvent[T]) {
+	obj := event.Object
+	k := key(PT(&obj))
+	inf.mu.Lock()
+	if event.Type == api.EventDeleted {
+		delete(inf.items, k)
+	} else {
+		inf.items[k] = &obj
+	}
+	handlers := inf.handlers
+	inf.mu.Unlock()
+	notify(handlers)
+}
+
+func key(obj api.Object) string {
+	meta := obj.GetObjectMeta()
+	return meta.Namespace + "/" + meta.Name
+}
+
+func notify(handlers []func()) {
+	for _, handler := range handlers {
+		handler()
+	}
+}
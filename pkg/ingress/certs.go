@@ -0,0 +1,106 @@
+package ingress
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"mini-k8s/pkg/api"
+	"strings"
+)
+
+// certTable 按 SNI 选择证书，和 routeTable 一起整体替换
+type certTable struct {
+	hosts map[string]*tls.Certificate
+	// wildcards 以去掉 "*." 之后的后缀为键
+	wildcards map[string]*tls.Certificate
+	// fallback 在客户端没有发送 SNI 或者没有匹配的证书时使用，是最早的 Ingress 里的第一个证书
+	fallback *tls.Certificate
+}
+
+func (t *certTable) get(serverName string) *tls.Certificate {
+	name := strings.ToLower(strings.TrimSuffix(serverName, "."))
+	if cert, ok := t.hosts[name]; ok {
+		return cert
+	}
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		if cert, ok := t.wildcards[name[i+1:]]; ok {
+			return cert
+		}
+	}
+	return t.fallback
+}
+
+// parsedCert 缓存解析好的证书，secret 没有变化时不重新解析
+type parsedCert struct {
+	resourceVersion string
+	cert            *tls.Certificate
+	err             error
+}
+
+// buildCertTable 加载 Ingress 引用的 TLS secret，ingresses 需要已经按创建时间排好序。
+// cache 以 namespace/name 为键，会被更新成这次用到的 secret
+func buildCertTable(ingresses []api.Ingress, secrets []api.Secret, cache map[string]*parsedCert) *certTable {
+	bySecret := map[string]*api.Secret{}
+	for i := range secrets {
+		bySecret[secrets[i].Namespace+"/"+secrets[i].Name] = &secrets[i]
+	}
+	used := map[string]bool{}
+	load := func(key string) (*tls.Certificate, error) {
+		used[key] = true
+		secret, ok := bySecret[key]
+		if !ok {
+			delete(cache, key)
+			return nil, fmt.Errorf("secret %s not found", key)
+		}
+		if cached, ok := cache[key]; ok && cached.resourceVersion == secret.ResourceVersion {
+			return cached.cert, cached.err
+		}
+		parsed := &parsedCert{resourceVersion: secret.ResourceVersion}
+		if secret.Type != api.SecretTypeTLS {
+			parsed.err = fmt.Errorf("secret %s has type %q, not %s", key, secret.Type, api.SecretTypeTLS)
+		} else {
+			cert, err := tls.X509KeyPair(secret.Data[api.TLSCertKey], secret.Data[api.TLSPrivateKeyKey])
+			if err != nil {
+				parsed.err = fmt.Errorf("secret %s: %w", key, err)
+			} else {
+				parsed.cert = &cert
+			}
+		}
+		cache[key] = parsed
+		if parsed.err != nil {
+			log.Printf("Cannot load TLS certificate: %v", parsed.err)
+		} else {
+			log.Printf("Loaded TLS certificate from secret %s", key)
+		}
+		return parsed.cert, parsed.err
+	}
+
+	table := &certTable{hosts: map[string]*tls.Certificate{}, wildcards: map[string]*tls.Certificate{}}
+	for _, ing := range ingresses {
+		for _, entry := range ing.Spec.TLS {
+			cert, err := load(ing.Namespace + "/" + entry.SecretName)
+			if err != nil {
+				continue
+			}
+			if table.fallback == nil {
+				table.fallback = cert
+			}
+			for _, host := range entry.Hosts {
+				set, key := table.hosts, host
+				if strings.HasPrefix(host, "*.") {
+					set, key = table.wildcards, host[2:]
+				}
+				//多个 Ingress 给同一个 host 配置了证书时以最早的为准
+				if _, ok := set[key]; !ok {
+					set[key] = cert
+				}
+			}
+		}
+	}
+	for key := range cache {
+		if !used[key] {
+			delete(cache, key)
+		}
+	}
+	return table
+}
@@ -0,0 +1,137 @@
+package ingress
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"mini-k8s/pkg/api"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Config 是 Router 的配置
+type Config struct {
+	// HTTPSPort 是 HTTPS 监听的端口，ssl-redirect 生成的地址使用它，为 0 时不带端口
+	HTTPSPort int
+	// DialTimeout 是连接后端的超时时间
+	DialTimeout time.Duration
+}
+
+// Router 按 Ingress 规则把请求转发给 service 的 endpoints。Sync 构建新的路由表和证书表后整体替换，
+// 正在处理的请求继续使用旧的表，不需要加锁
+type Router struct {
+	config Config
+	state  atomic.Pointer[routerState]
+	proxy  *httputil.ReverseProxy
+
+	// mu 保护 certCache，Sync 可能被并发调用
+	mu        sync.Mutex
+	certCache map[string]*parsedCert
+}
+
+type routerState struct {
+	routes *routeTable
+	certs  *certTable
+}
+
+// targetKey 是请求 context 里选中的后端地址
+type targetKey struct{}
+
+func NewRouter(config Config) *Router {
+	if config.DialTimeout == 0 {
+		config.DialTimeout = 5 * time.Second
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{Timeout: config.DialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	r := &Router{config: config, certCache: map[string]*parsedCert{}}
+	r.proxy = &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			target := pr.In.Context().Value(targetKey{}).(string)
+			pr.SetURL(&url.URL{Scheme: "http", Host: target})
+			pr.SetXForwarded()
+			//后端按客户端请求的 host 区分虚拟主机
+			pr.Out.Host = pr.In.Host
+		},
+		Transport: transport,
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			log.Printf("Error proxying %s %s%s to %s: %v", req.Method, req.Host, req.URL.Path, req.Context().Value(targetKey{}), err)
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+		},
+	}
+	r.state.Store(&routerState{routes: buildRouteTable(nil, nil, nil), certs: &certTable{}})
+	return r
+}
+
+// Sync 根据当前的对象重新构建路由表和证书表
+func (r *Router) Sync(ingresses []api.Ingress, services []api.Service, endpoints []api.Endpoints, secrets []api.Secret) {
+	//排序会修改切片，不能影响调用者
+	ingresses = append([]api.Ingress(nil), ingresses...)
+	routes := buildRouteTable(ingresses, services, endpoints)
+	r.mu.Lock()
+	certs := buildCertTable(ingresses, secrets, r.certCache)
+	r.mu.Unlock()
+	r.state.Store(&routerState{routes: routes, certs: certs})
+}
+
+// GetCertificate 用于 tls.Config，按 SNI 选择证书
+func (r *Router) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if cert := r.state.Load().certs.get(hello.ServerName); cert != nil {
+		return cert, nil
+	}
+	return nil, fmt.Errorf("no certificate for %q", hello.ServerName)
+}
+
+// TLSConfig 返回使用 Ingress 证书的 TLS 配置，证书随 Sync 更新，不需要重新监听
+func (r *Router) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+}
+
+// Status 返回当前路由表的所有规则
+func (r *Router) Status() []RouteStatus {
+	return r.state.Load().routes.status()
+}
+
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	host := requestHost(req.Host)
+	rt := r.state.Load().routes.lookup(host, req.URL.Path)
+	if rt == nil {
+		http.Error(w, "default backend - 404", http.StatusNotFound)
+		return
+	}
+	if rt.sslRedirect && req.TLS == nil {
+		target := url.URL{Scheme: "https", Host: host, Path: req.URL.Path, RawPath: req.URL.RawPath, RawQuery: req.URL.RawQuery}
+		if r.config.HTTPSPort != 0 && r.config.HTTPSPort != 443 {
+			target.Host = net.JoinHostPort(host, strconv.Itoa(r.config.HTTPSPort))
+		}
+		http.Redirect(w, req, target.String(), http.StatusPermanentRedirect)
+		return
+	}
+	target, ok := rt.backend.pick()
+	if !ok {
+		log.Printf("No endpoints for %s%s (ingress %s): %s", host, req.URL.Path, rt.ingress, rt.backend.reason)
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	r.proxy.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), targetKey{}, target)))
+}
+
+// requestHost 去掉 Host 头里的端口，统一成小写
+func requestHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
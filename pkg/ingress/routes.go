@@ -0,0 +1,381 @@
+// Package ingress implements an HTTP(S) reverse proxy that routes requests to
+// service endpoints according to Ingress rules.
+package ingress
+
+import (
+	"fmt"
+	"mini-k8s/pkg/api"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// backend 是一个 service 端口解析出来的后端地址，按轮询选择
+type backend struct {
+	// name 是 namespace/service:port，用于日志和 /routes
+	name      string
+	addresses []string
+	// reason 说明 addresses 为什么是空的
+	reason string
+	next   atomic.Uint64
+}
+
+func (b *backend) pick() (string, bool) {
+	if len(b.addresses) == 0 {
+		return "", false
+	}
+	n := b.next.Add(1) - 1
+	return b.addresses[n%uint64(len(b.addresses))], true
+}
+
+// route 是一条 path 规则
+type route struct {
+	path     string
+	pathType api.PathType
+	ingress  string
+	backend  *backend
+	// sslRedirect 为 true 时通过 HTTP 收到的请求被重定向到 HTTPS
+	sslRedirect bool
+}
+
+// hostRoutes 是一个 host 下的所有 path 规则
+type hostRoutes struct {
+	exact map[string]*route
+	// prefixes 按路径段从长到短排列，第一个匹配的就是最长前缀
+	prefixes []*route
+}
+
+func newHostRoutes() *hostRoutes {
+	return &hostRoutes{exact: map[string]*route{}}
+}
+
+// add 添加一条规则，同一个 host 下 path 和 pathType 都相同的规则只保留先添加的
+func (h *hostRoutes) add(r *route) bool {
+	if r.pathType == api.PathTypeExact {
+		if _, ok := h.exact[r.path]; ok {
+			return false
+		}
+		h.exact[r.path] = r
+		return true
+	}
+	for _, existing := range h.prefixes {
+		if existing.path == r.path {
+			return false
+		}
+	}
+	h.prefixes = append(h.prefixes, r)
+	return true
+}
+
+func (h *hostRoutes) sort() {
+	sort.SliceStable(h.prefixes, func(i, j int) bool {
+		return len(pathSegments(h.prefixes[i].path)) > len(pathSegments(h.prefixes[j].path))
+	})
+}
+
+// match 返回 path 匹配的规则：Exact 优先，其次是最长的 Prefix
+func (h *hostRoutes) match(path string) *route {
+	if h == nil {
+		return nil
+	}
+	if r, ok := h.exact[path]; ok {
+		return r
+	}
+	segments := pathSegments(path)
+	for _, r := range h.prefixes {
+		if hasPrefixSegments(segments, pathSegments(r.path)) {
+			return r
+		}
+	}
+	return nil
+}
+
+// pathSegments 按 / 切分路径，忽略空的段，所以 /foo/ 和 /foo 是同一个前缀
+func pathSegments(path string) []string {
+	var segments []string
+	for _, s := range strings.Split(path, "/") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments
+}
+
+func hasPrefixSegments(path, prefix []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// routeTable 是根据某一时刻的 Ingress、Service 和 Endpoints 构建的只读路由表，更新时整体替换
+type routeTable struct {
+	hosts map[string]*hostRoutes
+	// wildcards 以去掉 "*." 之后的后缀为键
+	wildcards map[string]*hostRoutes
+	// anyHost 是没有设置 host 的规则
+	anyHost        *hostRoutes
+	defaultBackend *route
+}
+
+// lookup 按 host 精确匹配、通配符匹配、不限 host 的顺序找到第一条匹配的规则，都没有时使用 defaultBackend
+func (t *routeTable) lookup(host, path string) *route {
+	if r := t.hosts[host].match(path); r != nil {
+		return r
+	}
+	if i := strings.IndexByte(host, '.'); i > 0 {
+		if r := t.wildcards[host[i+1:]].match(path); r != nil {
+			return r
+		}
+	}
+	if r := t.anyHost.match(path); r != nil {
+		return r
+	}
+	return t.defaultBackend
+}
+
+// SSLRedirectAnnotation 设为 "true" 时，Ingress 里配置了 TLS 的 host 通过 HTTP 访问会被重定向到 HTTPS
+const SSLRedirectAnnotation = "ingress.kubernetes.io/ssl-redirect"
+
+// buildRouteTable 按创建时间从早到晚处理 Ingress，多个 Ingress 写了同样的 host 和 path 时以最早的为准
+func buildRouteTable(ingresses []api.Ingress, services []api.Service, endpoints []api.Endpoints) *routeTable {
+	sort.SliceStable(ingresses, func(i, j int) bool {
+		a, b := ingresses[i], ingresses[j]
+		if ta, tb := creationTime(a.CreationTimestamp), creationTime(b.CreationTimestamp); !ta.Equal(tb) {
+			return ta.Before(tb)
+		}
+		return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+	})
+	resolver := newBackendResolver(services, endpoints)
+	table := &routeTable{
+		hosts:     map[string]*hostRoutes{},
+		wildcards: map[string]*hostRoutes{},
+		anyHost:   newHostRoutes(),
+	}
+	for i := range ingresses {
+		ing := &ingresses[i]
+		name := ing.Namespace + "/" + ing.Name
+		tlsHosts := map[string]bool{}
+		for _, tls := range ing.Spec.TLS {
+			for _, host := range tls.Hosts {
+				tlsHosts[host] = true
+			}
+		}
+		redirect := ing.Annotations[SSLRedirectAnnotation] == "true"
+		if ing.Spec.DefaultBackend != nil && table.defaultBackend == nil {
+			table.defaultBackend = &route{
+				path:     "/",
+				pathType: api.PathTypePrefix,
+				ingress:  name,
+				backend:  resolver.resolve(ing.Namespace, ing.Spec.DefaultBackend),
+			}
+		}
+		for _, rule := range ing.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+			routes := table.anyHost
+			if rule.Host != "" {
+				set, key := table.hosts, rule.Host
+				if strings.HasPrefix(rule.Host, "*.") {
+					set, key = table.wildcards, rule.Host[2:]
+				}
+				if set[key] == nil {
+					set[key] = newHostRoutes()
+				}
+				routes = set[key]
+			}
+			for _, p := range rule.HTTP.Paths {
+				r := &route{
+					path:        p.Path,
+					pathType:    p.PathType,
+					ingress:     name,
+					backend:     resolver.resolve(ing.Namespace, &p.Backend),
+					sslRedirect: redirect && rule.Host != "" && tlsHosts[rule.Host],
+				}
+				if r.path == "" {
+					r.path = "/"
+				}
+				if r.pathType == "" {
+					r.pathType = api.PathTypePrefix
+				}
+				routes.add(r)
+			}
+		}
+	}
+	for _, routes := range table.hosts {
+		routes.sort()
+	}
+	for _, routes := range table.wildcards {
+		routes.sort()
+	}
+	table.anyHost.sort()
+	return table
+}
+
+func creationTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+// backendResolver 把 Ingress 里引用的 service 端口解析成后端地址，同一个 service 端口共用一个 backend，
+// 这样轮询的计数在多条规则之间是共享的
+type backendResolver struct {
+	services  map[string]*api.Service
+	endpoints map[string]*api.Endpoints
+	backends  map[string]*backend
+}
+
+func newBackendResolver(services []api.Service, endpoints []api.Endpoints) *backendResolver {
+	r := &backendResolver{
+		services:  map[string]*api.Service{},
+		endpoints: map[string]*api.Endpoints{},
+		backends:  map[string]*backend{},
+	}
+	for i := range services {
+		r.services[services[i].Namespace+"/"+services[i].Name] = &services[i]
+	}
+	for i := range endpoints {
+		r.endpoints[endpoints[i].Namespace+"/"+endpoints[i].Name] = &endpoints[i]
+	}
+	return r
+}
+
+func (r *backendResolver) resolve(namespace string, ib *api.IngressBackend) *backend {
+	if ib == nil || ib.Service == nil {
+		return &backend{name: "<none>", reason: "backend has no service"}
+	}
+	key := namespace + "/" + ib.Service.Name
+	port := ib.Service.Port.Name
+	if port == "" {
+		port = fmt.Sprint(ib.Service.Port.Number)
+	}
+	name := key + ":" + port
+	if b, ok := r.backends[name]; ok {
+		return b
+	}
+	b := &backend{name: name}
+	r.backends[name] = b
+
+	svc, ok := r.services[key]
+	if !ok {
+		b.reason = "service " + key + " not found"
+		return b
+	}
+	var svcPort *api.ServicePort
+	for i := range svc.Spec.Ports {
+		p := &svc.Spec.Ports[i]
+		if p.Protocol != "" && p.Protocol != api.ProtocolTCP {
+			continue
+		}
+		if (ib.Service.Port.Name != "" && p.Name == ib.Service.Port.Name) || (ib.Service.Port.Number != 0 && p.Port == ib.Service.Port.Number) {
+			svcPort = p
+			break
+		}
+	}
+	if svcPort == nil {
+		b.reason = "service " + key + " has no TCP port " + port
+		return b
+	}
+	ep, ok := r.endpoints[key]
+	if !ok {
+		b.reason = "no endpoints for service " + key
+		return b
+	}
+	//endpoints 的端口和 service 的端口按名字对应
+	seen := map[string]bool{}
+	for _, subset := range ep.Subsets {
+		for _, p := range subset.Ports {
+			if p.Name != svcPort.Name || (p.Protocol != "" && p.Protocol != api.ProtocolTCP) {
+				continue
+			}
+			for _, addr := range subset.Addresses {
+				target := joinHostPort(addr.IP, p.Port)
+				if !seen[target] {
+					seen[target] = true
+					b.addresses = append(b.addresses, target)
+				}
+			}
+		}
+	}
+	sort.Strings(b.addresses)
+	if len(b.addresses) == 0 {
+		b.reason = "no ready endpoints for service " + key
+	}
+	return b
+}
+
+func joinHostPort(ip string, port int) string {
+	if strings.Contains(ip, ":") {
+		return fmt.Sprintf("[%s]:%d", ip, port)
+	}
+	return fmt.Sprintf("%s:%d", ip, port)
+}
+
+// RouteStatus 描述路由表里的一条规则，供 /routes 排查问题
+type RouteStatus struct {
+	Host      string   `json:"host"`
+	Path      string   `json:"path"`
+	PathType  string   `json:"pathType"`
+	Ingress   string   `json:"ingress"`
+	Backend   string   `json:"backend"`
+	Endpoints []string `json:"endpoints"`
+	Reason    string   `json:"reason,omitempty"`
+}
+
+func (t *routeTable) status() []RouteStatus {
+	var out []RouteStatus
+	add := func(host string, r *route) {
+		out = append(out, RouteStatus{
+			Host:      host,
+			Path:      r.path,
+			PathType:  string(r.pathType),
+			Ingress:   r.ingress,
+			Backend:   r.backend.name,
+			Endpoints: append([]string{}, r.backend.addresses...),
+			Reason:    r.backend.reason,
+		})
+	}
+	addAll := func(host string, h *hostRoutes) {
+		paths := make([]string, 0, len(h.exact))
+		for path := range h.exact {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			add(host, h.exact[path])
+		}
+		for _, r := range h.prefixes {
+			add(host, r)
+		}
+	}
+	hosts := make([]string, 0, len(t.hosts))
+	for host := range t.hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		addAll(host, t.hosts[host])
+	}
+	suffixes := make([]string, 0, len(t.wildcards))
+	for suffix := range t.wildcards {
+		suffixes = append(suffixes, suffix)
+	}
+	sort.Strings(suffixes)
+	for _, suffix := range suffixes {
+		addAll("*."+suffix, t.wildcards[suffix])
+	}
+	addAll("*", t.anyHost)
+	if t.defaultBackend != nil {
+		add("<default>", t.defaultBackend)
+	}
+	return out
+}
@@ -0,0 +1,348 @@
+// Package leaderelection lets several replicas of a component agree on a single
+// leader through a Lease object on the API server. Every write to the lease
+// carries the resourceVersion that was read, so two candidates racing for the
+// same lease cannot both win.
+package leaderelection
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	mathrand "math/rand"
+	"mini-k8s/pkg/api"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jitterFactor 让重试间隔在 RetryPeriod 到 1.2 倍 RetryPeriod 之间随机，避免候选者同时发请求
+const jitterFactor = 0.2
+
+// Callbacks 是领导权变化时调用的函数
+type Callbacks struct {
+	// OnStartedLeading 在获得领导权之后在新的 goroutine 里调用，stop 在失去领导权时被关闭。
+	// 它返回之后才会调用 OnStoppedLeading 并重新竞选
+	OnStartedLeading func(stop <-chan struct{})
+	// OnStoppedLeading 在失去领导权、OnStartedLeading 返回之后调用，可以为 nil
+	OnStoppedLeading func()
+	// OnNewLeader 在观察到的 leader 变化时调用，可以为 nil
+	OnNewLeader func(identity string)
+}
+
+type Config struct {
+	Client *api.Client
+	// Namespace 和 Name 是用来选举的 Lease
+	Namespace string
+	Name      string
+	// Identity 是这个候选者的唯一标识，写进 Lease 的 holderIdentity
+	Identity string
+	// LeaseDuration 是其他候选者观察到 lease 没有续约之后，要等多久才能抢占它
+	LeaseDuration time.Duration
+	// RenewDeadline 是 leader 续约连续失败多久之后放弃领导权，必须小于 LeaseDuration
+	RenewDeadline time.Duration
+	// RetryPeriod 是每次尝试获取或者续约之间的间隔
+	RetryPeriod time.Duration
+	// ReleaseOnCancel 为 true 时，Run 因为 stop 被关闭而退出前会释放 lease，让其他候选者不用等到 lease 过期
+	ReleaseOnCancel bool
+	Callbacks       Callbacks
+}
+
+// LeaderElector 通过 Lease 竞选 leader
+type LeaderElector struct {
+	config Config
+
+	mu sync.Mutex
+	// observed 是最近一次读到或者写入的 lease，observedTime 是本地观察到它的 spec 变化的时间。
+	// 判断 lease 是否过期只用本地时间，不要求各个进程的时钟一致
+	observed       *api.Lease
+	observedTime   time.Time
+	reportedLeader string
+}
+
+func NewLeaderElector(config Config) (*LeaderElector, error) {
+	if config.Client == nil {
+		return nil, fmt.Errorf("client must be specified")
+	}
+	if config.Name == "" {
+		return nil, fmt.Errorf("lease name must be specified")
+	}
+	if config.Identity == "" {
+		return nil, fmt.Errorf("identity must be specified")
+	}
+	if config.Namespace == "" {
+		config.Namespace = api.NamespaceSystem
+	}
+	if config.LeaseDuration <= config.RenewDeadline {
+		return nil, fmt.Errorf("lease duration %s must be greater than renew deadline %s", config.LeaseDuration, config.RenewDeadline)
+	}
+	if config.RenewDeadline <= time.Duration(float64(config.RetryPeriod)*(1+jitterFactor)) {
+		return nil, fmt.Errorf("renew deadline %s must be greater than retry period %s times %.1f", config.RenewDeadline, config.RetryPeriod, 1+jitterFactor)
+	}
+	if config.RetryPeriod <= 0 {
+		return nil, fmt.Errorf("retry period must be positive")
+	}
+	if config.Callbacks.OnStartedLeading == nil {
+		return nil, fmt.Errorf("OnStartedLeading callback must be specified")
+	}
+	return &LeaderElector{config: config}, nil
+}
+
+// Run 竞选 leader，获得领导权后运行 OnStartedLeading 并不断续约；失去领导权后等它退出、调用 OnStoppedLeading，
+// 然后重新竞选。stop 被关闭时返回
+func (le *LeaderElector) Run(stop <-chan struct{}) {
+	for {
+		if !le.acquire(stop) {
+			return
+		}
+		leading := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			le.config.Callbacks.OnStartedLeading(leading)
+		}()
+		le.renew(stop)
+		close(leading)
+		<-done
+		select {
+		case <-stop:
+			if le.config.ReleaseOnCancel {
+				le.release()
+			}
+		default:
+		}
+		if le.config.Callbacks.OnStoppedLeading != nil {
+			le.config.Callbacks.OnStoppedLeading()
+		}
+		select {
+		case <-stop:
+			return
+		default:
+		}
+	}
+}
+
+// IsLeader 表示最近一次观察到的 lease 是否由自己持有
+func (le *LeaderElector) IsLeader() bool {
+	return le.GetLeader() == le.config.Identity
+}
+
+// GetLeader 返回最近一次观察到的 leader
+func (le *LeaderElector) GetLeader() string {
+	le.mu.Lock()
+	defer le.mu.Unlock()
+	if le.observed == nil {
+		return ""
+	}
+	return le.observed.Spec.HolderIdentity
+}
+
+// acquire 每隔 RetryPeriod 尝试一次，直到获得领导权返回 true，或者 stop 被关闭返回 false
+func (le *LeaderElector) acquire(stop <-chan struct{}) bool {
+	log.Printf("Attempting to acquire lease %s/%s as %s", le.config.Namespace, le.config.Name, le.config.Identity)
+	for {
+		if le.tryAcquireOrRenew() {
+			log.Printf("Successfully acquired lease %s/%s", le.config.Namespace, le.config.Name)
+			return true
+		}
+		select {
+		case <-stop:
+			return false
+		case <-time.After(le.jitteredRetryPeriod()):
+		}
+	}
+}
+
+// renew 每隔 RetryPeriod 续约一次，直到续约在 RenewDeadline 内一直失败、lease 被别人拿走，或者 stop 被关闭
+func (le *LeaderElector) renew(stop <-chan struct{}) {
+	for {
+		deadline := time.Now().Add(le.config.RenewDeadline)
+		for !le.tryAcquireOrRenew() {
+			if leader := le.GetLeader(); leader != le.config.Identity {
+				log.Printf("Lease %s/%s was taken by %s", le.config.Namespace, le.config.Name, leader)
+				return
+			}
+			if time.Now().After(deadline) {
+				log.Printf("Failed to renew lease %s/%s within %s", le.config.Namespace, le.config.Name, le.config.RenewDeadline)
+				return
+			}
+			select {
+			case <-stop:
+				return
+			case <-time.After(le.jitteredRetryPeriod()):
+			}
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(le.config.RetryPeriod):
+		}
+	}
+}
+
+// tryAcquireOrRenew 读取 lease，在它不存在、已经过期或者属于自己时写入自己的身份。
+// 写入带着读到的 resourceVersion，和别人同时写时只有一个会成功
+func (le *LeaderElector) tryAcquireOrRenew() bool {
+	now := time.Now()
+	client := le.config.Client
+	lease, err := client.GetLease(le.config.Namespace, le.config.Name)
+	if err != nil {
+		if !strings.Contains(err.Error(), "not found") {
+			log.Printf("Error getting lease %s/%s: %v", le.config.Namespace, le.config.Name, err)
+			return false
+		}
+		lease = &api.Lease{
+			ObjectMeta: api.ObjectMeta{Name: le.config.Name, Namespace: le.config.Namespace},
+			Spec:       le.newSpec(api.LeaseSpec{}, now),
+		}
+		created, err := client.CreateLease(le.config.Namespace, lease)
+		if err != nil {
+			log.Printf("Error creating lease %s/%s: %v", le.config.Namespace, le.config.Name, err)
+			return false
+		}
+		le.setObserved(created, now)
+		return true
+	}
+
+	le.mu.Lock()
+	if le.observed == nil || !reflect.DeepEqual(le.observed.Spec, lease.Spec) {
+		le.observedTime = now
+	}
+	le.observed = lease
+	observedTime := le.observedTime
+	le.mu.Unlock()
+	le.reportLeader(lease.Spec.HolderIdentity)
+
+	holder := lease.Spec.HolderIdentity
+	expiry := observedTime.Add(time.Duration(lease.Spec.LeaseDurationSeconds) * time.Second)
+	if holder != "" && holder != le.config.Identity && expiry.After(now) {
+		return false
+	}
+
+	//observed 要保持读到的样子，写入失败时不能以为自己已经持有 lease
+	lease = lease.DeepCopy()
+	lease.Spec = le.newSpec(lease.Spec, now)
+	updated, err := client.UpdateLease(lease)
+	if err != nil {
+		log.Printf("Error updating lease %s/%s: %v", le.config.Namespace, le.config.Name, err)
+		return false
+	}
+	le.setObserved(updated, now)
+	return true
+}
+
+// newSpec 返回由自己持有、在 now 续约的 lease spec。之前不是自己持有时记一次领导权转移
+func (le *LeaderElector) newSpec(old api.LeaseSpec, now time.Time) api.LeaseSpec {
+	spec := old
+	if spec.HolderIdentity != le.config.Identity {
+		if spec.HolderIdentity != "" {
+			spec.LeaseTransitions++
+		}
+		spec.HolderIdentity = le.config.Identity
+		spec.AcquireTime = &now
+	}
+	spec.RenewTime = &now
+	spec.LeaseDurationSeconds = int(le.config.LeaseDuration / time.Second)
+	return spec
+}
+
+// release 清空 lease 的持有者，让其他候选者立刻就能获取
+func (le *LeaderElector) release() {
+	le.mu.Lock()
+	observed := le.observed
+	le.mu.Unlock()
+	if observed == nil || observed.Spec.HolderIdentity != le.config.Identity {
+		return
+	}
+	lease := observed.DeepCopy()
+	now := time.Now()
+	lease.Spec.HolderIdentity = ""
+	lease.Spec.AcquireTime = nil
+	lease.Spec.RenewTime = &now
+	lease.Spec.LeaseDurationSeconds = 1
+	updated, err := le.config.Client.UpdateLease(lease)
+	if err != nil {
+		log.Printf("Error releasing lease %s/%s: %v", le.config.Namespace, le.config.Name, err)
+		return
+	}
+	le.setObserved(updated, now)
+	log.Printf("Released lease %s/%s", le.config.Namespace, le.config.Name)
+}
+
+func (le *LeaderElector) setObserved(lease *api.Lease, now time.Time) {
+	le.mu.Lock()
+	le.observed = lease
+	le.observedTime = now
+	le.mu.Unlock()
+	le.reportLeader(lease.Spec.HolderIdentity)
+}
+
+// reportLeader 在观察到的 leader 变化时记日志并调用 OnNewLeader
+func (le *LeaderElector) reportLeader(identity string) {
+	le.mu.Lock()
+	changed := identity != le.reportedLeader
+	le.reportedLeader = identity
+	le.mu.Unlock()
+	if !changed || identity == "" {
+		return
+	}
+	log.Printf("New leader of lease %s/%s is %s", le.config.Namespace, le.config.Name, identity)
+	if le.config.Callbacks.OnNewLeader != nil {
+		le.config.Callbacks.OnNewLeader(identity)
+	}
+}
+
+func (le *LeaderElector) jitteredRetryPeriod() time.Duration {
+	return le.config.RetryPeriod + time.Duration(mathrand.Float64()*jitterFactor*float64(le.config.RetryPeriod))
+}
+
+// Options 是各个组件共用的 leader election 命令行参数
+type Options struct {
+	LeaderElect   bool
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+	Namespace     string
+	Name          string
+}
+
+// AddFlags 注册 -leader-elect 系列参数，defaultName 是组件默认使用的 lease 名字
+func (o *Options) AddFlags(fs *flag.FlagSet, defaultName string) {
+	fs.BoolVar(&o.LeaderElect, "leader-elect", true, "Acquire a lease before doing any work, so that only one replica is active at a time")
+	fs.DurationVar(&o.LeaseDuration, "leader-elect-lease-duration", 15*time.Second, "How long non-leaders wait after the last observed renewal before taking over the lease")
+	fs.DurationVar(&o.RenewDeadline, "leader-elect-renew-deadline", 10*time.Second, "How long the leader keeps retrying to renew the lease before stepping down")
+	fs.DurationVar(&o.RetryPeriod, "leader-elect-retry-period", 2*time.Second, "Interval between attempts to acquire or renew the lease")
+	fs.StringVar(&o.Namespace, "leader-elect-resource-namespace", api.NamespaceSystem, "Namespace of the lease used for leader election")
+	fs.StringVar(&o.Name, "leader-elect-resource-name", defaultName, "Name of the lease used for leader election")
+}
+
+// Config 用参数生成选举配置，ReleaseOnCancel 默认打开，正常退出时让其他副本马上接手
+func (o *Options) Config(client *api.Client, identity string, callbacks Callbacks) Config {
+	return Config{
+		Client:          client,
+		Namespace:       o.Namespace,
+		Name:            o.Name,
+		Identity:        identity,
+		LeaseDuration:   o.LeaseDuration,
+		RenewDeadline:   o.RenewDeadline,
+		RetryPeriod:     o.RetryPeriod,
+		ReleaseOnCancel: true,
+		Callbacks:       callbacks,
+	}
+}
+
+// DefaultIdentity 返回主机名加随机后缀，同一台机器上的多个副本也不会重复
+func DefaultIdentity() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "unknown"
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Sprintf("%s_%d", hostname, os.Getpid())
+	}
+	return hostname + "_" + hex.EncodeToString(suffix)
+}
@@ -0,0 +1,159 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"mini-k8s/pkg/api"
+	"sync"
+	"time"
+)
+
+// ErrNoEndpoints 表示 service 端口当前没有可以转发的后端
+var ErrNoEndpoints = errors.New("no ready endpoints")
+
+// ServicePortName 标识一个 service 的一个端口
+type ServicePortName struct {
+	Namespace string
+	Name      string
+	Port      string
+	Protocol  api.Protocol
+}
+
+func (n ServicePortName) String() string {
+	if n.Port == "" {
+		return fmt.Sprintf("%s/%s/%s", n.Namespace, n.Name, n.Protocol)
+	}
+	return fmt.Sprintf("%s/%s:%s/%s", n.Namespace, n.Name, n.Port, n.Protocol)
+}
+
+// affinity 记录一个客户端 IP 最近使用的后端
+type affinity struct {
+	endpoint string
+	lastUsed time.Time
+}
+
+// balancerState 是一个 service 端口的后端列表和轮询位置
+type balancerState struct {
+	endpoints []string
+	index     int
+	// affinityType 为 ClientIP 时，affinityTTL 内同一个客户端总是转发到 affinityMap 里记录的后端
+	affinityType api.ServiceAffinity
+	affinityTTL  time.Duration
+	affinityMap  map[string]*affinity
+}
+
+// LoadBalancer 为每个 service 端口轮询选择后端，支持按客户端 IP 的会话亲和性
+type LoadBalancer struct {
+	mu       sync.Mutex
+	services map[ServicePortName]*balancerState
+}
+
+func NewLoadBalancer() *LoadBalancer {
+	return &LoadBalancer{services: map[ServicePortName]*balancerState{}}
+}
+
+// SetAffinity 设置端口的会话亲和性，端口还不存在时创建它
+func (lb *LoadBalancer) SetAffinity(name ServicePortName, affinityType api.ServiceAffinity, ttl time.Duration) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	state := lb.stateFor(name)
+	if state.affinityType != affinityType || state.affinityTTL != ttl {
+		state.affinityMap = map[string]*affinity{}
+	}
+	state.affinityType = affinityType
+	state.affinityTTL = ttl
+}
+
+// NextEndpoint 为 clientIP 选择一个后端。resetAffinity 为 true 表示上次选中的后端连不上，不再使用亲和性记录
+func (lb *LoadBalancer) NextEndpoint(name ServicePortName, clientIP string, resetAffinity bool) (string, error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	state, ok := lb.services[name]
+	if !ok || len(state.endpoints) == 0 {
+		return "", ErrNoEndpoints
+	}
+	sticky := state.affinityType == api.ServiceAffinityClientIP && clientIP != ""
+	now := time.Now()
+	if sticky && !resetAffinity {
+		if a, ok := state.affinityMap[clientIP]; ok && now.Sub(a.lastUsed) < state.affinityTTL {
+			a.lastUsed = now
+			return a.endpoint, nil
+		}
+	}
+	endpoint := state.endpoints[state.index%len(state.endpoints)]
+	state.index = (state.index + 1) % len(state.endpoints)
+	if sticky {
+		state.affinityMap[clientIP] = &affinity{endpoint: endpoint, lastUsed: now}
+	}
+	return endpoint, nil
+}
+
+// SetEndpoints 替换端口的后端列表，返回被移除的后端。指向被移除后端的亲和性记录一起删除
+func (lb *LoadBalancer) SetEndpoints(name ServicePortName, endpoints []string) []string {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	state := lb.stateFor(name)
+	current := map[string]bool{}
+	for _, ep := range endpoints {
+		current[ep] = true
+	}
+	var removed []string
+	for _, ep := range state.endpoints {
+		if !current[ep] {
+			removed = append(removed, ep)
+		}
+	}
+	for clientIP, a := range state.affinityMap {
+		if !current[a.endpoint] {
+			delete(state.affinityMap, clientIP)
+		}
+	}
+	state.endpoints = endpoints
+	if len(endpoints) > 0 {
+		state.index %= len(endpoints)
+	} else {
+		state.index = 0
+	}
+	return removed
+}
+
+// Endpoints 返回端口当前的后端
+func (lb *LoadBalancer) Endpoints(name ServicePortName) []string {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	state, ok := lb.services[name]
+	if !ok {
+		return nil
+	}
+	return append([]string(nil), state.endpoints...)
+}
+
+// DeleteService 删除端口的所有状态
+func (lb *LoadBalancer) DeleteService(name ServicePortName) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	delete(lb.services, name)
+}
+
+// CleanupStaleAffinity 删除已经过期的亲和性记录
+func (lb *LoadBalancer) CleanupStaleAffinity() {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	now := time.Now()
+	for _, state := range lb.services {
+		for clientIP, a := range state.affinityMap {
+			if now.Sub(a.lastUsed) >= state.affinityTTL {
+				delete(state.affinityMap, clientIP)
+			}
+		}
+	}
+}
+
+func (lb *LoadBalancer) stateFor(name ServicePortName) *balancerState {
+	state, ok := lb.services[name]
+	if !ok {
+		state = &balancerState{affinityType: api.ServiceAffinityNone, affinityMap: map[string]*affinity{}}
+		lb.services[name] = state
+	}
+	return state
+}
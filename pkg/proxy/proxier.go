@@ -0,0 +1,279 @@
+// Package proxy implements a userspace service proxy. For every service port
+// it opens a local TCP or UDP listener and forwards connections to the ready
+// endpoints of the service, so that services can be reached without root
+// privileges or packet rewriting rules.
+package proxy
+
+import (
+	"fmt"
+	"log"
+	"mini-k8s/pkg/api"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	// BindAddress 是 ClusterIP 不能直接监听时 service 端口使用的地址，端口随机分配。
+	// ClusterIP 是 127.0.0.0/8 里的地址时直接监听 ClusterIP:port，不需要 root
+	BindAddress string
+	// NodePortAddress 是 nodePort 监听的地址，为空表示所有地址
+	NodePortAddress string
+	// UDPIdleTimeout 是 UDP 会话空闲多久后关闭
+	UDPIdleTimeout time.Duration
+}
+
+// serviceInfo 是一个 service 端口当前的监听状态
+type serviceInfo struct {
+	clusterIP    string
+	port         int
+	protocol     api.Protocol
+	nodePort     int
+	affinityType api.ServiceAffinity
+	affinityTTL  time.Duration
+
+	portal         proxySocket
+	nodePortSocket proxySocket
+}
+
+func (info *serviceInfo) sockets() []proxySocket {
+	var sockets []proxySocket
+	if info.portal != nil {
+		sockets = append(sockets, info.portal)
+	}
+	if info.nodePortSocket != nil {
+		sockets = append(sockets, info.nodePortSocket)
+	}
+	return sockets
+}
+
+// sameListeners 表示两个端口是否可以共用同样的监听 socket
+func (info *serviceInfo) sameListeners(other *serviceInfo) bool {
+	return info.clusterIP == other.clusterIP && info.port == other.port && info.protocol == other.protocol && info.nodePort == other.nodePort
+}
+
+// Proxier 根据 service 和 endpoints 打开、关闭监听 socket，并更新负载均衡的后端
+type Proxier struct {
+	config Config
+	lb     *LoadBalancer
+
+	mu       sync.Mutex
+	services map[ServicePortName]*serviceInfo
+}
+
+func NewProxier(config Config) *Proxier {
+	if config.UDPIdleTimeout <= 0 {
+		config.UDPIdleTimeout = 30 * time.Second
+	}
+	return &Proxier{config: config, lb: NewLoadBalancer(), services: map[ServicePortName]*serviceInfo{}}
+}
+
+// Sync 让监听的 socket 和后端与 services、endpoints 一致
+func (p *Proxier) Sync(services []api.Service, endpoints []api.Endpoints) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	desired := map[ServicePortName]*serviceInfo{}
+	for i := range services {
+		svc := &services[i]
+		if svc.IsHeadless() || svc.Spec.ClusterIP == "" || svc.DeletionTimestamp != nil {
+			continue
+		}
+		for _, port := range svc.Spec.Ports {
+			name := ServicePortName{Namespace: svc.Namespace, Name: svc.Name, Port: port.Name, Protocol: port.Protocol}
+			info := &serviceInfo{
+				clusterIP:    svc.Spec.ClusterIP,
+				port:         port.Port,
+				protocol:     port.Protocol,
+				affinityType: api.ServiceAffinityNone,
+			}
+			if svc.Spec.Type == api.ServiceTypeNodePort {
+				info.nodePort = port.NodePort
+			}
+			if svc.Spec.SessionAffinity == api.ServiceAffinityClientIP {
+				info.affinityType = api.ServiceAffinityClientIP
+				info.affinityTTL = time.Duration(api.DefaultClientIPServiceAffinitySeconds) * time.Second
+				if c := svc.Spec.SessionAffinityConfig; c != nil && c.ClientIP != nil && c.ClientIP.TimeoutSeconds != nil {
+					info.affinityTTL = time.Duration(*c.ClientIP.TimeoutSeconds) * time.Second
+				}
+			}
+			desired[name] = info
+		}
+	}
+
+	for name, info := range p.services {
+		if want, ok := desired[name]; !ok || !info.sameListeners(want) {
+			p.closeService(name, info)
+			delete(p.services, name)
+		}
+	}
+	for name, want := range desired {
+		info, ok := p.services[name]
+		if !ok {
+			if err := p.openService(name, want); err != nil {
+				log.Printf("Error opening proxy for %s: %v", name, err)
+				continue
+			}
+			p.services[name] = want
+			info = want
+		}
+		info.affinityType = want.affinityType
+		info.affinityTTL = want.affinityTTL
+		p.lb.SetAffinity(name, info.affinityType, info.affinityTTL)
+	}
+
+	backends := endpointsByPort(endpoints)
+	for name, info := range p.services {
+		removed := p.lb.SetEndpoints(name, backends[name])
+		for _, endpoint := range removed {
+			log.Printf("Endpoint %s of %s is gone, dropping its connections", endpoint, name)
+			for _, socket := range info.sockets() {
+				socket.DropEndpoint(endpoint)
+			}
+		}
+	}
+}
+
+// endpointsByPort 按 service 端口整理 Endpoints 里的就绪后端，端口按名字和协议对应
+func endpointsByPort(endpoints []api.Endpoints) map[ServicePortName][]string {
+	result := map[ServicePortName][]string{}
+	for _, ep := range endpoints {
+		for _, subset := range ep.Subsets {
+			for _, port := range subset.Ports {
+				protocol := port.Protocol
+				if protocol == "" {
+					protocol = api.ProtocolTCP
+				}
+				name := ServicePortName{Namespace: ep.Namespace, Name: ep.Name, Port: port.Name, Protocol: protocol}
+				for _, addr := range subset.Addresses {
+					result[name] = append(result[name], net.JoinHostPort(addr.IP, strconv.Itoa(port.Port)))
+				}
+			}
+		}
+	}
+	for name := range result {
+		sort.Strings(result[name])
+		result[name] = dedupe(result[name])
+	}
+	return result
+}
+
+// dedupe 去掉排好序的列表里重复的后端，多个 pod 共用节点网络时它们的地址相同
+func dedupe(sorted []string) []string {
+	out := sorted[:0]
+	for i, s := range sorted {
+		if i == 0 || s != sorted[i-1] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// openService 为端口打开监听 socket 并开始转发
+func (p *Proxier) openService(name ServicePortName, info *serviceInfo) error {
+	portal, err := p.openPortal(info)
+	if err != nil {
+		return err
+	}
+	info.portal = portal
+	go portal.ProxyLoop(name, p.lb)
+	log.Printf("Proxying %s (cluster IP %s:%d) on %s", name, info.clusterIP, info.port, portal.Addr())
+	if info.nodePort != 0 {
+		address := net.JoinHostPort(p.config.NodePortAddress, strconv.Itoa(info.nodePort))
+		socket, err := p.newSocket(info.protocol, address)
+		if err != nil {
+			portal.Close()
+			return fmt.Errorf("listening on node port %d: %w", info.nodePort, err)
+		}
+		info.nodePortSocket = socket
+		go socket.ProxyLoop(name, p.lb)
+		log.Printf("Proxying %s on node port %s", name, socket.Addr())
+	}
+	return nil
+}
+
+// openPortal 打开 service 端口的监听 socket：ClusterIP 是本机回环地址时直接监听它，否则在 BindAddress 上随机选一个端口
+func (p *Proxier) openPortal(info *serviceInfo) (proxySocket, error) {
+	if ip := net.ParseIP(info.clusterIP); ip != nil && ip.IsLoopback() {
+		socket, err := p.newSocket(info.protocol, net.JoinHostPort(info.clusterIP, strconv.Itoa(info.port)))
+		if err == nil {
+			return socket, nil
+		}
+		log.Printf("Cannot listen on cluster IP %s:%d, falling back to %s: %v", info.clusterIP, info.port, p.config.BindAddress, err)
+	}
+	return p.newSocket(info.protocol, net.JoinHostPort(p.config.BindAddress, "0"))
+}
+
+func (p *Proxier) newSocket(protocol api.Protocol, address string) (proxySocket, error) {
+	switch protocol {
+	case api.ProtocolTCP:
+		return newTCPProxySocket(address)
+	case api.ProtocolUDP:
+		return newUDPProxySocket(address, p.config.UDPIdleTimeout)
+	default:
+		return nil, fmt.Errorf("unknown protocol %q", protocol)
+	}
+}
+
+func (p *Proxier) closeService(name ServicePortName, info *serviceInfo) {
+	for _, socket := range info.sockets() {
+		if err := socket.Close(); err != nil {
+			log.Printf("Error closing proxy socket %s for %s: %v", socket.Addr(), name, err)
+		}
+	}
+	p.lb.DeleteService(name)
+	log.Printf("Stopped proxying %s", name)
+}
+
+// Close 关闭所有监听 socket
+func (p *Proxier) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for name, info := range p.services {
+		p.closeService(name, info)
+		delete(p.services, name)
+	}
+}
+
+// CleanupStaleAffinity 删除过期的会话亲和性记录，应该定期调用
+func (p *Proxier) CleanupStaleAffinity() {
+	p.lb.CleanupStaleAffinity()
+}
+
+// ServiceStatus 描述一个 service 端口在本机的监听地址和后端
+type ServiceStatus struct {
+	Service   string   `json:"service"`
+	ClusterIP string   `json:"clusterIP"`
+	Port      int      `json:"port"`
+	Protocol  string   `json:"protocol"`
+	Listen    string   `json:"listen"`
+	NodePort  string   `json:"nodePort,omitempty"`
+	Endpoints []string `json:"endpoints"`
+}
+
+// Status 返回所有 service 端口的监听地址，ClusterIP 不能直接监听时客户端从这里找到随机分配的端口
+func (p *Proxier) Status() []ServiceStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var result []ServiceStatus
+	for name, info := range p.services {
+		status := ServiceStatus{
+			Service:   name.String(),
+			ClusterIP: info.clusterIP,
+			Port:      info.port,
+			Protocol:  string(info.protocol),
+			Listen:    info.portal.Addr().String(),
+			Endpoints: p.lb.Endpoints(name),
+		}
+		if info.nodePortSocket != nil {
+			status.NodePort = info.nodePortSocket.Addr().String()
+		}
+		if status.Endpoints == nil {
+			status.Endpoints = []string{}
+		}
+		result = append(result, status)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Service < result[j].Service })
+	return result
+}
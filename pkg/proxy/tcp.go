@@ -0,0 +1,174 @@
+package proxy
+
+import (
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// dialTimeout 是连接后端的超时时间，超时后换下一个后端
+	dialTimeout = 2 * time.Second
+	// maxDialAttempts 是一个连接最多尝试的后端数量
+	maxDialAttempts = 4
+)
+
+// proxySocket 是一个 service 端口的监听者，把收到的流量转发给负载均衡选出的后端
+type proxySocket interface {
+	Addr() net.Addr
+	// ProxyLoop 接收流量并转发，直到 Close 被调用
+	ProxyLoop(name ServicePortName, lb *LoadBalancer)
+	// DropEndpoint 断开所有转发到 endpoint 的连接
+	DropEndpoint(endpoint string)
+	Close() error
+}
+
+// tcpProxySocket 为每个客户端连接打开一个到后端的连接，在两者之间复制数据
+type tcpProxySocket struct {
+	listener net.Listener
+
+	mu sync.Mutex
+	// conns 按后端记录正在转发的连接，后端消失时把它们断开
+	conns  map[string]map[*tcpConnPair]struct{}
+	closed bool
+}
+
+type tcpConnPair struct {
+	client, backend net.Conn
+	once            sync.Once
+}
+
+func (p *tcpConnPair) close() {
+	p.once.Do(func() {
+		p.client.Close()
+		p.backend.Close()
+	})
+}
+
+func newTCPProxySocket(address string) (*tcpProxySocket, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	return &tcpProxySocket{listener: listener, conns: map[string]map[*tcpConnPair]struct{}{}}, nil
+}
+
+func (s *tcpProxySocket) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+func (s *tcpProxySocket) ProxyLoop(name ServicePortName, lb *LoadBalancer) {
+	for {
+		client, err := s.listener.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return
+			}
+			log.Printf("Error accepting connection for %s: %v", name, err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		go s.handle(name, lb, client)
+	}
+}
+
+func (s *tcpProxySocket) handle(name ServicePortName, lb *LoadBalancer, client net.Conn) {
+	clientIP, _, _ := net.SplitHostPort(client.RemoteAddr().String())
+	var backend net.Conn
+	var endpoint string
+	for attempt := 0; attempt < maxDialAttempts; attempt++ {
+		var err error
+		endpoint, err = lb.NextEndpoint(name, clientIP, attempt > 0)
+		if err != nil {
+			log.Printf("Dropping connection to %s from %s: %v", name, client.RemoteAddr(), err)
+			client.Close()
+			return
+		}
+		backend, err = net.DialTimeout("tcp", endpoint, dialTimeout)
+		if err == nil {
+			break
+		}
+		log.Printf("Error connecting to endpoint %s of %s: %v", endpoint, name, err)
+	}
+	if backend == nil {
+		log.Printf("Dropping connection to %s from %s: no endpoint accepted the connection", name, client.RemoteAddr())
+		client.Close()
+		return
+	}
+	pair := &tcpConnPair{client: client, backend: backend}
+	if !s.track(endpoint, pair) {
+		pair.close()
+		return
+	}
+	defer s.untrack(endpoint, pair)
+	done := make(chan struct{}, 2)
+	copyAndClose := func(dst, src net.Conn) {
+		io.Copy(dst, src)
+		//一个方向结束后只关闭写端，让另一个方向的数据还能传完
+		if tcp, ok := dst.(*net.TCPConn); ok {
+			tcp.CloseWrite()
+		}
+		done <- struct{}{}
+	}
+	go copyAndClose(backend, client)
+	go copyAndClose(client, backend)
+	<-done
+	<-done
+	pair.close()
+}
+
+func (s *tcpProxySocket) track(endpoint string, pair *tcpConnPair) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	if s.conns[endpoint] == nil {
+		s.conns[endpoint] = map[*tcpConnPair]struct{}{}
+	}
+	s.conns[endpoint][pair] = struct{}{}
+	return true
+}
+
+func (s *tcpProxySocket) untrack(endpoint string, pair *tcpConnPair) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.conns[endpoint], pair)
+	if len(s.conns[endpoint]) == 0 {
+		delete(s.conns, endpoint)
+	}
+}
+
+func (s *tcpProxySocket) DropEndpoint(endpoint string) {
+	s.mu.Lock()
+	pairs := s.conns[endpoint]
+	delete(s.conns, endpoint)
+	s.mu.Unlock()
+	for pair := range pairs {
+		pair.close()
+	}
+}
+
+func (s *tcpProxySocket) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// Close 停止监听并断开所有正在转发的连接
+func (s *tcpProxySocket) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	conns := s.conns
+	s.conns = map[string]map[*tcpConnPair]struct{}{}
+	s.mu.Unlock()
+	err := s.listener.Close()
+	for _, pairs := range conns {
+		for pair := range pairs {
+			pair.close()
+		}
+	}
+	return err
+}
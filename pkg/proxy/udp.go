@@ -0,0 +1,152 @@
+package proxy
+
+import (
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// udpBufferSize 能装下最大的 UDP 数据报
+const udpBufferSize = 65535
+
+// udpProxySocket 为每个客户端地址维护一个到后端的会话：客户端发来的数据报转发给后端，
+// 后端的回复从监听的 socket 发回客户端。会话空闲超过 timeout 后关闭
+type udpProxySocket struct {
+	conn    *net.UDPConn
+	timeout time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*udpSession
+	closed   bool
+}
+
+type udpSession struct {
+	endpoint string
+	backend  *net.UDPConn
+}
+
+func newUDPProxySocket(address string, timeout time.Duration) (*udpProxySocket, error) {
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &udpProxySocket{conn: conn, timeout: timeout, sessions: map[string]*udpSession{}}, nil
+}
+
+func (s *udpProxySocket) Addr() net.Addr {
+	return s.conn.LocalAddr()
+}
+
+func (s *udpProxySocket) ProxyLoop(name ServicePortName, lb *LoadBalancer) {
+	buf := make([]byte, udpBufferSize)
+	for {
+		n, client, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			if s.isClosed() {
+				return
+			}
+			log.Printf("Error reading datagram for %s: %v", name, err)
+			continue
+		}
+		session, err := s.sessionFor(name, lb, client)
+		if err != nil {
+			log.Printf("Dropping datagram to %s from %s: %v", name, client, err)
+			continue
+		}
+		session.backend.SetReadDeadline(time.Now().Add(s.timeout))
+		if _, err := session.backend.Write(buf[:n]); err != nil {
+			log.Printf("Error forwarding datagram to endpoint %s of %s: %v", session.endpoint, name, err)
+		}
+	}
+}
+
+// sessionFor 返回客户端的会话，没有时选择一个后端新建一个
+func (s *udpProxySocket) sessionFor(name ServicePortName, lb *LoadBalancer, client *net.UDPAddr) (*udpSession, error) {
+	key := client.String()
+	s.mu.Lock()
+	session, ok := s.sessions[key]
+	s.mu.Unlock()
+	if ok {
+		return session, nil
+	}
+	endpoint, err := lb.NextEndpoint(name, client.IP.String(), false)
+	if err != nil {
+		return nil, err
+	}
+	addr, err := net.ResolveUDPAddr("udp", endpoint)
+	if err != nil {
+		return nil, err
+	}
+	backend, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		return nil, err
+	}
+	session = &udpSession{endpoint: endpoint, backend: backend}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		backend.Close()
+		return nil, net.ErrClosed
+	}
+	s.sessions[key] = session
+	s.mu.Unlock()
+	go s.replyLoop(key, client, session)
+	return session, nil
+}
+
+// replyLoop 把后端的回复发回客户端，会话超时或者被关闭时退出
+func (s *udpProxySocket) replyLoop(key string, client *net.UDPAddr, session *udpSession) {
+	defer func() {
+		s.mu.Lock()
+		if s.sessions[key] == session {
+			delete(s.sessions, key)
+		}
+		s.mu.Unlock()
+		session.backend.Close()
+	}()
+	buf := make([]byte, udpBufferSize)
+	for {
+		session.backend.SetReadDeadline(time.Now().Add(s.timeout))
+		n, err := session.backend.Read(buf)
+		if err != nil {
+			return
+		}
+		if _, err := s.conn.WriteToUDP(buf[:n], client); err != nil {
+			return
+		}
+	}
+}
+
+func (s *udpProxySocket) DropEndpoint(endpoint string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, session := range s.sessions {
+		if session.endpoint == endpoint {
+			delete(s.sessions, key)
+			session.backend.Close()
+		}
+	}
+}
+
+func (s *udpProxySocket) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// Close 停止监听并关闭所有会话
+func (s *udpProxySocket) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for key, session := range s.sessions {
+		delete(s.sessions, key)
+		session.backend.Close()
+	}
+	s.mu.Unlock()
+	return s.conn.Close()
+}
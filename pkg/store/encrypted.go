@@ -0,0 +1,251 @@
+package store
+
+import (
+	"fmt"
+	"log"
+	"mini-k8s/pkg/api"
+	"mini-k8s/pkg/encryption"
+	"sync"
+)
+
+// EncryptableResources 是 EncryptedStore 可以加密的资源
+var EncryptableResources = []string{ResourceSecrets, ResourceConfigMaps}
+
+// EncryptedStore 包装另一个 Store：写入 Secret 和 ConfigMap 之前按配置加密每个值，读出和 watch 时解密。
+// 键名、metadata 不加密。被包装的 Store 里只有密文，以后换成写磁盘的 Store 也不会落下明文
+type EncryptedStore struct {
+	Store
+	transformer *encryption.Transformer
+}
+
+// NewEncryptedStore 检查配置里的资源都是可以加密的
+func NewEncryptedStore(inner Store, transformer *encryption.Transformer, config *encryption.Config) (*EncryptedStore, error) {
+	if err := CheckEncryptionConfig(config); err != nil {
+		return nil, err
+	}
+	return &EncryptedStore{Store: inner, transformer: transformer}, nil
+}
+
+// CheckEncryptionConfig 检查配置里没有 EncryptedStore 不支持的资源，重新加载配置之前也要调用
+func CheckEncryptionConfig(config *encryption.Config) error {
+	for _, resource := range config.Resources {
+		supported := false
+		for _, r := range EncryptableResources {
+			supported = supported || r == resource
+		}
+		if !supported {
+			return fmt.Errorf("encryption of %s is not supported, only %v", resource, EncryptableResources)
+		}
+	}
+	return nil
+}
+
+// additionalData 把密文和它所在的对象、键绑定在一起
+func additionalData(resource string, meta *api.ObjectMeta, key string) []byte {
+	return []byte(resource + "/" + meta.Namespace + "/" + meta.Name + "/" + key)
+}
+
+func (es *EncryptedStore) encryptSecret(secret *api.Secret) (*api.Secret, error) {
+	if !es.transformer.Encrypts(ResourceSecrets) {
+		return secret, nil
+	}
+	out := secret.DeepCopy()
+	for k, v := range secret.Data {
+		enc, err := es.transformer.Encrypt(v, additionalData(ResourceSecrets, &secret.ObjectMeta, k))
+		if err != nil {
+			return nil, fmt.Errorf("encrypting secret %s/%s: %w", secret.Namespace, secret.Name, err)
+		}
+		out.Data[k] = []byte(enc)
+	}
+	return out, nil
+}
+
+func (es *EncryptedStore) decryptSecret(secret *api.Secret) error {
+	for k, v := range secret.Data {
+		plain, err := es.transformer.Decrypt(string(v), additionalData(ResourceSecrets, &secret.ObjectMeta, k))
+		if err != nil {
+			return fmt.Errorf("failed to decrypt secret %s/%s: %w", secret.Namespace, secret.Name, err)
+		}
+		secret.Data[k] = plain
+	}
+	return nil
+}
+
+func (es *EncryptedStore) encryptConfigMap(cm *api.ConfigMap) (*api.ConfigMap, error) {
+	if !es.transformer.Encrypts(ResourceConfigMaps) {
+		return cm, nil
+	}
+	out := cm.DeepCopy()
+	for k, v := range cm.Data {
+		enc, err := es.transformer.Encrypt([]byte(v), additionalData(ResourceConfigMaps, &cm.ObjectMeta, k))
+		if err != nil {
+			return nil, fmt.Errorf("encrypting configmap %s/%s: %w", cm.Namespace, cm.Name, err)
+		}
+		out.Data[k] = enc
+	}
+	for k, v := range cm.BinaryData {
+		enc, err := es.transformer.Encrypt(v, additionalData(ResourceConfigMaps, &cm.ObjectMeta, k))
+		if err != nil {
+			return nil, fmt.Errorf("encrypting configmap %s/%s: %w", cm.Namespace, cm.Name, err)
+		}
+		out.BinaryData[k] = []byte(enc)
+	}
+	return out, nil
+}
+
+func (es *EncryptedStore) decryptConfigMap(cm *api.ConfigMap) error {
+	for k, v := range cm.Data {
+		plain, err := es.transformer.Decrypt(v, additionalData(ResourceConfigMaps, &cm.ObjectMeta, k))
+		if err != nil {
+			return fmt.Errorf("failed to decrypt configmap %s/%s: %w", cm.Namespace, cm.Name, err)
+		}
+		cm.Data[k] = string(plain)
+	}
+	for k, v := range cm.BinaryData {
+		plain, err := es.transformer.Decrypt(string(v), additionalData(ResourceConfigMaps, &cm.ObjectMeta, k))
+		if err != nil {
+			return fmt.Errorf("failed to decrypt configmap %s/%s: %w", cm.Namespace, cm.Name, err)
+		}
+		cm.BinaryData[k] = plain
+	}
+	return nil
+}
+
+func (es *EncryptedStore) CreateSecret(secret *api.Secret) error {
+	enc, err := es.encryptSecret(secret)
+	if err != nil {
+		return err
+	}
+	if err := es.Store.CreateSecret(enc); err != nil {
+		return err
+	}
+	secret.ResourceVersion = enc.ResourceVersion
+	return nil
+}
+
+func (es *EncryptedStore) GetSecret(namespace, name string) (*api.Secret, error) {
+	secret, err := es.Store.GetSecret(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := es.decryptSecret(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+func (es *EncryptedStore) UpdateSecret(secret *api.Secret) error {
+	enc, err := es.encryptSecret(secret)
+	if err != nil {
+		return err
+	}
+	if err := es.Store.UpdateSecret(enc); err != nil {
+		return err
+	}
+	secret.ResourceVersion = enc.ResourceVersion
+	return nil
+}
+
+func (es *EncryptedStore) ListSecrets(namespace string) ([]*api.Secret, error) {
+	secrets, err := es.Store.ListSecrets(namespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, secret := range secrets {
+		if err := es.decryptSecret(secret); err != nil {
+			return nil, err
+		}
+	}
+	return secrets, nil
+}
+
+func (es *EncryptedStore) WatchSecrets(namespace string) (<-chan api.WatchEvent[api.Secret], func()) {
+	events, stop := es.Store.WatchSecrets(namespace)
+	return decryptEvents(events, stop, func(secret *api.Secret) error {
+		*secret = *secret.DeepCopy()
+		return es.decryptSecret(secret)
+	})
+}
+
+func (es *EncryptedStore) CreateConfigMap(cm *api.ConfigMap) error {
+	enc, err := es.encryptConfigMap(cm)
+	if err != nil {
+		return err
+	}
+	if err := es.Store.CreateConfigMap(enc); err != nil {
+		return err
+	}
+	cm.ResourceVersion = enc.ResourceVersion
+	return nil
+}
+
+func (es *EncryptedStore) GetConfigMap(namespace, name string) (*api.ConfigMap, error) {
+	cm, err := es.Store.GetConfigMap(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := es.decryptConfigMap(cm); err != nil {
+		return nil, err
+	}
+	return cm, nil
+}
+
+func (es *EncryptedStore) UpdateConfigMap(cm *api.ConfigMap) error {
+	enc, err := es.encryptConfigMap(cm)
+	if err != nil {
+		return err
+	}
+	if err := es.Store.UpdateConfigMap(enc); err != nil {
+		return err
+	}
+	cm.ResourceVersion = enc.ResourceVersion
+	return nil
+}
+
+func (es *EncryptedStore) ListConfigMaps(namespace string) ([]*api.ConfigMap, error) {
+	cms, err := es.Store.ListConfigMaps(namespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, cm := range cms {
+		if err := es.decryptConfigMap(cm); err != nil {
+			return nil, err
+		}
+	}
+	return cms, nil
+}
+
+func (es *EncryptedStore) WatchConfigMaps(namespace string) (<-chan api.WatchEvent[api.ConfigMap], func()) {
+	events, stop := es.Store.WatchConfigMaps(namespace)
+	return decryptEvents(events, stop, func(cm *api.ConfigMap) error {
+		*cm = *cm.DeepCopy()
+		return es.decryptConfigMap(cm)
+	})
+}
+
+// decryptEvents 解密 watch 事件里的对象。同一个事件会发给所有订阅者，decrypt 要先拷贝再解密。
+// 解密失败时结束 watch，客户端重新 list 时会看到错误
+func decryptEvents[T any](in <-chan api.WatchEvent[T], stop func(), decrypt func(*T) error) (<-chan api.WatchEvent[T], func()) {
+	out := make(chan api.WatchEvent[T], watchBufferSize)
+	done := make(chan struct{})
+	go func() {
+		defer close(out)
+		for event := range in {
+			if err := decrypt(&event.Object); err != nil {
+				log.Printf("Closing watch: %v", err)
+				stop()
+				return
+			}
+			select {
+			case out <- event:
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return out, func() {
+		once.Do(func() { close(done) })
+		stop()
+	}
+}
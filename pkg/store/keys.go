@@ -0,0 +1,79 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Key 是对象在存储里的位置。命名空间级别的资源是 /<resource>/<namespace>/<name>，
+// 集群级别的资源是 /<resource>/<name>。每一段都不能包含 "/"，所以不同的 namespace/name 组合不会得到相同的 key
+type Key string
+
+const (
+	ResourcePods       = "pods"
+	ResourceNodes      = "nodes"
+	ResourceNamespaces = "namespaces"
+
+	ResourceReplicaSets              = "replicasets"
+	ResourceDeployments              = "deployments"
+	ResourceJobs                     = "jobs"
+	ResourceCronJobs                 = "cronjobs"
+	ResourceDaemonSets               = "daemonsets"
+	ResourceStatefulSets             = "statefulsets"
+	ResourceControllerRevisions      = "controllerrevisions"
+	ResourceLeases                   = "leases"
+	ResourceServices                 = "services"
+	ResourceEndpoints                = "endpoints"
+	ResourceIngresses                = "ingresses"
+	ResourceSecrets                  = "secrets"
+	ResourceConfigMaps               = "configmaps"
+	ResourcePersistentVolumes        = "persistentvolumes"
+	ResourcePersistentVolumeClaims   = "persistentvolumeclaims"
+	ResourceStorageClasses           = "storageclasses"
+	ResourceHorizontalPodAutoscalers = "horizontalpodautoscalers"
+	ResourcePodDisruptionBudgets     = "poddisruptionbudgets"
+	ResourceRangeAllocations         = "rangeallocations"
+)
+
+func NamespacedKey(resource, namespace, name string) Key {
+	return Key("/" + resource + "/" + namespace + "/" + name)
+}
+
+func ClusterKey(resource, name string) Key {
+	return Key("/" + resource + "/" + name)
+}
+
+// NamespacedPrefix 返回某个命名空间下所有对象共同的前缀，namespace 为空时返回该资源所有对象的前缀
+func NamespacedPrefix(resource, namespace string) Key {
+	if namespace == "" {
+		return Key("/" + resource + "/")
+	}
+	return Key("/" + resource + "/" + namespace + "/")
+}
+
+func (k Key) HasPrefix(prefix Key) bool {
+	return strings.HasPrefix(string(k), string(prefix))
+}
+
+func PodKey(namespace, name string) Key {
+	return NamespacedKey(ResourcePods, namespace, name)
+}
+
+func NodeKey(name string) Key {
+	return ClusterKey(ResourceNodes, name)
+}
+
+func NamespaceKey(name string) Key {
+	return ClusterKey(ResourceNamespaces, name)
+}
+
+// validateKeySegment 保证 name 和 namespace 可以安全地拼进 key
+func validateKeySegment(kind, segment string) error {
+	if segment == "" {
+		return fmt.Errorf("%s must not be empty", kind)
+	}
+	if strings.Contains(segment, "/") {
+		return fmt.Errorf("%s %q must not contain '/'", kind, segment)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"mini-k8s/pkg/api"
+	"testing"
+)
+
+func TestNamespacedKeysDoNotCollide(t *testing.T) {
+	tests := []struct {
+		name                 string
+		namespace1, name1    string
+		namespace2, name2    string
+		resource1, resource2 string
+	}{
+		{name: "namespace absorbs the start of the name", namespace1: "ab", name1: "c", namespace2: "a", name2: "bc", resource1: ResourcePods, resource2: ResourcePods},
+		{name: "name absorbs the end of the namespace", namespace1: "a", name1: "b-c", namespace2: "a-b", name2: "c", resource1: ResourcePods, resource2: ResourcePods},
+		{name: "same namespace and name in different resources", namespace1: "a", name1: "b", namespace2: "a", name2: "b", resource1: ResourcePods, resource2: ResourceServices},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k1 := NamespacedKey(tt.resource1, tt.namespace1, tt.name1)
+			k2 := NamespacedKey(tt.resource2, tt.namespace2, tt.name2)
+			if k1 == k2 {
+				t.Errorf("%s/%s and %s/%s both map to key %q", tt.namespace1, tt.name1, tt.namespace2, tt.name2, k1)
+			}
+		})
+	}
+}
+
+func TestNamespacedPrefix(t *testing.T) {
+	tests := []struct {
+		key       Key
+		namespace string
+		want      bool
+	}{
+		{key: NamespacedKey(ResourcePods, "a", "bc"), namespace: "a", want: true},
+		{key: NamespacedKey(ResourcePods, "ab", "c"), namespace: "a", want: false},
+		{key: NamespacedKey(ResourcePods, "ab", "c"), namespace: "ab", want: true},
+		{key: NamespacedKey(ResourcePods, "ab", "c"), namespace: "", want: true},
+		{key: NamespacedKey(ResourceServices, "a", "bc"), namespace: "a", want: false},
+	}
+	for _, tt := range tests {
+		if got := tt.key.HasPrefix(NamespacedPrefix(ResourcePods, tt.namespace)); got != tt.want {
+			t.Errorf("%q.HasPrefix(pods prefix for %q) = %v, want %v", tt.key, tt.namespace, got, tt.want)
+		}
+	}
+}
+
+func TestValidateKeySegment(t *testing.T) {
+	tests := []struct {
+		segment string
+		wantErr bool
+	}{
+		{segment: "default", wantErr: false},
+		{segment: "web-1.example", wantErr: false},
+		{segment: "", wantErr: true},
+		{segment: "a/b", wantErr: true},
+		{segment: "/", wantErr: true},
+	}
+	for _, tt := range tests {
+		err := validateKeySegment("name", tt.segment)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateKeySegment(%q) error = %v, wantErr %v", tt.segment, err, tt.wantErr)
+		}
+	}
+}
+
+// TestListDoesNotCrossNamespaces 在存储层检查同样的场景：按命名空间 "a" 列出时不会带上 "ab" 里的对象
+func TestListDoesNotCrossNamespaces(t *testing.T) {
+	ms := NewInMemoryStore()
+	for _, pod := range []*api.Pod{
+		{ObjectMeta: api.ObjectMeta{Namespace: "a", Name: "bc"}},
+		{ObjectMeta: api.ObjectMeta{Namespace: "ab", Name: "c"}},
+	} {
+		if err := ms.CreatePod(pod); err != nil {
+			t.Fatalf("CreatePod(%s/%s): %v", pod.Namespace, pod.Name, err)
+		}
+	}
+	for _, svc := range []*api.Service{
+		{ObjectMeta: api.ObjectMeta{Namespace: "a", Name: "bc"}},
+		{ObjectMeta: api.ObjectMeta{Namespace: "ab", Name: "c"}},
+	} {
+		if err := ms.CreateService(svc); err != nil {
+			t.Fatalf("CreateService(%s/%s): %v", svc.Namespace, svc.Name, err)
+		}
+	}
+
+	pods, err := ms.ListPods("a")
+	if err != nil {
+		t.Fatalf("ListPods: %v", err)
+	}
+	if len(pods) != 1 || pods[0].Namespace != "a" || pods[0].Name != "bc" {
+		t.Errorf("ListPods(\"a\") = %v, want only a/bc", podNames(pods))
+	}
+	services, err := ms.ListServices("a")
+	if err != nil {
+		t.Fatalf("ListServices: %v", err)
+	}
+	if len(services) != 1 || services[0].Namespace != "a" {
+		t.Errorf("ListServices(\"a\") returned %d services, want only a/bc", len(services))
+	}
+	all, _ := ms.ListPods("")
+	if len(all) != 2 {
+		t.Errorf("ListPods(\"\") returned %d pods, want 2", len(all))
+	}
+}
+
+func TestCreateRejectsInvalidSegments(t *testing.T) {
+	ms := NewInMemoryStore()
+	tests := []struct{ namespace, name string }{
+		{"", "web"},
+		{"default", ""},
+		{"a/b", "c"},
+		{"a", "b/c"},
+	}
+	for _, tt := range tests {
+		if err := ms.CreatePod(&api.Pod{ObjectMeta: api.ObjectMeta{Namespace: tt.namespace, Name: tt.name}}); err == nil {
+			t.Errorf("CreatePod(%q/%q) succeeded, want an error", tt.namespace, tt.name)
+		}
+		if err := ms.CreateService(&api.Service{ObjectMeta: api.ObjectMeta{Namespace: tt.namespace, Name: tt.name}}); err == nil {
+			t.Errorf("CreateService(%q/%q) succeeded, want an error", tt.namespace, tt.name)
+		}
+	}
+}
+
+func podNames(pods []*api.Pod) []string {
+	var names []string
+	for _, pod := range pods {
+		names = append(names, pod.Namespace+"/"+pod.Name)
+	}
+	return names
+}
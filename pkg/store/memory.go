@@ -8,47 +8,110 @@ import (
 )
 
 type InMemoryStore struct {
-	mu    sync.RWMutex
-	pods  map[string]*api.Pod
-	nodes map[string]*api.Node
+	mu         sync.RWMutex
+	versions   *versioner
+	pods       map[Key]*api.Pod
+	nodes      map[Key]*api.Node
+	namespaces map[Key]*api.Namespace
+	podEvents  *broadcaster[api.Pod]
+	nodeEvents *broadcaster[api.Node]
+	nsEvents   *broadcaster[api.Namespace]
+
+	replicaSets              *table[api.ReplicaSet, *api.ReplicaSet]
+	deployments              *table[api.Deployment, *api.Deployment]
+	jobs                     *table[api.Job, *api.Job]
+	cronJobs                 *table[api.CronJob, *api.CronJob]
+	daemonSets               *table[api.DaemonSet, *api.DaemonSet]
+	statefulSets             *table[api.StatefulSet, *api.StatefulSet]
+	controllerRevisions      *table[api.ControllerRevision, *api.ControllerRevision]
+	leases                   *table[api.Lease, *api.Lease]
+	services                 *table[api.Service, *api.Service]
+	endpoints                *table[api.Endpoints, *api.Endpoints]
+	ingresses                *table[api.Ingress, *api.Ingress]
+	secrets                  *table[api.Secret, *api.Secret]
+	configMaps               *table[api.ConfigMap, *api.ConfigMap]
+	persistentVolumes        *table[api.PersistentVolume, *api.PersistentVolume]
+	persistentVolumeClaims   *table[api.PersistentVolumeClaim, *api.PersistentVolumeClaim]
+	storageClasses           *table[api.StorageClass, *api.StorageClass]
+	horizontalPodAutoscalers *table[api.HorizontalPodAutoscaler, *api.HorizontalPodAutoscaler]
+	podDisruptionBudgets     *table[api.PodDisruptionBudget, *api.PodDisruptionBudget]
+	rangeAllocations         *table[api.RangeAllocation, *api.RangeAllocation]
 }
 
 func NewInMemoryStore() *InMemoryStore {
+	versions := &versioner{}
 	return &InMemoryStore{
-		pods:  make(map[string]*api.Pod),
-		nodes: make(map[string]*api.Node),
+		versions:   versions,
+		pods:       make(map[Key]*api.Pod),
+		nodes:      make(map[Key]*api.Node),
+		namespaces: make(map[Key]*api.Namespace),
+		podEvents:  newBroadcaster[api.Pod](),
+		nodeEvents: newBroadcaster[api.Node](),
+		nsEvents:   newBroadcaster[api.Namespace](),
+
+		replicaSets:              newTable[api.ReplicaSet]("replicaset", ResourceReplicaSets, true, versions),
+		deployments:              newTable[api.Deployment]("deployment", ResourceDeployments, true, versions),
+		jobs:                     newTable[api.Job]("job", ResourceJobs, true, versions),
+		cronJobs:                 newTable[api.CronJob]("cronjob", ResourceCronJobs, true, versions),
+		daemonSets:               newTable[api.DaemonSet]("daemonset", ResourceDaemonSets, true, versions),
+		statefulSets:             newTable[api.StatefulSet]("statefulset", ResourceStatefulSets, true, versions),
+		controllerRevisions:      newTable[api.ControllerRevision]("controllerrevision", ResourceControllerRevisions, true, versions),
+		leases:                   newTable[api.Lease]("lease", ResourceLeases, true, versions),
+		services:                 newTable[api.Service]("service", ResourceServices, true, versions),
+		endpoints:                newTable[api.Endpoints]("endpoints", ResourceEndpoints, true, versions),
+		ingresses:                newTable[api.Ingress]("ingress", ResourceIngresses, true, versions),
+		secrets:                  newTable[api.Secret]("secret", ResourceSecrets, true, versions),
+		configMaps:               newTable[api.ConfigMap]("configmap", ResourceConfigMaps, true, versions),
+		persistentVolumes:        newTable[api.PersistentVolume]("persistentvolume", ResourcePersistentVolumes, false, versions),
+		persistentVolumeClaims:   newTable[api.PersistentVolumeClaim]("persistentvolumeclaim", ResourcePersistentVolumeClaims, true, versions),
+		storageClasses:           newTable[api.StorageClass]("storageclass", ResourceStorageClasses, false, versions),
+		horizontalPodAutoscalers: newTable[api.HorizontalPodAutoscaler]("horizontalpodautoscaler", ResourceHorizontalPodAutoscalers, true, versions),
+		podDisruptionBudgets:     newTable[api.PodDisruptionBudget]("poddisruptionbudget", ResourcePodDisruptionBudgets, true, versions),
+		rangeAllocations:         newTable[api.RangeAllocation]("rangeallocation", ResourceRangeAllocations, false, versions),
 	}
 }
 func (ms *InMemoryStore) CreatePod(pod *api.Pod) error {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
+	if err := validateKeySegment("namespace", pod.Namespace); err != nil {
+		return err
+	}
+	if err := validateKeySegment("pod name", pod.Name); err != nil {
+		return err
+	}
 	//检查是否存在，存在则返回错误，不存在则新加
-	key := fmt.Sprintf("%s%s", pod.Namespace, pod.Name)
+	key := PodKey(pod.Namespace, pod.Name)
 	if _, ok := ms.pods[key]; ok {
 		return fmt.Errorf("pod %s already exists", key)
 	} else {
-		ms.pods[key] = pod
+		pod.ResourceVersion = ms.versions.next()
+		ms.pods[key] = pod.DeepCopy()
 	}
+	ms.podEvents.publish(api.EventAdded, *pod.DeepCopy())
 	return nil
 }
 func (ms *InMemoryStore) GetPod(namespace, name string) (*api.Pod, error) {
 	ms.mu.RLock()
 	defer ms.mu.RUnlock()
-	key := fmt.Sprintf("%s%s", namespace, name)
+	key := PodKey(namespace, name)
 	pod, ok := ms.pods[key]
 	if !ok {
 		return nil, fmt.Errorf("pod %s not found", key)
 	}
-	return pod, nil
+	return pod.DeepCopy(), nil
 }
 func (ms *InMemoryStore) UpdatePod(pod *api.Pod) error {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
-	key := fmt.Sprintf("%s%s", pod.Namespace, pod.Name)
+	key := PodKey(pod.Namespace, pod.Name)
 	existingpod, ok := ms.pods[key]
 	if !ok {
 		return fmt.Errorf("pod %s not found", key)
 	}
+	if err := checkResourceVersion("pod "+pod.Namespace+"/"+pod.Name, &existingpod.ObjectMeta, &pod.ObjectMeta); err != nil {
+		return err
+	}
+	//resourceVersion 在所有检查都通过之后才分配，被拒绝的更新不会改动调用方的对象
 	//如果这个pod是正在被删除的过程中，那pod是新传入的状态，假如新传入的状态又显示没删除或者删除的时间戳不一致，那就是错误的更新，应该返回错误
 	if existingpod.DeletionTimestamp != nil {
 		if pod.DeletionTimestamp == nil || !pod.DeletionTimestamp.Equal(*existingpod.DeletionTimestamp) {
@@ -59,7 +122,16 @@ func (ms *InMemoryStore) UpdatePod(pod *api.Pod) error {
 			if pod.Name != existingpod.Name {
 				return fmt.Errorf("cannot update pod %s in namespace %s: the pod is terminating", pod.Name, pod.Namespace)
 			}
-			ms.pods[key] = pod
+			pod.ResourceVersion = ms.versions.next()
+			//kubelet 把 pod 标记为 Deleted 说明资源已经回收完了，没有调度的 pod 没有需要回收的资源。
+			//这时如果 finalizer 都已经移除，删除就完成了；否则 pod 留在存储里，等 controller 移除最后一个 finalizer
+			if len(pod.Finalizers) == 0 && (pod.Phase == api.PodDeleted || pod.NodeName == "") {
+				delete(ms.pods, key)
+				ms.podEvents.publish(api.EventDeleted, *pod.DeepCopy())
+				return nil
+			}
+			ms.pods[key] = pod.DeepCopy()
+			ms.podEvents.publish(api.EventModified, *pod.DeepCopy())
 			return nil
 		}
 		return fmt.Errorf("cannot update pod %s in namespace %s to phase %s as it is terminating; only Succeeded, Failed, or Terminating are allowed", pod.Name, pod.Namespace, pod.Phase)
@@ -69,7 +141,9 @@ func (ms *InMemoryStore) UpdatePod(pod *api.Pod) error {
 	if existingpod.DeletionTimestamp == nil && pod.DeletionTimestamp != nil {
 		return fmt.Errorf("to mark pod %s in namespace %s for deletion, use DeletePod method", pod.Name, pod.Namespace)
 	}
-	ms.pods[key] = pod
+	pod.ResourceVersion = ms.versions.next()
+	ms.pods[key] = pod.DeepCopy()
+	ms.podEvents.publish(api.EventModified, *pod.DeepCopy())
 	return nil
 }
 func (ms *InMemoryStore) DeletePod(namespace, name string) error {
@@ -77,79 +151,120 @@ func (ms *InMemoryStore) DeletePod(namespace, name string) error {
 	defer ms.mu.Unlock()
 	//1 假如说这个pod还在运行状态那就可以改为删除状态
 	//2 如果已经是删除状态了 那可以不设置了
-	key := fmt.Sprintf("%s%s", namespace, name)
-	pod, ok := ms.pods[key]
+	key := PodKey(namespace, name)
+	existing, ok := ms.pods[key]
 	if !ok {
 		return fmt.Errorf("pod %s not found", key)
 	}
+	//在副本上修改再替换，之前读出去的对象不会被改动
+	pod := existing.DeepCopy()
 	if pod.DeletionTimestamp != nil {
 		return fmt.Errorf(" pod %s in namespace %s is terminating", pod.Name, pod.Namespace)
 	}
+	//还没有被调度的 pod 没有 kubelet 负责清理，没有 finalizer 时直接删除
+	pod.ResourceVersion = ms.versions.next()
+	if pod.NodeName == "" && len(pod.Finalizers) == 0 {
+		delete(ms.pods, key)
+		ms.podEvents.publish(api.EventDeleted, *pod.DeepCopy())
+		return nil
+	}
 	now := time.Now()
 	pod.DeletionTimestamp = &now
 	pod.Phase = api.PodTerminating
 	ms.pods[key] = pod
+	ms.podEvents.publish(api.EventModified, *pod.DeepCopy())
 	return nil
 }
 
+// ListPods 返回命名空间里的 pod，namespace 为空时返回所有命名空间的 pod
 func (ms *InMemoryStore) ListPods(namespace string) ([]*api.Pod, error) {
-	ms.mu.Lock()
-	defer ms.mu.Unlock()
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	prefix := NamespacedPrefix(ResourcePods, namespace)
 	var result []*api.Pod
-	for _, pod := range ms.pods {
-		if pod.Namespace == namespace {
-			result = append(result, pod)
+	for key, pod := range ms.pods {
+		if key.HasPrefix(prefix) {
+			result = append(result, pod.DeepCopy())
 		}
 	}
 	return result, nil
 }
 
+// WatchPods 订阅命名空间里 pod 的变化，namespace 为空时订阅所有命名空间
+func (ms *InMemoryStore) WatchPods(namespace string) (<-chan api.WatchEvent[api.Pod], func()) {
+	return ms.podEvents.subscribe(func(pod api.Pod) bool {
+		return namespace == "" || pod.Namespace == namespace
+	})
+}
+
 func (ms *InMemoryStore) CreateNode(node *api.Node) error {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
-	_, ok := ms.nodes[node.Name]
+	if err := validateKeySegment("node name", node.Name); err != nil {
+		return err
+	}
+	key := NodeKey(node.Name)
+	_, ok := ms.nodes[key]
 	if !ok {
-		ms.nodes[node.Name] = node
+		node.ResourceVersion = ms.versions.next()
+		ms.nodes[key] = node.DeepCopy()
+		ms.nodeEvents.publish(api.EventAdded, *node.DeepCopy())
 		return nil
 	}
 
 	return fmt.Errorf("node %s already exists", node.Name)
 }
 func (ms *InMemoryStore) GetNode(name string) (*api.Node, error) {
-	ms.mu.Lock()
-	defer ms.mu.Unlock()
-	existingNode, ok := ms.nodes[name]
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	existingNode, ok := ms.nodes[NodeKey(name)]
 	if !ok {
 		return nil, fmt.Errorf("node %s not found", name)
 	}
-	return existingNode, nil
+	return existingNode.DeepCopy(), nil
 }
 func (ms *InMemoryStore) UpdateNode(node *api.Node) error {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
-	_, ok := ms.nodes[node.Name]
+	key := NodeKey(node.Name)
+	existing, ok := ms.nodes[key]
 	if !ok {
 		return fmt.Errorf("node %s not found", node.Name)
 	}
-	ms.nodes[node.Name] = node
+	if err := checkResourceVersion("node "+node.Name, &existing.ObjectMeta, &node.ObjectMeta); err != nil {
+		return err
+	}
+	node.ResourceVersion = ms.versions.next()
+	ms.nodes[key] = node.DeepCopy()
+	ms.nodeEvents.publish(api.EventModified, *node.DeepCopy())
 	return nil
 }
 func (s *InMemoryStore) DeleteNode(name string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.nodes[name]; !exists {
+	key := NodeKey(name)
+	existing, exists := s.nodes[key]
+	if !exists {
 		return fmt.Errorf("node %s not found for deletion", name)
 	}
-	delete(s.nodes, name)
+	delete(s.nodes, key)
+	deleted := existing.DeepCopy()
+	deleted.ResourceVersion = s.versions.next()
+	s.nodeEvents.publish(api.EventDeleted, *deleted)
 	return nil
 }
 func (ms *InMemoryStore) ListNodes() ([]*api.Node, error) {
-	ms.mu.Lock()
-	defer ms.mu.Unlock()
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
 	var result []*api.Node
 	for _, node := range ms.nodes {
-		result = append(result, node)
+		result = append(result, node.DeepCopy())
 	}
 	return result, nil
 }
+
+// WatchNodes 订阅节点的变化
+func (ms *InMemoryStore) WatchNodes() (<-chan api.WatchEvent[api.Node], func()) {
+	return ms.nodeEvents.subscribe(nil)
+}
@@ -0,0 +1,37 @@
+package store
+
+import "mini-k8s/pkg/api"
+
+func (ms *InMemoryStore) CreateConfigMap(configMap *api.ConfigMap) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.configMaps.create(configMap)
+}
+
+func (ms *InMemoryStore) GetConfigMap(namespace, name string) (*api.ConfigMap, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.configMaps.get(namespace, name)
+}
+
+func (ms *InMemoryStore) UpdateConfigMap(configMap *api.ConfigMap) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.configMaps.update(configMap)
+}
+
+func (ms *InMemoryStore) DeleteConfigMap(namespace, name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.configMaps.delete(namespace, name)
+}
+
+func (ms *InMemoryStore) ListConfigMaps(namespace string) ([]*api.ConfigMap, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.configMaps.list(namespace), nil
+}
+
+func (ms *InMemoryStore) WatchConfigMaps(namespace string) (<-chan api.WatchEvent[api.ConfigMap], func()) {
+	return ms.configMaps.watch(namespace)
+}
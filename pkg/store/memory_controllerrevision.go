@@ -0,0 +1,37 @@
+package store
+
+import "mini-k8s/pkg/api"
+
+func (ms *InMemoryStore) CreateControllerRevision(cr *api.ControllerRevision) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.controllerRevisions.create(cr)
+}
+
+func (ms *InMemoryStore) GetControllerRevision(namespace, name string) (*api.ControllerRevision, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.controllerRevisions.get(namespace, name)
+}
+
+func (ms *InMemoryStore) UpdateControllerRevision(cr *api.ControllerRevision) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.controllerRevisions.update(cr)
+}
+
+func (ms *InMemoryStore) DeleteControllerRevision(namespace, name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.controllerRevisions.delete(namespace, name)
+}
+
+func (ms *InMemoryStore) ListControllerRevisions(namespace string) ([]*api.ControllerRevision, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.controllerRevisions.list(namespace), nil
+}
+
+func (ms *InMemoryStore) WatchControllerRevisions(namespace string) (<-chan api.WatchEvent[api.ControllerRevision], func()) {
+	return ms.controllerRevisions.watch(namespace)
+}
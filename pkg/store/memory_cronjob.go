@@ -0,0 +1,37 @@
+package store
+
+import "mini-k8s/pkg/api"
+
+func (ms *InMemoryStore) CreateCronJob(cj *api.CronJob) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.cronJobs.create(cj)
+}
+
+func (ms *InMemoryStore) GetCronJob(namespace, name string) (*api.CronJob, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.cronJobs.get(namespace, name)
+}
+
+func (ms *InMemoryStore) UpdateCronJob(cj *api.CronJob) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.cronJobs.update(cj)
+}
+
+func (ms *InMemoryStore) DeleteCronJob(namespace, name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.cronJobs.delete(namespace, name)
+}
+
+func (ms *InMemoryStore) ListCronJobs(namespace string) ([]*api.CronJob, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.cronJobs.list(namespace), nil
+}
+
+func (ms *InMemoryStore) WatchCronJobs(namespace string) (<-chan api.WatchEvent[api.CronJob], func()) {
+	return ms.cronJobs.watch(namespace)
+}
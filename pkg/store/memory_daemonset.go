@@ -0,0 +1,37 @@
+package store
+
+import "mini-k8s/pkg/api"
+
+func (ms *InMemoryStore) CreateDaemonSet(ds *api.DaemonSet) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.daemonSets.create(ds)
+}
+
+func (ms *InMemoryStore) GetDaemonSet(namespace, name string) (*api.DaemonSet, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.daemonSets.get(namespace, name)
+}
+
+func (ms *InMemoryStore) UpdateDaemonSet(ds *api.DaemonSet) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.daemonSets.update(ds)
+}
+
+func (ms *InMemoryStore) DeleteDaemonSet(namespace, name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.daemonSets.delete(namespace, name)
+}
+
+func (ms *InMemoryStore) ListDaemonSets(namespace string) ([]*api.DaemonSet, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.daemonSets.list(namespace), nil
+}
+
+func (ms *InMemoryStore) WatchDaemonSets(namespace string) (<-chan api.WatchEvent[api.DaemonSet], func()) {
+	return ms.daemonSets.watch(namespace)
+}
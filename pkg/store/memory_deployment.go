@@ -0,0 +1,37 @@
+package store
+
+import "mini-k8s/pkg/api"
+
+func (ms *InMemoryStore) CreateDeployment(d *api.Deployment) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.deployments.create(d)
+}
+
+func (ms *InMemoryStore) GetDeployment(namespace, name string) (*api.Deployment, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.deployments.get(namespace, name)
+}
+
+func (ms *InMemoryStore) UpdateDeployment(d *api.Deployment) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.deployments.update(d)
+}
+
+func (ms *InMemoryStore) DeleteDeployment(namespace, name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.deployments.delete(namespace, name)
+}
+
+func (ms *InMemoryStore) ListDeployments(namespace string) ([]*api.Deployment, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.deployments.list(namespace), nil
+}
+
+func (ms *InMemoryStore) WatchDeployments(namespace string) (<-chan api.WatchEvent[api.Deployment], func()) {
+	return ms.deployments.watch(namespace)
+}
@@ -0,0 +1,37 @@
+package store
+
+import "mini-k8s/pkg/api"
+
+func (ms *InMemoryStore) CreateEndpoints(endpoints *api.Endpoints) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.endpoints.create(endpoints)
+}
+
+func (ms *InMemoryStore) GetEndpoints(namespace, name string) (*api.Endpoints, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.endpoints.get(namespace, name)
+}
+
+func (ms *InMemoryStore) UpdateEndpoints(endpoints *api.Endpoints) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.endpoints.update(endpoints)
+}
+
+func (ms *InMemoryStore) DeleteEndpoints(namespace, name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.endpoints.delete(namespace, name)
+}
+
+func (ms *InMemoryStore) ListEndpoints(namespace string) ([]*api.Endpoints, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.endpoints.list(namespace), nil
+}
+
+func (ms *InMemoryStore) WatchEndpoints(namespace string) (<-chan api.WatchEvent[api.Endpoints], func()) {
+	return ms.endpoints.watch(namespace)
+}
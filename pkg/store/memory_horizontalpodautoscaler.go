@@ -0,0 +1,37 @@
+package store
+
+import "mini-k8s/pkg/api"
+
+func (ms *InMemoryStore) CreateHorizontalPodAutoscaler(hpa *api.HorizontalPodAutoscaler) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.horizontalPodAutoscalers.create(hpa)
+}
+
+func (ms *InMemoryStore) GetHorizontalPodAutoscaler(namespace, name string) (*api.HorizontalPodAutoscaler, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.horizontalPodAutoscalers.get(namespace, name)
+}
+
+func (ms *InMemoryStore) UpdateHorizontalPodAutoscaler(hpa *api.HorizontalPodAutoscaler) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.horizontalPodAutoscalers.update(hpa)
+}
+
+func (ms *InMemoryStore) DeleteHorizontalPodAutoscaler(namespace, name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.horizontalPodAutoscalers.delete(namespace, name)
+}
+
+func (ms *InMemoryStore) ListHorizontalPodAutoscalers(namespace string) ([]*api.HorizontalPodAutoscaler, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.horizontalPodAutoscalers.list(namespace), nil
+}
+
+func (ms *InMemoryStore) WatchHorizontalPodAutoscalers(namespace string) (<-chan api.WatchEvent[api.HorizontalPodAutoscaler], func()) {
+	return ms.horizontalPodAutoscalers.watch(namespace)
+}
@@ -0,0 +1,37 @@
+package store
+
+import "mini-k8s/pkg/api"
+
+func (ms *InMemoryStore) CreateIngress(ingress *api.Ingress) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.ingresses.create(ingress)
+}
+
+func (ms *InMemoryStore) GetIngress(namespace, name string) (*api.Ingress, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.ingresses.get(namespace, name)
+}
+
+func (ms *InMemoryStore) UpdateIngress(ingress *api.Ingress) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.ingresses.update(ingress)
+}
+
+func (ms *InMemoryStore) DeleteIngress(namespace, name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.ingresses.delete(namespace, name)
+}
+
+func (ms *InMemoryStore) ListIngresses(namespace string) ([]*api.Ingress, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.ingresses.list(namespace), nil
+}
+
+func (ms *InMemoryStore) WatchIngresses(namespace string) (<-chan api.WatchEvent[api.Ingress], func()) {
+	return ms.ingresses.watch(namespace)
+}
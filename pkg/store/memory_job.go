@@ -0,0 +1,37 @@
+package store
+
+import "mini-k8s/pkg/api"
+
+func (ms *InMemoryStore) CreateJob(job *api.Job) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.jobs.create(job)
+}
+
+func (ms *InMemoryStore) GetJob(namespace, name string) (*api.Job, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.jobs.get(namespace, name)
+}
+
+func (ms *InMemoryStore) UpdateJob(job *api.Job) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.jobs.update(job)
+}
+
+func (ms *InMemoryStore) DeleteJob(namespace, name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.jobs.delete(namespace, name)
+}
+
+func (ms *InMemoryStore) ListJobs(namespace string) ([]*api.Job, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.jobs.list(namespace), nil
+}
+
+func (ms *InMemoryStore) WatchJobs(namespace string) (<-chan api.WatchEvent[api.Job], func()) {
+	return ms.jobs.watch(namespace)
+}
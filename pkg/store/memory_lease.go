@@ -0,0 +1,37 @@
+package store
+
+import "mini-k8s/pkg/api"
+
+func (ms *InMemoryStore) CreateLease(lease *api.Lease) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.leases.create(lease)
+}
+
+func (ms *InMemoryStore) GetLease(namespace, name string) (*api.Lease, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.leases.get(namespace, name)
+}
+
+func (ms *InMemoryStore) UpdateLease(lease *api.Lease) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.leases.update(lease)
+}
+
+func (ms *InMemoryStore) DeleteLease(namespace, name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.leases.delete(namespace, name)
+}
+
+func (ms *InMemoryStore) ListLeases(namespace string) ([]*api.Lease, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.leases.list(namespace), nil
+}
+
+func (ms *InMemoryStore) WatchLeases(namespace string) (<-chan api.WatchEvent[api.Lease], func()) {
+	return ms.leases.watch(namespace)
+}
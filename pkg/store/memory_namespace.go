@@ -0,0 +1,113 @@
+package store
+
+import (
+	"fmt"
+	"mini-k8s/pkg/api"
+	"time"
+)
+
+func (ms *InMemoryStore) CreateNamespace(ns *api.Namespace) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if err := validateKeySegment("namespace name", ns.Name); err != nil {
+		return err
+	}
+	key := NamespaceKey(ns.Name)
+	if _, ok := ms.namespaces[key]; ok {
+		return fmt.Errorf("namespace %s already exists", ns.Name)
+	}
+	ns.ResourceVersion = ms.versions.next()
+	ms.namespaces[key] = ns.DeepCopy()
+	ms.nsEvents.publish(api.EventAdded, *ns.DeepCopy())
+	return nil
+}
+
+func (ms *InMemoryStore) GetNamespace(name string) (*api.Namespace, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	ns, ok := ms.namespaces[NamespaceKey(name)]
+	if !ok {
+		return nil, fmt.Errorf("namespace %s not found", name)
+	}
+	return ns.DeepCopy(), nil
+}
+
+// UpdateNamespace 更新命名空间；处于 Terminating 的命名空间一旦没有 finalizer 了就会被真正删除
+func (ms *InMemoryStore) UpdateNamespace(ns *api.Namespace) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	key := NamespaceKey(ns.Name)
+	existing, ok := ms.namespaces[key]
+	if !ok {
+		return fmt.Errorf("namespace %s not found", ns.Name)
+	}
+	if err := checkResourceVersion("namespace "+ns.Name, &existing.ObjectMeta, &ns.ObjectMeta); err != nil {
+		return err
+	}
+	if existing.DeletionTimestamp != nil {
+		if ns.DeletionTimestamp == nil || !ns.DeletionTimestamp.Equal(*existing.DeletionTimestamp) {
+			return fmt.Errorf("cannot update namespace %s: incoming update does not have matching DeletionTimestamp for a terminating namespace", ns.Name)
+		}
+		if ns.Status.Phase != api.NamespaceTerminating {
+			return fmt.Errorf("cannot update namespace %s to phase %s as it is terminating", ns.Name, ns.Status.Phase)
+		}
+		ns.ResourceVersion = ms.versions.next()
+		if len(ns.Spec.Finalizers) == 0 {
+			delete(ms.namespaces, key)
+			ms.nsEvents.publish(api.EventDeleted, *ns.DeepCopy())
+			return nil
+		}
+		ms.namespaces[key] = ns.DeepCopy()
+		ms.nsEvents.publish(api.EventModified, *ns.DeepCopy())
+		return nil
+	}
+	if ns.DeletionTimestamp != nil {
+		return fmt.Errorf("to mark namespace %s for deletion, use DeleteNamespace method", ns.Name)
+	}
+	ns.ResourceVersion = ms.versions.next()
+	ms.namespaces[key] = ns.DeepCopy()
+	ms.nsEvents.publish(api.EventModified, *ns.DeepCopy())
+	return nil
+}
+
+// DeleteNamespace 把命名空间标记为 Terminating，等 namespace controller 清空内容并移除 finalizer 后再删除
+func (ms *InMemoryStore) DeleteNamespace(name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	key := NamespaceKey(name)
+	existing, ok := ms.namespaces[key]
+	if !ok {
+		return fmt.Errorf("namespace %s not found", name)
+	}
+	ns := existing.DeepCopy()
+	if ns.DeletionTimestamp != nil {
+		return fmt.Errorf("namespace %s is terminating", name)
+	}
+	ns.ResourceVersion = ms.versions.next()
+	if len(ns.Spec.Finalizers) == 0 {
+		delete(ms.namespaces, key)
+		ms.nsEvents.publish(api.EventDeleted, *ns.DeepCopy())
+		return nil
+	}
+	now := time.Now()
+	ns.DeletionTimestamp = &now
+	ns.Status.Phase = api.NamespaceTerminating
+	ms.namespaces[key] = ns
+	ms.nsEvents.publish(api.EventModified, *ns.DeepCopy())
+	return nil
+}
+
+func (ms *InMemoryStore) ListNamespaces() ([]*api.Namespace, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	var result []*api.Namespace
+	for _, ns := range ms.namespaces {
+		result = append(result, ns.DeepCopy())
+	}
+	return result, nil
+}
+
+// WatchNamespaces 订阅命名空间的变化
+func (ms *InMemoryStore) WatchNamespaces() (<-chan api.WatchEvent[api.Namespace], func()) {
+	return ms.nsEvents.subscribe(nil)
+}
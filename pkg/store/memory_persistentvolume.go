@@ -0,0 +1,37 @@
+package store
+
+import "mini-k8s/pkg/api"
+
+func (ms *InMemoryStore) CreatePersistentVolume(pv *api.PersistentVolume) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.persistentVolumes.create(pv)
+}
+
+func (ms *InMemoryStore) GetPersistentVolume(name string) (*api.PersistentVolume, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.persistentVolumes.get("", name)
+}
+
+func (ms *InMemoryStore) UpdatePersistentVolume(pv *api.PersistentVolume) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.persistentVolumes.update(pv)
+}
+
+func (ms *InMemoryStore) DeletePersistentVolume(name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.persistentVolumes.delete("", name)
+}
+
+func (ms *InMemoryStore) ListPersistentVolumes() ([]*api.PersistentVolume, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.persistentVolumes.list(""), nil
+}
+
+func (ms *InMemoryStore) WatchPersistentVolumes() (<-chan api.WatchEvent[api.PersistentVolume], func()) {
+	return ms.persistentVolumes.watch("")
+}
@@ -0,0 +1,37 @@
+package store
+
+import "mini-k8s/pkg/api"
+
+func (ms *InMemoryStore) CreatePersistentVolumeClaim(pvc *api.PersistentVolumeClaim) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.persistentVolumeClaims.create(pvc)
+}
+
+func (ms *InMemoryStore) GetPersistentVolumeClaim(namespace, name string) (*api.PersistentVolumeClaim, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.persistentVolumeClaims.get(namespace, name)
+}
+
+func (ms *InMemoryStore) UpdatePersistentVolumeClaim(pvc *api.PersistentVolumeClaim) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.persistentVolumeClaims.update(pvc)
+}
+
+func (ms *InMemoryStore) DeletePersistentVolumeClaim(namespace, name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.persistentVolumeClaims.delete(namespace, name)
+}
+
+func (ms *InMemoryStore) ListPersistentVolumeClaims(namespace string) ([]*api.PersistentVolumeClaim, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.persistentVolumeClaims.list(namespace), nil
+}
+
+func (ms *InMemoryStore) WatchPersistentVolumeClaims(namespace string) (<-chan api.WatchEvent[api.PersistentVolumeClaim], func()) {
+	return ms.persistentVolumeClaims.watch(namespace)
+}
@@ -0,0 +1,37 @@
+package store
+
+import "mini-k8s/pkg/api"
+
+func (ms *InMemoryStore) CreatePodDisruptionBudget(pdb *api.PodDisruptionBudget) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.podDisruptionBudgets.create(pdb)
+}
+
+func (ms *InMemoryStore) GetPodDisruptionBudget(namespace, name string) (*api.PodDisruptionBudget, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.podDisruptionBudgets.get(namespace, name)
+}
+
+func (ms *InMemoryStore) UpdatePodDisruptionBudget(pdb *api.PodDisruptionBudget) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.podDisruptionBudgets.update(pdb)
+}
+
+func (ms *InMemoryStore) DeletePodDisruptionBudget(namespace, name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.podDisruptionBudgets.delete(namespace, name)
+}
+
+func (ms *InMemoryStore) ListPodDisruptionBudgets(namespace string) ([]*api.PodDisruptionBudget, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.podDisruptionBudgets.list(namespace), nil
+}
+
+func (ms *InMemoryStore) WatchPodDisruptionBudgets(namespace string) (<-chan api.WatchEvent[api.PodDisruptionBudget], func()) {
+	return ms.podDisruptionBudgets.watch(namespace)
+}
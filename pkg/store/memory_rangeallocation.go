@@ -0,0 +1,21 @@
+package store
+
+import "mini-k8s/pkg/api"
+
+func (ms *InMemoryStore) CreateRangeAllocation(ra *api.RangeAllocation) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.rangeAllocations.create(ra)
+}
+
+func (ms *InMemoryStore) GetRangeAllocation(name string) (*api.RangeAllocation, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.rangeAllocations.get("", name)
+}
+
+func (ms *InMemoryStore) UpdateRangeAllocation(ra *api.RangeAllocation) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.rangeAllocations.update(ra)
+}
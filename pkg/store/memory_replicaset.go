@@ -0,0 +1,37 @@
+package store
+
+import "mini-k8s/pkg/api"
+
+func (ms *InMemoryStore) CreateReplicaSet(rs *api.ReplicaSet) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.replicaSets.create(rs)
+}
+
+func (ms *InMemoryStore) GetReplicaSet(namespace, name string) (*api.ReplicaSet, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.replicaSets.get(namespace, name)
+}
+
+func (ms *InMemoryStore) UpdateReplicaSet(rs *api.ReplicaSet) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.replicaSets.update(rs)
+}
+
+func (ms *InMemoryStore) DeleteReplicaSet(namespace, name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.replicaSets.delete(namespace, name)
+}
+
+func (ms *InMemoryStore) ListReplicaSets(namespace string) ([]*api.ReplicaSet, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.replicaSets.list(namespace), nil
+}
+
+func (ms *InMemoryStore) WatchReplicaSets(namespace string) (<-chan api.WatchEvent[api.ReplicaSet], func()) {
+	return ms.replicaSets.watch(namespace)
+}
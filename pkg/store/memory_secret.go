@@ -0,0 +1,37 @@
+package store
+
+import "mini-k8s/pkg/api"
+
+func (ms *InMemoryStore) CreateSecret(secret *api.Secret) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.secrets.create(secret)
+}
+
+func (ms *InMemoryStore) GetSecret(namespace, name string) (*api.Secret, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.secrets.get(namespace, name)
+}
+
+func (ms *InMemoryStore) UpdateSecret(secret *api.Secret) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.secrets.update(secret)
+}
+
+func (ms *InMemoryStore) DeleteSecret(namespace, name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.secrets.delete(namespace, name)
+}
+
+func (ms *InMemoryStore) ListSecrets(namespace string) ([]*api.Secret, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.secrets.list(namespace), nil
+}
+
+func (ms *InMemoryStore) WatchSecrets(namespace string) (<-chan api.WatchEvent[api.Secret], func()) {
+	return ms.secrets.watch(namespace)
+}
@@ -0,0 +1,37 @@
+package store
+
+import "mini-k8s/pkg/api"
+
+func (ms *InMemoryStore) CreateService(service *api.Service) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.services.create(service)
+}
+
+func (ms *InMemoryStore) GetService(namespace, name string) (*api.Service, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.services.get(namespace, name)
+}
+
+func (ms *InMemoryStore) UpdateService(service *api.Service) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.services.update(service)
+}
+
+func (ms *InMemoryStore) DeleteService(namespace, name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.services.delete(namespace, name)
+}
+
+func (ms *InMemoryStore) ListServices(namespace string) ([]*api.Service, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.services.list(namespace), nil
+}
+
+func (ms *InMemoryStore) WatchServices(namespace string) (<-chan api.WatchEvent[api.Service], func()) {
+	return ms.services.watch(namespace)
+}
@@ -0,0 +1,37 @@
+package store
+
+import "mini-k8s/pkg/api"
+
+func (ms *InMemoryStore) CreateStatefulSet(ss *api.StatefulSet) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.statefulSets.create(ss)
+}
+
+func (ms *InMemoryStore) GetStatefulSet(namespace, name string) (*api.StatefulSet, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.statefulSets.get(namespace, name)
+}
+
+func (ms *InMemoryStore) UpdateStatefulSet(ss *api.StatefulSet) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.statefulSets.update(ss)
+}
+
+func (ms *InMemoryStore) DeleteStatefulSet(namespace, name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.statefulSets.delete(namespace, name)
+}
+
+func (ms *InMemoryStore) ListStatefulSets(namespace string) ([]*api.StatefulSet, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.statefulSets.list(namespace), nil
+}
+
+func (ms *InMemoryStore) WatchStatefulSets(namespace string) (<-chan api.WatchEvent[api.StatefulSet], func()) {
+	return ms.statefulSets.watch(namespace)
+}
@@ -0,0 +1,37 @@
+package store
+
+import "mini-k8s/pkg/api"
+
+func (ms *InMemoryStore) CreateStorageClass(sc *api.StorageClass) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.storageClasses.create(sc)
+}
+
+func (ms *InMemoryStore) GetStorageClass(name string) (*api.StorageClass, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.storageClasses.get("", name)
+}
+
+func (ms *InMemoryStore) UpdateStorageClass(sc *api.StorageClass) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.storageClasses.update(sc)
+}
+
+func (ms *InMemoryStore) DeleteStorageClass(name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.storageClasses.delete("", name)
+}
+
+func (ms *InMemoryStore) ListStorageClasses() ([]*api.StorageClass, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.storageClasses.list(""), nil
+}
+
+func (ms *InMemoryStore) WatchStorageClasses() (<-chan api.WatchEvent[api.StorageClass], func()) {
+	return ms.storageClasses.watch("")
+}
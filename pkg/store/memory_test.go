@@ -0,0 +1,200 @@
+package store
+
+import (
+	"fmt"
+	"mini-k8s/pkg/api"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestPod(namespace, name string) *api.Pod {
+	return &api.Pod{
+		ObjectMeta: api.ObjectMeta{Namespace: namespace, Name: name, Labels: map[string]string{"app": "web"}},
+		PodSpec:    api.PodSpec{Image: "sleep", Command: []string{"sleep", "60"}},
+	}
+}
+
+// TestConcurrentPodAccess 同时创建、更新和列出 pod，并且随意修改拿到的对象。
+// 用 go test -race 运行时，如果存储把内部的对象（或者它的 map、slice）交给了调用者，race detector 会报告数据竞争；
+// 不依赖调度顺序的检查在 TestPodDeepCopyAtStoreBoundary 里
+func TestConcurrentPodAccess(t *testing.T) {
+	ms := NewInMemoryStore()
+	const writers, readers, podsPerWriter = 4, 2, 25
+	var writersWG, readersWG sync.WaitGroup
+	done := make(chan struct{})
+	for w := 0; w < writers; w++ {
+		writersWG.Add(1)
+		go func(w int) {
+			defer writersWG.Done()
+			for i := 0; i < podsPerWriter; i++ {
+				pod := newTestPod("default", fmt.Sprintf("pod-%d-%d", w, i))
+				if err := ms.CreatePod(pod); err != nil {
+					t.Errorf("CreatePod: %v", err)
+					return
+				}
+				//创建之后继续修改传进去的对象，不能影响存储里的副本
+				pod.Labels["app"] = "changed"
+				pod.Command[0] = "changed"
+
+				got, err := ms.GetPod("default", pod.Name)
+				if err != nil {
+					t.Errorf("GetPod: %v", err)
+					return
+				}
+				got.Labels["version"] = fmt.Sprint(i)
+				got.Command = append(got.Command, "extra")
+				got.Phase = api.PodRunning
+				if err := ms.UpdatePod(got); err != nil && !strings.Contains(err.Error(), "has been modified") {
+					t.Errorf("UpdatePod: %v", err)
+					return
+				}
+				got.Labels["after-update"] = "true"
+			}
+		}(w)
+	}
+	//读的一方一直列出 pod，直到所有写入结束，保证读写真正交错
+	for r := 0; r < readers; r++ {
+		readersWG.Add(1)
+		go func() {
+			defer readersWG.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				pods, err := ms.ListPods("default")
+				if err != nil {
+					t.Errorf("ListPods: %v", err)
+					return
+				}
+				for _, pod := range pods {
+					pod.Labels["listed"] = "true"
+					if len(pod.Command) > 0 {
+						pod.Command[0] = "listed"
+					}
+				}
+			}
+		}()
+	}
+	writersWG.Wait()
+	close(done)
+	readersWG.Wait()
+
+	pods, err := ms.ListPods("default")
+	if err != nil {
+		t.Fatalf("ListPods: %v", err)
+	}
+	if len(pods) != writers*podsPerWriter {
+		t.Fatalf("ListPods returned %d pods, want %d", len(pods), writers*podsPerWriter)
+	}
+	for _, pod := range pods {
+		if pod.Labels["app"] != "web" || pod.Labels["listed"] != "" || pod.Labels["after-update"] != "" {
+			t.Errorf("pod %s has labels %v, a caller's mutation reached the store", pod.Name, pod.Labels)
+		}
+		if pod.Command[0] != "sleep" {
+			t.Errorf("pod %s has command %v, a caller's mutation reached the store", pod.Name, pod.Command)
+		}
+	}
+}
+
+// TestPodDeepCopyAtStoreBoundary 检查 Create、Update、Get 和 List 都只交换副本
+func TestPodDeepCopyAtStoreBoundary(t *testing.T) {
+	ms := NewInMemoryStore()
+	pod := newTestPod("default", "web")
+	if err := ms.CreatePod(pod); err != nil {
+		t.Fatalf("CreatePod: %v", err)
+	}
+	pod.Labels["app"] = "create-arg"
+
+	got, err := ms.GetPod("default", "web")
+	if err != nil {
+		t.Fatalf("GetPod: %v", err)
+	}
+	if got.Labels["app"] != "web" {
+		t.Fatalf("mutating the object passed to CreatePod changed the stored pod: %v", got.Labels)
+	}
+	got.Labels["app"] = "get-result"
+	got.Command[0] = "get-result"
+
+	listed, _ := ms.ListPods("default")
+	if len(listed) != 1 {
+		t.Fatalf("ListPods returned %d pods, want 1", len(listed))
+	}
+	if listed[0].Labels["app"] != "web" || listed[0].Command[0] != "sleep" {
+		t.Fatalf("mutating the pod returned by GetPod changed the stored pod: %v %v", listed[0].Labels, listed[0].Command)
+	}
+	listed[0].Labels["app"] = "list-result"
+
+	update, _ := ms.GetPod("default", "web")
+	if update.Labels["app"] != "web" {
+		t.Fatalf("mutating the pod returned by ListPods changed the stored pod: %v", update.Labels)
+	}
+	update.Labels["tier"] = "frontend"
+	if err := ms.UpdatePod(update); err != nil {
+		t.Fatalf("UpdatePod: %v", err)
+	}
+	update.Labels["tier"] = "update-arg"
+
+	final, _ := ms.GetPod("default", "web")
+	if final.Labels["tier"] != "frontend" {
+		t.Fatalf("mutating the object passed to UpdatePod changed the stored pod: %v", final.Labels)
+	}
+}
+
+// TestTableDeepCopy 对通用的 table 做同样的检查，所有资源都用它保存
+func TestTableDeepCopy(t *testing.T) {
+	ms := NewInMemoryStore()
+	svc := &api.Service{ObjectMeta: api.ObjectMeta{Namespace: "default", Name: "web", Labels: map[string]string{"app": "web"}}}
+	if err := ms.CreateService(svc); err != nil {
+		t.Fatalf("CreateService: %v", err)
+	}
+	svc.Labels["app"] = "create-arg"
+	got, _ := ms.GetService("default", "web")
+	got.Labels["app"] = "get-result"
+	listed, _ := ms.ListServices("default")
+	if listed[0].Labels["app"] != "web" {
+		t.Fatalf("caller's mutation reached the stored service: %v", listed[0].Labels)
+	}
+}
+
+// TestRejectedPodUpdateKeepsResourceVersion 检查被拒绝的更新不会改动传入对象的 resourceVersion，
+// 调用方改正之后用同一个对象重试不会因为版本不一致而冲突
+func TestRejectedPodUpdateKeepsResourceVersion(t *testing.T) {
+	ms := NewInMemoryStore()
+	pod := newTestPod("default", "web")
+	pod.NodeName = "node-1"
+	if err := ms.CreatePod(pod); err != nil {
+		t.Fatalf("CreatePod: %v", err)
+	}
+
+	running, _ := ms.GetPod("default", "web")
+	now := time.Now()
+	running.DeletionTimestamp = &now
+	version := running.ResourceVersion
+	if err := ms.UpdatePod(running); err == nil {
+		t.Fatalf("UpdatePod setting deletionTimestamp succeeded, want an error")
+	}
+	if running.ResourceVersion != version {
+		t.Errorf("rejected update changed resourceVersion from %s to %s", version, running.ResourceVersion)
+	}
+
+	if err := ms.DeletePod("default", "web"); err != nil {
+		t.Fatalf("DeletePod: %v", err)
+	}
+	terminating, _ := ms.GetPod("default", "web")
+	version = terminating.ResourceVersion
+	terminating.Phase = api.PodRunning
+	if err := ms.UpdatePod(terminating); err == nil {
+		t.Fatalf("UpdatePod moving a terminating pod to Running succeeded, want an error")
+	}
+	if terminating.ResourceVersion != version {
+		t.Errorf("rejected update changed resourceVersion from %s to %s", version, terminating.ResourceVersion)
+	}
+	terminating.Phase = api.PodFailed
+	if err := ms.UpdatePod(terminating); err != nil {
+		t.Fatalf("retrying UpdatePod with an allowed phase: %v", err)
+	}
+}
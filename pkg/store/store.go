@@ -8,7 +8,8 @@ type Store interface {
 	GetPod(namespace, name string) (*api.Pod, error)
 	UpdatePod(pod *api.Pod) error
 	DeletePod(namespace, name string) error
-	ListPods(namespace string) ([]*api.Pod, error)
+	ListPods(namespace string) ([]*api.Pod, error) // an empty namespace lists pods in all namespaces
+	WatchPods(namespace string) (<-chan api.WatchEvent[api.Pod], func())
 
 	// Node operations
 	CreateNode(node *api.Node) error
@@ -16,4 +17,162 @@ type Store interface {
 	UpdateNode(node *api.Node) error
 	DeleteNode(name string) error
 	ListNodes() ([]*api.Node, error)
+	WatchNodes() (<-chan api.WatchEvent[api.Node], func())
+
+	// Namespace operations
+	CreateNamespace(ns *api.Namespace) error
+	GetNamespace(name string) (*api.Namespace, error)
+	UpdateNamespace(ns *api.Namespace) error
+	DeleteNamespace(name string) error
+	ListNamespaces() ([]*api.Namespace, error)
+	WatchNamespaces() (<-chan api.WatchEvent[api.Namespace], func())
+
+	// ReplicaSet operations
+	CreateReplicaSet(rs *api.ReplicaSet) error
+	GetReplicaSet(namespace, name string) (*api.ReplicaSet, error)
+	UpdateReplicaSet(rs *api.ReplicaSet) error
+	DeleteReplicaSet(namespace, name string) error
+	ListReplicaSets(namespace string) ([]*api.ReplicaSet, error) // an empty namespace lists all namespaces
+	WatchReplicaSets(namespace string) (<-chan api.WatchEvent[api.ReplicaSet], func())
+
+	// Deployment operations
+	CreateDeployment(d *api.Deployment) error
+	GetDeployment(namespace, name string) (*api.Deployment, error)
+	UpdateDeployment(d *api.Deployment) error
+	DeleteDeployment(namespace, name string) error
+	ListDeployments(namespace string) ([]*api.Deployment, error) // an empty namespace lists all namespaces
+	WatchDeployments(namespace string) (<-chan api.WatchEvent[api.Deployment], func())
+
+	// Job operations
+	CreateJob(job *api.Job) error
+	GetJob(namespace, name string) (*api.Job, error)
+	UpdateJob(job *api.Job) error
+	DeleteJob(namespace, name string) error
+	ListJobs(namespace string) ([]*api.Job, error) // an empty namespace lists all namespaces
+	WatchJobs(namespace string) (<-chan api.WatchEvent[api.Job], func())
+
+	// CronJob operations
+	CreateCronJob(cj *api.CronJob) error
+	GetCronJob(namespace, name string) (*api.CronJob, error)
+	UpdateCronJob(cj *api.CronJob) error
+	DeleteCronJob(namespace, name string) error
+	ListCronJobs(namespace string) ([]*api.CronJob, error) // an empty namespace lists all namespaces
+	WatchCronJobs(namespace string) (<-chan api.WatchEvent[api.CronJob], func())
+
+	// DaemonSet operations
+	CreateDaemonSet(ds *api.DaemonSet) error
+	GetDaemonSet(namespace, name string) (*api.DaemonSet, error)
+	UpdateDaemonSet(ds *api.DaemonSet) error
+	DeleteDaemonSet(namespace, name string) error
+	ListDaemonSets(namespace string) ([]*api.DaemonSet, error) // an empty namespace lists all namespaces
+	WatchDaemonSets(namespace string) (<-chan api.WatchEvent[api.DaemonSet], func())
+
+	// StatefulSet operations
+	CreateStatefulSet(ss *api.StatefulSet) error
+	GetStatefulSet(namespace, name string) (*api.StatefulSet, error)
+	UpdateStatefulSet(ss *api.StatefulSet) error
+	DeleteStatefulSet(namespace, name string) error
+	ListStatefulSets(namespace string) ([]*api.StatefulSet, error) // an empty namespace lists all namespaces
+	WatchStatefulSets(namespace string) (<-chan api.WatchEvent[api.StatefulSet], func())
+
+	// ControllerRevision operations
+	CreateControllerRevision(cr *api.ControllerRevision) error
+	GetControllerRevision(namespace, name string) (*api.ControllerRevision, error)
+	UpdateControllerRevision(cr *api.ControllerRevision) error
+	DeleteControllerRevision(namespace, name string) error
+	ListControllerRevisions(namespace string) ([]*api.ControllerRevision, error) // an empty namespace lists all namespaces
+	WatchControllerRevisions(namespace string) (<-chan api.WatchEvent[api.ControllerRevision], func())
+
+	// Lease operations
+	CreateLease(lease *api.Lease) error
+	GetLease(namespace, name string) (*api.Lease, error)
+	UpdateLease(lease *api.Lease) error
+	DeleteLease(namespace, name string) error
+	ListLeases(namespace string) ([]*api.Lease, error) // an empty namespace lists all namespaces
+	WatchLeases(namespace string) (<-chan api.WatchEvent[api.Lease], func())
+
+	// Service operations
+	CreateService(svc *api.Service) error
+	GetService(namespace, name string) (*api.Service, error)
+	UpdateService(svc *api.Service) error
+	DeleteService(namespace, name string) error
+	ListServices(namespace string) ([]*api.Service, error) // an empty namespace lists all namespaces
+	WatchServices(namespace string) (<-chan api.WatchEvent[api.Service], func())
+
+	// Endpoints operations
+	CreateEndpoints(ep *api.Endpoints) error
+	GetEndpoints(namespace, name string) (*api.Endpoints, error)
+	UpdateEndpoints(ep *api.Endpoints) error
+	DeleteEndpoints(namespace, name string) error
+	ListEndpoints(namespace string) ([]*api.Endpoints, error) // an empty namespace lists all namespaces
+	WatchEndpoints(namespace string) (<-chan api.WatchEvent[api.Endpoints], func())
+
+	// Ingress operations
+	CreateIngress(ing *api.Ingress) error
+	GetIngress(namespace, name string) (*api.Ingress, error)
+	UpdateIngress(ing *api.Ingress) error
+	DeleteIngress(namespace, name string) error
+	ListIngresses(namespace string) ([]*api.Ingress, error) // an empty namespace lists all namespaces
+	WatchIngresses(namespace string) (<-chan api.WatchEvent[api.Ingress], func())
+
+	// Secret operations
+	CreateSecret(secret *api.Secret) error
+	GetSecret(namespace, name string) (*api.Secret, error)
+	UpdateSecret(secret *api.Secret) error
+	DeleteSecret(namespace, name string) error
+	ListSecrets(namespace string) ([]*api.Secret, error) // an empty namespace lists all namespaces
+	WatchSecrets(namespace string) (<-chan api.WatchEvent[api.Secret], func())
+
+	// ConfigMap operations
+	CreateConfigMap(cm *api.ConfigMap) error
+	GetConfigMap(namespace, name string) (*api.ConfigMap, error)
+	UpdateConfigMap(cm *api.ConfigMap) error
+	DeleteConfigMap(namespace, name string) error
+	ListConfigMaps(namespace string) ([]*api.ConfigMap, error) // an empty namespace lists all namespaces
+	WatchConfigMaps(namespace string) (<-chan api.WatchEvent[api.ConfigMap], func())
+
+	// PersistentVolume operations, PersistentVolumes are cluster-scoped
+	CreatePersistentVolume(pv *api.PersistentVolume) error
+	GetPersistentVolume(name string) (*api.PersistentVolume, error)
+	UpdatePersistentVolume(pv *api.PersistentVolume) error
+	DeletePersistentVolume(name string) error
+	ListPersistentVolumes() ([]*api.PersistentVolume, error)
+	WatchPersistentVolumes() (<-chan api.WatchEvent[api.PersistentVolume], func())
+
+	// PersistentVolumeClaim operations
+	CreatePersistentVolumeClaim(pvc *api.PersistentVolumeClaim) error
+	GetPersistentVolumeClaim(namespace, name string) (*api.PersistentVolumeClaim, error)
+	UpdatePersistentVolumeClaim(pvc *api.PersistentVolumeClaim) error
+	DeletePersistentVolumeClaim(namespace, name string) error
+	ListPersistentVolumeClaims(namespace string) ([]*api.PersistentVolumeClaim, error) // an empty namespace lists all namespaces
+	WatchPersistentVolumeClaims(namespace string) (<-chan api.WatchEvent[api.PersistentVolumeClaim], func())
+
+	// StorageClass operations, StorageClasses are cluster-scoped
+	CreateStorageClass(sc *api.StorageClass) error
+	GetStorageClass(name string) (*api.StorageClass, error)
+	UpdateStorageClass(sc *api.StorageClass) error
+	DeleteStorageClass(name string) error
+	ListStorageClasses() ([]*api.StorageClass, error)
+	WatchStorageClasses() (<-chan api.WatchEvent[api.StorageClass], func())
+
+	// HorizontalPodAutoscaler operations
+	CreateHorizontalPodAutoscaler(hpa *api.HorizontalPodAutoscaler) error
+	GetHorizontalPodAutoscaler(namespace, name string) (*api.HorizontalPodAutoscaler, error)
+	UpdateHorizontalPodAutoscaler(hpa *api.HorizontalPodAutoscaler) error
+	DeleteHorizontalPodAutoscaler(namespace, name string) error
+	ListHorizontalPodAutoscalers(namespace string) ([]*api.HorizontalPodAutoscaler, error) // an empty namespace lists all namespaces
+	WatchHorizontalPodAutoscalers(namespace string) (<-chan api.WatchEvent[api.HorizontalPodAutoscaler], func())
+
+	// PodDisruptionBudget operations
+	CreatePodDisruptionBudget(pdb *api.PodDisruptionBudget) error
+	GetPodDisruptionBudget(namespace, name string) (*api.PodDisruptionBudget, error)
+	UpdatePodDisruptionBudget(pdb *api.PodDisruptionBudget) error
+	DeletePodDisruptionBudget(namespace, name string) error
+	ListPodDisruptionBudgets(namespace string) ([]*api.PodDisruptionBudget, error) // an empty namespace lists all namespaces
+	WatchPodDisruptionBudgets(namespace string) (<-chan api.WatchEvent[api.PodDisruptionBudget], func())
+
+	// RangeAllocation operations, used internally by the API server's allocators
+	CreateRangeAllocation(ra *api.RangeAllocation) error
+	GetRangeAllocation(name string) (*api.RangeAllocation, error)
+	UpdateRangeAllocation(ra *api.RangeAllocation) error
 }
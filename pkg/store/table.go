@@ -0,0 +1,127 @@
+package store
+
+import (
+	"fmt"
+	"mini-k8s/pkg/api"
+)
+
+// object 约束了 table 能保存的资源：指针类型、嵌入了 ObjectMeta，并且可以深拷贝
+type object[T any] interface {
+	*T
+	api.Object
+	DeepCopy() *T
+}
+
+// table 是一种资源在内存里的存储，负责计算 key、在读写边界上深拷贝以及分发 watch 事件。
+// 它本身不加锁，由 InMemoryStore 的方法持有 ms.mu 后调用。Pod、Node、Namespace 的删除语义比较特殊，仍然单独实现
+type table[T any, PT object[T]] struct {
+	kind       string
+	resource   string
+	namespaced bool
+	objects    map[Key]PT
+	events     *broadcaster[T]
+	versions   *versioner
+}
+
+func newTable[T any, PT object[T]](kind, resource string, namespaced bool, versions *versioner) *table[T, PT] {
+	return &table[T, PT]{
+		kind:       kind,
+		resource:   resource,
+		namespaced: namespaced,
+		objects:    make(map[Key]PT),
+		events:     newBroadcaster[T](),
+		versions:   versions,
+	}
+}
+
+func (t *table[T, PT]) key(namespace, name string) Key {
+	if t.namespaced {
+		return NamespacedKey(t.resource, namespace, name)
+	}
+	return ClusterKey(t.resource, name)
+}
+
+func (t *table[T, PT]) describe(namespace, name string) string {
+	if t.namespaced {
+		return fmt.Sprintf("%s %s/%s", t.kind, namespace, name)
+	}
+	return fmt.Sprintf("%s %s", t.kind, name)
+}
+
+func (t *table[T, PT]) create(obj PT) error {
+	meta := obj.GetObjectMeta()
+	if t.namespaced {
+		if err := validateKeySegment("namespace", meta.Namespace); err != nil {
+			return err
+		}
+	}
+	if err := validateKeySegment(t.kind+" name", meta.Name); err != nil {
+		return err
+	}
+	key := t.key(meta.Namespace, meta.Name)
+	if _, ok := t.objects[key]; ok {
+		return fmt.Errorf("%s already exists", t.describe(meta.Namespace, meta.Name))
+	}
+	meta.ResourceVersion = t.versions.next()
+	t.objects[key] = PT(obj.DeepCopy())
+	t.events.publish(api.EventAdded, *obj.DeepCopy())
+	return nil
+}
+
+func (t *table[T, PT]) get(namespace, name string) (PT, error) {
+	obj, ok := t.objects[t.key(namespace, name)]
+	if !ok {
+		return nil, fmt.Errorf("%s not found", t.describe(namespace, name))
+	}
+	return PT(obj.DeepCopy()), nil
+}
+
+func (t *table[T, PT]) update(obj PT) error {
+	meta := obj.GetObjectMeta()
+	key := t.key(meta.Namespace, meta.Name)
+	existing, ok := t.objects[key]
+	if !ok {
+		return fmt.Errorf("%s not found", t.describe(meta.Namespace, meta.Name))
+	}
+	if err := checkResourceVersion(t.describe(meta.Namespace, meta.Name), existing.GetObjectMeta(), meta); err != nil {
+		return err
+	}
+	meta.ResourceVersion = t.versions.next()
+	t.objects[key] = PT(obj.DeepCopy())
+	t.events.publish(api.EventModified, *obj.DeepCopy())
+	return nil
+}
+
+func (t *table[T, PT]) delete(namespace, name string) error {
+	key := t.key(namespace, name)
+	obj, ok := t.objects[key]
+	if !ok {
+		return fmt.Errorf("%s not found", t.describe(namespace, name))
+	}
+	delete(t.objects, key)
+	deleted := PT(obj.DeepCopy())
+	deleted.GetObjectMeta().ResourceVersion = t.versions.next()
+	t.events.publish(api.EventDeleted, *deleted)
+	return nil
+}
+
+// list 按 key 前缀列出对象，namespace 为空时列出所有命名空间
+func (t *table[T, PT]) list(namespace string) []PT {
+	prefix := NamespacedPrefix(t.resource, namespace)
+	if !t.namespaced {
+		prefix = NamespacedPrefix(t.resource, "")
+	}
+	var result []PT
+	for key, obj := range t.objects {
+		if key.HasPrefix(prefix) {
+			result = append(result, PT(obj.DeepCopy()))
+		}
+	}
+	return result
+}
+
+func (t *table[T, PT]) watch(namespace string) (<-chan api.WatchEvent[T], func()) {
+	return t.events.subscribe(func(obj T) bool {
+		return namespace == "" || PT(&obj).GetObjectMeta().Namespace == namespace
+	})
+}
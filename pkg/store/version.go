@@ -0,0 +1,28 @@
+package store
+
+import (
+	"fmt"
+	"mini-k8s/pkg/api"
+	"strconv"
+)
+
+// versioner 给每次写入分配一个递增的 resourceVersion，所有资源共用一个计数器。
+// 它本身不加锁，由 InMemoryStore 的方法持有 ms.mu 后调用
+type versioner struct {
+	current uint64
+}
+
+func (v *versioner) next() string {
+	v.current++
+	return strconv.FormatUint(v.current, 10)
+}
+
+// checkResourceVersion 实现乐观并发控制：客户端带了 resourceVersion 时，只有它和存储里的一致才允许写入。
+// 没带 resourceVersion 的更新无条件覆盖
+func checkResourceVersion(what string, existing, updated *api.ObjectMeta) error {
+	if updated.ResourceVersion == "" || updated.ResourceVersion == existing.ResourceVersion {
+		return nil
+	}
+	return fmt.Errorf("operation cannot be fulfilled on %s: the object has been modified (resourceVersion %s, current %s); please apply your changes to the latest version and try again",
+		what, updated.ResourceVersion, existing.ResourceVersion)
+}
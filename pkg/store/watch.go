@@ -0,0 +1,59 @@
+package store
+
+import (
+	"mini-k8s/pkg/api"
+	"sync"
+)
+
+// watchBufferSize 是每个订阅者的事件缓冲；订阅者跟不上时会被断开，由客户端重新 list 再 watch
+const watchBufferSize = 100
+
+type watcher[T any] struct {
+	ch     chan api.WatchEvent[T]
+	filter func(T) bool
+}
+
+// broadcaster 把对象的变化分发给所有订阅者，publish 在持有 store 锁的时候调用，保证事件顺序和写入顺序一致
+type broadcaster[T any] struct {
+	mu       sync.Mutex
+	watchers map[int]*watcher[T]
+	nextID   int
+}
+
+func newBroadcaster[T any]() *broadcaster[T] {
+	return &broadcaster[T]{watchers: make(map[int]*watcher[T])}
+}
+
+// subscribe 注册一个订阅者，filter 为 nil 时接收所有事件；返回的函数用于取消订阅
+func (b *broadcaster[T]) subscribe(filter func(T) bool) (<-chan api.WatchEvent[T], func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.nextID
+	b.nextID++
+	w := &watcher[T]{ch: make(chan api.WatchEvent[T], watchBufferSize), filter: filter}
+	b.watchers[id] = w
+	return w.ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.watchers[id]; ok {
+			delete(b.watchers, id)
+			close(w.ch)
+		}
+	}
+}
+
+func (b *broadcaster[T]) publish(eventType api.EventType, obj T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for id, w := range b.watchers {
+		if w.filter != nil && !w.filter(obj) {
+			continue
+		}
+		select {
+		case w.ch <- api.WatchEvent[T]{Type: eventType, Object: obj}:
+		default:
+			delete(b.watchers, id)
+			close(w.ch)
+		}
+	}
+}